@@ -27,7 +27,7 @@ func main() {
 		fatal(ctx, "Failed to connect to MongoDB in main", err)
 	}
 
-	for _, index := range xmongo.Indexes {
+	for _, index := range xmongo.IndexList() {
 		if err := db.ApplyIndex(ctx, index.Collection, index.Model); err != nil {
 			fatal(ctx, "Failed to apply index to collection "+index.Collection, err)
 		} else {
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/migrations"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/joho/godotenv"
+)
+
+/*
+Runs data migrations against the configured environment.
+Example usage:
+
+	go run cmd/db/migrate/main.go -direction=up
+	go run cmd/db/migrate/main.go -direction=up -target=3
+	go run cmd/db/migrate/main.go -direction=down -steps=1
+	go run cmd/db/migrate/main.go -direction=status
+*/
+func main() {
+	ctx := context.Background()
+	direction := flag.String("direction", "up", "up, down or status")
+	target := flag.Int("target", 0, "highest version to apply when migrating up (0 = latest)")
+	steps := flag.Int("steps", 1, "number of migrations to revert when migrating down")
+
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		fatal(ctx, "Failed to load .env", err)
+	}
+	config, err := config.Load()
+	if err != nil {
+		fatal(ctx, "Failed to load config", err)
+	}
+
+	db, err := xmongo.New(ctx, config.Atlas)
+	if err != nil {
+		fatal(ctx, "Failed to connect to MongoDB in main", err)
+	}
+
+	runner := migrations.NewRunner(db.DB)
+
+	switch *direction {
+	case "up":
+		applied, err := runner.Up(ctx, *target)
+		if err != nil {
+			fatal(ctx, "Failed to apply migrations", err)
+		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "Migrations applied", slog.Any("versions", applied), slog.String("Environment", db.DB.Name()))
+	case "down":
+		reverted, err := runner.Down(ctx, *steps)
+		if err != nil {
+			fatal(ctx, "Failed to revert migrations", err)
+		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "Migrations reverted", slog.Any("versions", reverted), slog.String("Environment", db.DB.Name()))
+	case "status":
+		statuses, err := runner.Status(ctx)
+		if err != nil {
+			fatal(ctx, "Failed to read migration status", err)
+		}
+		for _, status := range statuses {
+			slog.LogAttrs(ctx, slog.LevelInfo, "Migration", slog.Int("version", status.Version), slog.String("name", status.Name), slog.Bool("applied", status.Applied))
+		}
+	default:
+		fatal(ctx, "Unknown direction "+*direction, nil)
+	}
+}
+
+func fatal(ctx context.Context, msg string, err error) {
+	attrs := []slog.Attr{}
+	if err != nil {
+		attrs = append(attrs, xslog.Error(err))
+	}
+	slog.LogAttrs(
+		ctx,
+		slog.LevelError,
+		msg,
+		attrs...,
+	)
+	os.Exit(1)
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/config"
 	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
 	"github.com/abhikaboy/SocialToDo/internal/handlers/health"
 	"github.com/abhikaboy/SocialToDo/internal/xerr"
@@ -16,11 +17,11 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func New(collections map[string]*mongo.Collection) *fiber.App {
+func New(collections map[string]*mongo.Collection, cfg config.Config) *fiber.App {
 	app := setupApp()
 
-	health.Routes(app, collections)
-	auth.Routes(app, collections)
+	health.Routes(app, collections, nil)
+	auth.Routes(app, collections, cfg)
 
 	return app
 }
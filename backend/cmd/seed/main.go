@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/seed"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/joho/godotenv"
+)
+
+/*
+Fills the configured (non production) environment with generated users,
+friendships, categories, tasks and feed history. Every seeded account logs in
+with seed.Password.
+Example usage:
+
+	go run cmd/seed/main.go -profile=demo
+	go run cmd/seed/main.go -profile=load -seed=7
+	go run cmd/seed/main.go -profile=small -users=3 -activity=50
+	go run cmd/seed/main.go -reset-only
+*/
+func main() {
+	ctx := context.Background()
+	profileName := flag.String("profile", "demo", "one of small, demo or load")
+	seedValue := flag.Int64("seed", 1, "the same seed generates the same data")
+	reset := flag.Bool("reset", true, "delete previously seeded data first")
+	resetOnly := flag.Bool("reset-only", false, "delete previously seeded data and exit")
+	// overrides for the chosen profile, 0 keeps the profile's value
+	users := flag.Int("users", 0, "number of users")
+	friends := flag.Int("friends", 0, "friends per user")
+	categories := flag.Int("categories", 0, "categories per user")
+	tasks := flag.Int("tasks", 0, "tasks per category")
+	activity := flag.Int("activity", 0, "activity items per user")
+	days := flag.Int("days", 0, "days of history")
+
+	flag.Parse()
+
+	profile, err := seed.Lookup(*profileName)
+	if err != nil {
+		fatal(ctx, "Invalid profile", err)
+	}
+	override(&profile.Users, *users)
+	override(&profile.FriendsPerUser, *friends)
+	override(&profile.CategoriesPerUser, *categories)
+	override(&profile.TasksPerCategory, *tasks)
+	override(&profile.ActivityPerUser, *activity)
+	override(&profile.HistoryDays, *days)
+
+	if err := godotenv.Load(); err != nil {
+		fatal(ctx, "Failed to load .env", err)
+	}
+	config, err := config.Load()
+	if err != nil {
+		fatal(ctx, "Failed to load config", err)
+	}
+	if err := seed.CheckEnvironment(config.Atlas.Environment); err != nil {
+		fatal(ctx, "Invalid environment", err)
+	}
+
+	db, err := xmongo.New(ctx, config.Atlas)
+	if err != nil {
+		fatal(ctx, "Failed to connect to MongoDB in main", err)
+	}
+
+	if *reset || *resetOnly {
+		deleted, err := seed.Reset(ctx, db.Collections)
+		if err != nil {
+			fatal(ctx, "Failed to reset seeded data", err)
+		}
+		slog.LogAttrs(ctx, slog.LevelInfo, "Seeded data removed", slog.Int64("documents", deleted), slog.String("Environment", db.DB.Name()))
+	}
+	if *resetOnly {
+		return
+	}
+
+	result, err := seed.Run(ctx, db.Collections, profile, *seedValue)
+	if err != nil {
+		fatal(ctx, "Failed to seed", err)
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "Environment seeded",
+		slog.String("profile", result.Profile),
+		slog.Int("users", result.Users),
+		slog.Int("friendships", result.Friendships),
+		slog.Int("categories", result.Categories),
+		slog.Int("tasks", result.Tasks),
+		slog.Int("activity", result.Activity),
+		slog.String("Environment", db.DB.Name()),
+	)
+}
+
+func override(field *int, value int) {
+	if value > 0 {
+		*field = value
+	}
+}
+
+func fatal(ctx context.Context, msg string, err error) {
+	attrs := []slog.Attr{}
+	if err != nil {
+		attrs = append(attrs, xslog.Error(err))
+	}
+	slog.LogAttrs(
+		ctx,
+		slog.LevelError,
+		msg,
+		attrs...,
+	)
+	os.Exit(1)
+}
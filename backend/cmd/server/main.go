@@ -2,64 +2,63 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"log/slog"
+	"net"
 	"os"
 	"os/exec"
-	"os/signal"
 	"runtime"
 	"strconv"
 	"strings"
-	"sync"
-	"syscall"
-	"time"
 
+	"github.com/abhikaboy/SocialToDo/internal/changestream"
 	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/achievements"
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth/forgot_pass"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/exports"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/groups"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/hooks"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/imports"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/shortcuts"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/stats"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/gcal"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/github"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/slack"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/lifecycle"
+	"github.com/abhikaboy/SocialToDo/internal/migrations"
+	"github.com/abhikaboy/SocialToDo/internal/outbox"
+	"github.com/abhikaboy/SocialToDo/internal/rpc"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
 	"github.com/abhikaboy/SocialToDo/internal/server"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
 	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xredis"
+	"github.com/abhikaboy/SocialToDo/internal/xanalytics"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xmail"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xpush"
 	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/abhikaboy/SocialToDo/internal/xsms"
+	"github.com/abhikaboy/SocialToDo/internal/xtrace"
+	"github.com/abhikaboy/SocialToDo/internal/xworker"
 	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func main() {
 	run(os.Stderr, os.Args[1:])
 }
 
-func IterateChangeStream(routineCtx context.Context, waitGroup sync.WaitGroup, stream *mongo.ChangeStream) {
-	fmt.Printf("Waiting for changes...\n")
-	defer stream.Close(routineCtx)
-	defer waitGroup.Done()
-	for stream.Next(routineCtx) {
-		var data bson.M
-		if err := stream.Decode(&data); err != nil {
-			panic(err)
-		}
-		// fmt.Printf("%v\n", data["fullDocument"])
-		_, err := json.Marshal(data["fullDocument"])
-		if err != nil {
-			fmt.Println(err)
-		}
-		// fmt.Printf("DATA \n")
-		// fmt.Printf("%v\n", body)
-		// url := "http://localhost:8080/api/v1/mechanics/alert"
-		// _, err = http.Post(
-		// 	url,
-		// 	"application/json",
-		// 	bytes.NewBuffer(body),
-		// )
-		// if err != nil {
-		// 	fmt.Println(err)
-		// }
-
-	}
-	fmt.Print("Stream closed\n")
-}
-
 func run(stderr io.Writer, args []string) {
 	cmd := flag.NewFlagSet("", flag.ExitOnError)
 	verboseFlag := cmd.Bool("v", false, "")
@@ -83,6 +82,16 @@ func run(stderr io.Writer, args []string) {
 		fatal(ctx, "Failed to load config", err)
 	}
 
+	// shutdown runs the stop steps registered below, newest first
+	shutdown := lifecycle.New(config.App.ShutdownTimeout)
+
+	shutdownTracing, err := xtrace.Setup(ctx, config.Tracing)
+	if err != nil {
+		fatal(ctx, "Failed to set up tracing", err)
+	}
+	// after everything that could still be recording spans
+	shutdown.OnStop("tracing", shutdownTracing)
+
 	port, err := strconv.Atoi(config.App.Port)
 	if err != nil {
 		fatal(ctx, "Failed to convert port to int", err)
@@ -99,41 +108,158 @@ func run(stderr io.Writer, args []string) {
 	if err != nil {
 		fatal(ctx, "Failed to connect to MongoDB", err)
 	}
+	shutdown.OnStop("MongoDB", db.Client.Disconnect)
+
+	if config.Atlas.Migrate {
+		// another instance already migrating is fine, it leaves the same state behind
+		applied, err := migrations.NewRunner(db.DB).Up(ctx, 0)
+		switch {
+		case errors.Is(err, migrations.ErrLocked):
+			slog.LogAttrs(ctx, slog.LevelInfo, "Migrations are running elsewhere")
+		case err != nil:
+			fatal(ctx, "Failed to apply migrations", err)
+		default:
+			slog.LogAttrs(ctx, slog.LevelInfo, "Migrations applied", slog.Any("versions", applied))
+		}
+	}
+
+	if err := db.EnsureIndexes(ctx); err != nil {
+		// /readyz reports the missing indexes, keep serving
+		slog.LogAttrs(ctx, slog.LevelError, "Some indexes could not be ensured", xslog.Error(err))
+	}
 
-	app := server.New(db.Collections, db.Stream)
-	fmt.Printf("After New")
+	var redis *xredis.Client
+	if config.Redis.Addr != "" {
+		redis, err = xredis.New(ctx, config.Redis)
+		if err != nil {
+			fatal(ctx, "Failed to connect to Redis", err)
+		}
+		shutdown.OnStop("Redis", lifecycle.Close(redis.Close))
+	}
+
+	cache := xcache.New(redis, config.Cache)
+	bus := events.NewBus()
+	// after the relays and handlers that publish, before the connections async handlers use
+	shutdown.OnStop("event bus", bus.Close)
+
+	// stopped in three groups: the scheduler before the job worker it enqueues for,
+	// and both before the relays and buffers the rest of the process feeds
+	workers := xworker.NewGroup(context.Background())
+	workers.Go("change-stream", changestream.New(db.DB, bus).Run)
+	workers.Go("outbox-relay", outbox.NewRelay(db.Collections[outbox.Collection], bus).Run)
+	shutdown.OnStop("background workers", workers.Stop)
+	jobWorkers := xworker.NewGroup(context.Background())
+	shutdown.OnStop("job worker", jobWorkers.Stop)
+	schedulers := xworker.NewGroup(context.Background())
+	shutdown.OnStop("scheduler", schedulers.Stop)
+
+	jobWorker := jobs.NewWorker(jobs.New(db.Collections[jobs.Collection]), jobs.WorkerConfig{
+		Concurrency:  config.Jobs.Concurrency,
+		PollInterval: config.Jobs.PollInterval,
+		Lease:        config.Jobs.Lease,
+	})
+	mailer := xmail.New(config.Mail, config.AWS)
+	auth.RegisterJobs(jobWorker, mailer, xsms.New(config.SMS))
+	forgot_pass.RegisterJobs(jobWorker, mailer)
+	imports.RegisterJobs(jobWorker, db.Collections, cache)
+	hooks.RegisterJobs(jobWorker, db.Collections, cache)
+	shortcuts.RegisterJobs(jobWorker, db.Collections, cache)
+	fileStore := xfiles.New(db.DB, config.Uploads, config.AWS)
+	exports.RegisterJobs(jobWorker, db.Collections, fileStore, config.Notion, config.Uploads)
+	hooks.SubscribeEvents(bus, db.Collections, cache)
+	groups.RegisterJobs(jobWorker, db.Collections)
+	groups.SubscribeEvents(bus, db.Collections)
+	stats.RegisterJobs(jobWorker, bus, db.Collections)
+	stats.SubscribeEvents(bus, db.Collections)
+	achievements.SubscribeEvents(bus, db.Collections)
+	pusher, err := xpush.New(config.Push)
+	if err != nil {
+		fatal(ctx, "Failed to set up push notifications", err)
+	}
+	notifications.RegisterJobs(jobWorker, db.Collections, pusher)
+	notifications.SubscribeEvents(bus, db.Collections)
+	calendar := gcal.New(db.Collections, config.Google)
+	if calendar.Enabled() {
+		gcal.RegisterJobs(jobWorker, calendar)
+		gcal.SyncOn(bus, calendar)
+	}
+	issues := github.New(db.Collections, cache, config.GitHub)
+	if issues.Enabled() {
+		github.RegisterJobs(jobWorker, issues)
+		github.CloseOn(bus, issues)
+	}
+	jobWorkers.Go("jobs", jobWorker.Run)
+
+	// every instance runs the scheduler, the lock in the schedules collection picks one per run
+	owner, _ := os.Hostname()
+	cron := scheduler.New(db.Collections[scheduler.Collection], owner+":"+strconv.Itoa(os.Getpid()))
+	activity.RegisterSchedules(cron, db.Collections)
+	softdelete.RegisterSchedules(cron, db.Collections)
+	task.RegisterSchedules(cron, db.Collections, config.Reminders)
+	exports.RegisterSchedules(cron, db.Collections, fileStore)
+	groups.RegisterSchedules(cron, db.Collections)
+	stats.RegisterSchedules(cron, db.Collections)
+	if calendar.Enabled() {
+		gcal.RegisterSchedules(cron, calendar)
+	}
+	if slackApp := slack.New(db.Collections, cache, config.Slack); slackApp.Enabled() {
+		slack.RegisterSchedules(cron, slackApp)
+	}
+	if issues.Enabled() {
+		github.RegisterSchedules(cron, issues)
+	}
+	schedulers.Go("scheduler", cron.Run)
+
+	analytics := xanalytics.NewBuffer(xanalytics.NewSink(db.Collections, config.Analytics),
+		config.Analytics.BufferSize, config.Analytics.BatchSize, config.Analytics.FlushInterval)
+	workers.Go("analytics", analytics.Run)
+
+	app := server.New(db.Collections, redis, cache, bus, analytics, config)
+	// a route added without its OpenAPI operation fails fast where it's written
+	if config.App.DevEndpoints {
+		if missing := xopenapi.Missing(app); len(missing) > 0 {
+			fatal(ctx, "Routes without OpenAPI operations", errors.New(strings.Join(missing, ", ")))
+		}
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Hour)
 	go func() {
 		if err := app.Listen(":" + config.App.Port); err != nil {
 			fatal(ctx, "Failed to start server", err)
 		}
 	}()
+	// stop accepting connections and wait for in-flight handlers
+	shutdown.OnStop("HTTP server", app.ShutdownWithContext)
 
-	var waitGroup sync.WaitGroup
-	waitGroup.Add(1)
-	go IterateChangeStream(ctx, waitGroup, db.Stream)
-	defer cancel()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-
-	<-quit
-	slog.LogAttrs(
-		ctx,
-		slog.LevelInfo,
-		"Stopping server",
-	)
-
-	if err := app.Shutdown(); err != nil {
-		fatal(ctx, "Failed to shutdown server", err)
+	// internal RPC for other backend services, only when a shared token is configured
+	if config.RPC.Token != "" {
+		rpcServer := rpc.New(db.Collections, cache, config)
+		lis, err := net.Listen("tcp", config.RPC.Addr)
+		if err != nil {
+			fatal(ctx, "Failed to start RPC server", err)
+		}
+		go func() {
+			if err := rpcServer.Serve(lis); err != nil {
+				fatal(ctx, "Failed to start RPC server", err)
+			}
+		}()
+		shutdown.OnStop("RPC server", func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				rpcServer.GracefulStop()
+				close(stopped)
+			}()
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				// drop the calls still running
+				rpcServer.Stop()
+				return ctx.Err()
+			}
+		})
 	}
 
-	slog.LogAttrs(
-		ctx,
-		slog.LevelInfo,
-		"Server shutdown",
-	)
+	shutdown.Wait(ctx)
 }
 
 func newLogger(logLevel string, verbose bool, stderr io.Writer) *slog.Logger {
@@ -149,10 +275,11 @@ func newLogger(logLevel string, verbose bool, stderr io.Writer) *slog.Logger {
 	case "error":
 		level = slog.LevelError.Level()
 	}
-	return slog.New(slog.NewJSONHandler(stderr, &slog.HandlerOptions{
-		AddSource: logLevel == "debug",
-		Level:     level,
-	}))
+	return slog.New(xslog.NewContextHandler(slog.NewJSONHandler(stderr, &slog.HandlerOptions{
+		AddSource:   logLevel == "debug",
+		Level:       level,
+		ReplaceAttr: xslog.Redact,
+	})))
 }
 
 func fatal(ctx context.Context, msg string, err error) {
@@ -8,8 +8,10 @@ import (
 	"testing"
 
 	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/events"
 	"github.com/abhikaboy/SocialToDo/internal/server"
 	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
@@ -88,5 +90,5 @@ func setup(t *testing.T) *fiber.App {
 	if err != nil {
 		t.Fatalf("Failed to connect to MongoDB: %v", err)
 	}
-	return server.New(db.Collections, db.Stream)
+	return server.New(db.Collections, nil, xcache.Noop{}, events.NewBus(), nil, config.Config{})
 }
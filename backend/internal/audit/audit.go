@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+The audit log is an append-only record of what happened to an account's
+security: sign-ins, failed attempts, refreshes and the like, with where each
+came from, so users can review who has been using their account. Events are
+never updated; they expire after Retention (TTL index in xmongo.Indexes).
+*/
+
+const (
+	Collection = "audit_log"
+	Retention  = 180 * 24 * time.Hour
+
+	maxUserAgent = 256
+)
+
+type Type string
+
+const (
+	Login           Type = "login"
+	LoginFailed     Type = "login_failed"
+	TokenRefreshed  Type = "token_refreshed"
+	TokenReuse      Type = "token_reuse"
+	PasswordChanged Type = "password_changed"
+	AccountDeleted  Type = "account_deleted"
+	PhoneLinked     Type = "phone_linked"
+)
+
+// Method is how a user proved who they are when signing in
+type Method string
+
+const (
+	Password Method = "password"
+	Google   Method = "google"
+	Apple    Method = "apple"
+	// a code texted to the user's phone
+	Phone Method = "phone"
+)
+
+// Client is where a request came from
+type Client struct {
+	IP        string `bson:"ip,omitempty" json:"ip,omitempty"`
+	UserAgent string `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+}
+
+// ClientOf is the client of the request, its IP as resolved through the trusted proxy header
+func ClientOf(c *fiber.Ctx) Client {
+	return Client{IP: c.IP(), UserAgent: c.Get(fiber.HeaderUserAgent)}
+}
+
+type Event struct {
+	ID     primitive.ObjectID `bson:"_id" json:"id"`
+	User   primitive.ObjectID `bson:"user" json:"-"`
+	Type   Type               `bson:"type" json:"type"`
+	Method Method             `bson:"method,omitempty" json:"method,omitempty"`
+	Client `bson:",inline"`
+	At     time.Time `bson:"at" json:"at"`
+}
+
+// New is an event of the user's happening now
+func New(user primitive.ObjectID, typ Type, client Client) Event {
+	if len(client.UserAgent) > maxUserAgent {
+		client.UserAgent = client.UserAgent[:maxUserAgent]
+	}
+	return Event{ID: primitive.NewObjectID(), User: user, Type: typ, Client: client, At: time.Now()}
+}
+
+// Record appends the event, doing nothing without a collection
+func Record(ctx context.Context, events *mongo.Collection, event Event) error {
+	if events == nil {
+		return nil
+	}
+	_, err := events.InsertOne(ctx, event)
+	return err
+}
+
+// Log is Record for callers whose action stands whether or not the event is stored
+func Log(ctx context.Context, events *mongo.Collection, event Event) {
+	if err := Record(ctx, events, event); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to record audit event",
+			slog.String("user_id", event.User.Hex()), slog.String("type", string(event.Type)), xslog.Error(err))
+	}
+}
+
+// List is up to limit of the user's events, newest first, older than after unless that's zero
+func List(ctx context.Context, events *mongo.Collection, user primitive.ObjectID, after primitive.ObjectID, limit int) ([]Event, error) {
+	filter := bson.M{"user": user}
+	if !after.IsZero() {
+		filter["_id"] = bson.M{"$lt": after}
+	}
+	cursor, err := events.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Event, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,192 @@
+package changestream
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Consumer watches the database change stream and republishes writes to the
+collections real-time features care about as internal events, so handlers
+don't need to publish anything themselves.
+*/
+type Consumer struct {
+	db  *mongo.Database
+	bus *events.Bus
+}
+
+// collections whose writes become events
+var watched = []string{"users", "activity", "notifications"}
+
+const maxBackoff = 30 * time.Second
+
+func New(db *mongo.Database, bus *events.Bus) *Consumer {
+	return &Consumer{db: db, bus: bus}
+}
+
+type change struct {
+	ID            bson.Raw `bson:"_id"`
+	OperationType string   `bson:"operationType"`
+	Namespace     struct {
+		Collection string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument      bson.M `bson:"fullDocument"`
+	UpdateDescription struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+/*
+Run consumes the stream until ctx is cancelled, reopening it (from the last
+resume token) with exponential backoff when it fails.
+*/
+func (c *Consumer) Run(ctx context.Context) {
+	var resumeToken bson.Raw
+	backoff := time.Second
+
+	for ctx.Err() == nil {
+		token, err := c.consume(ctx, resumeToken)
+		if token != nil {
+			resumeToken = token
+			backoff = time.Second
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.LogAttrs(ctx, slog.LevelWarn, "Change stream interrupted, reconnecting",
+			slog.Duration("backoff", backoff), xslog.Error(errOrClosed(err)))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (c *Consumer) consume(ctx context.Context, resumeToken bson.Raw) (bson.Raw, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"ns.coll":       bson.M{"$in": watched},
+			"operationType": bson.M{"$in": []string{"insert", "update", "replace", "delete"}},
+		}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeToken != nil {
+		opts.SetResumeAfter(resumeToken)
+	}
+
+	stream, err := c.db.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close(context.Background())
+
+	slog.LogAttrs(ctx, slog.LevelInfo, "Change stream opened", slog.Any("collections", watched))
+
+	var last bson.Raw
+	for stream.Next(ctx) {
+		var ch change
+		if err := stream.Decode(&ch); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to decode change event", xslog.Error(err))
+			continue
+		}
+		last = stream.ResumeToken()
+
+		if event, ok := toEvent(ch); ok {
+			c.bus.Publish(ctx, event)
+		}
+	}
+	return last, stream.Err()
+}
+
+func toEvent(ch change) (events.Event, bool) {
+	event := events.Event{
+		ID:         ch.ID.String(),
+		Collection: ch.Namespace.Collection,
+		DocumentID: ch.DocumentKey.ID.Hex(),
+		OccurredAt: time.Now(),
+	}
+
+	switch ch.Namespace.Collection {
+	case "users":
+		event.UserID = ch.DocumentKey.ID.Hex()
+		event.Type = events.UserChanged
+		if touchesCategories(ch) {
+			event.Type = events.TasksChanged
+			if ch.FullDocument != nil {
+				event.Payload = ch.FullDocument["categories"]
+			}
+		}
+	case "activity":
+		if ch.OperationType != "insert" {
+			return event, false
+		}
+		event.Type = events.FeedCreated
+		event.UserID = ownerOf(ch.FullDocument)
+		event.Payload = ch.FullDocument
+	case "notifications":
+		if ch.OperationType != "insert" {
+			return event, false
+		}
+		event.Type = events.NotificationCreated
+		event.UserID = ownerOf(ch.FullDocument)
+		event.Payload = ch.FullDocument
+	default:
+		return event, false
+	}
+	return event, true
+}
+
+func touchesCategories(ch change) bool {
+	if ch.OperationType != "update" {
+		// inserts, replaces and deletes rewrite the whole document
+		return true
+	}
+	for field := range ch.UpdateDescription.UpdatedFields {
+		if strings.HasPrefix(field, "categories") {
+			return true
+		}
+	}
+	for _, field := range ch.UpdateDescription.RemovedFields {
+		if strings.HasPrefix(field, "categories") {
+			return true
+		}
+	}
+	return false
+}
+
+func ownerOf(doc bson.M) string {
+	switch id := doc["user"].(type) {
+	case primitive.ObjectID:
+		return id.Hex()
+	case string:
+		return id
+	}
+	return ""
+}
+
+type closedError struct{}
+
+func (closedError) Error() string { return "stream closed" }
+
+func errOrClosed(err error) error {
+	if err == nil {
+		return closedError{}
+	}
+	return err
+}
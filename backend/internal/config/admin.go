@@ -0,0 +1,6 @@
+package config
+
+type Admin struct {
+	// comma separated ids always allowed to use /api/v1/admin, on top of users with the admin role
+	UserIDs []string `env:"USER_IDS" envSeparator:","`
+}
@@ -0,0 +1,36 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Analytics struct {
+	// mongo stores client events in the analytics_events collection, http posts them to URL, none drops them
+	Sink  string `env:"SINK" envDefault:"mongo"`
+	URL   string `env:"URL"`
+	Token string `env:"TOKEN"`
+	// share of users, 0-1, whose events are kept
+	SampleRate float64 `env:"SAMPLE_RATE" envDefault:"1"`
+	// events held in memory between flushes; beyond it new events are dropped
+	BufferSize    int           `env:"BUFFER_SIZE" envDefault:"10000"`
+	BatchSize     int           `env:"BATCH_SIZE" envDefault:"500"`
+	FlushInterval time.Duration `env:"FLUSH_INTERVAL" envDefault:"10s"`
+}
+
+func (a Analytics) validate() error {
+	switch a.Sink {
+	case "mongo", "none":
+	case "http":
+		if a.URL == "" {
+			return errors.New("ANALYTICS_SINK=http needs ANALYTICS_URL")
+		}
+	default:
+		return fmt.Errorf("ANALYTICS_SINK must be mongo, http or none, got %q", a.Sink)
+	}
+	if a.SampleRate < 0 || a.SampleRate > 1 {
+		return fmt.Errorf("ANALYTICS_SAMPLE_RATE must be between 0 and 1, got %v", a.SampleRate)
+	}
+	return nil
+}
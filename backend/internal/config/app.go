@@ -1,5 +1,17 @@
 package config
 
+import "time"
+
 type App struct {
 	Port string `env:"PORT" envDefault:"8080"`
+	// how long to wait for in-flight requests and background workers on shutdown
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"15s"`
+	// deadline on each request's context, and so on the database calls it makes
+	RequestTimeout time.Duration `env:"REQUEST_TIMEOUT" envDefault:"30s"`
+	// mounts /api/v1/dev (seeding); never honoured against the production database
+	DevEndpoints bool `env:"DEV_ENDPOINTS" envDefault:"false"`
+	// where clients reach the API, for links handed to other apps such as calendar feeds
+	PublicURL string `env:"PUBLIC_URL" envDefault:"https://api.socialtodo.app"`
+	// the web app, where users confirm device sign-ins
+	WebURL string `env:"WEB_URL" envDefault:"https://socialtodo.app"`
 }
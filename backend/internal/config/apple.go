@@ -0,0 +1,6 @@
+package config
+
+type Apple struct {
+	// the app's bundle id and web Services ID, the audiences Sign in with Apple tokens are accepted for; sign-in is off without any
+	ClientIDs []string `env:"CLIENT_IDS" envSeparator:","`
+}
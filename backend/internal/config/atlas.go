@@ -1,12 +1,19 @@
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Atlas struct {
 	User        string `env:"USER"`
 	Pass        string `env:"PASS"`
 	Cluster     string `env:"CLUSTER"`
 	Environment string `env:"ENVIRONMENT"`
+	// upper bound on any single operation, whatever deadline its context carries
+	Timeout time.Duration `env:"TIMEOUT" envDefault:"10s"`
+	// apply pending migrations at boot, before the indexes they may make room for
+	Migrate bool `env:"MIGRATE" envDefault:"false"`
 }
 
 const placeholderURI string = "mongodb+srv://%s:%s@%s.q2lnn.mongodb.net/"
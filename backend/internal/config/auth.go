@@ -1,5 +1,19 @@
 package config
 
+import "errors"
+
 type Auth struct {
 	Secret string `env:"SECRET" envDefault:""`
+	// bcrypt cost for stored passwords; raising it rehashes each password on its next login
+	PasswordCost int `env:"PASSWORD_COST" envDefault:"12"`
+	// also send issued tokens as access_token/refresh_token response headers, for clients from before they were in the body
+	TokenHeaders bool `env:"TOKEN_HEADERS" envDefault:"true"`
+}
+
+func (a Auth) validate() error {
+	// bcrypt's MinCost and MaxCost
+	if a.PasswordCost < 4 || a.PasswordCost > 31 {
+		return errors.New("AUTH_PASSWORD_COST must be between 4 and 31")
+	}
+	return nil
 }
@@ -5,4 +5,8 @@ type AWS struct {
 	Region          string `env:"REGION"`
 	AccessKeyID     string `env:"ACCESS_KEY_ID"`
 	SecretAccessKey string `env:"SECRET_ACCESS_KEY"`
+	// for S3-compatible storage such as MinIO or R2, empty uses AWS
+	Endpoint string `env:"ENDPOINT"`
+	// address buckets as endpoint/bucket instead of bucket.endpoint, which most S3-compatible servers need
+	UsePathStyle bool `env:"USE_PATH_STYLE"`
 }
@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+type Cache struct {
+	// set to false to run cacheless (local development, debugging stale reads)
+	Enabled    bool          `env:"ENABLED" envDefault:"true"`
+	DefaultTTL time.Duration `env:"DEFAULT_TTL" envDefault:"5m"`
+}
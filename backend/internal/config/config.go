@@ -1,14 +1,49 @@
 package config
 
-import "github.com/caarlos0/env/v11"
+import (
+	"errors"
+
+	"github.com/caarlos0/env/v11"
+)
 
 type Config struct {
 	App   `envPrefix:"APP_"`
+	HTTP  `envPrefix:"HTTP_"`
+	CORS  `envPrefix:"CORS_"`
 	Atlas `envPrefix:"ATLAS_"`
 	Auth  `envPrefix:"AUTH_"`
 	AWS   `envPrefix:"AWS_"`
+	Redis `envPrefix:"REDIS_"`
+	Cache `envPrefix:"CACHE_"`
+	RPC   `envPrefix:"RPC_"`
+	Jobs  `envPrefix:"JOBS_"`
+	Admin `envPrefix:"ADMIN_"`
+	Mail  `envPrefix:"MAIL_"`
+	SMS   `envPrefix:"SMS_"`
+
+	Tokens `envPrefix:"TOKENS_"`
+
+	Reminders `envPrefix:"REMINDERS_"`
+	Push      `envPrefix:"PUSH_"`
+
+	Search    `envPrefix:"SEARCH_"`
+	Analytics `envPrefix:"ANALYTICS_"`
+	Uploads   `envPrefix:"UPLOADS_"`
+	Google    `envPrefix:"GOOGLE_"`
+	Apple     `envPrefix:"APPLE_"`
+	Slack     `envPrefix:"SLACK_"`
+	GitHub    `envPrefix:"GITHUB_"`
+	Notion    `envPrefix:"NOTION_"`
+	Inbound   `envPrefix:"INBOUND_"`
+
+	RateLimit `envPrefix:"RATE_LIMIT_"`
+	Tracing   `envPrefix:"TRACING_"`
 }
 
 func Load() (Config, error) {
-	return env.ParseAs[Config]()
+	cfg, err := env.ParseAs[Config]()
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, errors.Join(cfg.HTTP.validate(), cfg.Auth.validate(), cfg.Tokens.validate(cfg.Auth.Secret), cfg.CORS.validate(), cfg.Search.validate(), cfg.Analytics.validate(), cfg.Uploads.validate(cfg.AWS), cfg.Mail.validate(cfg.AWS), cfg.SMS.validate(), cfg.GitHub.validate(), cfg.Push.validate())
 }
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"errors"
+	"slices"
+)
+
+type CORS struct {
+	// comma separated origins of the web client for this environment, * allows any
+	AllowOrigins []string `env:"ALLOW_ORIGINS" envSeparator:"," envDefault:"*"`
+	// needed when the web client authenticates with cookies; requires explicit origins
+	AllowCredentials bool `env:"ALLOW_CREDENTIALS" envDefault:"false"`
+	// seconds browsers may cache a preflight response
+	MaxAge int `env:"MAX_AGE" envDefault:"600"`
+}
+
+func (c CORS) validate() error {
+	if c.AllowCredentials && slices.Contains(c.AllowOrigins, "*") {
+		return errors.New("CORS_ALLOW_CREDENTIALS needs explicit CORS_ALLOW_ORIGINS, not *")
+	}
+	return nil
+}
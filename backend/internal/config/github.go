@@ -0,0 +1,22 @@
+package config
+
+import "errors"
+
+type GitHub struct {
+	// OAuth app for GitHub Issues sync; the integration is off without a client id
+	ClientID     string `env:"CLIENT_ID"`
+	ClientSecret string `env:"CLIENT_SECRET"`
+	// where GitHub sends the user back after authorizing, .../api/v1/integrations/github/callback
+	RedirectURL string `env:"REDIRECT_URL"`
+	// public https address of /hooks/github for repository webhooks, empty falls back to polling
+	WebhookURL string `env:"WEBHOOK_URL"`
+	// signs the webhook deliveries
+	WebhookSecret string `env:"WEBHOOK_SECRET"`
+}
+
+func (g GitHub) validate() error {
+	if g.WebhookURL != "" && g.WebhookSecret == "" {
+		return errors.New("GITHUB_WEBHOOK_URL needs GITHUB_WEBHOOK_SECRET to verify deliveries")
+	}
+	return nil
+}
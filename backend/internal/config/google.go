@@ -0,0 +1,13 @@
+package config
+
+type Google struct {
+	// OAuth client for Google Calendar sync; the integration is off without a client id
+	ClientID     string `env:"CLIENT_ID"`
+	ClientSecret string `env:"CLIENT_SECRET"`
+	// where Google sends the user back after consent, .../api/v1/integrations/google-calendar/callback
+	RedirectURL string `env:"REDIRECT_URL"`
+	// public https address of /hooks/google-calendar for change notifications, empty falls back to polling
+	WebhookURL string `env:"WEBHOOK_URL"`
+	// OAuth client ids (web, iOS, Android) whose ID tokens Google Sign-In accepts; sign-in is off without any
+	SignInClientIDs []string `env:"SIGN_IN_CLIENT_IDS" envSeparator:","`
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+type HTTP struct {
+	// fiber's compress levels: -1 disabled, 0 default, 1 best speed, 2 best compression
+	CompressionLevel int `env:"COMPRESSION_LEVEL" envDefault:"1"`
+	// largest request body in bytes for most routes
+	BodyLimit int `env:"BODY_LIMIT" envDefault:"1048576"`
+	// for routes that take many records at once (batch, offline sync pushes, imports)
+	BulkBodyLimit int `env:"BULK_BODY_LIMIT" envDefault:"10485760"`
+	// for files sent through the uploads API when the storage backend can't presign
+	UploadBodyLimit int `env:"UPLOAD_BODY_LIMIT" envDefault:"26214400"`
+	// Strict-Transport-Security max-age in seconds, 0 leaves it off (e.g. plain http in development)
+	HSTSMaxAge int `env:"HSTS_MAX_AGE" envDefault:"0"`
+	// share of fast, successful requests written to the access log; errors and slow requests always are
+	AccessLogSampleRate float64 `env:"ACCESS_LOG_SAMPLE_RATE" envDefault:"1"`
+	// requests taking at least this long are always logged
+	SlowRequest time.Duration `env:"SLOW_REQUEST" envDefault:"1s"`
+
+	// header the load balancer puts the client's address in, e.g. X-Real-IP or CF-Connecting-IP;
+	// it should be one the proxy overwrites, since fiber reads the first address of a list
+	ProxyHeader string `env:"PROXY_HEADER"`
+	// comma separated IPs or CIDRs of the proxies ProxyHeader is believed from
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+}
+
+func (h HTTP) validate() error {
+	// anyone could claim any address otherwise
+	if h.ProxyHeader != "" && len(h.TrustedProxies) == 0 {
+		return errors.New("HTTP_PROXY_HEADER needs HTTP_TRUSTED_PROXIES")
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+package config
+
+type Inbound struct {
+	// mail for task-<token>@Domain is routed to the inbound webhook by the mail provider
+	Domain string `env:"DOMAIN" envDefault:"in.socialtodo.app"`
+	// the last path segment of the webhook URL given to the provider, the webhook is off without one
+	Secret string `env:"SECRET"`
+}
@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+type Jobs struct {
+	// jobs run at the same time by each instance
+	Concurrency  int           `env:"CONCURRENCY" envDefault:"4"`
+	PollInterval time.Duration `env:"POLL_INTERVAL" envDefault:"1s"`
+	// a job still running after this is assumed lost and handed to another worker
+	Lease time.Duration `env:"LEASE" envDefault:"5m"`
+}
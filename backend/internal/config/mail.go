@@ -0,0 +1,35 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Mail struct {
+	// smtp, ses (in AWS_REGION) or log, which only logs the messages for local development
+	Backend string `env:"BACKEND" envDefault:"log"`
+	From    string `env:"FROM" envDefault:"SocialToDo <no-reply@socialtodo.app>"`
+
+	SMTPHost     string `env:"SMTP_HOST"`
+	SMTPPort     int    `env:"SMTP_PORT" envDefault:"587"`
+	SMTPUsername string `env:"SMTP_USERNAME"`
+	SMTPPassword string `env:"SMTP_PASSWORD"`
+}
+
+func (m Mail) validate(aws AWS) error {
+	switch m.Backend {
+	case "log":
+		return nil
+	case "smtp":
+		if m.SMTPHost == "" {
+			return errors.New("MAIL_BACKEND=smtp needs MAIL_SMTP_HOST")
+		}
+		return nil
+	case "ses":
+		if aws.Region == "" {
+			return errors.New("MAIL_BACKEND=ses needs AWS_REGION")
+		}
+		return nil
+	}
+	return fmt.Errorf("MAIL_BACKEND must be smtp, ses or log, got %q", m.Backend)
+}
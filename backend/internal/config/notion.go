@@ -0,0 +1,9 @@
+package config
+
+type Notion struct {
+	// public Notion integration for exports; Notion export is off without a client id
+	ClientID     string `env:"CLIENT_ID"`
+	ClientSecret string `env:"CLIENT_SECRET"`
+	// where Notion sends the user back after sharing pages, .../api/v1/integrations/notion/callback
+	RedirectURL string `env:"REDIRECT_URL"`
+}
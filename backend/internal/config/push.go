@@ -0,0 +1,23 @@
+package config
+
+import "errors"
+
+type Push struct {
+	// APNs token-based auth, the contents of the .p8 key; iOS pushes are only logged without one
+	APNsKey    string `env:"APNS_KEY"`
+	APNsKeyID  string `env:"APNS_KEY_ID"`
+	APNsTeamID string `env:"APNS_TEAM_ID"`
+	// the app's bundle id
+	APNsTopic string `env:"APNS_TOPIC"`
+	// development builds get their tokens from the sandbox
+	APNsSandbox bool `env:"APNS_SANDBOX"`
+	// service account JSON of the Firebase project; Android pushes are only logged without it
+	FCMCredentials string `env:"FCM_CREDENTIALS"`
+}
+
+func (p Push) validate() error {
+	if p.APNsKey != "" && (p.APNsKeyID == "" || p.APNsTeamID == "" || p.APNsTopic == "") {
+		return errors.New("PUSH_APNS_KEY needs PUSH_APNS_KEY_ID, PUSH_APNS_TEAM_ID and PUSH_APNS_TOPIC")
+	}
+	return nil
+}
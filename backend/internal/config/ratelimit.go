@@ -0,0 +1,33 @@
+package config
+
+import "time"
+
+type RateLimit struct {
+	Enabled bool `env:"ENABLED" envDefault:"true"`
+	// default bucket for every API route
+	Requests int           `env:"REQUESTS" envDefault:"300"`
+	Window   time.Duration `env:"WINDOW" envDefault:"1m"`
+	// stricter bucket for /api/v1/auth (login, register, password resets)
+	AuthRequests int           `env:"AUTH_REQUESTS" envDefault:"20"`
+	AuthWindow   time.Duration `env:"AUTH_WINDOW" envDefault:"1m"`
+
+	// per route budgets on top, counted per client IP and per user (or account
+	// email and client IP while signed out), see middleware.RatePolicy
+	LoginPerIP    int           `env:"LOGIN_PER_IP" envDefault:"10"`
+	LoginPerUser  int           `env:"LOGIN_PER_USER" envDefault:"5"`
+	LoginWindow   time.Duration `env:"LOGIN_WINDOW" envDefault:"5m"`
+	RegisterPerIP int           `env:"REGISTER_PER_IP" envDefault:"5"`
+	// one account per email anyway, this only slows down probing for taken ones
+	RegisterPerUser int           `env:"REGISTER_PER_USER" envDefault:"3"`
+	RegisterWindow  time.Duration `env:"REGISTER_WINDOW" envDefault:"1h"`
+	ResetPerIP      int           `env:"RESET_PER_IP" envDefault:"5"`
+	ResetPerUser    int           `env:"RESET_PER_USER" envDefault:"3"`
+	ResetWindow     time.Duration `env:"RESET_WINDOW" envDefault:"1h"`
+	SearchPerIP     int           `env:"SEARCH_PER_IP" envDefault:"120"`
+	SearchPerUser   int           `env:"SEARCH_PER_USER" envDefault:"60"`
+	SearchWindow    time.Duration `env:"SEARCH_WINDOW" envDefault:"1m"`
+	// phone sign-in codes, on top of the send limit per number
+	OTPPerIP   int           `env:"OTP_PER_IP" envDefault:"10"`
+	OTPPerUser int           `env:"OTP_PER_USER" envDefault:"5"`
+	OTPWindow  time.Duration `env:"OTP_WINDOW" envDefault:"15m"`
+}
@@ -0,0 +1,9 @@
+package config
+
+type Redis struct {
+	// leave empty to run without Redis (local development)
+	Addr     string `env:"ADDR" envDefault:""`
+	Password string `env:"PASSWORD" envDefault:""`
+	DB       int    `env:"DB" envDefault:"0"`
+	PoolSize int    `env:"POOL_SIZE" envDefault:"10"`
+}
@@ -0,0 +1,8 @@
+package config
+
+import "time"
+
+type Reminders struct {
+	// tasks coming due within this get a reminder in the owner's notifications; 0 turns reminders off
+	Window time.Duration `env:"WINDOW" envDefault:"1h"`
+}
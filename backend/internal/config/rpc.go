@@ -0,0 +1,8 @@
+package config
+
+type RPC struct {
+	// internal listener for service-to-service calls, keep it off the public load balancer
+	Addr string `env:"ADDR" envDefault:":9090"`
+	// shared secret internal callers send as a bearer token; the RPC server isn't started without one
+	Token string `env:"TOKEN"`
+}
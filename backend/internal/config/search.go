@@ -0,0 +1,18 @@
+package config
+
+import "fmt"
+
+type Search struct {
+	// mongo uses the collections' text indexes, atlas needs an Atlas Search index on users
+	Engine string `env:"ENGINE" envDefault:"mongo"`
+	// name of the Atlas Search index on the users collection
+	AtlasIndex string `env:"ATLAS_INDEX" envDefault:"default"`
+}
+
+func (s Search) validate() error {
+	switch s.Engine {
+	case "mongo", "atlas":
+		return nil
+	}
+	return fmt.Errorf("SEARCH_ENGINE must be mongo or atlas, got %q", s.Engine)
+}
@@ -0,0 +1,11 @@
+package config
+
+type Slack struct {
+	// the Slack app's OAuth client; the integration is off without a client id
+	ClientID     string `env:"CLIENT_ID"`
+	ClientSecret string `env:"CLIENT_SECRET"`
+	// verifies that slash commands really come from Slack
+	SigningSecret string `env:"SIGNING_SECRET"`
+	// where Slack sends the user back after installing, .../api/v1/integrations/slack/callback
+	RedirectURL string `env:"REDIRECT_URL"`
+}
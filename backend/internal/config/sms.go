@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+type SMS struct {
+	// twilio, or log, which only logs the messages for local development
+	Backend string `env:"BACKEND" envDefault:"log"`
+	// the number or messaging service (MG...) texts are sent from
+	From string `env:"FROM"`
+
+	TwilioAccountSID string `env:"TWILIO_ACCOUNT_SID"`
+	TwilioAuthToken  string `env:"TWILIO_AUTH_TOKEN"`
+}
+
+func (s SMS) validate() error {
+	switch s.Backend {
+	case "log":
+		return nil
+	case "twilio":
+		if s.TwilioAccountSID == "" || s.TwilioAuthToken == "" || s.From == "" {
+			return errors.New("SMS_BACKEND=twilio needs SMS_TWILIO_ACCOUNT_SID, SMS_TWILIO_AUTH_TOKEN and SMS_FROM")
+		}
+		return nil
+	}
+	return fmt.Errorf("SMS_BACKEND must be twilio or log, got %q", s.Backend)
+}
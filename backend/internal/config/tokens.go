@@ -0,0 +1,139 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+/*
+Tokens configures how access and refresh tokens are signed. Rotating the key
+means making the old one the previous key, with an until at least a refresh
+token's lifetime away, and setting the new one as the key: tokens signed with
+the old key keep working until then.
+*/
+type Tokens struct {
+	// HS256, RS256 or EdDSA
+	Algorithm string `env:"ALGORITHM" envDefault:"HS256"`
+	// the kid tokens are signed with, derived from the key when empty
+	KeyID string `env:"KEY_ID"`
+	// a PEM private key for RS256 and EdDSA, the shared secret for HS256 (AUTH_SECRET when empty)
+	Key SigningKey `env:"KEY"`
+
+	PreviousAlgorithm string `env:"PREVIOUS_ALGORITHM"`
+	PreviousKeyID     string `env:"PREVIOUS_KEY_ID"`
+	// may be just the public key, nothing is signed with it anymore
+	PreviousKey SigningKey `env:"PREVIOUS_KEY"`
+	// tokens signed with the previous key are accepted until then (RFC 3339)
+	PreviousUntil time.Time `env:"PREVIOUS_UNTIL"`
+	// tokens from before kids, signed HS256 with AUTH_SECRET, are accepted until then; unset, they aren't
+	LegacyUntil time.Time `env:"LEGACY_UNTIL"`
+
+	AccessTTL  time.Duration `env:"ACCESS_TTL" envDefault:"1h"`
+	RefreshTTL time.Duration `env:"REFRESH_TTL" envDefault:"5040h"`
+}
+
+/*
+SigningKey is a key read from the environment: a PEM block (PKCS #8 or PKCS #1
+private keys, PKIX public keys), or for anything else the bytes themselves,
+an HMAC secret. It is parsed on load so a bad key fails startup.
+*/
+type SigningKey struct {
+	// []byte, *rsa.PrivateKey, *rsa.PublicKey, ed25519.PrivateKey or ed25519.PublicKey
+	Key any
+}
+
+func (k *SigningKey) UnmarshalText(text []byte) error {
+	if !strings.HasPrefix(strings.TrimSpace(string(text)), "-----BEGIN") {
+		k.Key = text
+		return nil
+	}
+	block, _ := pem.Decode([]byte(strings.TrimSpace(string(text))))
+	if block == nil {
+		return errors.New("malformed PEM key")
+	}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		k.Key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		k.Key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		k.Key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	// ParsePKCS8PrivateKey hands out Ed25519 keys as values, the rest as pointers
+	if key, ok := k.Key.(*ed25519.PrivateKey); ok {
+		k.Key = *key
+	}
+	return err
+}
+
+// validate checks the keys; secret is AUTH_SECRET, the HS256 key when TOKENS_KEY is unset
+func (t Tokens) validate(secret string) error {
+	var errs []error
+	key := t.Key.Key
+	if key == nil && t.Algorithm == "HS256" && secret != "" {
+		key = []byte(secret)
+	}
+	if err := checkKey(t.Algorithm, key, false); err != nil {
+		errs = append(errs, fmt.Errorf("TOKENS_KEY: %w", err))
+	}
+	if t.PreviousKey.Key != nil {
+		algorithm := t.PreviousAlgorithm
+		if algorithm == "" {
+			algorithm = t.Algorithm
+		}
+		if err := checkKey(algorithm, t.PreviousKey.Key, true); err != nil {
+			errs = append(errs, fmt.Errorf("TOKENS_PREVIOUS_KEY: %w", err))
+		}
+		if t.PreviousUntil.IsZero() {
+			errs = append(errs, errors.New("TOKENS_PREVIOUS_UNTIL is required with TOKENS_PREVIOUS_KEY"))
+		}
+	}
+	if !t.LegacyUntil.IsZero() && secret == "" {
+		errs = append(errs, errors.New("TOKENS_LEGACY_UNTIL needs the AUTH_SECRET legacy tokens were signed with"))
+	}
+	if t.AccessTTL <= 0 || t.RefreshTTL < t.AccessTTL {
+		errs = append(errs, errors.New("TOKENS_ACCESS_TTL must be positive and TOKENS_REFRESH_TTL at least as long"))
+	}
+	return errors.Join(errs...)
+}
+
+// checkKey reports whether key can sign (or with verifyOnly, verify) tokens with algorithm
+func checkKey(algorithm string, key any, verifyOnly bool) error {
+	switch algorithm {
+	case "HS256":
+		if secret, ok := key.([]byte); !ok || len(secret) == 0 {
+			return errors.New("HS256 takes a secret, set TOKENS_KEY or AUTH_SECRET")
+		}
+		return nil
+	case "RS256":
+		switch key.(type) {
+		case *rsa.PrivateKey:
+			return nil
+		case *rsa.PublicKey:
+			if verifyOnly {
+				return nil
+			}
+		}
+		return errors.New("RS256 takes an RSA private key")
+	case "EdDSA":
+		switch key.(type) {
+		case ed25519.PrivateKey:
+			return nil
+		case ed25519.PublicKey:
+			if verifyOnly {
+				return nil
+			}
+		}
+		return errors.New("EdDSA takes an Ed25519 private key")
+	}
+	return fmt.Errorf("unknown algorithm %q, expected HS256, RS256 or EdDSA", algorithm)
+}
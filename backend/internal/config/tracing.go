@@ -0,0 +1,11 @@
+package config
+
+type Tracing struct {
+	// OTLP/HTTP traces endpoint, e.g. https://otel-collector:4318/v1/traces; tracing is off when empty
+	Endpoint string `env:"ENDPOINT"`
+	// sent as-is, e.g. for the collector's API key
+	Headers     map[string]string `env:"HEADERS"`
+	ServiceName string            `env:"SERVICE_NAME" envDefault:"socialtodo-api"`
+	// share of traces started here that are kept; a caller's sampling decision is always followed
+	SampleRatio float64 `env:"SAMPLE_RATIO" envDefault:"1"`
+}
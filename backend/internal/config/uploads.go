@@ -0,0 +1,29 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Uploads struct {
+	// s3 stores files in AWS_BUCKET_NAME, gridfs in the database
+	Backend string `env:"BACKEND" envDefault:"gridfs"`
+	// lifetime of presigned upload and download URLs
+	PresignTTL time.Duration `env:"PRESIGN_TTL" envDefault:"15m"`
+	// virus scanner the files are posted to before they are accepted, empty skips scanning
+	ScanURL string `env:"SCAN_URL"`
+}
+
+func (u Uploads) validate(aws AWS) error {
+	switch u.Backend {
+	case "gridfs":
+		return nil
+	case "s3":
+		if aws.BucketName == "" {
+			return errors.New("UPLOADS_BACKEND=s3 needs AWS_BUCKET_NAME")
+		}
+		return nil
+	}
+	return fmt.Errorf("UPLOADS_BACKEND must be s3 or gridfs, got %q", u.Backend)
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+)
+
+/*
+In-process publish/subscribe for internal events. Producers (the change stream
+consumer, services) publish without knowing who listens; the WebSocket hub,
+cache invalidation and other modules subscribe to the event types they care about.
+*/
+
+type Type string
+
+const (
+	// a user's categories or the tasks embedded in them changed
+	TasksChanged Type = "tasks.changed"
+	// profile fields on the user document changed
+	UserChanged         Type = "user.changed"
+	FeedCreated         Type = "feed.created"
+	NotificationCreated Type = "notification.created"
+	// a user's stats were recomputed after a completion; the payload is the new *stats.Stats
+	StatsUpdated Type = "stats.updated"
+
+	// domain events, relayed from the outbox after the change commits, with the payloads in payloads.go
+	TaskCreated    Type = "task.created"
+	TaskCompleted  Type = "task.completed"
+	UserRegistered Type = "user.registered"
+	// published for both friends; the user is the one who gained a friend
+	FriendAdded Type = "friend.added"
+)
+
+type Event struct {
+	ID         string    `json:"id"`
+	Type       Type      `json:"type"`
+	UserID     string    `json:"user_id"`
+	Collection string    `json:"collection,omitempty"`
+	DocumentID string    `json:"document_id,omitempty"`
+	Payload    any       `json:"payload,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type Handler func(ctx context.Context, event Event)
+
+const (
+	// events waiting for an async handler; past it they are dropped rather than block the publisher
+	asyncQueueSize = 1024
+	asyncWorkers   = 8
+	// how long an async handler gets per event
+	AsyncTimeout = 10 * time.Second
+)
+
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+	all      []Handler
+
+	// deliveries to SubscribeAsync handlers, run by a pool started on the first one
+	queue   chan delivery
+	start   sync.Once
+	workers sync.WaitGroup
+	closed  bool
+}
+
+type delivery struct {
+	ctx     context.Context
+	handler Handler
+	event   Event
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[Type][]Handler), queue: make(chan delivery, asyncQueueSize)}
+}
+
+// Subscribe registers handler for the given event types, or for every event when none are given
+func (b *Bus) Subscribe(handler Handler, types ...Type) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(types) == 0 {
+		b.all = append(b.all, handler)
+		return
+	}
+	for _, t := range types {
+		b.handlers[t] = append(b.handlers[t], handler)
+	}
+}
+
+/*
+SubscribeAsync is Subscribe for handlers that do I/O. Events are queued and
+handled by a small worker pool instead of on the publisher's goroutine, each
+with AsyncTimeout and the publisher's context values but not its
+cancellation. Close waits for the queue to drain.
+*/
+func (b *Bus) SubscribeAsync(handler Handler, types ...Type) {
+	b.start.Do(func() {
+		for range asyncWorkers {
+			b.workers.Add(1)
+			go b.work()
+		}
+	})
+	b.Subscribe(func(ctx context.Context, event Event) {
+		b.enqueue(delivery{context.WithoutCancel(ctx), handler, event})
+	}, types...)
+}
+
+func (b *Bus) enqueue(d delivery) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		slog.LogAttrs(d.ctx, slog.LevelWarn, "Event published after the bus closed", slog.String("event_type", string(d.event.Type)))
+		return
+	}
+	select {
+	case b.queue <- d:
+	default:
+		slog.LogAttrs(d.ctx, slog.LevelError, "Event queue full, dropping event", slog.String("event_type", string(d.event.Type)))
+	}
+}
+
+func (b *Bus) work() {
+	defer b.workers.Done()
+	for d := range b.queue {
+		ctx, cancel := context.WithTimeout(d.ctx, AsyncTimeout)
+		deliver(ctx, d.handler, d.event)
+		cancel()
+	}
+}
+
+/*
+Close stops queueing events for async handlers and waits until the ones
+already queued are handled, or ctx is done. Synchronous handlers keep
+receiving events.
+*/
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+/*
+Publish delivers event to every subscriber synchronously, in subscription order.
+Handlers must be quick; anything doing I/O subscribes with SubscribeAsync.
+A panicking handler is logged and does not stop delivery to the others.
+*/
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, 0, len(b.handlers[event.Type])+len(b.all))
+	handlers = append(handlers, b.handlers[event.Type]...)
+	handlers = append(handlers, b.all...)
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		deliver(ctx, handler, event)
+	}
+}
+
+func deliver(ctx context.Context, handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Event handler panicked",
+				slog.String("event_type", string(event.Type)), xslog.Error(fmt.Errorf("%v", r)))
+		}
+	}()
+	handler(ctx, event)
+}
@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPublish(t *testing.T) {
+	bus := NewBus()
+	var got []Type
+	bus.Subscribe(func(ctx context.Context, event Event) { got = append(got, event.Type) }, TaskCompleted)
+	bus.Subscribe(func(ctx context.Context, event Event) { panic("boom") }, TaskCompleted)
+	bus.Subscribe(func(ctx context.Context, event Event) { got = append(got, "all:"+event.Type) })
+
+	bus.Publish(context.Background(), Event{Type: TaskCompleted})
+	bus.Publish(context.Background(), Event{Type: FriendAdded})
+
+	expected := []Type{TaskCompleted, "all:" + TaskCompleted, "all:" + FriendAdded}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestSubscribeAsync(t *testing.T) {
+	bus := NewBus()
+	var handled atomic.Int32
+	release := make(chan struct{})
+	bus.SubscribeAsync(func(ctx context.Context, event Event) {
+		<-release
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected the handler's context to have a deadline")
+		}
+		if event.Type == FriendAdded {
+			panic("boom")
+		}
+		handled.Add(1)
+	}, TaskCompleted, FriendAdded)
+
+	// the publisher's context ending doesn't cancel the handler
+	ctx, cancel := context.WithCancel(context.Background())
+	for range 3 {
+		bus.Publish(ctx, Event{Type: TaskCompleted})
+	}
+	bus.Publish(ctx, Event{Type: FriendAdded})
+	cancel()
+	if handled.Load() != 0 {
+		t.Fatal("expected Publish not to wait for async handlers")
+	}
+
+	close(release)
+	closeCtx, stop := context.WithTimeout(context.Background(), time.Second)
+	defer stop()
+	if err := bus.Close(closeCtx); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if handled.Load() != 3 {
+		t.Errorf("expected Close to drain 3 events, handled %d", handled.Load())
+	}
+
+	// dropped rather than sent on the closed queue
+	bus.Publish(context.Background(), Event{Type: TaskCompleted})
+}
+
+func TestCloseTimeout(t *testing.T) {
+	bus := NewBus()
+	block := make(chan struct{})
+	defer close(block)
+	bus.SubscribeAsync(func(ctx context.Context, event Event) { <-block }, TaskCompleted)
+	bus.Publish(context.Background(), Event{Type: TaskCompleted})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bus.Close(ctx); err == nil {
+		t.Error("expected Close to give up at the deadline")
+	}
+}
+
+func TestDecodePayload(t *testing.T) {
+	taskID, by := primitive.NewObjectID(), primitive.NewObjectID()
+	at := time.Date(2026, time.March, 11, 10, 0, 0, 0, time.UTC)
+	expected := TaskCompletedPayload{TaskID: taskID, CompletedAt: at, CompletedBy: by}
+
+	tests := []struct {
+		name          string
+		payload       any
+		expectedError bool
+	}{
+		{"published", expected, false},
+		{"published as a pointer", &expected, false},
+		{"relayed from the outbox", bson.M{"task_id": taskID, "completed_at": primitive.NewDateTimeFromTime(at), "completed_by": by}, false},
+		{"missing", nil, true},
+		{"not a document", "t1", true},
+	}
+	for _, tt := range tests {
+		got, err := DecodePayload[TaskCompletedPayload](Event{Type: TaskCompleted, Payload: tt.payload})
+		if (err != nil) != tt.expectedError {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.expectedError, err)
+			continue
+		}
+		if err == nil && (got.TaskID != taskID || got.CompletedBy != by || !got.CompletedAt.Equal(at)) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, expected, got)
+		}
+	}
+}
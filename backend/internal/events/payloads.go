@@ -0,0 +1,61 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+Payloads of the domain events. Producers publish these, but events relayed
+from the outbox arrive with the payload as the bson.M it was stored as, so
+subscribers read them with DecodePayload rather than a type assertion.
+*/
+
+// TaskCreatedPayload has SeriesID for the next occurrence of a recurring task, and CategoryID otherwise
+type TaskCreatedPayload struct {
+	TaskID     primitive.ObjectID  `bson:"task_id" json:"task_id"`
+	CategoryID primitive.ObjectID  `bson:"category_id,omitempty" json:"category_id,omitempty"`
+	SeriesID   *primitive.ObjectID `bson:"series_id,omitempty" json:"series_id,omitempty"`
+}
+
+// TaskCompletedPayload is published for the task's owner; CompletedBy may be a collaborator
+type TaskCompletedPayload struct {
+	TaskID      primitive.ObjectID `bson:"task_id" json:"task_id"`
+	CompletedAt time.Time          `bson:"completed_at" json:"completed_at"`
+	CompletedBy primitive.ObjectID `bson:"completed_by" json:"completed_by"`
+}
+
+type UserRegisteredPayload struct {
+	Handle string `bson:"handle" json:"handle"`
+}
+
+type FriendAddedPayload struct {
+	FriendID primitive.ObjectID `bson:"friend_id" json:"friend_id"`
+}
+
+// DecodePayload reads event's payload as a T, whether it was published as one, a *T or relayed as a document
+func DecodePayload[T any](event Event) (T, error) {
+	var payload T
+	switch p := event.Payload.(type) {
+	case T:
+		return p, nil
+	case *T:
+		if p != nil {
+			return *p, nil
+		}
+	case nil:
+	default:
+		raw, err := bson.Marshal(p)
+		if err != nil {
+			return payload, fmt.Errorf("%s payload: %w", event.Type, err)
+		}
+		if err := bson.Unmarshal(raw, &payload); err != nil {
+			return payload, fmt.Errorf("%s payload: %w", event.Type, err)
+		}
+		return payload, nil
+	}
+	return payload, fmt.Errorf("%s event has no payload", event.Type)
+}
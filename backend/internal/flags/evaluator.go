@@ -0,0 +1,66 @@
+package flags
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// CacheTTL is how long an evaluator serves flags before reloading them, and so how long a change takes to apply everywhere
+const CacheTTL = 15 * time.Second
+
+/*
+Evaluator answers flag checks inside request handling. It keeps every flag in
+memory and reloads the whole set once the cache is older than its ttl, so a
+check costs at most one query per ttl per process. If a reload fails the
+previous set is kept; with nothing loaded yet every flag is off.
+*/
+type Evaluator struct {
+	store *Store
+	ttl   time.Duration
+
+	mu       sync.Mutex
+	flags    map[string]Flag
+	loadedAt time.Time
+}
+
+func NewEvaluator(store *Store, ttl time.Duration) *Evaluator {
+	return &Evaluator{store: store, ttl: ttl}
+}
+
+// Enabled reports whether name is on for userID
+func (e *Evaluator) Enabled(ctx context.Context, name string, userID string) bool {
+	flag, ok := e.snapshot(ctx)[name]
+	return ok && flag.For(userID)
+}
+
+// Invalidate makes the next check reload the flags
+func (e *Evaluator) Invalidate() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.loadedAt = time.Time{}
+}
+
+func (e *Evaluator) snapshot(ctx context.Context) map[string]Flag {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if time.Since(e.loadedAt) < e.ttl {
+		return e.flags
+	}
+
+	list, err := e.store.List(ctx)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Loading feature flags failed", slog.Any("error", err))
+		// back off for a full ttl rather than retrying on every check
+		e.loadedAt = time.Now()
+		return e.flags
+	}
+	flags := make(map[string]Flag, len(list))
+	for _, flag := range list {
+		flags[flag.Name] = flag
+	}
+	e.flags = flags
+	e.loadedAt = time.Now()
+	return e.flags
+}
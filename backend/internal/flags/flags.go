@@ -0,0 +1,126 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Feature flags live in the flags collection, one document per flag keyed by
+its name, so they can be flipped from the admin API without a deploy. A flag
+is a plain switch unless it carries rollout rules: users listed in Users
+always get it, and Percentage rolls it out to a stable share of everyone
+else. Enabled turns the whole flag off regardless of its rules.
+*/
+
+const Collection = "flags"
+
+// FeedRanking switches the feed from newest first to the ranked order
+const FeedRanking = "feed_ranking"
+
+type Flag struct {
+	Name    string `bson:"_id" json:"name"`
+	Enabled bool   `bson:"enabled" json:"enabled"`
+	// Percentage of users, 0-100, that get the flag; nil leaves it on for everyone unless Users is set
+	Percentage  *int      `bson:"percentage,omitempty" json:"percentage,omitempty"`
+	Users       []string  `bson:"users,omitempty" json:"users,omitempty"`
+	Description string    `bson:"description,omitempty" json:"description,omitempty"`
+	UpdatedAt   time.Time `bson:"updated_at" json:"updated_at"`
+	UpdatedBy   string    `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+}
+
+type Store struct {
+	flags *mongo.Collection
+}
+
+func New(flags *mongo.Collection) *Store {
+	return &Store{flags: flags}
+}
+
+// List returns every flag, most recently changed first
+func (s *Store) List(ctx context.Context) ([]Flag, error) {
+	cursor, err := s.flags.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Flag, 0)
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Get returns a single flag, mongo.ErrNoDocuments if it doesn't exist
+func (s *Store) Get(ctx context.Context, name string) (*Flag, error) {
+	var flag Flag
+	if err := s.flags.FindOne(ctx, bson.M{"_id": name}).Decode(&flag); err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// Set creates or replaces a flag
+func (s *Store) Set(ctx context.Context, flag Flag) error {
+	flag.UpdatedAt = time.Now()
+	_, err := s.flags.ReplaceOne(ctx, bson.M{"_id": flag.Name}, flag, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete removes a flag, mongo.ErrNoDocuments if it doesn't exist
+func (s *Store) Delete(ctx context.Context, name string) error {
+	result, err := s.flags.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Enabled reports whether name is on for userID; unknown flags are off
+func (s *Store) Enabled(ctx context.Context, name string, userID string) (bool, error) {
+	flag, err := s.Get(ctx, name)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return flag.For(userID), nil
+}
+
+// For evaluates the flag's rules for userID
+func (f *Flag) For(userID string) bool {
+	if !f.Enabled {
+		return false
+	}
+	if slices.Contains(f.Users, userID) {
+		return true
+	}
+	if f.Percentage == nil {
+		// a flag targeted at a list of users is off for everyone else
+		return len(f.Users) == 0
+	}
+	return userID != "" && bucket(f.Name, userID) < *f.Percentage
+}
+
+/*
+bucket places a user in 0-99 for a flag. Hashing the flag name in keeps each
+user's bucket stable as a rollout grows, without the same users landing in
+the first few percent of every flag.
+*/
+func bucket(name string, userID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,51 @@
+package achievements
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:generate mockgen -source=achievements.go -destination=mock_test.go -package=achievements
+
+// Achievements is what Handler needs of the service
+type Achievements interface {
+	Earned(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) ([]EarnedBadge, error)
+}
+
+var _ Achievements = (*Service)(nil)
+
+/*
+Handler to execute business logic for badges
+*/
+type Handler struct {
+	service Achievements
+}
+
+func (h *Handler) GetUserBadges(c *fiber.Ctx) error {
+	viewer, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	badges, err := h.service.Earned(c.UserContext(), viewer, id)
+	if errors.Is(err, ErrUserNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load badges",
+		})
+	}
+	return c.JSON(badges)
+}
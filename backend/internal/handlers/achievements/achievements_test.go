@@ -0,0 +1,98 @@
+package achievements
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestEarned(t *testing.T) {
+	tests := []struct {
+		name     string
+		on       events.Type
+		facts    Facts
+		expected []string
+	}{
+		{"nothing yet", events.StatsUpdated, Facts{}, nil},
+		{"first completion", events.StatsUpdated, Facts{Completed: 1, LongestStreak: 1}, []string{"first_task"}},
+		{"a week in a row", events.StatsUpdated, Facts{Completed: 12, LongestStreak: 7}, []string{"first_task", "streak_7"}},
+		{"a hundred", events.StatsUpdated, Facts{Completed: 100, LongestStreak: 3}, []string{"first_task", "tasks_100"}},
+		{"stats don't award friend badges", events.StatsUpdated, Facts{Completed: 1, Friends: 4}, []string{"first_task"}},
+		{"first friend", events.FriendAdded, Facts{Friends: 1}, []string{"first_friend"}},
+		{"friend removed before handling", events.FriendAdded, Facts{Completed: 100}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, badge := range earned(tt.on, tt.facts) {
+			got = append(got, badge.ID)
+		}
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestProfileBadges(t *testing.T) {
+	at := time.Date(2026, time.March, 11, 10, 0, 0, 0, time.UTC)
+	badges := profileBadges([]UserBadge{
+		{Badge: "first_friend", EarnedAt: at},
+		{Badge: "retired", EarnedAt: at},
+		{Badge: "first_task", EarnedAt: at.Add(-time.Hour)},
+	})
+	var ids []string
+	for _, badge := range badges {
+		ids = append(ids, badge.ID)
+		if badge.Name == "" {
+			t.Errorf("%s: expected a name", badge.ID)
+		}
+	}
+	if expected := []string{"first_task", "first_friend"}; !slices.Equal(ids, expected) {
+		t.Errorf("expected %v, got %v", expected, ids)
+	}
+	if !badges[0].EarnedAt.Equal(at.Add(-time.Hour)) {
+		t.Errorf("expected first_task earned at %v, got %v", at.Add(-time.Hour), badges[0].EarnedAt)
+	}
+}
+
+func TestGetUserBadges(t *testing.T) {
+	userID, friendID, strangerID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	service := NewMockAchievements(gomock.NewController(t))
+	service.EXPECT().Earned(gomock.Any(), userID, friendID).Return([]EarnedBadge{{ID: "first_task"}}, nil)
+	service.EXPECT().Earned(gomock.Any(), userID, strangerID).Return(nil, ErrUserNotFound)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Get("/users/:id/badges", handler.GetUserBadges)
+
+	tests := []struct {
+		name         string
+		route        string
+		expectedCode int
+	}{
+		{"a friend's", "/users/" + friendID.Hex() + "/badges", fiber.StatusOK},
+		{"a hidden profile's", "/users/" + strangerID.Hex() + "/badges", fiber.StatusNotFound},
+		{"invalid id", "/users/nope/badges", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, tt.route, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expectedCode {
+			body, _ := io.ReadAll(res.Body)
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.expectedCode, res.StatusCode, body)
+		}
+	}
+}
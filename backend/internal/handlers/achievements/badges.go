@@ -0,0 +1,94 @@
+package achievements
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/stats"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Badges are all the badges there are, in the order profiles show them
+var Badges = []Badge{
+	{
+		ID:          "first_task",
+		Name:        "First Task",
+		Description: "Completed a task",
+		on:          events.StatsUpdated,
+		earned:      func(f Facts) bool { return f.Completed >= 1 },
+	},
+	{
+		ID:          "streak_7",
+		Name:        "Week Streak",
+		Description: "Completed a task every day for 7 days in a row",
+		on:          events.StatsUpdated,
+		earned:      func(f Facts) bool { return f.LongestStreak >= 7 },
+	},
+	{
+		ID:          "tasks_100",
+		Name:        "Centurion",
+		Description: "Completed 100 tasks",
+		on:          events.StatsUpdated,
+		earned:      func(f Facts) bool { return f.Completed >= 100 },
+	},
+	{
+		ID:          "first_friend",
+		Name:        "First Friend",
+		Description: "Made a friend",
+		on:          events.FriendAdded,
+		earned:      func(f Facts) bool { return f.Friends >= 1 },
+	},
+}
+
+// earned picks out the badges checked on the event that facts hold for
+func earned(on events.Type, facts Facts) []Badge {
+	var badges []Badge
+	for _, badge := range Badges {
+		if badge.on == on && badge.earned(facts) {
+			badges = append(badges, badge)
+		}
+	}
+	return badges
+}
+
+/*
+SubscribeEvents awards badges as stats are recomputed after a completion and
+as friends are added. Awarding is idempotent, so a redelivered event or one
+handled on two instances at once earns a badge once.
+*/
+func SubscribeEvents(bus *events.Bus, collections map[string]*mongo.Collection) {
+	s := newService(collections)
+	bus.SubscribeAsync(func(ctx context.Context, event events.Event) {
+		userID, err := primitive.ObjectIDFromHex(event.UserID)
+		if err != nil {
+			return
+		}
+		var facts Facts
+		switch event.Type {
+		case events.StatsUpdated:
+			updated, err := events.DecodePayload[stats.Stats](event)
+			if err != nil {
+				return
+			}
+			facts = Facts{Completed: updated.Total, LongestStreak: updated.LongestStreak}
+		case events.FriendAdded:
+			friends, err := s.friends(ctx, userID)
+			if err != nil {
+				slog.LogAttrs(ctx, slog.LevelError, "Failed to count friends for badges",
+					slog.String("user_id", userID.Hex()), xslog.Error(err))
+				return
+			}
+			facts = Facts{Friends: friends}
+		}
+
+		for _, badge := range earned(event.Type, facts) {
+			if err := s.Award(ctx, userID, badge.ID); err != nil {
+				slog.LogAttrs(ctx, slog.LevelError, "Failed to award badge",
+					slog.String("user_id", userID.Hex()), slog.String("badge", badge.ID), xslog.Error(err))
+			}
+		}
+	}, events.StatsUpdated, events.FriendAdded)
+}
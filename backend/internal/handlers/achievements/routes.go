@@ -0,0 +1,24 @@
+package achievements
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Users := apiV1.Group("/users", authenticate)
+	Users.Get("/:id/badges", handler.GetUserBadges)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/users/:id/badges": {Summary: "The badges a user has earned, to whoever may see their profile", Auth: true, Response: []EarnedBadge{}},
+	})
+}
@@ -0,0 +1,97 @@
+package achievements
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// newService receives the map of collections and picks out UserBadges and Users
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{
+		badges: collections[Collection],
+		users:  collections["users"],
+	}
+}
+
+// Award records that the user earned badge, keeping when they first did
+func (s *Service) Award(ctx context.Context, userID primitive.ObjectID, badge string) error {
+	_, err := s.badges.UpdateOne(ctx, bson.M{"user": userID, "badge": badge},
+		bson.M{"$setOnInsert": bson.M{"earned_at": time.Now()}},
+		options.Update().SetUpsert(true))
+	// the same badge upserted concurrently, which the unique index lets through once
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+/*
+Earned is the badges id has earned, in the order of Badges, for viewers who
+may see their profile; anyone else gets ErrUserNotFound, as for the profile.
+*/
+func (s *Service) Earned(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) ([]EarnedBadge, error) {
+	relations, err := privacy.Load(ctx, s.users, []primitive.ObjectID{viewer, id})
+	if err != nil {
+		return nil, err
+	}
+	owner, ok := relations[id]
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	if me, ok := relations[viewer]; !ok || !privacy.CanView(me, owner) {
+		return nil, ErrUserNotFound
+	}
+
+	cursor, err := s.badges.Find(ctx, bson.M{"user": id})
+	if err != nil {
+		return nil, err
+	}
+	var records []UserBadge
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	return profileBadges(records), nil
+}
+
+// profileBadges describes the badges recorded, skipping any since retired from Badges
+func profileBadges(records []UserBadge) []EarnedBadge {
+	earnedAt := make(map[string]time.Time, len(records))
+	for _, record := range records {
+		earnedAt[record.Badge] = record.EarnedAt
+	}
+	badges := make([]EarnedBadge, 0, len(records))
+	for _, badge := range Badges {
+		if at, ok := earnedAt[badge.ID]; ok {
+			badges = append(badges, EarnedBadge{
+				ID:          badge.ID,
+				Name:        badge.Name,
+				Description: badge.Description,
+				EarnedAt:    at,
+			})
+		}
+	}
+	return badges
+}
+
+// friends counts the user's friends now, which may be fewer than when the event was published
+func (s *Service) friends(ctx context.Context, userID primitive.ObjectID) (int, error) {
+	var user struct {
+		Friends []primitive.ObjectID `bson:"friends"`
+	}
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(bson.M{"friends": 1})).Decode(&user)
+	if err != nil {
+		return 0, err
+	}
+	return len(user.Friends), nil
+}
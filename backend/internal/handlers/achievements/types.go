@@ -0,0 +1,57 @@
+package achievements
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const Collection = "user_badges"
+
+/*
+Badge is an achievement a user earns once and keeps. Each is checked when the
+event that can change what it depends on comes in, against the Facts that
+event gives.
+*/
+type Badge struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	on     events.Type
+	earned func(Facts) bool
+}
+
+// Facts is what badges are earned on, as of the event being handled
+type Facts struct {
+	Completed     int
+	LongestStreak int
+	Friends       int
+}
+
+// UserBadge records a badge earned; there is one per user and badge
+type UserBadge struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	User     primitive.ObjectID `bson:"user" json:"user_id"`
+	Badge    string             `bson:"badge" json:"badge"`
+	EarnedAt time.Time          `bson:"earned_at" json:"earned_at"`
+}
+
+// EarnedBadge is a badge as shown on a profile
+type EarnedBadge struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	EarnedAt    time.Time `json:"earned_at"`
+}
+
+/*
+Achievements Service to be used by Achievements Handler to interact with the
+Database layer of the application
+*/
+type Service struct {
+	badges *mongo.Collection
+	users  *mongo.Collection
+}
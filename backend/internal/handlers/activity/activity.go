@@ -1,15 +1,38 @@
 package Activity
 
 import (
+	"context"
+	"errors"
 	"time"
 
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+//go:generate mockgen -source=activity.go -destination=mock_test.go -package=Activity
+
+// Activities is the activity service as Handler uses it
+type Activities interface {
+	GetFeed(ctx context.Context, viewer primitive.ObjectID, query FeedQuery) (*FeedPage, error)
+	React(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID, emoji string) (*Reaction, error)
+	Unreact(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID, emoji string) error
+	GetVisibleActivitys(ctx context.Context, viewer primitive.ObjectID, query xpage.Query) (*xpage.Page[ActivityEntry], error)
+	GetActivityByID(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) (*ActivityEntry, error)
+	Owns(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) error
+	CreateActivity(ctx context.Context, user primitive.ObjectID, r *ActivityDocument) (*ActivityEntry, error)
+	UpdatePartialActivity(ctx context.Context, id primitive.ObjectID, updated UpdateActivityDocument) error
+	DeleteActivity(ctx context.Context, id primitive.ObjectID) error
+}
+
+var _ Activities = (*Service)(nil)
+
 type Handler struct {
-	service *Service
+	service Activities
 }
 
 func (h *Handler) CreateActivity(c *fiber.Ctx) error {
@@ -27,6 +50,11 @@ func (h *Handler) CreateActivity(c *fiber.Ctx) error {
 		})
 	}
 
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+
 	doc := ActivityDocument{
 		ID:        primitive.NewObjectID(),
 		Field1:    params.Field1,
@@ -35,18 +63,38 @@ func (h *Handler) CreateActivity(c *fiber.Ctx) error {
 		Timestamp: time.Now(),
 	}
 
-	_, err := h.service.CreateActivity(&doc)
+	entry, err := h.service.CreateActivity(c.UserContext(), userId, &doc)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create Activity",
 		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(doc)
+	return c.Status(fiber.StatusCreated).JSON(entry)
 }
 
 func (h *Handler) GetActivitys(c *fiber.Ctx) error {
-	Activitys, err := h.service.GetAllActivitys()
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	var query xpage.Query
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := xvalidator.Validator.Validate(query); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	Activitys, err := h.service.GetVisibleActivitys(c.UserContext(), userId, query)
+	if errors.Is(err, ErrInvalidCursor) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch Activitys",
@@ -57,6 +105,10 @@ func (h *Handler) GetActivitys(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetActivity(c *fiber.Ctx) error {
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
 	id, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -64,16 +116,48 @@ func (h *Handler) GetActivity(c *fiber.Ctx) error {
 		})
 	}
 
-	Activity, err := h.service.GetActivityByID(id)
-	if err != nil {
+	Activity, err := h.service.GetActivityByID(c.UserContext(), userId, id)
+	if errors.Is(err, ErrActivityNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Activity not found",
 		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch Activity",
+		})
 	}
 
 	return c.JSON(Activity)
 }
 
+/*
+OwnActivity lets the request through only for the caller's own activity items;
+anyone else's answer 404 as though they didn't exist, as OwnTask does for tasks.
+*/
+func (h *Handler) OwnActivity(c *fiber.Ctx) error {
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.service.Owns(c.UserContext(), userId, id); errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Activity not found",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch Activity",
+		})
+	}
+	return c.Next()
+}
+
 func (h *Handler) UpdatePartialActivity(c *fiber.Ctx) error {
 	id, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
@@ -89,7 +173,7 @@ func (h *Handler) UpdatePartialActivity(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.UpdatePartialActivity(id, update); err != nil {
+	if err := h.service.UpdatePartialActivity(c.UserContext(), id, update); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update Activity",
 		})
@@ -106,7 +190,7 @@ func (h *Handler) DeleteActivity(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.DeleteActivity(id); err != nil {
+	if err := h.service.DeleteActivity(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete Activity",
 		})
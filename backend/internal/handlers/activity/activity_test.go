@@ -0,0 +1,122 @@
+package Activity
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+func TestOwnActivity(t *testing.T) {
+	userID := primitive.NewObjectID()
+	own, others, broken := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	service := NewMockActivities(gomock.NewController(t))
+	service.EXPECT().Owns(gomock.Any(), userID, own).Return(nil)
+	service.EXPECT().Owns(gomock.Any(), userID, others).Return(mongo.ErrNoDocuments)
+	service.EXPECT().Owns(gomock.Any(), userID, broken).Return(errors.New("connection reset"))
+	service.EXPECT().DeleteActivity(gomock.Any(), own).Return(nil)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Delete("/:id", func(c *fiber.Ctx) error {
+		xauth.Set(c, userID.Hex(), "", nil)
+		return c.Next()
+	}, handler.OwnActivity, handler.DeleteActivity)
+
+	tests := []struct {
+		name     string
+		id       string
+		expected int
+	}{
+		{"own", own.Hex(), fiber.StatusOK},
+		{"someone else's", others.Hex(), fiber.StatusNotFound},
+		{"failing lookup", broken.Hex(), fiber.StatusInternalServerError},
+		{"bad id", "nope", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := app.Test(httptest.NewRequest(http.MethodDelete, "/"+tt.id, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
+
+func TestGetActivity(t *testing.T) {
+	userID := primitive.NewObjectID()
+	visible, hidden := primitive.NewObjectID(), primitive.NewObjectID()
+
+	service := NewMockActivities(gomock.NewController(t))
+	service.EXPECT().GetActivityByID(gomock.Any(), userID, visible).Return(&ActivityEntry{ActivityDocument: ActivityDocument{ID: visible}}, nil)
+	service.EXPECT().GetActivityByID(gomock.Any(), userID, hidden).Return(nil, ErrActivityNotFound)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Get("/:id", func(c *fiber.Ctx) error {
+		xauth.Set(c, userID.Hex(), "", nil)
+		return c.Next()
+	}, handler.GetActivity)
+
+	tests := []struct {
+		name     string
+		id       primitive.ObjectID
+		expected int
+	}{
+		{"visible", visible, fiber.StatusOK},
+		{"outside the feed", hidden, fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, "/"+tt.id.Hex(), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
+
+func TestGetActivitys(t *testing.T) {
+	userID := primitive.NewObjectID()
+	service := NewMockActivities(gomock.NewController(t))
+	service.EXPECT().GetVisibleActivitys(gomock.Any(), userID, xpage.Query{}).Return(&xpage.Page[ActivityEntry]{}, nil)
+	service.EXPECT().GetVisibleActivitys(gomock.Any(), userID, xpage.Query{Limit: 10, Cursor: "next"}).Return(&xpage.Page[ActivityEntry]{}, nil)
+	service.EXPECT().GetVisibleActivitys(gomock.Any(), userID, xpage.Query{Cursor: "forged"}).Return(nil, ErrInvalidCursor)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		xauth.Set(c, userID.Hex(), "", nil)
+		return c.Next()
+	}, handler.GetActivitys)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{"first page", "", fiber.StatusOK},
+		{"next page", "?limit=10&cursor=next", fiber.StatusOK},
+		{"forged cursor", "?cursor=forged", fiber.StatusBadRequest},
+		{"too many", "?limit=500", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, "/"+tt.query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package Activity
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/stats"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+The feed is read from the activity collection, where every entry carries its
+author in "user": a page is the newest entries of the caller and the friends
+sharing their activity (see privacy.FeedAuthors), with each author, their
+streak and the reaction counts looked up alongside. Pages follow on from the (timestamp, id) of the last entry of the
+one before, so entries written meanwhile don't shift them.
+*/
+
+func (h *Handler) GetFeed(c *fiber.Ctx) error {
+	var query FeedQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := xvalidator.Validator.Validate(query); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+	id, _ := c.Locals("user_id").(string)
+	viewer, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Not Authorized",
+		})
+	}
+
+	page, err := h.service.GetFeed(c.UserContext(), viewer, query)
+	if errors.Is(err, ErrInvalidCursor) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch feed",
+		})
+	}
+	return c.JSON(page)
+}
+
+// GetFeed returns a page of the viewer's feed, newest first
+func (s *Service) GetFeed(ctx context.Context, viewer primitive.ObjectID, query FeedQuery) (*FeedPage, error) {
+	limit := query.Size(xpage.DefaultLimit)
+	authors, err := privacy.FeedAuthors(ctx, s.Users, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	match := bson.M{"user": bson.M{"$in": authors}}
+	if query.Type != "" {
+		match["field2"] = bson.M{"$in": strings.Split(query.Type, ",")}
+	}
+	if query.Cursor != "" {
+		var at time.Time
+		id, err := xpage.Decode(query.Cursor, &at)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(match, xpage.After("timestamp", -1, at, id))
+	}
+
+	cursor, err := s.Activitys.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: xpage.Sort("timestamp", -1)}},
+		// one more than the page, to tell whether another follows
+		{{Key: "$limit", Value: limit + 1}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         s.Users.Name(),
+			"localField":   "user",
+			"foreignField": "_id",
+			"as":           "author",
+			"pipeline": bson.A{
+				bson.M{"$project": bson.M{"display_name": 1, "handle": 1, "profile_picture": 1}},
+				bson.M{"$lookup": bson.M{
+					"from":         stats.Collection,
+					"localField":   "_id",
+					"foreignField": "_id",
+					"as":           "stats",
+					"pipeline":     bson.A{bson.M{"$project": bson.M{"tz": 1, "current_streak": 1, "last_active": 1}}},
+				}},
+				bson.M{"$set": bson.M{"stats": bson.M{"$first": "$stats"}}},
+			},
+		}}},
+		{{Key: "$set", Value: bson.M{"author": bson.M{"$first": "$author"}}}},
+		// the count of each emoji, most used first
+		{{Key: "$lookup", Value: bson.M{
+			"from":         s.Reactions.Name(),
+			"localField":   "_id",
+			"foreignField": "activity",
+			"as":           "reactions",
+			"pipeline": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":     "$emoji",
+					"count":   bson.M{"$sum": 1},
+					"reacted": bson.M{"$max": bson.M{"$eq": bson.A{"$user", viewer}}},
+				}},
+				bson.M{"$project": bson.M{"_id": 0, "emoji": "$_id", "count": 1, "reacted": 1}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "emoji", Value: 1}}},
+			},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]FeedItem, 0, limit+1)
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, item := range items {
+		if item.Author != nil && item.Author.Stats != nil {
+			item.Author.Streak = item.Author.Stats.Streak(now)
+		}
+	}
+
+	return xpage.New(items, limit, func(item FeedItem) (any, primitive.ObjectID) {
+		return item.Timestamp, item.ID
+	}), nil
+}
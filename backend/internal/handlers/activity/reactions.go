@@ -0,0 +1,192 @@
+package Activity
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (h *Handler) AddReaction(c *fiber.Ctx) error {
+	var params ReactionParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	viewer, id, err := reactionTarget(c, params)
+	if err != nil {
+		return err
+	}
+
+	reaction, err := h.service.React(c.UserContext(), viewer, id, params.Emoji)
+	if err != nil {
+		return reactionError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(reaction)
+}
+
+func (h *Handler) RemoveReaction(c *fiber.Ctx) error {
+	var params ReactionParams
+	if err := c.QueryParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	viewer, id, err := reactionTarget(c, params)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Unreact(c.UserContext(), viewer, id, params.Emoji); err != nil {
+		return reactionError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// reactionTarget checks the emoji and returns the caller and the activity in :id
+func reactionTarget(c *fiber.Ctx, params ReactionParams) (primitive.ObjectID, primitive.ObjectID, error) {
+	if errs := xvalidator.Validator.Validate(params); errs != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest,
+			"emoji must be one of "+strings.Join(ReactionSet, " "))
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "Invalid ID format")
+	}
+	userID, _ := c.Locals("user_id").(string)
+	viewer, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fiber.NewError(fiber.StatusUnauthorized, "Not Authorized")
+	}
+	return viewer, id, nil
+}
+
+func reactionError(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrActivityNotFound), errors.Is(err, ErrNotReacted):
+		status = fiber.StatusNotFound
+	case errors.Is(err, ErrAlreadyReacted):
+		status = fiber.StatusConflict
+	default:
+		err = errors.New("Failed to update reactions")
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// visibleItem returns the activity item if it is in the viewer's feed
+func (s *Service) visibleItem(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) (*ActivityEntry, error) {
+	var item ActivityEntry
+	err := s.Activitys.FindOne(ctx, bson.M{"_id": id}).Decode(&item)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrActivityNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	authors, err := privacy.FeedAuthors(ctx, s.Users, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(authors, item.User) {
+		return nil, ErrActivityNotFound
+	}
+	return &item, nil
+}
+
+/*
+React adds the viewer's emoji to an item of their feed. Reacting to someone
+else's item goes in the viewer's own activity and notifies the poster.
+*/
+func (s *Service) React(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID, emoji string) (*Reaction, error) {
+	item, err := s.visibleItem(ctx, viewer, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reaction := Reaction{ID: primitive.NewObjectID(), Activity: id, User: viewer, Emoji: emoji, CreatedAt: time.Now()}
+	if _, err := s.Reactions.InsertOne(ctx, reaction); mongo.IsDuplicateKeyError(err) {
+		return nil, ErrAlreadyReacted
+	} else if err != nil {
+		return nil, err
+	}
+	if item.User != viewer {
+		s.announce(ctx, reaction, item)
+	}
+	return &reaction, nil
+}
+
+// Unreact takes the viewer's emoji off the item
+func (s *Service) Unreact(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID, emoji string) error {
+	result, err := s.Reactions.DeleteOne(ctx, bson.M{"activity": id, "user": viewer, "emoji": emoji})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotReacted
+	}
+	return nil
+}
+
+// announce writes the reaction to the reactor's activity and the poster's notifications, which it stands without
+func (s *Service) announce(ctx context.Context, reaction Reaction, item *ActivityEntry) {
+	cursor, err := s.Users.Find(ctx, softdelete.Filter(bson.M{"_id": bson.M{"$in": bson.A{reaction.User, item.User}}}),
+		options.Find().SetProjection(bson.M{"display_name": 1, "handle": 1}))
+	var users []FeedAuthor
+	if err == nil {
+		err = cursor.All(ctx, &users)
+	}
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to announce reaction", xslog.Error(err))
+		return
+	}
+	names := make(map[primitive.ObjectID]string, len(users))
+	for _, u := range users {
+		names[u.ID] = u.DisplayName
+		if names[u.ID] == "" {
+			names[u.ID] = "@" + u.Handle
+		}
+	}
+
+	_, err = s.Activitys.InsertOne(ctx, bson.M{
+		"_id":       primitive.NewObjectID(),
+		"field1":    names[reaction.User] + " reacted " + reaction.Emoji + " to " + names[item.User] + "'s activity",
+		"field2":    Reacted,
+		"picture":   nil,
+		"timestamp": reaction.CreatedAt,
+		"user":      reaction.User,
+	})
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to write reaction activity", xslog.Error(err))
+	}
+
+	_, err = notifications.Insert(ctx, s.Notifications, notifications.Notification{
+		ID:        primitive.NewObjectID(),
+		User:      item.User,
+		Type:      notifications.ActivityReaction,
+		Key:       string(notifications.ActivityReaction) + ":" + item.ID.Hex() + ":" + reaction.User.Hex() + ":" + reaction.Emoji,
+		Title:     item.Field1,
+		Emoji:     reaction.Emoji,
+		FromUser:  &reaction.User,
+		CreatedAt: reaction.CreatedAt,
+	})
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to write reaction notification", xslog.Error(err))
+	}
+}
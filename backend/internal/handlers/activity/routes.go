@@ -1,6 +1,8 @@
 package Activity
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -8,7 +10,7 @@ import (
 /*
 Router maps endpoints to handlers
 */
-func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
 	service := newService(collections)
 	handler := Handler{service}
 
@@ -18,10 +20,24 @@ func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
 	// Add Sample group under API Version 1
 	Activitys := apiV1.Group("/Activity")
 
-	Activitys.Post("/", handler.CreateActivity)
-	Activitys.Get("/", handler.GetActivitys)
-	Activitys.Get("/:id", handler.GetActivity)
-	Activitys.Patch("/:id", handler.UpdatePartialActivity)
-	Activitys.Delete("/:id", handler.DeleteActivity)
+	Activitys.Post("/", authenticate, handler.CreateActivity)
+	Activitys.Get("/", authenticate, handler.GetActivitys)
+	Activitys.Get("/:id", authenticate, handler.GetActivity)
+	Activitys.Patch("/:id", authenticate, handler.OwnActivity, handler.UpdatePartialActivity)
+	Activitys.Delete("/:id", authenticate, handler.OwnActivity, handler.DeleteActivity)
+	Activitys.Post("/:id/reactions", authenticate, handler.AddReaction)
+	Activitys.Delete("/:id/reactions", authenticate, handler.RemoveReaction)
 
+	apiV1.Get("/feed", authenticate, handler.GetFeed)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/Activity/":                {Summary: "Record an activity item of the caller's", Auth: true, Request: CreateActivityParams{}, Response: ActivityEntry{}, Status: fiber.StatusCreated},
+		"GET /api/v1/Activity/":                 {Summary: "List the activity items the caller's feed shows, a page at a time", Auth: true, Query: xpage.Query{}, Response: xpage.Page[ActivityEntry]{}},
+		"GET /api/v1/Activity/:id":              {Summary: "Get an activity item from the caller's feed", Auth: true, Response: ActivityEntry{}},
+		"PATCH /api/v1/Activity/:id":            {Summary: "Update an activity item", Auth: true, Request: UpdateActivityDocument{}},
+		"DELETE /api/v1/Activity/:id":           {Summary: "Delete an activity item", Auth: true},
+		"POST /api/v1/Activity/:id/reactions":   {Summary: "React to an activity item", Auth: true, Request: ReactionParams{}, Response: Reaction{}, Status: fiber.StatusCreated},
+		"DELETE /api/v1/Activity/:id/reactions": {Summary: "Take back a reaction", Auth: true, Query: ReactionParams{}, Status: fiber.StatusNoContent},
+		"GET /api/v1/feed":                      {Summary: "The caller's feed of their and their friends' activity", Auth: true, Query: FeedQuery{}, Response: FeedPage{}},
+	})
 }
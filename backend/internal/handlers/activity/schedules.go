@@ -0,0 +1,82 @@
+package Activity
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// activity older than this moves out of the feed collection
+	archiveAfter = 90 * 24 * time.Hour
+	archiveBatch = 500
+)
+
+// RegisterSchedules adds the activity maintenance entries to the scheduler
+func RegisterSchedules(s *scheduler.Scheduler, collections map[string]*mongo.Collection) {
+	activity, archive := collections["activity"], collections["activity_archive"]
+	s.Register("activity-archival", "30 3 * * *", time.Hour, func(ctx context.Context) error {
+		_, err := archiveActivity(ctx, activity, archive, time.Now().Add(-archiveAfter))
+		return err
+	})
+}
+
+/*
+archiveActivity moves activity older than cutoff into the archive collection
+in batches, copying before deleting so an interrupted run loses nothing; a
+rerun skips documents already copied.
+*/
+func archiveActivity(ctx context.Context, activity *mongo.Collection, archive *mongo.Collection, cutoff time.Time) (int, error) {
+	moved := 0
+	for {
+		cursor, err := activity.Find(ctx, bson.M{"timestamp": bson.M{"$lt": cutoff}},
+			options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}).SetLimit(archiveBatch))
+		if err != nil {
+			return moved, err
+		}
+		var docs []bson.Raw
+		if err := cursor.All(ctx, &docs); err != nil {
+			return moved, err
+		}
+		if len(docs) == 0 {
+			return moved, nil
+		}
+
+		ids := make([]interface{}, 0, len(docs))
+		batch := make([]interface{}, 0, len(docs))
+		for _, doc := range docs {
+			ids = append(ids, doc.Lookup("_id"))
+			batch = append(batch, doc)
+		}
+		_, err = archive.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
+		if err != nil && !onlyDuplicates(err) {
+			return moved, err
+		}
+		if _, err := activity.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+			return moved, err
+		}
+
+		moved += len(docs)
+		if len(docs) < archiveBatch {
+			return moved, nil
+		}
+	}
+}
+
+func onlyDuplicates(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return false
+		}
+	}
+	return true
+}
@@ -3,77 +3,92 @@ package Activity
 import (
 	"context"
 	"log/slog"
+	"maps"
+	"time"
 
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
 	"github.com/abhikaboy/SocialToDo/xutils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // newService receives the map of collections and picks out Jobs
 func newService(collections map[string]*mongo.Collection) *Service {
 	return &Service{
-		Activitys: collections["activity"],
+		Activitys:     collections["activity"],
+		Users:         collections["users"],
+		Reactions:     collections[ReactionCollection],
+		Notifications: collections[notifications.Collection],
 	}
 }
 
-// GetAllActivitys fetches all Activity documents from MongoDB
-func (s *Service) GetAllActivitys() ([]ActivityDocument, error) {
-	ctx := context.Background()
-	cursor, err := s.Activitys.Find(ctx, bson.M{})
+// GetVisibleActivitys fetches a page of the activity the viewer's feed would show, newest first, past the same block and privacy checks
+func (s *Service) GetVisibleActivitys(ctx context.Context, viewer primitive.ObjectID, query xpage.Query) (*xpage.Page[ActivityEntry], error) {
+	authors, err := privacy.FeedAuthors(ctx, s.Users, viewer)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"user": bson.M{"$in": authors}}
+	if query.Cursor != "" {
+		var at time.Time
+		id, err := xpage.Decode(query.Cursor, &at)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(filter, xpage.After("timestamp", -1, at, id))
+	}
+	size := query.Size(xpage.DefaultLimit)
+	cursor, err := s.Activitys.Find(ctx, filter,
+		options.Find().SetSort(xpage.Sort("timestamp", -1)).SetLimit(int64(size+1)))
 	if err != nil {
 		return nil, err
 	}
 	defer cursor.Close(ctx)
 
-	var results []ActivityDocument
+	results := make([]ActivityEntry, 0)
 	if err := cursor.All(ctx, &results); err != nil {
 		return nil, err
 	}
 
-	return results, nil
+	return xpage.New(results, size, func(entry ActivityEntry) (any, primitive.ObjectID) {
+		return entry.Timestamp, entry.ID
+	}), nil
 }
 
-// GetActivityByID returns a single Activity document by its ObjectID
-func (s *Service) GetActivityByID(id primitive.ObjectID) (*ActivityDocument, error) {
-	ctx := context.Background()
-	filter := bson.M{"_id": id}
-
-	var Activity ActivityDocument
-	err := s.Activitys.FindOne(ctx, filter).Decode(&Activity)
+// GetActivityByID returns a single Activity document by its ObjectID, ErrActivityNotFound unless it is in the viewer's feed
+func (s *Service) GetActivityByID(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) (*ActivityEntry, error) {
+	return s.visibleItem(ctx, viewer, id)
+}
 
-	if err == mongo.ErrNoDocuments {
-		// No matching Activity found
-		return nil, mongo.ErrNoDocuments
-	} else if err != nil {
-		// Different error occurred
-		return nil, err
+// Owns returns mongo.ErrNoDocuments unless the activity item is the user's
+func (s *Service) Owns(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) error {
+	count, err := s.Activitys.CountDocuments(ctx, bson.M{"_id": id, "user": userId}, options.Count().SetLimit(1))
+	if err != nil {
+		return err
 	}
-
-	return &Activity, nil
+	if count == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
 }
 
-// InsertActivity adds a new Activity document
-func (s *Service) CreateActivity(r *ActivityDocument) (*ActivityDocument, error) {
-	ctx := context.Background()
-	// Insert the document into the collection
-
-	result, err := s.Activitys.InsertOne(ctx, r)
-	if err != nil {
+// CreateActivity adds a new Activity document to the user's activity
+func (s *Service) CreateActivity(ctx context.Context, user primitive.ObjectID, r *ActivityDocument) (*ActivityEntry, error) {
+	entry := ActivityEntry{ActivityDocument: *r, User: user}
+	if _, err := s.Activitys.InsertOne(ctx, entry); err != nil {
 		return nil, err
 	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "Activity inserted", slog.String("id", entry.ID.Hex()))
 
-	// Cast the inserted ID to ObjectID
-	id := result.InsertedID.(primitive.ObjectID)
-	r.ID = id
-	slog.LogAttrs(ctx, slog.LevelInfo, "Activity inserted", slog.String("id", id.Hex()))
-
-	return r, nil
+	return &entry, nil
 }
 
 // UpdatePartialActivity updates only specified fields of a Activity document by ObjectID.
-func (s *Service) UpdatePartialActivity(id primitive.ObjectID, updated UpdateActivityDocument) error {
-	ctx := context.Background()
+func (s *Service) UpdatePartialActivity(ctx context.Context, id primitive.ObjectID, updated UpdateActivityDocument) error {
 	filter := bson.M{"_id": id}
 
 	updateFields, err := xutils.ToDoc(updated)
@@ -88,8 +103,7 @@ func (s *Service) UpdatePartialActivity(id primitive.ObjectID, updated UpdateAct
 }
 
 // DeleteActivity removes a Activity document by ObjectID.
-func (s *Service) DeleteActivity(id primitive.ObjectID) error {
-	ctx := context.Background()
+func (s *Service) DeleteActivity(ctx context.Context, id primitive.ObjectID) error {
 
 	filter := bson.M{"_id": id}
 
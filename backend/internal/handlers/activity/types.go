@@ -1,8 +1,11 @@
 package Activity
 
 import (
+	"errors"
 	"time"
 
+	"github.com/abhikaboy/SocialToDo/internal/handlers/stats"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -26,18 +29,87 @@ type ActivityDocument struct {
 	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
 }
 
+// ActivityEntry is an activity document with its author, as the collection stores it
+type ActivityEntry struct {
+	ActivityDocument `bson:",inline"`
+	User             primitive.ObjectID `bson:"user" json:"user_id"`
+}
+
 type UpdateActivityDocument struct {
 	Field1  string      `bson:"field1,omitempty" json:"field1,omitempty"`
 	Field2  Enumeration `bson:"field2,omitempty" json:"field2,omitempty"`
 	Picture *string     `bson:"picture,omitempty" json:"picture,omitempty"`
 }
 
+var (
+	ErrInvalidCursor    = xpage.ErrInvalidCursor
+	ErrActivityNotFound = errors.New("activity not found")
+	ErrAlreadyReacted   = errors.New("already reacted")
+	ErrNotReacted       = errors.New("no reaction to remove")
+)
+
+const ReactionCollection = "reactions"
+
+// the emoji friends can react to an activity item with
+var ReactionSet = []string{"🎉", "👏", "🔥", "💪", "❤️"}
+
+// Reaction is one user's emoji on an activity item; a user can add each emoji once
+type Reaction struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	Activity  primitive.ObjectID `bson:"activity" json:"activity_id"`
+	User      primitive.ObjectID `bson:"user" json:"user_id"`
+	Emoji     string             `bson:"emoji" json:"emoji"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type ReactionParams struct {
+	Emoji string `validate:"required,oneof=🎉 👏 🔥 💪 ❤️" json:"emoji" query:"emoji"`
+}
+
+// ReactionCount is how many reacted to an item with an emoji, and whether the viewer did
+type ReactionCount struct {
+	Emoji   string `bson:"emoji" json:"emoji"`
+	Count   int    `bson:"count" json:"count"`
+	Reacted bool   `bson:"reacted" json:"reacted"`
+}
+
+type FeedQuery struct {
+	xpage.Query
+	// comma separated activity types (field2), all of them when empty
+	Type string `query:"type"`
+}
+
+type FeedAuthor struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	DisplayName    string             `bson:"display_name" json:"display_name"`
+	Handle         string             `bson:"handle" json:"handle"`
+	ProfilePicture string             `bson:"profile_picture" json:"profile_picture"`
+	// the author's current streak, only filled in on the feed
+	Streak int          `bson:"-" json:"streak,omitempty"`
+	Stats  *stats.Stats `bson:"stats,omitempty" json:"-"`
+}
+
+type FeedItem struct {
+	ActivityDocument `bson:",inline"`
+	User             primitive.ObjectID `bson:"user" json:"user_id"`
+	Author           *FeedAuthor        `bson:"author" json:"author"`
+	Reactions        []ReactionCount    `bson:"reactions" json:"reactions"`
+}
+
+type FeedPage = xpage.Page[FeedItem]
+
 type Enumeration string
 
 const (
 	Option1 Enumeration = "Option1"
 	Option2 Enumeration = "Option2"
 	Option3 Enumeration = "Option3"
+	// the user made a friend
+	Friended Enumeration = "Friended"
+	// the user reacted to a friend's activity
+	Reacted Enumeration = "Reacted"
+	// the user completed one of their public tasks
+	Completed Enumeration = "Completed"
 )
 
 /*
@@ -47,4 +119,8 @@ Database layer of the application
 
 type Service struct {
 	Activitys *mongo.Collection
+	// feed authors are looked up here
+	Users         *mongo.Collection
+	Reactions     *mongo.Collection
+	Notifications *mongo.Collection
 }
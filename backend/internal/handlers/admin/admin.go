@@ -0,0 +1,417 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/abhikaboy/SocialToDo/internal/flags"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=admin.go -destination=mock_test.go -package=admin
+
+// Operations is what the admin handlers run against
+type Operations interface {
+	GetJobs(ctx context.Context, query JobsQuery) (*JobsReport, error)
+	RetryJob(ctx context.Context, id primitive.ObjectID) error
+	GetSchedules(ctx context.Context) ([]scheduler.Run, error)
+	SearchUsers(ctx context.Context, query UsersQuery) (*xpage.Page[UserView], error)
+	GetUser(ctx context.Context, id primitive.ObjectID) (*UserView, error)
+	SuspendUser(ctx context.Context, id primitive.ObjectID, reason string, by string) error
+	ReinstateUser(ctx context.Context, id primitive.ObjectID, by string) error
+	LogoutUser(ctx context.Context, id primitive.ObjectID, by string) error
+	SetRoles(ctx context.Context, id primitive.ObjectID, roles []string, by string) (*UserView, error)
+	SetQuotas(ctx context.Context, id primitive.ObjectID, quotas map[string]*int64) (*UserView, error)
+	TakeDownPost(ctx context.Context, id primitive.ObjectID, by string) error
+	TakeDownActivity(ctx context.Context, id primitive.ObjectID, by string) error
+	GetFlags(ctx context.Context) ([]flags.Flag, error)
+	GetFlag(ctx context.Context, name string) (*flags.Flag, error)
+	SetFlag(ctx context.Context, name string, req FlagRequest, by string) (*flags.Flag, error)
+	DeleteFlag(ctx context.Context, name string, by string) error
+	GetStats(ctx context.Context) (*Stats, error)
+}
+
+var _ Operations = (*Service)(nil)
+
+/*
+Handler to execute business logic for the admin endpoints
+*/
+type Handler struct {
+	service Operations
+	// ADMIN_USER_IDS, admins whatever their roles
+	admins []string
+}
+
+// GetJobs returns the background job queue depth, ?status=dead lists the dead-lettered jobs
+func (h *Handler) GetJobs(c *fiber.Ctx) error {
+	var query JobsQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := validator.Validate(query); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	report, err := h.service.GetJobs(c.UserContext(), query)
+	if err != nil {
+		return err
+	}
+	return c.JSON(report)
+}
+
+// RetryJob puts a dead job back on the queue
+func (h *Handler) RetryJob(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid job id",
+		})
+	}
+
+	err = h.service.RetryJob(c.UserContext(), id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No dead job with this id",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetSchedules lists the cron entries with their last run status
+func (h *Handler) GetSchedules(c *fiber.Ctx) error {
+	runs, err := h.service.GetSchedules(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(runs)
+}
+
+// GetUsers searches users by exact email, handle or role, newest first
+func (h *Handler) GetUsers(c *fiber.Ctx) error {
+	var query UsersQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := validator.Validate(query); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	users, err := h.service.SearchUsers(c.UserContext(), query)
+	if errors.Is(err, xpage.ErrInvalidCursor) {
+		return xerr.BadRequest(err)
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(users)
+}
+
+func (h *Handler) GetUser(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+
+	user, err := h.service.GetUser(c.UserContext(), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(user)
+}
+
+// SuspendUser blocks logins and revokes the user's tokens
+func (h *Handler) SuspendUser(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+	var req SuspendRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	// moderators can suspend users, not the admins above them
+	if !h.isAdmin(adminID(c), xauth.Roles(c)) {
+		user, err := h.service.GetUser(c.UserContext(), id)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		if err != nil {
+			return err
+		}
+		if h.isAdmin(id.Hex(), user.Roles) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Only admins can suspend an admin",
+			})
+		}
+	}
+
+	err = h.service.SuspendUser(c.UserContext(), id, req.Reason, adminID(c))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) ReinstateUser(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+
+	err = h.service.ReinstateUser(c.UserContext(), id, adminID(c))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// LogoutUser signs the user out of every device
+func (h *Handler) LogoutUser(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+
+	err = h.service.LogoutUser(c.UserContext(), id, adminID(c))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// SetRoles replaces a user's roles
+func (h *Handler) SetRoles(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+	var req RolesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	user, err := h.service.SetRoles(c.UserContext(), id, req.Roles, adminID(c))
+	switch {
+	case errors.Is(err, ErrOwnAdminRole):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case err != nil:
+		return err
+	}
+	return c.JSON(user)
+}
+
+// SetQuotas overrides a user's quotas, a null limit goes back to the default
+func (h *Handler) SetQuotas(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+	var req QuotasRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	user, err := h.service.SetQuotas(c.UserContext(), id, req.Quotas)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(user)
+}
+
+func (h *Handler) TakeDownPost(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid post id",
+		})
+	}
+
+	err = h.service.TakeDownPost(c.UserContext(), id, adminID(c))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Post not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) TakeDownActivity(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid activity id",
+		})
+	}
+
+	err = h.service.TakeDownActivity(c.UserContext(), id, adminID(c))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Activity not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) GetFlags(c *fiber.Ctx) error {
+	list, err := h.service.GetFlags(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(list)
+}
+
+func (h *Handler) GetFlag(c *fiber.Ctx) error {
+	flag, err := h.service.GetFlag(c.UserContext(), c.Params("name"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Flag not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(flag)
+}
+
+// SetFlag creates or updates a feature flag
+func (h *Handler) SetFlag(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if len(name) > 64 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Flag names are at most 64 characters",
+		})
+	}
+	var req FlagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	flag, err := h.service.SetFlag(c.UserContext(), name, req, adminID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(flag)
+}
+
+func (h *Handler) DeleteFlag(c *fiber.Ctx) error {
+	err := h.service.DeleteFlag(c.UserContext(), c.Params("name"), adminID(c))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Flag not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetStats returns platform wide counts
+func (h *Handler) GetStats(c *fiber.Ctx) error {
+	stats, err := h.service.GetStats(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(stats)
+}
+
+// adminID is the caller, recorded alongside every change made through the admin API
+// isAdmin reports whether the user holds the admin role or is a configured admin
+func (h *Handler) isAdmin(userID string, roles []string) bool {
+	return slices.Contains(h.admins, userID) || slices.Contains(roles, xauth.AdminRole)
+}
+
+func adminID(c *fiber.Ctx) string {
+	id, _ := c.Locals("user_id").(string)
+	return id
+}
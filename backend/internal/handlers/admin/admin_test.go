@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSuspendUser(t *testing.T) {
+	configured, admin, moderator := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	user := primitive.NewObjectID()
+
+	service := NewMockOperations(gomock.NewController(t))
+	service.EXPECT().GetUser(gomock.Any(), admin).Return(&UserView{ID: admin, Roles: []string{xauth.AdminRole}}, nil).AnyTimes()
+	service.EXPECT().GetUser(gomock.Any(), configured).Return(&UserView{ID: configured}, nil).AnyTimes()
+	service.EXPECT().GetUser(gomock.Any(), user).Return(&UserView{ID: user}, nil).AnyTimes()
+	service.EXPECT().SuspendUser(gomock.Any(), user, "spam", moderator.Hex()).Return(nil)
+	service.EXPECT().SuspendUser(gomock.Any(), admin, "spam", configured.Hex()).Return(nil)
+	handler := Handler{service, []string{configured.Hex()}}
+
+	tests := []struct {
+		name     string
+		caller   primitive.ObjectID
+		roles    []string
+		target   primitive.ObjectID
+		expected int
+	}{
+		{"moderator suspends a user", moderator, []string{xauth.ModeratorRole}, user, fiber.StatusNoContent},
+		{"moderator suspends an admin", moderator, []string{xauth.ModeratorRole}, admin, fiber.StatusForbidden},
+		{"moderator suspends a configured admin", moderator, []string{xauth.ModeratorRole}, configured, fiber.StatusForbidden},
+		{"configured admin suspends an admin", configured, nil, admin, fiber.StatusNoContent},
+	}
+	for _, tt := range tests {
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			xauth.Set(c, tt.caller.Hex(), "", tt.roles)
+			return c.Next()
+		})
+		app.Post("/users/:id/suspend", handler.SuspendUser)
+
+		req := httptest.NewRequest(http.MethodPost, "/users/"+tt.target.Hex()+"/suspend", strings.NewReader(`{"reason": "spam"}`))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
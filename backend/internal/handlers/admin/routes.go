@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/flags"
+	"github.com/abhikaboy/SocialToDo/internal/middleware"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler, cfg config.Admin) {
+	service := newService(collections)
+	handler := Handler{service, cfg.UserIDs}
+
+	apiV1 := app.Group("/api/v1")
+
+	// moderators get the routes for dealing with users and their content, admins everything
+	admins := middleware.RequireAdmin(cfg.UserIDs)
+	moderators := middleware.RequireRole(cfg.UserIDs, xauth.AdminRole, xauth.ModeratorRole)
+
+	Admin := apiV1.Group("/admin", authenticate)
+	Admin.Get("/jobs", admins, handler.GetJobs)
+	Admin.Post("/jobs/:id/retry", admins, handler.RetryJob)
+	Admin.Get("/schedules", admins, handler.GetSchedules)
+	Admin.Get("/stats", admins, handler.GetStats)
+
+	Admin.Get("/users", moderators, handler.GetUsers)
+	Admin.Get("/users/:id", moderators, handler.GetUser)
+	Admin.Post("/users/:id/suspend", moderators, handler.SuspendUser)
+	Admin.Post("/users/:id/reinstate", moderators, handler.ReinstateUser)
+	Admin.Post("/users/:id/logout", admins, handler.LogoutUser)
+	Admin.Put("/users/:id/roles", admins, handler.SetRoles)
+	Admin.Put("/users/:id/quotas", admins, handler.SetQuotas)
+
+	Admin.Delete("/posts/:id", moderators, handler.TakeDownPost)
+	Admin.Delete("/activity/:id", moderators, handler.TakeDownActivity)
+
+	Admin.Get("/flags", admins, handler.GetFlags)
+	Admin.Get("/flags/:name", admins, handler.GetFlag)
+	Admin.Put("/flags/:name", admins, handler.SetFlag)
+	Admin.Delete("/flags/:name", admins, handler.DeleteFlag)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/admin/jobs":                 {Summary: "Background jobs by status", Auth: true, Query: JobsQuery{}, Response: JobsReport{}},
+		"POST /api/v1/admin/jobs/:id/retry":      {Summary: "Queue a dead job again", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/admin/schedules":            {Summary: "Recent runs of the scheduled tasks", Auth: true, Response: []scheduler.Run{}},
+		"GET /api/v1/admin/stats":                {Summary: "Counts across the service", Auth: true, Response: Stats{}},
+		"GET /api/v1/admin/users":                {Summary: "Find users, newest first", Auth: true, Query: UsersQuery{}, Response: xpage.Page[UserView]{}},
+		"GET /api/v1/admin/users/:id":            {Summary: "Get a user", Auth: true, Response: UserView{}},
+		"POST /api/v1/admin/users/:id/suspend":   {Summary: "Suspend a user", Auth: true, Request: SuspendRequest{}, Status: fiber.StatusNoContent},
+		"POST /api/v1/admin/users/:id/reinstate": {Summary: "Lift a suspension", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/admin/users/:id/logout":    {Summary: "Sign a user out of every device", Auth: true, Status: fiber.StatusNoContent},
+		"PUT /api/v1/admin/users/:id/roles":      {Summary: "Replace a user's roles", Auth: true, Request: RolesRequest{}, Response: UserView{}},
+		"PUT /api/v1/admin/users/:id/quotas":     {Summary: "Override a user's quotas", Auth: true, Request: QuotasRequest{}, Response: UserView{}},
+		"DELETE /api/v1/admin/posts/:id":         {Summary: "Take down a post", Auth: true, Status: fiber.StatusNoContent},
+		"DELETE /api/v1/admin/activity/:id":      {Summary: "Take down an activity item", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/admin/flags":                {Summary: "List feature flags", Auth: true, Response: []flags.Flag{}},
+		"GET /api/v1/admin/flags/:name":          {Summary: "Get a feature flag", Auth: true, Response: flags.Flag{}},
+		"PUT /api/v1/admin/flags/:name":          {Summary: "Create or change a feature flag", Auth: true, Request: FlagRequest{}, Response: flags.Flag{}},
+		"DELETE /api/v1/admin/flags/:name":       {Summary: "Delete a feature flag", Auth: true, Status: fiber.StatusNoContent},
+	})
+}
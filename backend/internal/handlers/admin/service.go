@@ -0,0 +1,355 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/flags"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newService receives the map of collections and picks out Jobs, Schedules, Users, Posts, Activity and Flags
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{
+		queue:     jobs.New(collections[jobs.Collection]),
+		schedules: collections[scheduler.Collection],
+		users:     collections["users"],
+		sessions:  collections[auth.SessionCollection],
+		posts:     collections["posts"],
+		activity:  collections["activity"],
+		flags:     flags.New(collections[flags.Collection]),
+	}
+}
+
+// keeps credentials and the embedded categories out of admin responses
+var userViewProjection = bson.M{
+	"email":            1,
+	"phone":            1,
+	"handle":           1,
+	"display_name":     1,
+	"roles":            1,
+	"suspended_at":     1,
+	"suspended_reason": 1,
+	"quotas":           1,
+	"tasks_complete":   1,
+	"deleted_at":       1,
+}
+
+// GetJobs reports the queue depth per kind and status, plus a page of jobs when a status is given
+func (s *Service) GetJobs(ctx context.Context, query JobsQuery) (*JobsReport, error) {
+	depths, err := s.queue.Depths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &JobsReport{Depths: depths}
+	if query.Status == "" {
+		return report, nil
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = 20
+	}
+	report.Jobs, err = s.queue.List(ctx, jobs.Status(query.Status), limit)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (s *Service) RetryJob(ctx context.Context, id primitive.ObjectID) error {
+	return s.queue.Retry(ctx, id)
+}
+
+// GetSchedules returns the last run status and next run of every scheduled entry
+func (s *Service) GetSchedules(ctx context.Context) ([]scheduler.Run, error) {
+	return scheduler.List(ctx, s.schedules)
+}
+
+// SearchUsers finds users by exact email, handle or role, newest first
+func (s *Service) SearchUsers(ctx context.Context, query UsersQuery) (*xpage.Page[UserView], error) {
+	filter := bson.M{}
+	if query.Email != "" {
+		filter["email"] = query.Email
+	}
+	if query.Handle != "" {
+		filter["handle"] = query.Handle
+	}
+	if query.Role != "" {
+		filter["roles"] = query.Role
+	}
+	if query.Suspended != nil {
+		filter["suspended_at"] = bson.M{"$exists": *query.Suspended}
+	}
+	if query.Cursor != "" {
+		after, err := xpage.Decode(query.Cursor, nil)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(filter, xpage.After("_id", -1, nil, after))
+	}
+	size := query.Size(xpage.DefaultLimit)
+
+	cursor, err := s.users.Find(ctx, filter, options.Find().
+		SetProjection(userViewProjection).
+		SetSort(xpage.Sort("_id", -1)).
+		SetLimit(int64(size+1)))
+	if err != nil {
+		return nil, err
+	}
+	users := make([]UserView, 0)
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].CreatedAt = users[i].ID.Timestamp()
+	}
+	return xpage.New(users, size, func(user UserView) (any, primitive.ObjectID) {
+		return nil, user.ID
+	}), nil
+}
+
+func (s *Service) GetUser(ctx context.Context, id primitive.ObjectID) (*UserView, error) {
+	var user UserView
+	err := s.users.FindOne(ctx, bson.M{"_id": id}, options.FindOne().SetProjection(userViewProjection)).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	user.CreatedAt = user.ID.Timestamp()
+	return &user, nil
+}
+
+/*
+SuspendUser blocks the user from logging in and bumps their token count, which
+revokes every access and refresh token they hold.
+*/
+func (s *Service) SuspendUser(ctx context.Context, id primitive.ObjectID, reason string, by string) error {
+	result, err := s.users.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{"suspended_at": time.Now(), "suspended_reason": reason},
+		"$inc": bson.M{"count": 1},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	slog.LogAttrs(ctx, slog.LevelWarn, "User suspended",
+		slog.String("user_id", id.Hex()), slog.String("admin_id", by), slog.String("reason", reason))
+	return nil
+}
+
+func (s *Service) ReinstateUser(ctx context.Context, id primitive.ObjectID, by string) error {
+	result, err := s.users.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$unset": bson.M{"suspended_at": "", "suspended_reason": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	slog.LogAttrs(ctx, slog.LevelWarn, "User reinstated",
+		slog.String("user_id", id.Hex()), slog.String("admin_id", by))
+	return nil
+}
+
+// LogoutUser signs the user out everywhere: their sessions end and the bumped token count revokes every token they hold
+func (s *Service) LogoutUser(ctx context.Context, id primitive.ObjectID, by string) error {
+	result, err := s.users.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"count": 1}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	if _, err := s.sessions.DeleteMany(ctx, bson.M{"user": id}); err != nil {
+		return err
+	}
+	slog.LogAttrs(ctx, slog.LevelWarn, "User logged out by an admin",
+		slog.String("user_id", id.Hex()), slog.String("admin_id", by))
+	return nil
+}
+
+/*
+SetRoles replaces the user's roles, which apply from their next request since
+the auth middleware reads them from the user on every token check. Admins
+can't take the admin role away from themselves, so there is always one left.
+*/
+func (s *Service) SetRoles(ctx context.Context, id primitive.ObjectID, roles []string, by string) (*UserView, error) {
+	if id.Hex() == by && !slices.Contains(roles, xauth.AdminRole) {
+		return nil, ErrOwnAdminRole
+	}
+	slices.Sort(roles)
+	roles = slices.Compact(roles)
+	update := bson.M{"$set": bson.M{"roles": roles}}
+	if len(roles) == 0 {
+		update = bson.M{"$unset": bson.M{"roles": ""}}
+	}
+
+	var user UserView
+	err := s.users.FindOneAndUpdate(ctx, bson.M{"_id": id}, update,
+		options.FindOneAndUpdate().
+			SetProjection(userViewProjection).
+			SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	user.CreatedAt = user.ID.Timestamp()
+	slog.LogAttrs(ctx, slog.LevelWarn, "User roles changed",
+		slog.String("user_id", id.Hex()), slog.String("admin_id", by), slog.Any("roles", roles))
+	return &user, nil
+}
+
+// SetQuotas applies quota overrides for a user and returns the result
+func (s *Service) SetQuotas(ctx context.Context, id primitive.ObjectID, quotas map[string]*int64) (*UserView, error) {
+	set, unset := bson.M{}, bson.M{}
+	for name, limit := range quotas {
+		if limit == nil {
+			unset["quotas."+name] = ""
+		} else {
+			set["quotas."+name] = *limit
+		}
+	}
+	update := bson.M{}
+	if len(set) > 0 {
+		update["$set"] = set
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	var user UserView
+	err := s.users.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, options.FindOneAndUpdate().
+		SetProjection(userViewProjection).
+		SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	user.CreatedAt = user.ID.Timestamp()
+	return &user, nil
+}
+
+// TakeDownPost removes a post that breaks the content rules
+func (s *Service) TakeDownPost(ctx context.Context, id primitive.ObjectID, by string) error {
+	result, err := s.posts.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	slog.LogAttrs(ctx, slog.LevelWarn, "Post taken down",
+		slog.String("post_id", id.Hex()), slog.String("admin_id", by))
+	return nil
+}
+
+// TakeDownActivity removes an activity item, including the copy embedded in its owner's recent activity
+func (s *Service) TakeDownActivity(ctx context.Context, id primitive.ObjectID, by string) error {
+	result, err := s.activity.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	embedded, err := s.users.UpdateMany(ctx, bson.M{"recent_activity._id": id},
+		bson.M{"$pull": bson.M{"recent_activity": bson.M{"_id": id}}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 && embedded.ModifiedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	slog.LogAttrs(ctx, slog.LevelWarn, "Activity taken down",
+		slog.String("activity_id", id.Hex()), slog.String("admin_id", by))
+	return nil
+}
+
+func (s *Service) GetFlags(ctx context.Context) ([]flags.Flag, error) {
+	return s.flags.List(ctx)
+}
+
+func (s *Service) GetFlag(ctx context.Context, name string) (*flags.Flag, error) {
+	return s.flags.Get(ctx, name)
+}
+
+func (s *Service) SetFlag(ctx context.Context, name string, req FlagRequest, by string) (*flags.Flag, error) {
+	flag := flags.Flag{
+		Name:        name,
+		Enabled:     *req.Enabled,
+		Percentage:  req.Percentage,
+		Users:       req.Users,
+		Description: req.Description,
+		UpdatedBy:   by,
+	}
+	if err := s.flags.Set(ctx, flag); err != nil {
+		return nil, err
+	}
+	attrs := []slog.Attr{slog.String("flag", name), slog.Bool("enabled", flag.Enabled), slog.Int("users", len(flag.Users)), slog.String("admin_id", by)}
+	if flag.Percentage != nil {
+		attrs = append(attrs, slog.Int("percentage", *flag.Percentage))
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "Feature flag set", attrs...)
+	return &flag, nil
+}
+
+func (s *Service) DeleteFlag(ctx context.Context, name string, by string) error {
+	if err := s.flags.Delete(ctx, name); err != nil {
+		return err
+	}
+	slog.LogAttrs(ctx, slog.LevelInfo, "Feature flag deleted", slog.String("flag", name), slog.String("admin_id", by))
+	return nil
+}
+
+// GetStats aggregates platform wide counts for the admin dashboard
+func (s *Service) GetStats(ctx context.Context) (*Stats, error) {
+	now := time.Now()
+	stats := &Stats{GeneratedAt: now}
+
+	var err error
+	if stats.Users, err = s.users.EstimatedDocumentCount(ctx); err != nil {
+		return nil, err
+	}
+	// ids carry their creation time, so recent signups need no extra field
+	weekAgo := primitive.NewObjectIDFromTimestamp(now.AddDate(0, 0, -7))
+	if stats.NewUsers, err = s.users.CountDocuments(ctx, bson.M{"_id": bson.M{"$gte": weekAgo}}); err != nil {
+		return nil, err
+	}
+	if stats.SuspendedUsers, err = s.users.CountDocuments(ctx, bson.M{"suspended_at": bson.M{"$exists": true}}); err != nil {
+		return nil, err
+	}
+	if stats.Posts, err = s.posts.EstimatedDocumentCount(ctx); err != nil {
+		return nil, err
+	}
+	if stats.Activity, err = s.activity.EstimatedDocumentCount(ctx); err != nil {
+		return nil, err
+	}
+
+	cursor, err := s.users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": nil, "tasks_completed": bson.M{"$sum": "$tasks_complete"}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var totals []struct {
+		TasksCompleted float64 `bson:"tasks_completed"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, err
+	}
+	if len(totals) > 0 {
+		stats.TasksCompleted = totals[0].TasksCompleted
+	}
+	return stats, nil
+}
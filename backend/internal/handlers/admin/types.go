@@ -0,0 +1,95 @@
+package admin
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/flags"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrOwnAdminRole = errors.New("you can't take away your own admin role")
+
+/*
+Admin Service to be used by Admin Handler to interact with the
+Database layer of the application
+*/
+type Service struct {
+	queue     *jobs.Queue
+	schedules *mongo.Collection
+	users     *mongo.Collection
+	sessions  *mongo.Collection
+	posts     *mongo.Collection
+	activity  *mongo.Collection
+	flags     *flags.Store
+}
+
+type JobsQuery struct {
+	// list the jobs with this status alongside the depths, usually dead
+	Status string `query:"status" validate:"omitempty,oneof=queued running done dead"`
+	Limit  int64  `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+type JobsReport struct {
+	Depths []jobs.Depth `json:"depths"`
+	Jobs   []jobs.Job   `json:"jobs,omitempty"`
+}
+
+type UsersQuery struct {
+	Email     string `query:"email" validate:"omitempty,email"`
+	Handle    string `query:"handle"`
+	Role      string `query:"role"`
+	Suspended *bool  `query:"suspended"`
+	xpage.Query
+}
+
+// UserView is what the admin API shows of a user, credentials and embedded content left out
+type UserView struct {
+	ID              primitive.ObjectID `bson:"_id" json:"id"`
+	Email           string             `bson:"email" json:"email"`
+	Phone           string             `bson:"phone" json:"phone,omitempty"`
+	Handle          string             `bson:"handle" json:"handle"`
+	DisplayName     string             `bson:"display_name" json:"display_name"`
+	Roles           []string           `bson:"roles" json:"roles,omitempty"`
+	SuspendedAt     *time.Time         `bson:"suspended_at" json:"suspended_at,omitempty"`
+	SuspendedReason string             `bson:"suspended_reason" json:"suspended_reason,omitempty"`
+	Quotas          map[string]int64   `bson:"quotas" json:"quotas,omitempty"`
+	TasksComplete   float64            `bson:"tasks_complete" json:"tasks_complete"`
+	DeletedAt       *time.Time         `bson:"deleted_at" json:"deleted_at,omitempty"`
+	CreatedAt       time.Time          `bson:"-" json:"created_at"`
+}
+
+type SuspendRequest struct {
+	Reason string `validate:"required,max=500" json:"reason"`
+}
+
+// RolesRequest replaces the user's roles; an empty list takes them all away
+type RolesRequest struct {
+	Roles []string `validate:"max=8,dive,oneof=admin moderator" json:"roles"`
+}
+
+// QuotasRequest sets each named quota override, null removes it. Names become field paths, so no dots or $
+type QuotasRequest struct {
+	Quotas map[string]*int64 `validate:"required,min=1,dive,keys,min=1,max=64,excludesall=.$,endkeys,omitnil,min=0" json:"quotas"`
+}
+
+// FlagRequest replaces a flag; leaving out percentage and users makes it a plain on/off switch
+type FlagRequest struct {
+	Enabled     *bool    `validate:"required" json:"enabled"`
+	Percentage  *int     `validate:"omitnil,min=0,max=100" json:"percentage"`
+	Users       []string `validate:"max=1000,dive,mongodb" json:"users"`
+	Description string   `validate:"max=500" json:"description"`
+}
+
+type Stats struct {
+	Users          int64     `json:"users"`
+	NewUsers       int64     `json:"new_users_7d"`
+	SuspendedUsers int64     `json:"suspended_users"`
+	TasksCompleted float64   `json:"tasks_completed"`
+	Posts          int64     `json:"posts"`
+	Activity       int64     `json:"activity"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
@@ -0,0 +1,41 @@
+package analytics
+
+import (
+	"context"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=analytics.go -destination=mock_test.go -package=analytics
+
+// Recorder takes the client events Handler accepts
+type Recorder interface {
+	Record(ctx context.Context, userID string, req EventsRequest) int
+}
+
+var _ Recorder = (*Service)(nil)
+
+/*
+Handler to execute business logic for client analytics
+*/
+type Handler struct {
+	service Recorder
+}
+
+// RecordEvents accepts a batch of client events; they are written asynchronously
+func (h *Handler) RecordEvents(c *fiber.Ctx) error {
+	var req EventsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	accepted := h.service.Record(c.UserContext(), c.Locals("user_id").(string), req)
+	return c.Status(fiber.StatusAccepted).JSON(EventsResponse{Accepted: accepted})
+}
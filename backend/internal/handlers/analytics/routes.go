@@ -0,0 +1,24 @@
+package analytics
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/xanalytics"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, buffer *xanalytics.Buffer, authenticate fiber.Handler, cfg config.Analytics) {
+	service := newService(buffer, cfg)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	apiV1.Post("/events", authenticate, handler.RecordEvents)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/events": {Summary: "Record a batch of client analytics events", Auth: true, Request: EventsRequest{}, Response: EventsResponse{}, Status: fiber.StatusAccepted},
+	})
+}
@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/xanalytics"
+)
+
+// client clocks are trusted within this window, outside it the receive time is used
+const clockSkew = 24 * time.Hour
+
+func newService(buffer *xanalytics.Buffer, cfg config.Analytics) *Service {
+	return &Service{buffer, cfg}
+}
+
+/*
+Record samples, scrubs and buffers a batch, returning how many events were
+accepted. Events from users outside the sample are acknowledged but dropped,
+so clients don't need to know the sample rate.
+*/
+func (s *Service) Record(ctx context.Context, userID string, req EventsRequest) int {
+	if !xanalytics.Sampled(userID, s.cfg.SampleRate) {
+		return len(req.Events)
+	}
+
+	now := time.Now()
+	events := make([]xanalytics.Event, 0, len(req.Events))
+	for _, e := range req.Events {
+		occurred := e.Timestamp
+		if occurred.Before(now.Add(-clockSkew)) || occurred.After(now.Add(clockSkew)) {
+			occurred = now
+		}
+		events = append(events, xanalytics.Event{
+			Type:       e.Type,
+			Name:       e.Name,
+			UserID:     userID,
+			SessionID:  e.SessionID,
+			Platform:   req.Platform,
+			AppVersion: req.AppVersion,
+			Properties: xanalytics.Scrub(e.Properties),
+			OccurredAt: occurred,
+			ReceivedAt: now,
+		})
+	}
+	return s.buffer.Add(ctx, events)
+}
@@ -0,0 +1,36 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/xanalytics"
+)
+
+/*
+Analytics Service to be used by Analytics Handler to pass client
+events on to the analytics buffer
+*/
+type Service struct {
+	buffer *xanalytics.Buffer
+	cfg    config.Analytics
+}
+
+// EventsRequest is one batch from a client; Platform and AppVersion apply to every event in it
+type EventsRequest struct {
+	Platform   string        `validate:"omitempty,oneof=ios android web" json:"platform"`
+	AppVersion string        `validate:"max=32" json:"app_version"`
+	Events     []ClientEvent `validate:"required,min=1,max=100,dive" json:"events"`
+}
+
+type ClientEvent struct {
+	Type       xanalytics.Type `validate:"required,oneof=screen_view feature_usage" json:"type"`
+	Name       string          `validate:"required,max=100" json:"name"`
+	SessionID  string          `validate:"max=64" json:"session_id"`
+	Properties map[string]any  `validate:"max=50" json:"properties"`
+	Timestamp  time.Time       `validate:"required" json:"timestamp"`
+}
+
+type EventsResponse struct {
+	Accepted int `json:"accepted"`
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// deleted accounts can be restored until the purge removes them
+	restoreTTL     = softdelete.Retention
+	restorePurpose = "restore_account"
+)
+
+var ErrInvalidRestore = errors.New("invalid or expired restore link")
+
+/*
+DeleteAccount deletes the user and mails them a link that restores the
+account until restoreTTL has passed. It returns when that is.
+*/
+func (s *Service) DeleteAccount(ctx context.Context, id string, device Device) (time.Time, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// the link names the deletion, so it can't undo a later one; mongo keeps milliseconds
+	at := time.Now().Truncate(time.Millisecond)
+	if err := s.repo.DeleteAccount(ctx, id, at); err != nil {
+		return time.Time{}, err
+	}
+	until := at.Add(restoreTTL)
+	s.record(ctx, user.ID, audit.AccountDeleted, "", device)
+
+	if user.Email != "" && s.queue != nil {
+		// the account is gone either way, the email only offers a way back
+		if err := s.sendRestoreLink(ctx, id, user.Email, at, until); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to send restore link", slog.String("user_id", id), xslog.Error(err))
+		}
+	}
+	return until, nil
+}
+
+func (s *Service) sendRestoreLink(ctx context.Context, id string, email string, at time.Time, until time.Time) error {
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose":    restorePurpose,
+		"user_id":    id,
+		"deleted_at": at.UnixMilli(),
+		"exp":        until.Unix(),
+	})
+	token, err := t.SignedString(s.purposeKey(restorePurpose))
+	if err != nil {
+		return err
+	}
+	link := strings.TrimSuffix(s.config.App.WebURL, "/") + "/restore-account?token=" + url.QueryEscape(token)
+	_, err = s.queue.Enqueue(ctx, AccountDeletedEmailJob, LinkEmail{Email: email, Link: link})
+	return err
+}
+
+// RestoreAccount brings back the account deleted by the DeleteAccount that mailed token
+func (s *Service) RestoreAccount(ctx context.Context, token string) error {
+	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		return s.purposeKey(restorePurpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return ErrInvalidRestore
+	}
+	claims, _ := t.Claims.(jwt.MapClaims)
+	purpose, _ := claims["purpose"].(string)
+	userID, _ := claims["user_id"].(string)
+	deletedAt, _ := claims["deleted_at"].(float64)
+	if purpose != restorePurpose || userID == "" || deletedAt == 0 {
+		return ErrInvalidRestore
+	}
+
+	err = s.repo.RestoreAccount(ctx, userID, time.UnixMilli(int64(deletedAt)))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvalidRestore
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/abhikaboy/SocialToDo/internal/xjwks"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Apple's signing keys, shared by every Service
+var appleKeys = xjwks.New("https://appleid.apple.com/auth/keys")
+
+const appleIssuer = "https://appleid.apple.com"
+
+var (
+	ErrAppleDisabled     = fiber.NewError(fiber.StatusNotFound, "Sign in with Apple is not configured")
+	ErrInvalidAppleToken = fiber.NewError(fiber.StatusUnauthorized, "Not Authorized, Invalid Apple Token")
+)
+
+type appleClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+	// a string in older tokens, a boolean in newer ones
+	EmailVerified any `json:"email_verified"`
+}
+
+func (c appleClaims) verified() bool {
+	return c.EmailVerified == true || c.EmailVerified == "true"
+}
+
+// verifyApple checks the identity token's signature, issuer, audience and expiry
+func (s *Service) verifyApple(identityToken string) (*appleClaims, error) {
+	if len(s.config.Apple.ClientIDs) == 0 {
+		return nil, ErrAppleDisabled
+	}
+	var claims appleClaims
+	_, err := jwt.ParseWithClaims(identityToken, &claims, appleKeys.Keyfunc,
+		jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer(appleIssuer), jwt.WithExpirationRequired())
+	if err != nil || claims.Subject == "" {
+		return nil, ErrInvalidAppleToken
+	}
+	if !audienceIn(claims.Audience, s.config.Apple.ClientIDs) {
+		return nil, ErrInvalidAppleToken
+	}
+	return &claims, nil
+}
+
+// LoginFromApple signs in with a Sign in with Apple identity token, see federatedLogin
+func (s *Service) LoginFromApple(ctx context.Context, identityToken string) (_ primitive.ObjectID, _ float64, created bool, err error) {
+	defer xmetrics.Track("auth", "LoginFromApple")(&err)
+
+	claims, err := s.verifyApple(identityToken)
+	if err != nil {
+		return primitive.NewObjectID(), 0, false, err
+	}
+	return s.federatedLogin(ctx, federated{
+		subject:  claims.Subject,
+		email:    claims.Email,
+		verified: claims.verified(),
+		find:     s.repo.FindByAppleID,
+		link:     s.repo.LinkApple,
+		set:      func(user *User) { user.AppleID = claims.Subject },
+	})
+}
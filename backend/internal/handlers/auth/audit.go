@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// record adds to the user's audit log; the action it records stands whether or not that works
+func (s *Service) record(ctx context.Context, user primitive.ObjectID, typ audit.Type, method audit.Method, device Device) {
+	event := audit.New(user, typ, device.client())
+	event.Method = method
+	if err := s.repo.RecordEvent(ctx, event); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to record audit event",
+			slog.String("user_id", user.Hex()), slog.String("type", string(typ)), xslog.Error(err))
+	}
+}
+
+// recordOf is record for the user id in token claims
+func (s *Service) recordOf(ctx context.Context, userID string, typ audit.Type, device Device) {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return
+	}
+	s.record(ctx, user, typ, "", device)
+}
+
+// SecurityEvents pages through the user's audit log, newest first
+func (s *Service) SecurityEvents(ctx context.Context, userID string, query xpage.Query) (*xpage.Page[audit.Event], error) {
+	var after primitive.ObjectID
+	if query.Cursor != "" {
+		var err error
+		if after, err = xpage.Decode(query.Cursor, nil); err != nil {
+			return nil, err
+		}
+	}
+	size := query.Size(xpage.DefaultLimit)
+	events, err := s.repo.ListEvents(ctx, userID, after, size+1)
+	if err != nil {
+		return nil, err
+	}
+	return xpage.New(events, size, func(event audit.Event) (any, primitive.ObjectID) {
+		return nil, event.ID
+	}), nil
+}
@@ -1,15 +1,21 @@
 package auth
 
 import (
+	"errors"
 	"log/slog"
 	"strings"
 
-	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
-	categories "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"github.com/abhikaboy/SocialToDo/internal/handles"
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
 	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
 	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 /*
@@ -25,7 +31,7 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 	var req LoginRequest
 	err := c.BodyParser(&req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(xerr.InvalidJSON())
+		return xerr.InvalidJSON()
 	}
 
 	errs := xvalidator.Validator.Validate(req)
@@ -34,25 +40,24 @@ func (h *Handler) Login(c *fiber.Ctx) error {
 	}
 
 	// database call to find the user and verify credentials and get count
-	id, count, err := h.service.LoginFromCredentials(req.Email, req.Password)
+	id, count, err := h.service.LoginFromCredentials(c.UserContext(), req.Email, req.Password, device(c))
 	if err != nil {
 		return err
 	}
 
-	access, refresh, err := h.service.GenerateTokens(id.Hex(), count)
-	c.Response().Header.Add("access_token", access)
-	c.Response().Header.Add("refresh_token", refresh)
-	return err
+	resp, err := h.startSession(c, id.Hex(), count, audit.Password)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
 }
 
 func (h *Handler) Register(c *fiber.Ctx) error {
 	var req RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(xerr.InvalidJSON())
+		return xerr.InvalidJSON()
 	}
 
-	slog.Info("Register Request", "request", req)
-
 	errs := xvalidator.Validator.Validate(&req)
 	if len(errs) > 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(errs)
@@ -60,52 +65,57 @@ func (h *Handler) Register(c *fiber.Ctx) error {
 
 	id := primitive.NewObjectID()
 
-	access, refresh, err := h.service.GenerateTokens(id.Hex(), 0) // new users use count = 0
-
+	password, err := h.service.HashPassword(req.Password)
 	if err != nil {
 		return err
 	}
 
-	c.Response().Header.Add("access_token", access)
-	c.Response().Header.Add("refresh_token", refresh)
-
-	user := User{
-		Email:        req.Email,
-		Password:     req.Password,
-		ID:           id,
-		RefreshToken: refresh,
-		TokenUsed:    false,
-		Count:        0,
-
-		Categories: make([]categories.CategoryDocument, 0),
-		Friends:    make([]primitive.ObjectID, 0),
-		TasksComplete: 0,
-		RecentActivity: make([]activity.ActivityDocument, 0),
-
-		DisplayName: "Default Username",
-		Handle:      "@default",
-		ProfilePicture: "https://i.pinimg.com/736x/bd/46/35/bd463547b9ae986ba4d44d717828eb09.jpg",
-
+	user := newAccount(id, req.Email)
+	user.Password = password
+	if req.Handle != "" {
+		user.Handle, err = handles.Normalize(req.Handle)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+	if name := strings.TrimSpace(req.DisplayName); name != "" {
+		user.DisplayName = name
 	}
+	verified := false
+	user.EmailVerified = &verified
 
 	if err = user.Validate(); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(xerr.BadRequest(err))
+		return xerr.BadRequest(err)
 	}
 
-	err = h.service.CreateUser(user)
+	err = h.service.CreateUser(c.UserContext(), user)
+	if handleTaken(err) {
+		return ErrHandleTaken
+	}
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(xerr.BadRequest(err))
+		return xerr.BadRequest(err)
 	}
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "User Created Successfully",
-	})
+	// the account works without it, they can ask for another link
+	if err := h.service.SendVerification(c.UserContext(), id, user.Email); err != nil {
+		slog.LogAttrs(c.UserContext(), slog.LevelError, "Failed to send verification email", slog.String("user_id", id.Hex()), xslog.Error(err))
+	}
+
+	// new users use count = 0
+	resp, err := h.startSession(c, id.Hex(), 0, audit.Password)
+	if err != nil {
+		return err
+	}
+	resp.Message = "User Created Successfully"
+	return c.Status(fiber.StatusOK).JSON(resp)
 }
 
 func (h *Handler) LoginWithApple(c *fiber.Ctx) error {
 	var req LoginRequestApple
 	err := c.BodyParser(&req)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(xerr.InvalidJSON())
+		return xerr.InvalidJSON()
 	}
 
 	errs := xvalidator.Validator.Validate(req)
@@ -113,16 +123,202 @@ func (h *Handler) LoginWithApple(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(errs)
 	}
 
-	// database call to find the user and verify credentials and get count
-	id, count, err := h.service.LoginFromApple(req.AppleID)
+	// verifies the identity token, then finds or makes the account
+	id, count, _, err := h.service.LoginFromApple(c.UserContext(), req.IdentityToken)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.startSession(c, id.Hex(), count, audit.Apple)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+func (h *Handler) LoginWithGoogle(c *fiber.Ctx) error {
+	var req LoginRequestGoogle
+	if err := c.BodyParser(&req); err != nil {
+		return xerr.InvalidJSON()
+	}
+	if errs := xvalidator.Validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	id, count, _, err := h.service.LoginFromGoogle(c.UserContext(), req.IDToken)
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.startSession(c, id.Hex(), count, audit.Google)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
+}
+
+// RegisterWithGoogle is LoginWithGoogle answering 201 when it made the account, so clients can start onboarding
+func (h *Handler) RegisterWithGoogle(c *fiber.Ctx) error {
+	var req RegisterRequestGoogle
+	if err := c.BodyParser(&req); err != nil {
+		return xerr.InvalidJSON()
+	}
+	if errs := xvalidator.Validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	id, count, created, err := h.service.LoginFromGoogle(c.UserContext(), req.IDToken)
 	if err != nil {
 		return err
 	}
+	return h.registered(c, id.Hex(), count, audit.Google, created)
+}
+
+// RegisterWithApple is LoginWithApple answering 201 when it made the account
+func (h *Handler) RegisterWithApple(c *fiber.Ctx) error {
+	var req RegisterRequestApple
+	if err := c.BodyParser(&req); err != nil {
+		return xerr.InvalidJSON()
+	}
+	if errs := xvalidator.Validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
 
-	access, refresh, err := h.service.GenerateTokens(id.Hex(), count)
-	c.Response().Header.Add("access_token", access)
-	c.Response().Header.Add("refresh_token", refresh)
-	return err
+	id, count, created, err := h.service.LoginFromApple(c.UserContext(), req.IdentityToken)
+	if err != nil {
+		return err
+	}
+	return h.registered(c, id.Hex(), count, audit.Apple, created)
+}
+
+// RequestPhoneCode texts a sign-in code to the number
+func (h *Handler) RequestPhoneCode(c *fiber.Ctx) error {
+	var req PhoneCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return xerr.InvalidJSON()
+	}
+	if errs := xvalidator.Validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	if err := h.service.RequestPhoneCode(c.UserContext(), req.Phone); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// VerifyPhoneCode signs in with a texted code, answering 201 when the number was new and got an account
+func (h *Handler) VerifyPhoneCode(c *fiber.Ctx) error {
+	var req PhoneVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return xerr.InvalidJSON()
+	}
+	if errs := xvalidator.Validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	id, count, created, err := h.service.LoginFromPhone(c.UserContext(), req.Phone, req.Code, device(c))
+	if err != nil {
+		return err
+	}
+	return h.registered(c, id.Hex(), count, audit.Phone, created)
+}
+
+// LinkPhone lets the caller sign in with a number they got a code on
+func (h *Handler) LinkPhone(c *fiber.Ctx) error {
+	var req PhoneVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return xerr.InvalidJSON()
+	}
+	if errs := xvalidator.Validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	userID, _ := c.Locals("user_id").(string)
+	if err := h.service.LinkPhone(c.UserContext(), userID, req.Phone, req.Code, device(c)); err != nil {
+		return err
+	}
+	profile, err := h.service.Profile(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(profile)
+}
+
+// registered answers a sign-up through an identity provider, which may have found an existing account
+func (h *Handler) registered(c *fiber.Ctx, id string, count float64, method audit.Method, created bool) error {
+	resp, err := h.startSession(c, id, count, method)
+	if err != nil {
+		return err
+	}
+	if !created {
+		resp.Message = "Signed In To Existing Account"
+		return c.Status(fiber.StatusOK).JSON(resp)
+	}
+	resp.Message = "User Created Successfully"
+	return c.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// VerifyEmail is where the emailed link lands; it sends the browser on to the web app with the outcome
+func (h *Handler) VerifyEmail(c *fiber.Ctx) error {
+	status := "verified"
+	err := h.service.VerifyEmail(c.UserContext(), c.Query("token"))
+	if errors.Is(err, ErrInvalidVerification) {
+		status = "invalid"
+	} else if err != nil {
+		return err
+	}
+	return c.Redirect(strings.TrimSuffix(h.config.App.WebURL, "/")+"/verify-email?status="+status, fiber.StatusSeeOther)
+}
+
+func (h *Handler) ResendVerification(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	err := h.service.ResendVerification(c.UserContext(), userID)
+	if errors.Is(err, ErrAlreadyVerified) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Email already verified"})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+/*
+	Delete the signed-in user's account. Every device is signed out, and the
+	account can be restored from the emailed link until it is purged.
+*/
+
+func (h *Handler) DeleteAccount(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	until, err := h.service.DeleteAccount(c.UserContext(), userID, device(c))
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":       "Account deleted",
+		"restore_until": until,
+	})
+}
+
+func (h *Handler) RestoreAccount(c *fiber.Ctx) error {
+	var req RestoreAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return xerr.InvalidJSON()
+	}
+	if errs := xvalidator.Validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	err := h.service.RestoreAccount(c.UserContext(), req.Token)
+	if errors.Is(err, ErrInvalidRestore) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid or expired restore link"})
+	}
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Account restored, sign in to continue",
+	})
 }
 
 func (h *Handler) Test(c *fiber.Ctx) error {
@@ -134,18 +330,18 @@ func (h *Handler) AuthenticateMiddleware(c *fiber.Ctx) error {
 	refreshToken := c.Get("refresh_token")
 
 	if len(header) == 0 {
-		return fiber.NewError(400, "Not Authorized, Tokens not passed")
+		return ErrNoTokens
 	}
 
 	split := strings.Split(header, " ")
 
 	if len(split) != 2 {
-		return fiber.NewError(400, "Not Authorized, Invalid Token Format")
+		return ErrTokenFormat
 	}
 	tokenType, accessToken := split[0], split[1]
 
 	if tokenType != "Bearer" {
-		return fiber.NewError(400, "Not Authorized, Invalid Token Type")
+		return ErrTokenFormat
 	}
 
 	access, refresh, err := h.ValidateAndGenerateTokens(c, accessToken, refreshToken)
@@ -153,89 +349,184 @@ func (h *Handler) AuthenticateMiddleware(c *fiber.Ctx) error {
 		return err
 	}
 
-	c.Response().Header.Add("access_token", access)
-	c.Response().Header.Add("refresh_token", refresh)
+	// only set when the access token had expired and the session was refreshed
+	if access != "" {
+		c.Response().Header.Add("access_token", access)
+		c.Response().Header.Add("refresh_token", refresh)
+	}
 
 	return c.Next()
 }
 
-func (h *Handler) ValidateRefreshToken(c *fiber.Ctx, refreshToken string) (float64, error) {
-	// Okay, so the access token is invalid now we check if the refresh token is valid
-	user_id, count, err := h.service.ValidateToken(refreshToken)
+/*
+	Given an access and refresh token, check if they are valid. The access token
+	is enough on its own; once it expires, the refresh token is traded for a new
+	pair, which is returned.
+*/
+
+func (h *Handler) ValidateAndGenerateTokens(c *fiber.Ctx, accessToken string, refreshToken string) (string, string, error) {
+	claims, err := h.service.Authenticate(c.UserContext(), accessToken)
+	var access, refresh string
 	if err != nil {
-		return 0, fiber.NewError(400, "Not Authorized: Access and Refresh Tokens are Expired "+err.Error())
+		claims, access, refresh, err = h.service.Refresh(c.UserContext(), refreshToken, device(c))
+		xmetrics.TokenRefreshes.WithLabelValues(xmetrics.Outcome(err)).Inc()
+		if err != nil {
+			return "", "", refreshError(err)
+		}
 	}
-	// Check if the refresh token is unused
-	used, err := h.service.CheckIfTokenUsed(user_id)
+	// downstream handlers and the request logger read the caller from here
+	xauth.Set(c, claims.UserID, claims.Session, claims.Roles)
+	return access, refresh, nil
+}
+
+// RefreshTokens trades the refresh_token header for a new pair without calling anything else
+func (h *Handler) RefreshTokens(c *fiber.Ctx) error {
+	refreshToken := c.Get("refresh_token")
+	if refreshToken == "" {
+		return ErrNoTokens
+	}
+	claims, access, refresh, err := h.service.Refresh(c.UserContext(), refreshToken, device(c))
+	xmetrics.TokenRefreshes.WithLabelValues(xmetrics.Outcome(err)).Inc()
 	if err != nil {
-		return 0, fiber.NewError(400, "Not Authorized, Error Validating Token Reusage "+err.Error())
-	} else if used {
-		return 0, fiber.NewError(400, "Not Authorized, Token Reuse Detected")
+		return refreshError(err)
+	}
+	if access == "" {
+		// another request refreshed this session a moment ago and got the new pair
+		return c.SendStatus(fiber.StatusNoContent)
 	}
-	return count, nil
+	resp, err := h.tokenResponse(c, claims.UserID, access, refresh)
+	if err != nil {
+		return err
+	}
+	return c.JSON(resp)
 }
 
 /*
-	Given an access and refresh token, check if they are valid
-	and return a new pair of tokens if refresh token is valid.
+JWKS publishes the public keys tokens are signed with, for other services to
+verify them without calling back. Verifiers refetch on a kid they haven't
+seen, so caching for a while doesn't hold up a rotation.
 */
+func (h *Handler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.JSON(h.service.JWKS())
+}
 
-func (h *Handler) ValidateAndGenerateTokens(c *fiber.Ctx, accessToken string, refreshToken string) (string, string, error) {
-	/*
-		Check our tokens are valid by first checking if the access token is valid
-		and then checking if the refresh token is valid if the access token is invalid
-	*/
-	user_id, count, err := h.service.ValidateToken(accessToken)
+func (h *Handler) ListSessions(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	current, _ := c.Locals("session_id").(string)
+	sessions, err := h.service.ListSessions(c.UserContext(), userID, current)
 	if err != nil {
-		count, err = h.ValidateRefreshToken(c, refreshToken)
-		if err != nil {
-			return "", "", err
-		}
+		return err
 	}
-	// use the same count as the existing token
-	// Our refresh token is valid and unused, so we can use it to generate a new set of tokens
-	access, refresh, err := h.service.GenerateTokens(user_id, count)
-	if err != nil {
-		return "", "", fiber.NewError(400, "Not Authorized, Error Generating Tokens")
+	return c.JSON(sessions)
+}
+
+// ListSecurityEvents pages through the caller's audit log, so they can see who has been signing in to their account
+func (h *Handler) ListSecurityEvents(c *fiber.Ctx) error {
+	var query xpage.Query
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := xvalidator.Validator.Validate(query); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
 	}
 
-	if err := h.service.UseToken(user_id); err != nil {
-		return "", "", fiber.NewError(400, "Not Authorized, Error Updating Token Usage")
+	userID, _ := c.Locals("user_id").(string)
+	events, err := h.service.SecurityEvents(c.UserContext(), userID, query)
+	if errors.Is(err, xpage.ErrInvalidCursor) {
+		return xerr.BadRequest(err)
+	}
+	if err != nil {
+		return err
 	}
+	return c.JSON(events)
+}
 
-	return access, refresh, nil
+func (h *Handler) RevokeSession(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	err := h.service.RevokeSession(c.UserContext(), userID, c.Params("id"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Session not found"})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
 }
 
 /*
-	Given an access token, invalidate the access token and refresh token.
-	Invalidate the token by increasing the "count" field by one.
+	Given an access token, end the session it belongs to. Tokens from before
+	sessions are invalidated by increasing the "count" field by one, which
+	signs out every device.
 */
 
 func (h *Handler) Logout(c *fiber.Ctx) error {
 	header := c.Get("Authorization")
 
 	if len(header) == 0 {
-		return fiber.NewError(400, "Not Authorized, Tokens not passed")
+		return ErrNoTokens
 	}
 
 	split := strings.Split(header, " ")
 
 	if len(split) != 2 {
-		return fiber.NewError(400, "Not Authorized, Invalid Token Format")
+		return ErrTokenFormat
 	}
 	tokenType, accessToken := split[0], split[1]
 
 	if tokenType != "Bearer" {
-		return fiber.NewError(400, "Not Authorized, Invalid Token Type")
+		return ErrTokenFormat
 	}
-	// increase the count by one
-	user_id, _, err := h.service.ValidateToken(accessToken)
+	claims, err := h.service.Authenticate(c.UserContext(), accessToken)
 	if err != nil {
 		return err
 	}
-	err = h.service.InvalidateTokens(user_id)
+	if claims.Session != "" {
+		err = h.service.RevokeSession(c.UserContext(), claims.UserID, claims.Session)
+	} else {
+		err = h.service.InvalidateTokens(c.UserContext(), claims.UserID)
+	}
 	if err != nil {
 		return err
 	}
 	return c.SendString("Logout Successful")
 }
+
+// startSession signs the requesting device in and hands it its tokens
+func (h *Handler) startSession(c *fiber.Ctx, id string, count float64, method audit.Method) (*TokenResponse, error) {
+	access, refresh, err := h.service.StartSession(c.UserContext(), id, count, method, device(c))
+	if err != nil {
+		return nil, err
+	}
+	return h.tokenResponse(c, id, access, refresh)
+}
+
+// tokenResponse puts the tokens in the body, and in the headers too unless turned off
+func (h *Handler) tokenResponse(c *fiber.Ctx, id string, access string, refresh string) (*TokenResponse, error) {
+	if h.config.Auth.TokenHeaders {
+		c.Response().Header.Add("access_token", access)
+		c.Response().Header.Add("refresh_token", refresh)
+	}
+	profile, err := h.service.Profile(c.UserContext(), id)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{AccessToken: access, RefreshToken: refresh, User: profile}, nil
+}
+
+func device(c *fiber.Ctx) Device {
+	client := audit.ClientOf(c)
+	return Device{ID: c.Get(DeviceIDHeader), UserAgent: client.UserAgent, IP: client.IP}
+}
+
+// refreshError keeps the reasons clients act on and reports anything else as an expired session
+func refreshError(err error) error {
+	var apiErr *xerr.Error
+	var fiberErr *fiber.Error
+	if errors.As(err, &apiErr) || errors.As(err, &fiberErr) {
+		return err
+	}
+	return ErrExpired
+}
@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	testEmail    = "sam@example.com"
+	testPassword = "correct horse battery staple"
+)
+
+func TestLogin(t *testing.T) {
+	app := newTestApp(t, nil)
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{"valid", `{"email": "` + testEmail + `", "password": "` + testPassword + `"}`, fiber.StatusOK},
+		{"wrong password", `{"email": "` + testEmail + `", "password": "hunter22"}`, fiber.StatusUnauthorized},
+		{"unknown email", `{"email": "nobody@example.com", "password": "` + testPassword + `"}`, fiber.StatusNotFound},
+		{"missing password", `{"email": "` + testEmail + `"}`, fiber.StatusBadRequest},
+		{"invalid json", `{"email": `, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if res := do(t, app, "/login", tt.body, nil); res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+		}
+	}
+
+	tokens := login(t, app)
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" || tokens.User == nil || tokens.User.Email != testEmail {
+		t.Errorf("valid: unexpected body %+v", tokens)
+	}
+}
+
+func TestRefreshRotation(t *testing.T) {
+	app := newTestApp(t, nil)
+	first := login(t, app)
+
+	second := refresh(t, app, first.RefreshToken, fiber.StatusOK)
+	if second.RefreshToken == "" || second.RefreshToken == first.RefreshToken || second.AccessToken == first.AccessToken {
+		t.Fatalf("refresh: expected a new pair, got %+v", second)
+	}
+	// a request racing the refresh above still holds the old token
+	refresh(t, app, first.RefreshToken, fiber.StatusNoContent)
+
+	third := refresh(t, app, second.RefreshToken, fiber.StatusOK)
+	if third.RefreshToken == second.RefreshToken {
+		t.Fatal("second refresh: expected a new refresh token")
+	}
+	if res := do(t, app, "/logout", "", map[string]string{fiber.HeaderAuthorization: "Bearer " + third.AccessToken}); res.StatusCode != fiber.StatusOK {
+		t.Fatalf("logout: expected 200, got %d", res.StatusCode)
+	}
+	expectCode(t, do(t, app, "/refresh", "", map[string]string{"refresh_token": third.RefreshToken}), xerr.CodeSessionRevoked)
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	app := newTestApp(t, nil)
+	first := login(t, app)
+	second := refresh(t, app, first.RefreshToken, fiber.StatusOK)
+	third := refresh(t, app, second.RefreshToken, fiber.StatusOK)
+
+	// two rotations old, so neither the session's token nor the one in its grace period
+	expectCode(t, do(t, app, "/refresh", "", map[string]string{"refresh_token": first.RefreshToken}), xerr.CodeTokenReuse)
+	// the reuse ended the session for everyone holding its tokens
+	expectCode(t, do(t, app, "/refresh", "", map[string]string{"refresh_token": third.RefreshToken}), xerr.CodeSessionRevoked)
+}
+
+func TestTokenTypes(t *testing.T) {
+	app := newTestApp(t, nil)
+	tokens := login(t, app)
+
+	if res := do(t, app, "/protected", "", map[string]string{fiber.HeaderAuthorization: "Bearer " + tokens.AccessToken}); res.StatusCode != fiber.StatusOK {
+		t.Fatalf("access token: expected 200, got %d", res.StatusCode)
+	}
+	// a refresh token isn't an access token, and without the refresh_token header there's nothing to refresh with
+	expectCode(t, do(t, app, "/protected", "", map[string]string{fiber.HeaderAuthorization: "Bearer " + tokens.RefreshToken}), xerr.CodeAuthExpired)
+	// nor the other way around, and trying doesn't count as reuse
+	expectCode(t, do(t, app, "/refresh", "", map[string]string{"refresh_token": tokens.AccessToken}), xerr.CodeAuthInvalid)
+	refresh(t, app, tokens.RefreshToken, fiber.StatusOK)
+}
+
+func TestRotatedRefreshTokenAsAccessToken(t *testing.T) {
+	app := newTestApp(t, nil)
+	first := login(t, app)
+	refresh(t, app, first.RefreshToken, fiber.StatusOK)
+
+	expectCode(t, do(t, app, "/protected", "", map[string]string{fiber.HeaderAuthorization: "Bearer " + first.RefreshToken}), xerr.CodeAuthExpired)
+}
+
+func TestSecurityEvents(t *testing.T) {
+	app := newTestApp(t, nil)
+	if res := do(t, app, "/login", `{"email": "`+testEmail+`", "password": "hunter22"}`, map[string]string{fiber.HeaderUserAgent: "curl/8.0"}); res.StatusCode != fiber.StatusUnauthorized {
+		t.Fatalf("wrong password: expected 401, got %d", res.StatusCode)
+	}
+	first := login(t, app)
+	second := refresh(t, app, first.RefreshToken, fiber.StatusOK)
+	refresh(t, app, second.RefreshToken, fiber.StatusOK)
+	expectCode(t, do(t, app, "/refresh", "", map[string]string{"refresh_token": first.RefreshToken}), xerr.CodeTokenReuse)
+
+	tokens := login(t, app)
+	list := func(query string) xpage.Page[audit.Event] {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/security-events"+query, nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tokens.AccessToken)
+		res, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != fiber.StatusOK {
+			t.Fatalf("list %q: expected 200, got %d", query, res.StatusCode)
+		}
+		return decode[xpage.Page[audit.Event]](t, res)
+	}
+
+	expected := []audit.Type{audit.Login, audit.TokenReuse, audit.TokenRefreshed, audit.TokenRefreshed, audit.Login, audit.LoginFailed}
+	var got []audit.Event
+	for page, query := list("?limit=4"), ""; ; page = list(query) {
+		got = append(got, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		query = "?limit=4&cursor=" + page.NextCursor
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), got)
+	}
+	for i, event := range got {
+		if event.Type != expected[i] {
+			t.Errorf("event %d: expected %s, got %s", i, expected[i], event.Type)
+		}
+	}
+	if failed := got[len(got)-1]; failed.Method != audit.Password || failed.UserAgent != "curl/8.0" || failed.IP == "" {
+		t.Errorf("failed login: expected the method and client, got %+v", failed)
+	}
+}
+
+// failingProfile stands in for a service whose profile read fails after the session is made
+type failingProfile struct {
+	*Service
+}
+
+func (failingProfile) Profile(ctx context.Context, id string) (*Profile, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestLoginProfileFailure(t *testing.T) {
+	app := newTestApp(t, func(s *Service) Accounts { return failingProfile{s} })
+	res := do(t, app, "/login", `{"email": "`+testEmail+`", "password": "`+testPassword+`"}`, nil)
+	if res.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", res.StatusCode)
+	}
+}
+
+// newTestApp mounts the auth routes on a service over a memory repository holding one user
+func newTestApp(t *testing.T, wrap func(*Service) Accounts) *fiber.App {
+	t.Helper()
+	cfg := config.Config{Auth: config.Auth{Secret: "test-secret", PasswordCost: 4}}
+	repo := NewMemoryRepository()
+	hash, err := HashPassword(testPassword, cfg.Auth.PasswordCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := newAccount(primitive.NewObjectID(), testEmail)
+	user.Password = hash
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	var service Accounts = NewServiceWithRepository(repo, cfg)
+	if wrap != nil {
+		service = wrap(service.(*Service))
+	}
+	handler := Handler{service, cfg}
+	app := fiber.New(fiber.Config{ErrorHandler: xerr.ErrorHandler})
+	app.Post("/login", handler.Login)
+	app.Post("/refresh", handler.RefreshTokens)
+	app.Post("/logout", handler.Logout)
+	app.Post("/protected", handler.AuthenticateMiddleware, handler.Test)
+	app.Get("/security-events", handler.AuthenticateMiddleware, handler.ListSecurityEvents)
+	return app
+}
+
+func login(t *testing.T, app *fiber.App) TokenResponse {
+	t.Helper()
+	res := do(t, app, "/login", `{"email": "`+testEmail+`", "password": "`+testPassword+`"}`, nil)
+	if res.StatusCode != fiber.StatusOK {
+		t.Fatalf("login: expected 200, got %d", res.StatusCode)
+	}
+	return decode[TokenResponse](t, res)
+}
+
+func refresh(t *testing.T, app *fiber.App, token string, expected int) TokenResponse {
+	t.Helper()
+	res := do(t, app, "/refresh", "", map[string]string{"refresh_token": token})
+	if res.StatusCode != expected {
+		t.Fatalf("refresh: expected %d, got %d", expected, res.StatusCode)
+	}
+	if expected != fiber.StatusOK {
+		return TokenResponse{}
+	}
+	return decode[TokenResponse](t, res)
+}
+
+func expectCode(t *testing.T, res *http.Response, code xerr.Code) {
+	t.Helper()
+	if res.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", res.StatusCode)
+	}
+	if body := decode[xerr.Envelope](t, res); body.Code != code {
+		t.Errorf("expected %s, got %s", code, body.Code)
+	}
+}
+
+func decode[T any](t *testing.T, res *http.Response) T {
+	t.Helper()
+	var v T
+	body, _ := io.ReadAll(res.Body)
+	if err := gojson.Unmarshal(body, &v); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return v
+}
+
+func do(t *testing.T, app *fiber.App, route string, body string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, route, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrUnverifiedEmail = fiber.NewError(fiber.StatusBadRequest, "Account has no verified email")
+	/*
+		linking would let whoever registered the email with a password, without
+		proving it, into the provider user's account. Following the verification
+		link wouldn't help, the password would stay theirs; resetting the password
+		proves the email and replaces it.
+	*/
+	ErrLinkUnverified = xerr.New(fiber.StatusConflict, xerr.CodeConflict, "An account with this email exists but its email isn't verified; reset its password, then sign in again")
+)
+
+// federated is a user verified by an identity provider, and how accounts are linked to the provider
+type federated struct {
+	subject  string
+	email    string
+	verified bool
+	find     func(ctx context.Context, subject string) (*User, error)
+	link     func(ctx context.Context, id string, subject string) error
+	// marks a new account as the provider's user
+	set func(user *User)
+}
+
+/*
+federatedLogin signs a provider's user in: into the account linked to them,
+else the account with the same email, which gets linked if its owner proved
+the email too, else a new account. created reports the last case.
+*/
+func (s *Service) federatedLogin(ctx context.Context, f federated) (_ primitive.ObjectID, _ float64, created bool, err error) {
+	user, err := f.find(ctx, f.subject)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if !f.verified || f.email == "" {
+			return primitive.NewObjectID(), 0, false, ErrUnverifiedEmail
+		}
+		user, err = s.repo.FindByEmail(ctx, f.email)
+		if err == nil && user.EmailVerified != nil && !*user.EmailVerified {
+			return primitive.NewObjectID(), 0, false, ErrLinkUnverified
+		}
+		if err == nil && user.SuspendedAt == nil {
+			err = f.link(ctx, user.ID.Hex(), f.subject)
+		}
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			account := newAccount(primitive.NewObjectID(), f.email)
+			// the provider vouched for the email
+			verified := true
+			account.EmailVerified = &verified
+			f.set(&account)
+			if err := s.CreateUser(ctx, account); err != nil {
+				return primitive.NewObjectID(), 0, false, err
+			}
+			return account.ID, account.Count, true, nil
+		}
+	}
+	if err != nil {
+		return primitive.NewObjectID(), 0, false, err
+	}
+	if user.SuspendedAt != nil {
+		return primitive.NewObjectID(), 0, false, ErrSuspended
+	}
+	return user.ID, user.Count, false, nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFederatedLogin(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository()
+	service := NewServiceWithRepository(repo, config.Config{Auth: config.Auth{Secret: "test-secret", PasswordCost: 4}})
+
+	verified, unverified := true, false
+	// from before verification, counts as verified
+	legacy := newAccount(primitive.NewObjectID(), "legacy@example.com")
+	confirmed := newAccount(primitive.NewObjectID(), "confirmed@example.com")
+	confirmed.EmailVerified = &verified
+	// anyone could have registered it with a password
+	squatted := newAccount(primitive.NewObjectID(), "squatted@example.com")
+	squatted.Password = "$2a$04$attackerchosenpasswordhash"
+	squatted.EmailVerified = &unverified
+	for _, user := range []User{legacy, confirmed, squatted} {
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	google := func(subject string, email string) federated {
+		return federated{
+			subject:  subject,
+			email:    email,
+			verified: true,
+			find:     repo.FindByGoogleID,
+			link:     repo.LinkGoogle,
+			set:      func(user *User) { user.GoogleID = subject },
+		}
+	}
+
+	tests := []struct {
+		name     string
+		login    federated
+		expected primitive.ObjectID
+		created  bool
+		err      error
+	}{
+		{"links a verified account", google("g-confirmed", confirmed.Email), confirmed.ID, false, nil},
+		{"links an account from before verification", google("g-legacy", legacy.Email), legacy.ID, false, nil},
+		{"refuses an unverified account", google("g-squatted", squatted.Email), primitive.NilObjectID, false, ErrLinkUnverified},
+		{"creates an account", google("g-new", "new@example.com"), primitive.NilObjectID, true, nil},
+		{"signs in the linked account", google("g-confirmed", "changed@example.com"), confirmed.ID, false, nil},
+	}
+	for _, tt := range tests {
+		id, _, created, err := service.federatedLogin(ctx, tt.login)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+			continue
+		}
+		if created != tt.created || (!tt.expected.IsZero() && id != tt.expected) {
+			t.Errorf("%s: got id %s created %v", tt.name, id.Hex(), created)
+		}
+	}
+
+	user, err := repo.FindByEmail(ctx, squatted.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.GoogleID != "" || *user.EmailVerified {
+		t.Errorf("the unverified account was linked or promoted: %+v", user)
+	}
+}
@@ -0,0 +1,38 @@
+package forgot_pass
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/xmail"
+)
+
+const PasswordResetEmailJob = "email.password_reset"
+
+type PasswordResetEmail struct {
+	Email string `bson:"email"`
+	Code  string `bson:"code"`
+}
+
+// RegisterJobs adds the password reset job handlers to the worker
+func RegisterJobs(worker *jobs.Worker, mailer xmail.Sender) {
+	worker.Handle(PasswordResetEmailJob, func(ctx context.Context, job *jobs.Job) error {
+		var payload PasswordResetEmail
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+
+		err := mailer.Send(ctx, xmail.Message{
+			To:      payload.Email,
+			Subject: "Your SocialToDo password reset code",
+			Text: fmt.Sprintf("Your password reset code is %s. It expires in %d minutes.\n\n"+
+				"If you didn't ask to reset your password, you can ignore this email.", payload.Code, int(codeTTL.Minutes())),
+		})
+		if errors.Is(err, xmail.ErrRejected) {
+			return jobs.Permanent(err)
+		}
+		return err
+	})
+}
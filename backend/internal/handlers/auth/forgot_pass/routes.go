@@ -1,6 +1,7 @@
 package forgot_pass
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -8,14 +9,18 @@ import (
 /*
 Router maps endpoints to handlers
 */
-func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
-	service := newService(collections)
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, passwordCost int) {
+	service := newService(collections, passwordCost)
 	handler := Handler{service}
 
 	apiV1 := app.Group("/api/v1")
-	userV1 := apiV1.Group("/user")
+	authV1 := apiV1.Group("/auth")
 
-	userV1.Post("/forgot-password", handler.ForgotPassword)
-	userV1.Get("/verify-otp", handler.VerifyOTP)
-	userV1.Post("/change-password", handler.ChangePassword)
+	authV1.Post("/forgot-password", handler.ForgotPassword)
+	authV1.Post("/reset-password", handler.ResetPassword)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/auth/forgot-password": {Summary: "Email a code to reset the password", Request: ForgotPasswordRequest{}, Response: fiber.Map{}},
+		"POST /api/v1/auth/reset-password":  {Summary: "Set a new password with the emailed code", Request: ResetPasswordRequest{}, Response: fiber.Map{}},
+	})
 }
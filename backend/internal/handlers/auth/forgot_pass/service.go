@@ -2,184 +2,160 @@ package forgot_pass
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
 	"fmt"
-
-	"os"
+	"strings"
 	"time"
 
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
 	"github.com/abhikaboy/SocialToDo/xutils"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-
-	"github.com/sendgrid/sendgrid-go"
-	"github.com/sendgrid/sendgrid-go/helpers/mail"
 )
 
-/*
-Password Reset Service to be used by Password Reset Handler to interact with the
-Database layer of the application
-*/
-type Service struct {
-	pwResets *mongo.Collection
-	users    *mongo.Collection
-}
+const (
+	Collection = "password_resets"
 
-// newService picks out the collections from the map.
-func newService(collections map[string]*mongo.Collection) *Service {
+	codeLength = 6
+	codeTTL    = 15 * time.Minute
+	// guesses allowed per code before a new one has to be asked for
+	maxAttempts = 5
+	// asking again sooner than this doesn't mail another code
+	resendInterval = time.Minute
+)
 
-	indexModel := mongo.IndexModel{
-		Keys:    bson.M{"expiresAt": 1},
-		Options: options.Index().SetExpireAfterSeconds(0),
-	}
-	_, err := collections["passwordResets"].Indexes().CreateOne(context.Background(), indexModel)
-	if err != nil {
-		panic(err)
-	}
+var ErrInvalidCode = errors.New("invalid or expired code")
 
+// newService picks out the collections from the map.
+// the expires_at TTL index is declared in xmongo.Indexes
+func newService(collections map[string]*mongo.Collection, passwordCost int) *Service {
 	return &Service{
-		pwResets: collections["passwordResets"],
-		users:    collections["users"],
+		resets:       collections[Collection],
+		users:        collections["users"],
+		sessions:     collections[auth.SessionCollection],
+		audit:        collections[audit.Collection],
+		queue:        jobs.New(collections[jobs.Collection]),
+		passwordCost: passwordCost,
 	}
 }
 
-func (s *Service) CreateOTP(email string, expiryInMinutes int8) error {
-
-	ctx := context.Background()
-
-	// first we check if the provided email is associated with an account
-	// if it is, proceed, else do nothing; we do not want to inform a potential
-	// attacker that the email is not associated with an account
-
-	err := s.users.FindOne(ctx, bson.M{"email": email}).Err()
-
+/*
+ForgotPassword mails a reset code to the account with the email, replacing
+any code sent before. It succeeds whether or not there is such an account,
+so the endpoint can't be used to find out who has one.
+*/
+func (s *Service) ForgotPassword(ctx context.Context, email string) error {
+	var user struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"email": email}),
+		options.FindOne().SetProjection(bson.M{"_id": 1}),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
 	if err != nil {
-		// if no documents found, do nothing
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil
-		}
-		return fmt.Errorf("failed to check if email is associated with an account: %w", err)
+		return fmt.Errorf("failed to look up the account: %w", err)
 	}
 
-	// now we generate an OTP and upsert it into the pw-resets collection
-	// TODO: these are not unique, need an optimized method to generate unique OTPs
-	otp, err := xutils.GenerateOTP(6)
-
+	now := time.Now()
+	recent, err := s.resets.CountDocuments(ctx, bson.M{"_id": user.ID, "created_at": bson.M{"$gt": now.Add(-resendInterval)}})
 	if err != nil {
 		return err
 	}
-
-	// apply a filter and upsert the document
-	// in broader terms, if a user is already in the process of resetting their password
-	// (i.e. a document already exists for their email in passwordResets), just update the existing document.
-	// if not, create it
-	filter := bson.M{"email": email}
-
-	update := bson.M{
-		"$set": bson.M{
-			"verified":  false,
-			"otp":       otp,
-			"expiresAt": primitive.NewDateTimeFromTime(time.Now().Add(time.Minute * time.Duration(expiryInMinutes))),
-		},
-		"$setOnInsert": bson.M{
-			"email": email,
-		},
+	if recent > 0 {
+		return nil
 	}
 
-	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
-	err = s.pwResets.FindOneAndUpdate(ctx, filter, update, opts).Decode(&PasswordResetDocument{})
-
-	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
-		return fmt.Errorf("failed to upsert password reset document: %w", err)
+	code, err := xutils.GenerateOTP(codeLength)
+	if err != nil {
+		return err
 	}
-
-	// interact with SendGrid to send the email with the OTP
-	m := mail.NewV3Mail()
-	e := mail.NewEmail("PlateMate Team", "platemate@benpetrillo.dev")
-
-	m.SetFrom(e)
-	m.SetTemplateID("d-ab63353a33c94662a16c01d496072306")
-
-	p := mail.NewPersonalization()
-
-	tos := []*mail.Email{
-		// TODO: replace with associated account name
-		mail.NewEmail("Example User", email),
+	reset := PasswordReset{
+		User:      user.ID,
+		Hash:      hashCode(user.ID, code),
+		CreatedAt: now,
+		ExpiresAt: now.Add(codeTTL),
 	}
-
-	p.AddTos(tos...)
-	p.SetDynamicTemplateData("otp", otp)
-
-	m.AddPersonalizations(p)
-
-	req := sendgrid.GetRequest(os.Getenv("SENDGRID_API_KEY"), "/v3/mail/send", "https://api.sendgrid.com")
-	req.Method = "POST"
-	var Body = mail.GetRequestBody(m)
-	req.Body = Body
-	_, err = sendgrid.API(req)
+	_, err = s.resets.ReplaceOne(ctx, bson.M{"_id": user.ID}, reset, options.Replace().SetUpsert(true))
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return fmt.Errorf("failed to store the reset code: %w", err)
 	}
 
-	fmt.Printf("Upserted document and email sent.")
+	// the email is sent by a background job so a provider hiccup is retried
+	// instead of failing the request
+	_, err = s.queue.Enqueue(ctx, PasswordResetEmailJob, PasswordResetEmail{Email: email, Code: code})
+	if err != nil {
+		return fmt.Errorf("failed to queue password reset email: %w", err)
+	}
 	return nil
-
 }
 
-// VerifyOTP updates the 'verified' flag in the pw-resets collection.
-func (s *Service) VerifyOTP(otp string) error {
-	ctx := context.Background()
-
-	filter := bson.M{"otp": otp}
-	update := bson.M{"$set": bson.M{"verified": true}}
-
-	result, err := s.pwResets.UpdateOne(ctx, filter, update)
+/*
+ResetPassword sets a new password if code is the one last mailed to email,
+which also verifies the email. Every device is signed out, since whoever had
+the old password may be signed in on one of them, and the change goes in the
+audit log as coming from client.
+*/
+func (s *Service) ResetPassword(ctx context.Context, email string, code string, password string, client audit.Client) error {
+	var user struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"email": email}),
+		options.FindOne().SetProjection(bson.M{"_id": 1}),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvalidCode
+	}
 	if err != nil {
 		return err
 	}
-	if result.MatchedCount == 0 {
-		// No documents matched => invalid or expired OTP
-		return ErrUnauthorized
-	}
-	return nil
-}
 
-// ChangePassword checks the pw-resets collection for a verified OTP doc by email,
-// updates the user's password, and removes that pw-reset doc.
-func (s *Service) ChangePassword(email, newPass string) error {
-	ctx := context.Background()
-
-	filter := bson.M{"email": email}
-	var resetDoc PasswordResetDocument
-
-	err := s.pwResets.FindOne(ctx, filter).Decode(&resetDoc)
+	// counting the attempt before comparing keeps concurrent guesses within maxAttempts
+	var reset PasswordReset
+	err = s.resets.FindOneAndUpdate(ctx,
+		bson.M{"_id": user.ID, "expires_at": bson.M{"$gt": time.Now()}, "attempts": bson.M{"$lt": maxAttempts}},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+	).Decode(&reset)
 	if errors.Is(err, mongo.ErrNoDocuments) {
-		return ErrNoResetDoc
-	} else if err != nil {
+		return ErrInvalidCode
+	}
+	if err != nil {
 		return err
 	}
-
-	if !resetDoc.Verified {
-		return ErrUnauthorized
+	if subtle.ConstantTimeCompare([]byte(hashCode(user.ID, strings.ToUpper(code))), []byte(reset.Hash)) != 1 {
+		return ErrInvalidCode
 	}
 
-	// Update user’s password in the users collection
-	userFilter := bson.M{"email": email}
-	userUpdate := bson.M{"$set": bson.M{"password": newPass}} // should hash this
-
-	_, err = s.users.UpdateOne(ctx, userFilter, userUpdate, options.Update().SetUpsert(false))
+	hash, err := auth.HashPassword(password, s.passwordCost)
 	if err != nil {
 		return err
 	}
-
-	// Delete the reset document
-	_, err = s.pwResets.DeleteOne(ctx, filter)
+	// bumping count revokes tokens issued before sessions too
+	_, err = s.users.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{
+		"$set": bson.M{"password": hash, "email_verified": true},
+		"$inc": bson.M{"count": 1},
+	})
 	if err != nil {
 		return err
 	}
+	if _, err := s.sessions.DeleteMany(ctx, bson.M{"user": user.ID}); err != nil {
+		return err
+	}
+	audit.Log(ctx, s.audit, audit.New(user.ID, audit.PasswordChanged, client))
+	_, err = s.resets.DeleteOne(ctx, bson.M{"_id": user.ID})
+	return err
+}
 
-	return nil
+func hashCode(user primitive.ObjectID, code string) string {
+	sum := sha256.Sum256([]byte(user.Hex() + ":" + code))
+	return hex.EncodeToString(sum[:])
 }
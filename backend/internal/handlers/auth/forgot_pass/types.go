@@ -1,28 +1,46 @@
 package forgot_pass
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
 
-type ForgotPasswordRequestBody struct {
-	Email string `validate:"required,email" json:"email"`
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Password Reset Service to be used by Password Reset Handler to interact with the
+Database layer of the application
+*/
+type Service struct {
+	resets   *mongo.Collection
+	users    *mongo.Collection
+	sessions *mongo.Collection
+	audit    *mongo.Collection
+	queue    *jobs.Queue
+	// bcrypt cost new passwords are hashed at
+	passwordCost int
 }
 
-type ChangePasswordRequestBody struct {
-	Email   string `validate:"required,email" json:"email"`
-	NewPass string `validate:"required,len=10" json:"newPass"`
+type ForgotPasswordRequest struct {
+	Email string `validate:"required,email" json:"email"`
 }
 
-type VerifyOTPRequestParams struct {
-	OTP   string `validate:"required,len=6,alphanum,uppercase" json:"otp"`
-	Email string `validate:"required" json:"email"`
+type ResetPasswordRequest struct {
+	Email string `validate:"required,email" json:"email"`
+	Code  string `validate:"required,len=6,alphanum" json:"code"`
+	// bcrypt reads at most 72 bytes
+	Password string `validate:"required,min=8,maxbytes=72" json:"password"`
 }
 
 // *** MONGO DOCUMENTS BELOW *** //
 
-type PasswordResetDocument struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	Email     string             `bson:"email"         json:"email"`
-	OTP       string             `bson:"otp"           json:"otp"`
-	Verified  bool               `bson:"verified"      json:"verified"`
-	CreatedAt primitive.DateTime `bson:"createdAt"    json:"createdAt"`
-	ExpiresAt primitive.DateTime `bson:"expiresAt"`
+// PasswordReset is the code a user was last mailed, keyed by the user so only the newest one works
+type PasswordReset struct {
+	User primitive.ObjectID `bson:"_id"`
+	// sha256 of the user id and code, the code itself is only in the email
+	Hash      string    `bson:"hash"`
+	Attempts  int       `bson:"attempts"`
+	CreatedAt time.Time `bson:"created_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
 }
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/abhikaboy/SocialToDo/internal/xjwks"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Google's signing keys, shared by every Service
+var googleKeys = xjwks.New("https://www.googleapis.com/oauth2/v3/certs")
+
+var googleIssuers = []string{"accounts.google.com", "https://accounts.google.com"}
+
+var (
+	ErrGoogleDisabled     = fiber.NewError(fiber.StatusNotFound, "Google Sign-In is not configured")
+	ErrInvalidGoogleToken = fiber.NewError(fiber.StatusUnauthorized, "Not Authorized, Invalid Google Token")
+)
+
+type googleClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+	// Google has sent this as a string for some account types
+	EmailVerified any `json:"email_verified"`
+}
+
+func (c googleClaims) verified() bool {
+	return c.EmailVerified == true || c.EmailVerified == "true"
+}
+
+// verifyGoogle checks the ID token's signature, issuer, audience and expiry
+func (s *Service) verifyGoogle(idToken string) (*googleClaims, error) {
+	clientIDs := s.config.Google.SignInClientIDs
+	if len(clientIDs) == 0 {
+		return nil, ErrGoogleDisabled
+	}
+	var claims googleClaims
+	_, err := jwt.ParseWithClaims(idToken, &claims, googleKeys.Keyfunc,
+		jwt.WithValidMethods([]string{"RS256"}), jwt.WithExpirationRequired())
+	if err != nil || !slices.Contains(googleIssuers, claims.Issuer) || claims.Subject == "" {
+		return nil, ErrInvalidGoogleToken
+	}
+	if !audienceIn(claims.Audience, clientIDs) {
+		return nil, ErrInvalidGoogleToken
+	}
+	return &claims, nil
+}
+
+// LoginFromGoogle signs in with a Google ID token, see federatedLogin
+func (s *Service) LoginFromGoogle(ctx context.Context, idToken string) (_ primitive.ObjectID, _ float64, created bool, err error) {
+	defer xmetrics.Track("auth", "LoginFromGoogle")(&err)
+
+	claims, err := s.verifyGoogle(idToken)
+	if err != nil {
+		return primitive.NewObjectID(), 0, false, err
+	}
+	return s.federatedLogin(ctx, federated{
+		subject:  claims.Subject,
+		email:    claims.Email,
+		verified: claims.verified(),
+		find:     s.repo.FindByGoogleID,
+		link:     s.repo.LinkGoogle,
+		set:      func(user *User) { user.GoogleID = claims.Subject },
+	})
+}
+
+// audienceIn reports whether a token was issued for one of clientIDs
+func audienceIn(audience jwt.ClaimStrings, clientIDs []string) bool {
+	return slices.ContainsFunc(audience, func(aud string) bool { return slices.Contains(clientIDs, aud) })
+}
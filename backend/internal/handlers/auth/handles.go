@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/abhikaboy/SocialToDo/internal/handles"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrHandleTaken = fiber.NewError(fiber.StatusConflict, "Handle is taken")
+
+// handleTaken reports whether err is the unique handle index rejecting a write
+func handleTaken(err error) bool {
+	return mongo.IsDuplicateKeyError(err) && strings.Contains(err.Error(), "users_handle_unique")
+}
+
+// HandleAvailable returns the handle raw normalizes to and whether no account has it
+func (s *Service) HandleAvailable(ctx context.Context, raw string) (string, bool, error) {
+	handle, err := handles.Normalize(raw)
+	if err != nil {
+		return "", false, err
+	}
+	taken, err := s.repo.HandleTaken(ctx, handle)
+	if err != nil {
+		return "", false, err
+	}
+	return handle, !taken, nil
+}
+
+func (h *Handler) HandleAvailable(c *fiber.Ctx) error {
+	handle, available, err := h.service.HandleAvailable(c.UserContext(), c.Query("handle"))
+	if errors.Is(err, handles.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{
+		"handle":    handle,
+		"available": available,
+	})
+}
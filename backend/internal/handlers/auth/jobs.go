@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/xmail"
+	"github.com/abhikaboy/SocialToDo/internal/xsms"
+)
+
+const (
+	VerifyEmailJob         = "email.verify"
+	AccountDeletedEmailJob = "email.account_deleted"
+	PhoneCodeJob           = "sms.phone_code"
+)
+
+// LinkEmail is the payload of emails that carry a single link for the user to follow
+type LinkEmail struct {
+	Email string `bson:"email"`
+	Link  string `bson:"link"`
+}
+
+// PhoneCodeText is the payload of the text carrying a phone sign-in code
+type PhoneCodeText struct {
+	Phone string `bson:"phone"`
+	Code  string `bson:"code"`
+}
+
+// RegisterJobs adds the auth job handlers to the worker
+func RegisterJobs(worker *jobs.Worker, mailer xmail.Sender, texter xsms.Sender) {
+	worker.Handle(VerifyEmailJob, linkEmail(mailer, func(link string) xmail.Message {
+		return xmail.Message{
+			Subject: "Verify your SocialToDo email",
+			Text: fmt.Sprintf("Open this link to verify your email:\n\n%s\n\n"+
+				"It expires in %d hours. If you didn't make a SocialToDo account, you can ignore this email.", link, int(verifyTTL.Hours())),
+		}
+	}))
+	worker.Handle(AccountDeletedEmailJob, linkEmail(mailer, func(link string) xmail.Message {
+		return xmail.Message{
+			Subject: "Your SocialToDo account was deleted",
+			Text: fmt.Sprintf("Your SocialToDo account was deleted. If you change your mind, open this link within %d days to restore it:\n\n%s\n\n"+
+				"After that, the account and its tasks are gone for good.", int(restoreTTL.Hours()/24), link),
+		}
+	}))
+	worker.Handle(PhoneCodeJob, func(ctx context.Context, job *jobs.Job) error {
+		var payload PhoneCodeText
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		err := texter.Send(ctx, xsms.Message{
+			To:   payload.Phone,
+			Text: fmt.Sprintf("Your SocialToDo code is %s. It expires in %d minutes.", payload.Code, int(phoneCodeTTL.Minutes())),
+		})
+		if errors.Is(err, xsms.ErrRejected) {
+			return jobs.Permanent(err)
+		}
+		return err
+	})
+}
+
+func linkEmail(mailer xmail.Sender, compose func(link string) xmail.Message) jobs.Handler {
+	return func(ctx context.Context, job *jobs.Job) error {
+		var payload LinkEmail
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		msg := compose(payload.Link)
+		msg.To = payload.Email
+		err := mailer.Send(ctx, msg)
+		if errors.Is(err, xmail.ErrRejected) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+}
@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRepository keeps users in process, for handler tests and local tools
+type MemoryRepository struct {
+	mu       sync.RWMutex
+	users    map[string]*User
+	sessions map[string]*Session
+	events   []audit.Event
+	codes    map[string]*PhoneCode
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{users: make(map[string]*User), sessions: make(map[string]*Session), codes: make(map[string]*PhoneCode)}
+}
+
+func (r *MemoryRepository) find(match func(*User) bool) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.DeletedAt == nil && match(user) {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *MemoryRepository) FindByID(ctx context.Context, id string) (*User, error) {
+	return r.find(func(u *User) bool { return u.ID.Hex() == id })
+}
+
+func (r *MemoryRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	return r.find(func(u *User) bool { return u.Email == email })
+}
+
+func (r *MemoryRepository) FindByAppleID(ctx context.Context, appleID string) (*User, error) {
+	return r.find(func(u *User) bool { return appleID != "" && u.AppleID == appleID })
+}
+
+func (r *MemoryRepository) FindByGoogleID(ctx context.Context, googleID string) (*User, error) {
+	return r.find(func(u *User) bool { return googleID != "" && u.GoogleID == googleID })
+}
+
+func (r *MemoryRepository) FindByPhone(ctx context.Context, phone string) (*User, error) {
+	return r.find(func(u *User) bool { return phone != "" && u.VerifiedPhone == phone })
+}
+
+func (r *MemoryRepository) HandleTaken(ctx context.Context, handle string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Handle == handle {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.users {
+		if existing.ID == user.ID || (user.Email != "" && existing.Email == user.Email) {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key"}}}
+		}
+		if user.VerifiedPhone != "" && existing.VerifiedPhone == user.VerifiedPhone {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key error index: users_verified_phone_unique"}}}
+		}
+		if user.Handle != "" && existing.Handle == user.Handle {
+			return mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000, Message: "duplicate key error index: users_handle_unique"}}}
+		}
+	}
+	r.users[user.ID.Hex()] = &user
+	return nil
+}
+
+func (r *MemoryRepository) IncrementCount(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		user.Count++
+	}
+	return nil
+}
+
+func (r *MemoryRepository) SetTokenUsed(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		user.TokenUsed = true
+	}
+	return nil
+}
+
+func (r *MemoryRepository) ReplacePassword(ctx context.Context, id string, old string, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok && user.Password == old {
+		user.Password = hash
+	}
+	return nil
+}
+
+func (r *MemoryRepository) LinkGoogle(ctx context.Context, id string, googleID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		user.GoogleID = googleID
+	}
+	return nil
+}
+
+func (r *MemoryRepository) LinkApple(ctx context.Context, id string, appleID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if user, ok := r.users[id]; ok {
+		user.AppleID = appleID
+	}
+	return nil
+}
+
+func (r *MemoryRepository) LinkPhone(ctx context.Context, id string, phone string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.users {
+		if other.ID.Hex() != id && other.VerifiedPhone == phone {
+			return ErrPhoneTaken
+		}
+	}
+	if user, ok := r.users[id]; ok {
+		user.Phone, user.VerifiedPhone = phone, phone
+	}
+	return nil
+}
+
+func (r *MemoryRepository) MarkEmailVerified(ctx context.Context, id string, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.Email != email {
+		return mongo.ErrNoDocuments
+	}
+	verified := true
+	user.EmailVerified = &verified
+	return nil
+}
+
+func (r *MemoryRepository) DeleteAccount(ctx context.Context, id string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt != nil {
+		return mongo.ErrNoDocuments
+	}
+	user.DeletedAt = &at
+	user.Count++
+	for _, other := range r.users {
+		other.Friends = slices.DeleteFunc(other.Friends, func(friend primitive.ObjectID) bool { return friend == user.ID })
+	}
+	for sid, session := range r.sessions {
+		if session.User == user.ID {
+			delete(r.sessions, sid)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RestoreAccount(ctx context.Context, id string, deletedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok || user.DeletedAt == nil || !user.DeletedAt.Equal(deletedAt) {
+		return mongo.ErrNoDocuments
+	}
+	user.DeletedAt = nil
+	for _, friend := range user.Friends {
+		if other, ok := r.users[friend.Hex()]; ok && other.DeletedAt == nil && !slices.Contains(other.Friends, user.ID) {
+			other.Friends = append(other.Friends, user.ID)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRepository) CreateSession(ctx context.Context, session Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, existing := range r.sessions {
+		if existing.User == session.User && existing.DeviceID == session.DeviceID {
+			delete(r.sessions, id)
+		}
+	}
+	r.sessions[session.ID.Hex()] = &session
+	return nil
+}
+
+func (r *MemoryRepository) FindSession(ctx context.Context, id string) (*Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	session, ok := r.sessions[id]
+	if !ok || !session.ExpiresAt.After(time.Now()) {
+		return nil, mongo.ErrNoDocuments
+	}
+	found := *session
+	return &found, nil
+}
+
+func (r *MemoryRepository) RotateSession(ctx context.Context, id string, old string, hash string, seen time.Time, expires time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok || session.RefreshHash != old {
+		return mongo.ErrNoDocuments
+	}
+	session.RefreshHash, session.PreviousHash = hash, old
+	session.RotatedAt, session.LastSeenAt, session.ExpiresAt = &seen, seen, expires
+	return nil
+}
+
+func (r *MemoryRepository) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sessions := make([]Session, 0)
+	for _, session := range r.sessions {
+		if session.User.Hex() == userID && session.ExpiresAt.After(time.Now()) {
+			sessions = append(sessions, *session)
+		}
+	}
+	slices.SortFunc(sessions, func(a, b Session) int { return b.LastSeenAt.Compare(a.LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *MemoryRepository) DeleteSession(ctx context.Context, userID string, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok || session.User.Hex() != userID {
+		return mongo.ErrNoDocuments
+	}
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *MemoryRepository) FindPhoneCode(ctx context.Context, phone string) (*PhoneCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	code, ok := r.codes[phone]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	found := *code
+	return &found, nil
+}
+
+func (r *MemoryRepository) SavePhoneCode(ctx context.Context, code PhoneCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codes[code.Phone] = &code
+	return nil
+}
+
+func (r *MemoryRepository) AttemptPhoneCode(ctx context.Context, phone string, max int, now time.Time) (*PhoneCode, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code, ok := r.codes[phone]
+	if !ok || !code.ExpiresAt.After(now) || code.Attempts >= max {
+		return nil, mongo.ErrNoDocuments
+	}
+	code.Attempts++
+	found := *code
+	return &found, nil
+}
+
+func (r *MemoryRepository) SpendPhoneCode(ctx context.Context, phone string, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	code, ok := r.codes[phone]
+	if !ok || code.Hash != hash {
+		return mongo.ErrNoDocuments
+	}
+	code.Hash = ""
+	return nil
+}
+
+func (r *MemoryRepository) RecordEvent(ctx context.Context, event audit.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *MemoryRepository) ListEvents(ctx context.Context, userID string, after primitive.ObjectID, limit int) ([]audit.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := make([]audit.Event, 0)
+	for _, event := range slices.Backward(r.events) {
+		if event.User.Hex() == userID && (after.IsZero() || event.ID.Hex() < after.Hex()) && len(events) < limit {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
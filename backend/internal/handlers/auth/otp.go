@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Phone sign-in texts a one-time code to the number; entering it signs in to
+the account that proved the number before, or makes a new account for it.
+A signed-in user can prove a number the same way to sign in with it later.
+Codes are kept hashed, one per number, and each number can only be sent so
+many in a while, since every text costs money.
+*/
+
+const (
+	PhoneCodeCollection = "phone_codes"
+
+	phoneCodeTTL     = 10 * time.Minute
+	phoneCodeLength  = 6
+	phoneCodePurpose = "phone_code"
+	// guesses allowed per code before a new one has to be asked for
+	maxPhoneAttempts = 5
+	// asking again sooner than this doesn't text another code
+	phoneResendInterval = time.Minute
+	// texts a number gets per window, however many clients ask
+	maxPhoneSends = 5
+	phoneWindow   = time.Hour
+)
+
+var (
+	ErrInvalidPhoneCode = xerr.New(fiber.StatusUnauthorized, xerr.CodeAuthInvalid, "Invalid or expired code")
+	ErrTooManyCodes     = xerr.New(fiber.StatusTooManyRequests, xerr.CodeRateLimited, "Too many codes sent to this number, try again later")
+	ErrPhoneTaken       = xerr.New(fiber.StatusConflict, xerr.CodeConflict, "Phone number is linked to another account")
+)
+
+// PhoneCode is the code a number was last texted, keyed by the number so only the newest one works
+type PhoneCode struct {
+	Phone string `bson:"_id"`
+	// keyed hash of the number and code, the code itself is only in the text
+	Hash     string `bson:"hash"`
+	Attempts int    `bson:"attempts"`
+	// texts sent since WindowStart
+	Sends       int       `bson:"sends"`
+	WindowStart time.Time `bson:"window_start"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+	// when neither the code nor the send count is needed anymore (TTL index)
+	PurgeAt time.Time `bson:"purge_at"`
+}
+
+type PhoneCodeRequest struct {
+	Phone string `validate:"required,e164" json:"phone"`
+}
+
+type PhoneVerifyRequest struct {
+	Phone string `validate:"required,e164" json:"phone"`
+	Code  string `validate:"required,len=6,numeric" json:"code"`
+}
+
+/*
+RequestPhoneCode texts a sign-in code to phone, replacing any code sent
+before. Whether an account has the number doesn't matter, an unknown number
+signs up.
+*/
+func (s *Service) RequestPhoneCode(ctx context.Context, phone string) (err error) {
+	defer xmetrics.Track("auth", "RequestPhoneCode")(&err)
+
+	now := time.Now()
+	last, err := s.repo.FindPhoneCode(ctx, phone)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	code := PhoneCode{Phone: phone, WindowStart: now}
+	if last != nil {
+		if now.Sub(last.CreatedAt) < phoneResendInterval {
+			return nil
+		}
+		if now.Sub(last.WindowStart) < phoneWindow {
+			if last.Sends >= maxPhoneSends {
+				return ErrTooManyCodes
+			}
+			code.Sends, code.WindowStart = last.Sends, last.WindowStart
+		}
+	}
+
+	digits, err := phoneDigits()
+	if err != nil {
+		return err
+	}
+	code.Hash = s.hashPhoneCode(phone, digits)
+	code.Sends++
+	code.CreatedAt = now
+	code.ExpiresAt = now.Add(phoneCodeTTL)
+	code.PurgeAt = code.WindowStart.Add(phoneWindow)
+	if code.PurgeAt.Before(code.ExpiresAt) {
+		code.PurgeAt = code.ExpiresAt
+	}
+	if err := s.repo.SavePhoneCode(ctx, code); err != nil {
+		return err
+	}
+	if s.queue == nil {
+		return nil
+	}
+	_, err = s.queue.Enqueue(ctx, PhoneCodeJob, PhoneCodeText{Phone: phone, Code: digits})
+	return err
+}
+
+/*
+LoginFromPhone signs in with a code from RequestPhoneCode: into the account
+that proved the number, else a new account with it. created reports the
+latter, which has no email until the user adds one.
+*/
+func (s *Service) LoginFromPhone(ctx context.Context, phone string, code string, device Device) (_ primitive.ObjectID, _ float64, created bool, err error) {
+	defer xmetrics.Track("auth", "LoginFromPhone")(&err)
+
+	user, err := s.repo.FindByPhone(ctx, phone)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return primitive.NewObjectID(), 0, false, err
+	}
+	if err := s.usePhoneCode(ctx, phone, code); err != nil {
+		if user != nil && errors.Is(err, ErrInvalidPhoneCode) {
+			s.record(ctx, user.ID, audit.LoginFailed, audit.Phone, device)
+		}
+		return primitive.NewObjectID(), 0, false, err
+	}
+
+	if user == nil {
+		account := newAccount(primitive.NewObjectID(), "")
+		account.Phone, account.VerifiedPhone = phone, phone
+		if err := s.CreateUser(ctx, account); err != nil {
+			return primitive.NewObjectID(), 0, false, err
+		}
+		return account.ID, account.Count, true, nil
+	}
+	if user.SuspendedAt != nil {
+		return primitive.NewObjectID(), 0, false, ErrSuspended
+	}
+	return user.ID, user.Count, false, nil
+}
+
+// LinkPhone signs the user in with phone from now on, once code proves it's theirs
+func (s *Service) LinkPhone(ctx context.Context, userID string, phone string, code string, device Device) error {
+	if err := s.usePhoneCode(ctx, phone, code); err != nil {
+		return err
+	}
+	if err := s.repo.LinkPhone(ctx, userID, phone); err != nil {
+		return err
+	}
+	s.recordOf(ctx, userID, audit.PhoneLinked, device)
+	return nil
+}
+
+// usePhoneCode spends code if it is the one last texted to phone, so it can't be entered twice
+func (s *Service) usePhoneCode(ctx context.Context, phone string, code string) error {
+	// counting the attempt before comparing keeps concurrent guesses within maxPhoneAttempts
+	last, err := s.repo.AttemptPhoneCode(ctx, phone, maxPhoneAttempts, time.Now())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvalidPhoneCode
+	}
+	if err != nil {
+		return err
+	}
+	if last.Hash == "" || !hmac.Equal([]byte(s.hashPhoneCode(phone, code)), []byte(last.Hash)) {
+		return ErrInvalidPhoneCode
+	}
+	// a request racing this one with the same code loses
+	err = s.repo.SpendPhoneCode(ctx, phone, last.Hash)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvalidPhoneCode
+	}
+	return err
+}
+
+func (s *Service) hashPhoneCode(phone string, code string) string {
+	mac := hmac.New(sha256.New, s.purposeKey(phoneCodePurpose))
+	mac.Write([]byte(phone + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// phoneDigits is a random numeric code, which phones offer to fill in from the text
+func phoneDigits() (string, error) {
+	max := big.NewInt(1)
+	for range phoneCodeLength {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", phoneCodeLength, n), nil
+}
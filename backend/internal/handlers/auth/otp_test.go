@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const testPhone = "+15555550100"
+
+func TestPhoneLogin(t *testing.T) {
+	app, service, _ := newPhoneTestApp(t)
+	body := func(code string) string { return `{"phone": "` + testPhone + `", "code": "` + code + `"}` }
+
+	sendCode(t, service, testPhone, "123456")
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{"not a number", `{"phone": "555-0100", "code": "123456"}`, fiber.StatusBadRequest},
+		{"letters in the code", body("12345a"), fiber.StatusBadRequest},
+		{"wrong code", body("654321"), fiber.StatusUnauthorized},
+		{"new number", body("123456"), fiber.StatusCreated},
+		{"code used up", body("123456"), fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if res := do(t, app, "/otp/verify", tt.body, nil); res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+		}
+	}
+
+	sendCode(t, service, testPhone, "111111")
+	res := do(t, app, "/otp/verify", body("111111"), nil)
+	if res.StatusCode != fiber.StatusOK {
+		t.Fatalf("known number: expected 200, got %d", res.StatusCode)
+	}
+	if tokens := decode[TokenResponse](t, res); tokens.AccessToken == "" || tokens.User == nil || tokens.User.Phone != testPhone {
+		t.Errorf("known number: unexpected body %+v", tokens)
+	}
+
+	// guesses run out before the code is found
+	sendCode(t, service, testPhone, "222222")
+	for range maxPhoneAttempts {
+		do(t, app, "/otp/verify", body("000000"), nil)
+	}
+	if res := do(t, app, "/otp/verify", body("222222"), nil); res.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("after %d guesses: expected 401, got %d", maxPhoneAttempts, res.StatusCode)
+	}
+}
+
+func TestRequestPhoneCode(t *testing.T) {
+	_, service, repo := newPhoneTestApp(t)
+	ctx := context.Background()
+
+	if err := service.RequestPhoneCode(ctx, testPhone); err != nil {
+		t.Fatal(err)
+	}
+	first, err := repo.FindPhoneCode(ctx, testPhone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// too soon for another text
+	if err := service.RequestPhoneCode(ctx, testPhone); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := repo.FindPhoneCode(ctx, testPhone); again.Hash != first.Hash || again.Sends != 1 {
+		t.Errorf("expected the first code to stand, got %+v", again)
+	}
+
+	for sends := 2; sends <= maxPhoneSends+1; sends++ {
+		repo.codes[testPhone].CreatedAt = time.Now().Add(-phoneResendInterval)
+		err := service.RequestPhoneCode(ctx, testPhone)
+		if sends > maxPhoneSends {
+			if !errors.Is(err, ErrTooManyCodes) {
+				t.Errorf("send %d: expected too many codes, got %v", sends, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("send %d: %v", sends, err)
+		}
+	}
+
+	// a new window starts the count over
+	repo.codes[testPhone].WindowStart = time.Now().Add(-phoneWindow)
+	if err := service.RequestPhoneCode(ctx, testPhone); err != nil {
+		t.Errorf("new window: expected a code, got %v", err)
+	}
+	if code, _ := repo.FindPhoneCode(ctx, testPhone); code.Sends != 1 {
+		t.Errorf("new window: expected 1 send, got %d", code.Sends)
+	}
+}
+
+func TestLinkPhone(t *testing.T) {
+	app, service, repo := newPhoneTestApp(t)
+	tokens := login(t, app)
+	auth := map[string]string{fiber.HeaderAuthorization: "Bearer " + tokens.AccessToken}
+	body := `{"phone": "` + testPhone + `", "code": "123456"}`
+
+	if res := do(t, app, "/phone", body, auth); res.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("no code sent: expected 401, got %d", res.StatusCode)
+	}
+	sendCode(t, service, testPhone, "123456")
+	if res := do(t, app, "/phone", body, nil); res.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("signed out: expected 401, got %d", res.StatusCode)
+	}
+	res := do(t, app, "/phone", body, auth)
+	if res.StatusCode != fiber.StatusOK {
+		t.Fatalf("link: expected 200, got %d", res.StatusCode)
+	}
+	if profile := decode[Profile](t, res); profile.Phone != testPhone || profile.Email != testEmail {
+		t.Errorf("link: unexpected profile %+v", profile)
+	}
+
+	// the number now signs in to the email account
+	sendCode(t, service, testPhone, "111111")
+	res = do(t, app, "/otp/verify", `{"phone": "`+testPhone+`", "code": "111111"}`, nil)
+	if res.StatusCode != fiber.StatusOK {
+		t.Fatalf("login: expected 200, got %d", res.StatusCode)
+	}
+	if signedIn := decode[TokenResponse](t, res); signedIn.User == nil || signedIn.User.Email != testEmail {
+		t.Errorf("login: expected the email account, got %+v", signedIn.User)
+	}
+
+	other := newAccount(primitive.NewObjectID(), "alex@example.com")
+	if err := repo.Create(context.Background(), other); err != nil {
+		t.Fatal(err)
+	}
+	sendCode(t, service, testPhone, "222222")
+	err := service.LinkPhone(context.Background(), other.ID.Hex(), testPhone, "222222", Device{})
+	if err != ErrPhoneTaken {
+		t.Errorf("another account: expected phone taken, got %v", err)
+	}
+}
+
+// newPhoneTestApp mounts the phone routes next to login, on a memory repository holding the test user
+func newPhoneTestApp(t *testing.T) (*fiber.App, *Service, *MemoryRepository) {
+	t.Helper()
+	cfg := config.Config{Auth: config.Auth{Secret: "test-secret", PasswordCost: 4}}
+	repo := NewMemoryRepository()
+	hash, err := HashPassword(testPassword, cfg.Auth.PasswordCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := newAccount(primitive.NewObjectID(), testEmail)
+	user.Password = hash
+	if err := repo.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewServiceWithRepository(repo, cfg)
+	handler := Handler{service, cfg}
+	app := fiber.New(fiber.Config{ErrorHandler: xerr.ErrorHandler})
+	app.Post("/login", handler.Login)
+	app.Post("/otp/verify", handler.VerifyPhoneCode)
+	app.Post("/phone", handler.AuthenticateMiddleware, handler.LinkPhone)
+	return app, service, repo
+}
+
+// sendCode stores code as if it had just been texted to phone
+func sendCode(t *testing.T, s *Service, phone string, code string) {
+	t.Helper()
+	now := time.Now()
+	err := s.repo.SavePhoneCode(context.Background(), PhoneCode{
+		Phone:       phone,
+		Hash:        s.hashPhoneCode(phone, code),
+		Sends:       1,
+		WindowStart: now,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(phoneCodeTTL),
+		PurgeAt:     now.Add(phoneWindow),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
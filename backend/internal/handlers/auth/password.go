@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+Passwords are stored as bcrypt hashes. Accounts made before hashing still
+hold their password in plaintext; it is checked as such once more and
+replaced by a hash on that login, as is a hash made at an older cost.
+*/
+
+// HashPassword hashes password for storage at the given bcrypt cost
+func HashPassword(password string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether password matches stored, and whether stored should be rehashed at cost
+func checkPassword(stored string, password string, cost int) (match bool, rehash bool) {
+	if stored == "" {
+		// accounts from Apple or Google sign-in have no password
+		return false, false
+	}
+	// every bcrypt version prefix starts with $2, a plaintext password can't be told apart otherwise
+	if !strings.HasPrefix(stored, "$2") {
+		match := subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return match, match
+	}
+	if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+		return false, false
+	}
+	storedCost, err := bcrypt.Cost([]byte(stored))
+	return true, err == nil && storedCost != cost
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+)
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := HashPassword(testPassword, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		cost     int
+		match    bool
+		rehash   bool
+	}{
+		{"hash", hash, testPassword, 4, true, false},
+		{"wrong password", hash, "hunter22", 4, false, false},
+		{"cost raised", hash, testPassword, 5, true, true},
+		{"plaintext", testPassword, testPassword, 4, true, true},
+		{"plaintext wrong", testPassword, "hunter22", 4, false, false},
+		{"no password", "", "", 4, false, false},
+	}
+	for _, tt := range tests {
+		match, rehash := checkPassword(tt.stored, tt.password, tt.cost)
+		if match != tt.match || rehash != tt.rehash {
+			t.Errorf("%s: expected match %v rehash %v, got %v %v", tt.name, tt.match, tt.rehash, match, rehash)
+		}
+	}
+}
+
+func TestPasswordLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{"72 bytes", strings.Repeat("a", 72), true},
+		{"73 bytes", strings.Repeat("a", 73), false},
+		// 30 runes, but 90 bytes bcrypt would refuse
+		{"multibyte", strings.Repeat("密", 30), false},
+		{"short", "secret", false},
+	}
+	for _, tt := range tests {
+		errs := xvalidator.Validator.Validate(RegisterRequest{Email: testEmail, Password: tt.password})
+		if valid := len(errs) == 0; valid != tt.valid {
+			t.Errorf("%s: expected valid %v, got %+v", tt.name, tt.valid, errs)
+		}
+		if tt.valid {
+			if _, err := HashPassword(tt.password, 4); err != nil {
+				t.Errorf("%s: %v", tt.name, err)
+			}
+		}
+	}
+}
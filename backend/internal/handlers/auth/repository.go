@@ -0,0 +1,378 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/outbox"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Repository is the storage the auth Service needs. Ids are the hex strings
+carried in token claims. A missing user is mongo.ErrNoDocuments for every
+implementation.
+*/
+type Repository interface {
+	FindByID(ctx context.Context, id string) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindByAppleID(ctx context.Context, appleID string) (*User, error)
+	FindByGoogleID(ctx context.Context, googleID string) (*User, error)
+	// FindByPhone finds the user who proved phone, not one who only typed it into their profile
+	FindByPhone(ctx context.Context, phone string) (*User, error)
+	// HandleTaken reports whether any account has handle, deleted ones included
+	HandleTaken(ctx context.Context, handle string) (bool, error)
+	// Create stores the user and announces user.registered
+	Create(ctx context.Context, user User) error
+	IncrementCount(ctx context.Context, id string) error
+	SetTokenUsed(ctx context.Context, id string) error
+	// ReplacePassword stores hash if the stored password is still old, and does nothing otherwise
+	ReplacePassword(ctx context.Context, id string, old string, hash string) error
+	// LinkGoogle signs an existing account in with Google from now on
+	LinkGoogle(ctx context.Context, id string, googleID string) error
+	LinkApple(ctx context.Context, id string, appleID string) error
+	// LinkPhone makes phone the user's number to sign in with, ErrPhoneTaken when another account has it
+	LinkPhone(ctx context.Context, id string, phone string) error
+	// MarkEmailVerified verifies the user's email if it is still email, and is mongo.ErrNoDocuments otherwise
+	MarkEmailVerified(ctx context.Context, id string, email string) error
+
+	/*
+		DeleteAccount marks the live user deleted at at and revokes their tokens,
+		takes them out of their friends' friends and deletes their activity. The
+		user document keeps its own friends, categories and tasks until the purge.
+	*/
+	DeleteAccount(ctx context.Context, id string, at time.Time) error
+	// RestoreAccount undoes the DeleteAccount that happened at deletedAt, as far as it can
+	RestoreAccount(ctx context.Context, id string, deletedAt time.Time) error
+
+	// CreateSession stores the session, replacing the one the user had on the same device
+	CreateSession(ctx context.Context, session Session) error
+	FindSession(ctx context.Context, id string) (*Session, error)
+	// RotateSession swaps the refresh hash if it is still old, and is mongo.ErrNoDocuments otherwise
+	RotateSession(ctx context.Context, id string, old string, hash string, seen time.Time, expires time.Time) error
+	ListSessions(ctx context.Context, userID string) ([]Session, error)
+	DeleteSession(ctx context.Context, userID string, id string) error
+
+	FindPhoneCode(ctx context.Context, phone string) (*PhoneCode, error)
+	// SavePhoneCode replaces the number's code
+	SavePhoneCode(ctx context.Context, code PhoneCode) error
+	// AttemptPhoneCode counts a guess at the number's code, mongo.ErrNoDocuments when it expired or ran out of guesses
+	AttemptPhoneCode(ctx context.Context, phone string, max int, now time.Time) (*PhoneCode, error)
+	// SpendPhoneCode stops the code with hash working, and is mongo.ErrNoDocuments when it already was
+	SpendPhoneCode(ctx context.Context, phone string, hash string) error
+
+	// RecordEvent appends to the audit log
+	RecordEvent(ctx context.Context, event audit.Event) error
+	// ListEvents is up to limit of the user's audit events, newest first, older than after unless that's zero
+	ListEvents(ctx context.Context, userID string, after primitive.ObjectID, limit int) ([]audit.Event, error)
+}
+
+const SessionCollection = "sessions"
+
+type mongoRepository struct {
+	users      *mongo.Collection
+	sessions   *mongo.Collection
+	activity   *mongo.Collection
+	outbox     *mongo.Collection
+	audit      *mongo.Collection
+	phoneCodes *mongo.Collection
+}
+
+func NewMongoRepository(collections map[string]*mongo.Collection) Repository {
+	return &mongoRepository{
+		users:      collections["users"],
+		sessions:   collections[SessionCollection],
+		activity:   collections["activity"],
+		outbox:     collections[outbox.Collection],
+		audit:      collections[audit.Collection],
+		phoneCodes: collections[PhoneCodeCollection],
+	}
+}
+
+// findOne skips deleted accounts, so they can neither log in nor use their tokens
+func (r *mongoRepository) findOne(ctx context.Context, filter bson.M) (*User, error) {
+	var user User
+	if err := r.users.FindOne(ctx, softdelete.Filter(filter)).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *mongoRepository) FindByID(ctx context.Context, id string) (*User, error) {
+	return r.findOne(ctx, userFilter(id))
+}
+
+func (r *mongoRepository) FindByEmail(ctx context.Context, email string) (*User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *mongoRepository) FindByAppleID(ctx context.Context, appleID string) (*User, error) {
+	return r.findOne(ctx, bson.M{"apple_id": appleID})
+}
+
+func (r *mongoRepository) FindByGoogleID(ctx context.Context, googleID string) (*User, error) {
+	return r.findOne(ctx, bson.M{"google_id": googleID})
+}
+
+func (r *mongoRepository) FindByPhone(ctx context.Context, phone string) (*User, error) {
+	return r.findOne(ctx, bson.M{"verified_phone": phone})
+}
+
+func (r *mongoRepository) HandleTaken(ctx context.Context, handle string) (bool, error) {
+	n, err := r.users.CountDocuments(ctx, bson.M{"handle": handle}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// Create inserts the user and queues user.registered in the same transaction
+func (r *mongoRepository) Create(ctx context.Context, user User) error {
+	user.DisplayNameLower = strings.ToLower(user.DisplayName)
+	return xmongo.WithTransaction(ctx, r.users, func(sc mongo.SessionContext) error {
+		if _, err := r.users.InsertOne(sc, user); err != nil {
+			return err
+		}
+		return outbox.Write(sc, r.outbox, events.Event{
+			Type:       events.UserRegistered,
+			UserID:     user.ID.Hex(),
+			Collection: "users",
+			DocumentID: user.ID.Hex(),
+			Payload:    events.UserRegisteredPayload{Handle: user.Handle},
+		})
+	})
+}
+
+func (r *mongoRepository) IncrementCount(ctx context.Context, id string) error {
+	_, err := r.users.UpdateOne(ctx, userFilter(id), bson.M{"$inc": bson.M{"count": 1}})
+	return err
+}
+
+func (r *mongoRepository) SetTokenUsed(ctx context.Context, id string) error {
+	_, err := r.users.UpdateOne(ctx, userFilter(id), bson.M{"$set": bson.M{"token_used": true}})
+	return err
+}
+
+func (r *mongoRepository) ReplacePassword(ctx context.Context, id string, old string, hash string) error {
+	filter := userFilter(id)
+	filter["password"] = old
+	_, err := r.users.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"password": hash}})
+	return err
+}
+
+func (r *mongoRepository) LinkGoogle(ctx context.Context, id string, googleID string) error {
+	_, err := r.users.UpdateOne(ctx, userFilter(id), bson.M{"$set": bson.M{"google_id": googleID}})
+	return err
+}
+
+func (r *mongoRepository) LinkApple(ctx context.Context, id string, appleID string) error {
+	_, err := r.users.UpdateOne(ctx, userFilter(id), bson.M{"$set": bson.M{"apple_id": appleID}})
+	return err
+}
+
+func (r *mongoRepository) LinkPhone(ctx context.Context, id string, phone string) error {
+	_, err := r.users.UpdateOne(ctx, userFilter(id), bson.M{"$set": bson.M{"phone": phone, "verified_phone": phone}})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrPhoneTaken
+	}
+	return err
+}
+
+func (r *mongoRepository) MarkEmailVerified(ctx context.Context, id string, email string) error {
+	filter := userFilter(id)
+	filter["email"] = email
+	result, err := r.users.UpdateOne(ctx, softdelete.Filter(filter), bson.M{"$set": bson.M{"email_verified": true}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoRepository) DeleteAccount(ctx context.Context, id string, at time.Time) error {
+	filter := userFilter(id)
+	return xmongo.WithTransaction(ctx, r.users, func(sc mongo.SessionContext) error {
+		// bumping count revokes tokens issued before sessions too
+		result, err := r.users.UpdateOne(sc, softdelete.Filter(filter), bson.M{
+			"$set": bson.M{softdelete.Field: at},
+			"$inc": bson.M{"count": 1},
+		})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+		if _, err := r.users.UpdateMany(sc, bson.M{"friends": filter["_id"]}, bson.M{"$pull": bson.M{"friends": filter["_id"]}}); err != nil {
+			return err
+		}
+		if _, err := r.activity.DeleteMany(sc, bson.M{"user": filter["_id"]}); err != nil {
+			return err
+		}
+		_, err = r.sessions.DeleteMany(sc, bson.M{"user": filter["_id"]})
+		return err
+	})
+}
+
+// RestoreAccount puts the user back in their friends' friends; the deleted activity stays deleted
+func (r *mongoRepository) RestoreAccount(ctx context.Context, id string, deletedAt time.Time) error {
+	filter := userFilter(id)
+	filter[softdelete.Field] = deletedAt
+	return xmongo.WithTransaction(ctx, r.users, func(sc mongo.SessionContext) error {
+		var user struct {
+			ID      primitive.ObjectID   `bson:"_id"`
+			Friends []primitive.ObjectID `bson:"friends"`
+		}
+		err := r.users.FindOneAndUpdate(sc, filter, bson.M{"$unset": bson.M{softdelete.Field: ""}},
+			options.FindOneAndUpdate().SetProjection(bson.M{"friends": 1}),
+		).Decode(&user)
+		if err != nil {
+			return err
+		}
+		if len(user.Friends) == 0 {
+			return nil
+		}
+		_, err = r.users.UpdateMany(sc,
+			softdelete.Filter(bson.M{"_id": bson.M{"$in": user.Friends}}),
+			bson.M{"$addToSet": bson.M{"friends": user.ID}},
+		)
+		return err
+	})
+}
+
+func (r *mongoRepository) CreateSession(ctx context.Context, session Session) error {
+	_, err := r.sessions.DeleteOne(ctx, bson.M{"user": session.User, "device_id": session.DeviceID})
+	if err != nil {
+		return err
+	}
+	_, err = r.sessions.InsertOne(ctx, session)
+	return err
+}
+
+func (r *mongoRepository) FindSession(ctx context.Context, id string) (*Session, error) {
+	var session Session
+	// expired sessions linger until the TTL monitor gets to them
+	filter := userFilter(id)
+	filter["expires_at"] = bson.M{"$gt": time.Now()}
+	if err := r.sessions.FindOne(ctx, filter).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *mongoRepository) RotateSession(ctx context.Context, id string, old string, hash string, seen time.Time, expires time.Time) error {
+	filter := userFilter(id)
+	filter["refresh_hash"] = old
+	result, err := r.sessions.UpdateOne(ctx, filter, bson.M{"$set": bson.M{
+		"refresh_hash":  hash,
+		"previous_hash": old,
+		"rotated_at":    seen,
+		"last_seen_at":  seen,
+		"expires_at":    expires,
+	}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoRepository) ListSessions(ctx context.Context, userID string) ([]Session, error) {
+	owner := userFilter(userID)
+	cursor, err := r.sessions.Find(ctx,
+		bson.M{"user": owner["_id"], "expires_at": bson.M{"$gt": time.Now()}},
+		options.Find().SetSort(bson.M{"last_seen_at": -1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]Session, 0)
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *mongoRepository) DeleteSession(ctx context.Context, userID string, id string) error {
+	filter := userFilter(id)
+	filter["user"] = userFilter(userID)["_id"]
+	result, err := r.sessions.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+/*
+Token claims carry the user id as a hex string while documents are keyed by
+ObjectID, so lookups by claim have to convert it first
+*/
+func userFilter(id string) bson.M {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		// matches nothing
+		return bson.M{"_id": id}
+	}
+	return bson.M{"_id": oid}
+}
+
+func (r *mongoRepository) FindPhoneCode(ctx context.Context, phone string) (*PhoneCode, error) {
+	var code PhoneCode
+	if err := r.phoneCodes.FindOne(ctx, bson.M{"_id": phone}).Decode(&code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *mongoRepository) SavePhoneCode(ctx context.Context, code PhoneCode) error {
+	_, err := r.phoneCodes.ReplaceOne(ctx, bson.M{"_id": code.Phone}, code, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (r *mongoRepository) AttemptPhoneCode(ctx context.Context, phone string, max int, now time.Time) (*PhoneCode, error) {
+	var code PhoneCode
+	err := r.phoneCodes.FindOneAndUpdate(ctx,
+		bson.M{"_id": phone, "expires_at": bson.M{"$gt": now}, "attempts": bson.M{"$lt": max}},
+		bson.M{"$inc": bson.M{"attempts": 1}},
+	).Decode(&code)
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// SpendPhoneCode keeps the document, and so the send count, until the window ends
+func (r *mongoRepository) SpendPhoneCode(ctx context.Context, phone string, hash string) error {
+	result, err := r.phoneCodes.UpdateOne(ctx, bson.M{"_id": phone, "hash": hash}, bson.M{"$set": bson.M{"hash": ""}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoRepository) RecordEvent(ctx context.Context, event audit.Event) error {
+	return audit.Record(ctx, r.audit, event)
+}
+
+func (r *mongoRepository) ListEvents(ctx context.Context, userID string, after primitive.ObjectID, limit int) ([]audit.Event, error) {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	return audit.List(ctx, r.audit, user, after, limit)
+}
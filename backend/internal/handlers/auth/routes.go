@@ -1,9 +1,11 @@
 package auth
 
 import (
-	"log"
-
+	"github.com/abhikaboy/SocialToDo/internal/audit"
 	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xtokens"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -11,11 +13,7 @@ import (
 /*
 Router maps endpoints to handlers
 */
-func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
-	cfg, err := config.Load()
-	if err != nil {
-		log.Fatalf("Failed to load configuration: %v", err)
-	}
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cfg config.Config) {
 	service := newService(collections, cfg)
 	handler := Handler{service, cfg}
 
@@ -23,9 +21,66 @@ func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
 
 	route.Post("/login", handler.Login)
 	route.Post("/register", handler.Register)
+	route.Post("/login/google", handler.LoginWithGoogle)
+	route.Post("/register/google", handler.RegisterWithGoogle)
+	route.Post("/login/apple", handler.LoginWithApple)
+	route.Post("/register/apple", handler.RegisterWithApple)
+	route.Post("/otp/request", handler.RequestPhoneCode)
+	route.Post("/otp/verify", handler.VerifyPhoneCode)
+	route.Post("/phone", handler.AuthenticateMiddleware, handler.LinkPhone)
 	route.Post("/logout", handler.Logout)
+	route.Post("/refresh", handler.RefreshTokens)
+	route.Get("/verify", handler.VerifyEmail)
+	route.Post("/verify/resend", handler.AuthenticateMiddleware, handler.ResendVerification)
+
+	route.Delete("/account", handler.AuthenticateMiddleware, handler.DeleteAccount)
+	route.Post("/account/restore", handler.RestoreAccount)
+
+	Sessions := route.Group("/sessions")
+	Sessions.Use(handler.AuthenticateMiddleware)
+	Sessions.Get("/", handler.ListSessions)
+	Sessions.Delete("/:id", handler.RevokeSession)
+
+	app.Get("/.well-known/jwks.json", handler.JWKS)
+
+	// asked while picking a handle, before there is an account
+	app.Get("/api/v1/users/handle-available", handler.HandleAvailable)
+	app.Get("/api/v1/users/me/security-events", handler.AuthenticateMiddleware, handler.ListSecurityEvents)
 
 	api := app.Group("/protected")
 	api.Use(handler.AuthenticateMiddleware)
 	api.Get("/", handler.Test)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/auth/login":              {Summary: "Sign in with email and password", Request: LoginRequest{}, Response: TokenResponse{}},
+		"POST /api/v1/auth/register":           {Summary: "Create an account with email and password", Request: RegisterRequest{}, Response: TokenResponse{}},
+		"POST /api/v1/auth/login/google":       {Summary: "Sign in with a Google ID token", Request: LoginRequestGoogle{}, Response: TokenResponse{}},
+		"POST /api/v1/auth/register/google":    {Summary: "Sign in with a Google ID token, creating the account if needed", Request: RegisterRequestGoogle{}, Response: TokenResponse{}, Status: fiber.StatusCreated},
+		"POST /api/v1/auth/login/apple":        {Summary: "Sign in with an Apple identity token", Request: LoginRequestApple{}, Response: TokenResponse{}},
+		"POST /api/v1/auth/register/apple":     {Summary: "Sign in with an Apple identity token, creating the account if needed", Request: RegisterRequestApple{}, Response: TokenResponse{}, Status: fiber.StatusCreated},
+		"POST /api/v1/auth/otp/request":        {Summary: "Text a sign-in code to a phone number", Request: PhoneCodeRequest{}, Status: fiber.StatusAccepted},
+		"POST /api/v1/auth/otp/verify":         {Summary: "Sign in with a texted code, creating the account if the number is new", Request: PhoneVerifyRequest{}, Response: TokenResponse{}},
+		"POST /api/v1/auth/phone":              {Summary: "Sign in with a phone number from now on, proven by a texted code", Auth: true, Request: PhoneVerifyRequest{}, Response: Profile{}},
+		"POST /api/v1/auth/logout":             {Summary: "Sign this device out", Auth: true, Response: "", ResponseType: fiber.MIMETextPlain},
+		"POST /api/v1/auth/refresh":            {Summary: "Trade the refresh_token header for a new pair of tokens", Response: TokenResponse{}},
+		"GET /api/v1/auth/verify":              {Summary: "Confirm an email address from the emailed link, redirecting to the web app", Query: xopenapi.Query{"token"}, Status: fiber.StatusSeeOther},
+		"POST /api/v1/auth/verify/resend":      {Summary: "Email another verification link", Auth: true, Status: fiber.StatusAccepted},
+		"DELETE /api/v1/auth/account":          {Summary: "Delete the caller's account, restorable until it is purged", Auth: true, Response: fiber.Map{}},
+		"POST /api/v1/auth/account/restore":    {Summary: "Restore a deleted account from the emailed link", Request: RestoreAccountRequest{}, Response: fiber.Map{}},
+		"GET /api/v1/auth/sessions/":           {Summary: "The caller's signed-in devices", Auth: true, Response: []Session{}},
+		"DELETE /api/v1/auth/sessions/:id":     {Summary: "Sign a device out", Auth: true, Status: fiber.StatusNoContent},
+		"GET /.well-known/jwks.json":           {Summary: "The public keys access and refresh tokens are signed with", Response: xtokens.JWKS{}},
+		"GET /api/v1/users/handle-available":   {Summary: "Whether a handle is free to claim", Query: xopenapi.Query{"handle"}, Response: fiber.Map{}},
+		"GET /api/v1/users/me/security-events": {Summary: "The caller's sign-ins, refreshes and other account events, newest first", Auth: true, Query: xpage.Query{}, Response: xpage.Page[audit.Event]{}},
+		"GET /protected/":                      {Summary: "Check a token", Auth: true, Response: "", ResponseType: fiber.MIMETextPlain},
+	})
+}
+
+/*
+Middleware returns the authentication middleware for routes registered by
+other packages
+*/
+func Middleware(collections map[string]*mongo.Collection, cfg config.Config) fiber.Handler {
+	handler := Handler{newService(collections, cfg), cfg}
+	return handler.AuthenticateMiddleware
 }
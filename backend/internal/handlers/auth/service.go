@@ -2,148 +2,242 @@ package auth
 
 import (
 	"context"
+	"log/slog"
 	"time"
 
 	"errors"
 
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	categories "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/abhikaboy/SocialToDo/internal/xtokens"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrSuspended is returned on login to accounts suspended from the admin API
+var ErrSuspended = fiber.NewError(fiber.StatusForbidden, "Account suspended")
+
 /*
 Health Service to be used by Health Handler to interact with the
 Database layer of the application
 */
 
-func (s *Service) GenerateToken(id string, exp int64, count float64) (string, error) {
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256,
-		jwt.MapClaims{
-			"iss":     "dev-server",
-			"sub":     "",
-			"user_id": id,
-			"role":    "user",
-			"iat":     time.Now().Unix(),
-			"exp":     exp,
-			"count":   count,
-		})
-	// configure to use config in /internal/config/config.go
-	return t.SignedString([]byte(s.config.Auth.Secret))
+func (s *Service) GenerateToken(id string, session string, typ string, exp int64, count float64) (string, error) {
+	return s.tokens.Sign(jwt.MapClaims{
+		"iss":     "dev-server",
+		"sub":     "",
+		"user_id": id,
+		"sid":     session,
+		// access or refresh, so neither can stand in for the other
+		"typ": typ,
+		// two tokens made in the same second would otherwise be the same token
+		"jti":   primitive.NewObjectID().Hex(),
+		"role":  "user",
+		"iat":   time.Now().Unix(),
+		"exp":   exp,
+		"count": count,
+	})
+}
+
+func (s *Service) GenerateAccessToken(id string, session string, count float64) (string, error) {
+	return s.GenerateToken(id, session, accessType, time.Now().Add(s.tokens.AccessTTL).Unix(), count)
 }
 
-func (s *Service) GenerateAccessToken(id string, count float64) (string, error) {
-	return s.GenerateToken(id, time.Now().Add(time.Hour*1).Unix(), count)
+// JWKS is the keyset's public keys, none while tokens are signed with a shared secret
+func (s *Service) JWKS() xtokens.JWKS {
+	return s.tokens.JWKS()
 }
 
-func (s *Service) GetUserCount(id string) (float64, error) {
-	var user User
-	err := s.users.FindOne(context.Background(), bson.M{"_id": id}).Decode(&user)
+func (s *Service) GetUserCount(ctx context.Context, id string) (float64, error) {
+	user, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return 0, err
 	}
 	return user.Count, nil
 }
 
-func (s *Service) ValidateToken(token string) (string, float64, error) {
-	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fiber.NewError(400, "Not Authorized")
-		}
-		return []byte(s.config.Auth.Secret), nil
-	})
-
+// ValidateToken checks the token's signature, expiry, count and session
+func (s *Service) ValidateToken(ctx context.Context, token string) (string, float64, error) {
+	claims, err := s.Authenticate(ctx, token)
 	if err != nil {
 		return "", 0, err
 	}
-	claims, ok := t.Claims.(jwt.MapClaims)
-	// count matches the count in the database
-	db_count, err := s.GetUserCount(claims["user_id"].(string))
+	return claims.UserID, claims.Count, nil
+}
+
+/*
+Authenticate is ValidateToken, also telling which session the token belongs
+to. Only access tokens are accepted; tokens from before the typ claim are
+access tokens for as long as one lasts.
+*/
+func (s *Service) Authenticate(ctx context.Context, token string) (TokenClaims, error) {
+	claims, err := s.parseToken(ctx, token)
 	if err != nil {
-		return "", 0, err
+		return TokenClaims{}, err
 	}
-	if claims["count"].(float64) != db_count {
-		return "", 0, fiber.NewError(400, "Not Authorized, Revoked Token")
+	if claims.Type == refreshType {
+		return TokenClaims{}, ErrInvalidToken
 	}
-
-	if !ok || !t.Valid {
-		return claims["user_id"].(string), 0, fiber.NewError(400, "Not Authorized, Invalid Token")
+	// tokens from before sessions have none and stay valid until they expire
+	if claims.Session != "" {
+		if _, err := s.repo.FindSession(ctx, claims.Session); errors.Is(err, mongo.ErrNoDocuments) {
+			return TokenClaims{}, ErrSessionRevoked
+		} else if err != nil {
+			return TokenClaims{}, err
+		}
 	}
-	return claims["user_id"].(string), claims["count"].(float64), nil
+	return claims, nil
 }
 
-func (s *Service) LoginFromCredentials(email string, password string) (primitive.ObjectID, float64, error) {
-
-	var user User
-	err := s.users.FindOne(context.Background(), bson.M{"email": email}).Decode(&user)
-	if errors.Is(err, mongo.ErrNoDocuments) {
-		return primitive.NewObjectID(), 0, fiber.NewError(404, "Account does not exist")
+// parseToken checks the signature and expiry, and that the count matches the one in the database,
+// picking up the user's roles along the way
+func (s *Service) parseToken(ctx context.Context, token string) (TokenClaims, error) {
+	mapClaims := jwt.MapClaims{}
+	t, err := s.tokens.Parse(token, mapClaims)
+	if err != nil {
+		return TokenClaims{}, err
 	}
+	if !t.Valid {
+		return TokenClaims{}, ErrInvalidToken
+	}
+	var claims TokenClaims
+	claims.UserID, _ = mapClaims["user_id"].(string)
+	claims.Count, _ = mapClaims["count"].(float64)
+	claims.Session, _ = mapClaims["sid"].(string)
+	claims.Type, _ = mapClaims["typ"].(string)
+
+	user, err := s.repo.FindByID(ctx, claims.UserID)
 	if err != nil {
-		return primitive.NewObjectID(), 0, err
+		return TokenClaims{}, err
 	}
-	if user.Password != password {
-		return primitive.NewObjectID(), 0, fiber.NewError(400, "Not Authorized, Invalid Credentials")
+	if claims.Count != user.Count {
+		return TokenClaims{}, ErrSessionRevoked
 	}
-	return user.ID, user.Count, nil
+	claims.Roles = user.Roles
+	return claims, nil
 }
 
-func (s *Service) LoginFromApple(apple_id string) (primitive.ObjectID, float64, error) {
+func (s *Service) LoginFromCredentials(ctx context.Context, email string, password string, device Device) (_ primitive.ObjectID, _ float64, err error) {
+	defer xmetrics.Track("auth", "LoginFromCredentials")(&err)
 
-	var user User
-	err := s.users.FindOne(context.Background(), bson.M{"apple_id": apple_id}).Decode(&user)
+	user, err := s.repo.FindByEmail(ctx, email)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return primitive.NewObjectID(), 0, fiber.NewError(404, "Account does not exist")
 	}
 	if err != nil {
 		return primitive.NewObjectID(), 0, err
 	}
+	match, rehash := checkPassword(user.Password, password, s.config.Auth.PasswordCost)
+	if !match {
+		s.record(ctx, user.ID, audit.LoginFailed, audit.Password, device)
+		return primitive.NewObjectID(), 0, ErrCredentials
+	}
+	if user.SuspendedAt != nil {
+		return primitive.NewObjectID(), 0, ErrSuspended
+	}
+	if rehash {
+		// the login stands either way, the next one tries again
+		if err := s.rehash(ctx, user, password); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to rehash password", slog.String("user_id", user.ID.Hex()), xslog.Error(err))
+		}
+	}
 	return user.ID, user.Count, nil
 }
 
-func (s *Service) InvalidateTokens(user_id string) error {
+// rehash replaces a plaintext or outdated hash, unless the password changed since it was read
+func (s *Service) rehash(ctx context.Context, user *User, password string) error {
+	hash, err := s.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return s.repo.ReplacePassword(ctx, user.ID.Hex(), user.Password, hash)
+}
+
+// HashPassword hashes password at the configured cost
+func (s *Service) HashPassword(password string) (string, error) {
+	return HashPassword(password, s.config.Auth.PasswordCost)
+}
+
+func (s *Service) InvalidateTokens(ctx context.Context, user_id string) error {
 	// increase the count by one
-	_, err := s.users.UpdateOne(context.Background(), bson.M{"_id": user_id}, bson.M{"$inc": bson.M{"count": 1}})
-	return err
+	return s.repo.IncrementCount(ctx, user_id)
 }
 
-func (s *Service) GenerateRefreshToken(id string, count float64) (string, error) {
-	const toMonth = 24 * 7 * 30
-	return s.GenerateToken(id, time.Now().Add(time.Hour*toMonth).Unix(), count)
+func (s *Service) GenerateRefreshToken(id string, session string, count float64) (string, error) {
+	return s.GenerateToken(id, session, refreshType, time.Now().Add(s.tokens.RefreshTTL).Unix(), count)
 }
 
-func (s *Service) UseToken(user_id string) error {
-	_, err := s.users.UpdateOne(context.Background(), bson.M{"_id": user_id}, bson.M{"$set": bson.M{"token_used": true}})
-	return err
+func (s *Service) UseToken(ctx context.Context, user_id string) error {
+	return s.repo.SetTokenUsed(ctx, user_id)
 }
 
-func (s *Service) CheckIfTokenUsed(user_id string) (bool, error) {
-	var user User
-	err := s.users.FindOne(context.Background(), bson.M{"_id": user_id}).Decode(&user)
+func (s *Service) CheckIfTokenUsed(ctx context.Context, user_id string) (bool, error) {
+	user, err := s.repo.FindByID(ctx, user_id)
 	if err != nil {
 		return false, err
 	}
 	return user.TokenUsed, nil
 }
 
-func (s *Service) GenerateTokens(id string, count float64) (string, string, error) {
-	access, err := s.GenerateAccessToken(id, count)
+func (s *Service) GenerateTokens(id string, session string, count float64) (string, string, error) {
+	access, err := s.GenerateAccessToken(id, session, count)
 	if err != nil {
 		return "", "", err
 	}
-	refresh, err := s.GenerateRefreshToken(id, count)
+	refresh, err := s.GenerateRefreshToken(id, session, count)
 	if err != nil {
 		return "", "", err
 	}
 	return access, refresh, err
 }
 
+// Profile returns the user's public profile and email
+func (s *Service) Profile(ctx context.Context, id string) (*Profile, error) {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{
+		ID:             user.ID,
+		Email:          user.Email,
+		DisplayName:    user.DisplayName,
+		Handle:         user.Handle,
+		ProfilePicture: user.ProfilePicture,
+		EmailVerified:  user.EmailVerified == nil || *user.EmailVerified,
+		Phone:          user.VerifiedPhone,
+	}, nil
+}
+
+// newAccount is a user with the defaults every way of signing up starts from
+func newAccount(id primitive.ObjectID, email string) User {
+	return User{
+		ID:    id,
+		Email: email,
+
+		Categories:     make([]categories.CategoryDocument, 0),
+		Friends:        make([]primitive.ObjectID, 0),
+		RecentActivity: make([]activity.ActivityDocument, 0),
+
+		DisplayName: "Default Username",
+		// handles are unique, derive a placeholder from the id until the user picks one
+		Handle: "@user" + id.Hex(),
+		// none until they upload one (POST /api/v1/users/me/picture), clients draw a placeholder
+		ProfilePicture: "",
+	}
+}
+
 /*
 	Create a new user in the database
 */
 
-func (s *Service) CreateUser(user User) error {
-	_, err := s.users.InsertOne(context.Background(), user)
-	return err
+func (s *Service) CreateUser(ctx context.Context, user User) (err error) {
+	defer xmetrics.Track("auth", "CreateUser")(&err)
+
+	return s.repo.Create(ctx, user)
 }
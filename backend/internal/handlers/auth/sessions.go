@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DeviceIDHeader names the device a login comes from; clients keep it across logins
+	DeviceIDHeader = "X-Device-ID"
+
+	// how long the refresh token a session just rotated away from is still let through, for
+	// requests racing the one that refreshed
+	rotationGrace = 30 * time.Second
+	maxUserAgent  = 256
+
+	// the typ claim
+	accessType  = "access"
+	refreshType = "refresh"
+)
+
+var (
+	ErrNoTokens       = xerr.New(fiber.StatusUnauthorized, xerr.CodeAuthRequired, "Not Authorized, Tokens not passed")
+	ErrTokenFormat    = xerr.New(fiber.StatusUnauthorized, xerr.CodeAuthRequired, "Not Authorized, expected a Bearer token")
+	ErrInvalidToken   = xerr.New(fiber.StatusUnauthorized, xerr.CodeAuthInvalid, "Not Authorized, Invalid Token")
+	ErrExpired        = xerr.New(fiber.StatusUnauthorized, xerr.CodeAuthExpired, "Not Authorized: Access and Refresh Tokens are Expired")
+	ErrSessionRevoked = xerr.New(fiber.StatusUnauthorized, xerr.CodeSessionRevoked, "Not Authorized, Session Revoked")
+	ErrTokenReuse     = xerr.New(fiber.StatusUnauthorized, xerr.CodeTokenReuse, "Not Authorized, Token Reuse Detected")
+	ErrCredentials    = xerr.New(fiber.StatusUnauthorized, xerr.CodeAuthInvalid, "Not Authorized, Invalid Credentials")
+)
+
+// TokenClaims is what a valid token says about its user
+type TokenClaims struct {
+	UserID  string
+	Count   float64
+	Session string
+	// accessType or refreshType, empty for tokens from before the claim
+	Type string
+	// read from the user on every check, so a role change applies to the next request
+	Roles []string
+}
+
+/*
+StartSession signs the device in, replacing any session it already had, and
+returns its first pair of tokens. The sign-in is audited as a login by method,
+unless that's empty for a session a refresh started.
+*/
+func (s *Service) StartSession(ctx context.Context, userID string, count float64, method audit.Method, device Device) (string, string, error) {
+	user, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	session := Session{
+		ID:         primitive.NewObjectID(),
+		User:       user,
+		DeviceID:   device.ID,
+		UserAgent:  device.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.tokens.RefreshTTL),
+	}
+	if session.DeviceID == "" {
+		// clients that don't say which device they are get a session per login
+		session.DeviceID = primitive.NewObjectID().Hex()
+	}
+	if len(session.UserAgent) > maxUserAgent {
+		session.UserAgent = session.UserAgent[:maxUserAgent]
+	}
+
+	access, refresh, err := s.GenerateTokens(userID, session.ID.Hex(), count)
+	if err != nil {
+		return "", "", err
+	}
+	session.RefreshHash = hashToken(refresh)
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return "", "", err
+	}
+	if method != "" {
+		s.record(ctx, user, audit.Login, method, device)
+	}
+	return access, refresh, nil
+}
+
+/*
+Refresh trades a refresh token for a new pair and rotates its session. A
+refresh token the session already rotated away from ends the session, unless
+it comes back within rotationGrace, in which case the request is let through
+without new tokens since the client has them from the refresh it raced.
+Access tokens are refused without touching the session.
+*/
+func (s *Service) Refresh(ctx context.Context, token string, device Device) (TokenClaims, string, string, error) {
+	claims, err := s.parseToken(ctx, token)
+	if err != nil {
+		return TokenClaims{}, "", "", err
+	}
+	if claims.Type == accessType {
+		return TokenClaims{}, "", "", ErrInvalidToken
+	}
+	if claims.Session == "" {
+		return s.refreshLegacy(ctx, claims, device)
+	}
+
+	session, err := s.repo.FindSession(ctx, claims.Session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return TokenClaims{}, "", "", ErrSessionRevoked
+	}
+	if err != nil {
+		return TokenClaims{}, "", "", err
+	}
+
+	hash := hashToken(token)
+	switch {
+	case hash == session.RefreshHash:
+	case hash == session.PreviousHash && session.RotatedAt != nil && time.Since(*session.RotatedAt) < rotationGrace:
+		return claims, "", "", nil
+	case claims.Type == "":
+		// from before the typ claim, it may be an access token that was never this session's refresh token
+		return TokenClaims{}, "", "", ErrInvalidToken
+	default:
+		if err := s.repo.DeleteSession(ctx, claims.UserID, claims.Session); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return TokenClaims{}, "", "", err
+		}
+		s.recordOf(ctx, claims.UserID, audit.TokenReuse, device)
+		return TokenClaims{}, "", "", ErrTokenReuse
+	}
+
+	access, refresh, err := s.GenerateTokens(claims.UserID, claims.Session, claims.Count)
+	if err != nil {
+		return TokenClaims{}, "", "", err
+	}
+	now := time.Now()
+	err = s.repo.RotateSession(ctx, claims.Session, hash, hashToken(refresh), now, now.Add(s.tokens.RefreshTTL))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// a concurrent refresh rotated it first
+		return claims, "", "", nil
+	}
+	if err != nil {
+		return TokenClaims{}, "", "", err
+	}
+	s.recordOf(ctx, claims.UserID, audit.TokenRefreshed, device)
+	return claims, access, refresh, nil
+}
+
+// refreshLegacy lets a refresh token from before sessions refresh once more, into a session of its own
+func (s *Service) refreshLegacy(ctx context.Context, claims TokenClaims, device Device) (TokenClaims, string, string, error) {
+	used, err := s.CheckIfTokenUsed(ctx, claims.UserID)
+	if err != nil {
+		return TokenClaims{}, "", "", err
+	}
+	if used {
+		s.recordOf(ctx, claims.UserID, audit.TokenReuse, device)
+		return TokenClaims{}, "", "", ErrTokenReuse
+	}
+	if err := s.UseToken(ctx, claims.UserID); err != nil {
+		return TokenClaims{}, "", "", err
+	}
+	access, refresh, err := s.StartSession(ctx, claims.UserID, claims.Count, "", device)
+	if err != nil {
+		return TokenClaims{}, "", "", err
+	}
+	s.recordOf(ctx, claims.UserID, audit.TokenRefreshed, device)
+	// the new pair is what carries the session, the caller reads it from there next time
+	return claims, access, refresh, nil
+}
+
+// ListSessions returns the user's signed-in devices, most recently used first, marking current
+func (s *Service) ListSessions(ctx context.Context, userID string, current string) ([]Session, error) {
+	sessions, err := s.repo.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.Hex() == current
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs the device out; its tokens stop working on their next use
+func (s *Service) RevokeSession(ctx context.Context, userID string, id string) error {
+	return s.repo.DeleteSession(ctx, userID, id)
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
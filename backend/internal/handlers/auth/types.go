@@ -1,7 +1,16 @@
 package auth
 
 import (
+	"context"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/audit"
 	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xtokens"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 
@@ -10,71 +19,198 @@ import (
 )
 
 type Service struct {
-	users  *mongo.Collection
+	repo   Repository
 	config config.Config
+	// verification emails go out through it; nil sends none
+	queue *jobs.Queue
+	// signs and checks access and refresh tokens
+	tokens *xtokens.Keyset
 }
 
 func newService(collections map[string]*mongo.Collection, config config.Config) *Service {
-	return &Service{collections["users"], config}
+	return &Service{NewMongoRepository(collections), config, jobs.New(collections[jobs.Collection]), xtokens.New(config.Tokens, config.Auth.Secret)}
+}
+
+// NewService builds the auth service for callers outside this package (the internal RPC server)
+func NewService(collections map[string]*mongo.Collection, config config.Config) *Service {
+	return newService(collections, config)
 }
 
+// NewServiceWithRepository builds the service on any store, e.g. NewMemoryRepository in tests
+func NewServiceWithRepository(repo Repository, config config.Config) *Service {
+	return &Service{repo: repo, config: config, tokens: xtokens.New(config.Tokens, config.Auth.Secret)}
+}
+
+/*
+Accounts is what Handler needs from the service. *Service implements it on
+the database; handler tests can wrap one built with NewServiceWithRepository,
+or stub the odd method whose failure is easier to fake than to cause.
+*/
+type Accounts interface {
+	LoginFromCredentials(ctx context.Context, email string, password string, device Device) (primitive.ObjectID, float64, error)
+	LoginFromGoogle(ctx context.Context, idToken string) (primitive.ObjectID, float64, bool, error)
+	LoginFromApple(ctx context.Context, identityToken string) (primitive.ObjectID, float64, bool, error)
+	RequestPhoneCode(ctx context.Context, phone string) error
+	LoginFromPhone(ctx context.Context, phone string, code string, device Device) (primitive.ObjectID, float64, bool, error)
+	LinkPhone(ctx context.Context, userID string, phone string, code string, device Device) error
+	HashPassword(password string) (string, error)
+	CreateUser(ctx context.Context, user User) error
+	HandleAvailable(ctx context.Context, raw string) (string, bool, error)
+	Profile(ctx context.Context, id string) (*Profile, error)
+
+	StartSession(ctx context.Context, userID string, count float64, method audit.Method, device Device) (string, string, error)
+	Refresh(ctx context.Context, token string, device Device) (TokenClaims, string, string, error)
+	ListSessions(ctx context.Context, userID string, current string) ([]Session, error)
+	RevokeSession(ctx context.Context, userID string, id string) error
+	InvalidateTokens(ctx context.Context, userID string) error
+	Authenticate(ctx context.Context, token string) (TokenClaims, error)
+	JWKS() xtokens.JWKS
+
+	SendVerification(ctx context.Context, id primitive.ObjectID, email string) error
+	ResendVerification(ctx context.Context, id string) error
+	VerifyEmail(ctx context.Context, token string) error
+	DeleteAccount(ctx context.Context, id string, device Device) (time.Time, error)
+	RestoreAccount(ctx context.Context, token string) error
+
+	SecurityEvents(ctx context.Context, userID string, query xpage.Query) (*xpage.Page[audit.Event], error)
+}
+
+var _ Accounts = (*Service)(nil)
+
 type Handler struct {
-	service *Service
+	service Accounts
 	config  config.Config
 }
 
+// TokenResponse is the body of every response that signs a device in or refreshes its tokens
 type TokenResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	User         string `json:"user"`
+	AccessToken  string   `json:"access_token"`
+	RefreshToken string   `json:"refresh_token"`
+	User         *Profile `json:"user"`
+	// kept from the bodies register answered with before
+	Message string `json:"message,omitempty"`
+}
+
+// Profile is the signed-in user, so clients don't need another request after signing in
+type Profile struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	Email          string             `bson:"email" json:"email"`
+	DisplayName    string             `bson:"display_name" json:"display_name"`
+	Handle         string             `bson:"handle" json:"handle"`
+	ProfilePicture string             `bson:"profile_picture" json:"profile_picture"`
+	EmailVerified  bool               `bson:"-" json:"email_verified"`
+	// the number the user signs in with, if they proved one
+	Phone string `bson:"-" json:"phone,omitempty"`
 }
 
 type User struct {
 	ID           primitive.ObjectID  `bson:"_id"`
-	Email        string  `bson:"email"`
+	// none for accounts made by phone sign-in
+	Email        string  `bson:"email,omitempty"`
 	Phone        string  `bson:"phone"`
+	// the number the user signs in with by texted code, set once they proved it
+	VerifiedPhone string `bson:"verified_phone,omitempty"`
 	Password     string  `bson:"password"`
+	// false until the emailed link is followed; accounts from before verification have none and count as verified
+	EmailVerified *bool `bson:"email_verified,omitempty"`
 	AppleID      string  `bson:"apple_id,omitempty"`
 	GoogleID     string  `bson:"google_id,omitempty"`
+	// refresh tokens from before sessions, each still good for one refresh
 	RefreshToken string  `bson:"refresh_token"`
 	TokenUsed    bool    `bson:"token_used"`
 	Count        float64 `bson:"count"`
 
 	Categories []categories.CategoryDocument `bson:"categories"`
 	Friends    []primitive.ObjectID `bson:"friends"`
+	// users this one blocked; the two can't see each other or send each other friend requests
+	BlockedUsers []primitive.ObjectID `bson:"blocked_users,omitempty"`
+	Privacy privacy.Settings `bson:"privacy"`
+	NotificationPreferences notifications.Preferences `bson:"notification_preferences"`
 	TasksComplete float64            `bson:"tasks_complete"`
 	RecentActivity []activity.ActivityDocument `bson:"recent_activity"`
 
+	// set from the admin API; suspended users can't log in
+	Roles           []string         `bson:"roles,omitempty"`
+	SuspendedAt     *time.Time       `bson:"suspended_at,omitempty"`
+	SuspendedReason string           `bson:"suspended_reason,omitempty"`
+	Quotas          map[string]int64 `bson:"quotas,omitempty"`
+	DeletedAt       *time.Time       `bson:"deleted_at,omitempty"`
+
 	DisplayName string `bson:"display_name"`
+	// what user search matches a prefix of, kept by the repository and profile updates
+	DisplayNameLower string `bson:"display_name_lower"`
 	Handle      string `bson:"handle"`
 	ProfilePicture string `bson:"profile_picture"`
 	
 }
 
+/*
+Session is one signed-in device. Its refresh token rotates on every refresh
+and only the hash of the current one is kept, so an older token coming back
+means it was copied and ends the session.
+*/
+type Session struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	User      primitive.ObjectID `bson:"user" json:"-"`
+	DeviceID  string             `bson:"device_id" json:"device_id"`
+	UserAgent string             `bson:"user_agent" json:"user_agent"`
+	// sha256 of the current refresh token, and of the one it replaced
+	RefreshHash  string     `bson:"refresh_hash" json:"-"`
+	PreviousHash string     `bson:"previous_hash,omitempty" json:"-"`
+	RotatedAt    *time.Time `bson:"rotated_at,omitempty" json:"-"`
+	CreatedAt    time.Time  `bson:"created_at" json:"created_at"`
+	LastSeenAt   time.Time  `bson:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt    time.Time  `bson:"expires_at" json:"expires_at"`
+	// the session the listing was requested from
+	Current bool `bson:"-" json:"current"`
+}
+
+// Device identifies where a session was started from
+type Device struct {
+	ID        string
+	UserAgent string
+	IP        string
+}
+
+func (d Device) client() audit.Client {
+	return audit.Client{IP: d.IP, UserAgent: d.UserAgent}
+}
+
 type LoginRequest struct {
 	Email    string `validate:"required,email" json:"email"`
 	Password string `validate:"required,min=8" json:"password"`
 }
 
+// LoginRequestApple carries the identityToken from Sign in with Apple, which is verified rather than trusted
 type LoginRequestApple struct {
-	AppleID string `validate:"required" json:"apple_id"`
+	IdentityToken string `validate:"required" json:"identity_token"`
 }
 
+// LoginRequestGoogle carries the ID token from Google Sign-In, which is verified rather than trusted
 type LoginRequestGoogle struct {
-	GoogleID string `validate:"required" json:"google_id"`
+	IDToken string `validate:"required" json:"id_token"`
 }
 
+// RegisterRequestApple is LoginRequestApple; the email comes from the verified token
 type RegisterRequestApple struct {
-	AppleID string `validate:"required" json:"apple_id"`
-	Email   string `validate:"required,email" json:"email"`
+	IdentityToken string `validate:"required" json:"identity_token"`
 }
 
+// RegisterRequestGoogle is LoginRequestGoogle; the email comes from the verified token
 type RegisterRequestGoogle struct {
-	GoogleID string `validate:"required" json:"google_id"`
-	Email    string `validate:"required,email" json:"email"`
+	IDToken string `validate:"required" json:"id_token"`
+}
+
+// RestoreAccountRequest carries the token from the link mailed when the account was deleted
+type RestoreAccountRequest struct {
+	Token string `validate:"required" json:"token"`
 }
 
 type RegisterRequest struct {
-	Email    string `validate:"required,email" json:"email"`
-	Password string `validate:"required,min=8" json:"password"`
+	Email string `validate:"required,email" json:"email"`
+	// bcrypt reads at most 72 bytes
+	Password string `validate:"required,min=8,maxbytes=72" json:"password"`
+	// both optional, the account starts with placeholders without them
+	Handle      string `json:"handle"`
+	DisplayName string `validate:"max=50" json:"display_name"`
 }
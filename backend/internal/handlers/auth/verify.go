@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	verifyTTL     = 48 * time.Hour
+	verifyPurpose = "verify_email"
+)
+
+var (
+	ErrInvalidVerification = errors.New("invalid or expired verification link")
+	ErrAlreadyVerified     = errors.New("email already verified")
+)
+
+/*
+SendVerification mails the user a link that verifies email. The link carries
+a JWT signed with a key derived from the auth secret, so it can't be passed
+off as an access token, and names the email so it stops working if the
+user changes it.
+*/
+func (s *Service) SendVerification(ctx context.Context, id primitive.ObjectID, email string) error {
+	if s.queue == nil {
+		return nil
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"purpose": verifyPurpose,
+		"user_id": id.Hex(),
+		"email":   email,
+		"exp":     time.Now().Add(verifyTTL).Unix(),
+	})
+	token, err := t.SignedString(s.purposeKey(verifyPurpose))
+	if err != nil {
+		return err
+	}
+	link := strings.TrimSuffix(s.config.App.PublicURL, "/") + "/api/v1/auth/verify?token=" + url.QueryEscape(token)
+	_, err = s.queue.Enqueue(ctx, VerifyEmailJob, LinkEmail{Email: email, Link: link})
+	return err
+}
+
+// ResendVerification mails a new link to a user who hasn't followed the last one
+func (s *Service) ResendVerification(ctx context.Context, id string) error {
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified == nil || *user.EmailVerified {
+		return ErrAlreadyVerified
+	}
+	return s.SendVerification(ctx, user.ID, user.Email)
+}
+
+// VerifyEmail marks the email in a link from SendVerification as verified
+func (s *Service) VerifyEmail(ctx context.Context, token string) error {
+	t, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		return s.purposeKey(verifyPurpose), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return ErrInvalidVerification
+	}
+	claims, _ := t.Claims.(jwt.MapClaims)
+	purpose, _ := claims["purpose"].(string)
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	if purpose != verifyPurpose || userID == "" || email == "" {
+		return ErrInvalidVerification
+	}
+
+	err = s.repo.MarkEmailVerified(ctx, userID, email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrInvalidVerification
+	}
+	return err
+}
+
+// purposeKey derives the key for tokens meant for one purpose, which then can't be used as any other token
+func (s *Service) purposeKey(purpose string) []byte {
+	sum := sha256.Sum256([]byte(purpose + ":" + s.config.Auth.Secret))
+	return sum[:]
+}
@@ -0,0 +1,45 @@
+package batch
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=batch.go -destination=mock_test.go -package=batch
+
+// Runner replays a batch of requests against the app
+type Runner interface {
+	Execute(c *fiber.Ctx, requests []Request) []Response
+}
+
+var _ Runner = (*Service)(nil)
+
+/*
+Handler to execute business logic for batched requests
+*/
+type Handler struct {
+	service Runner
+}
+
+/*
+Batch executes up to 20 sub-requests and returns their responses in the same
+order. The batch itself always answers 200; check each item's status.
+*/
+func (h *Handler) Batch(c *fiber.Ctx) error {
+	var params BatchParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if errs := validator.Validate(params); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	return c.JSON(fiber.Map{
+		"responses": h.service.Execute(c, params.Requests),
+	})
+}
@@ -0,0 +1,104 @@
+package batch
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestBatch(t *testing.T) {
+	app := newTestApp()
+
+	res := do(t, app, `{"requests": [
+		{"method": "POST", "path": "/api/v1/echo", "body": {"content": "Take out the trash"}},
+		{"method": "GET", "path": "/api/v1/missing"}
+	]}`)
+	if res.StatusCode != fiber.StatusOK {
+		t.Fatalf("expected 200, got %d", res.StatusCode)
+	}
+	responses := decode(t, res)
+	if len(responses) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(responses))
+	}
+	if responses[0].Status != fiber.StatusCreated || !strings.Contains(string(responses[0].Body), "Take out the trash") {
+		t.Errorf("echo: unexpected response %+v", responses[0])
+	}
+	if responses[1].Status != fiber.StatusNotFound {
+		t.Errorf("missing: expected 404, got %d", responses[1].Status)
+	}
+}
+
+func TestBatchExcludedPaths(t *testing.T) {
+	app := newTestApp()
+
+	paths := []string{
+		"/api/v1/batch",
+		"/api/v1/Batch",
+		"/api/v1/BATCH/",
+		"/api/v1//batch",
+		"/api/v1/echo/../batch",
+		"/api/v1/%62atch",
+		"/api/v1/batch?x=1",
+		"/api/v1/stream",
+		"/api/v1/Stream",
+	}
+	for _, path := range paths {
+		body, _ := gojson.Marshal(fiber.Map{"requests": []fiber.Map{{"method": "POST", "path": path, "body": fiber.Map{"requests": []fiber.Map{}}}}})
+		responses := decode(t, do(t, app, string(body)))
+		if len(responses) != 1 || responses[0].Status != fiber.StatusBadRequest {
+			t.Errorf("%s: expected the sub-request to be refused, got %+v", path, responses)
+		}
+	}
+	if excluded("/api/v1/batches") {
+		t.Error("/api/v1/batches: only the batch route itself should be refused")
+	}
+}
+
+// newTestApp mounts the batch route next to an echo route and a stream that never ends
+func newTestApp() *fiber.App {
+	app := fiber.New()
+	app.Post("/api/v1/echo", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Status(fiber.StatusCreated).Send(c.Body())
+	})
+	app.Get("/api/v1/stream", func(c *fiber.Ctx) error {
+		select {}
+	})
+	Routes(app)
+	return app
+}
+
+type testResponse struct {
+	Status int               `json:"status"`
+	Body   gojson.RawMessage `json:"body"`
+}
+
+func decode(t *testing.T, res *http.Response) []testResponse {
+	t.Helper()
+	var out struct {
+		Responses []testResponse `json:"responses"`
+	}
+	body, _ := io.ReadAll(res.Body)
+	if err := gojson.Unmarshal(body, &out); err != nil {
+		t.Fatalf("decode %s: %v", body, err)
+	}
+	return out.Responses
+}
+
+func do(t *testing.T, app *fiber.App, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
@@ -0,0 +1,22 @@
+package batch
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App) {
+	service := newService(app)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	apiV1.Post("/batch", handler.Batch)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/batch": {Summary: "Run several API requests in one round trip", Request: BatchParams{}, Response: fiber.Map{}},
+	})
+}
@@ -0,0 +1,138 @@
+package batch
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/abhikaboy/SocialToDo/internal/middleware"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// newService receives the app whose routes sub-requests are dispatched to
+func newService(app *fiber.App) *Service {
+	return &Service{app: app}
+}
+
+// headers carried over from the batch request, so sub-requests run as the caller
+var forwardedHeaders = []string{
+	fiber.HeaderAuthorization,
+	"refresh_token",
+	"X-Device-ID",
+	fiber.HeaderAcceptLanguage,
+	fiber.HeaderUserAgent,
+}
+
+// headers of sub-responses worth returning to the client
+var returnedHeaders = []string{
+	fiber.HeaderContentType,
+	fiber.HeaderETag,
+	fiber.HeaderLocation,
+	middleware.IdempotentReplayedHeader,
+}
+
+// routes that can't be answered inside a batch: streams never finish, batches can't nest
+var excludedPrefixes = []string{"/api/v1/batch", "/api/v1/stream"}
+
+/*
+excluded checks the path the router will see: fasthttp decodes and cleans
+it, and routing ignores case, so /api/v1/Batch or /api/v1/x/../%62atch are
+still the batch route.
+*/
+func excluded(path string) bool {
+	var uri fasthttp.URI
+	uri.Parse(nil, []byte(path))
+	routed := strings.ToLower(string(uri.Path()))
+	for _, prefix := range excludedPrefixes {
+		if routed == prefix || strings.HasPrefix(routed, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Execute runs the sub-requests one at a time, in order, so later requests see
+the writes of earlier ones. Each goes through the full middleware stack and
+its route's own auth, exactly like a standalone request.
+*/
+func (s *Service) Execute(c *fiber.Ctx, requests []Request) []Response {
+	s.once.Do(func() {
+		s.handler = s.app.Handler()
+	})
+
+	parentID := middleware.GetRequestID(c)
+	responses := make([]Response, 0, len(requests))
+	for i, r := range requests {
+		if excluded(r.Path) {
+			responses = append(responses, errorResponse(fiber.StatusBadRequest, r.Path+" can't be used in a batch"))
+			continue
+		}
+
+		var req fasthttp.Request
+		req.Header.SetMethod(r.Method)
+		req.SetRequestURI(r.Path)
+		req.Header.SetHost(string(c.Request().Host()))
+		for key, value := range r.Headers {
+			req.Header.Set(key, value)
+		}
+		for _, key := range forwardedHeaders {
+			if value := c.Get(key); value != "" {
+				req.Header.Set(key, value)
+			}
+		}
+		if parentID != "" {
+			req.Header.Set(middleware.RequestIDHeader, parentID+"-"+strconv.Itoa(i))
+		}
+		if len(r.Body) > 0 {
+			req.Header.SetContentType(fiber.MIMEApplicationJSON)
+			req.SetBody(r.Body)
+		}
+
+		var sub fasthttp.RequestCtx
+		sub.Init(&req, remoteAddr(c), nil)
+		s.handler(&sub)
+
+		responses = append(responses, toResponse(&sub.Response))
+
+		// the auth middleware rotates tokens when it refreshes a session; hand the latest pair back
+		for _, key := range []string{"access_token", "refresh_token"} {
+			if value := sub.Response.Header.Peek(key); len(value) > 0 {
+				c.Set(key, string(value))
+			}
+		}
+	}
+	return responses
+}
+
+func toResponse(res *fasthttp.Response) Response {
+	out := Response{Status: res.StatusCode(), Headers: map[string]string{}}
+	for _, key := range returnedHeaders {
+		if value := res.Header.Peek(key); len(value) > 0 {
+			out.Headers[key] = string(value)
+		}
+	}
+
+	body := res.Body()
+	if len(body) == 0 {
+		return out
+	}
+	if bytes.HasPrefix(res.Header.ContentType(), []byte(fiber.MIMEApplicationJSON)) && gojson.Valid(body) {
+		out.Body = gojson.RawMessage(append([]byte(nil), body...))
+	} else {
+		out.Body = string(body)
+	}
+	return out
+}
+
+func errorResponse(status int, message string) Response {
+	return Response{Status: status, Body: fiber.Map{"error": message}}
+}
+
+// remoteAddr is the client's, not a proxy's, since sub-requests don't carry the proxy header
+func remoteAddr(c *fiber.Ctx) net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP(c.IP())}
+}
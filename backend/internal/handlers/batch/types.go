@@ -0,0 +1,38 @@
+package batch
+
+import (
+	"sync"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+type Request struct {
+	Method  string            `validate:"required,oneof=GET POST PUT PATCH DELETE" json:"method"`
+	Path    string            `validate:"required,startswith=/api/v1/" json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    gojson.RawMessage `json:"body,omitempty"`
+}
+
+type BatchParams struct {
+	Requests []Request `validate:"required,min=1,max=20,dive" json:"requests"`
+}
+
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// JSON bodies are inlined, anything else is returned as a string
+	Body any `json:"body,omitempty"`
+}
+
+/*
+Batch Service replays sub-requests through the app's own router
+*/
+
+type Service struct {
+	app *fiber.App
+
+	once    sync.Once
+	handler fasthttp.RequestHandler
+}
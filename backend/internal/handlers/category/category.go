@@ -1,16 +1,45 @@
 package Category
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xetag"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xquery"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+//go:generate mockgen -source=category.go -destination=mock_test.go -package=Category
+
+// Categories is the category service as Handler uses it
+type Categories interface {
+	GetAllCategories(ctx context.Context) ([]CategoryDocument, error)
+	GetCategoriesByUser(ctx context.Context, id primitive.ObjectID) ([]CategoryDocument, error)
+	GetCategoryViewsByUser(ctx context.Context, id primitive.ObjectID, fields *xquery.Fields) ([]map[string]any, error)
+	GetCategoryByID(ctx context.Context, id primitive.ObjectID) (*CategoryDocument, error)
+	CreateCategory(ctx context.Context, r *CategoryDocument) (*CategoryDocument, error)
+	UpdatePartialCategory(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, updated UpdateCategoryDocument) (*CategoryDocument, error)
+	DeleteCategory(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, version *int64) error
+	GetSharedCategories(ctx context.Context, userId primitive.ObjectID) ([]CategoryDocument, error)
+	InviteMember(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID, role sharing.Role) (*CategoryDocument, error)
+	UpdateMember(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID, role sharing.Role) (*CategoryDocument, error)
+	RemoveMember(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID) error
+	AcceptInvite(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) (*CategoryDocument, error)
+}
+
+var _ Categories = (*Service)(nil)
+
 type Handler struct {
-	service *Service
+	service Categories
 }
 
 func (h *Handler) CreateCategory(c *fiber.Ctx) error {
@@ -28,12 +57,13 @@ func (h *Handler) CreateCategory(c *fiber.Ctx) error {
 		})
 	}
 
-	// convert the hex string to ObjectID
-	userId, err := primitive.ObjectIDFromHex(params.User)
+	userId, err := xauth.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid user ID format",
-		})
+		return err
+	}
+	// older clients still send themselves as the user
+	if params.User != "" && params.User != userId.Hex() {
+		return xauth.ErrForbidden
 	}
 
 	doc := CategoryDocument{
@@ -44,7 +74,7 @@ func (h *Handler) CreateCategory(c *fiber.Ctx) error {
 		LastEdited: time.Now(),
 	}
 
-	_, err = h.service.CreateCategory(&doc)
+	_, err = h.service.CreateCategory(c.UserContext(), &doc)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create Category",
@@ -55,7 +85,7 @@ func (h *Handler) CreateCategory(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetCategories(c *fiber.Ctx) error {
-	Categories, err := h.service.GetAllCategories()
+	Categories, err := h.service.GetAllCategories(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(err)
 	}
@@ -71,11 +101,23 @@ func (h *Handler) GetCategory(c *fiber.Ctx) error {
 		})
 	}
 
-	Category, err := h.service.GetCategoryByID(id)
+	userId, err := xauth.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(err)
+		return err
 	}
 
+	Category, err := h.service.GetCategoryByID(c.UserContext(), id)
+	if err != nil || !sharing.Allows(Category.User, Category.Members, userId, sharing.Viewer) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+
+	etag, err := xetag.Versioned(Category.Version, Category)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderETag, etag)
 	return c.JSON(Category)
 }
 
@@ -87,12 +129,66 @@ func (h *Handler) GetCategoriesByUser(c *fiber.Ctx) error {
 		})
 	}
 
-	categories, err := h.service.GetCategoriesByUser(id)
+	// ?fields=id,name skips the embedded tasks
+	fields, err := xquery.ParseFields(c.Query("fields"), CategoryDocument{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var query xpage.Query
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if err := validator.New().Struct(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Validation failed",
+		})
+	}
+
+	// a user's categories come back whole, from the cache when they can, and are paged here
+	if fields != nil {
+		views, err := h.service.GetCategoryViewsByUser(c.UserContext(), id, fields)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(err)
+		}
+		page, err := xpage.Cut(views, query, xpage.MaxLimit, viewID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.JSON(page)
+	}
+
+	categories, err := h.service.GetCategoriesByUser(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(err)
 	}
+	page, err := xpage.Cut(categories, query, xpage.MaxLimit, func(category CategoryDocument) primitive.ObjectID {
+		return category.ID
+	})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(page)
+}
 
-	return c.JSON(categories)
+// viewID is the id of a ?fields= view, a hex string once it's been through the cache
+func viewID(view map[string]any) primitive.ObjectID {
+	switch id := view["id"].(type) {
+	case primitive.ObjectID:
+		return id
+	case string:
+		parsed, _ := primitive.ObjectIDFromHex(id)
+		return parsed
+	}
+	return primitive.NilObjectID
 }
 
 func (h *Handler) UpdatePartialCategory(c *fiber.Ctx) error {
@@ -102,11 +198,14 @@ func (h *Handler) UpdatePartialCategory(c *fiber.Ctx) error {
 			"error": "Invalid ID format for CategoryId",
 		})
 	}
-	user_id, err := primitive.ObjectIDFromHex(c.Params("user"))
+	user_id, err := xauth.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid ID format for UserId",
-		})
+		return err
+	}
+
+	version, err := xetag.IfMatch(c)
+	if err != nil {
+		return err
 	}
 
 	var update UpdateCategoryDocument
@@ -115,11 +214,28 @@ func (h *Handler) UpdatePartialCategory(c *fiber.Ctx) error {
 			"error": "Invalid request body",
 		})
 	}
+	if version != nil {
+		update.Version = version
+	}
 
-	results, err := h.service.UpdatePartialCategory(user_id,id, update); 
+	var conflict *xmongo.VersionConflict
+	results, err := h.service.UpdatePartialCategory(c.UserContext(), user_id, id, update)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+	if errors.Is(err, ErrNotEditor) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.As(err, &conflict) {
+		return h.conflict(c, id, conflict)
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(err)
-	} 
+	}
 
 	return c.JSON(results)
 }
@@ -132,16 +248,48 @@ func (h *Handler) DeleteCategory(c *fiber.Ctx) error {
 		})
 	}
 	
-	user_id, err := primitive.ObjectIDFromHex(c.Params("user"))
+	user_id, err := xauth.UserID(c)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid ID format for UserId",
-		})
+		return err
 	}
 
-	if err := h.service.DeleteCategory(user_id,id); err != nil {
+	version, err := xetag.IfMatch(c)
+	if err != nil {
+		return err
+	}
+
+	var conflict *xmongo.VersionConflict
+	err = h.service.DeleteCategory(c.UserContext(), user_id, id, version)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+	if errors.As(err, &conflict) {
+		return h.conflict(c, id, conflict)
+	}
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(err)
 	}
 
 	return c.SendStatus(fiber.StatusOK)
 }
+
+/*
+conflict answers a write made against a stale version with the category as it
+is now, and its ETag, for the client to redo its change on top of
+*/
+func (h *Handler) conflict(c *fiber.Ctx, id primitive.ObjectID, conflict *xmongo.VersionConflict) error {
+	body := fiber.Map{
+		"error":   "Category was changed by another request",
+		"version": conflict.Current,
+	}
+	if current, err := h.service.GetCategoryByID(c.UserContext(), id); err == nil {
+		body["version"] = current.Version
+		body["current"] = current
+		if etag, err := xetag.Versioned(current.Version, current); err == nil {
+			c.Set(fiber.HeaderETag, etag)
+		}
+	}
+	return c.Status(fiber.StatusConflict).JSON(body)
+}
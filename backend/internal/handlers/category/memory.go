@@ -0,0 +1,219 @@
+package Category
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xquery"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRepository keeps categories in process, for handler tests and local tools
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	categories map[primitive.ObjectID][]CategoryDocument
+	friends    map[primitive.ObjectID][]primitive.ObjectID
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		categories: make(map[primitive.ObjectID][]CategoryDocument),
+		friends:    make(map[primitive.ObjectID][]primitive.ObjectID),
+	}
+}
+
+func (r *MemoryRepository) All(ctx context.Context) ([]CategoryDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := make([]CategoryDocument, 0)
+	for _, categories := range r.categories {
+		results = append(results, categories...)
+	}
+	return results, nil
+}
+
+func (r *MemoryRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]CategoryDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.categories[userID]), nil
+}
+
+func (r *MemoryRepository) ListViewsByUser(ctx context.Context, userID primitive.ObjectID, fields *xquery.Fields) ([]bson.M, error) {
+	categories, _ := r.ListByUser(ctx, userID)
+	keep := fields.Projection()
+	docs := make([]bson.M, 0, len(categories))
+	for _, category := range categories {
+		raw, err := bson.Marshal(category)
+		if err != nil {
+			return nil, err
+		}
+		var doc bson.M
+		if err := bson.Unmarshal(raw, &doc); err != nil {
+			return nil, err
+		}
+		for key := range doc {
+			if _, ok := keep[key]; !ok {
+				delete(doc, key)
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func (r *MemoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*CategoryDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, categories := range r.categories {
+		for _, category := range categories {
+			if category.ID == id {
+				return &category, nil
+			}
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *MemoryRepository) Insert(ctx context.Context, doc *CategoryDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.categories[doc.User] = append(r.categories[doc.User], *doc)
+	return nil
+}
+
+func (r *MemoryRepository) Rename(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, name string, version *int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.categories[userID] {
+		if category := &r.categories[userID][i]; category.ID == id {
+			if version != nil && *version != category.Version {
+				return &xmongo.VersionConflict{Expected: *version, Current: category.Version}
+			}
+			category.Name = name
+			category.LastEdited = at
+			category.Version++
+			return nil
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, version *int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := slices.IndexFunc(r.categories[userID], func(category CategoryDocument) bool {
+		return category.ID == id
+	})
+	if i < 0 {
+		return mongo.ErrNoDocuments
+	}
+	if category := r.categories[userID][i]; version != nil && *version != category.Version {
+		return &xmongo.VersionConflict{Expected: *version, Current: category.Version}
+	}
+	r.categories[userID] = slices.Delete(r.categories[userID], i, i+1)
+	return nil
+}
+
+func (r *MemoryRepository) ListShared(ctx context.Context, userID primitive.ObjectID) ([]CategoryDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := make([]CategoryDocument, 0)
+	for _, categories := range r.categories {
+		for _, category := range categories {
+			if sharing.Find(category.Members, userID) != nil {
+				results = append(results, category)
+			}
+		}
+	}
+	return results, nil
+}
+
+func (r *MemoryRepository) AddMember(ctx context.Context, owner primitive.ObjectID, id primitive.ObjectID, member sharing.Member) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	category := r.category(id)
+	switch {
+	case category == nil || category.User != owner:
+		return mongo.ErrNoDocuments
+	case sharing.Find(category.Members, member.User) != nil:
+		return ErrAlreadyMember
+	case len(category.Members) >= sharing.MaxMembers:
+		return ErrFull
+	}
+	category.Members = append(category.Members, member)
+	return nil
+}
+
+func (r *MemoryRepository) SetRole(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role sharing.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	member := r.member(id, userID)
+	if member == nil {
+		return mongo.ErrNoDocuments
+	}
+	member.Role = role
+	return nil
+}
+
+func (r *MemoryRepository) Accept(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	member := r.member(id, userID)
+	if member == nil || member.Accepted() {
+		return mongo.ErrNoDocuments
+	}
+	member.AcceptedAt = &at
+	return nil
+}
+
+func (r *MemoryRepository) RemoveMember(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if category := r.category(id); category != nil {
+		category.Members = slices.DeleteFunc(category.Members, func(m sharing.Member) bool {
+			return m.User == userID
+		})
+	}
+	return nil
+}
+
+// AddFriends makes the two users friends for Friends
+func (r *MemoryRepository) AddFriends(userID primitive.ObjectID, friendID primitive.ObjectID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.friends[userID] = append(r.friends[userID], friendID)
+	r.friends[friendID] = append(r.friends[friendID], userID)
+}
+
+func (r *MemoryRepository) Friends(ctx context.Context, userID primitive.ObjectID, friendID primitive.ObjectID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Contains(r.friends[userID], friendID), nil
+}
+
+// category finds a category wherever it is; callers hold the lock
+func (r *MemoryRepository) category(id primitive.ObjectID) *CategoryDocument {
+	for owner := range r.categories {
+		for i := range r.categories[owner] {
+			if r.categories[owner][i].ID == id {
+				return &r.categories[owner][i]
+			}
+		}
+	}
+	return nil
+}
+
+// member finds userID's entry in a category's members; callers hold the lock
+func (r *MemoryRepository) member(id primitive.ObjectID, userID primitive.ObjectID) *sharing.Member {
+	category := r.category(id)
+	if category == nil {
+		return nil
+	}
+	return sharing.Find(category.Members, userID)
+}
@@ -0,0 +1,355 @@
+package Category
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/tombstone"
+	"github.com/abhikaboy/SocialToDo/internal/xquery"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Repository is the storage the category Service needs. Categories live inside
+their owner's user document. A missing category is mongo.ErrNoDocuments for
+every implementation.
+*/
+type Repository interface {
+	All(ctx context.Context) ([]CategoryDocument, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID) ([]CategoryDocument, error)
+	// ListViewsByUser returns bson keyed documents projected to fields
+	ListViewsByUser(ctx context.Context, userID primitive.ObjectID, fields *xquery.Fields) ([]bson.M, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*CategoryDocument, error)
+	Insert(ctx context.Context, doc *CategoryDocument) error
+	// Rename returns a *xmongo.VersionConflict when version is set and stale
+	Rename(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, name string, version *int64, at time.Time) error
+	// Delete returns a *xmongo.VersionConflict when version is set and stale
+	Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, version *int64) error
+	// ListShared returns the categories userID is a member of, invites still pending included
+	ListShared(ctx context.Context, userID primitive.ObjectID) ([]CategoryDocument, error)
+	// AddMember returns ErrAlreadyMember when member.User is a member already, and ErrFull at sharing.MaxMembers
+	AddMember(ctx context.Context, owner primitive.ObjectID, id primitive.ObjectID, member sharing.Member) error
+	SetRole(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role sharing.Role) error
+	// Accept returns mongo.ErrNoDocuments unless userID has a pending invite to the category
+	Accept(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) error
+	RemoveMember(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
+	// Friends reports whether the two users are friends, neither blocking the other
+	Friends(ctx context.Context, userID primitive.ObjectID, friendID primitive.ObjectID) (bool, error)
+}
+
+type mongoRepository struct {
+	users      *mongo.Collection
+	tombstones *mongo.Collection
+}
+
+// NewMongoRepository stores categories inside the users collection
+func NewMongoRepository(collections map[string]*mongo.Collection) Repository {
+	return &mongoRepository{
+		users:      collections["users"],
+		tombstones: collections[tombstone.Collection],
+	}
+}
+
+func (r *mongoRepository) All(ctx context.Context) ([]CategoryDocument, error) {
+	cursor, err := r.users.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []CategoryDocument
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *mongoRepository) ListByUser(ctx context.Context, userID primitive.ObjectID) ([]CategoryDocument, error) {
+	cursor, err := r.users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"_id": userID}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories",
+			}},
+		},
+		{
+			{Key: "$match", Value: softdelete.Live()},
+		},
+		{
+			{Key: "$set", Value: bson.M{"tasks": softdelete.LiveElements("tasks")}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []CategoryDocument
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *mongoRepository) ListViewsByUser(ctx context.Context, userID primitive.ObjectID, fields *xquery.Fields) ([]bson.M, error) {
+	cursor, err := r.users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"_id": userID}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories",
+			}},
+		},
+		{
+			{Key: "$match", Value: softdelete.Live()},
+		},
+		{
+			{Key: "$set", Value: bson.M{"tasks": softdelete.LiveElements("tasks")}},
+		},
+		{
+			{Key: "$project", Value: fields.Projection()},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *mongoRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*CategoryDocument, error) {
+	cursor, err := r.users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"categories._id": id}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$match", Value: bson.M{"categories._id": id}},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories",
+			}},
+		},
+		{
+			{Key: "$match", Value: softdelete.Live()},
+		},
+		{
+			{Key: "$set", Value: bson.M{"tasks": softdelete.LiveElements("tasks")}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []CategoryDocument
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		// No matching Category found
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return &results[0], nil
+}
+
+func (r *mongoRepository) Insert(ctx context.Context, doc *CategoryDocument) error {
+	_, err := r.users.UpdateOne(ctx, bson.M{"_id": doc.User}, bson.M{"$push": bson.M{"categories": doc}})
+	return err
+}
+
+func (r *mongoRepository) Rename(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, name string, version *int64, at time.Time) error {
+	return xmongo.UpdateVersioned(ctx, r.users, version, xmongo.VersionedUpdate{
+		Filter: func(version any) bson.M {
+			category := bson.M{"_id": id}
+			if version != nil {
+				category[xmongo.VersionField] = version
+			}
+			return bson.M{
+				"_id":        userID,
+				"categories": bson.M{"$elemMatch": softdelete.Filter(category)},
+			}
+		},
+		Update: bson.M{"$set": bson.M{
+			"categories.$.name":       name,
+			"categories.$.lastEdited": at,
+		}},
+		VersionPath: "categories.$." + xmongo.VersionField,
+		Projection:  bson.M{"_id": 1},
+		Current: func(ctx context.Context) (int64, error) {
+			current, err := r.FindByID(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			return current.Version, nil
+		},
+	}, nil)
+}
+
+// Delete moves the category, tasks included, to the trash; the purge schedule removes it for good
+func (r *mongoRepository) Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, version *int64) error {
+	err := xmongo.UpdateVersioned(ctx, r.users, version, xmongo.VersionedUpdate{
+		Filter: func(version any) bson.M {
+			category := bson.M{"_id": id}
+			if version != nil {
+				category[xmongo.VersionField] = version
+			}
+			return bson.M{
+				"_id":        userID,
+				"categories": bson.M{"$elemMatch": softdelete.Filter(category)},
+			}
+		},
+		Update:      bson.M{"$set": bson.M{"categories.$." + softdelete.Field: time.Now()}},
+		VersionPath: "categories.$." + xmongo.VersionField,
+		Projection:  bson.M{"_id": 1},
+		Current: func(ctx context.Context) (int64, error) {
+			current, err := r.FindByID(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			return current.Version, nil
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	// offline clients learn about the delete on their next sync
+	if err := tombstone.Record(ctx, r.tombstones, userID, tombstone.Category, id); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to record category tombstone", xslog.Error(err))
+	}
+	return nil
+}
+
+func (r *mongoRepository) ListShared(ctx context.Context, userID primitive.ObjectID) ([]CategoryDocument, error) {
+	cursor, err := r.users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"categories.members.user": userID}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories",
+			}},
+		},
+		{
+			{Key: "$match", Value: softdelete.Filter(bson.M{"members.user": userID})},
+		},
+		{
+			{Key: "$set", Value: bson.M{"tasks": softdelete.LiveElements("tasks")}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]CategoryDocument, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// AddMember holds the service's checks against concurrent invites in its filter
+func (r *mongoRepository) AddMember(ctx context.Context, owner primitive.ObjectID, id primitive.ObjectID, member sharing.Member) error {
+	result, err := r.users.UpdateOne(ctx,
+		bson.M{
+			"_id": owner,
+			"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{
+				"_id":          id,
+				"members.user": bson.M{"$ne": member.User},
+				"members." + strconv.Itoa(sharing.MaxMembers-1): bson.M{"$exists": false},
+			})},
+		},
+		bson.M{"$push": bson.M{"categories.$.members": member}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrAlreadyMember
+	}
+	return nil
+}
+
+func (r *mongoRepository) SetRole(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role sharing.Role) error {
+	return r.updateMember(ctx, id, userID, bson.M{}, bson.M{"$set": bson.M{"categories.$[c].members.$[m].role": role}})
+}
+
+func (r *mongoRepository) Accept(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) error {
+	return r.updateMember(ctx, id, userID, bson.M{"accepted_at": nil}, bson.M{"$set": bson.M{"categories.$[c].members.$[m].accepted_at": at}})
+}
+
+func (r *mongoRepository) RemoveMember(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	_, err := r.users.UpdateOne(ctx,
+		bson.M{"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": id, "members.user": userID})}},
+		bson.M{"$pull": bson.M{"categories.$.members": bson.M{"user": userID}}},
+	)
+	return err
+}
+
+// updateMember applies update to userID's entry in the category's members, when it also matches member
+func (r *mongoRepository) updateMember(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, member bson.M, update bson.M) error {
+	member["user"] = userID
+	result, err := r.users.UpdateOne(ctx,
+		bson.M{"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{
+			"_id":     id,
+			"members": bson.M{"$elemMatch": member},
+		})}},
+		update,
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{"c._id": id},
+			bson.M{"m.user": userID},
+		}}),
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (r *mongoRepository) Friends(ctx context.Context, userID primitive.ObjectID, friendID primitive.ObjectID) (bool, error) {
+	found, err := privacy.Load(ctx, r.users, []primitive.ObjectID{userID, friendID})
+	if err != nil {
+		return false, err
+	}
+	user, ok := found[userID]
+	friend, friendOK := found[friendID]
+	return ok && friendOK && slices.Contains(user.Friends, friendID) && !privacy.Blocked(user, friend), nil
+}
@@ -1,6 +1,10 @@
 package Category
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -8,21 +12,45 @@ import (
 /*
 Router maps endpoints to handlers
 */
-func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
-	service := newService(collections)
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler) {
+	service := newService(collections, cache)
 	handler := Handler{service}
 
 	// Add a group for API versioning
 	apiV1 := app.Group("/api/v1")
 
 	// Add Sample group under API Version 1
-	Categories := apiV1.Group("/Categories")
+	Categories := apiV1.Group("/Categories", authenticate)
 
 	Categories.Post("/", handler.CreateCategory)
-	Categories.Get("/", handler.GetCategories)
-	
-	Categories.Delete("/user/:user/:id", handler.DeleteCategory)
-	Categories.Patch("/user/:user/:id", handler.UpdatePartialCategory)
-	Categories.Get("/user/:id", handler.GetCategoriesByUser)
+	// every user's categories
+	Categories.Get("/", xauth.Require(xauth.AdminRole), handler.GetCategories)
 
+	// :user has to be the caller, it stays in the path for older clients
+	Categories.Delete("/user/:user/:id", xauth.Self("user"), handler.DeleteCategory)
+	Categories.Patch("/user/:user/:id", xauth.Self("user"), handler.UpdatePartialCategory)
+	Categories.Get("/user/:id", xauth.Self("id"), handler.GetCategoriesByUser)
+	// ahead of /:id, which would otherwise match it
+	Categories.Get("/shared", handler.GetSharedCategories)
+	Categories.Get("/:id", handler.GetCategory)
+
+	// sharing with friends; the owner manages members, who can remove themselves
+	Categories.Post("/:id/members", handler.InviteMember)
+	Categories.Patch("/:id/members/:user", handler.UpdateMember)
+	Categories.Delete("/:id/members/:user", handler.RemoveMember)
+	Categories.Post("/:id/accept", handler.AcceptInvite)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/Categories/":                    {Summary: "Create a category for the caller", Auth: true, Request: CreateCategoryParams{}, Response: CategoryDocument{}, Status: fiber.StatusCreated},
+		"GET /api/v1/Categories/":                     {Summary: "List every user's categories", Auth: true, Response: []CategoryDocument{}},
+		"DELETE /api/v1/Categories/user/:user/:id":    {Summary: "Move one of the caller's categories to the trash; If-Match must name the version deleted", Auth: true},
+		"PATCH /api/v1/Categories/user/:user/:id":     {Summary: "Rename one of the caller's categories, or one shared with them as an editor; If-Match must name the version renamed", Auth: true, Request: UpdateCategoryDocument{}, Response: CategoryDocument{}},
+		"GET /api/v1/Categories/user/:id":             {Summary: "The caller's categories, oldest first", Auth: true, Query: xopenapi.Query{"fields", "limit", "cursor"}, Response: xpage.Page[CategoryDocument]{}},
+		"GET /api/v1/Categories/shared":               {Summary: "Categories shared with the caller, pending invites included", Auth: true, Response: []CategoryDocument{}},
+		"GET /api/v1/Categories/:id":                  {Summary: "Get one of the caller's categories, or one shared with them", Auth: true, Response: CategoryDocument{}},
+		"POST /api/v1/Categories/:id/members":         {Summary: "Share one of the caller's categories with a friend", Auth: true, Request: InviteParams{}, Response: CategoryDocument{}, Status: fiber.StatusCreated},
+		"PATCH /api/v1/Categories/:id/members/:user":  {Summary: "Change a member's role", Auth: true, Request: MemberParams{}, Response: CategoryDocument{}},
+		"DELETE /api/v1/Categories/:id/members/:user": {Summary: "Remove a member, or leave a shared category", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/Categories/:id/accept":          {Summary: "Accept an invite to a shared category", Auth: true, Response: CategoryDocument{}},
+	})
 }
@@ -2,146 +2,223 @@ package Category
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log/slog"
 	"time"
 
-	"github.com/abhikaboy/SocialToDo/xutils"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/abhikaboy/SocialToDo/internal/xquery"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // newService receives the map of collections and picks out Jobs
-func newService(collections map[string]*mongo.Collection) *Service {
-	return &Service{
-		Users: collections["users"],
-	}
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache) *Service {
+	return NewServiceWithRepository(NewMongoRepository(collections), cache)
 }
 
-// GetAllCategories fetches all Category documents from MongoDB
-func (s *Service) GetAllCategories() ([]CategoryDocument, error) {
-	ctx := context.Background()
-	cursor, err := s.Users.Find(ctx, bson.M{})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
+// NewServiceWithRepository builds the service on any store, e.g. NewMemoryRepository in tests
+func NewServiceWithRepository(repo Repository, cache xcache.Cache) *Service {
+	return &Service{repo: repo, cache: cache}
+}
 
-	var results []CategoryDocument
-	if err := cursor.All(ctx, &results); err != nil {
-		return nil, err
-	}
+// GetAllCategories fetches all Category documents
+func (s *Service) GetAllCategories(ctx context.Context) ([]CategoryDocument, error) {
+	return s.repo.All(ctx)
+}
 
-	return results, nil
+// GetCategoriesByUser fetches a user's categories, served from the cache when possible
+func (s *Service) GetCategoriesByUser(ctx context.Context, id primitive.ObjectID) ([]CategoryDocument, error) {
+	return xcache.Fetch(ctx, s.cache, xcache.CategoriesKey(id.Hex()), 0,
+		[]string{xcache.CategoriesTag(id.Hex()), xcache.UserTag(id.Hex())},
+		func() ([]CategoryDocument, error) {
+			return s.repo.ListByUser(ctx, id)
+		})
 }
 
-// GetAllCategories fetches all Category documents from MongoDB
-func (s *Service) GetCategoriesByUser(id primitive.ObjectID) ([]CategoryDocument, error) {
-	ctx := context.Background()
+// GetCategoryViewsByUser is GetCategoriesByUser projected down to the ?fields= requested
+func (s *Service) GetCategoryViewsByUser(ctx context.Context, id primitive.ObjectID, fields *xquery.Fields) ([]map[string]any, error) {
+	return xcache.Fetch(ctx, s.cache, xcache.CategoriesFieldsKey(id.Hex(), fields.Key()), 0,
+		[]string{xcache.CategoriesTag(id.Hex()), xcache.UserTag(id.Hex())},
+		func() ([]map[string]any, error) {
+			docs, err := s.repo.ListViewsByUser(ctx, id, fields)
+			if err != nil {
+				return nil, err
+			}
+			results := make([]map[string]any, 0, len(docs))
+			for _, doc := range docs {
+				results = append(results, fields.Rename(doc))
+			}
+			return results, nil
+		})
+}
 
-	filter := bson.M{"_id": id}
-	cursor, err := s.Users.Aggregate(ctx, mongo.Pipeline{
-		{
-			{Key: "$match", Value: filter},
-		},
-		{
-			{Key: "$unwind", Value: "$categories"},
-		},
-		{
-			{Key: "$replaceRoot", Value: bson.M{
-				"newRoot": "$categories",
-			}},
-		},
-	})
+// GetCategoryByID returns a single Category by its ObjectID, wherever it is embedded
+func (s *Service) GetCategoryByID(ctx context.Context, id primitive.ObjectID) (*CategoryDocument, error) {
+	return s.repo.FindByID(ctx, id)
+}
 
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
+// InsertCategory adds a new Category document
+func (s *Service) CreateCategory(ctx context.Context, r *CategoryDocument) (_ *CategoryDocument, err error) {
+	defer xmetrics.Track("category", "CreateCategory")(&err)
 
-	var results []CategoryDocument
-	if err := cursor.All(ctx, &results); err != nil {
+	if err := s.repo.Insert(ctx, r); err != nil {
 		return nil, err
 	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(r.User.Hex()))
 
-	return results, nil
-}
+	slog.LogAttrs(ctx, slog.LevelInfo, "Category inserted", slog.String("id", r.ID.Hex()))
 
-// GetCategoryByID returns a single Category document by its ObjectID
-func (s *Service) GetCategoryByID(id primitive.ObjectID) (*CategoryDocument, error) {
-	ctx := context.Background()
-	filter := bson.M{"_id": id}
+	return r, nil
+}
 
-	var Category CategoryDocument
-	err := s.Users.FindOne(ctx, filter).Decode(&Category)
+// UpdatePartialCategory updates only specified fields of a Category document by ObjectID; editors can rename shared categories
+func (s *Service) UpdatePartialCategory(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, updated UpdateCategoryDocument) (*CategoryDocument, error) {
+	category, err := s.shared(ctx, userId, id, sharing.Editor)
+	if err != nil {
+		return nil, err
+	}
 
-	if err == mongo.ErrNoDocuments {
-		// No matching Category found
-		return nil, mongo.ErrNoDocuments
-	} else if err != nil {
-		// Different error occurred
+	if err := s.repo.Rename(ctx, category.User, id, updated.Name, updated.Version, time.Now()); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to update Category", slog.String("error", err.Error()))
 		return nil, err
 	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(category.User.Hex()))
 
-	return &Category, nil
+	return nil, nil
 }
 
-// InsertCategory adds a new Category document
-func (s *Service) CreateCategory(r *CategoryDocument) (*CategoryDocument, error) {
-	ctx := context.Background()
-	// Insert the document into the collection
+// DeleteCategory moves a Category to the trash, if it is still at version when that is set; see the trash package for restoring it
+func (s *Service) DeleteCategory(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, version *int64) error {
+	if err := s.repo.Delete(ctx, userId, id, version); err != nil {
+		return err
+	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(userId.Hex()))
+	return nil
+}
 
-	_, err := s.Users.UpdateOne(ctx, bson.M{"_id": r.User}, bson.M{"$push": bson.M{"categories": r}})
+// GetSharedCategories lists the categories shared with the user; a pending invite shows the category without its tasks
+func (s *Service) GetSharedCategories(ctx context.Context, userId primitive.ObjectID) ([]CategoryDocument, error) {
+	categories, err := s.repo.ListShared(ctx, userId)
 	if err != nil {
 		return nil, err
 	}
+	for i := range categories {
+		if !sharing.Find(categories[i].Members, userId).Accepted() {
+			categories[i].Tasks = []task.TaskDocument{}
+		}
+	}
+	return categories, nil
+}
 
-	slog.LogAttrs(ctx, slog.LevelInfo, "Category inserted", slog.String("id", r.ID.Hex()))
+// InviteMember shares the owner's category with one of their friends, who has access once they accept
+func (s *Service) InviteMember(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID, role sharing.Role) (*CategoryDocument, error) {
+	category, err := s.owned(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case sharing.Find(category.Members, member) != nil:
+		return nil, ErrAlreadyMember
+	case len(category.Members) >= sharing.MaxMembers:
+		return nil, ErrFull
+	}
+	friends, err := s.repo.Friends(ctx, userId, member)
+	if err != nil {
+		return nil, err
+	}
+	if !friends {
+		return nil, ErrNotFriend
+	}
 
-	return r, nil
+	err = s.repo.AddMember(ctx, userId, id, sharing.Member{User: member, Role: role, InvitedAt: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(userId.Hex()))
+	return s.repo.FindByID(ctx, id)
 }
 
-// UpdatePartialCategory updates only specified fields of a Category document by ObjectID.
-func (s *Service) UpdatePartialCategory(userId primitive.ObjectID,id primitive.ObjectID, updated UpdateCategoryDocument) (*CategoryDocument, error) {
-	ctx := context.Background()
-	// filter := bson.M{"_id": id}
-
-	updateFields, err := xutils.ToDoc(updated)
+// UpdateMember changes a member's role, for the owner
+func (s *Service) UpdateMember(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID, role sharing.Role) (*CategoryDocument, error) {
+	if _, err := s.owned(ctx, userId, id); err != nil {
+		return nil, err
+	}
+	err := s.repo.SetRole(ctx, id, member, role)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotMember
+	}
 	if err != nil {
 		return nil, err
 	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(userId.Hex()))
+	return s.repo.FindByID(ctx, id)
+}
 
-	fmt.Println(updateFields)
+// RemoveMember takes member off the category; the owner removes anyone, members leave or decline for themselves
+func (s *Service) RemoveMember(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID) error {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case category.User != userId && sharing.Find(category.Members, userId) == nil:
+		return mongo.ErrNoDocuments
+	case category.User != userId && member != userId:
+		return ErrNotOwner
+	case sharing.Find(category.Members, member) == nil:
+		return ErrNotMember
+	}
+	if err := s.repo.RemoveMember(ctx, id, member); err != nil {
+		return err
+	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(category.User.Hex()))
+	return nil
+}
 
-	res, err := s.Users.UpdateOne(ctx, 
-		bson.M{
-			"_id": userId, 
-			"categories": bson.M{"$elemMatch": bson.M{"_id": id}},
-		},
-			bson.D{{Key: "$set", Value: bson.D{
-				{Key: "categories.$.name", Value: updated.Name},
-				{Key: "categories.$.lastEdited", Value: time.Now()},
-			}}},
-	)
+// AcceptInvite gives the user the access they were invited with
+func (s *Service) AcceptInvite(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) (*CategoryDocument, error) {
+	if err := s.repo.Accept(ctx, id, userId, time.Now()); err != nil {
+		return nil, err
+	}
+	category, err := s.repo.FindByID(ctx, id)
 	if err != nil {
-		slog.LogAttrs(ctx, slog.LevelError, "Failed to update Category", slog.String("error", err.Error()))
 		return nil, err
 	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(category.User.Hex()))
+	return category, nil
+}
 
-	fmt.Print(res)
-	fmt.Print(res.MatchedCount)
-	fmt.Print(res.ModifiedCount)
-	slog.LogAttrs(ctx, slog.LevelInfo, "Categories Matched:", slog.String("id", string(res.MatchedCount)))
-	slog.LogAttrs(ctx, slog.LevelInfo, "Updated Count:", slog.String("id", string(res.UpsertedCount)))
-
-	
-	return nil, err
+/*
+shared finds a category the user may see, when their role grants need. It
+is mongo.ErrNoDocuments for anyone without access and ErrNotEditor for a
+viewer asking to change it.
+*/
+func (s *Service) shared(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID, need sharing.Role) (*CategoryDocument, error) {
+	category, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !sharing.Allows(category.User, category.Members, userId, sharing.Viewer) {
+		return nil, mongo.ErrNoDocuments
+	}
+	if !sharing.Allows(category.User, category.Members, userId, need) {
+		return nil, ErrNotEditor
+	}
+	return category, nil
 }
 
-// DeleteCategory removes a Category document by ObjectID.
-func (s *Service) DeleteCategory(userId primitive.ObjectID,id primitive.ObjectID) error {
-	ctx := context.Background()
-	_, err := s.Users.UpdateOne(ctx, bson.M{"_id": userId}, bson.M{"$pull": bson.M{"categories": bson.M{"_id": id}}})
-	return err
+// owned is shared for what only the owner may do
+func (s *Service) owned(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) (*CategoryDocument, error) {
+	category, err := s.shared(ctx, userId, id, sharing.Viewer)
+	if err != nil {
+		return nil, err
+	}
+	if category.User != userId {
+		return nil, ErrNotOwner
+	}
+	return category, nil
 }
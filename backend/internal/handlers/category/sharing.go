@@ -0,0 +1,161 @@
+package Category
+
+import (
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+The /Categories/:id/members routes share a category with the owner's
+friends, see sharing for what each role may do. Invitees find their invites
+in GET /Categories/shared and accept them with POST /Categories/:id/accept,
+or decline by removing themselves.
+*/
+
+func (h *Handler) GetSharedCategories(c *fiber.Ctx) error {
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	categories, err := h.service.GetSharedCategories(c.UserContext(), userId)
+	if err != nil {
+		return memberError(c, err)
+	}
+	return c.JSON(categories)
+}
+
+func (h *Handler) InviteMember(c *fiber.Ctx) error {
+	userId, id, err := categoryParams(c)
+	if err != nil {
+		return err
+	}
+
+	var params InviteParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validator.New().Struct(params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Validation failed",
+		})
+	}
+	member, _ := primitive.ObjectIDFromHex(params.UserID)
+
+	category, err := h.service.InviteMember(c.UserContext(), userId, id, member, params.Role)
+	if err != nil {
+		return memberError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(category)
+}
+
+func (h *Handler) UpdateMember(c *fiber.Ctx) error {
+	userId, id, err := categoryParams(c)
+	if err != nil {
+		return err
+	}
+	member, err := primitive.ObjectIDFromHex(c.Params("user"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var params MemberParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validator.New().Struct(params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Validation failed",
+		})
+	}
+
+	category, err := h.service.UpdateMember(c.UserContext(), userId, id, member, params.Role)
+	if err != nil {
+		return memberError(c, err)
+	}
+	return c.JSON(category)
+}
+
+// RemoveMember removes a member, or with the caller's own id leaves the category or declines its invite
+func (h *Handler) RemoveMember(c *fiber.Ctx) error {
+	userId, id, err := categoryParams(c)
+	if err != nil {
+		return err
+	}
+	member, err := primitive.ObjectIDFromHex(c.Params("user"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.service.RemoveMember(c.UserContext(), userId, id, member); err != nil {
+		return memberError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) AcceptInvite(c *fiber.Ctx) error {
+	userId, id, err := categoryParams(c)
+	if err != nil {
+		return err
+	}
+
+	category, err := h.service.AcceptInvite(c.UserContext(), userId, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Invite not found",
+		})
+	}
+	if err != nil {
+		return memberError(c, err)
+	}
+	return c.JSON(category)
+}
+
+func memberError(c *fiber.Ctx, err error) error {
+	var status int
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	case errors.Is(err, ErrNotOwner), errors.Is(err, ErrNotEditor), errors.Is(err, ErrNotFriend):
+		status = fiber.StatusForbidden
+	case errors.Is(err, ErrNotMember):
+		status = fiber.StatusNotFound
+	case errors.Is(err, ErrAlreadyMember), errors.Is(err, ErrFull):
+		status = fiber.StatusConflict
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update the category's members",
+		})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+// categoryParams reads the caller and the :id param
+func categoryParams(c *fiber.Ctx) (primitive.ObjectID, primitive.ObjectID, error) {
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "Invalid ID format")
+	}
+	return userId, id, nil
+}
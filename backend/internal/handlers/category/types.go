@@ -1,11 +1,14 @@
 package Category
 
 import (
+	"errors"
 	"time"
 
 	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateCategoryParams struct {
@@ -13,16 +16,59 @@ type CreateCategoryParams struct {
 	User string `bson:"user,omitempty" json:"user,omitempty"`
 }
 
+var (
+	ErrNotOwner      = errors.New("only the category's owner can do that")
+	ErrNotEditor     = errors.New("viewers can't change the category")
+	ErrNotFriend     = errors.New("you can only share a category with your friends")
+	ErrAlreadyMember = errors.New("already a member")
+	ErrNotMember     = errors.New("not a member")
+	ErrFull          = errors.New("the category is shared with too many friends")
+)
+
 type CategoryDocument struct {
 	ID         primitive.ObjectID  `bson:"_id" json:"id"`
 	Name       string              `bson:"name,omitempty" json:"name,omitempty"`
 	LastEdited time.Time           `bson:"lastEdited" json:"lastEdited"`
 	Tasks      []task.TaskDocument `bson:"tasks" json:"tasks"`
 	User       primitive.ObjectID  `bson:"user" json:"user"`
+	DeletedAt  *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	// the list an imported category came from, see TaskDocument.Source
+	Source string `bson:"source,omitempty" json:"source,omitempty"`
+	// upload shown as the category's cover image
+	Cover *primitive.ObjectID `bson:"cover,omitempty" json:"cover,omitempty"`
+	// bumped by every rename, see xmongo.UpdateVersioned
+	Version int64 `bson:"version" json:"version"`
+	// the friends it is shared with, see sharing
+	Members []sharing.Member `bson:"members,omitempty" json:"members,omitempty"`
+}
+
+func (c CategoryDocument) IsDeleted() bool {
+	return c.DeletedAt != nil
+}
+
+// Visible drops deleted categories, and deleted tasks from the rest, for code reading whole user documents
+func Visible(categories []CategoryDocument) []CategoryDocument {
+	visible := softdelete.Visible(categories)
+	for i := range visible {
+		visible[i].Tasks = softdelete.Visible(visible[i].Tasks)
+	}
+	return visible
 }
 
 type UpdateCategoryDocument struct {
 	Name string `bson:"name,omitempty" json:"name,omitempty"`
+	// the version the client last read; when set, the rename fails with a conflict if the category has changed since
+	Version *int64 `bson:"-" json:"version,omitempty"`
+}
+
+// InviteParams shares a category with one of the owner's friends
+type InviteParams struct {
+	UserID string       `validate:"required,mongodb" json:"user_id"`
+	Role   sharing.Role `validate:"required,oneof=viewer editor" json:"role"`
+}
+
+type MemberParams struct {
+	Role sharing.Role `validate:"required,oneof=viewer editor" json:"role"`
 }
 
 /*
@@ -31,5 +77,6 @@ Database layer of the application
 */
 
 type Service struct {
-	Users *mongo.Collection
+	repo  Repository
+	cache xcache.Cache
 }
@@ -1,13 +1,27 @@
 package chat
 
 import (
+	"context"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+//go:generate mockgen -source=chat.go -destination=mock_test.go -package=chat
+
+// Chats is the chat service as Handler uses it
+type Chats interface {
+	GetAllChats(ctx context.Context) ([]ChatDocument, error)
+	GetChatByID(ctx context.Context, id primitive.ObjectID) (*ChatDocument, error)
+	CreateChat(ctx context.Context, r *ChatDocument) (*ChatDocument, error)
+	UpdatePartialChat(ctx context.Context, id primitive.ObjectID, updated UpdateChatDocument) error
+	DeleteChat(ctx context.Context, id primitive.ObjectID) error
+}
+
+var _ Chats = (*Service)(nil)
+
 type Handler struct {
-	service *Service
+	service Chats
 }
 
 func (h *Handler) CreateChat(c *fiber.Ctx) error {
@@ -31,7 +45,7 @@ func (h *Handler) CreateChat(c *fiber.Ctx) error {
 		Content: params.Content,
 	}
 
-	_, err := h.service.CreateChat(&doc)
+	_, err := h.service.CreateChat(c.UserContext(), &doc)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create Chat",
@@ -42,7 +56,7 @@ func (h *Handler) CreateChat(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetChats(c *fiber.Ctx) error {
-	Chats, err := h.service.GetAllChats()
+	Chats, err := h.service.GetAllChats(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch Chats",
@@ -60,7 +74,7 @@ func (h *Handler) GetChat(c *fiber.Ctx) error {
 		})
 	}
 
-	Chat, err := h.service.GetChatByID(id)
+	Chat, err := h.service.GetChatByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Chat not found",
@@ -85,7 +99,7 @@ func (h *Handler) UpdatePartialChat(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.UpdatePartialChat(id, update); err != nil {
+	if err := h.service.UpdatePartialChat(c.UserContext(), id, update); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update Chat",
 		})
@@ -102,7 +116,7 @@ func (h *Handler) DeleteChat(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.DeleteChat(id); err != nil {
+	if err := h.service.DeleteChat(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete Chat",
 		})
@@ -1,6 +1,7 @@
 package chat
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -24,4 +25,11 @@ func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
 	Chats.Patch("/:id", handler.UpdatePartialChat)
 	Chats.Delete("/:id", handler.DeleteChat)
 
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/Chats/":      {Summary: "Create a chat", Request: CreateChatParams{}, Response: ChatDocument{}, Status: fiber.StatusCreated},
+		"GET /api/v1/Chats/":       {Summary: "List every chat", Response: []ChatDocument{}},
+		"GET /api/v1/Chats/:id":    {Summary: "Get a chat", Response: ChatDocument{}},
+		"PATCH /api/v1/Chats/:id":  {Summary: "Update a chat", Request: UpdateChatDocument{}},
+		"DELETE /api/v1/Chats/:id": {Summary: "Delete a chat"},
+	})
 }
@@ -18,8 +18,7 @@ func newService(collections map[string]*mongo.Collection) *Service {
 }
 
 // GetAllChats fetches all Chat documents from MongoDB
-func (s *Service) GetAllChats() ([]ChatDocument, error) {
-	ctx := context.Background()
+func (s *Service) GetAllChats(ctx context.Context) ([]ChatDocument, error) {
 	cursor, err := s.Chats.Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
@@ -35,8 +34,7 @@ func (s *Service) GetAllChats() ([]ChatDocument, error) {
 }
 
 // GetChatByID returns a single Chat document by its ObjectID
-func (s *Service) GetChatByID(id primitive.ObjectID) (*ChatDocument, error) {
-	ctx := context.Background()
+func (s *Service) GetChatByID(ctx context.Context, id primitive.ObjectID) (*ChatDocument, error) {
 	filter := bson.M{"_id": id}
 
 	var Chat ChatDocument
@@ -54,8 +52,7 @@ func (s *Service) GetChatByID(id primitive.ObjectID) (*ChatDocument, error) {
 }
 
 // InsertChat adds a new Chat document
-func (s *Service) CreateChat(r *ChatDocument) (*ChatDocument, error) {
-	ctx := context.Background()
+func (s *Service) CreateChat(ctx context.Context, r *ChatDocument) (*ChatDocument, error) {
 	// Insert the document into the collection
 
 	result, err := s.Chats.InsertOne(ctx, r)
@@ -72,8 +69,7 @@ func (s *Service) CreateChat(r *ChatDocument) (*ChatDocument, error) {
 }
 
 // UpdatePartialChat updates only specified fields of a Chat document by ObjectID.
-func (s *Service) UpdatePartialChat(id primitive.ObjectID, updated UpdateChatDocument) error {
-	ctx := context.Background()
+func (s *Service) UpdatePartialChat(ctx context.Context, id primitive.ObjectID, updated UpdateChatDocument) error {
 	filter := bson.M{"_id": id}
 
 	updateFields, err := xutils.ToDoc(updated)
@@ -88,8 +84,7 @@ func (s *Service) UpdatePartialChat(id primitive.ObjectID, updated UpdateChatDoc
 }
 
 // DeleteChat removes a Chat document by ObjectID.
-func (s *Service) DeleteChat(id primitive.ObjectID) error {
-	ctx := context.Background()
+func (s *Service) DeleteChat(ctx context.Context, id primitive.ObjectID) error {
 
 	filter := bson.M{"_id": id}
 
@@ -0,0 +1,123 @@
+package comments
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=comments.go -destination=mock_test.go -package=comments
+
+// Comments is the comment service as Handler uses it
+type Comments interface {
+	Create(ctx context.Context, author primitive.ObjectID, taskID primitive.ObjectID, text string) (*Comment, error)
+	List(ctx context.Context, viewer primitive.ObjectID, taskID primitive.ObjectID, query xpage.Query) (*xpage.Page[Comment], error)
+	Delete(ctx context.Context, userID primitive.ObjectID, taskID primitive.ObjectID, id primitive.ObjectID) error
+}
+
+var _ Comments = (*Service)(nil)
+
+type Handler struct {
+	service Comments
+}
+
+func (h *Handler) CreateComment(c *fiber.Ctx) error {
+	taskID, err := taskParam(c)
+	if err != nil {
+		return err
+	}
+	var params CreateCommentParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	comment, err := h.service.Create(c.UserContext(), userID(c), taskID, params.Text)
+	if err != nil {
+		return commentError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(comment)
+}
+
+func (h *Handler) GetComments(c *fiber.Ctx) error {
+	taskID, err := taskParam(c)
+	if err != nil {
+		return err
+	}
+	var query xpage.Query
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := validator.Validate(query); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	comments, err := h.service.List(c.UserContext(), userID(c), taskID, query)
+	if err != nil {
+		return commentError(c, err)
+	}
+	return c.JSON(comments)
+}
+
+func (h *Handler) DeleteComment(c *fiber.Ctx) error {
+	taskID, err := taskParam(c)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.service.Delete(c.UserContext(), userID(c), taskID, id); err != nil {
+		return commentError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func taskParam(c *fiber.Ctx) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(c.Params("task"))
+	if err != nil {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "Invalid ID format")
+	}
+	return id, nil
+}
+
+func commentError(c *fiber.Ctx, err error) error {
+	var status int
+	switch {
+	case errors.Is(err, ErrTaskNotFound), errors.Is(err, ErrCommentNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, xpage.ErrInvalidCursor):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, ErrNotCompleted):
+		status = fiber.StatusConflict
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update comments",
+		})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := c.Locals("user_id").(string)
+	userID, _ := primitive.ObjectIDFromHex(id)
+	return userID
+}
@@ -0,0 +1,29 @@
+package comments
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Comments := apiV1.Group("/tasks/:task/comments", authenticate)
+	Comments.Post("/", handler.CreateComment)
+	Comments.Get("/", handler.GetComments)
+	Comments.Delete("/:id", handler.DeleteComment)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/tasks/:task/comments/":      {Summary: "Comment on a task", Auth: true, Request: CreateCommentParams{}, Response: Comment{}, Status: fiber.StatusCreated},
+		"GET /api/v1/tasks/:task/comments/":       {Summary: "A task's comments, oldest first, a page at a time", Auth: true, Query: xpage.Query{}, Response: xpage.Page[Comment]{}},
+		"DELETE /api/v1/tasks/:task/comments/:id": {Summary: "Delete a comment", Auth: true, Status: fiber.StatusNoContent},
+	})
+}
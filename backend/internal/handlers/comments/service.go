@@ -0,0 +1,248 @@
+package comments
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handles"
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var mention = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.]{1,30})`)
+
+// newService receives the map of collections and picks out Users, Comments and Notifications
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{
+		users:         collections["users"],
+		comments:      collections[Collection],
+		notifications: collections[notifications.Collection],
+	}
+}
+
+type sharedTask struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Content   string             `bson:"content"`
+	Public    bool               `bson:"public"`
+	Completed bool               `bson:"completed"`
+	DeletedAt *time.Time         `bson:"deleted_at"`
+}
+
+type taskOwner struct {
+	privacy.Relations `bson:",inline"`
+	Categories        []struct {
+		DeletedAt *time.Time   `bson:"deleted_at"`
+		Tasks     []sharedTask `bson:"tasks"`
+	} `bson:"categories"`
+}
+
+/*
+task finds the task and its owner. Only the owner and, for a public task,
+the friends the owner's activity is shared with can see it; to everyone else
+it doesn't exist.
+*/
+func (s *Service) task(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) (*taskOwner, *sharedTask, error) {
+	var owner taskOwner
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"categories.tasks._id": id}), options.FindOne().SetProjection(bson.M{
+		"friends":                     1,
+		"privacy":                     1,
+		"categories.deleted_at":       1,
+		"categories.tasks._id":        1,
+		"categories.tasks.content":    1,
+		"categories.tasks.public":     1,
+		"categories.tasks.completed":  1,
+		"categories.tasks.deleted_at": 1,
+	})).Decode(&owner)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range owner.Categories {
+		for i := range c.Tasks {
+			if t := &c.Tasks[i]; t.ID == id && c.DeletedAt == nil && t.DeletedAt == nil && owner.shares(t, viewer) {
+				return &owner, t, nil
+			}
+		}
+	}
+	return nil, nil, ErrTaskNotFound
+}
+
+func (o *taskOwner) shares(t *sharedTask, viewer primitive.ObjectID) bool {
+	if o.ID == viewer {
+		return true
+	}
+	return t.Public && o.Privacy.SharesActivity() && o.Privacy.Visibility() != privacy.Private && slices.Contains(o.Friends, viewer)
+}
+
+/*
+Create adds the author's comment to a completed task. The owner is notified,
+and so is everyone @mentioned who can see the task.
+*/
+func (s *Service) Create(ctx context.Context, author primitive.ObjectID, taskID primitive.ObjectID, text string) (*Comment, error) {
+	owner, task, err := s.task(ctx, author, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if !task.Completed {
+		return nil, ErrNotCompleted
+	}
+	mentions, err := s.mentions(ctx, owner, task, author, text)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := Comment{
+		ID:        primitive.NewObjectID(),
+		Task:      taskID,
+		Owner:     owner.ID,
+		Author:    author,
+		Text:      text,
+		Mentions:  mentions,
+		CreatedAt: time.Now(),
+	}
+	if _, err := s.comments.InsertOne(ctx, comment); err != nil {
+		return nil, err
+	}
+	s.notify(ctx, comment, task.Content)
+	return &comment, nil
+}
+
+// mentions resolves the @handles in text to the users who can see the task, leaving out the author
+func (s *Service) mentions(ctx context.Context, owner *taskOwner, task *sharedTask, author primitive.ObjectID, text string) ([]primitive.ObjectID, error) {
+	written := make([]string, 0)
+	for _, match := range mention.FindAllStringSubmatch(text, -1) {
+		handle, err := handles.Normalize(match[1])
+		if err == nil && !slices.Contains(written, handle) {
+			written = append(written, handle)
+		}
+	}
+	if len(written) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := s.users.Find(ctx, softdelete.Filter(bson.M{"handle": bson.M{"$in": written}}),
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var found []Author
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	mentioned := make([]primitive.ObjectID, 0, len(found))
+	for _, user := range found {
+		if user.ID != author && owner.shares(task, user.ID) {
+			mentioned = append(mentioned, user.ID)
+		}
+	}
+	return mentioned, nil
+}
+
+// notify tells the owner and the mentioned users about comment, which stands without it
+func (s *Service) notify(ctx context.Context, comment Comment, content string) {
+	docs := make([]notifications.Notification, 0, len(comment.Mentions)+1)
+	add := func(user primitive.ObjectID, kind notifications.Type) {
+		docs = append(docs, notifications.Notification{
+			ID:        primitive.NewObjectID(),
+			User:      user,
+			Type:      kind,
+			Key:       string(kind) + ":" + comment.ID.Hex(),
+			Title:     content,
+			TaskID:    &comment.Task,
+			FromUser:  &comment.Author,
+			CreatedAt: comment.CreatedAt,
+		})
+	}
+	if comment.Owner != comment.Author {
+		add(comment.Owner, notifications.TaskComment)
+	}
+	for _, user := range comment.Mentions {
+		// the owner already hears about every comment
+		if user != comment.Owner {
+			add(user, notifications.CommentMention)
+		}
+	}
+	if _, err := notifications.Insert(ctx, s.notifications, docs...); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to write comment notifications", xslog.Error(err))
+	}
+}
+
+// List returns a page of the comments on a task the viewer can see, oldest first, with their authors
+func (s *Service) List(ctx context.Context, viewer primitive.ObjectID, taskID primitive.ObjectID, query xpage.Query) (*xpage.Page[Comment], error) {
+	if _, _, err := s.task(ctx, viewer, taskID); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"task": taskID}
+	if query.Cursor != "" {
+		after, err := xpage.Decode(query.Cursor, nil)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(filter, xpage.After("_id", 1, nil, after))
+	}
+	size := query.Size(defaultLimit)
+	cursor, err := s.comments.Find(ctx, filter, options.Find().SetSort(xpage.Sort("_id", 1)).SetLimit(int64(size+1)))
+	if err != nil {
+		return nil, err
+	}
+	comments := make([]Comment, 0)
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(comments))
+	for _, c := range comments {
+		ids = append(ids, c.Author)
+	}
+	cursor, err = s.users.Find(ctx, softdelete.Filter(bson.M{"_id": bson.M{"$in": ids}}),
+		options.Find().SetProjection(bson.M{"display_name": 1, "handle": 1, "profile_picture": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var authors []Author
+	if err := cursor.All(ctx, &authors); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*Author, len(authors))
+	for i := range authors {
+		byID[authors[i].ID] = &authors[i]
+	}
+	for i := range comments {
+		comments[i].User = byID[comments[i].Author]
+	}
+	return xpage.New(comments, size, func(c Comment) (any, primitive.ObjectID) {
+		return nil, c.ID
+	}), nil
+}
+
+// Delete removes a comment; its author and the task's owner may
+func (s *Service) Delete(ctx context.Context, userID primitive.ObjectID, taskID primitive.ObjectID, id primitive.ObjectID) error {
+	result, err := s.comments.DeleteOne(ctx, bson.M{
+		"_id":  id,
+		"task": taskID,
+		"$or":  bson.A{bson.M{"user": userID}, bson.M{"owner": userID}},
+	})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCommentNotFound
+	}
+	return nil
+}
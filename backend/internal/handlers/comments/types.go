@@ -0,0 +1,58 @@
+package comments
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	Collection = "comments"
+
+	defaultLimit = 50
+)
+
+var (
+	ErrTaskNotFound    = errors.New("task not found")
+	ErrNotCompleted    = errors.New("only completed tasks can be commented on")
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+// Comment is a reply to a completed task, from its owner or one of the friends it was shared with
+type Comment struct {
+	ID   primitive.ObjectID `bson:"_id" json:"id"`
+	Task primitive.ObjectID `bson:"task" json:"task_id"`
+	// the task's owner, who can delete any comment on it
+	Owner  primitive.ObjectID `bson:"owner" json:"-"`
+	Author primitive.ObjectID `bson:"user" json:"user_id"`
+	Text   string             `bson:"text" json:"text"`
+	// the users @mentioned in the text who can see the task
+	Mentions  []primitive.ObjectID `bson:"mentions,omitempty" json:"mentions,omitempty"`
+	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+	// filled in for listings
+	User *Author `bson:"-" json:"user,omitempty"`
+}
+
+type Author struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	DisplayName    string             `bson:"display_name" json:"display_name"`
+	Handle         string             `bson:"handle" json:"handle"`
+	ProfilePicture string             `bson:"profile_picture" json:"profile_picture"`
+}
+
+type CreateCommentParams struct {
+	Text string `validate:"required,min=1,max=1000" json:"text"`
+}
+
+/*
+Comments Service to be used by Comments Handler to interact with the
+Database layer of the application
+*/
+
+type Service struct {
+	users         *mongo.Collection
+	comments      *mongo.Collection
+	notifications *mongo.Collection
+}
@@ -0,0 +1,58 @@
+package dev
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=dev.go -destination=mock_test.go -package=dev
+
+// Seeder fills and clears the development data set
+type Seeder interface {
+	Seed(ctx context.Context, req SeedRequest) (*SeedResponse, error)
+	Reset(ctx context.Context) (int64, error)
+}
+
+var _ Seeder = (*Service)(nil)
+
+/*
+Handler to execute business logic for the development endpoints
+*/
+type Handler struct {
+	service Seeder
+}
+
+// Seed generates a data set from a profile, replacing any earlier seeded data
+func (h *Handler) Seed(c *fiber.Ctx) error {
+	var req SeedRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	response, err := h.service.Seed(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+	slog.LogAttrs(c.UserContext(), slog.LevelInfo, "Environment seeded",
+		slog.String("profile", response.Seeded.Profile), slog.Int("users", response.Seeded.Users))
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// Reset removes everything seeding created
+func (h *Handler) Reset(c *fiber.Ctx) error {
+	removed, err := h.service.Reset(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"removed": removed})
+}
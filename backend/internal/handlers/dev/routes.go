@@ -0,0 +1,27 @@
+package dev
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers. Only mounted when APP_DEV_ENDPOINTS is set
+and the database isn't production.
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Dev := apiV1.Group("/dev")
+	Dev.Post("/seed", handler.Seed)
+	Dev.Delete("/seed", handler.Reset)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/dev/seed":   {Summary: "Fill the database with generated users", Request: SeedRequest{}, Response: SeedResponse{}, Status: fiber.StatusCreated},
+		"DELETE /api/v1/dev/seed": {Summary: "Remove the generated users", Response: fiber.Map{}},
+	})
+}
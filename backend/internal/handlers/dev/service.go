@@ -0,0 +1,48 @@
+package dev
+
+import (
+	"context"
+
+	"github.com/abhikaboy/SocialToDo/internal/seed"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// newService receives the map of collections, seeding writes to several of them
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{collections}
+}
+
+func (s *Service) Seed(ctx context.Context, req SeedRequest) (*SeedResponse, error) {
+	profile, err := seed.Lookup(req.Profile)
+	if err != nil {
+		return nil, err
+	}
+	override(&profile.Users, req.Users)
+	override(&profile.FriendsPerUser, req.FriendsPerUser)
+	override(&profile.CategoriesPerUser, req.CategoriesPerUser)
+	override(&profile.TasksPerCategory, req.TasksPerCategory)
+	override(&profile.ActivityPerUser, req.ActivityPerUser)
+	override(&profile.CompletedRatio, req.CompletedRatio)
+	override(&profile.HistoryDays, req.HistoryDays)
+
+	response := &SeedResponse{}
+	if req.Reset == nil || *req.Reset {
+		if response.Removed, err = seed.Reset(ctx, s.collections); err != nil {
+			return nil, err
+		}
+	}
+	if response.Seeded, err = seed.Run(ctx, s.collections, profile, req.Seed); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (s *Service) Reset(ctx context.Context) (int64, error) {
+	return seed.Reset(ctx, s.collections)
+}
+
+func override[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
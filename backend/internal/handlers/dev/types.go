@@ -0,0 +1,36 @@
+package dev
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/seed"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Dev Service to be used by Dev Handler to interact with the
+Database layer of the application
+*/
+type Service struct {
+	collections map[string]*mongo.Collection
+}
+
+/*
+SeedRequest picks a built-in profile; the other fields override its sizes.
+Reset defaults to true so seeding twice replaces the first data set.
+*/
+type SeedRequest struct {
+	Profile           string   `validate:"required,oneof=small demo load" json:"profile"`
+	Seed              int64    `json:"seed"`
+	Reset             *bool    `json:"reset"`
+	Users             *int     `validate:"omitempty,min=1,max=5000" json:"users"`
+	FriendsPerUser    *int     `validate:"omitempty,min=0,max=200" json:"friends_per_user"`
+	CategoriesPerUser *int     `validate:"omitempty,min=0,max=50" json:"categories_per_user"`
+	TasksPerCategory  *int     `validate:"omitempty,min=0,max=200" json:"tasks_per_category"`
+	ActivityPerUser   *int     `validate:"omitempty,min=0,max=1000" json:"activity_per_user"`
+	CompletedRatio    *float64 `validate:"omitempty,min=0,max=1" json:"completed_ratio"`
+	HistoryDays       *int     `validate:"omitempty,min=1,max=730" json:"history_days"`
+}
+
+type SeedResponse struct {
+	Removed int64        `json:"removed"`
+	Seeded  *seed.Result `json:"seeded"`
+}
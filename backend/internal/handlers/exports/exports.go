@@ -0,0 +1,162 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=exports.go -destination=mock_test.go -package=exports
+
+// Exporter runs and hands out the user's exports
+type Exporter interface {
+	Start(ctx context.Context, userID primitive.ObjectID, req ExportRequest) (*Export, error)
+	GetExport(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Export, error)
+	Archive(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (key string, url string, err error)
+	SignedArchive(ctx context.Context, id primitive.ObjectID, expires string, signature string) (key string, url string, err error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+var _ Exporter = (*Service)(nil)
+
+/*
+Handler to execute business logic for Exports Endpoint
+*/
+type Handler struct {
+	service Exporter
+}
+
+var validator = xvalidator.Validator
+
+// StartExport queues an export, the client polls GetExport until it is done
+func (h *Handler) StartExport(c *fiber.Ctx) error {
+	var req ExportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	export, err := h.service.Start(c.UserContext(), userID(c), req)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	case errors.Is(err, notion.ErrDisabled):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Notion is not available",
+		})
+	case errors.Is(err, ErrNotConnected):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Connect Notion first",
+		})
+	case err != nil:
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(export)
+}
+
+// StartTakeout queues an export of everything in the account; the user is notified when it can be downloaded
+func (h *Handler) StartTakeout(c *fiber.Ctx) error {
+	export, err := h.service.Start(c.UserContext(), userID(c), ExportRequest{Format: string(Takeout)})
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(export)
+}
+
+func (h *Handler) GetExport(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	export, err := h.service.GetExport(c.UserContext(), userID(c), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Export not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(export)
+}
+
+// Download redirects to the archive when the backend presigns and streams it otherwise
+func (h *Handler) Download(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	key, url, err := h.service.Archive(c.UserContext(), userID(c), id)
+	return h.send(c, key, url, err)
+}
+
+// SignedDownload is Download for the signed link of an export, which needs no session
+func (h *Handler) SignedDownload(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	key, url, err := h.service.SignedArchive(c.UserContext(), id, c.Query("expires"), c.Query("signature"))
+	if errors.Is(err, ErrBadLink) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Download link is invalid or has expired",
+		})
+	}
+	return h.send(c, key, url, err)
+}
+
+// send redirects to the archive when the backend presigned url and streams key otherwise
+func (h *Handler) send(c *fiber.Ctx, key string, url string, err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Export not found",
+		})
+	case errors.Is(err, ErrNotReady):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Export has no archive to download",
+		})
+	case err != nil:
+		return err
+	}
+	if url != "" {
+		return c.Redirect(url, fiber.StatusFound)
+	}
+
+	body, err := h.service.Open(c.UserContext(), key)
+	if errors.Is(err, xfiles.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Export has expired",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="socialtodo-export.zip"`)
+	return c.SendStream(body)
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	return id
+}
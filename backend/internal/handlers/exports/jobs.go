@@ -0,0 +1,215 @@
+package exports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	ExportJob = "export.run"
+
+	exportAttempts = 3
+	// archives are removed after this, a new export is a tap away
+	retention = 7 * 24 * time.Hour
+)
+
+type ExportPayload struct {
+	ExportID primitive.ObjectID `bson:"export_id"`
+}
+
+func RegisterJobs(worker *jobs.Worker, collections map[string]*mongo.Collection, files xfiles.Backend, notionCfg config.Notion, cfg config.Uploads) {
+	s := newService(collections, files, notion.New(collections, notionCfg), cfg)
+	worker.Handle(ExportJob, s.runExport)
+}
+
+// RegisterSchedules removes old exports along with their archives
+func RegisterSchedules(cron *scheduler.Scheduler, collections map[string]*mongo.Collection, files xfiles.Backend) {
+	s := &Service{Exports: collections[Collection], files: files}
+	cron.Register("exports-purge", "30 3 * * *", 10*time.Minute, s.purge)
+}
+
+/*
+runExport renders the export and stores the archive or creates the Notion
+pages, telling the user when a takeout is ready. Only the last attempt marks
+the export failed; a retried Notion export may create a category's page again.
+*/
+func (s *Service) runExport(ctx context.Context, job *jobs.Job) error {
+	var payload ExportPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var export Export
+	if err := s.Exports.FindOne(ctx, bson.M{"_id": payload.ExportID}).Decode(&export); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	if export.Status == Done && export.Format == Takeout {
+		// finished before the notification was written
+		return s.notifyReady(ctx, &export)
+	}
+	if export.Status == Done || export.Status == Failed {
+		return nil
+	}
+	if err := s.setExport(ctx, export.ID, bson.M{"status": Running}); err != nil {
+		return err
+	}
+
+	set, err := s.render(ctx, &export)
+	if err != nil {
+		permanent := errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, notion.ErrRevoked) ||
+			errors.Is(err, notion.ErrNoPage) || errors.Is(err, ErrNotConnected)
+		if !permanent && job.Attempts < job.MaxAttempts {
+			return err
+		}
+		if finishErr := s.finish(ctx, export.ID, Failed, bson.M{"error": exportError(err)}); finishErr != nil {
+			return finishErr
+		}
+		return jobs.Permanent(err)
+	}
+	if err := s.finish(ctx, export.ID, Done, set); err != nil {
+		return err
+	}
+	if export.Format == Takeout {
+		return s.notifyReady(ctx, &export)
+	}
+	return nil
+}
+
+// render returns the fields a finished export records
+func (s *Service) render(ctx context.Context, export *Export) (bson.M, error) {
+	if export.Format == Takeout {
+		body, err := s.takeout(ctx, export.User)
+		if err != nil {
+			return nil, err
+		}
+		return s.store(ctx, export, body)
+	}
+
+	categories, err := s.categories(ctx, export.User, export.Category)
+	if err != nil {
+		return nil, err
+	}
+
+	if export.Format == Markdown {
+		body, err := archive(categories)
+		if err != nil {
+			return nil, err
+		}
+		return s.store(ctx, export, body)
+	}
+
+	integration, err := s.notion.Store().Get(ctx, export.User)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !integration.Connected()) {
+		return nil, ErrNotConnected
+	}
+	if err != nil {
+		return nil, err
+	}
+	client := s.notion.Client()
+	parent := export.Parent
+	if parent == "" {
+		if parent, err = client.FirstPage(ctx, integration.Token); err != nil {
+			return nil, err
+		}
+	}
+	pages := make([]Page, 0, len(categories))
+	for i := range categories {
+		url, err := client.CreatePage(ctx, integration.Token, parent, categories[i].Name, blocks(&categories[i]))
+		if errors.Is(err, notion.ErrRevoked) {
+			if err := s.notion.Store().Disconnected(ctx, integration.ID); err != nil {
+				return nil, err
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, Page{Category: categories[i].Name, URL: url})
+	}
+	return bson.M{"pages": pages}, nil
+}
+
+// store puts the export's archive in the file backend
+func (s *Service) store(ctx context.Context, export *Export, body []byte) (bson.M, error) {
+	key := "exports/" + export.ID.Hex() + ".zip"
+	if err := s.files.Put(ctx, key, "application/zip", bytes.NewReader(body)); err != nil {
+		return nil, err
+	}
+	return bson.M{"key": key}, nil
+}
+
+// notifyReady puts the finished takeout in the user's inbox, once however often the job runs
+func (s *Service) notifyReady(ctx context.Context, export *Export) error {
+	_, err := notifications.Insert(ctx, s.Notifications, notifications.Notification{
+		ID:        primitive.NewObjectID(),
+		User:      export.User,
+		Type:      notifications.ExportReady,
+		Key:       string(notifications.ExportReady) + ":" + export.ID.Hex(),
+		Title:     "Your data export is ready",
+		ExportID:  &export.ID,
+		CreatedAt: time.Now(),
+	})
+	return err
+}
+
+func (s *Service) setExport(ctx context.Context, id primitive.ObjectID, set bson.M) error {
+	set["updated_at"] = time.Now()
+	_, err := s.Exports.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+func (s *Service) finish(ctx context.Context, id primitive.ObjectID, status Status, set bson.M) error {
+	now := time.Now()
+	set["status"] = status
+	set["finished_at"] = now
+	return s.setExport(ctx, id, set)
+}
+
+func (s *Service) purge(ctx context.Context) error {
+	filter := bson.M{"created_at": bson.M{"$lt": time.Now().Add(-retention)}}
+	cursor, err := s.Exports.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var old []Export
+	if err := cursor.All(ctx, &old); err != nil {
+		return err
+	}
+	for _, export := range old {
+		if export.Key == "" {
+			continue
+		}
+		if err := s.files.Delete(ctx, export.Key); err != nil && !errors.Is(err, xfiles.ErrNotFound) {
+			return err
+		}
+	}
+	_, err = s.Exports.DeleteMany(ctx, filter)
+	return err
+}
+
+// exportError is what the client is shown, internal errors stay in the job's last_error
+func exportError(err error) string {
+	switch {
+	case errors.Is(err, ErrNotConnected), errors.Is(err, notion.ErrRevoked):
+		return "Notion is not connected"
+	case errors.Is(err, notion.ErrNoPage):
+		return "No Notion page was shared to export into"
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return "The category no longer exists"
+	default:
+		return "The export failed"
+	}
+}
@@ -0,0 +1,116 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+)
+
+/*
+Both formats list a category's tasks as a checklist, open ones first, with
+the due date and notes under each task.
+*/
+
+func ordered(tasks []task.TaskDocument) []task.TaskDocument {
+	open := make([]task.TaskDocument, 0, len(tasks))
+	var done []task.TaskDocument
+	for _, t := range tasks {
+		if t.Completed {
+			done = append(done, t)
+		} else {
+			open = append(open, t)
+		}
+	}
+	return append(open, done...)
+}
+
+// details is the line under a task: its due date, priority and labels
+func details(t *task.TaskDocument) string {
+	var parts []string
+	if t.DueDate != nil {
+		parts = append(parts, "due "+t.DueDate.UTC().Format(time.DateOnly))
+	}
+	parts = append(parts, fmt.Sprintf("P%d", t.Priority))
+	for _, label := range t.Labels {
+		parts = append(parts, "#"+label)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func markdown(c *category.CategoryDocument) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n\n", c.Name)
+	for _, t := range ordered(c.Tasks) {
+		check := " "
+		if t.Completed {
+			check = "x"
+		}
+		fmt.Fprintf(&b, "- [%s] %s\n", check, strings.ReplaceAll(t.Content, "\n", " "))
+		fmt.Fprintf(&b, "  %s\n", details(&t))
+		for _, line := range strings.Split(strings.TrimSpace(t.Notes), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "  > %s\n", line)
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// archive zips one Markdown file per category, named after it
+func archive(categories []category.CategoryDocument) ([]byte, error) {
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	used := map[string]int{}
+	for i := range categories {
+		name := filename(categories[i].Name)
+		if used[name]++; used[name] > 1 {
+			name = fmt.Sprintf("%s (%d)", name, used[name])
+		}
+		f, err := w.Create(name + ".md")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(markdown(&categories[i])); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// filename keeps a category name usable as a file name in any OS
+func filename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 32 {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" || name == "." || name == ".." {
+		return "Untitled"
+	}
+	return name
+}
+
+func blocks(c *category.CategoryDocument) []notion.Block {
+	list := make([]notion.Block, 0, len(c.Tasks))
+	for _, t := range ordered(c.Tasks) {
+		content := t.Content
+		if d := details(&t); d != "" {
+			content += " (" + d + ")"
+		}
+		list = append(list, notion.ToDo(content, t.Completed))
+		if notes := strings.TrimSpace(t.Notes); notes != "" {
+			list = append(list, notion.Paragraph(notes))
+		}
+	}
+	return list
+}
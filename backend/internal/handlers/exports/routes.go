@@ -0,0 +1,40 @@
+package exports
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, files xfiles.Backend, authenticate fiber.Handler, notionCfg config.Notion, cfg config.Uploads, secret string, publicURL string) {
+	service := newService(collections, files, notion.New(collections, notionCfg), cfg)
+	service.secret, service.publicURL = secret, publicURL
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	// opened in a browser, which carries no session; ahead of the group so it isn't authenticated
+	apiV1.Get("/exports/:id/archive", handler.SignedDownload)
+
+	Users := apiV1.Group("/users", authenticate)
+	Users.Post("/me/export", handler.StartTakeout)
+
+	Exports := apiV1.Group("/exports", authenticate)
+	Exports.Post("/", handler.StartExport)
+	Exports.Get("/:id", handler.GetExport)
+	Exports.Get("/:id/download", handler.Download)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/users/me/export":     {Summary: "Start exporting everything in the caller's account, notifying them when it is ready", Auth: true, Response: Export{}, Status: fiber.StatusAccepted},
+		"GET /api/v1/exports/:id/archive":  {Summary: "Download an export through its signed link", Query: xopenapi.Query{"expires", "signature"}, Response: []byte{}, ResponseType: "application/zip"},
+		"POST /api/v1/exports/":            {Summary: "Start exporting the caller's data", Auth: true, Request: ExportRequest{}, Response: Export{}, Status: fiber.StatusAccepted},
+		"GET /api/v1/exports/:id":          {Summary: "Check on an export", Auth: true, Response: Export{}},
+		"GET /api/v1/exports/:id/download": {Summary: "Download a finished export, or be redirected to it", Auth: true, Response: []byte{}, ResponseType: "application/zip"},
+	})
+}
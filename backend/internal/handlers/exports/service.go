@@ -0,0 +1,157 @@
+package exports
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/comments"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const Collection = "exports"
+
+var (
+	ErrNotConnected = errors.New("notion is not connected")
+	ErrNotReady     = errors.New("export is not ready")
+)
+
+// newService receives the map of collections and picks out Exports, Users and what a takeout reads
+func newService(collections map[string]*mongo.Collection, files xfiles.Backend, notion *notion.Notion, cfg config.Uploads) *Service {
+	return &Service{
+		Exports:         collections[Collection],
+		Users:           collections["users"],
+		Activity:        collections["activity"],
+		ActivityArchive: collections["activity_archive"],
+		Comments:        collections[comments.Collection],
+		Notifications:   collections[notifications.Collection],
+		files:           files,
+		notion:          notion,
+		queue:           jobs.New(collections[jobs.Collection]),
+		cfg:             cfg,
+	}
+}
+
+// Start queues an export; Notion exports need a connected workspace up front so the user hears about it right away
+func (s *Service) Start(ctx context.Context, userID primitive.ObjectID, req ExportRequest) (*Export, error) {
+	now := time.Now()
+	export := &Export{
+		ID:        primitive.NewObjectID(),
+		User:      userID,
+		Format:    Format(req.Format),
+		Parent:    req.Parent,
+		Status:    Queued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.Category != "" {
+		id, _ := primitive.ObjectIDFromHex(req.Category)
+		if _, err := s.categories(ctx, userID, &id); err != nil {
+			return nil, err
+		}
+		export.Category = &id
+	}
+	if export.Format == Notion {
+		if !s.notion.Enabled() {
+			return nil, notion.ErrDisabled
+		}
+		integration, err := s.notion.Store().Get(ctx, userID)
+		if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !integration.Connected()) {
+			return nil, ErrNotConnected
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.Exports.InsertOne(ctx, export); err != nil {
+		return nil, err
+	}
+	if _, err := s.queue.Enqueue(ctx, ExportJob, ExportPayload{ExportID: export.ID}, jobs.MaxAttempts(exportAttempts)); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+// GetExport returns one of the user's exports with its archive link, mongo.ErrNoDocuments for anyone else's
+func (s *Service) GetExport(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Export, error) {
+	var export Export
+	if err := s.Exports.FindOne(ctx, bson.M{"_id": id, "user": userID}).Decode(&export); err != nil {
+		return nil, err
+	}
+	s.link(&export, time.Now())
+	return &export, nil
+}
+
+// Archive returns a direct download URL for a finished export's archive, or "" when the API has to serve key
+func (s *Service) Archive(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (key string, url string, err error) {
+	export, err := s.GetExport(ctx, userID, id)
+	if err != nil {
+		return "", "", err
+	}
+	return s.archive(ctx, export)
+}
+
+// SignedArchive is Archive for whoever holds the export's signed link, ErrBadLink when it doesn't check out
+func (s *Service) SignedArchive(ctx context.Context, id primitive.ObjectID, expires string, signature string) (key string, url string, err error) {
+	if !verify(s.secret, id, expires, signature, time.Now()) {
+		return "", "", ErrBadLink
+	}
+	var export Export
+	if err := s.Exports.FindOne(ctx, bson.M{"_id": id}).Decode(&export); err != nil {
+		return "", "", err
+	}
+	return s.archive(ctx, &export)
+}
+
+func (s *Service) archive(ctx context.Context, export *Export) (key string, url string, err error) {
+	if export.Status != Done || export.Key == "" {
+		return "", "", ErrNotReady
+	}
+	url, err = s.files.PresignGet(ctx, export.Key, s.cfg.PresignTTL)
+	if errors.Is(err, xfiles.ErrNoPresign) {
+		return export.Key, "", nil
+	}
+	return export.Key, url, err
+}
+
+func (s *Service) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.files.Open(ctx, key)
+}
+
+// categories returns the user's live categories with their live tasks, only the one with id when set
+func (s *Service) categories(ctx context.Context, userID primitive.ObjectID, id *primitive.ObjectID) ([]category.CategoryDocument, error) {
+	var owner struct {
+		Categories []category.CategoryDocument `bson:"categories"`
+	}
+	err := s.Users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(bson.M{"categories": 1}),
+	).Decode(&owner)
+	if err != nil {
+		return nil, err
+	}
+	live := softdelete.Visible(owner.Categories)
+	if id != nil {
+		i := slices.IndexFunc(live, func(c category.CategoryDocument) bool { return c.ID == *id })
+		if i < 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		live = live[i : i+1]
+	}
+	for i := range live {
+		live[i].Tasks = softdelete.Visible(live[i].Tasks)
+	}
+	return live, nil
+}
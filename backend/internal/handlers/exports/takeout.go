@@ -0,0 +1,137 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/comments"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/profile"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+A takeout is the user's copy of their account: their profile, categories with
+their tasks, activity (archived too) and the comments they wrote, one JSON
+file each in a zip. Archives are downloaded through a signed link so the user
+can open it in a browser, away from the app's session.
+*/
+
+// a signed link works this long, or until the archive is purged if that's sooner
+const linkTTL = 24 * time.Hour
+
+// ErrBadLink means an archive link was tampered with or has expired
+var ErrBadLink = errors.New("export link is invalid or expired")
+
+// takeout renders the user's archive
+func (s *Service) takeout(ctx context.Context, userID primitive.ObjectID) ([]byte, error) {
+	var me profile.Me
+	err := s.Users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(profile.Projection)).Decode(&me)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.categories(ctx, userID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	byTime := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	archived, err := find[activity.ActivityDocument](ctx, s.ActivityArchive, bson.M{"user": userID}, byTime)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := find[activity.ActivityDocument](ctx, s.Activity, bson.M{"user": userID}, byTime)
+	if err != nil {
+		return nil, err
+	}
+	written, err := find[comments.Comment](ctx, s.Comments, bson.M{"user": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	w := zip.NewWriter(&b)
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", me},
+		{"categories.json", categories},
+		{"activity.json", append(archived, recent...)},
+		{"comments.json", written},
+	}
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		f, err := w.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := f.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func find[T any](ctx context.Context, collection *mongo.Collection, filter bson.M, opts *options.FindOptions) ([]T, error) {
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]T, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// link fills in the signed URL of a finished export's archive as of now
+func (s *Service) link(export *Export, now time.Time) {
+	if export.Status != Done || export.Key == "" || s.secret == "" {
+		return
+	}
+	expires := now.Add(linkTTL)
+	if purged := export.CreatedAt.Add(retention); purged.Before(expires) {
+		expires = purged
+	}
+	expires = expires.Truncate(time.Second)
+	unix := strconv.FormatInt(expires.Unix(), 10)
+	export.URL = s.publicURL + "/api/v1/exports/" + export.ID.Hex() + "/archive?expires=" + unix +
+		"&signature=" + sign(s.secret, export.ID, unix)
+	export.ExpiresAt = &expires
+}
+
+func sign(secret string, id primitive.ObjectID, expires string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("export:" + id.Hex() + ":" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verify reports whether signature is the link's and it hasn't expired by now
+func verify(secret string, id primitive.ObjectID, expires string, signature string, now time.Time) bool {
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || secret == "" || now.Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(sign(secret, id, expires)))
+}
@@ -0,0 +1,61 @@
+package exports
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSignedLink(t *testing.T) {
+	now := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	s := &Service{secret: "secret", publicURL: "https://api.example.com"}
+	export := &Export{ID: primitive.NewObjectID(), Status: Done, Key: "exports/a.zip", CreatedAt: now.Add(-time.Hour)}
+	s.link(export, now)
+	if export.URL == "" || export.ExpiresAt == nil {
+		t.Fatalf("expected a link, got %+v", export)
+	}
+	if !export.ExpiresAt.Equal(now.Add(linkTTL)) {
+		t.Errorf("expected the link to expire at %v, got %v", now.Add(linkTTL), export.ExpiresAt)
+	}
+	link, err := url.Parse(export.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expires, signature := link.Query().Get("expires"), link.Query().Get("signature")
+
+	tests := []struct {
+		name      string
+		secret    string
+		id        primitive.ObjectID
+		expires   string
+		signature string
+		at        time.Time
+		expected  bool
+	}{
+		{"the link", "secret", export.ID, expires, signature, now, true},
+		{"expired", "secret", export.ID, expires, signature, now.Add(linkTTL + time.Second), false},
+		{"another export", "secret", primitive.NewObjectID(), expires, signature, now, false},
+		{"a later expiry", "secret", export.ID, "9999999999", signature, now, false},
+		{"another secret", "rotated", export.ID, expires, signature, now, false},
+		{"no secret", "", export.ID, expires, sign("", export.ID, expires), now, false},
+	}
+	for _, tt := range tests {
+		if got := verify(tt.secret, tt.id, tt.expires, tt.signature, tt.at); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+
+	// links don't outlive the archive
+	old := &Export{ID: primitive.NewObjectID(), Status: Done, Key: "exports/b.zip", CreatedAt: now.Add(-retention + time.Hour)}
+	s.link(old, now)
+	if old.ExpiresAt == nil || !old.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the link to expire with the archive at %v, got %v", now.Add(time.Hour), old.ExpiresAt)
+	}
+	queued := &Export{ID: primitive.NewObjectID(), Status: Queued}
+	s.link(queued, now)
+	if queued.URL != "" {
+		t.Errorf("expected no link before the export is done, got %s", queued.URL)
+	}
+}
@@ -0,0 +1,87 @@
+package exports
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Format string
+
+const (
+	// a zip with one Markdown file per category
+	Markdown Format = "markdown"
+	// one Notion page per category
+	Notion Format = "notion"
+	// a zip of everything the account holds as JSON, for the user to take elsewhere
+	Takeout Format = "takeout"
+)
+
+type Status string
+
+const (
+	Queued  Status = "queued"
+	Running Status = "running"
+	Done    Status = "done"
+	Failed  Status = "failed"
+)
+
+type ExportRequest struct {
+	Format string `validate:"required,oneof=markdown notion takeout" json:"format"`
+	// one category, the whole account when empty
+	Category string `validate:"omitempty,mongodb" json:"category"`
+	// the Notion page the export goes under, a page shared with the integration when empty
+	Parent string `validate:"max=100" json:"parent"`
+}
+
+// Page is a Notion page the export made
+type Page struct {
+	Category string `bson:"category" json:"category"`
+	URL      string `bson:"url" json:"url"`
+}
+
+type Export struct {
+	ID       primitive.ObjectID  `bson:"_id" json:"id"`
+	User     primitive.ObjectID  `bson:"user" json:"-"`
+	Format   Format              `bson:"format" json:"format"`
+	Category *primitive.ObjectID `bson:"category,omitempty" json:"category,omitempty"`
+	Parent   string              `bson:"parent,omitempty" json:"-"`
+	Status   Status              `bson:"status" json:"status"`
+	// where the archive is stored, download it through the API
+	Key string `bson:"key,omitempty" json:"-"`
+	// a signed link to the archive that needs no session, working until ExpiresAt
+	URL       string     `bson:"-" json:"url,omitempty"`
+	ExpiresAt *time.Time `bson:"-" json:"expires_at,omitempty"`
+	Pages     []Page     `bson:"pages,omitempty" json:"pages,omitempty"`
+	// what went wrong, for the user
+	Error      string     `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+/*
+Exports Service to be used by Exports Handler to render a user's categories
+as Markdown or Notion pages, or their whole account as a takeout, in the
+background
+*/
+type Service struct {
+	Exports         *mongo.Collection
+	Users           *mongo.Collection
+	Activity        *mongo.Collection
+	ActivityArchive *mongo.Collection
+	Comments        *mongo.Collection
+	Notifications   *mongo.Collection
+	files           xfiles.Backend
+	notion          *notion.Notion
+	queue           *jobs.Queue
+	cfg             config.Uploads
+	// signs archive links, and where they point
+	secret    string
+	publicURL string
+}
@@ -0,0 +1,134 @@
+package feeds
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=feeds.go -destination=mock_test.go -package=feeds
+
+// Feeds is the calendar feed service as Handler uses it
+type Feeds interface {
+	Create(ctx context.Context, userID primitive.ObjectID, req CreateFeedRequest) (*Feed, error)
+	List(ctx context.Context, userID primitive.ObjectID) ([]Feed, error)
+	Rotate(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Feed, error)
+	Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	Calendar(ctx context.Context, token string) ([]byte, error)
+}
+
+var _ Feeds = (*Service)(nil)
+
+/*
+Handler to execute business logic for Feeds Endpoint
+*/
+type Handler struct {
+	service Feeds
+}
+
+var validator = xvalidator.Validator
+
+func (h *Handler) CreateFeed(c *fiber.Ctx) error {
+	var req CreateFeedRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	feed, err := h.service.Create(c.UserContext(), userID(c), req)
+	switch {
+	case errors.Is(err, ErrTooManyFeeds):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Too many calendar feeds, revoke one first",
+		})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	case err != nil:
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(feed)
+}
+
+func (h *Handler) ListFeeds(c *fiber.Ctx) error {
+	feeds, err := h.service.List(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(feeds)
+}
+
+// RotateFeed replaces the feed's token, answering with its new URL
+func (h *Handler) RotateFeed(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	feed, err := h.service.Rotate(c.UserContext(), userID(c), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Calendar feed not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(feed)
+}
+
+func (h *Handler) RevokeFeed(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	err = h.service.Revoke(c.UserContext(), userID(c), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Calendar feed not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Calendar serves a feed to calendar apps, the token in the query or path is its only credential
+func (h *Handler) Calendar(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		token = strings.TrimSuffix(c.Params("token"), ".ics")
+	}
+	if token == "" {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	body, err := h.service.Calendar(c.UserContext(), token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, "private, max-age=300")
+	return c.Send(body)
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	return id
+}
@@ -0,0 +1,149 @@
+package feeds
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+)
+
+/*
+iCalendar (RFC 5545) rendering. Each open task with a due date is a short
+event at its due time; calendar apps show VTODOs poorly if at all. The UID is
+derived from the task id, so edits update the event in place. The head of a
+recurring series repeats by its rule, the occurrences before it are events of
+their own until they are completed.
+*/
+
+const (
+	stamp = "20060102T150405Z"
+	// a local time, for a series counted in its time zone
+	localStamp = "20060102T150405"
+	// like the Google Calendar sync, so a task looks the same in both
+	eventDuration = "PT30M"
+	// content lines are folded at 75 octets
+	lineLimit = 75
+)
+
+func calendar(name string, tasks []feedTask, now time.Time) []byte {
+	var b strings.Builder
+	line(&b, "BEGIN:VCALENDAR")
+	line(&b, "VERSION:2.0")
+	line(&b, "PRODID:-//SocialToDo//Calendar Feed//EN")
+	line(&b, "CALSCALE:GREGORIAN")
+	line(&b, "METHOD:PUBLISH")
+	line(&b, "X-WR-CALNAME:"+escape(name))
+	// how often calendar apps that honour it should refetch
+	line(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	line(&b, "X-PUBLISHED-TTL:PT1H")
+	for _, t := range tasks {
+		updated := t.UpdatedAt
+		if updated.IsZero() {
+			updated = now
+		}
+		line(&b, "BEGIN:VEVENT")
+		line(&b, "UID:task-"+t.ID.Hex()+"@socialtodo.app")
+		line(&b, "DTSTAMP:"+updated.UTC().Format(stamp))
+		if t.Recurrence != nil {
+			line(&b, dtstart(t.Recurrence, t.DueDate))
+			line(&b, "RRULE:"+rrule(t.Recurrence))
+		} else {
+			line(&b, "DTSTART:"+t.DueDate.UTC().Format(stamp))
+		}
+		line(&b, "DURATION:"+eventDuration)
+		line(&b, "SUMMARY:"+escape(t.Content))
+		if t.Notes != "" {
+			line(&b, "DESCRIPTION:"+escape(t.Notes))
+		}
+		line(&b, "CATEGORIES:"+escape(t.Category))
+		line(&b, "END:VEVENT")
+	}
+	line(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+/*
+dtstart starts a series in its own time zone, so the weekdays and days of the
+month it repeats on, and its time of day across DST changes, are the ones
+Recurrence.Next counts in
+*/
+func dtstart(r *task.Recurrence, due time.Time) string {
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil || loc == time.UTC {
+		return "DTSTART:" + due.UTC().Format(stamp)
+	}
+	return "DTSTART;TZID=" + loc.String() + ":" + due.In(loc).Format(localStamp)
+}
+
+var weekdays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+/*
+rrule is the rule of a series repeating from its head's due date. Weeks
+start on Sunday as they do for Recurrence.Next, and a monthly series on a day
+some months don't have falls on their last day instead, the last of the days
+from the 28th up to it.
+*/
+func rrule(r *task.Recurrence) string {
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start := r.Start.In(loc)
+
+	parts := []string{"FREQ=" + strings.ToUpper(string(r.Freq))}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	switch r.Freq {
+	case task.Weekly:
+		days := slices.Clone(r.Weekdays)
+		if len(days) == 0 {
+			days = []int{int(start.Weekday())}
+		}
+		slices.Sort(days)
+		byDay := make([]string, 0, len(days))
+		for _, day := range slices.Compact(days) {
+			byDay = append(byDay, weekdays[day])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(byDay, ","), "WKST=SU")
+	case task.Monthly:
+		if day := start.Day(); day > 28 {
+			byMonthDay := make([]string, 0, day-27)
+			for d := 28; d <= day; d++ {
+				byMonthDay = append(byMonthDay, strconv.Itoa(d))
+			}
+			parts = append(parts, "BYMONTHDAY="+strings.Join(byMonthDay, ","), "BYSETPOS=-1")
+		}
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format(stamp))
+	}
+	return strings.Join(parts, ";")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func escape(text string) string {
+	return escaper.Replace(text)
+}
+
+// line writes a content line, folding it without splitting a UTF-8 character
+func line(b *strings.Builder, s string) {
+	limit := lineLimit
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// the leading space of a continuation counts toward its length
+		limit = lineLimit - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,57 @@
+package feeds
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecurringEvents(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a Monday
+	due := time.Date(2026, time.March, 2, 9, 0, 0, 0, berlin)
+	until := time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC)
+	endOfMonth := time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		due        time.Time
+		recurrence *task.Recurrence
+		expected   []string
+	}{
+		{"once", due, nil, []string{"DTSTART:20260302T080000Z"}},
+		{"daily", due, &task.Recurrence{Freq: task.Daily, Start: due}, []string{"DTSTART:20260302T080000Z", "RRULE:FREQ=DAILY\r\n"}},
+		{
+			"every other week in a time zone", due,
+			&task.Recurrence{Freq: task.Weekly, Interval: 2, Weekdays: []int{3, 1}, TimeZone: "Europe/Berlin", Until: &until, Start: due},
+			[]string{"DTSTART;TZID=Europe/Berlin:20260302T090000", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;WKST=SU;UNTIL=20260630T000000Z"},
+		},
+		{
+			"monthly at the end of the month", endOfMonth.AddDate(0, 1, -3),
+			&task.Recurrence{Freq: task.Monthly, Start: endOfMonth},
+			[]string{"DTSTART:20260228T090000Z", "RRULE:FREQ=MONTHLY;BYMONTHDAY=28,29,30,31;BYSETPOS=-1"},
+		},
+	}
+	for _, tt := range tests {
+		body := string(calendar("SocialToDo", []feedTask{{
+			ID:         primitive.NewObjectID(),
+			Content:    "Water the plants",
+			DueDate:    tt.due,
+			Recurrence: tt.recurrence,
+		}}, time.Now()))
+		for _, expected := range tt.expected {
+			if !strings.Contains(body, expected) {
+				t.Errorf("%s: expected %q in\n%s", tt.name, expected, body)
+			}
+		}
+		if tt.recurrence == nil && strings.Contains(body, "RRULE") {
+			t.Errorf("%s: expected no RRULE in\n%s", tt.name, body)
+		}
+	}
+}
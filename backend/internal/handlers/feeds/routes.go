@@ -0,0 +1,36 @@
+package feeds
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler, publicURL string) {
+	service := newService(collections, publicURL)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	// fetched by calendar apps, which carry no session; /ics/ is where the first links pointed
+	apiV1.Get("/calendar.ics", handler.Calendar)
+	app.Get("/ics/:token", handler.Calendar)
+
+	Feeds := apiV1.Group("/calendar-feeds", authenticate)
+	Feeds.Post("/", handler.CreateFeed)
+	Feeds.Get("/", handler.ListFeeds)
+	Feeds.Post("/:id/rotate", handler.RotateFeed)
+	Feeds.Delete("/:id", handler.RevokeFeed)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/calendar.ics":               {Summary: "A calendar feed of the owner's tasks due, recurring ones repeating by their rule", Query: xopenapi.Query{"token"}, Response: "", ResponseType: "text/calendar"},
+		"GET /ics/:token":                        {Summary: "A calendar feed of the owner's tasks, at the path of the first feed links", Response: "", ResponseType: "text/calendar"},
+		"POST /api/v1/calendar-feeds/":           {Summary: "Create a calendar feed link", Auth: true, Request: CreateFeedRequest{}, Response: Feed{}, Status: fiber.StatusCreated},
+		"GET /api/v1/calendar-feeds/":            {Summary: "The caller's calendar feeds", Auth: true, Response: []Feed{}},
+		"POST /api/v1/calendar-feeds/:id/rotate": {Summary: "Replace a calendar feed's secret token, the old link stops working", Auth: true, Response: Feed{}},
+		"DELETE /api/v1/calendar-feeds/:id":      {Summary: "Revoke a calendar feed link", Auth: true, Status: fiber.StatusNoContent},
+	})
+}
@@ -0,0 +1,188 @@
+package feeds
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	Collection = "calendar_feeds"
+
+	maxFeeds = 20
+)
+
+var ErrTooManyFeeds = errors.New("too many calendar feeds")
+
+// newService receives the map of collections and picks out Feeds and Users
+func newService(collections map[string]*mongo.Collection, publicURL string) *Service {
+	return &Service{
+		Feeds:     collections[Collection],
+		Users:     collections["users"],
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+// url is the feed's subscription link; links under /ics/ handed out before it still work
+func (s *Service) url(feed *Feed) {
+	feed.URL = s.publicURL + "/api/v1/calendar.ics?token=" + url.QueryEscape(feed.Token)
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (s *Service) Create(ctx context.Context, userID primitive.ObjectID, req CreateFeedRequest) (*Feed, error) {
+	count, err := s.Feeds.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxFeeds {
+		return nil, ErrTooManyFeeds
+	}
+
+	feed := &Feed{ID: primitive.NewObjectID(), User: userID, CreatedAt: time.Now()}
+	if req.Category != "" {
+		id, _ := primitive.ObjectIDFromHex(req.Category)
+		if _, err := s.categoryName(ctx, userID, id); err != nil {
+			return nil, err
+		}
+		feed.Category = &id
+	}
+	if feed.Token, err = newToken(); err != nil {
+		return nil, err
+	}
+	if _, err := s.Feeds.InsertOne(ctx, feed); err != nil {
+		return nil, err
+	}
+	s.url(feed)
+	return feed, nil
+}
+
+func (s *Service) List(ctx context.Context, userID primitive.ObjectID) ([]Feed, error) {
+	cursor, err := s.Feeds.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	feeds := make([]Feed, 0)
+	if err := cursor.All(ctx, &feeds); err != nil {
+		return nil, err
+	}
+	for i := range feeds {
+		s.url(&feeds[i])
+	}
+	return feeds, nil
+}
+
+/*
+Rotate gives the feed a new token, for when its link has leaked. Calendars
+subscribed to the old link stop updating until they subscribe to the new one.
+*/
+func (s *Service) Rotate(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Feed, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	var feed Feed
+	err = s.Feeds.FindOneAndUpdate(ctx, bson.M{"_id": id, "user": userID},
+		bson.M{"$set": bson.M{"token": token, "rotated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&feed)
+	if err != nil {
+		return nil, err
+	}
+	s.url(&feed)
+	return &feed, nil
+}
+
+func (s *Service) Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	result, err := s.Feeds.DeleteOne(ctx, bson.M{"_id": id, "user": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+/*
+Calendar renders the feed behind token. A feed whose category was deleted
+renders empty rather than failing, so the subscribed calendar just clears.
+*/
+func (s *Service) Calendar(ctx context.Context, token string) ([]byte, error) {
+	var feed Feed
+	if err := s.Feeds.FindOne(ctx, bson.M{"token": token}).Decode(&feed); err != nil {
+		return nil, err
+	}
+
+	name := "SocialToDo"
+	match := softdelete.LiveAt("categories")
+	if feed.Category != nil {
+		category, err := s.categoryName(ctx, feed.User, *feed.Category)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		name += " · " + category
+		match = bson.M{"categories._id": *feed.Category, "categories." + softdelete.Field: nil}
+	}
+
+	cursor, err := s.Users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: softdelete.Filter(bson.M{"_id": feed.User})}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{
+			"$categories.tasks",
+			bson.M{"category": "$categories.name"},
+		}}}}},
+		{{Key: "$match", Value: softdelete.Filter(bson.M{
+			"due_date":  bson.M{"$ne": nil},
+			"completed": bson.M{"$ne": true},
+			"draft":     bson.M{"$ne": true},
+		})}},
+		{{Key: "$sort", Value: bson.M{"due_date": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var tasks []feedTask
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	return calendar(name, tasks, time.Now()), nil
+}
+
+func (s *Service) categoryName(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (string, error) {
+	var owner struct {
+		Categories []category.CategoryDocument `bson:"categories"`
+	}
+	err := s.Users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(bson.M{"categories._id": 1, "categories.name": 1, "categories." + softdelete.Field: 1}),
+	).Decode(&owner)
+	if err != nil {
+		return "", err
+	}
+	live := softdelete.Visible(owner.Categories)
+	i := slices.IndexFunc(live, func(c category.CategoryDocument) bool { return c.ID == id })
+	if i < 0 {
+		return "", mongo.ErrNoDocuments
+	}
+	return live[i].Name, nil
+}
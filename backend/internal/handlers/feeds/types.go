@@ -0,0 +1,53 @@
+package feeds
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Feed is an iCalendar subscription to the user's due dates, one category's
+or all of them. The token in its URL is the only credential, calendar apps
+can't send any other, so revoking the feed deletes it.
+*/
+type Feed struct {
+	ID    primitive.ObjectID `bson:"_id" json:"id"`
+	User  primitive.ObjectID `bson:"user" json:"-"`
+	Token string             `bson:"token" json:"-"`
+	// nil for a feed of every category
+	Category  *primitive.ObjectID `bson:"category,omitempty" json:"category,omitempty"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+	// when the token was last replaced, the URL from before then stopped working
+	RotatedAt *time.Time `bson:"rotated_at,omitempty" json:"rotated_at,omitempty"`
+	URL       string     `bson:"-" json:"url"`
+}
+
+type CreateFeedRequest struct {
+	// the category to subscribe to, every category when empty
+	Category string `validate:"omitempty,mongodb" json:"category"`
+}
+
+// feedTask is what an event is made from
+type feedTask struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Content   string             `bson:"content"`
+	Notes     string             `bson:"notes"`
+	DueDate   time.Time          `bson:"due_date"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+	Category  string             `bson:"category"`
+	// only the head of a series has one, see task.Recurrence
+	Recurrence *task.Recurrence `bson:"recurrence"`
+}
+
+/*
+Feeds Service to be used by Feeds Handler to hand out calendar subscription
+URLs and render the calendars behind them
+*/
+type Service struct {
+	Feeds     *mongo.Collection
+	Users     *mongo.Collection
+	publicURL string
+}
@@ -0,0 +1,46 @@
+package friends
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestBlocks(t *testing.T) {
+	userID := primitive.NewObjectID()
+	other, gone, stranger := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	service := NewMockFriendships(gomock.NewController(t))
+	service.EXPECT().Block(gomock.Any(), userID, other).Return(nil)
+	service.EXPECT().Block(gomock.Any(), userID, gone).Return(ErrUserNotFound)
+	service.EXPECT().Block(gomock.Any(), userID, userID).Return(ErrBlockSelf)
+	service.EXPECT().Unblock(gomock.Any(), userID, other).Return(nil)
+	service.EXPECT().Unblock(gomock.Any(), userID, stranger).Return(ErrNotBlocked)
+	// a blocked user's request is refused as though the blocker didn't exist, see Service.Request
+	service.EXPECT().Request(gomock.Any(), userID, other).Return(Status(""), ErrBlocked)
+	app := serve(service, userID)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"block", http.MethodPost, "/blocks", `{"user_id": "` + other.Hex() + `"}`, fiber.StatusNoContent},
+		{"block a deleted user", http.MethodPost, "/blocks", `{"user_id": "` + gone.Hex() + `"}`, fiber.StatusNotFound},
+		{"block yourself", http.MethodPost, "/blocks", `{"user_id": "` + userID.Hex() + `"}`, fiber.StatusBadRequest},
+		{"befriend a blocked user", http.MethodPost, "/friends/request", `{"user_id": "` + other.Hex() + `"}`, fiber.StatusForbidden},
+		{"unblock", http.MethodDelete, "/blocks/" + other.Hex(), "", fiber.StatusNoContent},
+		{"unblock someone not blocked", http.MethodDelete, "/blocks/" + stranger.Hex(), "", fiber.StatusNotFound},
+		{"unblock a bad id", http.MethodDelete, "/blocks/abhi", "", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if code, _ := send(t, app, tt.method, tt.path, tt.body); code != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, code)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package friends
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=friends.go -destination=mock_test.go -package=friends
+
+// Friendships is what Handler needs for friends, blocks and privacy
+type Friendships interface {
+	Request(ctx context.Context, from primitive.ObjectID, to primitive.ObjectID) (Status, error)
+	Accept(ctx context.Context, userID primitive.ObjectID, requester primitive.ObjectID) error
+	Decline(ctx context.Context, userID primitive.ObjectID, requester primitive.ObjectID) error
+	Remove(ctx context.Context, userID primitive.ObjectID, friend primitive.ObjectID) error
+	Block(ctx context.Context, userID primitive.ObjectID, other primitive.ObjectID) error
+	Unblock(ctx context.Context, userID primitive.ObjectID, other primitive.ObjectID) error
+	BlockedUsers(ctx context.Context, userID primitive.ObjectID) ([]UserSummary, error)
+	Privacy(ctx context.Context, userID primitive.ObjectID) (privacy.Settings, error)
+	UpdatePrivacy(ctx context.Context, userID primitive.ObjectID, params UpdatePrivacyParams) (privacy.Settings, error)
+	Pending(ctx context.Context, userID primitive.ObjectID) (*PendingRequests, error)
+}
+
+var _ Friendships = (*Service)(nil)
+
+type Handler struct {
+	service Friendships
+}
+
+func (h *Handler) SendRequest(c *fiber.Ctx) error {
+	to, err := parseUser(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := h.service.Request(c.UserContext(), userID(c), to)
+	if err != nil {
+		return friendError(c, err)
+	}
+	code := fiber.StatusCreated
+	if status == Accepted {
+		code = fiber.StatusOK
+	}
+	return c.Status(code).JSON(fiber.Map{
+		"status": status,
+	})
+}
+
+func (h *Handler) AcceptRequest(c *fiber.Ctx) error {
+	requester, err := parseUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Accept(c.UserContext(), userID(c), requester); err != nil {
+		return friendError(c, err)
+	}
+	return c.JSON(fiber.Map{
+		"status": Accepted,
+	})
+}
+
+func (h *Handler) DeclineRequest(c *fiber.Ctx) error {
+	requester, err := parseUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Decline(c.UserContext(), userID(c), requester); err != nil {
+		return friendError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) GetRequests(c *fiber.Ctx) error {
+	pending, err := h.service.Pending(c.UserContext(), userID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch friend requests",
+		})
+	}
+	return c.JSON(pending)
+}
+
+func (h *Handler) RemoveFriend(c *fiber.Ctx) error {
+	friend, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.service.Remove(c.UserContext(), userID(c), friend); err != nil {
+		return friendError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) BlockUser(c *fiber.Ctx) error {
+	other, err := parseUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.service.Block(c.UserContext(), userID(c), other); err != nil {
+		return friendError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) UnblockUser(c *fiber.Ctx) error {
+	other, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.service.Unblock(c.UserContext(), userID(c), other); err != nil {
+		return friendError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) GetBlockedUsers(c *fiber.Ctx) error {
+	blocked, err := h.service.BlockedUsers(c.UserContext(), userID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch blocked users",
+		})
+	}
+	return c.JSON(blocked)
+}
+
+func (h *Handler) GetPrivacy(c *fiber.Ctx) error {
+	settings, err := h.service.Privacy(c.UserContext(), userID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch privacy settings",
+		})
+	}
+	return c.JSON(settings)
+}
+
+func (h *Handler) UpdatePrivacy(c *fiber.Ctx) error {
+	var params UpdatePrivacyParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "profile_visibility must be one of public, friends, private",
+		})
+	}
+
+	settings, err := h.service.UpdatePrivacy(c.UserContext(), userID(c), params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update privacy settings",
+		})
+	}
+	return c.JSON(settings)
+}
+
+// parseUser reads the other user every request body names
+func parseUser(c *fiber.Ctx) (primitive.ObjectID, error) {
+	var params FriendRequestParams
+	if err := c.BodyParser(&params); err != nil {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "user_id must be a user id")
+	}
+	id, _ := primitive.ObjectIDFromHex(params.UserID)
+	return id, nil
+}
+
+func friendError(c *fiber.Ctx, err error) error {
+	var status int
+	switch {
+	case errors.Is(err, ErrSelf), errors.Is(err, ErrBlockSelf):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, ErrBlocked):
+		status = fiber.StatusForbidden
+	case errors.Is(err, ErrUserNotFound), errors.Is(err, ErrRequestNotFound), errors.Is(err, ErrNotFriends), errors.Is(err, ErrNotBlocked):
+		status = fiber.StatusNotFound
+	case errors.Is(err, ErrAlreadyFriends), errors.Is(err, ErrAlreadyRequested):
+		status = fiber.StatusConflict
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update friends",
+		})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := c.Locals("user_id").(string)
+	userID, _ := primitive.ObjectIDFromHex(id)
+	return userID
+}
@@ -0,0 +1,135 @@
+package friends
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+// serve routes the friends endpoints for userID, as Routes does behind the auth middleware
+func serve(service Friendships, userID primitive.ObjectID) *fiber.App {
+	handler := Handler{service}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Post("/friends/request", handler.SendRequest)
+	app.Post("/friends/accept", handler.AcceptRequest)
+	app.Post("/friends/decline", handler.DeclineRequest)
+	app.Delete("/friends/:id", handler.RemoveFriend)
+	app.Post("/blocks", handler.BlockUser)
+	app.Delete("/blocks/:id", handler.UnblockUser)
+	return app
+}
+
+func send(t *testing.T, app *fiber.App, method string, path string, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(res.Body)
+	var got struct {
+		Error  string `json:"error"`
+		Status Status `json:"status"`
+	}
+	if err := gojson.Unmarshal(raw, &got); err != nil {
+		// fiber's own errors are plain text
+		return res.StatusCode, string(raw)
+	}
+	if got.Error != "" {
+		return res.StatusCode, got.Error
+	}
+	return res.StatusCode, string(got.Status)
+}
+
+func TestSendRequest(t *testing.T) {
+	userID := primitive.NewObjectID()
+	stranger, asked, friend, hidden, self := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), userID
+
+	service := NewMockFriendships(gomock.NewController(t))
+	service.EXPECT().Request(gomock.Any(), userID, stranger).Return(Requested, nil)
+	service.EXPECT().Request(gomock.Any(), userID, asked).Return(Accepted, nil)
+	service.EXPECT().Request(gomock.Any(), userID, friend).Return(Status(""), ErrAlreadyFriends)
+	service.EXPECT().Request(gomock.Any(), userID, hidden).Return(Status(""), ErrUserNotFound)
+	service.EXPECT().Request(gomock.Any(), userID, self).Return(Status(""), ErrSelf)
+	app := serve(service, userID)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+		result   string
+	}{
+		{"new request", `{"user_id": "` + stranger.Hex() + `"}`, fiber.StatusCreated, string(Requested)},
+		// they had asked first, so this accepts theirs
+		{"crossed requests", `{"user_id": "` + asked.Hex() + `"}`, fiber.StatusOK, string(Accepted)},
+		{"already friends", `{"user_id": "` + friend.Hex() + `"}`, fiber.StatusConflict, ErrAlreadyFriends.Error()},
+		{"blocked or private", `{"user_id": "` + hidden.Hex() + `"}`, fiber.StatusNotFound, ErrUserNotFound.Error()},
+		{"self", `{"user_id": "` + self.Hex() + `"}`, fiber.StatusBadRequest, ErrSelf.Error()},
+		{"not a user id", `{"user_id": "abhi"}`, fiber.StatusBadRequest, "user_id must be a user id"},
+	}
+	for _, tt := range tests {
+		if code, result := send(t, app, http.MethodPost, "/friends/request", tt.body); code != tt.expected || result != tt.result {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.name, tt.expected, tt.result, code, result)
+		}
+	}
+}
+
+func TestAnswerRequest(t *testing.T) {
+	userID := primitive.NewObjectID()
+	requester, nobody := primitive.NewObjectID(), primitive.NewObjectID()
+
+	service := NewMockFriendships(gomock.NewController(t))
+	service.EXPECT().Accept(gomock.Any(), userID, requester).Return(nil)
+	service.EXPECT().Accept(gomock.Any(), userID, nobody).Return(ErrRequestNotFound)
+	service.EXPECT().Decline(gomock.Any(), userID, requester).Return(nil)
+	service.EXPECT().Decline(gomock.Any(), userID, nobody).Return(ErrRequestNotFound)
+	service.EXPECT().Remove(gomock.Any(), userID, requester).Return(nil)
+	service.EXPECT().Remove(gomock.Any(), userID, nobody).Return(ErrNotFriends)
+	app := serve(service, userID)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"accept", http.MethodPost, "/friends/accept", `{"user_id": "` + requester.Hex() + `"}`, fiber.StatusOK},
+		{"accept without a request", http.MethodPost, "/friends/accept", `{"user_id": "` + nobody.Hex() + `"}`, fiber.StatusNotFound},
+		{"decline", http.MethodPost, "/friends/decline", `{"user_id": "` + requester.Hex() + `"}`, fiber.StatusNoContent},
+		{"decline without a request", http.MethodPost, "/friends/decline", `{"user_id": "` + nobody.Hex() + `"}`, fiber.StatusNotFound},
+		{"unfriend", http.MethodDelete, "/friends/" + requester.Hex(), "", fiber.StatusNoContent},
+		{"unfriend a stranger", http.MethodDelete, "/friends/" + nobody.Hex(), "", fiber.StatusNotFound},
+		{"unfriend a bad id", http.MethodDelete, "/friends/abhi", "", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if code, _ := send(t, app, tt.method, tt.path, tt.body); code != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, code)
+		}
+	}
+}
+
+func TestFriendError(t *testing.T) {
+	app := fiber.New()
+	var err error
+	app.Get("/", func(c *fiber.Ctx) error { return friendError(c, err) })
+
+	// a failure the service didn't name doesn't leak its message
+	err = errors.New("connection reset by peer")
+	if code, message := send(t, app, http.MethodGet, "/", ""); code != fiber.StatusInternalServerError || message != "Failed to update friends" {
+		t.Errorf("expected a generic 500, got %d %q", code, message)
+	}
+}
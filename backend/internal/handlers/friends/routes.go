@@ -0,0 +1,47 @@
+package friends
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler, verified fiber.Handler) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Friends := apiV1.Group("/friends", authenticate)
+	Friends.Post("/request", verified, handler.SendRequest)
+	Friends.Post("/accept", handler.AcceptRequest)
+	Friends.Post("/decline", handler.DeclineRequest)
+	Friends.Get("/requests", handler.GetRequests)
+	Friends.Delete("/:id", handler.RemoveFriend)
+
+	Blocks := apiV1.Group("/blocks", authenticate)
+	Blocks.Get("/", handler.GetBlockedUsers)
+	Blocks.Post("/", handler.BlockUser)
+	Blocks.Delete("/:id", handler.UnblockUser)
+
+	Privacy := apiV1.Group("/privacy", authenticate)
+	Privacy.Get("/", handler.GetPrivacy)
+	Privacy.Patch("/", handler.UpdatePrivacy)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/friends/request": {Summary: "Ask a user to be friends, accepting their request if they already sent one", Auth: true, Request: FriendRequestParams{}, Response: fiber.Map{}, Status: fiber.StatusCreated},
+		"POST /api/v1/friends/accept":  {Summary: "Accept a friend request", Auth: true, Request: FriendRequestParams{}},
+		"POST /api/v1/friends/decline": {Summary: "Decline a friend request", Auth: true, Request: FriendRequestParams{}, Status: fiber.StatusNoContent},
+		"GET /api/v1/friends/requests": {Summary: "The caller's pending friend requests, both ways", Auth: true, Response: PendingRequests{}},
+		"DELETE /api/v1/friends/:id":   {Summary: "Unfriend a user", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/blocks/":          {Summary: "The users the caller blocked", Auth: true, Response: []UserSummary{}},
+		"POST /api/v1/blocks/":         {Summary: "Block a user", Auth: true, Request: FriendRequestParams{}, Status: fiber.StatusNoContent},
+		"DELETE /api/v1/blocks/:id":    {Summary: "Unblock a user", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/privacy/":         {Summary: "The caller's privacy settings", Auth: true, Response: privacy.Settings{}},
+		"PATCH /api/v1/privacy/":       {Summary: "Change the caller's privacy settings", Auth: true, Request: UpdatePrivacyParams{}, Response: privacy.Settings{}},
+	})
+}
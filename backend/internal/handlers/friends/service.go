@@ -0,0 +1,362 @@
+package friends
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/outbox"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newService receives the map of collections and picks out Users, Requests, Activity, Outbox and Notifications
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{
+		users:         collections["users"],
+		requests:      collections[RequestCollection],
+		activity:      collections["activity"],
+		outbox:        collections[outbox.Collection],
+		notifications: collections[notifications.Collection],
+	}
+}
+
+/*
+Request asks to to be from's friend. If to had already asked from, that
+request is accepted instead. A user who blocked from, or whose profile is
+private, looks like one that doesn't exist.
+*/
+func (s *Service) Request(ctx context.Context, from primitive.ObjectID, to primitive.ObjectID) (Status, error) {
+	if from == to {
+		return "", ErrSelf
+	}
+	found, err := privacy.Load(ctx, s.users, []primitive.ObjectID{from, to})
+	if err != nil {
+		return "", err
+	}
+	sender, hasSender := found[from]
+	target, hasTarget := found[to]
+	switch {
+	case !hasSender || !hasTarget || slices.Contains(target.BlockedUsers, from) || target.Privacy.Visibility() == privacy.Private:
+		return "", ErrUserNotFound
+	case slices.Contains(sender.BlockedUsers, to):
+		return "", ErrBlocked
+	case slices.Contains(sender.Friends, to):
+		return "", ErrAlreadyFriends
+	}
+
+	err = s.Accept(ctx, from, to)
+	if err == nil {
+		return Accepted, nil
+	}
+	if !errors.Is(err, ErrRequestNotFound) {
+		return "", err
+	}
+
+	request := Request{ID: primitive.NewObjectID(), From: from, To: to, CreatedAt: time.Now()}
+	if _, err := s.requests.InsertOne(ctx, request); mongo.IsDuplicateKeyError(err) {
+		return "", ErrAlreadyRequested
+	} else if err != nil {
+		return "", err
+	}
+	s.notify(ctx, to, notifications.FriendRequest, "friend_request:"+request.ID.Hex(), from)
+	return Requested, nil
+}
+
+/*
+Accept makes the user and the requester friends, publishing friend.added for
+each of them and adding the friendship to both of their feeds.
+*/
+func (s *Service) Accept(ctx context.Context, userID primitive.ObjectID, requester primitive.ObjectID) error {
+	var request Request
+	err := xmongo.WithTransaction(ctx, s.users, func(sc mongo.SessionContext) error {
+		err := s.requests.FindOneAndDelete(sc, bson.M{"from": requester, "to": userID}).Decode(&request)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrRequestNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		pair := [2][2]primitive.ObjectID{{userID, requester}, {requester, userID}}
+		names := make(map[primitive.ObjectID]string, 2)
+		for _, p := range pair {
+			var user UserSummary
+			err := s.users.FindOneAndUpdate(sc,
+				softdelete.Filter(bson.M{"_id": p[0]}),
+				bson.M{"$addToSet": bson.M{"friends": p[1]}},
+				options.FindOneAndUpdate().SetProjection(bson.M{"display_name": 1, "handle": 1}),
+			).Decode(&user)
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return ErrUserNotFound
+			}
+			if err != nil {
+				return err
+			}
+			names[p[0]] = displayName(user)
+
+			err = outbox.Write(sc, s.outbox, events.Event{
+				Type:       events.FriendAdded,
+				UserID:     p[0].Hex(),
+				Collection: "users",
+				DocumentID: p[1].Hex(),
+				Payload:    events.FriendAddedPayload{FriendID: p[1]},
+				OccurredAt: now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		entries := make([]interface{}, 0, len(pair))
+		for _, p := range pair {
+			entries = append(entries, feedEntry{
+				ActivityDocument: activity.ActivityDocument{
+					ID:        primitive.NewObjectID(),
+					Field1:    names[p[0]] + " is now friends with " + names[p[1]],
+					Field2:    activity.Friended,
+					Timestamp: now,
+				},
+				User: p[0],
+			})
+		}
+		_, err = s.activity.InsertMany(sc, entries)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.notify(ctx, requester, notifications.FriendAccepted, "friend_accepted:"+request.ID.Hex(), userID)
+	return nil
+}
+
+// Decline drops the request requester sent the user
+func (s *Service) Decline(ctx context.Context, userID primitive.ObjectID, requester primitive.ObjectID) error {
+	result, err := s.requests.DeleteOne(ctx, bson.M{"from": requester, "to": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrRequestNotFound
+	}
+	return nil
+}
+
+// Remove ends the friendship on both sides
+func (s *Service) Remove(ctx context.Context, userID primitive.ObjectID, friend primitive.ObjectID) error {
+	return xmongo.WithTransaction(ctx, s.users, func(sc mongo.SessionContext) error {
+		result, err := s.users.UpdateOne(sc, bson.M{"_id": userID, "friends": friend}, bson.M{"$pull": bson.M{"friends": friend}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrNotFriends
+		}
+		_, err = s.users.UpdateOne(sc, bson.M{"_id": friend}, bson.M{"$pull": bson.M{"friends": userID}})
+		return err
+	})
+}
+
+/*
+Block hides the two users from each other. It ends their friendship and drops
+the friend requests between them, either way.
+*/
+func (s *Service) Block(ctx context.Context, userID primitive.ObjectID, other primitive.ObjectID) error {
+	if userID == other {
+		return ErrBlockSelf
+	}
+	return xmongo.WithTransaction(ctx, s.users, func(sc mongo.SessionContext) error {
+		result, err := s.users.UpdateOne(sc, softdelete.Filter(bson.M{"_id": other}), bson.M{"$pull": bson.M{"friends": userID}})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrUserNotFound
+		}
+		_, err = s.users.UpdateOne(sc, bson.M{"_id": userID}, bson.M{
+			"$addToSet": bson.M{"blocked_users": other},
+			"$pull":     bson.M{"friends": other},
+		})
+		if err != nil {
+			return err
+		}
+		_, err = s.requests.DeleteMany(sc, bson.M{"$or": bson.A{
+			bson.M{"from": userID, "to": other},
+			bson.M{"from": other, "to": userID},
+		}})
+		return err
+	})
+}
+
+// Unblock lets the two users see each other again; it doesn't restore their friendship
+func (s *Service) Unblock(ctx context.Context, userID primitive.ObjectID, other primitive.ObjectID) error {
+	result, err := s.users.UpdateOne(ctx, bson.M{"_id": userID, "blocked_users": other}, bson.M{"$pull": bson.M{"blocked_users": other}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+// BlockedUsers lists the users the user blocked, most recent first
+func (s *Service) BlockedUsers(ctx context.Context, userID primitive.ObjectID) ([]UserSummary, error) {
+	var user privacy.Relations
+	err := s.users.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(privacy.Projection)).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.summaries(ctx, user.BlockedUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make([]UserSummary, 0, len(users))
+	for _, id := range slices.Backward(user.BlockedUsers) {
+		if u, ok := users[id]; ok {
+			blocked = append(blocked, *u)
+		}
+	}
+	return blocked, nil
+}
+
+// Privacy returns the user's privacy settings with the defaults filled in
+func (s *Service) Privacy(ctx context.Context, userID primitive.ObjectID) (privacy.Settings, error) {
+	var user privacy.Relations
+	err := s.users.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"privacy": 1})).Decode(&user)
+	if err != nil {
+		return privacy.Settings{}, err
+	}
+	return user.Privacy.Resolved(), nil
+}
+
+// UpdatePrivacy changes the settings params sets and returns the result
+func (s *Service) UpdatePrivacy(ctx context.Context, userID primitive.ObjectID, params UpdatePrivacyParams) (privacy.Settings, error) {
+	set := bson.M{}
+	if params.ProfileVisibility != nil {
+		set["privacy.profile_visibility"] = *params.ProfileVisibility
+	}
+	if params.ShareActivity != nil {
+		set["privacy.share_activity"] = *params.ShareActivity
+	}
+	if params.Leaderboard != nil {
+		set["privacy.leaderboard"] = *params.Leaderboard
+	}
+	if len(set) == 0 {
+		return s.Privacy(ctx, userID)
+	}
+	// privacy is part of the profile offline sync sends
+	set["updated_at"] = time.Now()
+
+	var user privacy.Relations
+	err := s.users.FindOneAndUpdate(ctx, bson.M{"_id": userID}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetProjection(bson.M{"privacy": 1}).SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return privacy.Settings{}, err
+	}
+	return user.Privacy.Resolved(), nil
+}
+
+// Pending lists the requests the user has received and sent, newest first, with the user on the other side
+func (s *Service) Pending(ctx context.Context, userID primitive.ObjectID) (*PendingRequests, error) {
+	cursor, err := s.requests.Find(ctx,
+		bson.M{"$or": bson.A{bson.M{"to": userID}, bson.M{"from": userID}}},
+		options.Find().SetSort(bson.M{"created_at": -1}))
+	if err != nil {
+		return nil, err
+	}
+	var requests []Request
+	if err := cursor.All(ctx, &requests); err != nil {
+		return nil, err
+	}
+
+	others := make([]primitive.ObjectID, 0, len(requests))
+	for _, r := range requests {
+		others = append(others, other(r, userID))
+	}
+	byID, err := s.summaries(ctx, others)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := &PendingRequests{Incoming: make([]Request, 0), Outgoing: make([]Request, 0)}
+	for _, r := range requests {
+		// requests involving a deleted account wait for it to be restored or purged
+		if r.User = byID[other(r, userID)]; r.User == nil {
+			continue
+		}
+		if r.To == userID {
+			pending.Incoming = append(pending.Incoming, r)
+		} else {
+			pending.Outgoing = append(pending.Outgoing, r)
+		}
+	}
+	return pending, nil
+}
+
+// summaries loads the live users among ids
+func (s *Service) summaries(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*UserSummary, error) {
+	cursor, err := s.users.Find(ctx, softdelete.Filter(bson.M{"_id": bson.M{"$in": ids}}),
+		options.Find().SetProjection(bson.M{"display_name": 1, "handle": 1, "profile_picture": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var users []UserSummary
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*UserSummary, len(users))
+	for i := range users {
+		byID[users[i].ID] = &users[i]
+	}
+	return byID, nil
+}
+
+// notify tells user about a friend request or its acceptance, which stand without it
+func (s *Service) notify(ctx context.Context, user primitive.ObjectID, kind notifications.Type, key string, from primitive.ObjectID) {
+	_, err := notifications.Insert(ctx, s.notifications, notifications.Notification{
+		ID:        primitive.NewObjectID(),
+		User:      user,
+		Type:      kind,
+		Key:       key,
+		FromUser:  &from,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to write friend notification", xslog.Error(err))
+	}
+}
+
+// feedEntry is an activity document with its owner, like the feed reads them
+type feedEntry struct {
+	activity.ActivityDocument `bson:",inline"`
+	User                      primitive.ObjectID `bson:"user"`
+}
+
+func other(r Request, userID primitive.ObjectID) primitive.ObjectID {
+	if r.From == userID {
+		return r.To
+	}
+	return r.From
+}
+
+func displayName(user UserSummary) string {
+	if user.DisplayName != "" {
+		return user.DisplayName
+	}
+	return "@" + user.Handle
+}
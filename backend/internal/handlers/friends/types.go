@@ -0,0 +1,79 @@
+package friends
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const RequestCollection = "friend_requests"
+
+var (
+	ErrSelf             = errors.New("you can't send yourself a friend request")
+	ErrUserNotFound     = errors.New("user not found")
+	ErrBlocked          = errors.New("you blocked this user")
+	ErrAlreadyFriends   = errors.New("already friends")
+	ErrAlreadyRequested = errors.New("friend request already sent")
+	ErrRequestNotFound  = errors.New("friend request not found")
+	ErrNotFriends       = errors.New("not friends")
+	ErrBlockSelf        = errors.New("you can't block yourself")
+	ErrNotBlocked       = errors.New("user not blocked")
+)
+
+// Request is a pending friend request; accepting or declining it deletes it
+type Request struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	From      primitive.ObjectID `bson:"from" json:"from"`
+	To        primitive.ObjectID `bson:"to" json:"to"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	// the other side of the request, filled in for listings
+	User *UserSummary `bson:"-" json:"user,omitempty"`
+}
+
+type UserSummary struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	DisplayName    string             `bson:"display_name" json:"display_name"`
+	Handle         string             `bson:"handle" json:"handle"`
+	ProfilePicture string             `bson:"profile_picture" json:"profile_picture"`
+}
+
+type FriendRequestParams struct {
+	UserID string `validate:"required,mongodb" json:"user_id"`
+}
+
+// UpdatePrivacyParams changes the privacy settings it sets
+type UpdatePrivacyParams struct {
+	ProfileVisibility *privacy.Visibility `validate:"omitempty,oneof=public friends private" json:"profile_visibility"`
+	ShareActivity     *bool               `json:"share_activity"`
+	// false leaves the user off their friends' leaderboards
+	Leaderboard *bool `json:"leaderboard"`
+}
+
+type PendingRequests struct {
+	Incoming []Request `json:"incoming"`
+	Outgoing []Request `json:"outgoing"`
+}
+
+type Status string
+
+const (
+	Requested Status = "requested"
+	// the other user had already asked, so the request accepted theirs
+	Accepted Status = "accepted"
+)
+
+/*
+Friends Service to be used by Friends Handler to interact with the
+Database layer of the application
+*/
+
+type Service struct {
+	users         *mongo.Collection
+	requests      *mongo.Collection
+	activity      *mongo.Collection
+	outbox        *mongo.Collection
+	notifications *mongo.Collection
+}
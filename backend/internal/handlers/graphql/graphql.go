@@ -0,0 +1,66 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//go:generate gqlgen generate
+//go:generate mockgen -source=graphql.go -destination=mock_test.go -package=graphql
+
+// Executor runs GraphQL queries for Handler
+type Executor interface {
+	Execute(ctx context.Context, viewer primitive.ObjectID, req Request) *graphql.Response
+}
+
+var _ Executor = (*Service)(nil)
+
+/*
+Handler to execute business logic for GraphQL
+*/
+type Handler struct {
+	service Executor
+}
+
+/*
+Query executes a GraphQL query. POST takes the standard JSON body; GET takes
+query, operationName and variables (JSON) as query parameters. Like other
+GraphQL servers, field errors are reported in "errors" with a 200.
+*/
+func (h *Handler) Query(c *fiber.Ctx) error {
+	id, _ := c.Locals("user_id").(string)
+	viewer, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user",
+		})
+	}
+
+	var req Request
+	if c.Method() == fiber.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := gojson.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid variables",
+				})
+			}
+		}
+	} else if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.Query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing query",
+		})
+	}
+
+	return c.JSON(h.service.Execute(c.UserContext(), viewer, req))
+}
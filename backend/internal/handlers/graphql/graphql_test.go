@@ -0,0 +1,254 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestQuery(t *testing.T) {
+	viewer := primitive.NewObjectID()
+	executor := NewMockExecutor(gomock.NewController(t))
+	executor.EXPECT().Execute(gomock.Any(), viewer, Request{Query: "{ me { id } }"}).Return(&graphql.Response{}).Times(2)
+	executor.EXPECT().Execute(gomock.Any(), viewer, Request{Query: "query($id: ID!) { user(id: $id) { id } }", Variables: map[string]any{"id": "1"}}).Return(&graphql.Response{})
+	handler := Handler{executor}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", viewer.Hex())
+		return c.Next()
+	})
+	app.Get("/graphql", handler.Query)
+	app.Post("/graphql", handler.Query)
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		expected int
+	}{
+		{"post", http.MethodPost, "/graphql", `{"query": "{ me { id } }"}`, fiber.StatusOK},
+		{"get", http.MethodGet, "/graphql?query=" + url.QueryEscape("{ me { id } }"), "", fiber.StatusOK},
+		{"get with variables", http.MethodGet, "/graphql?query=" + url.QueryEscape("query($id: ID!) { user(id: $id) { id } }") + "&variables=" + url.QueryEscape(`{"id": "1"}`), "", fiber.StatusOK},
+		{"bad variables", http.MethodGet, "/graphql?query=q&variables=" + url.QueryEscape("{"), "", fiber.StatusBadRequest},
+		{"missing query", http.MethodPost, "/graphql", `{}`, fiber.StatusBadRequest},
+		{"bad body", http.MethodPost, "/graphql", `{`, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
+
+// memoryStore serves users and a feed from memory, counting the user queries
+type memoryStore struct {
+	mu      sync.Mutex
+	users   map[primitive.ObjectID]*User
+	feed    []FeedItem
+	batches [][]primitive.ObjectID
+	limit   int
+	before  time.Time
+}
+
+func (m *memoryStore) FindUsers(ctx context.Context, ids []primitive.ObjectID) ([]*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches = append(m.batches, ids)
+	found := make([]*User, 0, len(ids))
+	for _, id := range ids {
+		if user, ok := m.users[id]; ok {
+			found = append(found, user)
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryStore) Feed(ctx context.Context, viewer primitive.ObjectID, limit int, before time.Time) ([]FeedItem, error) {
+	m.limit, m.before = limit, before
+	return m.feed, nil
+}
+
+func execute(t *testing.T, s *Service, viewer primitive.ObjectID, query string) (map[string]any, []string) {
+	t.Helper()
+	resp := s.Execute(context.Background(), viewer, Request{Query: query})
+	var data map[string]any
+	if len(resp.Data) > 0 {
+		if err := gojson.Unmarshal(resp.Data, &data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var errs []string
+	for _, err := range resp.Errors {
+		errs = append(errs, err.Message)
+	}
+	return data, errs
+}
+
+func encode(t *testing.T, v any) string {
+	t.Helper()
+	raw, err := gojson.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(raw)
+}
+
+func TestResolvers(t *testing.T) {
+	me, friend, private, blocker, stranger := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	done := time.Date(2026, 10, 12, 9, 30, 0, 0, time.UTC)
+	tasks := []task.TaskDocument{
+		{ID: primitive.NewObjectID(), Content: "run", Priority: 1, Value: 3, Public: true, Timestamp: done},
+		{ID: primitive.NewObjectID(), Content: "diary", Priority: 3, Value: 1, Timestamp: done.Add(time.Hour)},
+	}
+	store := &memoryStore{users: map[primitive.ObjectID]*User{
+		me:       {ID: me, Handle: "me", Friends: []primitive.ObjectID{friend, private, blocker, primitive.NewObjectID()}, Categories: []category.CategoryDocument{{Name: "health", Tasks: tasks}}},
+		friend:   {ID: friend, Handle: "friend", Friends: []primitive.ObjectID{me}, Categories: []category.CategoryDocument{{Name: "health", Tasks: tasks}}},
+		private:  {ID: private, Handle: "private", Privacy: privacy.Settings{ProfileVisibility: privacy.Private}},
+		blocker:  {ID: blocker, Handle: "blocker", BlockedUsers: []primitive.ObjectID{me}},
+		stranger: {ID: stranger, Handle: "stranger"},
+	}}
+	s := newServiceWithStore(store)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		errs     []string
+	}{
+		{"friends the viewer can see", `{ me { friends { handle } } }`, `{"me":{"friends":[{"handle":"friend"}]}}`, nil},
+		{"own private tasks", `{ me { categories { tasks { content } } } }`, `{"me":{"categories":[{"tasks":[{"content":"run"},{"content":"diary"}]}]}}`, nil},
+		{"only a friend's public tasks", `{ user(id: "` + friend.Hex() + `") { categories { tasks { content timestamp } } } }`, `{"user":{"categories":[{"tasks":[{"content":"run","timestamp":"2026-10-12T09:30:00Z"}]}]}}`, nil},
+		{"public stranger", `{ user(id: "` + stranger.Hex() + `") { handle } }`, `{"user":{"handle":"stranger"}}`, nil},
+		{"private user", `{ user(id: "` + private.Hex() + `") { handle } }`, `{"user":null}`, nil},
+		{"user who blocked the viewer", `{ user(id: "` + blocker.Hex() + `") { handle } }`, `{"user":null}`, nil},
+		{"bad id", `{ user(id: "abhi") { handle } }`, `{"user":null}`, []string{`invalid id "abhi"`}},
+		{"tasks by priority", `{ tasks(sortBy: "priority") { content } }`, `{"tasks":[{"content":"run"},{"content":"diary"}]}`, nil},
+		{"tasks by value", `{ tasks(sortBy: "value") { content } }`, `{"tasks":[{"content":"run"},{"content":"diary"}]}`, nil},
+		{"tasks newest first", `{ tasks { content } }`, `{"tasks":[{"content":"diary"},{"content":"run"}]}`, nil},
+		{"unknown sort", `{ tasks(sortBy: "name") { content } }`, `null`, []string{"sortBy must be one of timestamp, priority, value"}},
+		{"writes", `mutation { me { id } }`, `null`, []string{`Schema does not support operation type "mutation"`}},
+		{"unknown field", `{ me { password } }`, `null`, []string{`Cannot query field "password" on type "User".`}},
+	}
+	for _, tt := range tests {
+		data, errs := execute(t, s, me, tt.query)
+		if got := encode(t, data); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+		if encode(t, errs) != encode(t, tt.errs) {
+			t.Errorf("%s: expected errors %v, got %v", tt.name, tt.errs, errs)
+		}
+	}
+}
+
+func TestFeed(t *testing.T) {
+	me, ari, bo := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	item := func(user primitive.ObjectID) FeedItem {
+		return FeedItem{ActivityDocument: activity.ActivityDocument{ID: primitive.NewObjectID(), Field1: "done"}, User: user}
+	}
+	store := &memoryStore{
+		users: map[primitive.ObjectID]*User{
+			me:  {ID: me, Handle: "me"},
+			ari: {ID: ari, Handle: "ari"},
+			bo:  {ID: bo, Handle: "bo"},
+		},
+		feed: []FeedItem{item(ari), item(bo), item(ari), item(bo), item(primitive.NewObjectID())},
+	}
+	s := newServiceWithStore(store)
+
+	data, errs := execute(t, s, me, `{ feed(limit: 5, before: "2026-10-12T09:30:00Z") { field1 user { handle } } }`)
+	expected := `{"feed":[{"field1":"done","user":{"handle":"ari"}},{"field1":"done","user":{"handle":"bo"}},{"field1":"done","user":{"handle":"ari"}},{"field1":"done","user":{"handle":"bo"}},{"field1":"done","user":null}]}`
+	if got := encode(t, data); got != expected || errs != nil {
+		t.Errorf("expected %s, got %s %v", expected, got, errs)
+	}
+	if store.limit != 5 || !store.before.Equal(time.Date(2026, 10, 12, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected the page asked for, got %d before %s", store.limit, store.before)
+	}
+	// the viewer, then every author on the page in one batch
+	if len(store.batches) != 2 || len(store.batches[1]) != 3 {
+		t.Errorf("expected the authors loaded in one batch, got %v", store.batches)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		limit int
+		errs  []string
+	}{
+		{"default size", `{ feed { id } }`, 20, nil},
+		{"too big", `{ feed(limit: 500) { id } }`, maxFeedSize, nil},
+		{"bad cursor", `{ feed(before: "yesterday") { id } }`, 0, []string{"before must be an RFC 3339 timestamp"}},
+	}
+	for _, tt := range tests {
+		store.limit = 0
+		_, errs := execute(t, s, me, tt.query)
+		if store.limit != tt.limit || encode(t, errs) != encode(t, tt.errs) {
+			t.Errorf("%s: expected limit %d and errors %v, got %d %v", tt.name, tt.limit, tt.errs, store.limit, errs)
+		}
+	}
+}
+
+func TestComplexityLimit(t *testing.T) {
+	me := primitive.NewObjectID()
+	s := newServiceWithStore(&memoryStore{users: map[primitive.ObjectID]*User{me: {ID: me, Friends: []primitive.ObjectID{me}}}})
+
+	query := "{ me { id } }"
+	for range maxComplexity {
+		query = strings.Replace(query, "{ id }", "{ id friends { id } }", 1)
+	}
+	_, errs := execute(t, s, me, query)
+	if len(errs) != 1 || !strings.Contains(errs[0], "exceeds the limit of 200") {
+		t.Errorf("expected the query rejected as too complex, got %v", errs)
+	}
+}
+
+func TestRankFeed(t *testing.T) {
+	ari, bo := primitive.NewObjectID(), primitive.NewObjectID()
+	items := []FeedItem{{User: ari}, {User: ari}, {User: ari}, {User: bo}}
+	for i := range items {
+		items[i].ID = primitive.NewObjectID()
+	}
+	ranked := rankFeed(items)
+	expected := []primitive.ObjectID{items[0].ID, items[3].ID, items[1].ID, items[2].ID}
+	for i, item := range ranked {
+		if item.ID != expected[i] {
+			t.Errorf("position %d: expected %s, got %s", i, expected[i].Hex(), item.ID.Hex())
+		}
+	}
+}
+
+func TestScalars(t *testing.T) {
+	id := primitive.NewObjectID()
+	var out strings.Builder
+	MarshalObjectID(id).MarshalGQL(&out)
+	if out.String() != `"`+id.Hex()+`"` {
+		t.Errorf("expected %q, got %s", id.Hex(), out.String())
+	}
+	if got, err := UnmarshalObjectID(id.Hex()); got != id || err != nil {
+		t.Errorf("expected %s, got %s %v", id.Hex(), got.Hex(), err)
+	}
+	if _, err := UnmarshalObjectID(12); err == nil {
+		t.Error("expected a number rejected")
+	}
+}
@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+Resolver serves schema.graphql through the code gqlgen generates from it.
+Everything is resolved from the user documents (categories and tasks are
+embedded) and the activity collection; users are batched through the
+request's loader so a feed page costs one query for its authors.
+*/
+type Resolver struct {
+	service *Service
+}
+
+func (r *Resolver) Query() QueryResolver       { return &queryResolver{r} }
+func (r *Resolver) User() UserResolver         { return &userResolver{r} }
+func (r *Resolver) Category() CategoryResolver { return &categoryResolver{r} }
+func (r *Resolver) Task() TaskResolver         { return &taskResolver{r} }
+func (r *Resolver) Activity() ActivityResolver { return &activityResolver{r} }
+
+type queryResolver struct{ *Resolver }
+
+func (r *queryResolver) Me(ctx context.Context) (*User, error) {
+	return r.service.me(ctx)
+}
+
+func (r *queryResolver) User(ctx context.Context, id primitive.ObjectID) (*User, error) {
+	user, err := r.service.loadUser(ctx, id)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	visible, err := r.service.visibleUsers(ctx, []*User{user})
+	if err != nil || len(visible) == 0 {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *queryResolver) Categories(ctx context.Context) ([]category.CategoryDocument, error) {
+	me, err := r.service.me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return me.Categories, nil
+}
+
+func (r *queryResolver) Tasks(ctx context.Context, sortBy *string) ([]task.TaskDocument, error) {
+	me, err := r.service.me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if sortBy == nil {
+		return sortedTasks(me.Categories, "timestamp")
+	}
+	return sortedTasks(me.Categories, *sortBy)
+}
+
+func (r *queryResolver) Feed(ctx context.Context, limit *int, before *string) ([]FeedItem, error) {
+	me, err := r.service.me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	size := maxFeedSize
+	if limit != nil && *limit > 0 && *limit < maxFeedSize {
+		size = *limit
+	}
+	var after time.Time
+	if before != nil && *before != "" {
+		if after, err = time.Parse(time.RFC3339, *before); err != nil {
+			return nil, fmt.Errorf("before must be an RFC 3339 timestamp")
+		}
+	}
+	return r.service.store.Feed(ctx, me.ID, size, after)
+}
+
+type userResolver struct{ *Resolver }
+
+func (r *userResolver) Friends(ctx context.Context, obj *User) ([]User, error) {
+	friends, err := r.service.loadUsers(ctx, obj.Friends)
+	if err != nil {
+		return nil, err
+	}
+	visible, err := r.service.visibleUsers(ctx, friends)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]User, len(visible))
+	for i, user := range visible {
+		users[i] = *user
+	}
+	return users, nil
+}
+
+// other users only see the public tasks in someone's categories
+func (r *userResolver) Categories(ctx context.Context, obj *User) ([]category.CategoryDocument, error) {
+	if obj.ID == viewerFrom(ctx) {
+		return obj.Categories, nil
+	}
+	visible := make([]category.CategoryDocument, 0, len(obj.Categories))
+	for _, c := range obj.Categories {
+		tasks := make([]task.TaskDocument, 0, len(c.Tasks))
+		for _, t := range c.Tasks {
+			if t.Public {
+				tasks = append(tasks, t)
+			}
+		}
+		c.Tasks = tasks
+		visible = append(visible, c)
+	}
+	return visible, nil
+}
+
+type categoryResolver struct{ *Resolver }
+
+func (r *categoryResolver) LastEdited(ctx context.Context, obj *category.CategoryDocument) (*string, error) {
+	return timestamp(obj.LastEdited), nil
+}
+
+type taskResolver struct{ *Resolver }
+
+func (r *taskResolver) Timestamp(ctx context.Context, obj *task.TaskDocument) (*string, error) {
+	return timestamp(obj.Timestamp), nil
+}
+
+func (r *taskResolver) UpdatedAt(ctx context.Context, obj *task.TaskDocument) (*string, error) {
+	return timestamp(obj.UpdatedAt), nil
+}
+
+type activityResolver struct{ *Resolver }
+
+// User is nil when the author has since deleted their account
+func (r *activityResolver) User(ctx context.Context, obj *FeedItem) (*User, error) {
+	return r.service.loadUser(ctx, obj.User)
+}
+
+func (r *activityResolver) Field2(ctx context.Context, obj *FeedItem) (*string, error) {
+	field2 := string(obj.Field2)
+	return &field2, nil
+}
+
+func (r *activityResolver) Timestamp(ctx context.Context, obj *FeedItem) (*string, error) {
+	return timestamp(obj.Timestamp), nil
+}
+
+// the schema's times are RFC 3339 strings, null when unset
+func timestamp(t time.Time) *string {
+	if t.IsZero() {
+		return nil
+	}
+	formatted := t.UTC().Format(time.RFC3339Nano)
+	return &formatted
+}
+
+func sortedTasks(categories []category.CategoryDocument, sortBy string) ([]task.TaskDocument, error) {
+	var less func(a, b task.TaskDocument) bool
+	switch sortBy {
+	case "timestamp":
+		less = func(a, b task.TaskDocument) bool { return a.Timestamp.After(b.Timestamp) }
+	case "priority":
+		less = func(a, b task.TaskDocument) bool { return a.Priority < b.Priority }
+	case "value":
+		less = func(a, b task.TaskDocument) bool { return a.Value > b.Value }
+	default:
+		return nil, fmt.Errorf("sortBy must be one of timestamp, priority, value")
+	}
+
+	tasks := make([]task.TaskDocument, 0)
+	for _, c := range categories {
+		tasks = append(tasks, c.Tasks...)
+	}
+	sort.SliceStable(tasks, func(i, j int) bool { return less(tasks[i], tasks[j]) })
+	return tasks, nil
+}
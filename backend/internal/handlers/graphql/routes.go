@@ -0,0 +1,23 @@
+package graphql
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	app.Get("/graphql", authenticate, handler.Query)
+	app.Post("/graphql", authenticate, handler.Query)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /graphql":  {Summary: "Run a read-only GraphQL query", Auth: true, Query: xopenapi.Query{"query", "operationName", "variables"}, Response: map[string]any{}},
+		"POST /graphql": {Summary: "Run a read-only GraphQL query", Auth: true, Request: Request{}, Response: map[string]any{}},
+	})
+}
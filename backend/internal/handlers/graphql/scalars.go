@@ -0,0 +1,29 @@
+package graphql
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MarshalObjectID serves ID fields as the hex of the document id, see gqlgen.yml
+func MarshalObjectID(id primitive.ObjectID) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		io.WriteString(w, strconv.Quote(id.Hex()))
+	})
+}
+
+func UnmarshalObjectID(v any) (primitive.ObjectID, error) {
+	s, ok := v.(string)
+	if !ok {
+		return primitive.NilObjectID, fmt.Errorf("id must be a string")
+	}
+	id, err := primitive.ObjectIDFromHex(s)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("invalid id %q", s)
+	}
+	return id, nil
+}
@@ -0,0 +1,271 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/executor"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/abhikaboy/SocialToDo/internal/flags"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/graph-gophers/dataloader"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// how long the user loader waits for sibling resolvers before querying
+	batchWait   = 2 * time.Millisecond
+	maxBatch    = 100
+	maxFeedSize = 50
+	// each selected field costs 1, so this bounds both the size and the nesting of a query
+	maxComplexity = 200
+)
+
+var userProjection = bson.M{
+	"handle":          1,
+	"display_name":    1,
+	"profile_picture": 1,
+	"tasks_complete":  1,
+	"friends":         1,
+	"blocked_users":   1,
+	"privacy":         1,
+	"categories":      1,
+}
+
+// Store is what the schema is resolved from
+type Store interface {
+	// FindUsers returns the live users among ids, in any order
+	FindUsers(ctx context.Context, ids []primitive.ObjectID) ([]*User, error)
+	// Feed returns a page of activity from the viewer and the friends sharing theirs, newest first
+	Feed(ctx context.Context, viewer primitive.ObjectID, limit int, before time.Time) ([]FeedItem, error)
+}
+
+/*
+GraphQL Service to be used by GraphQL Handler to interact with the
+Database layer of the application
+*/
+type Service struct {
+	store    Store
+	executor *executor.Executor
+}
+
+// newService receives the map of collections and picks out Users, Activity and Flags
+func newService(collections map[string]*mongo.Collection) *Service {
+	return newServiceWithStore(&mongoStore{
+		users:    collections["users"],
+		activity: collections["activity"],
+		flags:    flags.NewEvaluator(flags.New(collections[flags.Collection]), flags.CacheTTL),
+	})
+}
+
+func newServiceWithStore(store Store) *Service {
+	s := &Service{store: store}
+	s.executor = executor.New(NewExecutableSchema(Config{Resolvers: &Resolver{s}}))
+	s.executor.Use(extension.Introspection{})
+	s.executor.Use(extension.FixedComplexityLimit(maxComplexity))
+	return s
+}
+
+type loadersKey struct{}
+type viewerKey struct{}
+
+type loaders struct {
+	users *dataloader.Loader
+}
+
+// userKey is a user id as the loader's key
+type userKey primitive.ObjectID
+
+func (k userKey) String() string { return primitive.ObjectID(k).Hex() }
+func (k userKey) Raw() any       { return primitive.ObjectID(k) }
+
+// Execute runs a query as viewer with a fresh set of loaders
+func (s *Service) Execute(ctx context.Context, viewer primitive.ObjectID, req Request) *graphql.Response {
+	ctx = context.WithValue(ctx, viewerKey{}, viewer)
+	ctx = context.WithValue(ctx, loadersKey{}, &loaders{
+		users: dataloader.NewBatchedLoader(s.getUsers, dataloader.WithWait(batchWait), dataloader.WithBatchCapacity(maxBatch)),
+	})
+	ctx = graphql.StartOperationTrace(ctx)
+
+	now := graphql.Now()
+	op, errs := s.executor.CreateOperationContext(ctx, &graphql.RawParams{
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+		ReadTime:      graphql.TraceTiming{Start: now, End: now},
+	})
+	if errs != nil {
+		return s.executor.DispatchError(graphql.WithOperationContext(ctx, op), errs)
+	}
+	responses, ctx := s.executor.DispatchOperation(ctx, op)
+	return responses(ctx)
+}
+
+func viewerFrom(ctx context.Context) primitive.ObjectID {
+	id, _ := ctx.Value(viewerKey{}).(primitive.ObjectID)
+	return id
+}
+
+// loadUser batches user lookups made while resolving the same request; a missing user is nil
+func (s *Service) loadUser(ctx context.Context, id primitive.ObjectID) (*User, error) {
+	found, err := ctx.Value(loadersKey{}).(*loaders).users.Load(ctx, userKey(id))()
+	if err != nil {
+		return nil, err
+	}
+	return found.(*User), nil
+}
+
+// loadUsers is loadUser for many, skipping the missing ones such as dangling friend references
+func (s *Service) loadUsers(ctx context.Context, ids []primitive.ObjectID) ([]*User, error) {
+	keys := make(dataloader.Keys, len(ids))
+	for i, id := range ids {
+		keys[i] = userKey(id)
+	}
+	found, errs := ctx.Value(loadersKey{}).(*loaders).users.LoadMany(ctx, keys)()
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	users := make([]*User, 0, len(found))
+	for _, user := range found {
+		if user := user.(*User); user != nil {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// getUsers is the user loader's batch function, its results line up with keys
+func (s *Service) getUsers(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+	ids := make([]primitive.ObjectID, len(keys))
+	for i, key := range keys {
+		ids[i] = key.Raw().(primitive.ObjectID)
+	}
+	results := make([]*dataloader.Result, len(keys))
+	found, err := s.store.FindUsers(ctx, ids)
+	if err != nil {
+		for i := range results {
+			results[i] = &dataloader.Result{Error: err}
+		}
+		return results
+	}
+	byID := make(map[primitive.ObjectID]*User, len(found))
+	for _, user := range found {
+		byID[user.ID] = user
+	}
+	for i, id := range ids {
+		results[i] = &dataloader.Result{Data: byID[id]}
+	}
+	return results
+}
+
+func (s *Service) me(ctx context.Context) (*User, error) {
+	me, err := s.loadUser(ctx, viewerFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if me == nil {
+		return nil, errors.New("user not found")
+	}
+	return me, nil
+}
+
+// visibleUsers drops the users whose profile the viewer can't see
+func (s *Service) visibleUsers(ctx context.Context, users []*User) ([]*User, error) {
+	me, err := s.me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	visible := make([]*User, 0, len(users))
+	for _, user := range users {
+		if privacy.CanView(me.relations(), user.relations()) {
+			visible = append(visible, user)
+		}
+	}
+	return visible, nil
+}
+
+type mongoStore struct {
+	users    *mongo.Collection
+	activity *mongo.Collection
+	flags    *flags.Evaluator
+}
+
+func (m *mongoStore) FindUsers(ctx context.Context, ids []primitive.ObjectID) ([]*User, error) {
+	cursor, err := m.users.Find(ctx, softdelete.Filter(bson.M{"_id": bson.M{"$in": ids}}), options.Find().SetProjection(userProjection))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []*User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		user.Categories = category.Visible(user.Categories)
+	}
+	return users, nil
+}
+
+func (m *mongoStore) Feed(ctx context.Context, viewer primitive.ObjectID, limit int, before time.Time) ([]FeedItem, error) {
+	authors, err := privacy.FeedAuthors(ctx, m.users, viewer)
+	if err != nil {
+		return nil, err
+	}
+	filter := bson.M{"user": bson.M{"$in": authors}}
+	if !before.IsZero() {
+		filter["timestamp"] = bson.M{"$lt": before}
+	}
+
+	cursor, err := m.activity.Find(ctx, filter,
+		options.Find().SetSort(bson.M{"timestamp": -1}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]FeedItem, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if m.flags.Enabled(ctx, flags.FeedRanking, viewer.Hex()) {
+		results = rankFeed(results)
+	}
+	return results, nil
+}
+
+/*
+rankFeed reorders a page so one busy friend can't fill it: authors take turns,
+each contributing their newest remaining item, in the order they first appear.
+The page holds the same items as the chronological one, so paging on the
+oldest timestamp still works.
+*/
+func rankFeed(items []FeedItem) []FeedItem {
+	order := make([]primitive.ObjectID, 0)
+	byAuthor := make(map[primitive.ObjectID][]FeedItem)
+	for _, item := range items {
+		if _, ok := byAuthor[item.User]; !ok {
+			order = append(order, item.User)
+		}
+		byAuthor[item.User] = append(byAuthor[item.User], item)
+	}
+
+	ranked := make([]FeedItem, 0, len(items))
+	for len(ranked) < len(items) {
+		for _, author := range order {
+			if queue := byAuthor[author]; len(queue) > 0 {
+				ranked = append(ranked, queue[0])
+				byAuthor[author] = queue[1:]
+			}
+		}
+	}
+	return ranked
+}
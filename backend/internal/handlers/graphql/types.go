@@ -0,0 +1,37 @@
+package graphql
+
+import (
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Request is a GraphQL request, as the JSON body of POST /graphql or the query parameters of GET
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// User is the public part of a user document; credentials are never loaded
+type User struct {
+	ID             primitive.ObjectID          `bson:"_id" json:"id"`
+	Handle         string                      `bson:"handle" json:"handle"`
+	DisplayName    string                      `bson:"display_name" json:"displayName"`
+	ProfilePicture string                      `bson:"profile_picture" json:"profilePicture"`
+	TasksComplete  float64                     `bson:"tasks_complete" json:"tasksComplete"`
+	Friends        []primitive.ObjectID        `bson:"friends" json:"-"`
+	BlockedUsers   []primitive.ObjectID        `bson:"blocked_users" json:"-"`
+	Privacy        privacy.Settings            `bson:"privacy" json:"-"`
+	Categories     []category.CategoryDocument `bson:"categories" json:"-"`
+}
+
+func (u *User) relations() privacy.Relations {
+	return privacy.Relations{ID: u.ID, Friends: u.Friends, BlockedUsers: u.BlockedUsers, Privacy: u.Privacy}
+}
+
+type FeedItem struct {
+	activity.ActivityDocument `bson:",inline"`
+	User                      primitive.ObjectID `bson:"user" json:"-"`
+}
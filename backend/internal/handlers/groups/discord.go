@@ -0,0 +1,359 @@
+package groups
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/discord"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const PostJob = "groups.discord"
+
+// the kinds of post
+const (
+	ChallengePost   = "challenge"
+	LeaderboardPost = "leaderboard"
+	TestPost        = "test"
+)
+
+type PostPayload struct {
+	Group primitive.ObjectID `bson:"group"`
+	Kind  string             `bson:"kind"`
+	// the Monday the week starts, as weekKey formats it
+	Week string `bson:"week,omitempty"`
+}
+
+const (
+	defaultChallenge   = "🎉 **{{.Group}}** met this week's challenge: {{.Completed}} of {{.Goal}} tasks done!"
+	defaultLeaderboard = "🏆 **{{.Group}}**, week of {{.Week}}\n" +
+		"{{range .Ranking}}{{.Place}}. {{.Name}}: {{.Completed}}\n{{else}}Nobody completed a task this week.\n{{end}}"
+	testMessage = "👋 **%s** is connected, its milestones will be posted here."
+)
+
+var ErrTemplate = errors.New("invalid template")
+
+// Milestone is the data the Discord templates are executed with
+type Milestone struct {
+	Group string
+	// the Monday the week starts, 2006-01-02
+	Week string
+	// the weekly goal, and the tasks the members completed towards it
+	Goal      int
+	Completed int
+	// members by tasks completed this week, most first; those who don't share their activity are left out
+	Ranking []Rank
+}
+
+type Rank struct {
+	// members with the same count share a place
+	Place     int
+	Name      string
+	Completed int
+}
+
+// RegisterJobs adds the Discord post job handler to the worker
+func RegisterJobs(worker *jobs.Worker, collections map[string]*mongo.Collection) {
+	s := newService(collections)
+	worker.Handle(PostJob, s.post)
+}
+
+// SubscribeEvents checks a member's groups' challenges as they complete tasks
+func SubscribeEvents(bus *events.Bus, collections map[string]*mongo.Collection) {
+	s := newService(collections)
+	bus.SubscribeAsync(func(ctx context.Context, event events.Event) {
+		userID, err := primitive.ObjectIDFromHex(event.UserID)
+		if err != nil {
+			return
+		}
+		if err := s.checkChallenges(ctx, userID, event.OccurredAt); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to check group challenges", xslog.Error(err))
+		}
+	}, events.TaskCompleted)
+}
+
+// RegisterSchedules posts last week's leaderboard of every group on Discord, Monday mornings UTC
+func RegisterSchedules(cron *scheduler.Scheduler, collections map[string]*mongo.Collection) {
+	s := newService(collections)
+	cron.Register("groups-discord-leaderboard", "0 9 * * 1", 10*time.Minute, func(ctx context.Context) error {
+		week := weekKey(weekOf(time.Now()).AddDate(0, 0, -7))
+		cursor, err := s.groups.Find(ctx, bson.M{"discord.webhook_url": bson.M{"$exists": true}})
+		if err != nil {
+			return err
+		}
+		var groups []Group
+		if err := cursor.All(ctx, &groups); err != nil {
+			return err
+		}
+		for _, group := range groups {
+			if _, err := s.queue.Enqueue(ctx, PostJob, PostPayload{Group: group.ID, Kind: LeaderboardPost, Week: week}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+/*
+checkChallenges queues the announcement for each of userID's groups that
+met its goal this week with the task just completed. Marking the week first
+means the announcement goes out once, however many completions race here.
+*/
+func (s *Service) checkChallenges(ctx context.Context, userID primitive.ObjectID, at time.Time) error {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	week := weekKey(weekOf(at))
+	cursor, err := s.groups.Find(ctx, bson.M{
+		"members":             userID,
+		"weekly_goal":         bson.M{"$gt": 0},
+		"challenge_week":      bson.M{"$ne": week},
+		"discord.webhook_url": bson.M{"$exists": true},
+	})
+	if err != nil {
+		return err
+	}
+	var groups []Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		return err
+	}
+
+	for _, group := range groups {
+		completed, _, err := s.weekly(ctx, group.Members, weekOf(at))
+		if err != nil {
+			return err
+		}
+		if completed < group.WeeklyGoal {
+			continue
+		}
+		result, err := s.groups.UpdateOne(ctx, bson.M{"_id": group.ID, "challenge_week": bson.M{"$ne": week}}, bson.M{"$set": bson.M{"challenge_week": week}})
+		if err != nil {
+			return err
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+		if _, err := s.queue.Enqueue(ctx, PostJob, PostPayload{Group: group.ID, Kind: ChallengePost, Week: week}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+post renders the milestone as the group is now and posts it. Failures are
+retried with the queue's backoff; a webhook Discord no longer knows is
+detached, with the reason kept for the admin to see.
+*/
+func (s *Service) post(ctx context.Context, job *jobs.Job) error {
+	var payload PostPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var group Group
+	err := s.groups.FindOne(ctx, bson.M{"_id": payload.Group}).Decode(&group)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// deleted since
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !group.Discord.connected() {
+		return nil
+	}
+
+	content, err := s.content(ctx, &group, payload)
+	if err != nil {
+		return err
+	}
+	err = s.discord.Post(ctx, group.Discord.WebhookURL, content)
+	if errors.Is(err, discord.ErrGone) {
+		_, err := s.groups.UpdateOne(ctx,
+			bson.M{"_id": group.ID, "discord.webhook_url": group.Discord.WebhookURL},
+			bson.M{"$unset": bson.M{"discord.webhook_url": ""}, "$set": bson.M{"discord.error": "Discord no longer accepts the webhook, connect it again"}},
+		)
+		return err
+	}
+	return err
+}
+
+func (s *Service) content(ctx context.Context, group *Group, payload PostPayload) (string, error) {
+	var source string
+	switch payload.Kind {
+	case TestPost:
+		return fmt.Sprintf(testMessage, group.Name), nil
+	case ChallengePost:
+		source = cmp.Or(group.Discord.Templates.Challenge, defaultChallenge)
+	case LeaderboardPost:
+		source = cmp.Or(group.Discord.Templates.Leaderboard, defaultLeaderboard)
+	default:
+		return "", jobs.Permanent(fmt.Errorf("unknown post kind %q", payload.Kind))
+	}
+	week, err := time.Parse(time.DateOnly, payload.Week)
+	if err != nil {
+		return "", jobs.Permanent(err)
+	}
+
+	completed, ranking, err := s.weekly(ctx, group.Members, week)
+	if err != nil {
+		return "", err
+	}
+	content, err := render(source, Milestone{
+		Group:     group.Name,
+		Week:      payload.Week,
+		Goal:      group.WeeklyGoal,
+		Completed: completed,
+		Ranking:   ranking,
+	})
+	if err != nil {
+		// checked when it was saved, but a template can still fail on data it wasn't tried with
+		return "", jobs.Permanent(err)
+	}
+	return content, nil
+}
+
+type memberWeek struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	DisplayName string             `bson:"display_name"`
+	Handle      string             `bson:"handle"`
+	Privacy     privacy.Settings   `bson:"privacy"`
+	Completed   int                `bson:"completed"`
+}
+
+// weekly counts the tasks members completed in the week starting at week, and ranks the members who share their activity
+func (s *Service) weekly(ctx context.Context, members []primitive.ObjectID, week time.Time) (int, []Rank, error) {
+	end := week.AddDate(0, 0, 7)
+	tasks := bson.M{"$reduce": bson.M{
+		"input":        softdelete.LiveElements("categories"),
+		"initialValue": bson.A{},
+		"in":           bson.M{"$concatArrays": bson.A{"$$value", bson.M{"$ifNull": bson.A{"$$this.tasks", bson.A{}}}}},
+	}}
+	cursor, err := s.users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: softdelete.Filter(bson.M{"_id": bson.M{"$in": members}})}},
+		{{Key: "$project", Value: bson.M{
+			"display_name": 1,
+			"handle":       1,
+			"privacy":      1,
+			"completed": bson.M{"$size": bson.M{"$filter": bson.M{
+				"input": tasks,
+				"as":    "task",
+				"cond": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$$task." + softdelete.Field, nil}}, nil}},
+					bson.M{"$gte": bson.A{"$$task.completed_at", week}},
+					bson.M{"$lt": bson.A{"$$task.completed_at", end}},
+				}},
+			}}},
+		}}},
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	var found []memberWeek
+	if err := cursor.All(ctx, &found); err != nil {
+		return 0, nil, err
+	}
+	completed, ranking := rank(found)
+	return completed, ranking, nil
+}
+
+func rank(members []memberWeek) (int, []Rank) {
+	slices.SortFunc(members, func(a, b memberWeek) int {
+		if a.Completed != b.Completed {
+			return b.Completed - a.Completed
+		}
+		return strings.Compare(a.name(), b.name())
+	})
+	total := 0
+	ranking := []Rank{}
+	for _, member := range members {
+		total += member.Completed
+		if !member.Privacy.SharesActivity() {
+			continue
+		}
+		place := len(ranking) + 1
+		if last := len(ranking) - 1; last >= 0 && ranking[last].Completed == member.Completed {
+			place = ranking[last].Place
+		}
+		ranking = append(ranking, Rank{Place: place, Name: member.name(), Completed: member.Completed})
+	}
+	return total, ranking
+}
+
+func (m memberWeek) name() string {
+	if m.DisplayName != "" {
+		return m.DisplayName
+	}
+	return "@" + m.Handle
+}
+
+// weekOf is the start of t's week, Monday 00:00 UTC
+func weekOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+}
+
+func weekKey(week time.Time) string {
+	return week.Format(time.DateOnly)
+}
+
+// checkTemplates parses the templates and tries them on sample data, so mistakes show when they're saved
+func checkTemplates(templates Templates) error {
+	sample := Milestone{Group: "Group", Week: "2006-01-02", Goal: 10, Completed: 10, Ranking: []Rank{{Place: 1, Name: "Name", Completed: 10}}}
+	for _, source := range []string{templates.Challenge, templates.Leaderboard} {
+		if source == "" {
+			continue
+		}
+		if _, err := render(source, sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+render executes a template. Output past what Discord would take is
+dropped, and stops the template, so a loop can't run away with the worker.
+*/
+func render(source string, data Milestone) (string, error) {
+	tmpl, err := template.New("discord").Option("missingkey=error").Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrTemplate, err)
+	}
+	out := limitedWriter{limit: 4 * discord.MaxContent}
+	if err := tmpl.Execute(&out, data); err != nil && !errors.Is(err, errLimit) {
+		return "", fmt.Errorf("%w: %v", ErrTemplate, err)
+	}
+	return out.String(), nil
+}
+
+var errLimit = errors.New("template output too long")
+
+type limitedWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.limit {
+		w.Buffer.Write(p[:w.limit-w.Len()])
+		return 0, errLimit
+	}
+	return w.Buffer.Write(p)
+}
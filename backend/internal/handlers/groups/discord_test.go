@@ -0,0 +1,79 @@
+package groups
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+)
+
+func TestWeekOf(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		at       time.Time
+		expected time.Time
+	}{
+		{monday, monday},
+		{time.Date(2026, 10, 18, 23, 59, 0, 0, time.UTC), monday},
+		// Monday morning in Tokyo is still Sunday in UTC
+		{time.Date(2026, 10, 19, 8, 0, 0, 0, time.FixedZone("JST", 9*60*60)), monday},
+		{time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), monday.AddDate(0, 0, 7)},
+	}
+	for _, tt := range tests {
+		if got := weekOf(tt.at); !got.Equal(tt.expected) {
+			t.Errorf("%s: expected %s, got %s", tt.at, tt.expected, got)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	hidden := false
+	total, ranking := rank([]memberWeek{
+		{DisplayName: "Bo", Completed: 3},
+		{Handle: "cam", Completed: 5},
+		{DisplayName: "Ari", Completed: 3},
+		{DisplayName: "Dee", Completed: 4, Privacy: privacy.Settings{ShareActivity: &hidden}},
+		{DisplayName: "Eve"},
+	})
+	expected := []Rank{{1, "@cam", 5}, {2, "Ari", 3}, {2, "Bo", 3}, {4, "Eve", 0}}
+	if total != 15 {
+		t.Errorf("expected the hidden member counted in the total 15, got %d", total)
+	}
+	if len(ranking) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, ranking)
+	}
+	for i := range expected {
+		if ranking[i] != expected[i] {
+			t.Errorf("place %d: expected %v, got %v", i, expected[i], ranking[i])
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	milestone := Milestone{Group: "Gym", Week: "2026-10-12", Goal: 10, Completed: 12, Ranking: []Rank{{1, "Ari", 7}, {2, "Bo", 5}}}
+	tests := []struct {
+		name     string
+		source   string
+		expected string
+		err      error
+	}{
+		{"challenge", defaultChallenge, "🎉 **Gym** met this week's challenge: 12 of 10 tasks done!", nil},
+		{"leaderboard", defaultLeaderboard, "🏆 **Gym**, week of 2026-10-12\n1. Ari: 7\n2. Bo: 5\n", nil},
+		{"custom", "{{.Group}} did {{.Completed}}", "Gym did 12", nil},
+		{"unknown field", "{{.Streak}}", "", ErrTemplate},
+		{"bad syntax", "{{.Group", "", ErrTemplate},
+		{"runaway loop", "{{range 1000000000}}spam{{end}}", strings.Repeat("spam", 2000), nil},
+	}
+	for _, tt := range tests {
+		got, err := render(tt.source, milestone)
+		if !errors.Is(err, tt.err) || got != tt.expected {
+			t.Errorf("%s: expected %q %v, got %q %v", tt.name, tt.expected, tt.err, got, err)
+		}
+	}
+
+	if err := checkTemplates(Templates{Leaderboard: "{{range .Ranking}}{{.Nmae}}{{end}}"}); !errors.Is(err, ErrTemplate) {
+		t.Errorf("expected a misspelled field rejected, got %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package groups
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/integrations/discord"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=groups.go -destination=mock_test.go -package=groups
+
+// Groups is what Handler needs of the service
+type Groups interface {
+	Create(ctx context.Context, userID primitive.ObjectID, params CreateGroupParams) (*Group, error)
+	List(ctx context.Context, userID primitive.ObjectID, query xpage.Query) (*xpage.Page[Group], error)
+	Get(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Group, error)
+	Update(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, params UpdateGroupParams) (*Group, error)
+	Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	AddMember(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID) (*Group, error)
+	RemoveMember(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID) error
+	ConnectDiscord(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, params DiscordParams) (*Group, error)
+	DisconnectDiscord(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	TestDiscord(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+}
+
+var _ Groups = (*Service)(nil)
+
+type Handler struct {
+	service Groups
+}
+
+func (h *Handler) CreateGroup(c *fiber.Ctx) error {
+	var params CreateGroupParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	group, err := h.service.Create(c.UserContext(), userID(c), params)
+	if err != nil {
+		return groupError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(group)
+}
+
+func (h *Handler) ListGroups(c *fiber.Ctx) error {
+	var query xpage.Query
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := validator.Validate(query); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	groups, err := h.service.List(c.UserContext(), userID(c), query)
+	if err != nil {
+		return groupError(c, err)
+	}
+	return c.JSON(groups)
+}
+
+func (h *Handler) GetGroup(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	group, err := h.service.Get(c.UserContext(), userID(c), id)
+	if err != nil {
+		return groupError(c, err)
+	}
+	return c.JSON(group)
+}
+
+func (h *Handler) UpdateGroup(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	var params UpdateGroupParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	group, err := h.service.Update(c.UserContext(), userID(c), id, params)
+	if err != nil {
+		return groupError(c, err)
+	}
+	return c.JSON(group)
+}
+
+func (h *Handler) DeleteGroup(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := h.service.Delete(c.UserContext(), userID(c), id); err != nil {
+		return groupError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) AddMember(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	var params AddMemberParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+	member, _ := primitive.ObjectIDFromHex(params.UserID)
+
+	group, err := h.service.AddMember(c.UserContext(), userID(c), id, member)
+	if err != nil {
+		return groupError(c, err)
+	}
+	return c.JSON(group)
+}
+
+// RemoveMember removes a member, or with the caller's own id leaves the group
+func (h *Handler) RemoveMember(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	member, err := parseID(c, "user")
+	if err != nil {
+		return err
+	}
+	if err := h.service.RemoveMember(c.UserContext(), userID(c), id, member); err != nil {
+		return groupError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) ConnectDiscord(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	var params DiscordParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	group, err := h.service.ConnectDiscord(c.UserContext(), userID(c), id, params)
+	if err != nil {
+		return groupError(c, err)
+	}
+	return c.JSON(group)
+}
+
+func (h *Handler) DisconnectDiscord(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := h.service.DisconnectDiscord(c.UserContext(), userID(c), id); err != nil {
+		return groupError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) TestDiscord(c *fiber.Ctx) error {
+	id, err := parseID(c, "id")
+	if err != nil {
+		return err
+	}
+	if err := h.service.TestDiscord(c.UserContext(), userID(c), id); err != nil {
+		return groupError(c, err)
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+func groupError(c *fiber.Ctx, err error) error {
+	var status int
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Group not found",
+		})
+	case errors.Is(err, discord.ErrInvalidWebhook), errors.Is(err, ErrTemplate), errors.Is(err, ErrAdminLeaves), errors.Is(err, xpage.ErrInvalidCursor):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, ErrNotAdmin), errors.Is(err, ErrNotFriend):
+		status = fiber.StatusForbidden
+	case errors.Is(err, ErrNotMember):
+		status = fiber.StatusNotFound
+	case errors.Is(err, ErrAlreadyMember), errors.Is(err, ErrFull), errors.Is(err, ErrNoDiscord):
+		status = fiber.StatusConflict
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update the group",
+		})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func parseID(c *fiber.Ctx, param string) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(c.Params(param))
+	if err != nil {
+		return primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "Invalid ID format")
+	}
+	return id, nil
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := c.Locals("user_id").(string)
+	userID, _ := primitive.ObjectIDFromHex(id)
+	return userID
+}
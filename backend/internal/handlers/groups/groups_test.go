@@ -0,0 +1,127 @@
+package groups
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/integrations/discord"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+func serve(service Groups, userID primitive.ObjectID) *fiber.App {
+	handler := Handler{service}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Post("/groups", handler.CreateGroup)
+	app.Get("/groups/:id", handler.GetGroup)
+	app.Post("/groups/:id/members", handler.AddMember)
+	app.Delete("/groups/:id/members/:user", handler.RemoveMember)
+	app.Put("/groups/:id/discord", handler.ConnectDiscord)
+	app.Post("/groups/:id/discord/test", handler.TestDiscord)
+	return app
+}
+
+func send(t *testing.T, app *fiber.App, method string, path string, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(res.Body)
+	return res.StatusCode, string(raw)
+}
+
+func TestGroups(t *testing.T) {
+	userID, groupID, other := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	stranger, member, full := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	group := &Group{ID: groupID, Name: "Gym", Admin: userID, Members: []primitive.ObjectID{userID}}
+
+	service := NewMockGroups(gomock.NewController(t))
+	service.EXPECT().Create(gomock.Any(), userID, CreateGroupParams{Name: "Gym", WeeklyGoal: 20}).Return(group, nil)
+	service.EXPECT().Get(gomock.Any(), userID, groupID).Return(group, nil)
+	service.EXPECT().Get(gomock.Any(), userID, other).Return(nil, mongo.ErrNoDocuments)
+	service.EXPECT().AddMember(gomock.Any(), userID, groupID, member).Return(group, nil)
+	service.EXPECT().AddMember(gomock.Any(), userID, groupID, stranger).Return(nil, ErrNotFriend)
+	service.EXPECT().AddMember(gomock.Any(), userID, other, member).Return(nil, ErrNotAdmin)
+	service.EXPECT().AddMember(gomock.Any(), userID, groupID, full).Return(nil, ErrFull)
+	service.EXPECT().RemoveMember(gomock.Any(), userID, groupID, member).Return(nil)
+	service.EXPECT().RemoveMember(gomock.Any(), userID, groupID, userID).Return(ErrAdminLeaves)
+	app := serve(service, userID)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"create", http.MethodPost, "/groups", `{"name": "Gym", "weekly_goal": 20}`, fiber.StatusCreated},
+		{"create without a name", http.MethodPost, "/groups", `{"weekly_goal": 20}`, fiber.StatusBadRequest},
+		{"create with a negative goal", http.MethodPost, "/groups", `{"name": "Gym", "weekly_goal": -1}`, fiber.StatusBadRequest},
+		{"get", http.MethodGet, "/groups/" + groupID.Hex(), "", fiber.StatusOK},
+		{"get someone else's group", http.MethodGet, "/groups/" + other.Hex(), "", fiber.StatusNotFound},
+		{"get a bad id", http.MethodGet, "/groups/gym", "", fiber.StatusBadRequest},
+		{"add a friend", http.MethodPost, "/groups/" + groupID.Hex() + "/members", `{"user_id": "` + member.Hex() + `"}`, fiber.StatusOK},
+		{"add a stranger", http.MethodPost, "/groups/" + groupID.Hex() + "/members", `{"user_id": "` + stranger.Hex() + `"}`, fiber.StatusForbidden},
+		{"add as a member", http.MethodPost, "/groups/" + other.Hex() + "/members", `{"user_id": "` + member.Hex() + `"}`, fiber.StatusForbidden},
+		{"add to a full group", http.MethodPost, "/groups/" + groupID.Hex() + "/members", `{"user_id": "` + full.Hex() + `"}`, fiber.StatusConflict},
+		{"add a bad id", http.MethodPost, "/groups/" + groupID.Hex() + "/members", `{"user_id": "abhi"}`, fiber.StatusBadRequest},
+		{"remove a member", http.MethodDelete, "/groups/" + groupID.Hex() + "/members/" + member.Hex(), "", fiber.StatusNoContent},
+		{"admin leaves", http.MethodDelete, "/groups/" + groupID.Hex() + "/members/" + userID.Hex(), "", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if code, body := send(t, app, tt.method, tt.path, tt.body); code != tt.expected {
+			t.Errorf("%s: expected %d, got %d %s", tt.name, tt.expected, code, body)
+		}
+	}
+}
+
+func TestDiscord(t *testing.T) {
+	userID, groupID, bare := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	webhook := "https://discord.com/api/webhooks/123/token"
+	connected := &Group{ID: groupID, Admin: userID, Discord: &Discord{WebhookURL: webhook, Connected: true}}
+
+	service := NewMockGroups(gomock.NewController(t))
+	service.EXPECT().ConnectDiscord(gomock.Any(), userID, groupID, DiscordParams{WebhookURL: webhook, Templates: Templates{Challenge: "{{.Group}} did it"}}).Return(connected, nil)
+	service.EXPECT().ConnectDiscord(gomock.Any(), userID, groupID, DiscordParams{WebhookURL: "https://example.com/hook"}).Return(nil, discord.ErrInvalidWebhook)
+	service.EXPECT().ConnectDiscord(gomock.Any(), userID, groupID, DiscordParams{WebhookURL: webhook, Templates: Templates{Challenge: "{{.Streak}}"}}).Return(nil, ErrTemplate)
+	service.EXPECT().TestDiscord(gomock.Any(), userID, groupID).Return(nil)
+	service.EXPECT().TestDiscord(gomock.Any(), userID, bare).Return(ErrNoDiscord)
+	app := serve(service, userID)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		expected int
+	}{
+		{"connect", http.MethodPut, "/groups/" + groupID.Hex() + "/discord", `{"webhook_url": "` + webhook + `", "templates": {"challenge": "{{.Group}} did it"}}`, fiber.StatusOK},
+		{"connect another site", http.MethodPut, "/groups/" + groupID.Hex() + "/discord", `{"webhook_url": "https://example.com/hook"}`, fiber.StatusBadRequest},
+		{"connect a broken template", http.MethodPut, "/groups/" + groupID.Hex() + "/discord", `{"webhook_url": "` + webhook + `", "templates": {"challenge": "{{.Streak}}"}}`, fiber.StatusBadRequest},
+		{"connect a long template", http.MethodPut, "/groups/" + groupID.Hex() + "/discord", `{"webhook_url": "` + webhook + `", "templates": {"leaderboard": "` + strings.Repeat("a", 1001) + `"}}`, fiber.StatusBadRequest},
+		{"connect without a URL", http.MethodPut, "/groups/" + groupID.Hex() + "/discord", `{}`, fiber.StatusBadRequest},
+		{"test", http.MethodPost, "/groups/" + groupID.Hex() + "/discord/test", "", fiber.StatusAccepted},
+		{"test without a webhook", http.MethodPost, "/groups/" + bare.Hex() + "/discord/test", "", fiber.StatusConflict},
+	}
+	for _, tt := range tests {
+		code, body := send(t, app, tt.method, tt.path, tt.body)
+		if code != tt.expected {
+			t.Errorf("%s: expected %d, got %d %s", tt.name, tt.expected, code, body)
+		}
+		if strings.Contains(body, "token") {
+			t.Errorf("%s: expected the webhook URL kept out of the response, got %s", tt.name, body)
+		}
+	}
+}
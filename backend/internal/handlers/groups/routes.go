@@ -0,0 +1,43 @@
+package groups
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+	Groups := apiV1.Group("/groups", authenticate)
+
+	Groups.Post("/", handler.CreateGroup)
+	Groups.Get("/", handler.ListGroups)
+	Groups.Get("/:id", handler.GetGroup)
+	Groups.Patch("/:id", handler.UpdateGroup)
+	Groups.Delete("/:id", handler.DeleteGroup)
+	Groups.Post("/:id/members", handler.AddMember)
+	Groups.Delete("/:id/members/:user", handler.RemoveMember)
+	Groups.Put("/:id/discord", handler.ConnectDiscord)
+	Groups.Delete("/:id/discord", handler.DisconnectDiscord)
+	Groups.Post("/:id/discord/test", handler.TestDiscord)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/groups/":                    {Summary: "Create a group, with the caller as its admin", Auth: true, Request: CreateGroupParams{}, Response: Group{}, Status: fiber.StatusCreated},
+		"GET /api/v1/groups/":                     {Summary: "The groups the caller is a member of, a page at a time", Auth: true, Query: xpage.Query{}, Response: xpage.Page[Group]{}},
+		"GET /api/v1/groups/:id":                  {Summary: "Get a group the caller is a member of", Auth: true, Response: Group{}},
+		"PATCH /api/v1/groups/:id":                {Summary: "Rename a group or change its weekly goal, admin only", Auth: true, Request: UpdateGroupParams{}, Response: Group{}},
+		"DELETE /api/v1/groups/:id":               {Summary: "Delete a group, admin only", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/groups/:id/members":         {Summary: "Add one of the admin's friends to a group, admin only", Auth: true, Request: AddMemberParams{}, Response: Group{}},
+		"DELETE /api/v1/groups/:id/members/:user": {Summary: "Remove a member, or leave the group with the caller's own id", Auth: true, Status: fiber.StatusNoContent},
+		"PUT /api/v1/groups/:id/discord":          {Summary: "Post the group's milestones to a Discord channel's webhook, admin only", Auth: true, Request: DiscordParams{}, Response: Group{}},
+		"DELETE /api/v1/groups/:id/discord":       {Summary: "Stop posting to Discord, admin only", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/groups/:id/discord/test":    {Summary: "Queue a test post to the group's Discord webhook, admin only", Auth: true, Status: fiber.StatusAccepted},
+	})
+}
@@ -0,0 +1,231 @@
+package groups
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/integrations/discord"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Service struct {
+	groups  *mongo.Collection
+	users   *mongo.Collection
+	queue   *jobs.Queue
+	discord *discord.Client
+}
+
+// newService receives the map of collections and picks out Groups, Users and Jobs
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{
+		groups:  collections[Collection],
+		users:   collections["users"],
+		queue:   jobs.New(collections[jobs.Collection]),
+		discord: discord.NewClient(),
+	}
+}
+
+func (s *Service) Create(ctx context.Context, userID primitive.ObjectID, params CreateGroupParams) (*Group, error) {
+	group := Group{
+		ID:         primitive.NewObjectID(),
+		Name:       params.Name,
+		Admin:      userID,
+		Members:    []primitive.ObjectID{userID},
+		WeeklyGoal: params.WeeklyGoal,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if _, err := s.groups.InsertOne(ctx, group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// List is a page of the groups userID is a member of, oldest first
+func (s *Service) List(ctx context.Context, userID primitive.ObjectID, query xpage.Query) (*xpage.Page[Group], error) {
+	filter := bson.M{"members": userID}
+	if query.Cursor != "" {
+		after, err := xpage.Decode(query.Cursor, nil)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(filter, xpage.After("_id", 1, nil, after))
+	}
+	size := query.Size(xpage.DefaultLimit)
+	cursor, err := s.groups.Find(ctx, filter, options.Find().SetSort(xpage.Sort("_id", 1)).SetLimit(int64(size+1)))
+	if err != nil {
+		return nil, err
+	}
+	groups := []Group{}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].shown()
+	}
+	return xpage.New(groups, size, func(group Group) (any, primitive.ObjectID) {
+		return nil, group.ID
+	}), nil
+}
+
+// Get finds a group userID is a member of; other groups look like they don't exist
+func (s *Service) Get(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Group, error) {
+	var group Group
+	if err := s.groups.FindOne(ctx, bson.M{"_id": id, "members": userID}).Decode(&group); err != nil {
+		return nil, err
+	}
+	group.shown()
+	return &group, nil
+}
+
+// administered is Get for what only the admin may do
+func (s *Service) administered(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Group, error) {
+	group, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if group.Admin != userID {
+		return nil, ErrNotAdmin
+	}
+	return group, nil
+}
+
+func (s *Service) Update(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, params UpdateGroupParams) (*Group, error) {
+	if _, err := s.administered(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	set := bson.M{}
+	if params.Name != nil {
+		set["name"] = *params.Name
+	}
+	if params.WeeklyGoal != nil {
+		set["weekly_goal"] = *params.WeeklyGoal
+	}
+	if len(set) > 0 {
+		if _, err := s.groups.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set}); err != nil {
+			return nil, err
+		}
+	}
+	return s.Get(ctx, userID, id)
+}
+
+func (s *Service) Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	if _, err := s.administered(ctx, userID, id); err != nil {
+		return err
+	}
+	_, err := s.groups.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// AddMember adds one of the admin's friends to the group
+func (s *Service) AddMember(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID) (*Group, error) {
+	group, err := s.administered(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case slices.Contains(group.Members, member):
+		return nil, ErrAlreadyMember
+	case len(group.Members) >= MaxMembers:
+		return nil, ErrFull
+	}
+	found, err := privacy.Load(ctx, s.users, []primitive.ObjectID{userID, member})
+	if err != nil {
+		return nil, err
+	}
+	admin, friend := found[userID], found[member]
+	if _, ok := found[member]; !ok || !slices.Contains(admin.Friends, member) || privacy.Blocked(admin, friend) {
+		return nil, ErrNotFriend
+	}
+
+	// the filter holds the checks above against concurrent adds
+	result, err := s.groups.UpdateOne(ctx, bson.M{
+		"_id":                                   id,
+		"members":                               bson.M{"$ne": member},
+		"members." + strconv.Itoa(MaxMembers-1): bson.M{"$exists": false},
+	}, bson.M{"$push": bson.M{"members": member}})
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrFull
+	}
+	return s.Get(ctx, userID, id)
+}
+
+// RemoveMember takes member out of the group; the admin removes anyone, the others only themselves
+func (s *Service) RemoveMember(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, member primitive.ObjectID) error {
+	group, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	switch {
+	case member == group.Admin:
+		return ErrAdminLeaves
+	case userID != group.Admin && userID != member:
+		return ErrNotAdmin
+	case !slices.Contains(group.Members, member):
+		return ErrNotMember
+	}
+	_, err = s.groups.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"members": member}})
+	return err
+}
+
+// ConnectDiscord sets the webhook the group's milestones are posted to, replacing any before it
+func (s *Service) ConnectDiscord(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, params DiscordParams) (*Group, error) {
+	if err := discord.ValidWebhook(params.WebhookURL); err != nil {
+		return nil, err
+	}
+	if err := checkTemplates(params.Templates); err != nil {
+		return nil, err
+	}
+	if _, err := s.administered(ctx, userID, id); err != nil {
+		return nil, err
+	}
+	_, err := s.groups.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"discord": Discord{WebhookURL: params.WebhookURL, Templates: params.Templates},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID, id)
+}
+
+func (s *Service) DisconnectDiscord(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	if _, err := s.administered(ctx, userID, id); err != nil {
+		return err
+	}
+	_, err := s.groups.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"discord": ""}})
+	return err
+}
+
+// TestDiscord queues a post to the webhook, for the admin to see it arrive
+func (s *Service) TestDiscord(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	group, err := s.administered(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !group.Discord.connected() {
+		return ErrNoDiscord
+	}
+	_, err = s.queue.Enqueue(ctx, PostJob, PostPayload{Group: id, Kind: TestPost})
+	return err
+}
+
+func (g *Group) shown() {
+	if g.Discord != nil {
+		g.Discord.Connected = g.Discord.connected()
+	}
+}
+
+func (d *Discord) connected() bool {
+	return d != nil && d.WebhookURL != ""
+}
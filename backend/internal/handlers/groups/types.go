@@ -0,0 +1,76 @@
+package groups
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	Collection = "groups"
+	MaxMembers = 50
+)
+
+var (
+	ErrNotAdmin      = errors.New("only the group's admin can do that")
+	ErrNotFriend     = errors.New("you can only add your friends")
+	ErrAlreadyMember = errors.New("already a member")
+	ErrNotMember     = errors.New("not a member")
+	ErrFull          = errors.New("the group is full")
+	ErrAdminLeaves   = errors.New("the admin can't leave, delete the group instead")
+	ErrNoDiscord     = errors.New("the group has no Discord webhook")
+)
+
+/*
+Group is a few friends working towards a shared weekly goal. Its admin
+created it and is the only one who manages its members and integrations.
+*/
+type Group struct {
+	ID      primitive.ObjectID   `bson:"_id" json:"id"`
+	Name    string               `bson:"name" json:"name"`
+	Admin   primitive.ObjectID   `bson:"admin" json:"admin"`
+	Members []primitive.ObjectID `bson:"members" json:"members"`
+	// tasks the members mean to complete between them each week, 0 for no challenge
+	WeeklyGoal int `bson:"weekly_goal" json:"weekly_goal"`
+	// the week the challenge was last met, so it's announced once
+	ChallengeWeek string    `bson:"challenge_week,omitempty" json:"-"`
+	Discord       *Discord  `bson:"discord,omitempty" json:"discord,omitempty"`
+	CreatedAt     time.Time `bson:"created_at" json:"created_at"`
+}
+
+// Discord is where the group's milestones are posted
+type Discord struct {
+	// holds the webhook's token, so it isn't shown back
+	WebhookURL string    `bson:"webhook_url,omitempty" json:"-"`
+	Connected  bool      `bson:"-" json:"connected"`
+	Templates  Templates `bson:"templates" json:"templates"`
+	// why the webhook was detached, when Discord stopped accepting it
+	Error string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// Templates are text/template sources for the posts, empty for the defaults; see Milestone for their data
+type Templates struct {
+	Challenge   string `validate:"omitempty,max=1000" bson:"challenge,omitempty" json:"challenge,omitempty"`
+	Leaderboard string `validate:"omitempty,max=1000" bson:"leaderboard,omitempty" json:"leaderboard,omitempty"`
+}
+
+type CreateGroupParams struct {
+	Name       string `validate:"required,max=100" json:"name"`
+	WeeklyGoal int    `validate:"min=0,max=1000" json:"weekly_goal"`
+}
+
+// UpdateGroupParams changes the fields it sets
+type UpdateGroupParams struct {
+	Name       *string `validate:"omitempty,min=1,max=100" json:"name"`
+	WeeklyGoal *int    `validate:"omitempty,min=0,max=1000" json:"weekly_goal"`
+}
+
+type AddMemberParams struct {
+	UserID string `validate:"required,mongodb" json:"user_id"`
+}
+
+type DiscordParams struct {
+	WebhookURL string    `validate:"required,url,max=500" json:"webhook_url"`
+	Templates  Templates `json:"templates"`
+}
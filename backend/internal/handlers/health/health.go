@@ -1,16 +1,51 @@
 package health
 
 import (
+	"context"
 	"github.com/gofiber/fiber/v2"
 )
 
+//go:generate mockgen -source=health.go -destination=mock_test.go -package=health
+
+// Checker reports on the app's dependencies
+type Checker interface {
+	CheckReadiness(ctx context.Context) ReadinessReport
+}
+
+var _ Checker = (*Service)(nil)
+
 /*
 Handler to execute business logic for Health Endpoint
 */
 type Handler struct {
-	service *Service
+	service Checker
 }
 
 func (h *Handler) GetHealth(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusOK)
 }
+
+/*
+Liveness probe: the process is up and serving requests.
+Deliberately does not touch any dependency so a database outage
+doesn't get every pod restarted.
+*/
+func (h *Handler) GetLiveness(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status": StatusOK,
+	})
+}
+
+/*
+Readiness probe: every dependency needed to serve traffic is reachable.
+Responds 503 with the per-dependency breakdown when any check fails.
+*/
+func (h *Handler) GetReadiness(c *fiber.Ctx) error {
+	report := h.service.CheckReadiness(c.UserContext())
+
+	status := fiber.StatusOK
+	if report.Status != StatusOK {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(report)
+}
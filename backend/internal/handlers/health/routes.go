@@ -1,6 +1,8 @@
 package health
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/storage/xredis"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -8,11 +10,20 @@ import (
 /*
 Router maps endpoints to handlers
 */
-func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
-	service := newService(collections)
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, redis *xredis.Client) {
+	service := newService(collections, redis)
 	handler := Handler{service}
 
 	api := app.Group("/health")
 	api.Get("/", handler.GetHealth)
 
+	// Kubernetes / load balancer probes
+	app.Get("/healthz", handler.GetLiveness)
+	app.Get("/readyz", handler.GetReadiness)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /health/": {Summary: "Always OK while the process serves requests"},
+		"GET /healthz": {Summary: "Liveness probe", Response: fiber.Map{}},
+		"GET /readyz":  {Summary: "Readiness probe, 503 with the failing dependencies", Response: ReadinessReport{}},
+	})
 }
@@ -1,13 +1,104 @@
 package health
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xredis"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// upper bound on each dependency check so a hung dependency can't hang the probe
+const checkTimeout = 2 * time.Second
+
 type Service struct {
 	health *mongo.Collection
+	db     *mongo.Database
+	redis  *xredis.Client
+}
+
+func newService(collections map[string]*mongo.Collection, redis *xredis.Client) *Service {
+	var db *mongo.Database
+	for _, collection := range collections {
+		db = collection.Database()
+		break
+	}
+	return &Service{collections["health"], db, redis}
+}
+
+type check func(ctx context.Context) DependencyStatus
+
+// CheckReadiness runs every dependency check concurrently
+func (s *Service) CheckReadiness(ctx context.Context) ReadinessReport {
+	checks := map[string]check{
+		"mongo":         s.checkMongo,
+		"mongo_indexes": s.checkIndexes,
+		"redis":         s.checkRedis,
+	}
+
+	report := ReadinessReport{Status: StatusOK, Dependencies: make(map[string]DependencyStatus, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, run := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+
+			start := time.Now()
+			result := run(checkCtx)
+			result.LatencyMS = time.Since(start).Milliseconds()
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Dependencies[name] = result
+			if result.Status == StatusDown {
+				report.Status = StatusDown
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func (s *Service) checkMongo(ctx context.Context) DependencyStatus {
+	if s.db == nil {
+		return down(errors.New("no collections loaded"))
+	}
+	if err := s.db.Client().Ping(ctx, nil); err != nil {
+		return down(err)
+	}
+	return DependencyStatus{Status: StatusOK}
+}
+
+func (s *Service) checkIndexes(ctx context.Context) DependencyStatus {
+	if s.db == nil {
+		return down(errors.New("no collections loaded"))
+	}
+	missing, err := xmongo.MissingIndexes(ctx, s.db)
+	if err != nil {
+		return down(err)
+	}
+	if len(missing) > 0 {
+		return DependencyStatus{Status: StatusDown, Error: "required indexes missing", Missing: missing}
+	}
+	return DependencyStatus{Status: StatusOK}
+}
+
+func (s *Service) checkRedis(ctx context.Context) DependencyStatus {
+	if s.redis == nil {
+		return DependencyStatus{Status: StatusDisabled}
+	}
+	if err := s.redis.Ping(ctx).Err(); err != nil {
+		return down(err)
+	}
+	return DependencyStatus{Status: StatusOK}
 }
 
-func newService(collections map[string]*mongo.Collection) *Service {
-	return &Service{collections["health"]}
+func down(err error) DependencyStatus {
+	return DependencyStatus{Status: StatusDown, Error: err.Error()}
 }
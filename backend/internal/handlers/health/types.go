@@ -0,0 +1,19 @@
+package health
+
+const (
+	StatusOK       = "ok"
+	StatusDown     = "down"
+	StatusDisabled = "disabled"
+)
+
+type DependencyStatus struct {
+	Status    string   `json:"status"`
+	LatencyMS int64    `json:"latency_ms"`
+	Error     string   `json:"error,omitempty"`
+	Missing   []string `json:"missing,omitempty"`
+}
+
+type ReadinessReport struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
@@ -0,0 +1,155 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xhttp"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	gojson "github.com/goccy/go-json"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const DeliverJob = "hooks.deliver"
+
+type DeliverPayload struct {
+	Hook primitive.ObjectID `bson:"hook"`
+	// the task or friend the event is about
+	Item primitive.ObjectID `bson:"item"`
+}
+
+// hooks are posted to user-supplied URLs, and a redirect is the target's answer, not followed
+var client = xhttp.NewPublicClient(10*time.Second, 0)
+
+// RegisterJobs adds the hook delivery job handler to the worker
+func RegisterJobs(worker *jobs.Worker, collections map[string]*mongo.Collection, cache xcache.Cache) {
+	s := newService(collections, cache, "")
+	worker.Handle(DeliverJob, s.deliver)
+}
+
+// SubscribeEvents queues a delivery to each hook subscribed to an event as it happens
+func SubscribeEvents(bus *events.Bus, collections map[string]*mongo.Collection, cache xcache.Cache) {
+	s := newService(collections, cache, "")
+	bus.SubscribeAsync(func(ctx context.Context, event events.Event) {
+		userID, err := primitive.ObjectIDFromHex(event.UserID)
+		if err != nil {
+			return
+		}
+		item, err := primitive.ObjectIDFromHex(event.DocumentID)
+		if err != nil {
+			return
+		}
+		if err := s.enqueue(ctx, userID, event.Type, item); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to queue hook deliveries",
+				slog.String("event_type", string(event.Type)), xslog.Error(err))
+		}
+	}, Events...)
+}
+
+func (s *Service) enqueue(ctx context.Context, userID primitive.ObjectID, event events.Type, item primitive.ObjectID) error {
+	hooks, err := s.Hooks.Find(ctx, bson.M{"user": userID, "event": event})
+	if err != nil {
+		return err
+	}
+	var subscribed []Hook
+	if err := hooks.All(ctx, &subscribed); err != nil {
+		return err
+	}
+	for _, hook := range subscribed {
+		if _, err := s.queue.Enqueue(ctx, DeliverJob, DeliverPayload{Hook: hook.ID, Item: item}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+deliver posts the item as it is now, so a task edited in between goes out
+edited. Failures are retried with the queue's backoff; a 410 Gone is the
+REST hook way of unsubscribing and removes the hook.
+*/
+func (s *Service) deliver(ctx context.Context, job *jobs.Job) error {
+	var payload DeliverPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var hook Hook
+	err := s.Hooks.FindOne(ctx, bson.M{"_id": payload.Hook}).Decode(&hook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// unsubscribed since
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	item, err := s.item(ctx, &hook, payload.Item)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// deleted since, there is nothing to send
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	body, err := gojson.Marshal(item)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.TargetURL, bytes.NewReader(body))
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if errors.Is(err, xhttp.ErrPrivateAddress) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		_, err := s.Hooks.DeleteOne(ctx, bson.M{"_id": hook.ID})
+		return err
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("hook target responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *Service) item(ctx context.Context, hook *Hook, id primitive.ObjectID) (any, error) {
+	switch hook.Event {
+	case events.TaskCreated, events.TaskCompleted:
+		items, err := s.taskItems(ctx, hook.User, bson.M{"_id": id}, "timestamp")
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		return items[0], nil
+	case events.FriendAdded:
+		items, err := s.friendItems(ctx, hook.User, []primitive.ObjectID{id})
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		return items[0], nil
+	}
+	return nil, jobs.Permanent(ErrUnknownEvent)
+}
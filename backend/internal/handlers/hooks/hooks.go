@@ -0,0 +1,255 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=hooks.go -destination=mock_test.go -package=hooks
+
+// Hooks is the webhook service as Handler uses it
+type Hooks interface {
+	CreateInbound(ctx context.Context, userID primitive.ObjectID, req InboundHookRequest) (*CreatedInboundHook, error)
+	ListInbound(ctx context.Context, userID primitive.ObjectID) ([]InboundHook, error)
+	UpdateMapping(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, mapping Mapping) (*InboundHook, error)
+	DeleteInbound(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	Receive(ctx context.Context, token string, signature string, timestamp string, body []byte) (*TaskItem, error)
+	Subscribe(ctx context.Context, userID primitive.ObjectID, req SubscribeRequest) (*Hook, error)
+	Unsubscribe(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	List(ctx context.Context, userID primitive.ObjectID) ([]Hook, error)
+	Poll(ctx context.Context, userID primitive.ObjectID, event events.Type) (any, error)
+	CreateTask(ctx context.Context, userID primitive.ObjectID, req CreateTaskRequest) (*TaskItem, error)
+}
+
+var _ Hooks = (*Service)(nil)
+
+/*
+Handler to execute business logic for Hooks Endpoint
+*/
+type Handler struct {
+	service Hooks
+}
+
+var validator = xvalidator.Validator
+
+// Subscribe is Zapier's subscribe call; the returned id is what it sends back to unsubscribe
+func (h *Handler) Subscribe(c *fiber.Ctx) error {
+	var req SubscribeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	hook, err := h.service.Subscribe(c.UserContext(), userID(c), req)
+	if errors.Is(err, ErrTooManyHooks) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Too many hooks, remove one first",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(hook)
+}
+
+func (h *Handler) Unsubscribe(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	err = h.service.Unsubscribe(c.UserContext(), userID(c), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Hook not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) ListHooks(c *fiber.Ctx) error {
+	hooks, err := h.service.List(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(hooks)
+}
+
+// Poll serves the polling trigger for an event, also used for sample data while a zap is set up
+func (h *Handler) Poll(c *fiber.Ctx) error {
+	items, err := h.service.Poll(c.UserContext(), userID(c), events.Type(c.Params("event")))
+	if errors.Is(err, ErrUnknownEvent) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Unknown trigger",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(items)
+}
+
+func (h *Handler) CreateTask(c *fiber.Ctx) error {
+	var req CreateTaskRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	item, err := h.service.CreateTask(c.UserContext(), userID(c), req)
+	switch {
+	case errors.Is(err, ErrNoCategory):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Create a category first",
+		})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	case err != nil:
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(item)
+}
+
+func (h *Handler) CreateInbound(c *fiber.Ctx) error {
+	var req InboundHookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	hook, err := h.service.CreateInbound(c.UserContext(), userID(c), req)
+	switch {
+	case errors.Is(err, ErrTooManyHooks):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Too many inbound hooks, delete one first",
+		})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	case err != nil:
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(hook)
+}
+
+func (h *Handler) ListInbound(c *fiber.Ctx) error {
+	hooks, err := h.service.ListInbound(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(hooks)
+}
+
+func (h *Handler) UpdateMapping(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	var mapping Mapping
+	if err := c.BodyParser(&mapping); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(mapping); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	hook, err := h.service.UpdateMapping(c.UserContext(), userID(c), id, mapping)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Inbound hook not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(hook)
+}
+
+func (h *Handler) DeleteInbound(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	err = h.service.DeleteInbound(c.UserContext(), userID(c), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Inbound hook not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+/*
+Receive takes a delivery to an inbound hook. It answers 200 without a task
+when the delivery was deduplicated, so senders don't retry it.
+*/
+func (h *Handler) Receive(c *fiber.Ctx) error {
+	item, err := h.service.Receive(c.UserContext(), c.Params("token"), c.Get(SignatureHeader), c.Get(SignatureTimestampHeader), c.Body())
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Hook not found",
+		})
+	case errors.Is(err, ErrBadSignature):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid signature",
+		})
+	case errors.Is(err, ErrReplayed):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Delivery already received or its timestamp is too old",
+		})
+	case errors.Is(err, ErrBadPayload):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Body must be JSON",
+		})
+	case errors.Is(err, ErrNoCategory):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "The hook's owner has no category",
+		})
+	case err != nil:
+		return err
+	}
+	if item == nil {
+		return c.JSON(fiber.Map{"duplicate": true})
+	}
+	return c.Status(fiber.StatusCreated).JSON(item)
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	return id
+}
@@ -0,0 +1,324 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	gojson "github.com/goccy/go-json"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	maxInboundHooks = 20
+	// the task is titled with the hook's name when the title renders empty
+	maxTitle = 500
+	maxNotes = 5000
+)
+
+const (
+	SignatureHeader          = "X-Signature-256"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+	// how far a delivery's timestamp may be from now; a signature is only accepted once within it
+	signatureTolerance = 5 * time.Minute
+)
+
+var (
+	ErrBadSignature = errors.New("bad signature")
+	ErrReplayed     = errors.New("stale or replayed delivery")
+	ErrBadPayload   = errors.New("payload is not JSON")
+)
+
+// a payload of {"title": ..., "body": ...} works without setting up a mapping
+var defaultMapping = Mapping{Title: "{{title}}", Notes: "{{body}}"}
+
+func (s *Service) inboundURL(hook *InboundHook) {
+	hook.URL = s.publicURL + "/hooks/in/" + hook.Token
+}
+
+func (s *Service) CreateInbound(ctx context.Context, userID primitive.ObjectID, req InboundHookRequest) (*CreatedInboundHook, error) {
+	count, err := s.Inbound.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxInboundHooks {
+		return nil, ErrTooManyHooks
+	}
+
+	hook := InboundHook{
+		ID:        primitive.NewObjectID(),
+		User:      userID,
+		Name:      req.Name,
+		Mapping:   defaultMapping,
+		CreatedAt: time.Now(),
+	}
+	if req.Mapping != nil {
+		hook.Mapping = *req.Mapping
+	}
+	if req.Category != "" {
+		id, _ := primitive.ObjectIDFromHex(req.Category)
+		live, err := s.Users.CountDocuments(ctx, bson.M{
+			"_id":        userID,
+			"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": id})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if live == 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		hook.Category = &id
+	}
+	if hook.Token, err = randomToken(24); err != nil {
+		return nil, err
+	}
+	if hook.Secret, err = randomToken(32); err != nil {
+		return nil, err
+	}
+	if _, err := s.Inbound.InsertOne(ctx, hook); err != nil {
+		return nil, err
+	}
+	s.inboundURL(&hook)
+	return &CreatedInboundHook{InboundHook: hook, Secret: hook.Secret}, nil
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func (s *Service) ListInbound(ctx context.Context, userID primitive.ObjectID) ([]InboundHook, error) {
+	cursor, err := s.Inbound.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	hooks := make([]InboundHook, 0)
+	if err := cursor.All(ctx, &hooks); err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		s.inboundURL(&hooks[i])
+	}
+	return hooks, nil
+}
+
+// UpdateMapping replaces how the hook's payloads become tasks
+func (s *Service) UpdateMapping(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, mapping Mapping) (*InboundHook, error) {
+	var hook InboundHook
+	err := s.Inbound.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "user": userID},
+		bson.M{"$set": bson.M{"mapping": mapping}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&hook)
+	if err != nil {
+		return nil, err
+	}
+	s.inboundURL(&hook)
+	return &hook, nil
+}
+
+func (s *Service) DeleteInbound(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	result, err := s.Inbound.DeleteOne(ctx, bson.M{"_id": id, "user": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+/*
+Receive creates the task a delivery maps to. It returns a nil item when the
+delivery's dedupe key matches a task that is still open. Every delivery is
+signed: signature is the X-Signature-256 header, "sha256=" and the hex HMAC
+with the hook's secret of the X-Signature-Timestamp header (unix seconds), a
+dot and the body. The timestamp has to be recent and each signature works
+once, so a captured delivery can't be replayed. Deliveries are recorded by
+the decoded MAC, hex being case-insensitive.
+*/
+func (s *Service) Receive(ctx context.Context, token string, signature string, timestamp string, body []byte) (*TaskItem, error) {
+	var hook InboundHook
+	if err := s.Inbound.FindOne(ctx, bson.M{"token": token}).Decode(&hook); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	mac, err := verifyDelivery(hook.Secret, signature, timestamp, body, now)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.Deliveries.InsertOne(ctx, bson.M{"_id": deliveryID(hook.ID, mac), "hook": hook.ID, "received_at": now})
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrReplayed
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := gojson.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var payload any
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, ErrBadPayload
+	}
+
+	req := CreateTaskRequest{
+		Content:  truncate(render(hook.Mapping.Title, payload), maxTitle),
+		Notes:    truncate(render(hook.Mapping.Notes, payload), maxNotes),
+		Priority: hook.Mapping.Priority,
+	}
+	if req.Content == "" {
+		req.Content = hook.Name
+	}
+	if hook.Category != nil {
+		req.Category = hook.Category.Hex()
+	}
+	if key := render(hook.Mapping.Dedupe, payload); key != "" {
+		req.Source = "hook:" + hook.ID.Hex() + ":" + truncate(key, maxTitle)
+		open, err := s.Users.CountDocuments(ctx, bson.M{
+			"_id": hook.User,
+			"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{
+				"tasks": bson.M{"$elemMatch": softdelete.Filter(bson.M{
+					"source":    req.Source,
+					"completed": bson.M{"$ne": true},
+				})},
+			})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if open > 0 {
+			return nil, s.received(ctx, hook.ID)
+		}
+	}
+
+	item, err := s.CreateTask(ctx, hook.User, req)
+	if err != nil {
+		return nil, err
+	}
+	return item, s.received(ctx, hook.ID)
+}
+
+func (s *Service) received(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.Inbound.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_received_at": time.Now()}})
+	return err
+}
+
+/*
+verifyDelivery checks the signature over timestamp and body, and that
+timestamp is within signatureTolerance of now. It returns the signature's
+MAC, the same however its hex was cased.
+*/
+func verifyDelivery(secret string, signature string, timestamp string, body []byte, now time.Time) ([]byte, error) {
+	mac, ok := validSignature(secret, signature, timestamp, body)
+	if !ok {
+		return nil, ErrBadSignature
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrBadSignature
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > signatureTolerance || skew < -signatureTolerance {
+		return nil, ErrReplayed
+	}
+	return mac, nil
+}
+
+func validSignature(secret string, signature string, timestamp string, body []byte) ([]byte, bool) {
+	sum, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok || timestamp == "" {
+		return nil, false
+	}
+	got, err := hex.DecodeString(sum)
+	if err != nil {
+		return nil, false
+	}
+	return got, hmac.Equal(got, sign(secret, timestamp, body))
+}
+
+// deliveryID is the key a delivery is recorded under
+func deliveryID(hookID primitive.ObjectID, mac []byte) string {
+	return hookID.Hex() + ":" + hex.EncodeToString(mac)
+}
+
+func sign(secret string, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+var placeholder = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// render fills a template's {{path}} placeholders from payload; missing fields render empty
+func render(template string, payload any) string {
+	out := placeholder.ReplaceAllStringFunc(template, func(match string) string {
+		return format(lookup(payload, placeholder.FindStringSubmatch(match)[1]))
+	})
+	return strings.TrimSpace(out)
+}
+
+// lookup follows a dotted path through objects, and arrays by index
+func lookup(value any, path string) any {
+	if path == "" {
+		return nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil
+			}
+			value = v[i]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func format(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case gojson.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, err := gojson.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+func truncate(s string, limit int) string {
+	if runes := []rune(s); len(runes) > limit {
+		return string(runes[:limit])
+	}
+	return s
+}
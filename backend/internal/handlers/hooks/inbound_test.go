@@ -0,0 +1,82 @@
+package hooks
+
+import (
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVerifyDelivery(t *testing.T) {
+	const secret = "hook-secret"
+	body := []byte(`{"title": "Disk almost full"}`)
+	now := time.Now()
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	signed := func(secret string, timestamp string, body []byte) string {
+		return "sha256=" + hex.EncodeToString(sign(secret, timestamp, body))
+	}
+
+	tests := []struct {
+		name      string
+		signature string
+		timestamp string
+		body      []byte
+		expected  error
+	}{
+		{"valid", signed(secret, fresh, body), fresh, body, nil},
+		{"unsigned", "", fresh, body, ErrBadSignature},
+		{"no timestamp", signed(secret, "", body), "", body, ErrBadSignature},
+		{"other secret", signed("guess", fresh, body), fresh, body, ErrBadSignature},
+		{"tampered body", signed(secret, fresh, body), fresh, []byte(`{"title": "Rotate the keys"}`), ErrBadSignature},
+		// the timestamp is signed, moving it forward breaks the signature
+		{"timestamp swapped", signed(secret, "1700000000", body), fresh, body, ErrBadSignature},
+		{"missing prefix", hex.EncodeToString(sign(secret, fresh, body)), fresh, body, ErrBadSignature},
+		{"not hex", "sha256=zz", fresh, body, ErrBadSignature},
+		{"replayed", signed(secret, "1700000000", body), "1700000000", body, ErrReplayed},
+		{"from the future", signed(secret, strconv.FormatInt(now.Add(time.Hour).Unix(), 10), body), strconv.FormatInt(now.Add(time.Hour).Unix(), 10), body, ErrReplayed},
+	}
+	for _, tt := range tests {
+		if _, err := verifyDelivery(secret, tt.signature, tt.timestamp, tt.body, now); !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+
+	// re-casing the hex is the same signature, so it has to be recorded as the same delivery
+	hookID := primitive.NewObjectID()
+	lower, err := verifyDelivery(secret, signed(secret, fresh, body), fresh, body, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upper, err := verifyDelivery(secret, "sha256="+strings.ToUpper(hex.EncodeToString(sign(secret, fresh, body))), fresh, body, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deliveryID(hookID, lower) != deliveryID(hookID, upper) {
+		t.Errorf("upper-case signature recorded as %s, lower-case as %s", deliveryID(hookID, upper), deliveryID(hookID, lower))
+	}
+}
+
+func TestRender(t *testing.T) {
+	payload := map[string]any{
+		"alert": map[string]any{"name": "Disk almost full", "labels": map[string]any{"severity": "high"}},
+		"items": []any{map[string]any{"id": "a1"}},
+	}
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"{{alert.labels.severity}}: {{alert.name}}", "high: Disk almost full"},
+		{"{{ items.0.id }}", "a1"},
+		{"{{items.5.id}}{{missing}}", ""},
+		{"{{alert.labels}}", `{"severity":"high"}`},
+	}
+	for _, tt := range tests {
+		if got := render(tt.template, payload); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.template, tt.expected, got)
+		}
+	}
+}
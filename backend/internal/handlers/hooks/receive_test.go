@@ -0,0 +1,47 @@
+//go:build integration
+
+package hooks
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo/mongotest"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestReceiveReplayed(t *testing.T) {
+	db := mongotest.Database(t)
+	s := newService(db.Collections, xcache.Noop{}, "")
+	ctx := context.Background()
+
+	userID := primitive.NewObjectID()
+	if _, err := s.Users.InsertOne(ctx, bson.M{"_id": userID, "categories": bson.A{
+		bson.M{"_id": primitive.NewObjectID(), "name": "Inbox", "tasks": bson.A{}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	hook := InboundHook{ID: primitive.NewObjectID(), User: userID, Name: "Alerts", Token: "hook-token", Secret: "hook-secret", Mapping: defaultMapping}
+	if _, err := s.Inbound.InsertOne(ctx, hook); err != nil {
+		t.Fatal(err)
+	}
+
+	body := []byte(`{"title": "Disk almost full"}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sum := hex.EncodeToString(sign(hook.Secret, timestamp, body))
+	if _, err := s.Receive(ctx, hook.Token, "sha256="+sum, timestamp, body); err != nil {
+		t.Fatal(err)
+	}
+	for _, signature := range []string{"sha256=" + sum, "sha256=" + strings.ToUpper(sum)} {
+		if _, err := s.Receive(ctx, hook.Token, signature, timestamp, body); !errors.Is(err, ErrReplayed) {
+			t.Errorf("%s: expected ErrReplayed, got %v", signature, err)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package hooks
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler, publicURL string) {
+	service := newService(collections, cache, publicURL)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	// Zapier's REST hook pattern: subscribe, unsubscribe, a polling fallback per trigger, and actions
+	Hooks := apiV1.Group("/hooks", authenticate)
+	Hooks.Post("/", handler.Subscribe)
+	Hooks.Get("/", handler.ListHooks)
+	Hooks.Delete("/:id", handler.Unsubscribe)
+	Hooks.Get("/triggers/:event", handler.Poll)
+	Hooks.Post("/actions/tasks", handler.CreateTask)
+
+	// URLs for other systems to create tasks with, they carry their own token
+	Inbound := Hooks.Group("/inbound")
+	Inbound.Post("/", handler.CreateInbound)
+	Inbound.Get("/", handler.ListInbound)
+	Inbound.Put("/:id/mapping", handler.UpdateMapping)
+	Inbound.Delete("/:id", handler.DeleteInbound)
+	app.Post("/hooks/in/:token", handler.Receive)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/hooks/":                   {Summary: "Subscribe a URL to the caller's events", Auth: true, Request: SubscribeRequest{}, Response: Hook{}, Status: fiber.StatusCreated},
+		"GET /api/v1/hooks/":                    {Summary: "The caller's event subscriptions", Auth: true, Response: []Hook{}},
+		"DELETE /api/v1/hooks/:id":              {Summary: "Unsubscribe", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/hooks/triggers/:event":     {Summary: "Recent items for a polling trigger", Auth: true, Response: []any{}},
+		"POST /api/v1/hooks/actions/tasks":      {Summary: "Create a task from an automation", Auth: true, Request: CreateTaskRequest{}, Response: TaskItem{}, Status: fiber.StatusCreated},
+		"POST /api/v1/hooks/inbound/":           {Summary: "Create an inbound webhook URL", Auth: true, Request: InboundHookRequest{}, Response: CreatedInboundHook{}, Status: fiber.StatusCreated},
+		"GET /api/v1/hooks/inbound/":            {Summary: "The caller's inbound webhooks", Auth: true, Response: []InboundHook{}},
+		"PUT /api/v1/hooks/inbound/:id/mapping": {Summary: "Change how an inbound webhook's payload maps to a task", Auth: true, Request: Mapping{}, Response: InboundHook{}},
+		"DELETE /api/v1/hooks/inbound/:id":      {Summary: "Delete an inbound webhook", Auth: true, Status: fiber.StatusNoContent},
+		"POST /hooks/in/:token":                 {Summary: "Deliver a payload to an inbound webhook, signed in X-Signature-256 over X-Signature-Timestamp and the body", Request: map[string]any{}, Response: TaskItem{}, Status: fiber.StatusCreated},
+	})
+}
@@ -0,0 +1,233 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	Collection        = "hooks"
+	InboundCollection = "inbound_hooks"
+	// expires its documents once their timestamps are past signatureTolerance
+	DeliveriesCollection = "inbound_hook_deliveries"
+
+	maxHooks = 20
+	// how many recent items a polling trigger returns
+	pollLimit = 50
+	// tasks created through the action get the middle of the 1-10 value scale by default
+	defaultValue = 5
+)
+
+var (
+	ErrTooManyHooks = errors.New("too many hooks")
+	ErrNoCategory   = errors.New("no category to add the task to")
+	ErrUnknownEvent = errors.New("unknown event")
+)
+
+// newService receives the map of collections and picks out Hooks, Inbound, Users and Deliveries
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache, publicURL string) *Service {
+	return &Service{
+		Hooks:      collections[Collection],
+		Inbound:    collections[InboundCollection],
+		Users:      collections["users"],
+		Deliveries: collections[DeliveriesCollection],
+		tasks:      task.NewService(collections, cache),
+		queue:      jobs.New(collections[jobs.Collection]),
+		publicURL:  strings.TrimSuffix(publicURL, "/"),
+	}
+}
+
+func (s *Service) Subscribe(ctx context.Context, userID primitive.ObjectID, req SubscribeRequest) (*Hook, error) {
+	count, err := s.Hooks.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxHooks {
+		return nil, ErrTooManyHooks
+	}
+	hook := &Hook{
+		ID:        primitive.NewObjectID(),
+		User:      userID,
+		Event:     events.Type(req.Event),
+		TargetURL: req.TargetURL,
+		CreatedAt: time.Now(),
+	}
+	if _, err := s.Hooks.InsertOne(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (s *Service) Unsubscribe(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	result, err := s.Hooks.DeleteOne(ctx, bson.M{"_id": id, "user": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (s *Service) List(ctx context.Context, userID primitive.ObjectID) ([]Hook, error) {
+	cursor, err := s.Hooks.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	hooks := make([]Hook, 0)
+	if err := cursor.All(ctx, &hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// Poll returns an event's most recent items, newest first, for polling triggers and sample data
+func (s *Service) Poll(ctx context.Context, userID primitive.ObjectID, event events.Type) (any, error) {
+	switch event {
+	case events.TaskCreated:
+		return s.taskItems(ctx, userID, bson.M{}, "timestamp")
+	case events.TaskCompleted:
+		return s.taskItems(ctx, userID, bson.M{"completed": true}, "completed_at")
+	case events.FriendAdded:
+		return s.friendItems(ctx, userID, nil)
+	}
+	return nil, ErrUnknownEvent
+}
+
+// CreateTask is the action endpoint; unlike the task API it needs no category id, and the task isn't a draft
+func (s *Service) CreateTask(ctx context.Context, userID primitive.ObjectID, req CreateTaskRequest) (*TaskItem, error) {
+	var owner struct {
+		Categories []category.CategoryDocument `bson:"categories"`
+	}
+	err := s.Users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(bson.M{"categories._id": 1, "categories.name": 1, "categories." + softdelete.Field: 1}),
+	).Decode(&owner)
+	if err != nil {
+		return nil, err
+	}
+	live := softdelete.Visible(owner.Categories)
+	if len(live) == 0 {
+		return nil, ErrNoCategory
+	}
+	target := live[0]
+	if req.Category != "" {
+		id, _ := primitive.ObjectIDFromHex(req.Category)
+		i := slices.IndexFunc(live, func(c category.CategoryDocument) bool { return c.ID == id })
+		if i < 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		target = live[i]
+	}
+
+	now := time.Now()
+	doc := task.TaskDocument{
+		ID:        primitive.NewObjectID(),
+		Priority:  task.DefaultPriority,
+		Content:   req.Content,
+		Notes:     req.Notes,
+		Value:     req.Value,
+		Active:    true,
+		DueDate:   req.DueDate,
+		Source:    req.Source,
+		Timestamp: now,
+		UpdatedAt: now,
+	}
+	if req.Priority != nil {
+		doc.Priority = *req.Priority
+	}
+	if doc.Value == 0 {
+		doc.Value = defaultValue
+	}
+	if _, err := s.tasks.CreateTask(ctx, userID, target.ID, &doc); err != nil {
+		return nil, err
+	}
+	return &TaskItem{
+		ID:         doc.ID,
+		Content:    doc.Content,
+		Notes:      doc.Notes,
+		Priority:   doc.Priority,
+		Value:      doc.Value,
+		DueDate:    doc.DueDate,
+		CategoryID: target.ID,
+		Category:   target.Name,
+		CreatedAt:  doc.Timestamp,
+	}, nil
+}
+
+// taskItems returns the user's live tasks matching match, newest by sortBy first
+func (s *Service) taskItems(ctx context.Context, userID primitive.ObjectID, match bson.M, sortBy string) ([]TaskItem, error) {
+	cursor, err := s.Users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": userID}}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$match", Value: softdelete.LiveAt("categories")}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": bson.M{"$mergeObjects": bson.A{
+			"$categories.tasks",
+			bson.M{"category_id": "$categories._id", "category": "$categories.name"},
+		}}}}},
+		{{Key: "$match", Value: softdelete.Filter(match)}},
+		{{Key: "$sort", Value: bson.M{sortBy: -1}}},
+		{{Key: "$limit", Value: pollLimit}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]TaskItem, 0)
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+/*
+friendItems returns the user's friends, only those in ids when set. Friends
+carry no date, so the latest additions (at the end of the list) come first.
+*/
+func (s *Service) friendItems(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) ([]FriendItem, error) {
+	var user struct {
+		Friends []primitive.ObjectID `bson:"friends"`
+	}
+	err := s.Users.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"friends": 1})).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	friends := user.Friends
+	if ids != nil {
+		friends = slices.DeleteFunc(slices.Clone(friends), func(id primitive.ObjectID) bool { return !slices.Contains(ids, id) })
+	}
+	slices.Reverse(friends)
+	if len(friends) > pollLimit {
+		friends = friends[:pollLimit]
+	}
+
+	cursor, err := s.Users.Find(ctx, softdelete.Filter(bson.M{"_id": bson.M{"$in": friends}}),
+		options.Find().SetProjection(bson.M{"handle": 1, "display_name": 1, "profile_picture": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var found []FriendItem
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	items := make([]FriendItem, 0, len(found))
+	for _, id := range friends {
+		if i := slices.IndexFunc(found, func(f FriendItem) bool { return f.ID == id }); i >= 0 {
+			items = append(items, found[i])
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,126 @@
+package hooks
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// the events a hook can subscribe to, and the triggers that can be polled
+var Events = []events.Type{events.TaskCreated, events.TaskCompleted, events.FriendAdded}
+
+// Hook is a REST hook subscription: matching events are POSTed to TargetURL
+type Hook struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	User      primitive.ObjectID `bson:"user" json:"-"`
+	Event     events.Type        `bson:"event" json:"event"`
+	TargetURL string             `bson:"target_url" json:"target_url"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type SubscribeRequest struct {
+	Event     string `validate:"required,oneof=task.created task.completed friend.added" json:"event"`
+	TargetURL string `validate:"required,url,startswith=https://,max=2000" json:"target_url"`
+}
+
+type CreateTaskRequest struct {
+	Content string `validate:"required,max=500" json:"content"`
+	// one of the user's categories, the first one when empty
+	Category string `validate:"omitempty,mongodb" json:"category"`
+	// P0 to P3, task.DefaultPriority when left out
+	Priority *int       `validate:"omitempty,min=0,max=3" json:"priority"`
+	Value    float64    `validate:"omitempty,min=0,max=10" json:"value"`
+	DueDate  *time.Time `json:"due_date"`
+	Notes    string     `validate:"max=5000" json:"notes"`
+	// set by inbound hooks, see Mapping.Dedupe
+	Source string `json:"-"`
+}
+
+/*
+InboundHook is a URL external systems (monitoring, CI, home automation) POST
+JSON to, creating a task for the hook's owner as Mapping describes. The token
+in the URL identifies the hook; every delivery must also be signed with
+Secret, see Service.Receive.
+*/
+type InboundHook struct {
+	ID     primitive.ObjectID `bson:"_id" json:"id"`
+	User   primitive.ObjectID `bson:"user" json:"-"`
+	Name   string             `bson:"name" json:"name"`
+	Token  string             `bson:"token" json:"-"`
+	Secret string             `bson:"secret" json:"-"`
+	// nil for the user's first category
+	Category       *primitive.ObjectID `bson:"category,omitempty" json:"category,omitempty"`
+	Mapping        Mapping             `bson:"mapping" json:"mapping"`
+	CreatedAt      time.Time           `bson:"created_at" json:"created_at"`
+	LastReceivedAt *time.Time          `bson:"last_received_at,omitempty" json:"last_received_at,omitempty"`
+	URL            string              `bson:"-" json:"url"`
+}
+
+/*
+Mapping turns a payload into a task. Templates put payload fields in with
+{{path}}, e.g. "{{alert.labels.severity}}: {{alert.name}}" or "{{items.0.id}}".
+*/
+type Mapping struct {
+	Title string `bson:"title" json:"title" validate:"max=500"`
+	Notes string `bson:"notes" json:"notes" validate:"max=2000"`
+	// deliveries rendering the same key while its task is open add no second task, e.g. "{{alert.id}}"
+	Dedupe   string `bson:"dedupe,omitempty" json:"dedupe,omitempty" validate:"max=500"`
+	Priority *int   `bson:"priority,omitempty" json:"priority,omitempty" validate:"omitempty,min=0,max=3"`
+}
+
+type InboundHookRequest struct {
+	Name     string   `validate:"required,max=100" json:"name"`
+	Category string   `validate:"omitempty,mongodb" json:"category"`
+	Mapping  *Mapping `json:"mapping"`
+}
+
+// CreatedInboundHook carries the signing secret, shown only when the hook is made
+type CreatedInboundHook struct {
+	InboundHook
+	Secret string `json:"secret"`
+}
+
+/*
+TaskItem and FriendItem are what triggers send, the same shape whether
+pushed to a hook or polled, as Zapier expects. ID is what Zapier dedupes on.
+*/
+type TaskItem struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Content     string             `bson:"content" json:"content"`
+	Notes       string             `bson:"notes,omitempty" json:"notes,omitempty"`
+	Priority    int                `bson:"priority" json:"priority"`
+	Value       float64            `bson:"value" json:"value"`
+	DueDate     *time.Time         `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	Completed   bool               `bson:"completed" json:"completed"`
+	CompletedAt *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	CategoryID  primitive.ObjectID `bson:"category_id" json:"category_id"`
+	Category    string             `bson:"category" json:"category"`
+	CreatedAt   time.Time          `bson:"timestamp" json:"created_at"`
+}
+
+type FriendItem struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	Handle         string             `bson:"handle" json:"handle"`
+	DisplayName    string             `bson:"display_name" json:"display_name"`
+	ProfilePicture string             `bson:"profile_picture" json:"profile_picture"`
+}
+
+/*
+Hooks Service to be used by Hooks Handler to manage REST hook subscriptions,
+serve polling triggers, deliver events and take inbound hooks
+*/
+type Service struct {
+	Hooks   *mongo.Collection
+	Inbound *mongo.Collection
+	Users   *mongo.Collection
+	// signatures of recent deliveries, so none is accepted twice
+	Deliveries *mongo.Collection
+	tasks      *task.Service
+	queue      *jobs.Queue
+	// where inbound hook URLs point
+	publicURL string
+}
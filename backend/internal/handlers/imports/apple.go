@@ -0,0 +1,36 @@
+package imports
+
+import "github.com/abhikaboy/SocialToDo/internal/handlers/task"
+
+// appleLists adapts the iOS client's EventKit export
+func appleLists(payload AppleReminders) []List {
+	lists := make([]List, 0, len(payload.Lists))
+	for _, l := range payload.Lists {
+		list := List{ID: l.ID, Title: l.Title, Items: make([]Item, 0, len(l.Reminders))}
+		for _, r := range l.Reminders {
+			list.Items = append(list.Items, Item{
+				ID:          r.ID,
+				Title:       r.Title,
+				DueDate:     r.DueDate,
+				Completed:   r.Completed,
+				CompletedAt: r.CompletionDate,
+				Priority:    reminderPriority(r.Priority),
+				Recurring:   r.Recurring,
+			})
+		}
+		lists = append(lists, list)
+	}
+	return lists
+}
+
+// reminderPriority maps EventKit's 1-4 high onto P1, 5 medium onto P2 and the rest onto P3
+func reminderPriority(p int) int {
+	switch {
+	case p >= 1 && p <= 4:
+		return task.P1
+	case p == 5:
+		return task.P2
+	default:
+		return task.P3
+	}
+}
@@ -0,0 +1,211 @@
+package imports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+)
+
+var (
+	ErrUnknownFormat  = errors.New("unknown import format, expected todoist or csv")
+	ErrInvalidCSVFile = errors.New("not a CSV of tasks: no header row with a title column")
+)
+
+// the category a CSV without a list column goes into, when the file has no name either
+const defaultList = "Imported"
+
+/*
+Headers a CSV's columns are recognized by, compared ignoring case. The first
+row naming a title column is the header; Todoist's CSV templates (TYPE,
+CONTENT, PRIORITY, DATE) read as one too.
+*/
+var csvColumns = map[string][]string{
+	"id":           {"id", "task id"},
+	"type":         {"type"},
+	"list":         {"list", "list name", "category", "project"},
+	"title":        {"title", "content", "task", "name"},
+	"due":          {"due", "due date", "due_date", "date"},
+	"priority":     {"priority"},
+	"labels":       {"labels", "tags"},
+	"completed":    {"completed", "done", "status"},
+	"completed_at": {"completed at", "completed_at", "completed time"},
+}
+
+var csvLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04", time.DateOnly}
+
+/*
+parseFile reads an uploaded export as format, guessing it when that's empty
+from the content type, the file name and then the content itself: a JSON
+object is a Todoist export, anything else a CSV. A CSV is read as a TickTick
+backup when it is one. It returns the provider the lists came from.
+*/
+func parseFile(data []byte, filename string, contentType string, format string) (string, []List, error) {
+	if format == "" {
+		switch {
+		case strings.Contains(contentType, "json"), strings.EqualFold(path.Ext(filename), ".json"):
+			format = ProviderTodoist
+		case strings.Contains(contentType, "csv"), strings.EqualFold(path.Ext(filename), ".csv"):
+			format = ProviderCSV
+		case bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")):
+			format = ProviderTodoist
+		default:
+			format = ProviderCSV
+		}
+	}
+
+	switch format {
+	case ProviderTodoist:
+		lists, err := parseTodoistExport(bytes.NewReader(data))
+		return ProviderTodoist, lists, err
+	case ProviderCSV:
+		if lists, err := parseTickTick(bytes.NewReader(data)); err == nil {
+			return ProviderTickTick, lists, nil
+		}
+		name := strings.TrimSuffix(path.Base(filename), path.Ext(filename))
+		if filename == "" || name == "" {
+			name = defaultList
+		}
+		lists, err := parseCSV(bytes.NewReader(data), name)
+		return ProviderCSV, lists, err
+	}
+	return "", nil, ErrUnknownFormat
+}
+
+/*
+parseCSV reads a spreadsheet of tasks, one per row. Rows without a list go
+into one named name, after the file. Rows without an id are identified by
+their list, title and due date, so importing the same file again skips them.
+*/
+func parseCSV(r io.Reader, name string) ([]List, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var columns map[string]int
+	// Todoist's templates number priorities as the app shows them, 1 (urgent) to 4
+	var todoist bool
+	lists := make([]List, 0)
+	index := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if columns == nil {
+			columns = csvHeader(record)
+			_, todoist = columns["type"]
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		title := field("title")
+		if title == "" || field("type") != "" && !strings.EqualFold(field("type"), "task") {
+			continue
+		}
+
+		listName := field("list")
+		if listName == "" {
+			listName = name
+		}
+		i, ok := index[listName]
+		if !ok {
+			i = len(lists)
+			index[listName] = i
+			lists = append(lists, List{ID: listName, Title: listName, Items: []Item{}})
+		}
+
+		item := Item{
+			ID:        field("id"),
+			Title:     title,
+			DueDate:   csvTime(field("due")),
+			Completed: csvCompleted(field("completed")) || field("completed_at") != "",
+			Priority:  csvPriority(field("priority"), todoist),
+			Labels:    tickTickTags(field("labels")),
+		}
+		if item.Completed {
+			item.CompletedAt = csvTime(field("completed_at"))
+		}
+		if item.ID == "" {
+			item.ID = hashID(listName, title, field("due"))
+		}
+		lists[i].Items = append(lists[i].Items, item)
+	}
+	if columns == nil {
+		return nil, ErrInvalidCSVFile
+	}
+	return lists, nil
+}
+
+// csvHeader returns column positions once record is the header row, nil for anything before it
+func csvHeader(record []string) map[string]int {
+	columns := make(map[string]int, len(record))
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		for column, aliases := range csvColumns {
+			if _, ok := columns[column]; !ok && slices.Contains(aliases, name) {
+				columns[column] = i
+			}
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		return nil
+	}
+	return columns
+}
+
+func csvTime(value string) *time.Time {
+	for _, layout := range csvLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+func csvCompleted(value string) bool {
+	switch strings.ToLower(value) {
+	case "1", "x", "y", "yes", "true", "done", "completed":
+		return true
+	}
+	return false
+}
+
+// csvPriority takes P0 to P3 as written in the app, or urgent, high, medium and low
+func csvPriority(value string, todoist bool) int {
+	value = strings.ToLower(value)
+	switch value {
+	case "urgent":
+		return task.P0
+	case "high":
+		return task.P1
+	case "medium":
+		return task.P2
+	case "low":
+		return task.P3
+	}
+	p, err := strconv.Atoi(strings.TrimPrefix(value, "p"))
+	if todoist {
+		p--
+	}
+	if err == nil && task.ValidPriority(p) {
+		return p
+	}
+	return task.DefaultPriority
+}
@@ -0,0 +1,91 @@
+package imports
+
+import (
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+)
+
+func TestParseFile(t *testing.T) {
+	todoist := `{"projects": [{"id": "1", "name": "Errands"}], "items": [
+		{"id": "10", "project_id": "1", "content": "Buy milk", "priority": 4, "due": {"date": "2026-03-02"}},
+		{"id": "11", "project_id": "1", "content": "Post a letter", "checked": true, "completed_at": "2026-03-01T10:00:00Z"},
+		{"id": "12", "project_id": "2", "content": "In an archived project"}
+	]}`
+	spreadsheet := "Title,Category,Due Date,Priority,Done\n" +
+		"Buy milk,Errands,2026-03-02,high,\n" +
+		"Post a letter,,2026-03-01 10:00,P3,yes\n"
+	todoistCSV := "TYPE,CONTENT,PRIORITY,DATE\n" +
+		"section,Someday,,\n" +
+		"task,Buy milk,1,2026-03-02\n"
+
+	tests := []struct {
+		name             string
+		data             string
+		filename         string
+		contentType      string
+		format           string
+		expectedProvider string
+		expectedLists    int
+		expectedTitles   []string
+		expectedPriority int
+		expectedErr      bool
+	}{
+		{"a todoist export", todoist, "", "application/json", "", ProviderTodoist, 1, []string{"Buy milk", "Post a letter"}, task.P0, false},
+		{"a todoist export guessed from its content", todoist, "", "", "", ProviderTodoist, 1, []string{"Buy milk", "Post a letter"}, task.P0, false},
+		{"a spreadsheet", spreadsheet, "chores.csv", "text/csv", "", ProviderCSV, 2, []string{"Buy milk"}, task.P1, false},
+		{"a todoist template", todoistCSV, "", "", "csv", ProviderCSV, 1, []string{"Buy milk"}, task.P0, false},
+		{"a CSV without a title column", "a,b\n1,2\n", "", "text/csv", "", "", 0, nil, 0, true},
+		{"JSON without projects", `{"items": []}`, "", "application/json", "", "", 0, nil, 0, true},
+		{"an unknown format", todoist, "", "", "omnifocus", "", 0, nil, 0, true},
+	}
+	for _, tt := range tests {
+		provider, lists, err := parseFile([]byte(tt.data), tt.filename, tt.contentType, tt.format)
+		if tt.expectedErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.name, err)
+			continue
+		}
+		if provider != tt.expectedProvider {
+			t.Errorf("%s: expected provider %s, got %s", tt.name, tt.expectedProvider, provider)
+		}
+		if len(lists) != tt.expectedLists {
+			t.Errorf("%s: expected %d lists, got %d", tt.name, tt.expectedLists, len(lists))
+			continue
+		}
+		items := lists[0].Items
+		if len(items) != len(tt.expectedTitles) {
+			t.Errorf("%s: expected %d items, got %d", tt.name, len(tt.expectedTitles), len(items))
+			continue
+		}
+		for i, title := range tt.expectedTitles {
+			if items[i].Title != title {
+				t.Errorf("%s: expected %q, got %q", tt.name, title, items[i].Title)
+			}
+		}
+		if items[0].Priority != tt.expectedPriority {
+			t.Errorf("%s: expected priority %d, got %d", tt.name, tt.expectedPriority, items[0].Priority)
+		}
+		if items[0].DueDate == nil {
+			t.Errorf("%s: expected a due date", tt.name)
+		}
+	}
+}
+
+func TestParseCSVCompleted(t *testing.T) {
+	_, lists, err := parseFile([]byte("Title,Category,Done\nPost a letter,,yes\n"), "chores.csv", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 || lists[0].Title != "chores" {
+		t.Fatalf("expected one list named after the file, got %+v", lists)
+	}
+	if item := lists[0].Items[0]; !item.Completed || item.ID == "" {
+		t.Errorf("expected a completed item with an id, got %+v", item)
+	}
+}
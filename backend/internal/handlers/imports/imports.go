@@ -0,0 +1,181 @@
+package imports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=imports.go -destination=mock_test.go -package=imports
+
+// Importer runs imports from other apps for Handler
+type Importer interface {
+	ImportAppleReminders(ctx context.Context, userID primitive.ObjectID, payload AppleReminders) (*Result, error)
+	StartTodoist(ctx context.Context, userID primitive.ObjectID, token string) (*Import, error)
+	StartTickTick(ctx context.Context, userID primitive.ObjectID, lists []List) (*Import, error)
+	StartFile(ctx context.Context, userID primitive.ObjectID, provider string, lists []List) (*Import, error)
+	GetImport(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Import, error)
+}
+
+var _ Importer = (*Service)(nil)
+
+/*
+Handler to execute business logic for Imports Endpoint
+*/
+type Handler struct {
+	service Importer
+}
+
+var validator = xvalidator.Validator
+
+func (h *Handler) ImportAppleReminders(c *fiber.Ctx) error {
+	var payload AppleReminders
+	if err := c.BodyParser(&payload); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(payload); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	result, err := h.service.ImportAppleReminders(c.UserContext(), userID(c), payload)
+	if err != nil {
+		return importFailed(c, err)
+	}
+	return c.JSON(result)
+}
+
+// ImportTodoist starts a background import, poll GetImport for progress
+func (h *Handler) ImportTodoist(c *fiber.Ctx) error {
+	var params TodoistRequest
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	imp, err := h.service.StartTodoist(c.UserContext(), userID(c), params.Token)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusAccepted).JSON(imp)
+}
+
+// ImportTickTick takes the backup CSV as a multipart "file" field or as the raw body
+func (h *Handler) ImportTickTick(c *fiber.Ctx) error {
+	data, _, _, err := upload(c)
+	if err != nil {
+		return err
+	}
+
+	lists, err := parseTickTick(bytes.NewReader(data))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid TickTick backup",
+		})
+	}
+
+	imp, err := h.service.StartTickTick(c.UserContext(), userID(c), lists)
+	if err != nil {
+		return importFailed(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(imp)
+}
+
+/*
+ImportFile starts importing an export file, sent as a multipart "file" field
+or as the body: a Todoist export (JSON with projects and items), a TickTick
+backup or a CSV with a header row naming a title column. ?format=todoist or
+csv overrides guessing which from the content type, file name and content.
+*/
+func (h *Handler) ImportFile(c *fiber.Ctx) error {
+	data, filename, contentType, err := upload(c)
+	if err != nil {
+		return err
+	}
+
+	provider, lists, err := parseFile(data, filename, contentType, c.Query("format"))
+	if errors.Is(err, ErrUnknownFormat) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid export file",
+		})
+	}
+
+	imp, err := h.service.StartFile(c.UserContext(), userID(c), provider, lists)
+	if err != nil {
+		return importFailed(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(imp)
+}
+
+func (h *Handler) GetImport(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	imp, err := h.service.GetImport(c.UserContext(), userID(c), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Import not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(imp)
+}
+
+func importFailed(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrTooManyItems):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "The export has too many tasks",
+		})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	return err
+}
+
+// upload is the file in the multipart "file" field, or else the body, with its name and content type
+func upload(c *fiber.Ctx) ([]byte, string, string, error) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Body(), "", c.Get(fiber.HeaderContentType), nil
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return data, file.Filename, file.Header.Get(fiber.HeaderContentType), nil
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	return id
+}
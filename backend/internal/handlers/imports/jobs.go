@@ -0,0 +1,120 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	ImportJob = "import.run"
+
+	importAttempts = 3
+)
+
+type ImportPayload struct {
+	ImportID primitive.ObjectID `bson:"import_id"`
+}
+
+func RegisterJobs(worker *jobs.Worker, collections map[string]*mongo.Collection, cache xcache.Cache) {
+	s := newService(collections, cache)
+	worker.Handle(ImportJob, s.runImport)
+}
+
+/*
+runImport fetches or reads the export and feeds it to apply, recording
+progress on the import as each list lands. Only the last attempt marks the
+import failed; earlier ones leave it running for the retry.
+*/
+func (s *Service) runImport(ctx context.Context, job *jobs.Job) error {
+	var payload ImportPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var imp Import
+	if err := s.Imports.FindOne(ctx, bson.M{"_id": payload.ImportID}).Decode(&imp); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	if imp.Status == Done || imp.Status == Failed {
+		return nil
+	}
+	if err := s.setImport(ctx, imp.ID, bson.M{"status": Running}); err != nil {
+		return err
+	}
+
+	result, err := s.importLists(ctx, &imp)
+	if err != nil {
+		permanent := errors.Is(err, ErrTodoistToken) || errors.Is(err, ErrTooManyItems)
+		if !permanent && job.Attempts < job.MaxAttempts {
+			return err
+		}
+		if finishErr := s.finish(ctx, imp.ID, Failed, nil, err); finishErr != nil {
+			return finishErr
+		}
+		return jobs.Permanent(err)
+	}
+	return s.finish(ctx, imp.ID, Done, result, nil)
+}
+
+func (s *Service) importLists(ctx context.Context, imp *Import) (*Result, error) {
+	lists := imp.Lists
+	if imp.Provider == ProviderTodoist {
+		var err error
+		if lists, err = s.todoist.lists(ctx, imp.Token); err != nil {
+			return nil, err
+		}
+		if count(lists) > maxItems {
+			return nil, ErrTooManyItems
+		}
+		if err := s.setImport(ctx, imp.ID, bson.M{"total": count(lists)}); err != nil {
+			return nil, err
+		}
+	}
+	return s.apply(ctx, imp.User, imp.Provider, lists, func(ctx context.Context, processed int, result *Result) error {
+		return s.setImport(ctx, imp.ID, bson.M{"processed": processed, "result": result})
+	})
+}
+
+func (s *Service) setImport(ctx context.Context, id primitive.ObjectID, set bson.M) error {
+	set["updated_at"] = time.Now()
+	_, err := s.Imports.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": set})
+	return err
+}
+
+// finish records the outcome and drops the token and export, they aren't needed any more
+func (s *Service) finish(ctx context.Context, id primitive.ObjectID, status Status, result *Result, cause error) error {
+	now := time.Now()
+	set := bson.M{"status": status, "updated_at": now, "finished_at": now}
+	if result != nil {
+		set["result"] = result
+	}
+	if cause != nil {
+		set["error"] = importError(cause)
+	}
+	_, err := s.Imports.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"token": "", "lists": ""},
+	})
+	return err
+}
+
+// importError is what the client is shown, internal errors stay in the job's last_error
+func importError(err error) string {
+	switch {
+	case errors.Is(err, ErrTodoistToken):
+		return "Todoist rejected the API token"
+	case errors.Is(err, ErrTooManyItems):
+		return "The export has too many tasks"
+	default:
+		return "The import failed"
+	}
+}
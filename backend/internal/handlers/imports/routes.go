@@ -0,0 +1,33 @@
+package imports
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler) {
+	service := newService(collections, cache)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Imports := apiV1.Group("/import", authenticate)
+	Imports.Post("/", handler.ImportFile)
+	Imports.Post("/apple-reminders", handler.ImportAppleReminders)
+	Imports.Post("/todoist", handler.ImportTodoist)
+	Imports.Post("/ticktick", handler.ImportTickTick)
+	Imports.Get("/:id", handler.GetImport)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/import/":                {Summary: "Start importing a Todoist export or a CSV file, as the body or a multipart file field", Auth: true, Query: xopenapi.Query{"format"}, Request: "", RequestType: "text/csv", Response: Import{}, Status: fiber.StatusAccepted},
+		"POST /api/v1/import/apple-reminders": {Summary: "Import lists exported by the Shortcuts app", Auth: true, Request: AppleReminders{}, Response: Result{}},
+		"POST /api/v1/import/todoist":         {Summary: "Start importing from Todoist", Auth: true, Request: TodoistRequest{}, Response: Import{}, Status: fiber.StatusAccepted},
+		"POST /api/v1/import/ticktick":        {Summary: "Start importing a TickTick backup, as the body or a multipart file field", Auth: true, Request: "", RequestType: "text/csv", Response: Import{}, Status: fiber.StatusAccepted},
+		"GET /api/v1/import/:id":              {Summary: "Check on an import", Auth: true, Response: Import{}},
+	})
+}
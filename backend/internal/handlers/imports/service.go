@@ -0,0 +1,274 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	Collection = "imports"
+
+	// providers, also the prefix of every Source they write
+	ProviderAppleReminders = "apple_reminders"
+	ProviderTodoist        = "todoist"
+	ProviderTickTick       = "ticktick"
+	ProviderCSV            = "csv"
+
+	// an export larger than this is refused rather than half imported
+	maxItems = 20000
+)
+
+// imported tasks get the middle of the 1-10 value scale, the other apps have nothing to map from
+const defaultValue = 5
+
+var ErrTooManyItems = errors.New("export has too many tasks")
+
+// newService receives the map of collections and picks out Users and Imports
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache) *Service {
+	return &Service{
+		Users:   collections["users"],
+		Imports: collections[Collection],
+		queue:   jobs.New(collections[jobs.Collection]),
+		todoist: newTodoistClient(),
+		cache:   cache,
+	}
+}
+
+// ImportAppleReminders runs in the request, the payload is already the whole export
+func (s *Service) ImportAppleReminders(ctx context.Context, userID primitive.ObjectID, payload AppleReminders) (*Result, error) {
+	lists := appleLists(payload)
+	if count(lists) > maxItems {
+		return nil, ErrTooManyItems
+	}
+	return s.apply(ctx, userID, ProviderAppleReminders, lists, nil)
+}
+
+// StartTodoist queues an import that reads the account through Todoist's API
+func (s *Service) StartTodoist(ctx context.Context, userID primitive.ObjectID, token string) (*Import, error) {
+	return s.start(ctx, &Import{User: userID, Provider: ProviderTodoist, Token: token})
+}
+
+// StartTickTick queues an import of an already parsed TickTick backup
+func (s *Service) StartTickTick(ctx context.Context, userID primitive.ObjectID, lists []List) (*Import, error) {
+	return s.StartFile(ctx, userID, ProviderTickTick, lists)
+}
+
+// StartFile queues an import of an already parsed export file from provider
+func (s *Service) StartFile(ctx context.Context, userID primitive.ObjectID, provider string, lists []List) (*Import, error) {
+	total := count(lists)
+	if total > maxItems {
+		return nil, ErrTooManyItems
+	}
+	return s.start(ctx, &Import{User: userID, Provider: provider, Lists: lists, Total: total})
+}
+
+func (s *Service) start(ctx context.Context, imp *Import) (*Import, error) {
+	now := time.Now()
+	imp.ID = primitive.NewObjectID()
+	imp.Status = Queued
+	imp.CreatedAt = now
+	imp.UpdatedAt = now
+	if _, err := s.Imports.InsertOne(ctx, imp); err != nil {
+		return nil, err
+	}
+	// a retried run skips what the failed one already imported, see apply
+	if _, err := s.queue.Enqueue(ctx, ImportJob, ImportPayload{ImportID: imp.ID}, jobs.MaxAttempts(importAttempts)); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// GetImport returns one of the user's imports, mongo.ErrNoDocuments for anyone else's
+func (s *Service) GetImport(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Import, error) {
+	var imp Import
+	err := s.Imports.FindOne(ctx, bson.M{"_id": id, "user": userID},
+		options.FindOne().SetProjection(bson.M{"token": 0, "lists": 0}),
+	).Decode(&imp)
+	if err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+/*
+apply maps lists onto categories and items onto tasks, writing one list at a
+time and reporting each to progress. A list joins the category it was
+imported into before, or a live one with the same name, otherwise it
+becomes a new category.
+
+The dedupe pass skips items imported before (by provider id, including ones
+since deleted here, so deleting an imported task keeps it deleted) and items
+matching a task already in the target category by title and due date.
+Completed items come over completed but don't count toward the user's
+completed total, that is for work done in the app.
+*/
+func (s *Service) apply(ctx context.Context, userID primitive.ObjectID, provider string, lists []List, progress func(ctx context.Context, processed int, result *Result) error) (*Result, error) {
+	var owner struct {
+		Categories []category.CategoryDocument `bson:"categories"`
+	}
+	err := s.Users.FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{"categories": 1})).Decode(&owner)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	imported := make(map[string]bool)
+	for _, c := range owner.Categories {
+		for _, t := range c.Tasks {
+			if t.Source != "" {
+				imported[t.Source] = true
+			}
+		}
+	}
+
+	processed := 0
+	seen := make(map[primitive.ObjectID]map[string]bool)
+	for _, list := range lists {
+		now := time.Now()
+		listSource := provider + ":" + list.ID
+		target := matchCategory(owner.Categories, listSource, list.Title)
+		fresh := target == nil
+		if fresh {
+			target = &category.CategoryDocument{
+				ID:         primitive.NewObjectID(),
+				Name:       strings.TrimSpace(list.Title),
+				LastEdited: now,
+				Tasks:      []task.TaskDocument{},
+				User:       userID,
+				Source:     listSource,
+			}
+		}
+		if seen[target.ID] == nil {
+			seen[target.ID] = make(map[string]bool)
+			for _, t := range softdelete.Visible(target.Tasks) {
+				seen[target.ID][dedupeKey(t.Content, t.DueDate)] = true
+			}
+		}
+
+		tasks := make([]task.TaskDocument, 0, len(list.Items))
+		for _, item := range list.Items {
+			source := provider + ":" + item.ID
+			key := dedupeKey(item.Title, item.DueDate)
+			if imported[source] || seen[target.ID][key] {
+				result.Skipped++
+				continue
+			}
+			imported[source] = true
+			seen[target.ID][key] = true
+			tasks = append(tasks, itemTask(item, source, now))
+		}
+
+		switch {
+		case fresh:
+			target.Tasks = tasks
+			_, err = s.Users.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$push": bson.M{"categories": target}})
+			// later lists with the same name join it
+			owner.Categories = append(owner.Categories, *target)
+			result.CategoriesCreated++
+		case len(tasks) > 0:
+			_, err = s.Users.UpdateOne(ctx,
+				bson.M{
+					"_id":        userID,
+					"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": target.ID})},
+				},
+				bson.M{
+					"$push": bson.M{"categories.$.tasks": bson.M{"$each": tasks}},
+					"$set":  bson.M{"categories.$.lastEdited": now},
+				},
+			)
+			result.CategoriesMatched++
+		default:
+			result.CategoriesMatched++
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.TasksImported += len(tasks)
+
+		if len(tasks) > 0 || fresh {
+			xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(userID.Hex()))
+		}
+		processed += len(list.Items)
+		if progress != nil {
+			if err := progress(ctx, processed, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// matchCategory finds the live category a list was imported into before, falling back to one with the same name
+func matchCategory(categories []category.CategoryDocument, source string, title string) *category.CategoryDocument {
+	var byName *category.CategoryDocument
+	for i := range categories {
+		c := &categories[i]
+		if c.IsDeleted() {
+			continue
+		}
+		if c.Source == source {
+			return c
+		}
+		if byName == nil && strings.EqualFold(strings.TrimSpace(c.Name), strings.TrimSpace(title)) {
+			byName = c
+		}
+	}
+	return byName
+}
+
+func itemTask(item Item, source string, now time.Time) task.TaskDocument {
+	doc := task.TaskDocument{
+		ID:        primitive.NewObjectID(),
+		Priority:  item.Priority,
+		Content:   strings.TrimSpace(item.Title),
+		Value:     defaultValue,
+		Recurring: item.Recurring,
+		Active:    true,
+		DueDate:   item.DueDate,
+		Labels:    item.Labels,
+		Timestamp: now,
+		UpdatedAt: now,
+		Source:    source,
+	}
+	if !task.ValidPriority(doc.Priority) {
+		doc.Priority = task.P3
+	}
+	if item.Completed {
+		completedAt := now
+		if item.CompletedAt != nil {
+			completedAt = *item.CompletedAt
+		}
+		doc.Completed = true
+		doc.CompletedAt = &completedAt
+	}
+	return doc
+}
+
+// dedupeKey compares titles ignoring case and spacing, and due dates to the minute
+func dedupeKey(title string, due *time.Time) string {
+	key := strings.ToLower(strings.Join(strings.Fields(title), " "))
+	if due != nil {
+		key += "|" + due.UTC().Truncate(time.Minute).Format(time.RFC3339)
+	}
+	return key
+}
+
+func count(lists []List) int {
+	n := 0
+	for _, list := range lists {
+		n += len(list.Items)
+	}
+	return n
+}
@@ -0,0 +1,137 @@
+package imports
+
+import (
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+)
+
+var ErrInvalidCSV = errors.New("not a TickTick backup: no header row with List Name and Title")
+
+// TickTick writes offsets without a colon
+var tickTickLayouts = []string{"2006-01-02T15:04:05-0700", time.RFC3339, "2006-01-02 15:04:05"}
+
+/*
+parseTickTick reads the CSV from TickTick's "Generate Backup". The file opens
+with a few lines of metadata before the header row, so columns are found by
+name rather than position. Notes (Kind NOTE) aren't tasks and are left out.
+*/
+func parseTickTick(r io.Reader) ([]List, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var columns map[string]int
+	lists := make([]List, 0)
+	index := make(map[string]int)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if columns == nil {
+			columns = tickTickHeader(record)
+			continue
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		title := field("Title")
+		if title == "" || strings.EqualFold(field("Kind"), "NOTE") {
+			continue
+		}
+
+		listName := field("List Name")
+		i, ok := index[listName]
+		if !ok {
+			i = len(lists)
+			index[listName] = i
+			// the backup has no list ids, names are what stays stable between backups
+			lists = append(lists, List{ID: listName, Title: listName, Items: []Item{}})
+		}
+
+		item := Item{
+			ID:        field("taskId"),
+			Title:     title,
+			DueDate:   tickTickTime(field("Due Date")),
+			Completed: field("Status") == "1" || field("Status") == "2",
+			Priority:  tickTickPriority(field("Priority")),
+			Labels:    tickTickTags(field("Tags")),
+			Recurring: field("Repeat") != "",
+		}
+		if item.Completed {
+			item.CompletedAt = tickTickTime(field("Completed Time"))
+		}
+		if item.ID == "" {
+			item.ID = hashID(listName, title, field("Created Time"))
+		}
+		lists[i].Items = append(lists[i].Items, item)
+	}
+	if columns == nil {
+		return nil, ErrInvalidCSV
+	}
+	return lists, nil
+}
+
+// tickTickHeader returns column positions once record is the header row, nil for the metadata lines before it
+func tickTickHeader(record []string) map[string]int {
+	columns := make(map[string]int, len(record))
+	for i, name := range record {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	_, list := columns["List Name"]
+	_, title := columns["Title"]
+	if !list || !title {
+		return nil
+	}
+	return columns
+}
+
+func tickTickTime(value string) *time.Time {
+	for _, layout := range tickTickLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// tickTickPriority maps TickTick's 0 none, 1 low, 3 medium, 5 high
+func tickTickPriority(value string) int {
+	switch value {
+	case "5":
+		return task.P1
+	case "3":
+		return task.P2
+	default:
+		return task.P3
+	}
+}
+
+func tickTickTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func hashID(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,177 @@
+package imports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	gojson "github.com/goccy/go-json"
+)
+
+const todoistAPI = "https://api.todoist.com/rest/v2"
+
+var (
+	// ErrTodoistToken means Todoist refused the token, retrying won't help
+	ErrTodoistToken   = errors.New("todoist rejected the API token")
+	ErrInvalidTodoist = errors.New("not a Todoist export: no projects")
+)
+
+type todoistClient struct {
+	http *http.Client
+}
+
+func newTodoistClient() *todoistClient {
+	return &todoistClient{http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+type todoistProject struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type todoistTask struct {
+	ID        string   `json:"id"`
+	ProjectID string   `json:"project_id"`
+	Content   string   `json:"content"`
+	Labels    []string `json:"labels"`
+	// 1 (normal) to 4 (urgent), the reverse of the P1-P4 shown in the app
+	Priority int `json:"priority"`
+	Due      *struct {
+		Date        string `json:"date"`
+		Datetime    string `json:"datetime"`
+		IsRecurring bool   `json:"is_recurring"`
+	} `json:"due"`
+	// only in exports, the REST API leaves completed tasks out
+	Checked     bool   `json:"checked"`
+	CompletedAt string `json:"completed_at"`
+}
+
+/*
+todoistExport is a Todoist account as its Sync API returns it, which is what
+export tools save: projects and their items. Tasks holds them instead in
+exports saved from the REST API.
+*/
+type todoistExport struct {
+	Projects []todoistProject `json:"projects"`
+	Items    []todoistTask    `json:"items"`
+	Tasks    []todoistTask    `json:"tasks"`
+}
+
+/*
+lists reads projects and their active tasks. The REST API doesn't return
+completed tasks, so a Todoist import brings over open work only. Subtasks
+are flattened into their project.
+*/
+func (c *todoistClient) lists(ctx context.Context, token string) ([]List, error) {
+	var projects []todoistProject
+	if err := c.get(ctx, token, "/projects", &projects); err != nil {
+		return nil, err
+	}
+	var tasks []todoistTask
+	if err := c.get(ctx, token, "/tasks", &tasks); err != nil {
+		return nil, err
+	}
+	return todoistLists(projects, tasks), nil
+}
+
+// parseTodoistExport reads an exported account, completed tasks included
+func parseTodoistExport(r io.Reader) ([]List, error) {
+	var export todoistExport
+	if err := gojson.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	if len(export.Projects) == 0 {
+		return nil, ErrInvalidTodoist
+	}
+	return todoistLists(export.Projects, append(export.Items, export.Tasks...)), nil
+}
+
+// todoistLists puts each task in its project, leaving out those of projects not given
+func todoistLists(projects []todoistProject, tasks []todoistTask) []List {
+	lists := make([]List, 0, len(projects))
+	index := make(map[string]int, len(projects))
+	for _, p := range projects {
+		index[p.ID] = len(lists)
+		lists = append(lists, List{ID: p.ID, Title: p.Name, Items: []Item{}})
+	}
+	for _, t := range tasks {
+		i, ok := index[t.ProjectID]
+		if !ok {
+			continue
+		}
+		item := Item{
+			ID:       t.ID,
+			Title:    t.Content,
+			Priority: todoistPriority(t.Priority),
+			Labels:   t.Labels,
+		}
+		if t.Due != nil {
+			item.DueDate = todoistDue(t.Due.Datetime, t.Due.Date)
+			item.Recurring = t.Due.IsRecurring
+		}
+		if t.Checked || t.CompletedAt != "" {
+			item.Completed = true
+			item.CompletedAt = todoistDue(t.CompletedAt, "")
+		}
+		lists[i].Items = append(lists[i].Items, item)
+	}
+	return lists
+}
+
+func (c *todoistClient) get(ctx context.Context, token string, path string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, todoistAPI+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return ErrTodoistToken
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("todoist %s: %s", path, resp.Status)
+	}
+	return gojson.NewDecoder(resp.Body).Decode(v)
+}
+
+// todoistPriority maps Todoist's p1 (sent as 4) to P0 and so on down to p4 (sent as 1), which is P3
+func todoistPriority(p int) int {
+	switch p {
+	case 4:
+		return task.P0
+	case 3:
+		return task.P1
+	case 2:
+		return task.P2
+	default:
+		return task.P3
+	}
+}
+
+// todoistDue prefers the exact time; date-only dues land at midnight UTC. The Sync API puts either in date.
+func todoistDue(datetime string, date string) *time.Time {
+	if t, err := time.Parse(time.RFC3339, datetime); err == nil {
+		return &t
+	}
+	// floating times have no offset
+	if t, err := time.Parse("2006-01-02T15:04:05", datetime); err == nil {
+		return &t
+	}
+	if t, err := time.Parse(time.DateOnly, date); err == nil {
+		return &t
+	}
+	if datetime == "" && date != "" {
+		return todoistDue(date, "")
+	}
+	return nil
+}
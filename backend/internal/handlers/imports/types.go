@@ -0,0 +1,112 @@
+package imports
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AppleReminders is the export the iOS client builds from EventKit, one entry per reminders list
+type AppleReminders struct {
+	Lists []ReminderList `validate:"required,max=200,dive" json:"lists"`
+}
+
+type ReminderList struct {
+	// EKCalendar.calendarIdentifier
+	ID        string     `validate:"required,max=200" json:"id"`
+	Title     string     `validate:"required,max=200" json:"title"`
+	Reminders []Reminder `validate:"max=5000,dive" json:"reminders"`
+}
+
+type Reminder struct {
+	// EKReminder.calendarItemIdentifier
+	ID             string     `validate:"required,max=200" json:"id"`
+	Title          string     `validate:"required,max=1000" json:"title"`
+	DueDate        *time.Time `json:"due_date,omitempty"`
+	Completed      bool       `json:"completed"`
+	CompletionDate *time.Time `json:"completion_date,omitempty"`
+	// EventKit's scale: 0 none, 1-4 high, 5 medium, 6-9 low
+	Priority  int  `validate:"min=0,max=9" json:"priority"`
+	Recurring bool `json:"recurring"`
+}
+
+type TodoistRequest struct {
+	// a personal API token from Todoist's integration settings, used once and then dropped
+	Token string `validate:"required,max=200" json:"token"`
+}
+
+/*
+List and Item are what every provider adapter produces, the pipeline only
+knows these. IDs are the provider's own and only need to be stable across
+exports of the same account.
+*/
+type List struct {
+	ID    string `bson:"id"`
+	Title string `bson:"title"`
+	Items []Item `bson:"items"`
+}
+
+type Item struct {
+	ID          string     `bson:"id"`
+	Title       string     `bson:"title"`
+	DueDate     *time.Time `bson:"due_date,omitempty"`
+	Completed   bool       `bson:"completed"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty"`
+	// already on the task scale, 1 (low) to 3 (high)
+	Priority  int      `bson:"priority"`
+	Labels    []string `bson:"labels,omitempty"`
+	Recurring bool     `bson:"recurring"`
+}
+
+// Result summarizes an import; Skipped counts items found to be duplicates
+type Result struct {
+	CategoriesCreated int `bson:"categories_created" json:"categories_created"`
+	CategoriesMatched int `bson:"categories_matched" json:"categories_matched"`
+	TasksImported     int `bson:"tasks_imported" json:"tasks_imported"`
+	Skipped           int `bson:"skipped" json:"skipped"`
+}
+
+type Status string
+
+const (
+	Queued  Status = "queued"
+	Running Status = "running"
+	Done    Status = "done"
+	Failed  Status = "failed"
+)
+
+/*
+Import tracks a background import for progress polling. Whatever the job
+needs to read (a token, or the parsed export) is kept on it until the job
+finishes.
+*/
+type Import struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	User       primitive.ObjectID `bson:"user" json:"-"`
+	Provider   string             `bson:"provider" json:"provider"`
+	Status     Status             `bson:"status" json:"status"`
+	Total      int                `bson:"total" json:"total"`
+	Processed  int                `bson:"processed" json:"processed"`
+	Result     *Result            `bson:"result,omitempty" json:"result,omitempty"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	Token      string             `bson:"token,omitempty" json:"-"`
+	Lists      []List             `bson:"lists,omitempty" json:"-"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+/*
+Imports Service to be used by Imports Handler to bring tasks over from
+other apps
+*/
+type Service struct {
+	Users   *mongo.Collection
+	Imports *mongo.Collection
+	queue   *jobs.Queue
+	todoist *todoistClient
+	cache   xcache.Cache
+}
@@ -0,0 +1,151 @@
+package inbound
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=inbound.go -destination=mock_test.go -package=inbound
+
+// Mailboxes is what Handler needs to run the inbound addresses
+type Mailboxes interface {
+	GetAddress(ctx context.Context, userID primitive.ObjectID) (*AddressView, error)
+	RotateAddress(ctx context.Context, userID primitive.ObjectID) (*AddressView, error)
+	SetCategory(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID) (*AddressView, error)
+	Receive(ctx context.Context, m Mail) (*task.TaskDocument, error)
+}
+
+var _ Mailboxes = (*Service)(nil)
+
+/*
+Handler to execute business logic for Inbound Endpoint
+*/
+type Handler struct {
+	service Mailboxes
+	// the path secret the mail provider posts to
+	secret string
+}
+
+var validator = xvalidator.Validator
+
+func (h *Handler) GetAddress(c *fiber.Ctx) error {
+	address, err := h.service.GetAddress(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(address)
+}
+
+func (h *Handler) RotateAddress(c *fiber.Ctx) error {
+	address, err := h.service.RotateAddress(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(address)
+}
+
+func (h *Handler) UpdateAddress(c *fiber.Ctx) error {
+	var settings AddressSettings
+	if err := c.BodyParser(&settings); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(settings); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	categoryID, _ := primitive.ObjectIDFromHex(settings.Category)
+	address, err := h.service.SetCategory(c.UserContext(), userID(c), categoryID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(address)
+}
+
+/*
+ReceiveMail is the webhook for the mail provider, in SendGrid Inbound Parse's
+format: a multipart form with the headers as fields and the attachments as
+files. Mail that can't become a task is still acknowledged, otherwise the
+provider keeps retrying it.
+*/
+func (h *Handler) ReceiveMail(c *fiber.Ctx) error {
+	secret := h.secret
+	if secret == "" || subtle.ConstantTimeCompare([]byte(c.Params("secret")), []byte(secret)) != 1 {
+		return c.SendStatus(fiber.StatusNotFound)
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.SendStatus(fiber.StatusBadRequest)
+	}
+	value := func(name string) string {
+		if v := form.Value[name]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	m := Mail{
+		Recipients: []string{value("to")},
+		From:       value("from"),
+		Subject:    value("subject"),
+		Text:       value("text"),
+	}
+	// the envelope has the actual recipients, which differ from To for BCCs and forwarding
+	var envelope struct {
+		To []string `json:"to"`
+	}
+	if err := gojson.Unmarshal([]byte(value("envelope")), &envelope); err == nil {
+		m.Recipients = append(m.Recipients, envelope.To...)
+	}
+	for _, files := range form.File {
+		for _, file := range files {
+			f, err := file.Open()
+			if err != nil {
+				continue
+			}
+			body, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				continue
+			}
+			m.Attachments = append(m.Attachments, Attachment{
+				Name:        file.Filename,
+				ContentType: file.Header.Get("Content-Type"),
+				Body:        body,
+			})
+		}
+	}
+
+	_, err = h.service.Receive(c.UserContext(), m)
+	switch {
+	case errors.Is(err, ErrUnknownAddress), errors.Is(err, ErrNoCategory), errors.Is(err, mongo.ErrNoDocuments):
+		slog.LogAttrs(c.UserContext(), slog.LevelInfo, "Inbound mail dropped", xslog.Error(err))
+	case err != nil:
+		// a 5xx has the provider retry later
+		return err
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	return id
+}
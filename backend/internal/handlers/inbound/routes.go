@@ -0,0 +1,35 @@
+package inbound
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, files xfiles.Backend, authenticate fiber.Handler, uploadsCfg config.Uploads, cfg config.Inbound) {
+	service := newService(collections, cache, files, uploadsCfg, cfg)
+	handler := Handler{service, cfg.Secret}
+
+	apiV1 := app.Group("/api/v1")
+
+	// the provider can't authenticate, the secret in the path stands in
+	app.Post("/hooks/inbound-email/:secret", handler.ReceiveMail)
+
+	Inbound := apiV1.Group("/inbound-email", authenticate)
+	Inbound.Get("/", handler.GetAddress)
+	Inbound.Post("/rotate", handler.RotateAddress)
+	Inbound.Patch("/", handler.UpdateAddress)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /hooks/inbound-email/:secret": {Summary: "Mail provider's parse webhook for the inbound addresses", Request: Mail{}, RequestType: fiber.MIMEMultipartForm},
+		"GET /api/v1/inbound-email/":        {Summary: "The caller's address for emailing in tasks", Auth: true, Response: AddressView{}},
+		"POST /api/v1/inbound-email/rotate": {Summary: "Replace the caller's address with a new one", Auth: true, Response: AddressView{}},
+		"PATCH /api/v1/inbound-email/":      {Summary: "Choose the category emailed tasks go to", Auth: true, Request: AddressSettings{}, Response: AddressView{}},
+	})
+}
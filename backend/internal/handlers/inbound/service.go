@@ -0,0 +1,291 @@
+package inbound
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/uploads"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	Collection = "inbound_addresses"
+
+	tokenLength = 10
+	// no 0/o or 1/l, the address gets read aloud and retyped
+	tokenAlphabet = "abcdefghijkmnpqrstuvwxyz23456789"
+
+	maxContent     = 200
+	maxNotes       = 5000
+	maxAttachments = 10
+	// tasks from email get the middle of the 1-10 value scale
+	defaultValue = 5
+)
+
+var (
+	// ErrUnknownAddress is mail for no address of ours; it is dropped
+	ErrUnknownAddress = errors.New("no inbound address among the recipients")
+	ErrNoCategory     = errors.New("no category to add the task to")
+)
+
+// newService receives the map of collections and picks out Addresses and Users
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache, files xfiles.Backend, uploadsCfg config.Uploads, cfg config.Inbound) *Service {
+	return &Service{
+		Addresses: collections[Collection],
+		Users:     collections["users"],
+		tasks:     task.NewService(collections, cache),
+		uploads:   uploads.NewService(collections, files, uploadsCfg),
+		cfg:       cfg,
+	}
+}
+
+// GetAddress returns the user's address, giving them one on first use
+func (s *Service) GetAddress(ctx context.Context, userID primitive.ObjectID) (*AddressView, error) {
+	var address Address
+	err := s.Addresses.FindOne(ctx, bson.M{"user": userID}).Decode(&address)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s.assign(ctx, userID, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.view(&address), nil
+}
+
+// RotateAddress replaces the user's address, mail to the old one is dropped from then on
+func (s *Service) RotateAddress(ctx context.Context, userID primitive.ObjectID) (*AddressView, error) {
+	return s.assign(ctx, userID, true)
+}
+
+// assign sets a fresh token, on insert only unless replace; a token collision is retried with another
+func (s *Service) assign(ctx context.Context, userID primitive.ObjectID, replace bool) (*AddressView, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := newToken()
+		if err != nil {
+			return nil, err
+		}
+		update := bson.M{"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": time.Now(), "token": token}}
+		if replace {
+			update = bson.M{
+				"$set":         bson.M{"token": token},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": time.Now()},
+			}
+		}
+		var address Address
+		err = s.Addresses.FindOneAndUpdate(ctx, bson.M{"user": userID}, update,
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&address)
+		if mongo.IsDuplicateKeyError(err) && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return s.view(&address), nil
+	}
+}
+
+// SetCategory picks where mailed tasks go; the category has to be one of the user's live ones
+func (s *Service) SetCategory(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID) (*AddressView, error) {
+	count, err := s.Users.CountDocuments(ctx, bson.M{
+		"_id":        userID,
+		"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": categoryID})},
+	}, options.Count().SetLimit(1))
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	if _, err := s.GetAddress(ctx, userID); err != nil {
+		return nil, err
+	}
+	var address Address
+	err = s.Addresses.FindOneAndUpdate(ctx, bson.M{"user": userID},
+		bson.M{"$set": bson.M{"category": categoryID}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&address)
+	if err != nil {
+		return nil, err
+	}
+	return s.view(&address), nil
+}
+
+/*
+Receive turns an email into a draft task: the subject becomes the content and
+the text body the notes, minus the signature. Attachments are stored like
+client uploads; one that isn't accepted is left off without failing the
+task.
+*/
+func (s *Service) Receive(ctx context.Context, m Mail) (*task.TaskDocument, error) {
+	address, err := s.lookup(ctx, m.Recipients)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.category(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	doc := task.TaskDocument{
+		ID:        primitive.NewObjectID(),
+		Priority:  1,
+		Content:   content(m.Subject, m.Text),
+		Notes:     truncate(notes(m.Text), maxNotes),
+		Value:     defaultValue,
+		Active:    true,
+		Draft:     true,
+		Timestamp: now,
+		UpdatedAt: now,
+	}
+	if _, err := s.tasks.CreateTask(ctx, address.User, target, &doc); err != nil {
+		return nil, err
+	}
+
+	for i, attachment := range m.Attachments {
+		if i == maxAttachments {
+			break
+		}
+		_, err := s.uploads.Attach(ctx, address.User, doc.ID, attachment.Name, attachment.ContentType, attachment.Body)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "Emailed attachment not stored",
+				slog.String("task_id", doc.ID.Hex()), slog.String("name", attachment.Name), xslog.Error(err))
+		}
+	}
+	return &doc, nil
+}
+
+// lookup finds the address the mail was sent to; other recipients (CCs, the user's own address) are ignored
+func (s *Service) lookup(ctx context.Context, recipients []string) (*Address, error) {
+	tokens := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		list, err := mail.ParseAddressList(recipient)
+		if err != nil {
+			continue
+		}
+		for _, a := range list {
+			local, domain, _ := strings.Cut(strings.ToLower(a.Address), "@")
+			if token, ok := strings.CutPrefix(local, "task-"); ok && domain == strings.ToLower(s.cfg.Domain) {
+				tokens = append(tokens, token)
+			}
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, ErrUnknownAddress
+	}
+
+	var address Address
+	err := s.Addresses.FindOne(ctx, bson.M{"token": bson.M{"$in": tokens}}).Decode(&address)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUnknownAddress
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// category is the address's chosen category while it is live, otherwise the user's first one
+func (s *Service) category(ctx context.Context, address *Address) (primitive.ObjectID, error) {
+	var owner struct {
+		Categories []category.CategoryDocument `bson:"categories"`
+	}
+	err := s.Users.FindOne(ctx, softdelete.Filter(bson.M{"_id": address.User}),
+		options.FindOne().SetProjection(bson.M{"categories._id": 1, "categories." + softdelete.Field: 1}),
+	).Decode(&owner)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	live := softdelete.Visible(owner.Categories)
+	if len(live) == 0 {
+		return primitive.NilObjectID, ErrNoCategory
+	}
+	if address.Category != nil {
+		for _, c := range live {
+			if c.ID == *address.Category {
+				return c.ID, nil
+			}
+		}
+	}
+	return live[0].ID, nil
+}
+
+func (s *Service) view(address *Address) *AddressView {
+	return &AddressView{
+		Address:  "task-" + address.Token + "@" + s.cfg.Domain,
+		Category: address.Category,
+	}
+}
+
+// content is the subject without reply and forward prefixes, or the body's first line for mail without one
+func content(subject string, text string) string {
+	subject = strings.TrimSpace(subject)
+	for {
+		lower := strings.ToLower(subject)
+		prefix := ""
+		for _, p := range []string{"re:", "fwd:", "fw:"} {
+			if strings.HasPrefix(lower, p) {
+				prefix = p
+			}
+		}
+		if prefix == "" {
+			break
+		}
+		subject = strings.TrimSpace(subject[len(prefix):])
+	}
+	if subject == "" {
+		for _, line := range strings.Split(text, "\n") {
+			if subject = strings.TrimSpace(line); subject != "" {
+				break
+			}
+		}
+	}
+	if subject == "" {
+		subject = "Untitled email"
+	}
+	return truncate(subject, maxContent)
+}
+
+// notes is the text body up to the conventional "-- " signature separator
+func notes(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if i := strings.Index(text, "\n-- \n"); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
+
+func newToken() (string, error) {
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = tokenAlphabet[int(b[i])%len(tokenAlphabet)]
+	}
+	return string(b), nil
+}
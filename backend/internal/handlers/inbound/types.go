@@ -0,0 +1,56 @@
+package inbound
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/uploads"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Address is a user's inbound email address, task-<Token>@<domain>
+type Address struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	User  primitive.ObjectID `bson:"user"`
+	Token string             `bson:"token"`
+	// where mailed tasks go, the first category when unset
+	Category  *primitive.ObjectID `bson:"category,omitempty"`
+	CreatedAt time.Time           `bson:"created_at"`
+}
+
+type AddressView struct {
+	Address  string              `json:"address"`
+	Category *primitive.ObjectID `json:"category,omitempty"`
+}
+
+type AddressSettings struct {
+	Category string `validate:"required,mongodb" json:"category"`
+}
+
+// Mail is what the webhook takes from the provider's request
+type Mail struct {
+	Recipients  []string
+	From        string
+	Subject     string
+	Text        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Name        string
+	ContentType string
+	Body        []byte
+}
+
+/*
+Inbound Service to be used by Inbound Handler to turn emails into tasks
+*/
+type Service struct {
+	Addresses *mongo.Collection
+	Users     *mongo.Collection
+	tasks     *task.Service
+	uploads   *uploads.Service
+	cfg       config.Inbound
+}
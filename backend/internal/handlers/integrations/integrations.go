@@ -0,0 +1,478 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+
+	"github.com/abhikaboy/SocialToDo/internal/integrations/gcal"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/github"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/slack"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=integrations.go -destination=mock_test.go -package=integrations
+
+// Connections is what Handler needs from the integrations service
+type Connections interface {
+	CalendarStatus(ctx context.Context, userID primitive.ObjectID) (*CalendarStatus, error)
+	ConnectCalendar(ctx context.Context, userID primitive.ObjectID) (*ConnectResponse, error)
+	CompleteCalendar(ctx context.Context, state string, code string) error
+	SyncCalendar(ctx context.Context, userID primitive.ObjectID) error
+	DisconnectCalendar(ctx context.Context, userID primitive.ObjectID) error
+	CalendarNotification(ctx context.Context, channelID string, token string) error
+	SlackStatus(ctx context.Context, userID primitive.ObjectID) (*SlackStatus, error)
+	ConnectSlack(ctx context.Context, userID primitive.ObjectID) (*ConnectResponse, error)
+	CompleteSlack(ctx context.Context, state string, code string) error
+	UpdateSlack(ctx context.Context, userID primitive.ObjectID, settings SlackSettings) (*SlackStatus, error)
+	DisconnectSlack(ctx context.Context, userID primitive.ObjectID) error
+	SlackCommand(ctx context.Context, timestamp string, signature string, body []byte, command slack.Command) (string, error)
+	GitHubStatus(ctx context.Context, userID primitive.ObjectID) (*GitHubStatus, error)
+	ConnectGitHub(ctx context.Context, userID primitive.ObjectID) (*ConnectResponse, error)
+	CompleteGitHub(ctx context.Context, state string, code string) error
+	LinkRepo(ctx context.Context, userID primitive.ObjectID, req LinkRequest) (*github.Link, error)
+	UnlinkRepo(ctx context.Context, userID primitive.ObjectID, repo string) error
+	SyncGitHub(ctx context.Context, userID primitive.ObjectID) error
+	DisconnectGitHub(ctx context.Context, userID primitive.ObjectID) error
+	GitHubDelivery(ctx context.Context, signature string, body []byte, repo string) error
+	NotionStatus(ctx context.Context, userID primitive.ObjectID) (*NotionStatus, error)
+	ConnectNotion(ctx context.Context, userID primitive.ObjectID) (*ConnectResponse, error)
+	CompleteNotion(ctx context.Context, state string, code string) error
+	DisconnectNotion(ctx context.Context, userID primitive.ObjectID) error
+}
+
+var _ Connections = (*Service)(nil)
+
+/*
+Handler to execute business logic for third-party integrations
+*/
+type Handler struct {
+	service Connections
+}
+
+var validator = xvalidator.Validator
+
+func (h *Handler) GetCalendar(c *fiber.Ctx) error {
+	status, err := h.service.CalendarStatus(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(status)
+}
+
+// ConnectCalendar returns the Google consent URL for the client to open
+func (h *Handler) ConnectCalendar(c *fiber.Ctx) error {
+	response, err := h.service.ConnectCalendar(c.UserContext(), userID(c))
+	if errors.Is(err, gcal.ErrDisabled) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Google Calendar sync is not available",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(response)
+}
+
+// CalendarCallback is where Google sends the browser after consent, so it isn't authenticated; the state identifies the user
+func (h *Handler) CalendarCallback(c *fiber.Ctx) error {
+	if reason := c.Query("error"); reason != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Google Calendar access was not granted",
+		})
+	}
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing state or code",
+		})
+	}
+
+	err := h.service.CompleteCalendar(c.UserContext(), state, code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown or expired connection attempt",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendString("Google Calendar is connected, you can close this window.")
+}
+
+func (h *Handler) SyncCalendar(c *fiber.Ctx) error {
+	err := h.service.SyncCalendar(c.UserContext(), userID(c))
+	if errors.Is(err, ErrNotConnected) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Google Calendar is not connected",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+func (h *Handler) DisconnectCalendar(c *fiber.Ctx) error {
+	err := h.service.DisconnectCalendar(c.UserContext(), userID(c))
+	if errors.Is(err, ErrNotConnected) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Google Calendar is not connected",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+/*
+CalendarWebhook receives Google's change notifications. Google only checks
+for a 2xx, so failures are logged rather than returned and retried.
+*/
+func (h *Handler) CalendarWebhook(c *fiber.Ctx) error {
+	// the first message on a new channel only confirms it
+	if c.Get("X-Goog-Resource-State") == "sync" {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	err := h.service.CalendarNotification(c.UserContext(), c.Get("X-Goog-Channel-ID"), c.Get("X-Goog-Channel-Token"))
+	if err != nil {
+		slog.LogAttrs(c.UserContext(), slog.LevelError, "Failed to handle calendar notification", xslog.Error(err))
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) GetSlack(c *fiber.Ctx) error {
+	status, err := h.service.SlackStatus(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(status)
+}
+
+// ConnectSlack returns the Slack install URL for the client to open
+func (h *Handler) ConnectSlack(c *fiber.Ctx) error {
+	response, err := h.service.ConnectSlack(c.UserContext(), userID(c))
+	if errors.Is(err, slack.ErrDisabled) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Slack is not available",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(response)
+}
+
+// SlackCallback is where Slack sends the browser after the install; the state identifies the user
+func (h *Handler) SlackCallback(c *fiber.Ctx) error {
+	if reason := c.Query("error"); reason != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Slack was not installed",
+		})
+	}
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing state or code",
+		})
+	}
+
+	err := h.service.CompleteSlack(c.UserContext(), state, code)
+	if errors.Is(err, mongo.ErrNoDocuments) || errors.Is(err, slack.ErrRevoked) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown or expired connection attempt",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendString("Slack is connected, you can close this window.")
+}
+
+func (h *Handler) UpdateSlack(c *fiber.Ctx) error {
+	var settings SlackSettings
+	if err := c.BodyParser(&settings); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(settings); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	status, err := h.service.UpdateSlack(c.UserContext(), userID(c), settings)
+	if errors.Is(err, ErrNotConnected) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Slack is not connected",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(status)
+}
+
+func (h *Handler) DisconnectSlack(c *fiber.Ctx) error {
+	err := h.service.DisconnectSlack(c.UserContext(), userID(c))
+	if errors.Is(err, ErrNotConnected) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Slack is not connected",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+/*
+SlackCommand answers /todo. Slack signs the raw form body, so it is verified
+before parsing, and shows the reply only to the member who typed the command.
+*/
+func (h *Handler) SlackCommand(c *fiber.Ctx) error {
+	body := c.Body()
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return c.SendStatus(fiber.StatusBadRequest)
+	}
+
+	text, err := h.service.SlackCommand(c.UserContext(), c.Get("X-Slack-Request-Timestamp"), c.Get("X-Slack-Signature"), body, slack.Command{
+		TeamID: form.Get("team_id"),
+		UserID: form.Get("user_id"),
+		Text:   form.Get("text"),
+	})
+	switch {
+	case errors.Is(err, slack.ErrBadSignature):
+		return c.SendStatus(fiber.StatusUnauthorized)
+	case errors.Is(err, slack.ErrDisabled):
+		return c.SendStatus(fiber.StatusNotFound)
+	case err != nil:
+		slog.LogAttrs(c.UserContext(), slog.LevelError, "Failed to run Slack command", xslog.Error(err))
+		text = "Something went wrong, please try again."
+	}
+	return c.JSON(fiber.Map{"response_type": "ephemeral", "text": text})
+}
+
+func (h *Handler) GetGitHub(c *fiber.Ctx) error {
+	status, err := h.service.GitHubStatus(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(status)
+}
+
+// ConnectGitHub returns the GitHub authorization URL for the client to open
+func (h *Handler) ConnectGitHub(c *fiber.Ctx) error {
+	response, err := h.service.ConnectGitHub(c.UserContext(), userID(c))
+	if errors.Is(err, github.ErrDisabled) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "GitHub sync is not available",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(response)
+}
+
+// GitHubCallback is where GitHub sends the browser after authorizing; the state identifies the user
+func (h *Handler) GitHubCallback(c *fiber.Ctx) error {
+	if reason := c.Query("error"); reason != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "GitHub access was not granted",
+		})
+	}
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing state or code",
+		})
+	}
+
+	err := h.service.CompleteGitHub(c.UserContext(), state, code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown or expired connection attempt",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendString("GitHub is connected, you can close this window.")
+}
+
+// LinkRepo syncs the issues assigned to the user in a repo into one of their categories
+func (h *Handler) LinkRepo(c *fiber.Ctx) error {
+	var req LinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	link, err := h.service.LinkRepo(c.UserContext(), userID(c), req)
+	switch {
+	case errors.Is(err, ErrNotConnected):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "GitHub is not connected",
+		})
+	case errors.Is(err, github.ErrNoCategory):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	case errors.Is(err, github.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Repository not found",
+		})
+	case err != nil:
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(link)
+}
+
+func (h *Handler) UnlinkRepo(c *fiber.Ctx) error {
+	err := h.service.UnlinkRepo(c.UserContext(), userID(c), c.Params("owner")+"/"+c.Params("repo"))
+	switch {
+	case errors.Is(err, ErrNotConnected):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "GitHub is not connected",
+		})
+	case errors.Is(err, github.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Repository is not linked",
+		})
+	case err != nil:
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) SyncGitHub(c *fiber.Ctx) error {
+	err := h.service.SyncGitHub(c.UserContext(), userID(c))
+	if errors.Is(err, ErrNotConnected) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "GitHub is not connected",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+func (h *Handler) DisconnectGitHub(c *fiber.Ctx) error {
+	err := h.service.DisconnectGitHub(c.UserContext(), userID(c))
+	if errors.Is(err, ErrNotConnected) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "GitHub is not connected",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+/*
+GitHubWebhook receives the linked repos' issue events. A delivery only
+queues a sync of the repo, so only the repo name is read from it.
+*/
+func (h *Handler) GitHubWebhook(c *fiber.Ctx) error {
+	if c.Get("X-GitHub-Event") != "issues" {
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+	var delivery struct {
+		Repository struct {
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+	}
+	if err := gojson.Unmarshal(c.Body(), &delivery); err != nil {
+		return c.SendStatus(fiber.StatusBadRequest)
+	}
+
+	err := h.service.GitHubDelivery(c.UserContext(), c.Get("X-Hub-Signature-256"), c.Body(), delivery.Repository.FullName)
+	if errors.Is(err, github.ErrBadSignature) {
+		return c.SendStatus(fiber.StatusUnauthorized)
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) GetNotion(c *fiber.Ctx) error {
+	status, err := h.service.NotionStatus(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(status)
+}
+
+// ConnectNotion returns the Notion authorization URL for the client to open
+func (h *Handler) ConnectNotion(c *fiber.Ctx) error {
+	response, err := h.service.ConnectNotion(c.UserContext(), userID(c))
+	if errors.Is(err, notion.ErrDisabled) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Notion is not available",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(response)
+}
+
+// NotionCallback is where Notion sends the browser after the user shares pages; the state identifies the user
+func (h *Handler) NotionCallback(c *fiber.Ctx) error {
+	if reason := c.Query("error"); reason != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Notion access was not granted",
+		})
+	}
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing state or code",
+		})
+	}
+
+	err := h.service.CompleteNotion(c.UserContext(), state, code)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Unknown or expired connection attempt",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendString("Notion is connected, you can close this window.")
+}
+
+func (h *Handler) DisconnectNotion(c *fiber.Ctx) error {
+	err := h.service.DisconnectNotion(c.UserContext(), userID(c))
+	if errors.Is(err, ErrNotConnected) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Notion is not connected",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	return id
+}
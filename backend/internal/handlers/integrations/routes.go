@@ -0,0 +1,89 @@
+package integrations
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/gcal"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/github"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/slack"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler, google config.Google, slackCfg config.Slack, githubCfg config.GitHub, notionCfg config.Notion) {
+	service := newService(
+		gcal.New(collections, google),
+		slack.New(collections, cache, slackCfg),
+		github.New(collections, cache, githubCfg),
+		notion.New(collections, notionCfg),
+	)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	// registered ahead of the authenticated groups, the providers' redirects carry no token
+	apiV1.Get("/integrations/google-calendar/callback", handler.CalendarCallback)
+	apiV1.Get("/integrations/slack/callback", handler.SlackCallback)
+	apiV1.Get("/integrations/github/callback", handler.GitHubCallback)
+	apiV1.Get("/integrations/notion/callback", handler.NotionCallback)
+	app.Post("/hooks/google-calendar", handler.CalendarWebhook)
+	app.Post("/hooks/slack/commands", handler.SlackCommand)
+	app.Post("/hooks/github", handler.GitHubWebhook)
+
+	Calendar := apiV1.Group("/integrations/google-calendar", authenticate)
+	Calendar.Get("/", handler.GetCalendar)
+	Calendar.Post("/", handler.ConnectCalendar)
+	Calendar.Post("/sync", handler.SyncCalendar)
+	Calendar.Delete("/", handler.DisconnectCalendar)
+
+	Slack := apiV1.Group("/integrations/slack", authenticate)
+	Slack.Get("/", handler.GetSlack)
+	Slack.Post("/", handler.ConnectSlack)
+	Slack.Patch("/", handler.UpdateSlack)
+	Slack.Delete("/", handler.DisconnectSlack)
+
+	GitHub := apiV1.Group("/integrations/github", authenticate)
+	GitHub.Get("/", handler.GetGitHub)
+	GitHub.Post("/", handler.ConnectGitHub)
+	GitHub.Post("/sync", handler.SyncGitHub)
+	GitHub.Delete("/", handler.DisconnectGitHub)
+	GitHub.Post("/links", handler.LinkRepo)
+	GitHub.Delete("/links/:owner/:repo", handler.UnlinkRepo)
+
+	Notion := apiV1.Group("/integrations/notion", authenticate)
+	Notion.Get("/", handler.GetNotion)
+	Notion.Post("/", handler.ConnectNotion)
+	Notion.Delete("/", handler.DisconnectNotion)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/integrations/google-calendar/callback":     {Summary: "Google's OAuth redirect", Query: xopenapi.Query{"state", "code", "error"}, Response: "", ResponseType: fiber.MIMETextPlain},
+		"GET /api/v1/integrations/slack/callback":               {Summary: "Slack's OAuth redirect", Query: xopenapi.Query{"state", "code", "error"}, Response: "", ResponseType: fiber.MIMETextPlain},
+		"GET /api/v1/integrations/github/callback":              {Summary: "GitHub's OAuth redirect", Query: xopenapi.Query{"state", "code", "error"}, Response: "", ResponseType: fiber.MIMETextPlain},
+		"GET /api/v1/integrations/notion/callback":              {Summary: "Notion's OAuth redirect", Query: xopenapi.Query{"state", "code", "error"}, Response: "", ResponseType: fiber.MIMETextPlain},
+		"POST /hooks/google-calendar":                           {Summary: "Google Calendar push notifications"},
+		"POST /hooks/slack/commands":                            {Summary: "Slack's /todo slash command", Request: map[string]string{}, RequestType: fiber.MIMEApplicationForm, Response: fiber.Map{}},
+		"POST /hooks/github":                                    {Summary: "GitHub issue events for linked repositories", Request: map[string]any{}, Status: fiber.StatusNoContent},
+		"GET /api/v1/integrations/google-calendar/":             {Summary: "The caller's Google Calendar connection", Auth: true, Response: CalendarStatus{}},
+		"POST /api/v1/integrations/google-calendar/":            {Summary: "Start connecting Google Calendar", Auth: true, Response: ConnectResponse{}},
+		"POST /api/v1/integrations/google-calendar/sync":        {Summary: "Sync Google Calendar now", Auth: true, Status: fiber.StatusAccepted},
+		"DELETE /api/v1/integrations/google-calendar/":          {Summary: "Disconnect Google Calendar", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/integrations/slack/":                       {Summary: "The caller's Slack connection", Auth: true, Response: SlackStatus{}},
+		"POST /api/v1/integrations/slack/":                      {Summary: "Start connecting Slack", Auth: true, Response: ConnectResponse{}},
+		"PATCH /api/v1/integrations/slack/":                     {Summary: "Change the Slack notification settings", Auth: true, Request: SlackSettings{}, Response: SlackStatus{}},
+		"DELETE /api/v1/integrations/slack/":                    {Summary: "Disconnect Slack", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/integrations/github/":                      {Summary: "The caller's GitHub connection", Auth: true, Response: GitHubStatus{}},
+		"POST /api/v1/integrations/github/":                     {Summary: "Start connecting GitHub", Auth: true, Response: ConnectResponse{}},
+		"POST /api/v1/integrations/github/sync":                 {Summary: "Sync linked repositories now", Auth: true, Status: fiber.StatusAccepted},
+		"DELETE /api/v1/integrations/github/":                   {Summary: "Disconnect GitHub", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/integrations/github/links":                {Summary: "Link a repository's issues to a category", Auth: true, Request: LinkRequest{}, Response: github.Link{}, Status: fiber.StatusCreated},
+		"DELETE /api/v1/integrations/github/links/:owner/:repo": {Summary: "Unlink a repository", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/integrations/notion/":                      {Summary: "The caller's Notion connection", Auth: true, Response: NotionStatus{}},
+		"POST /api/v1/integrations/notion/":                     {Summary: "Start connecting Notion", Auth: true, Response: ConnectResponse{}},
+		"DELETE /api/v1/integrations/notion/":                   {Summary: "Disconnect Notion", Auth: true, Status: fiber.StatusNoContent},
+	})
+}
@@ -0,0 +1,357 @@
+package integrations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+
+	"github.com/abhikaboy/SocialToDo/internal/integrations/gcal"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/github"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/slack"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrNotConnected = errors.New("integration is not connected")
+
+func newService(calendar *gcal.Syncer, slack *slack.App, github *github.Syncer, notion *notion.Notion) *Service {
+	return &Service{calendar, slack, github, notion}
+}
+
+func (s *Service) CalendarStatus(ctx context.Context, userID primitive.ObjectID) (*CalendarStatus, error) {
+	integration, err := s.calendar.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &CalendarStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	status := &CalendarStatus{
+		Connected:    integration.Connected(),
+		CalendarID:   integration.CalendarID,
+		LastSyncedAt: integration.LastSyncedAt,
+		LastError:    integration.LastError,
+	}
+	if integration.Channel != nil {
+		status.Notifications = true
+		status.ChannelExpireAt = &integration.Channel.Expiration
+	}
+	return status, nil
+}
+
+// ConnectCalendar starts the OAuth flow, the state ties the callback back to the user
+func (s *Service) ConnectCalendar(ctx context.Context, userID primitive.ObjectID) (*ConnectResponse, error) {
+	if !s.calendar.Enabled() {
+		return nil, gcal.ErrDisabled
+	}
+	state, err := newState()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.calendar.Store().Begin(ctx, userID, state); err != nil {
+		return nil, err
+	}
+	return &ConnectResponse{AuthURL: s.calendar.Client().AuthURL(state)}, nil
+}
+
+// CompleteCalendar finishes the OAuth flow, then opens a notification channel and queues the first sync
+func (s *Service) CompleteCalendar(ctx context.Context, state string, code string) error {
+	token, err := s.calendar.Client().Exchange(ctx, code)
+	if err != nil {
+		return err
+	}
+	integration, err := s.calendar.Store().Connect(ctx, state, token)
+	if err != nil {
+		return err
+	}
+	// without a channel the poll still syncs, and the renewal schedule retries the watch
+	if err := s.calendar.Watch(ctx, integration); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "Failed to watch calendar", xslog.Error(err))
+	}
+	return s.calendar.Enqueue(ctx, integration.User)
+}
+
+func (s *Service) SyncCalendar(ctx context.Context, userID primitive.ObjectID) error {
+	integration, err := s.calendar.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !integration.Connected()) {
+		return ErrNotConnected
+	}
+	if err != nil {
+		return err
+	}
+	return s.calendar.Enqueue(ctx, userID)
+}
+
+func (s *Service) DisconnectCalendar(ctx context.Context, userID primitive.ObjectID) error {
+	integration, err := s.calendar.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotConnected
+	}
+	if err != nil {
+		return err
+	}
+	return s.calendar.Disconnect(ctx, integration)
+}
+
+// CalendarNotification queues a pull for the channel's user; unknown channels and tokens are ignored
+func (s *Service) CalendarNotification(ctx context.Context, channelID string, token string) error {
+	integration, err := s.calendar.Store().ByChannel(ctx, channelID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if integration.Channel.Token != token {
+		return nil
+	}
+	return s.calendar.Enqueue(ctx, integration.User)
+}
+
+func (s *Service) SlackStatus(ctx context.Context, userID primitive.ObjectID) (*SlackStatus, error) {
+	integration, err := s.slack.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &SlackStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &SlackStatus{
+		Connected: integration.Connected(),
+		TeamName:  integration.TeamName,
+		NotifyDue: integration.NotifyDue,
+		Category:  integration.Category,
+	}, nil
+}
+
+// ConnectSlack starts the app install, the state ties the callback back to the user
+func (s *Service) ConnectSlack(ctx context.Context, userID primitive.ObjectID) (*ConnectResponse, error) {
+	if !s.slack.Enabled() {
+		return nil, slack.ErrDisabled
+	}
+	state, err := newState()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.slack.Store().Begin(ctx, userID, state); err != nil {
+		return nil, err
+	}
+	return &ConnectResponse{AuthURL: s.slack.Client().AuthURL(state)}, nil
+}
+
+func (s *Service) CompleteSlack(ctx context.Context, state string, code string) error {
+	install, err := s.slack.Client().Exchange(ctx, code)
+	if err != nil {
+		return err
+	}
+	_, err = s.slack.Store().Connect(ctx, state, install)
+	return err
+}
+
+func (s *Service) UpdateSlack(ctx context.Context, userID primitive.ObjectID, settings SlackSettings) (*SlackStatus, error) {
+	integration, err := s.slack.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !integration.Connected()) {
+		return nil, ErrNotConnected
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := bson.M{}
+	if settings.NotifyDue != nil {
+		fields["notify_due"] = *settings.NotifyDue
+	}
+	if settings.Category != nil {
+		id, _ := primitive.ObjectIDFromHex(*settings.Category)
+		fields["category"] = id
+	}
+	if len(fields) > 0 {
+		if err := s.slack.Store().Update(ctx, integration.ID, fields); err != nil {
+			return nil, err
+		}
+	}
+	return s.SlackStatus(ctx, userID)
+}
+
+func (s *Service) DisconnectSlack(ctx context.Context, userID primitive.ObjectID) error {
+	integration, err := s.slack.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotConnected
+	}
+	if err != nil {
+		return err
+	}
+	return s.slack.Disconnect(ctx, integration)
+}
+
+// SlackCommand answers a slash command once its signature checks out
+func (s *Service) SlackCommand(ctx context.Context, timestamp string, signature string, body []byte, command slack.Command) (string, error) {
+	if !s.slack.Enabled() {
+		return "", slack.ErrDisabled
+	}
+	if err := s.slack.Verify(timestamp, signature, body); err != nil {
+		return "", err
+	}
+	return s.slack.Run(ctx, command)
+}
+
+func (s *Service) GitHubStatus(ctx context.Context, userID primitive.ObjectID) (*GitHubStatus, error) {
+	integration, err := s.github.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &GitHubStatus{Links: []github.Link{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &GitHubStatus{
+		Connected:    integration.Connected(),
+		Login:        integration.Login,
+		Links:        integration.Links,
+		LastSyncedAt: integration.LastSyncedAt,
+		LastError:    integration.LastError,
+	}, nil
+}
+
+// ConnectGitHub starts the OAuth flow, the state ties the callback back to the user
+func (s *Service) ConnectGitHub(ctx context.Context, userID primitive.ObjectID) (*ConnectResponse, error) {
+	if !s.github.Enabled() {
+		return nil, github.ErrDisabled
+	}
+	state, err := newState()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.github.Store().Begin(ctx, userID, state); err != nil {
+		return nil, err
+	}
+	return &ConnectResponse{AuthURL: s.github.Client().AuthURL(state)}, nil
+}
+
+// CompleteGitHub finishes the OAuth flow and queues a sync for links kept from an earlier connection
+func (s *Service) CompleteGitHub(ctx context.Context, state string, code string) error {
+	token, err := s.github.Client().Exchange(ctx, code)
+	if err != nil {
+		return err
+	}
+	login, err := s.github.Client().Login(ctx, token)
+	if err != nil {
+		return err
+	}
+	integration, err := s.github.Store().Connect(ctx, state, token, login)
+	if err != nil {
+		return err
+	}
+	return s.github.Enqueue(ctx, integration.User)
+}
+
+func (s *Service) connectedGitHub(ctx context.Context, userID primitive.ObjectID) (*github.Integration, error) {
+	integration, err := s.github.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !integration.Connected()) {
+		return nil, ErrNotConnected
+	}
+	return integration, err
+}
+
+func (s *Service) LinkRepo(ctx context.Context, userID primitive.ObjectID, req LinkRequest) (*github.Link, error) {
+	integration, err := s.connectedGitHub(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	category, _ := primitive.ObjectIDFromHex(req.Category)
+	return s.github.Link(ctx, integration, req.Repo, category)
+}
+
+func (s *Service) UnlinkRepo(ctx context.Context, userID primitive.ObjectID, repo string) error {
+	integration, err := s.connectedGitHub(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.github.Unlink(ctx, integration, repo)
+}
+
+func (s *Service) SyncGitHub(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := s.connectedGitHub(ctx, userID); err != nil {
+		return err
+	}
+	return s.github.Enqueue(ctx, userID)
+}
+
+func (s *Service) DisconnectGitHub(ctx context.Context, userID primitive.ObjectID) error {
+	integration, err := s.github.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotConnected
+	}
+	if err != nil {
+		return err
+	}
+	return s.github.Disconnect(ctx, integration)
+}
+
+// GitHubDelivery queues syncs for the repo an issues webhook delivery is about, once its signature checks out
+func (s *Service) GitHubDelivery(ctx context.Context, signature string, body []byte, repo string) error {
+	if err := github.Verify(s.github.WebhookSecret(), signature, body); err != nil {
+		return err
+	}
+	return s.github.Delivered(ctx, repo)
+}
+
+func (s *Service) NotionStatus(ctx context.Context, userID primitive.ObjectID) (*NotionStatus, error) {
+	integration, err := s.notion.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &NotionStatus{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &NotionStatus{Connected: integration.Connected(), WorkspaceName: integration.WorkspaceName}, nil
+}
+
+// ConnectNotion starts the OAuth flow, in which the user also picks the pages exports may go under
+func (s *Service) ConnectNotion(ctx context.Context, userID primitive.ObjectID) (*ConnectResponse, error) {
+	if !s.notion.Enabled() {
+		return nil, notion.ErrDisabled
+	}
+	state, err := newState()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.notion.Store().Begin(ctx, userID, state); err != nil {
+		return nil, err
+	}
+	return &ConnectResponse{AuthURL: s.notion.Client().AuthURL(state)}, nil
+}
+
+func (s *Service) CompleteNotion(ctx context.Context, state string, code string) error {
+	grant, err := s.notion.Client().Exchange(ctx, code)
+	if err != nil {
+		return err
+	}
+	_, err = s.notion.Store().Connect(ctx, state, grant)
+	return err
+}
+
+// DisconnectNotion forgets the token; Notion has no revoke call, the user removes the integration in Notion
+func (s *Service) DisconnectNotion(ctx context.Context, userID primitive.ObjectID) error {
+	integration, err := s.notion.Store().Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotConnected
+	}
+	if err != nil {
+		return err
+	}
+	return s.notion.Store().Delete(ctx, integration.ID)
+}
+
+// newState is an unguessable OAuth state
+func newState() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
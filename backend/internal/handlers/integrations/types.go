@@ -0,0 +1,71 @@
+package integrations
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/integrations/gcal"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/github"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/notion"
+	"github.com/abhikaboy/SocialToDo/internal/integrations/slack"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+Integrations Service to be used by Integrations Handler to connect
+third-party services
+*/
+type Service struct {
+	calendar *gcal.Syncer
+	slack    *slack.App
+	github   *github.Syncer
+	notion   *notion.Notion
+}
+
+// CalendarStatus is what a user sees of their Google Calendar connection, tokens left out
+type CalendarStatus struct {
+	Connected       bool       `json:"connected"`
+	CalendarID      string     `json:"calendar_id,omitempty"`
+	LastSyncedAt    *time.Time `json:"last_synced_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	Notifications   bool       `json:"notifications"`
+	ChannelExpireAt *time.Time `json:"channel_expires_at,omitempty"`
+}
+
+type ConnectResponse struct {
+	AuthURL string `json:"auth_url"`
+}
+
+// SlackStatus is what a user sees of their Slack link
+type SlackStatus struct {
+	Connected bool                `json:"connected"`
+	TeamName  string              `json:"team_name,omitempty"`
+	NotifyDue bool                `json:"notify_due"`
+	Category  *primitive.ObjectID `json:"category,omitempty"`
+}
+
+type SlackSettings struct {
+	NotifyDue *bool `json:"notify_due"`
+	// the category /todo add uses
+	Category *string `validate:"omitnil,mongodb" json:"category"`
+}
+
+// GitHubStatus is what a user sees of their GitHub connection, the token left out
+type GitHubStatus struct {
+	Connected    bool          `json:"connected"`
+	Login        string        `json:"login,omitempty"`
+	Links        []github.Link `json:"links"`
+	LastSyncedAt *time.Time    `json:"last_synced_at,omitempty"`
+	LastError    string        `json:"last_error,omitempty"`
+}
+
+type LinkRequest struct {
+	// "owner/name"
+	Repo     string `validate:"required,contains=/,max=200" json:"repo"`
+	Category string `validate:"required,mongodb" json:"category"`
+}
+
+// NotionStatus is what a user sees of their Notion connection
+type NotionStatus struct {
+	Connected     bool   `json:"connected"`
+	WorkspaceName string `json:"workspace_name,omitempty"`
+}
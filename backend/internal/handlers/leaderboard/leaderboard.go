@@ -0,0 +1,62 @@
+package leaderboard
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=leaderboard.go -destination=mock_test.go -package=leaderboard
+
+// Leaderboards is what Handler needs of the service
+type Leaderboards interface {
+	Get(ctx context.Context, viewer primitive.ObjectID, by string) (*Leaderboard, error)
+}
+
+var _ Leaderboards = (*Service)(nil)
+
+/*
+Handler to execute business logic for leaderboards
+*/
+type Handler struct {
+	service Leaderboards
+}
+
+func (h *Handler) GetLeaderboard(c *fiber.Ctx) error {
+	userID, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	var query Query
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := validator.Validate(query); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+	if query.By == "" {
+		query.By = ByWeek
+	}
+
+	board, err := h.service.Get(c.UserContext(), userID, query.By)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load the leaderboard",
+		})
+	}
+	return c.JSON(board)
+}
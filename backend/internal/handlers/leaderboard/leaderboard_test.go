@@ -0,0 +1,81 @@
+package leaderboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRank(t *testing.T) {
+	entries := func() []Entry {
+		return []Entry{
+			{Handle: "@ada", Week: 4, CurrentStreak: 2},
+			{Handle: "@bob", Week: 9, CurrentStreak: 1},
+			{Handle: "@cy", Week: 4, CurrentStreak: 2},
+			{Handle: "@dee", Week: 4, CurrentStreak: 6},
+			{Handle: "@eve", Week: 0, CurrentStreak: 0},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		by             string
+		expectedOrder  []string
+		expectedPlaces []int
+	}{
+		{"by week, streak breaking ties", ByWeek, []string{"@bob", "@dee", "@ada", "@cy", "@eve"}, []int{1, 2, 3, 3, 5}},
+		{"by streak, week breaking ties", ByStreak, []string{"@dee", "@ada", "@cy", "@bob", "@eve"}, []int{1, 2, 2, 4, 5}},
+	}
+	for _, tt := range tests {
+		ranked := entries()
+		rank(ranked, tt.by)
+		var order []string
+		var places []int
+		for _, e := range ranked {
+			order = append(order, e.Handle)
+			places = append(places, e.Place)
+		}
+		if !slices.Equal(order, tt.expectedOrder) || !slices.Equal(places, tt.expectedPlaces) {
+			t.Errorf("%s: expected %v %v, got %v %v", tt.name, tt.expectedOrder, tt.expectedPlaces, order, places)
+		}
+	}
+}
+
+func TestGetLeaderboard(t *testing.T) {
+	userID := primitive.NewObjectID()
+	service := NewMockLeaderboards(gomock.NewController(t))
+	service.EXPECT().Get(gomock.Any(), userID, ByWeek).Return(&Leaderboard{By: ByWeek}, nil)
+	service.EXPECT().Get(gomock.Any(), userID, ByStreak).Return(&Leaderboard{By: ByStreak}, nil)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Get("/leaderboard", handler.GetLeaderboard)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+	}{
+		{"by week by default", "", fiber.StatusOK},
+		{"by streak", "?by=streak", fiber.StatusOK},
+		{"by something else", "?by=value", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, "/leaderboard"+tt.query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+		}
+	}
+}
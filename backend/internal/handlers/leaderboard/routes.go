@@ -0,0 +1,24 @@
+package leaderboard
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler) {
+	service := newService(collections, cache)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	apiV1.Get("/leaderboard", authenticate, handler.GetLeaderboard)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/leaderboard": {Summary: "The caller and their friends ranked by tasks completed this week, or by ?by=streak", Auth: true, Query: xopenapi.Query{"by"}, Response: Leaderboard{}},
+	})
+}
@@ -0,0 +1,154 @@
+package leaderboard
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/stats"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache) *Service {
+	return &Service{
+		users: collections["users"],
+		cache: cache,
+	}
+}
+
+type viewer struct {
+	privacy.Relations `bson:",inline"`
+	TimeZone          string `bson:"timezone"`
+}
+
+// Get is the viewer's leaderboard for this week, cached for ttl under their user tag
+func (s *Service) Get(ctx context.Context, viewerID primitive.ObjectID, by string) (*Leaderboard, error) {
+	projection := maps.Clone(privacy.Projection)
+	projection["timezone"] = 1
+	var me viewer
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"_id": viewerID}), options.FindOne().SetProjection(projection)).Decode(&me)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().In(stats.Location(me.TimeZone))
+	week := stats.WeekOf(now)
+
+	return xcache.Fetch(ctx, s.cache, xcache.LeaderboardKey(viewerID.Hex(), by, week.Format(time.DateOnly)), ttl,
+		[]string{xcache.UserTag(viewerID.Hex())},
+		func() (*Leaderboard, error) {
+			return s.leaderboard(ctx, me, by, week, now)
+		})
+}
+
+type row struct {
+	ID             primitive.ObjectID `bson:"_id"`
+	DisplayName    string             `bson:"display_name"`
+	Handle         string             `bson:"handle"`
+	ProfilePicture string             `bson:"profile_picture"`
+	Week           int                `bson:"week"`
+	Stats          *stats.Stats       `bson:"stats"`
+}
+
+/*
+leaderboard counts each entrant's completions since week began and looks up
+their streaks in one pipeline over the user documents. Friends the viewer
+can't see, or who opted out, aren't entered; the viewer always is, so they
+see where they'd stand.
+*/
+func (s *Service) leaderboard(ctx context.Context, me viewer, by string, week time.Time, now time.Time) (*Leaderboard, error) {
+	friends, err := privacy.Load(ctx, s.users, me.Friends)
+	if err != nil {
+		return nil, err
+	}
+	entrants := []primitive.ObjectID{me.ID}
+	for _, id := range me.Friends {
+		if friend, ok := friends[id]; ok && friend.Privacy.OnLeaderboard() && privacy.CanView(me.Relations, friend) {
+			entrants = append(entrants, id)
+		}
+	}
+
+	cursor, err := s.users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: softdelete.Filter(bson.M{"_id": bson.M{"$in": entrants}})}},
+		{{Key: "$project", Value: bson.M{"display_name": 1, "handle": 1, "profile_picture": 1}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         stats.CompletionsCollection,
+			"localField":   "_id",
+			"foreignField": "user",
+			"as":           "week",
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"completed_at": bson.M{"$gte": week}}},
+				bson.M{"$count": "completed"},
+			},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         stats.Collection,
+			"localField":   "_id",
+			"foreignField": "_id",
+			"as":           "stats",
+			"pipeline":     bson.A{bson.M{"$project": bson.M{"tz": 1, "current_streak": 1, "longest_streak": 1, "last_active": 1}}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"week":  bson.M{"$ifNull": bson.A{bson.M{"$first": "$week.completed"}, 0}},
+			"stats": bson.M{"$first": "$stats"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []row
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(rows))
+	for _, r := range rows {
+		entry := Entry{
+			User:           r.ID,
+			DisplayName:    r.DisplayName,
+			Handle:         r.Handle,
+			ProfilePicture: r.ProfilePicture,
+			Week:           r.Week,
+		}
+		// streaks are as of the last recomputation, and may have lapsed since
+		if r.Stats != nil {
+			entry.CurrentStreak = r.Stats.Streak(now)
+			entry.LongestStreak = r.Stats.LongestStreak
+		}
+		entries = append(entries, entry)
+	}
+	rank(entries, by)
+	return &Leaderboard{By: by, Week: week.Format(time.DateOnly), Entries: entries}, nil
+}
+
+// rank orders the entries by the count by names, then by the other one, and gives entries tied on both the same place
+func rank(entries []Entry, by string) {
+	counts := func(e Entry) [2]int {
+		if by == ByStreak {
+			return [2]int{e.CurrentStreak, e.Week}
+		}
+		return [2]int{e.Week, e.CurrentStreak}
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		ca, cb := counts(a), counts(b)
+		return cmp.Or(
+			cmp.Compare(cb[0], ca[0]),
+			cmp.Compare(cb[1], ca[1]),
+			strings.Compare(a.Handle, b.Handle),
+		)
+	})
+	for i := range entries {
+		entries[i].Place = i + 1
+		if i > 0 && counts(entries[i]) == counts(entries[i-1]) {
+			entries[i].Place = entries[i-1].Place
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package leaderboard
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// how long a leaderboard is served from the cache, so how late completions can show on it
+	ttl = time.Minute
+
+	// tasks completed this week
+	ByWeek = "week"
+	// the current streak
+	ByStreak = "streak"
+)
+
+type Query struct {
+	By string `validate:"omitempty,oneof=week streak" query:"by"`
+}
+
+/*
+Leaderboard ranks the caller and those of their friends who haven't opted
+out in privacy.Settings. Weeks start on Monday in the caller's time zone,
+the same week for everyone on the board.
+*/
+type Leaderboard struct {
+	By string `json:"by"`
+	// the Monday the week started
+	Week    string  `json:"week"`
+	Entries []Entry `json:"entries"`
+}
+
+type Entry struct {
+	// entries tied on both counts share a place
+	Place          int                `json:"place"`
+	User           primitive.ObjectID `json:"user_id"`
+	DisplayName    string             `json:"display_name"`
+	Handle         string             `json:"handle"`
+	ProfilePicture string             `json:"profile_picture"`
+	Week           int                `json:"week"`
+	CurrentStreak  int                `json:"current_streak"`
+	LongestStreak  int                `json:"longest_streak"`
+}
+
+/*
+Leaderboard Service to be used by Leaderboard Handler to interact with the
+Database layer of the application
+*/
+type Service struct {
+	users *mongo.Collection
+	cache xcache.Cache
+}
@@ -0,0 +1,125 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+
+	inbox "github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=notifications.go -destination=mock_test.go -package=notifications
+
+// Notifications is the notification service as Handler uses it
+type Notifications interface {
+	List(ctx context.Context, userID primitive.ObjectID, query xpage.Query) (*xpage.Page[inbox.Notification], error)
+	RegisterDevice(ctx context.Context, userID primitive.ObjectID, params RegisterDeviceParams) (*inbox.Device, error)
+	UnregisterDevice(ctx context.Context, userID primitive.ObjectID, token string) error
+	Preferences(ctx context.Context, userID primitive.ObjectID) (inbox.Preferences, error)
+	UpdatePreferences(ctx context.Context, userID primitive.ObjectID, params UpdatePreferencesParams) (inbox.Preferences, error)
+}
+
+var _ Notifications = (*Service)(nil)
+
+type Handler struct {
+	service Notifications
+}
+
+func (h *Handler) ListNotifications(c *fiber.Ctx) error {
+	var query xpage.Query
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	if errs := validator.Validate(query); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	notifications, err := h.service.List(c.UserContext(), userID(c), query)
+	if errors.Is(err, xpage.ErrInvalidCursor) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch notifications",
+		})
+	}
+	return c.JSON(notifications)
+}
+
+func (h *Handler) RegisterDevice(c *fiber.Ctx) error {
+	var params RegisterDeviceParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	device, err := h.service.RegisterDevice(c.UserContext(), userID(c), params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to register device",
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(device)
+}
+
+func (h *Handler) UnregisterDevice(c *fiber.Ctx) error {
+	err := h.service.UnregisterDevice(c.UserContext(), userID(c), c.Params("token"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Device not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to unregister device",
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) GetPreferences(c *fiber.Ctx) error {
+	preferences, err := h.service.Preferences(c.UserContext(), userID(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch notification preferences",
+		})
+	}
+	return c.JSON(preferences)
+}
+
+func (h *Handler) UpdatePreferences(c *fiber.Ctx) error {
+	var params UpdatePreferencesParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	preferences, err := h.service.UpdatePreferences(c.UserContext(), userID(c), params)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update notification preferences",
+		})
+	}
+	return c.JSON(preferences)
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := c.Locals("user_id").(string)
+	userID, _ := primitive.ObjectIDFromHex(id)
+	return userID
+}
@@ -0,0 +1,279 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	inbox "github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xpush"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Every notification written to the inbox (friend requests, due reminders,
+friends completing tasks, comments, reactions, finished exports) arrives here through the change stream and is
+pushed to the user's devices, one job per device, unless their preferences
+turn that kind off. Each instance sees the change; the one that sets
+pushed_at first queues the pushes.
+*/
+
+// RegisterJobs adds the push and fan-out job handlers to the worker
+func RegisterJobs(worker *jobs.Worker, collections map[string]*mongo.Collection, pusher *xpush.Pusher) {
+	s := newService(collections)
+	s.pusher = pusher
+	worker.Handle(PushJob, s.push)
+	worker.Handle(FanOutJob, s.fanOut)
+}
+
+// SubscribeEvents queues the pushes of new notifications and the fan-out of completed tasks to friends
+func SubscribeEvents(bus *events.Bus, collections map[string]*mongo.Collection) {
+	s := newService(collections)
+	bus.SubscribeAsync(func(ctx context.Context, event events.Event) {
+		id, err := primitive.ObjectIDFromHex(event.DocumentID)
+		if err != nil {
+			return
+		}
+		switch event.Type {
+		case events.NotificationCreated:
+			err = s.queuePushes(ctx, id)
+		case events.TaskCompleted:
+			err = s.queueFanOut(ctx, event.UserID, id, event.OccurredAt)
+		}
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to handle event for notifications",
+				slog.String("event_type", string(event.Type)), xslog.Error(err))
+		}
+	}, events.NotificationCreated, events.TaskCompleted)
+}
+
+func (s *Service) queuePushes(ctx context.Context, id primitive.ObjectID) error {
+	var n inbox.Notification
+	err := s.notifications.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "pushed_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"pushed_at": time.Now()}},
+	).Decode(&n)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// another instance has it
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var user userPreferences
+	err = s.users.FindOne(ctx, softdelete.Filter(bson.M{"_id": n.User}),
+		options.FindOne().SetProjection(bson.M{"notification_preferences": 1})).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil || !user.Preferences.Pushes(n.Type) {
+		return err
+	}
+
+	cursor, err := s.devices.Find(ctx, bson.M{"user": n.User}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	var devices []inbox.Device
+	if err := cursor.All(ctx, &devices); err != nil {
+		return err
+	}
+	for _, device := range devices {
+		// a push is stale soon, no point retrying for long
+		if _, err := s.queue.Enqueue(ctx, PushJob, PushPayload{Notification: n.ID, Device: device.ID}, jobs.MaxAttempts(5)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type taskOwner struct {
+	privacy.Relations `bson:",inline"`
+	Categories        []struct {
+		Tasks []struct {
+			ID      primitive.ObjectID `bson:"_id"`
+			Content string             `bson:"content"`
+			Public  bool               `bson:"public"`
+		} `bson:"tasks"`
+	} `bson:"categories"`
+}
+
+// queueFanOut hands the completion to a job, so a failed fan-out is retried rather than lost with the event
+func (s *Service) queueFanOut(ctx context.Context, ownerID string, taskID primitive.ObjectID, at time.Time) error {
+	owner, err := primitive.ObjectIDFromHex(ownerID)
+	if err != nil {
+		return nil
+	}
+	_, err = s.queue.Enqueue(ctx, FanOutJob, FanOutPayload{Owner: owner, Task: taskID, CompletedAt: at})
+	return err
+}
+
+func (s *Service) fanOut(ctx context.Context, job *jobs.Job) error {
+	var payload FanOutPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	return s.notifyFriends(ctx, payload.Owner, payload.Task, payload.CompletedAt)
+}
+
+/*
+notifyFriends tells the owner's friends about a public task they completed,
+unless the owner keeps their activity to themselves. The completion time is
+in the key, so the relayed event coming twice, or the job running twice,
+notifies once.
+*/
+func (s *Service) notifyFriends(ctx context.Context, owner primitive.ObjectID, taskID primitive.ObjectID, at time.Time) error {
+	var user taskOwner
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"_id": owner}), options.FindOne().SetProjection(bson.M{
+		"friends":                  1,
+		"privacy":                  1,
+		"categories.tasks._id":     1,
+		"categories.tasks.content": 1,
+		"categories.tasks.public":  1,
+	})).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.Privacy.SharesActivity() || user.Privacy.Visibility() == privacy.Private {
+		return nil
+	}
+
+	var content string
+	public := false
+	for _, c := range user.Categories {
+		for _, t := range c.Tasks {
+			if t.ID == taskID {
+				content, public = t.Content, t.Public
+			}
+		}
+	}
+	if !public {
+		return nil
+	}
+
+	key := string(inbox.FriendCompleted) + ":" + taskID.Hex() + ":" + strconv.FormatInt(at.Unix(), 10)
+	now := time.Now()
+	docs := make([]inbox.Notification, 0, len(user.Friends))
+	for _, friend := range user.Friends {
+		docs = append(docs, inbox.Notification{
+			ID:        primitive.NewObjectID(),
+			User:      friend,
+			Type:      inbox.FriendCompleted,
+			Key:       key,
+			Title:     content,
+			TaskID:    &taskID,
+			FromUser:  &owner,
+			CreatedAt: now,
+		})
+	}
+	_, err = inbox.Insert(ctx, s.notifications, docs...)
+	return err
+}
+
+/*
+push sends one notification to one device. Either having gone away since
+means there is nothing to send, and a token the push service no longer knows
+takes the device with it.
+*/
+func (s *Service) push(ctx context.Context, job *jobs.Job) error {
+	var payload PushPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var n inbox.Notification
+	err := s.notifications.FindOne(ctx, bson.M{"_id": payload.Notification}).Decode(&n)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var device inbox.Device
+	err = s.devices.FindOne(ctx, bson.M{"_id": payload.Device, "user": n.User}).Decode(&device)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	msg, err := s.message(ctx, n)
+	if err != nil {
+		return err
+	}
+	msg.Token = device.Token
+	err = s.pusher.Send(ctx, device.Platform, msg)
+	switch {
+	case errors.Is(err, xpush.ErrUnregistered):
+		_, err := s.devices.DeleteOne(ctx, bson.M{"_id": device.ID, "token": device.Token})
+		return err
+	case errors.Is(err, xpush.ErrRejected):
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// message words the push for n
+func (s *Service) message(ctx context.Context, n inbox.Notification) (xpush.Message, error) {
+	msg := xpush.Message{Data: map[string]string{"notification_id": n.ID.Hex(), "type": string(n.Type)}}
+	if n.TaskID != nil {
+		msg.Data["task_id"] = n.TaskID.Hex()
+	}
+	if n.ExportID != nil {
+		msg.Data["export_id"] = n.ExportID.Hex()
+	}
+
+	var from string
+	if n.FromUser != nil {
+		msg.Data["user_id"] = n.FromUser.Hex()
+		var user struct {
+			DisplayName string `bson:"display_name"`
+			Handle      string `bson:"handle"`
+		}
+		err := s.users.FindOne(ctx, bson.M{"_id": *n.FromUser},
+			options.FindOne().SetProjection(bson.M{"display_name": 1, "handle": 1})).Decode(&user)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return msg, err
+		}
+		from = user.DisplayName
+		if from == "" {
+			from = "@" + user.Handle
+		}
+	}
+
+	switch n.Type {
+	case inbox.TaskDue:
+		msg.Title, msg.Body = "Due soon", n.Title
+	case inbox.FriendRequest:
+		msg.Title, msg.Body = "New friend request", from+" wants to be your friend"
+	case inbox.FriendAccepted:
+		msg.Title, msg.Body = "Friend request accepted", from+" accepted your friend request"
+	case inbox.FriendCompleted:
+		msg.Title, msg.Body = from+" completed a task", n.Title
+	case inbox.TaskComment:
+		msg.Title, msg.Body = from+" commented", "on "+n.Title
+	case inbox.CommentMention:
+		msg.Title, msg.Body = from+" mentioned you", "in a comment on "+n.Title
+	case inbox.ActivityReaction:
+		msg.Title, msg.Body = from+" reacted "+n.Emoji, n.Title
+	case inbox.ExportReady:
+		msg.Title, msg.Body = "Your data export is ready", "Download it before the link expires"
+	default:
+		msg.Title = n.Title
+	}
+	return msg, nil
+}
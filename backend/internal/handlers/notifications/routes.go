@@ -0,0 +1,34 @@
+package notifications
+
+import (
+	inbox "github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Notifications := apiV1.Group("/notifications", authenticate)
+	Notifications.Get("/", handler.ListNotifications)
+	Notifications.Post("/devices", handler.RegisterDevice)
+	Notifications.Delete("/devices/:token", handler.UnregisterDevice)
+	Notifications.Get("/preferences", handler.GetPreferences)
+	Notifications.Patch("/preferences", handler.UpdatePreferences)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/notifications/":                  {Summary: "The caller's notifications, newest first", Auth: true, Query: xpage.Query{}, Response: xpage.Page[inbox.Notification]{}},
+		"POST /api/v1/notifications/devices":          {Summary: "Register a device for push notifications", Auth: true, Request: RegisterDeviceParams{}, Response: inbox.Device{}, Status: fiber.StatusCreated},
+		"DELETE /api/v1/notifications/devices/:token": {Summary: "Stop pushing to a device", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/notifications/preferences":       {Summary: "Which notifications the caller gets pushed", Auth: true, Response: inbox.Preferences{}},
+		"PATCH /api/v1/notifications/preferences":     {Summary: "Change which notifications the caller gets pushed", Auth: true, Request: UpdatePreferencesParams{}, Response: inbox.Preferences{}},
+	})
+}
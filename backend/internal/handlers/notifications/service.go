@@ -0,0 +1,120 @@
+package notifications
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	inbox "github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newService receives the map of collections and picks out Users, Notifications, Devices and Jobs
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{
+		users:         collections["users"],
+		notifications: collections[inbox.Collection],
+		devices:       collections[inbox.DeviceCollection],
+		queue:         jobs.New(collections[jobs.Collection]),
+	}
+}
+
+// List returns a page of the user's notifications, newest first
+func (s *Service) List(ctx context.Context, userID primitive.ObjectID, query xpage.Query) (*xpage.Page[inbox.Notification], error) {
+	filter := bson.M{"user": userID}
+	if query.Cursor != "" {
+		var at time.Time
+		id, err := xpage.Decode(query.Cursor, &at)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(filter, xpage.After("created_at", -1, at, id))
+	}
+	size := query.Size(defaultLimit)
+	cursor, err := s.notifications.Find(ctx, filter,
+		options.Find().SetSort(xpage.Sort("created_at", -1)).SetLimit(int64(size+1)))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]inbox.Notification, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return xpage.New(results, size, func(n inbox.Notification) (any, primitive.ObjectID) {
+		return n.CreatedAt, n.ID
+	}), nil
+}
+
+// RegisterDevice adds the device, or moves it to the user when another account had signed in on it
+func (s *Service) RegisterDevice(ctx context.Context, userID primitive.ObjectID, params RegisterDeviceParams) (*inbox.Device, error) {
+	now := time.Now()
+	var device inbox.Device
+	err := s.devices.FindOneAndUpdate(ctx,
+		bson.M{"token": params.Token},
+		bson.M{
+			"$set":         bson.M{"user": userID, "platform": params.Platform, "last_seen_at": now},
+			"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&device)
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// UnregisterDevice stops pushes to the user's device with token
+func (s *Service) UnregisterDevice(ctx context.Context, userID primitive.ObjectID, token string) error {
+	result, err := s.devices.DeleteOne(ctx, bson.M{"user": userID, "token": token})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func (s *Service) Preferences(ctx context.Context, userID primitive.ObjectID) (inbox.Preferences, error) {
+	var user userPreferences
+	err := s.users.FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"notification_preferences": 1})).Decode(&user)
+	if err != nil {
+		return inbox.Preferences{}, err
+	}
+	return user.Preferences.Resolved(), nil
+}
+
+// UpdatePreferences changes the switches params sets and returns the result
+func (s *Service) UpdatePreferences(ctx context.Context, userID primitive.ObjectID, params UpdatePreferencesParams) (inbox.Preferences, error) {
+	set := bson.M{}
+	for field, value := range map[string]*bool{
+		"push":            params.Push,
+		"friend_requests": params.FriendRequests,
+		"friend_activity": params.FriendActivity,
+		"reminders":       params.Reminders,
+		"comments":        params.Comments,
+		"reactions":       params.Reactions,
+	} {
+		if value != nil {
+			set["notification_preferences."+field] = *value
+		}
+	}
+	if len(set) == 0 {
+		return s.Preferences(ctx, userID)
+	}
+
+	var user userPreferences
+	err := s.users.FindOneAndUpdate(ctx, bson.M{"_id": userID}, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetProjection(bson.M{"notification_preferences": 1}).SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return inbox.Preferences{}, err
+	}
+	return user.Preferences.Resolved(), nil
+}
@@ -0,0 +1,62 @@
+package notifications
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	inbox "github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/xpush"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+const (
+	PushJob = "notifications.push"
+	// telling the owner's friends about a task they completed
+	FanOutJob = "notifications.fan_out"
+
+	defaultLimit = 20
+)
+
+type PushPayload struct {
+	Notification primitive.ObjectID `bson:"notification"`
+	Device       primitive.ObjectID `bson:"device"`
+}
+
+type FanOutPayload struct {
+	Owner       primitive.ObjectID `bson:"owner"`
+	Task        primitive.ObjectID `bson:"task"`
+	CompletedAt time.Time          `bson:"completed_at"`
+}
+
+type RegisterDeviceParams struct {
+	Token    string         `validate:"required,max=4096" json:"token"`
+	Platform xpush.Platform `validate:"required,oneof=ios android" json:"platform"`
+}
+
+// UpdatePreferencesParams changes the switches it sets
+type UpdatePreferencesParams struct {
+	Push           *bool `json:"push"`
+	FriendRequests *bool `json:"friend_requests"`
+	FriendActivity *bool `json:"friend_activity"`
+	Reminders      *bool `json:"reminders"`
+	Comments       *bool `json:"comments"`
+	Reactions      *bool `json:"reactions"`
+}
+
+/*
+Notifications Service to be used by Notifications Handler to interact with the
+Database layer of the application
+*/
+
+type Service struct {
+	users         *mongo.Collection
+	notifications *mongo.Collection
+	devices       *mongo.Collection
+	queue         *jobs.Queue
+	// only set for the push job
+	pusher *xpush.Pusher
+}
+
+type userPreferences struct {
+	Preferences inbox.Preferences `bson:"notification_preferences"`
+}
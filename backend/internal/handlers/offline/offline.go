@@ -0,0 +1,98 @@
+package offline
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=offline.go -destination=mock_test.go -package=offline
+
+// Syncer applies and reads back offline changes
+type Syncer interface {
+	Pull(ctx context.Context, userID primitive.ObjectID, token string) (*Changes, error)
+	Push(ctx context.Context, userID primitive.ObjectID, mutations []Mutation) (*PushResult, error)
+}
+
+var _ Syncer = (*Service)(nil)
+
+/*
+Handler to execute business logic for offline sync
+*/
+type Handler struct {
+	service Syncer
+}
+
+/*
+Pull returns the changes since ?since= (the token from the previous pull or
+push). Without a token the whole dataset is returned with reset set.
+*/
+func (h *Handler) Pull(c *fiber.Ctx) error {
+	id, _ := c.Locals("user_id").(string)
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user",
+		})
+	}
+
+	changes, err := h.service.Pull(c.UserContext(), userID, c.Query("since"))
+	if errors.Is(err, ErrInvalidToken) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(changes)
+}
+
+/*
+Push applies the client's queued offline mutations and reports a result for
+each one, including the server copy to keep when there was a conflict.
+*/
+func (h *Handler) Push(c *fiber.Ctx) error {
+	id, _ := c.Locals("user_id").(string)
+	userID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid user",
+		})
+	}
+
+	var params PushParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if errs := validator.Validate(params); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	result, err := h.service.Push(c.UserContext(), userID, params.Mutations)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
@@ -0,0 +1,80 @@
+package offline
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPull(t *testing.T) {
+	userID := primitive.NewObjectID()
+	token := encodeToken(time.Now())
+	service := NewMockSyncer(gomock.NewController(t))
+	service.EXPECT().Pull(gomock.Any(), userID, "").Return(&Changes{Token: token, Reset: true}, nil)
+	service.EXPECT().Pull(gomock.Any(), userID, token).Return(&Changes{Token: token}, nil)
+	service.EXPECT().Pull(gomock.Any(), userID, "nope").Return(nil, ErrInvalidToken)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Get("/sync", handler.Pull)
+
+	tests := []struct {
+		name         string
+		route        string
+		expectedCode int
+	}{
+		{"everything", "/sync", fiber.StatusOK},
+		{"since a token", "/sync?since=" + token, fiber.StatusOK},
+		{"invalid token", "/sync?since=nope", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, tt.route, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expectedCode {
+			body, _ := io.ReadAll(res.Body)
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.expectedCode, res.StatusCode, body)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	since := time.UnixMilli(1_700_000_000_000)
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		expected time.Time
+	}{
+		{"clock ahead", since.Add(time.Minute), since.Add(time.Minute)},
+		{"same instant", since, since},
+		{"clock behind the issuer", since.Add(-time.Second), since},
+	}
+	for _, tt := range tests {
+		token := encodeToken(nextToken(since, tt.now))
+		got, err := decodeToken(token)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !got.Equal(tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+
+	for _, token := range []string{"!", "LTE", "YWJj"} {
+		if _, err := decodeToken(token); err != ErrInvalidToken {
+			t.Errorf("%q: expected ErrInvalidToken, got %v", token, err)
+		}
+	}
+}
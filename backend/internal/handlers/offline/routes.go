@@ -0,0 +1,27 @@
+package offline
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler) {
+	service := newService(collections, cache)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Sync := apiV1.Group("/sync", authenticate)
+	Sync.Get("/", handler.Pull)
+	Sync.Post("/", handler.Push)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/sync/":  {Summary: "Changes since the sync token, or everything without one", Auth: true, Query: xopenapi.Query{"since"}, Response: Changes{}},
+		"POST /api/v1/sync/": {Summary: "Apply mutations made offline", Auth: true, Request: PushParams{}, Response: PushResult{}},
+	})
+}
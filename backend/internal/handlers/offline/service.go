@@ -0,0 +1,567 @@
+package offline
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/profile"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/tombstone"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/xutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Writes are stamped with the server clock when they happen but become visible
+when they commit, so a pull re-sends anything changed within overlap of the
+client's token. Clients apply changes by id, so the repeats are harmless.
+*/
+const overlap = 5 * time.Second
+
+var ErrInvalidToken = errors.New("invalid sync token")
+
+// newService receives the map of collections and picks out Jobs
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache) *Service {
+	return &Service{
+		Users:      collections["users"],
+		Tombstones: collections[tombstone.Collection],
+		cache:      cache,
+	}
+}
+
+// Pull returns everything that changed for the user since the given token
+func (s *Service) Pull(ctx context.Context, userID primitive.ObjectID, token string) (*Changes, error) {
+	since, err := decodeToken(token)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	// deletes older than the tombstone retention are gone, start over
+	reset := since.IsZero() || now.Sub(since) > tombstone.Retention
+	cutoff := since.Add(-overlap)
+	if reset {
+		cutoff = time.Time{}
+	}
+
+	state, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &Changes{
+		Token:      encodeToken(nextToken(since, now)),
+		Reset:      reset,
+		Categories: make([]CategoryChange, 0),
+		Tasks:      make([]TaskChange, 0),
+		Deleted:    make([]tombstone.Tombstone, 0),
+	}
+	if reset || state.Profile.UpdatedAt.After(cutoff) {
+		me := state.Profile
+		me.Privacy = me.Privacy.Resolved()
+		changes.Profile = &me
+	}
+	for _, c := range state.Categories {
+		if c.LastEdited.After(cutoff) {
+			changes.Categories = append(changes.Categories, toCategoryChange(c))
+		}
+		for _, t := range c.Tasks {
+			if taskUpdatedAt(t).After(cutoff) {
+				changes.Tasks = append(changes.Tasks, TaskChange{t, c.ID})
+			}
+		}
+	}
+	if state.Preferences != nil && state.Preferences.UpdatedAt.After(cutoff) {
+		changes.Preferences = state.Preferences
+	}
+	if !reset {
+		changes.Deleted, err = tombstone.Since(ctx, s.Tombstones, userID, cutoff)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changes, nil
+}
+
+/*
+Push applies a batch of queued client mutations in order. Each mutation is
+resolved on its own; a conflict on one doesn't stop the rest of the batch.
+*/
+func (s *Service) Push(ctx context.Context, userID primitive.ObjectID, mutations []Mutation) (*PushResult, error) {
+	state, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]MutationResult, 0, len(mutations))
+	wrote := false
+	for _, m := range mutations {
+		if m.Strategy == "" {
+			m.Strategy = ServerWins
+		}
+		result, changed, err := s.apply(ctx, userID, state, m)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			wrote = true
+			if state, err = s.load(ctx, userID); err != nil {
+				return nil, err
+			}
+		}
+		if result.Status != Rejected && m.Op != "delete" {
+			result.Server = lookup(state, m.Entity, result.ID)
+		}
+		results = append(results, result)
+	}
+
+	if wrote {
+		xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(userID.Hex()))
+		slog.LogAttrs(ctx, slog.LevelInfo, "Offline mutations applied",
+			slog.String("user_id", userID.Hex()),
+			slog.Int("count", len(mutations)),
+		)
+	}
+
+	return &PushResult{Token: encodeToken(time.Now()), Results: results}, nil
+}
+
+func (s *Service) apply(ctx context.Context, userID primitive.ObjectID, state *userState, m Mutation) (MutationResult, bool, error) {
+	result := MutationResult{ClientID: m.ClientID, Entity: m.Entity, ID: m.ID}
+	reject := func(reason string) (MutationResult, bool, error) {
+		result.Status = Rejected
+		result.Error = reason
+		return result, false, nil
+	}
+
+	if m.Entity == PreferencesEntity {
+		if m.Op == "delete" {
+			return reject("preferences can't be deleted")
+		}
+		return s.applyPreferences(ctx, userID, state, m, result)
+	}
+
+	id, err := primitive.ObjectIDFromHex(m.ID)
+	if err != nil {
+		return reject("invalid id")
+	}
+
+	switch m.Entity {
+	case CategoryEntity:
+		return s.applyCategory(ctx, userID, state, m, id, result)
+	case TaskEntity:
+		return s.applyTask(ctx, userID, state, m, id, result)
+	}
+	return reject("unknown entity")
+}
+
+func (s *Service) applyCategory(ctx context.Context, userID primitive.ObjectID, state *userState, m Mutation, id primitive.ObjectID, result MutationResult) (MutationResult, bool, error) {
+	existing := findCategory(state, id)
+	now := time.Now()
+
+	if m.Op == "delete" {
+		if existing == nil {
+			result.Status = Applied
+			return result, false, nil
+		}
+		if conflicts(existing.LastEdited, m.BaseUpdatedAt) && m.Strategy != ClientWins {
+			result.Status = Conflict
+			result.Server = toCategoryChange(*existing)
+			return result, false, nil
+		}
+		if _, err := s.Users.UpdateOne(ctx, bson.M{"_id": userID},
+			bson.M{"$set": bson.M{"categories.$[c]." + softdelete.Field: now}},
+			options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{bson.M{"c._id": id}},
+			}),
+		); err != nil {
+			return result, false, err
+		}
+		if err := tombstone.Record(ctx, s.Tombstones, userID, tombstone.Category, id); err != nil {
+			return result, false, err
+		}
+		result.Status = Applied
+		return result, true, nil
+	}
+
+	if existing == nil {
+		var patch categoryPatch
+		if err := decodePatch(m.Data, &patch); err != nil || patch.Name == nil {
+			result.Status, result.Error = Rejected, "name is required"
+			return result, false, nil
+		}
+		doc := category.CategoryDocument{
+			ID:         id,
+			Name:       *patch.Name,
+			LastEdited: now,
+			Tasks:      []task.TaskDocument{},
+			User:       userID,
+		}
+		// the $ne guard makes a retried create a no-op
+		if _, err := s.Users.UpdateOne(ctx,
+			bson.M{"_id": userID, "categories._id": bson.M{"$ne": id}},
+			bson.M{"$push": bson.M{"categories": doc}},
+		); err != nil {
+			return result, false, err
+		}
+		result.Status = Applied
+		return result, true, nil
+	}
+
+	fields, status := resolve(m, toCategoryChange(*existing), existing.LastEdited)
+	result.Status = status
+	if len(fields) == 0 {
+		return result, false, nil
+	}
+	var patch categoryPatch
+	if err := decodePatch(fields, &patch); err != nil {
+		result.Status, result.Error = Rejected, err.Error()
+		return result, false, nil
+	}
+	set, err := setFields("categories.$[c].", patch)
+	if err != nil {
+		return result, false, err
+	}
+	set["categories.$[c].lastEdited"] = now
+	// keeps versioned writes from the REST API honest about sync edits
+	inc := bson.M{"categories.$[c]." + xmongo.VersionField: 1}
+	_, err = s.Users.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": set, "$inc": inc},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"c._id": id}},
+		}),
+	)
+	return result, err == nil, err
+}
+
+/*
+Tasks are located by id across all categories. category_id is only used to
+place new tasks; moving a task between categories isn't synced.
+*/
+func (s *Service) applyTask(ctx context.Context, userID primitive.ObjectID, state *userState, m Mutation, id primitive.ObjectID, result MutationResult) (MutationResult, bool, error) {
+	existing, categoryID := findTask(state, id)
+	now := time.Now()
+
+	if m.Op == "delete" {
+		if existing == nil {
+			result.Status = Applied
+			return result, false, nil
+		}
+		if conflicts(taskUpdatedAt(*existing), m.BaseUpdatedAt) && m.Strategy != ClientWins {
+			result.Status = Conflict
+			result.Server = TaskChange{*existing, categoryID}
+			return result, false, nil
+		}
+		if _, err := s.Users.UpdateOne(ctx,
+			bson.M{"_id": userID},
+			bson.M{"$set": bson.M{"categories.$[c].tasks.$[t]." + softdelete.Field: now}},
+			options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{bson.M{"c._id": categoryID}, bson.M{"t._id": id}},
+			}),
+		); err != nil {
+			return result, false, err
+		}
+		if err := tombstone.Record(ctx, s.Tombstones, userID, tombstone.Task, id); err != nil {
+			return result, false, err
+		}
+		result.Status = Applied
+		return result, true, nil
+	}
+
+	if existing == nil {
+		categoryID, err := primitive.ObjectIDFromHex(m.CategoryID)
+		if err != nil || findCategory(state, categoryID) == nil {
+			result.Status, result.Error = Rejected, "category not found"
+			return result, false, nil
+		}
+		var patch taskPatch
+		if err := decodePatch(m.Data, &patch); err != nil || patch.Content == nil {
+			result.Status, result.Error = Rejected, "content is required"
+			return result, false, nil
+		}
+		doc := task.TaskDocument{ID: id, Timestamp: now, UpdatedAt: now}
+		patch.applyTo(&doc)
+		if _, err := s.Users.UpdateOne(ctx,
+			bson.M{"_id": userID, "categories.tasks._id": bson.M{"$ne": id}},
+			bson.M{"$push": bson.M{"categories.$[c].tasks": doc}},
+			options.Update().SetArrayFilters(options.ArrayFilters{
+				Filters: []interface{}{bson.M{"c._id": categoryID}},
+			}),
+		); err != nil {
+			return result, false, err
+		}
+		result.Status = Applied
+		return result, true, nil
+	}
+
+	fields, status := resolve(m, existing, taskUpdatedAt(*existing))
+	result.Status = status
+	if len(fields) == 0 {
+		return result, false, nil
+	}
+	var patch taskPatch
+	if err := decodePatch(fields, &patch); err != nil {
+		result.Status, result.Error = Rejected, err.Error()
+		return result, false, nil
+	}
+	set, err := setFields("categories.$[c].tasks.$[t].", patch)
+	if err != nil {
+		return result, false, err
+	}
+	set["categories.$[c].tasks.$[t].updated_at"] = now
+	inc := bson.M{"categories.$[c].tasks.$[t]." + xmongo.VersionField: 1}
+	_, err = s.Users.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": set, "$inc": inc},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"c._id": categoryID}, bson.M{"t._id": id}},
+		}),
+	)
+	return result, err == nil, err
+}
+
+// Preferences resolve per key, there is no whole-document conflict
+func (s *Service) applyPreferences(ctx context.Context, userID primitive.ObjectID, state *userState, m Mutation, result MutationResult) (MutationResult, bool, error) {
+	server := &Preferences{Data: map[string]interface{}{}}
+	if state.Preferences != nil {
+		server = state.Preferences
+	}
+	for key := range m.Data {
+		if key == "" || strings.ContainsAny(key, ".$") {
+			result.Status, result.Error = Rejected, fmt.Sprintf("invalid preference key %q", key)
+			return result, false, nil
+		}
+	}
+
+	fields, status := resolve(m, server.Data, server.UpdatedAt)
+	result.Status = status
+	if len(fields) == 0 {
+		return result, false, nil
+	}
+	set := bson.M{"preferences.updated_at": time.Now()}
+	for key, value := range fields {
+		set["preferences.data."+key] = value
+	}
+	_, err := s.Users.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": set})
+	return result, err == nil, err
+}
+
+/*
+resolve picks which of the client's fields to write. A mutation conflicts when
+the server copy changed after the version the client based its edit on.
+*/
+func resolve(m Mutation, server interface{}, serverUpdatedAt time.Time) (map[string]interface{}, Status) {
+	if !conflicts(serverUpdatedAt, m.BaseUpdatedAt) || m.Strategy == ClientWins {
+		return m.Data, Applied
+	}
+	if m.Strategy == ServerWins {
+		return nil, Conflict
+	}
+
+	// three-way merge: take the client's value only where the server still
+	// has the value the client started from
+	current, _ := normalize(server).(map[string]interface{})
+	fields := make(map[string]interface{})
+	for key, value := range m.Data {
+		base, ok := m.Base[key]
+		if ok && reflect.DeepEqual(normalize(base), normalize(current[key])) {
+			fields[key] = value
+		}
+	}
+	return fields, Merged
+}
+
+func conflicts(serverUpdatedAt time.Time, base *time.Time) bool {
+	if base == nil {
+		return true
+	}
+	// mongo stores milliseconds
+	return serverUpdatedAt.Truncate(time.Millisecond).After(base.Truncate(time.Millisecond))
+}
+
+func (s *Service) load(ctx context.Context, userID primitive.ObjectID) (*userState, error) {
+	projection := maps.Clone(profile.Projection)
+	projection["categories"] = 1
+	projection["preferences"] = 1
+	var state userState
+	err := s.Users.FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(projection),
+	).Decode(&state)
+	if err != nil {
+		return nil, err
+	}
+	// deleted entries stay in the document until the purge, clients only hear about them through tombstones
+	state.Categories = category.Visible(state.Categories)
+	return &state, nil
+}
+
+func lookup(state *userState, entity Entity, id string) interface{} {
+	if entity == PreferencesEntity {
+		return state.Preferences
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+	switch entity {
+	case CategoryEntity:
+		if c := findCategory(state, oid); c != nil {
+			return toCategoryChange(*c)
+		}
+	case TaskEntity:
+		if t, categoryID := findTask(state, oid); t != nil {
+			return TaskChange{*t, categoryID}
+		}
+	}
+	return nil
+}
+
+func findCategory(state *userState, id primitive.ObjectID) *category.CategoryDocument {
+	for i := range state.Categories {
+		if state.Categories[i].ID == id {
+			return &state.Categories[i]
+		}
+	}
+	return nil
+}
+
+func findTask(state *userState, id primitive.ObjectID) (*task.TaskDocument, primitive.ObjectID) {
+	for i := range state.Categories {
+		for j := range state.Categories[i].Tasks {
+			if state.Categories[i].Tasks[j].ID == id {
+				return &state.Categories[i].Tasks[j], state.Categories[i].ID
+			}
+		}
+	}
+	return nil, primitive.NilObjectID
+}
+
+func toCategoryChange(c category.CategoryDocument) CategoryChange {
+	return CategoryChange{ID: c.ID, Name: c.Name, LastEdited: c.LastEdited}
+}
+
+// tasks written before updated_at existed fall back to their creation time
+func taskUpdatedAt(t task.TaskDocument) time.Time {
+	if t.UpdatedAt.IsZero() {
+		return t.Timestamp
+	}
+	return t.UpdatedAt
+}
+
+type categoryPatch struct {
+	Name *string `bson:"name,omitempty" json:"name"`
+}
+
+type taskPatch struct {
+	Priority     *int                    `bson:"priority,omitempty" json:"priority"`
+	Content      *string                 `bson:"content,omitempty" json:"content"`
+	Value        *float64                `bson:"value,omitempty" json:"value"`
+	Recurring    *bool                   `bson:"recurring,omitempty" json:"recurring"`
+	RecurDetails *map[string]interface{} `bson:"recurDetails,omitempty" json:"recurDetails"`
+	Public       *bool                   `bson:"public,omitempty" json:"public"`
+	Active       *bool                   `bson:"active,omitempty" json:"active"`
+}
+
+func (p taskPatch) applyTo(t *task.TaskDocument) {
+	if p.Priority != nil {
+		t.Priority = *p.Priority
+	}
+	if p.Content != nil {
+		t.Content = *p.Content
+	}
+	if p.Value != nil {
+		t.Value = *p.Value
+	}
+	if p.Recurring != nil {
+		t.Recurring = *p.Recurring
+	}
+	if p.RecurDetails != nil {
+		t.RecurDetails = *p.RecurDetails
+	}
+	if p.Public != nil {
+		t.Public = *p.Public
+	}
+	if p.Active != nil {
+		t.Active = *p.Active
+	}
+}
+
+// decodePatch type-checks client fields by round-tripping them through JSON
+func decodePatch(fields map[string]interface{}, patch interface{}) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, patch)
+}
+
+func setFields(prefix string, patch interface{}) (bson.M, error) {
+	doc, err := xutils.ToDoc(patch)
+	if err != nil {
+		return nil, err
+	}
+	set := bson.M{}
+	if doc == nil {
+		return set, nil
+	}
+	for _, e := range *doc {
+		set[prefix+e.Key] = e.Value
+	}
+	return set, nil
+}
+
+// normalize converts a value to its generic JSON shape so server and client copies compare
+func normalize(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out interface{}
+	_ = json.Unmarshal(data, &out)
+	return out
+}
+
+/*
+nextToken is the token to hand back to a client that pulled with since.
+Tokens only move forward: an instance whose clock runs behind the one that
+issued since returns since again rather than an earlier token.
+*/
+func nextToken(since time.Time, now time.Time) time.Time {
+	if now.Before(since) {
+		return since
+	}
+	return now
+}
+
+func encodeToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixMilli(), 10)))
+}
+
+func decodeToken(token string) (time.Time, error) {
+	if token == "" {
+		return time.Time{}, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, ErrInvalidToken
+	}
+	millis, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || millis <= 0 {
+		return time.Time{}, ErrInvalidToken
+	}
+	return time.UnixMilli(millis), nil
+}
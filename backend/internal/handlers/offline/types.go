@@ -0,0 +1,123 @@
+package offline
+
+import (
+	"time"
+
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/profile"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/tombstone"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Entity string
+
+const (
+	CategoryEntity    Entity = "category"
+	TaskEntity        Entity = "task"
+	PreferencesEntity Entity = "preferences"
+)
+
+type Strategy string
+
+const (
+	// ServerWins drops the client's change and returns the server copy
+	ServerWins Strategy = "server-wins"
+	// ClientWins overwrites the server copy with every field the client sent
+	ClientWins Strategy = "client-wins"
+	// Merge keeps server-side changes to fields the client didn't also change
+	Merge Strategy = "merge"
+)
+
+type Status string
+
+const (
+	Applied  Status = "applied"
+	Merged   Status = "merged"
+	Conflict Status = "conflict"
+	Rejected Status = "rejected"
+)
+
+// Preferences is a free-form per-user settings map
+type Preferences struct {
+	Data      map[string]interface{} `bson:"data" json:"data"`
+	UpdatedAt time.Time              `bson:"updated_at" json:"updated_at"`
+}
+
+type CategoryChange struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	LastEdited time.Time          `bson:"lastEdited" json:"lastEdited"`
+}
+
+type TaskChange struct {
+	task.TaskDocument `bson:",inline"`
+	CategoryID        primitive.ObjectID `bson:"category" json:"category_id"`
+}
+
+type Changes struct {
+	// Token is passed back as ?since= on the next pull
+	Token string `json:"token"`
+	// Reset means the client's token is too old to diff against; it should
+	// replace its local state with this response
+	Reset       bool                  `json:"reset"`
+	Profile     *profile.Me           `json:"profile,omitempty"`
+	Categories  []CategoryChange      `json:"categories"`
+	Tasks       []TaskChange          `json:"tasks"`
+	Preferences *Preferences          `json:"preferences,omitempty"`
+	Deleted     []tombstone.Tombstone `json:"deleted"`
+}
+
+type Mutation struct {
+	// ClientID is echoed back so the client can match results to its queue
+	ClientID   string   `json:"client_id"`
+	Entity     Entity   `validate:"required,oneof=category task preferences" json:"entity"`
+	Op         string   `validate:"required,oneof=upsert delete" json:"op"`
+	ID         string   `json:"id"`
+	CategoryID string   `json:"category_id"`
+	Strategy   Strategy `validate:"omitempty,oneof=server-wins client-wins merge" json:"strategy"`
+	// BaseUpdatedAt is the server updated_at the client last saw for this entity
+	BaseUpdatedAt *time.Time `json:"base_updated_at"`
+	// Data holds the changed fields, Base their values as of BaseUpdatedAt
+	Data map[string]interface{} `json:"data"`
+	Base map[string]interface{} `json:"base"`
+}
+
+type PushParams struct {
+	Mutations []Mutation `validate:"required,max=100,dive" json:"mutations"`
+}
+
+type MutationResult struct {
+	ClientID string `json:"client_id,omitempty"`
+	Entity   Entity `json:"entity"`
+	ID       string `json:"id,omitempty"`
+	Status   Status `json:"status"`
+	Error    string `json:"error,omitempty"`
+	// Server is the authoritative copy after the mutation was resolved
+	Server interface{} `json:"server,omitempty"`
+}
+
+type PushResult struct {
+	Token   string           `json:"token"`
+	Results []MutationResult `json:"results"`
+}
+
+// userState is the slice of the user document sync cares about
+type userState struct {
+	Profile     profile.Me                  `bson:",inline"`
+	Categories  []category.CategoryDocument `bson:"categories"`
+	Preferences *Preferences                `bson:"preferences"`
+}
+
+/*
+Offline Service to be used by Offline Handler to interact with the
+Database layer of the application
+*/
+
+type Service struct {
+	Users      *mongo.Collection
+	Tombstones *mongo.Collection
+	cache      xcache.Cache
+}
@@ -1,6 +1,7 @@
 package Post
 
 import (
+	"context"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -8,8 +9,21 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+//go:generate mockgen -source=post.go -destination=mock_test.go -package=Post
+
+// Posts is the post service as Handler uses it
+type Posts interface {
+	GetAllPosts(ctx context.Context) ([]PostDocument, error)
+	GetPostByID(ctx context.Context, id primitive.ObjectID) (*PostDocument, error)
+	CreatePost(ctx context.Context, r *PostDocument) (*PostDocument, error)
+	UpdatePartialPost(ctx context.Context, id primitive.ObjectID, updated UpdatePostDocument) error
+	DeletePost(ctx context.Context, id primitive.ObjectID) error
+}
+
+var _ Posts = (*Service)(nil)
+
 type Handler struct {
-	service *Service
+	service Posts
 }
 
 func (h *Handler) CreatePost(c *fiber.Ctx) error {
@@ -35,7 +49,7 @@ func (h *Handler) CreatePost(c *fiber.Ctx) error {
 		Timestamp: time.Now(),
 	}
 
-	_, err := h.service.CreatePost(&doc)
+	_, err := h.service.CreatePost(c.UserContext(), &doc)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create Post",
@@ -46,7 +60,7 @@ func (h *Handler) CreatePost(c *fiber.Ctx) error {
 }
 
 func (h *Handler) GetPosts(c *fiber.Ctx) error {
-	Posts, err := h.service.GetAllPosts()
+	Posts, err := h.service.GetAllPosts(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch Posts",
@@ -64,7 +78,7 @@ func (h *Handler) GetPost(c *fiber.Ctx) error {
 		})
 	}
 
-	Post, err := h.service.GetPostByID(id)
+	Post, err := h.service.GetPostByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Post not found",
@@ -89,7 +103,7 @@ func (h *Handler) UpdatePartialPost(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.UpdatePartialPost(id, update); err != nil {
+	if err := h.service.UpdatePartialPost(c.UserContext(), id, update); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update Post",
 		})
@@ -106,7 +120,7 @@ func (h *Handler) DeletePost(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.DeletePost(id); err != nil {
+	if err := h.service.DeletePost(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete Post",
 		})
@@ -1,6 +1,7 @@
 package Post
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -8,7 +9,7 @@ import (
 /*
 Router maps endpoints to handlers
 */
-func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler, verified fiber.Handler) {
 	service := newService(collections)
 	handler := Handler{service}
 
@@ -18,10 +19,18 @@ func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
 	// Add Sample group under API Version 1
 	Posts := apiV1.Group("/Posts")
 
-	Posts.Post("/", handler.CreatePost)
+	// posts are public, only users with a verified email can make them
+	Posts.Post("/", authenticate, verified, handler.CreatePost)
 	Posts.Get("/", handler.GetPosts)
 	Posts.Get("/:id", handler.GetPost)
 	Posts.Patch("/:id", handler.UpdatePartialPost)
 	Posts.Delete("/:id", handler.DeletePost)
 
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/Posts/":      {Summary: "Create a post", Auth: true, Request: CreatePostParams{}, Response: PostDocument{}, Status: fiber.StatusCreated},
+		"GET /api/v1/Posts/":       {Summary: "List every post", Response: []PostDocument{}},
+		"GET /api/v1/Posts/:id":    {Summary: "Get a post", Response: PostDocument{}},
+		"PATCH /api/v1/Posts/:id":  {Summary: "Update a post", Request: UpdatePostDocument{}},
+		"DELETE /api/v1/Posts/:id": {Summary: "Delete a post"},
+	})
 }
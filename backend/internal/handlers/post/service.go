@@ -18,8 +18,7 @@ func newService(collections map[string]*mongo.Collection) *Service {
 }
 
 // GetAllPosts fetches all Post documents from MongoDB
-func (s *Service) GetAllPosts() ([]PostDocument, error) {
-	ctx := context.Background()
+func (s *Service) GetAllPosts(ctx context.Context) ([]PostDocument, error) {
 	cursor, err := s.Posts.Find(ctx, bson.M{})
 	if err != nil {
 		return nil, err
@@ -35,8 +34,7 @@ func (s *Service) GetAllPosts() ([]PostDocument, error) {
 }
 
 // GetPostByID returns a single Post document by its ObjectID
-func (s *Service) GetPostByID(id primitive.ObjectID) (*PostDocument, error) {
-	ctx := context.Background()
+func (s *Service) GetPostByID(ctx context.Context, id primitive.ObjectID) (*PostDocument, error) {
 	filter := bson.M{"_id": id}
 
 	var Post PostDocument
@@ -54,8 +52,7 @@ func (s *Service) GetPostByID(id primitive.ObjectID) (*PostDocument, error) {
 }
 
 // InsertPost adds a new Post document
-func (s *Service) CreatePost(r *PostDocument) (*PostDocument, error) {
-	ctx := context.Background()
+func (s *Service) CreatePost(ctx context.Context, r *PostDocument) (*PostDocument, error) {
 	// Insert the document into the collection
 
 	result, err := s.Posts.InsertOne(ctx, r)
@@ -72,8 +69,7 @@ func (s *Service) CreatePost(r *PostDocument) (*PostDocument, error) {
 }
 
 // UpdatePartialPost updates only specified fields of a Post document by ObjectID.
-func (s *Service) UpdatePartialPost(id primitive.ObjectID, updated UpdatePostDocument) error {
-	ctx := context.Background()
+func (s *Service) UpdatePartialPost(ctx context.Context, id primitive.ObjectID, updated UpdatePostDocument) error {
 	filter := bson.M{"_id": id}
 
 	updateFields, err := xutils.ToDoc(updated)
@@ -88,8 +84,7 @@ func (s *Service) UpdatePartialPost(id primitive.ObjectID, updated UpdatePostDoc
 }
 
 // DeletePost removes a Post document by ObjectID.
-func (s *Service) DeletePost(id primitive.ObjectID) error {
-	ctx := context.Background()
+func (s *Service) DeletePost(ctx context.Context, id primitive.ObjectID) error {
 
 	filter := bson.M{"_id": id}
 
@@ -0,0 +1,94 @@
+package profile
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/handles"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=profile.go -destination=mock_test.go -package=profile
+
+// Profiles is the profile service as Handler uses it
+type Profiles interface {
+	Me(ctx context.Context, id primitive.ObjectID) (*Me, error)
+	Update(ctx context.Context, id primitive.ObjectID, params UpdateProfileParams) (*Me, error)
+	Public(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) (*PublicProfile, error)
+}
+
+var _ Profiles = (*Service)(nil)
+
+type Handler struct {
+	service Profiles
+}
+
+func (h *Handler) GetMe(c *fiber.Ctx) error {
+	me, err := h.service.Me(c.UserContext(), userID(c))
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(me)
+}
+
+func (h *Handler) UpdateMe(c *fiber.Ctx) error {
+	var params UpdateProfileParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(params); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	me, err := h.service.Update(c.UserContext(), userID(c), params)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(me)
+}
+
+func (h *Handler) GetUser(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	profile, err := h.service.Public(c.UserContext(), userID(c), id)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(profile)
+}
+
+func profileError(c *fiber.Ctx, err error) error {
+	var status int
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		status = fiber.StatusNotFound
+	case errors.Is(err, handles.ErrInvalid), errors.Is(err, ErrNoChanges):
+		status = fiber.StatusBadRequest
+	case errors.Is(err, ErrHandleTaken):
+		status = fiber.StatusConflict
+	default:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load profile",
+		})
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := c.Locals("user_id").(string)
+	userID, _ := primitive.ObjectIDFromHex(id)
+	return userID
+}
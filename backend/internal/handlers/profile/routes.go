@@ -0,0 +1,28 @@
+package profile
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Users := apiV1.Group("/users", authenticate)
+	Users.Get("/me", handler.GetMe)
+	Users.Patch("/me", handler.UpdateMe)
+	Users.Get("/:id", handler.GetUser)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/users/me":   {Summary: "The caller's own profile", Auth: true, Response: Me{}},
+		"PATCH /api/v1/users/me": {Summary: "Change the caller's profile", Auth: true, Request: UpdateProfileParams{}, Response: Me{}},
+		"GET /api/v1/users/:id":  {Summary: "Another user's profile, as much as their privacy settings show the caller", Auth: true, Response: PublicProfile{}},
+	})
+}
@@ -0,0 +1,129 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handles"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newService receives the map of collections and picks out Users
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{
+		users: collections["users"],
+	}
+}
+
+// Me returns the user's own profile
+func (s *Service) Me(ctx context.Context, id primitive.ObjectID) (*Me, error) {
+	var me Me
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"_id": id}),
+		options.FindOne().SetProjection(Projection)).Decode(&me)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	me.Privacy = me.Privacy.Resolved()
+	return &me, nil
+}
+
+/*
+Update sets the fields params has and returns the result. A new handle is
+normalized and kept unique by the users_handle_unique index.
+*/
+func (s *Service) Update(ctx context.Context, id primitive.ObjectID, params UpdateProfileParams) (*Me, error) {
+	set, unset := bson.M{}, bson.M{}
+	if params.DisplayName != nil {
+		name := strings.TrimSpace(*params.DisplayName)
+		set["display_name"], set["display_name_lower"] = name, strings.ToLower(name)
+	}
+	if params.Handle != nil {
+		handle, err := handles.Normalize(*params.Handle)
+		if err != nil {
+			return nil, err
+		}
+		set["handle"] = handle
+	}
+	if params.ProfilePicture != nil {
+		set["profile_picture"] = *params.ProfilePicture
+	}
+	if params.Phone != nil {
+		if *params.Phone == "" {
+			unset["phone"] = ""
+		} else {
+			set["phone"] = *params.Phone
+		}
+	}
+	if params.TimeZone != nil {
+		set["timezone"] = *params.TimeZone
+	}
+	if len(set) == 0 && len(unset) == 0 {
+		return nil, ErrNoChanges
+	}
+	set["updated_at"] = time.Now()
+
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	var me Me
+	err := s.users.FindOneAndUpdate(ctx, softdelete.Filter(bson.M{"_id": id}), update,
+		options.FindOneAndUpdate().SetProjection(Projection).SetReturnDocument(options.After)).Decode(&me)
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrHandleTaken
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	me.Privacy = me.Privacy.Resolved()
+	return &me, nil
+}
+
+// Public returns the profile of id as the viewer may see it; one they can't see is ErrUserNotFound
+func (s *Service) Public(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) (*PublicProfile, error) {
+	projection := bson.M{"display_name": 1, "handle": 1, "profile_picture": 1, "tasks_complete": 1}
+	for field := range privacy.Projection {
+		projection[field] = 1
+	}
+	var user publicUser
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"_id": id}),
+		options.FindOne().SetProjection(projection)).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	relations, err := privacy.Load(ctx, s.users, []primitive.ObjectID{viewer})
+	if err != nil {
+		return nil, err
+	}
+	me, ok := relations[viewer]
+	if !ok || !privacy.CanView(me, user.Relations) {
+		return nil, ErrUserNotFound
+	}
+	return &PublicProfile{
+		ID:             user.ID,
+		DisplayName:    user.DisplayName,
+		Handle:         user.Handle,
+		ProfilePicture: user.ProfilePicture,
+		TasksComplete:  user.TasksComplete,
+		FriendCount:    len(user.Friends),
+		Friend:         slices.Contains(user.Friends, viewer),
+	}, nil
+}
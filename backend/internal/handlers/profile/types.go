@@ -0,0 +1,85 @@
+package profile
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrHandleTaken  = errors.New("handle is taken")
+	ErrNoChanges    = errors.New("nothing to update")
+)
+
+// Me is the signed-in user's own profile, with the contact details only they see
+type Me struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	Email          string             `bson:"email" json:"email"`
+	Phone          string             `bson:"phone" json:"phone"`
+	DisplayName    string             `bson:"display_name" json:"display_name"`
+	Handle         string             `bson:"handle" json:"handle"`
+	ProfilePicture string             `bson:"profile_picture" json:"profile_picture"`
+	TasksComplete  float64            `bson:"tasks_complete" json:"tasks_complete"`
+	Privacy        privacy.Settings   `bson:"privacy" json:"privacy"`
+	// IANA name days are counted in for stats, UTC when empty
+	TimeZone string `bson:"timezone" json:"timezone"`
+	// when any of the above last changed, zero for users who haven't edited since it was tracked
+	UpdatedAt time.Time `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// Projection loads Me, leaving out credentials and everything embedded
+var Projection = bson.M{
+	"email":           1,
+	"phone":           1,
+	"display_name":    1,
+	"handle":          1,
+	"profile_picture": 1,
+	"tasks_complete":  1,
+	"privacy":         1,
+	"timezone":        1,
+	"updated_at":      1,
+}
+
+// PublicProfile is what other users see of a profile they may view
+type PublicProfile struct {
+	ID             primitive.ObjectID `json:"id"`
+	DisplayName    string             `json:"display_name"`
+	Handle         string             `json:"handle"`
+	ProfilePicture string             `json:"profile_picture"`
+	TasksComplete  float64            `json:"tasks_complete"`
+	FriendCount    int                `json:"friend_count"`
+	// whether the viewer and the user are friends
+	Friend bool `json:"friend"`
+}
+
+type publicUser struct {
+	privacy.Relations `bson:",inline"`
+	DisplayName       string  `bson:"display_name"`
+	Handle            string  `bson:"handle"`
+	ProfilePicture    string  `bson:"profile_picture"`
+	TasksComplete     float64 `bson:"tasks_complete"`
+}
+
+// UpdateProfileParams changes the fields it sets; an empty phone removes it
+type UpdateProfileParams struct {
+	DisplayName    *string `validate:"omitempty,min=1,max=50" json:"display_name"`
+	Handle         *string `json:"handle"`
+	ProfilePicture *string `validate:"omitempty,url" json:"profile_picture"`
+	Phone          *string `validate:"omitempty,e164" json:"phone"`
+	// an IANA name such as "Europe/Berlin"
+	TimeZone *string `validate:"omitempty,timezone" json:"timezone"`
+}
+
+/*
+Profile Service to be used by Profile Handler to interact with the
+Database layer of the application
+*/
+
+type Service struct {
+	users *mongo.Collection
+}
@@ -1,6 +1,7 @@
 package s3bucket
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -18,8 +19,18 @@ type PostParams struct {
 	Filetype string
 }
 
+//go:generate mockgen -source=s3bucket.go -destination=mock_test.go -package=s3bucket
+
+// Buckets is the S3 service as Handler uses it
+type Buckets interface {
+	GetPresignedUrl(ctx context.Context, inputs *GetParams) (*DownloadUrl, error)
+	CreateUrlAndKey(ctx context.Context, inputs *PostParams) (*UploadUrl, error)
+}
+
+var _ Buckets = (*Service)(nil)
+
 type Handler struct {
-	service *Service
+	service Buckets
 	config  config.Config
 }
 
@@ -36,7 +47,7 @@ func (h *Handler) GetPresignedUrlHandler(c *fiber.Ctx) error {
 		Bucket: bucketName,
 		Key:    key,
 	}
-	url, err := h.service.GetPresignedUrl(object)
+	url, err := h.service.GetPresignedUrl(c.UserContext(), object)
 	if err != nil {
 		return err
 	}
@@ -66,7 +77,7 @@ func (h *Handler) PostPresignedUrlHandler(c *fiber.Ctx) error {
 		Filetype: fileType,
 	}
 
-	urlAndKey, err := h.service.CreateUrlAndKey(object)
+	urlAndKey, err := h.service.CreateUrlAndKey(c.UserContext(), object)
 	if err != nil {
 		return err
 	}
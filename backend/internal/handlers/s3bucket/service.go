@@ -17,9 +17,9 @@ type UploadUrl struct {
 	Key string `json:"key"`
 }
 
-func (s *Service) GetPresignedUrl(inputs *GetParams) (*DownloadUrl, error) {
+func (s *Service) GetPresignedUrl(ctx context.Context, inputs *GetParams) (*DownloadUrl, error) {
 	// generate a presigned URL
-	req, err := s.Presigner.PresignGetObject(context.Background(), &s3.GetObjectInput{
+	req, err := s.Presigner.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(inputs.Bucket),
 		Key:    aws.String(inputs.Key),
 	})
@@ -32,12 +32,12 @@ func (s *Service) GetPresignedUrl(inputs *GetParams) (*DownloadUrl, error) {
 	}, nil
 }
 
-func (s *Service) CreateUrlAndKey(inputs *PostParams) (*UploadUrl, error) {
+func (s *Service) CreateUrlAndKey(ctx context.Context, inputs *PostParams) (*UploadUrl, error) {
 	// generate uuid
 	fileUUID := uuid.New().String()
 	fileKey := fileUUID + "." + inputs.Filetype
 
-	req, err := s.Presigner.PresignPutObject(context.Background(), &s3.PutObjectInput{
+	req, err := s.Presigner.PresignPutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(inputs.Bucket),
 		Key:    aws.String(fileKey),
 	})
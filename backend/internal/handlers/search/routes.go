@@ -0,0 +1,30 @@
+package search
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xsearch"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, engine xsearch.Engine, authenticate fiber.Handler) {
+	service := newService(engine, collections["users"])
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Search := apiV1.Group("/search", authenticate)
+	Search.Get("/tasks", handler.SearchTasks)
+	Search.Get("/users", handler.SearchUsers)
+
+	apiV1.Get("/users/search", authenticate, handler.SearchUsersPage)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/search/tasks": {Summary: "Search the caller's tasks", Auth: true, Query: SearchQuery{}, Response: []xsearch.TaskHit{}},
+		"GET /api/v1/search/users": {Summary: "Search users, friends first", Auth: true, Query: SearchQuery{}, Response: []xsearch.UserHit{}},
+		"GET /api/v1/users/search": {Summary: "Search users a page at a time, friends and mutual friends first", Auth: true, Query: SearchQuery{}, Response: UserPage{}},
+	})
+}
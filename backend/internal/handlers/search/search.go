@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xsearch"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=search.go -destination=mock_test.go -package=search
+
+// Searcher runs the search queries Handler serves
+type Searcher interface {
+	SearchTasks(ctx context.Context, owner primitive.ObjectID, query SearchQuery) ([]xsearch.TaskHit, error)
+	SearchUsers(ctx context.Context, viewer primitive.ObjectID, query SearchQuery) (*UserPage, error)
+}
+
+var _ Searcher = (*Service)(nil)
+
+/*
+Handler to execute business logic for the search endpoints
+*/
+type Handler struct {
+	service Searcher
+}
+
+// SearchTasks searches the caller's own tasks
+func (h *Handler) SearchTasks(c *fiber.Ctx) error {
+	query, err := h.query(c)
+	if query == nil {
+		return err
+	}
+	owner, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+
+	hits, err := h.service.SearchTasks(c.UserContext(), owner, *query)
+	if err != nil {
+		return err
+	}
+	return c.JSON(hits)
+}
+
+// SearchUsers answers with the first page of users as a bare list, as it did before pagination
+func (h *Handler) SearchUsers(c *fiber.Ctx) error {
+	page, err := h.users(c)
+	if page == nil {
+		return err
+	}
+	return c.JSON(page.Items)
+}
+
+// SearchUsersPage answers with a page of users and the cursor of the next
+func (h *Handler) SearchUsersPage(c *fiber.Ctx) error {
+	page, err := h.users(c)
+	if page == nil {
+		return err
+	}
+	return c.JSON(page)
+}
+
+// users searches for the caller, it returns nil once it has answered the request with an error
+func (h *Handler) users(c *fiber.Ctx) (*UserPage, error) {
+	query, err := h.query(c)
+	if query == nil {
+		return nil, err
+	}
+
+	viewer, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user id",
+		})
+	}
+	page, err := h.service.SearchUsers(c.UserContext(), viewer, *query)
+	if errors.Is(err, xpage.ErrInvalidCursor) {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return page, err
+}
+
+// query parses ?q= and ?limit=, it returns nil once it has answered the request with an error
+func (h *Handler) query(c *fiber.Ctx) (*SearchQuery, error) {
+	var query SearchQuery
+	if err := c.QueryParser(&query); err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid query parameters",
+		})
+	}
+	query.Q = strings.TrimSpace(query.Q)
+	if errs := validator.Validate(query); len(errs) > 0 {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+	return &query, nil
+}
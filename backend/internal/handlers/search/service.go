@@ -0,0 +1,122 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xsearch"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	defaultLimit = 20
+	// how many matches users are ranked among
+	userWindow = 200
+)
+
+func newService(engine xsearch.Engine, users *mongo.Collection) *Service {
+	return &Service{engine, users}
+}
+
+func (s *Service) SearchTasks(ctx context.Context, owner primitive.ObjectID, query SearchQuery) ([]xsearch.TaskHit, error) {
+	return s.engine.Tasks(ctx, owner, toQuery(query))
+}
+
+/*
+SearchUsers ranks the users matching the query by how well they match and
+then by how close they are to the viewer: friends get a point, and each
+mutual friend a quarter, up to another point. Ranking needs every match at
+once, so the engine returns up to userWindow of them and pages are cut from
+those. The viewer's friends and their friends are searched first, so they
+are ranked even when more than userWindow users match, and the general match
+fills in the rest. Users whose profile the viewer can't see are left out.
+*/
+func (s *Service) SearchUsers(ctx context.Context, viewer primitive.ObjectID, query SearchQuery) (*UserPage, error) {
+	hits, err := s.matchUsers(ctx, viewer, query.Q)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []primitive.ObjectID{viewer}
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	relations, err := privacy.Load(ctx, s.users, ids)
+	if err != nil {
+		return nil, err
+	}
+	me := relations[viewer]
+	hits = slices.DeleteFunc(hits, func(hit xsearch.UserHit) bool {
+		user, ok := relations[hit.ID]
+		return !ok || !privacy.CanView(me, user)
+	})
+	for i := range hits {
+		user := relations[hits[i].ID]
+		hits[i].Friend = slices.Contains(me.Friends, user.ID)
+		for _, friend := range user.Friends {
+			if slices.Contains(me.Friends, friend) {
+				hits[i].MutualFriends++
+			}
+		}
+		if hits[i].Friend {
+			hits[i].Score++
+		}
+		hits[i].Score += min(float64(hits[i].MutualFriends)*0.25, 1)
+	}
+	slices.SortStableFunc(hits, func(a, b xsearch.UserHit) int { return cmp.Compare(b.Score, a.Score) })
+
+	// the cursor holds how far into the ranking the page before ended
+	offset := 0
+	if query.Cursor != "" {
+		if _, err := xpage.Decode(query.Cursor, &offset); err != nil || offset < 0 {
+			return nil, xpage.ErrInvalidCursor
+		}
+	}
+	size := xpage.Query{Limit: query.Limit}.Size(defaultLimit)
+	hits = hits[min(offset, len(hits)):]
+	end := min(size+1, len(hits))
+	return xpage.New(hits[:end], size, func(hit xsearch.UserHit) (any, primitive.ObjectID) {
+		return offset + size, hit.ID
+	}), nil
+}
+
+// matchUsers is the matches among the viewer's friends and their friends, then the rest
+func (s *Service) matchUsers(ctx context.Context, viewer primitive.ObjectID, text string) ([]xsearch.UserHit, error) {
+	relations, err := privacy.Load(ctx, s.users, []primitive.ObjectID{viewer})
+	if err != nil {
+		return nil, err
+	}
+	var hits []xsearch.UserHit
+	if friends := relations[viewer].Friends; len(friends) > 0 {
+		hits, err = s.engine.Users(ctx, xsearch.Query{Text: text, Limit: userWindow, Network: friends})
+		if err != nil {
+			return nil, err
+		}
+	}
+	rest, err := s.engine.Users(ctx, xsearch.Query{Text: text, Limit: userWindow})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[primitive.ObjectID]bool, len(hits))
+	for _, hit := range hits {
+		seen[hit.ID] = true
+	}
+	for _, hit := range rest {
+		if !seen[hit.ID] {
+			hits = append(hits, hit)
+		}
+	}
+	return hits, nil
+}
+
+func toQuery(query SearchQuery) xsearch.Query {
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	return xsearch.Query{Text: query.Q, Limit: limit}
+}
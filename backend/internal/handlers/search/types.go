@@ -0,0 +1,26 @@
+package search
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xpage"
+	"github.com/abhikaboy/SocialToDo/internal/xsearch"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Search Service to be used by Search Handler to query the
+configured search engine
+*/
+type Service struct {
+	engine xsearch.Engine
+	// for the privacy of the users found
+	users *mongo.Collection
+}
+
+type SearchQuery struct {
+	Q     string `query:"q" validate:"required,min=1,max=100"`
+	Limit int    `query:"limit" validate:"omitempty,min=1,max=50"`
+	// users only, next_cursor of the page before; pages stay within the first userWindow matches
+	Cursor string `query:"cursor"`
+}
+
+type UserPage = xpage.Page[xsearch.UserHit]
@@ -0,0 +1,244 @@
+package shortcuts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+The assistant endpoint is the fulfillment backend for voice skills: the
+platform turns speech into one of a few intents and the reply comes back as
+a sentence to read out. Completing a task goes by a fuzzy match on its
+title, since what the user says rarely matches what they typed.
+*/
+
+const (
+	// titles scoring below this don't count as a match
+	matchThreshold = 0.6
+	// a runner-up this close to the best match makes the request ambiguous
+	ambiguityMargin = 0.05
+	// tasks read out for list_today
+	spokenLimit = 3
+)
+
+var (
+	ErrNoMatch   = errors.New("no task matches")
+	ErrAmbiguous = errors.New("more than one task matches")
+)
+
+// IntentScope is the scope a scoped token needs for each intent
+var IntentScope = map[Intent]Scope{
+	IntentAddTask:      ScopeTasksCreate,
+	IntentListToday:    ScopeTasksReadToday,
+	IntentCompleteTask: ScopeTasksComplete,
+}
+
+func (s *Service) Assist(ctx context.Context, userID primitive.ObjectID, req AssistantRequest, loc *time.Location) (*AssistantResponse, error) {
+	switch Intent(req.Intent) {
+	case IntentAddTask:
+		created, err := s.QuickAdd(ctx, userID, QuickAddRequest{Content: req.Title})
+		if errors.Is(err, ErrNoCategory) {
+			return &AssistantResponse{Speech: "You don't have any categories yet. Create one in the app first."}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &AssistantResponse{
+			Speech: fmt.Sprintf("Added %s to %s.", strings.TrimSpace(req.Title), created.Category),
+			Task:   &SummaryTask{ID: created.ID, Content: strings.TrimSpace(req.Title), Priority: 1},
+		}, nil
+
+	case IntentListToday:
+		summary, err := s.Today(ctx, userID, loc)
+		if err != nil {
+			return nil, err
+		}
+		return &AssistantResponse{Speech: spokenSummary(summary), Tasks: summary.Next}, nil
+
+	case IntentCompleteTask:
+		match, candidates, err := s.completeByTitle(ctx, userID, req.Title)
+		switch {
+		case errors.Is(err, ErrNoMatch):
+			return &AssistantResponse{Speech: fmt.Sprintf("I couldn't find a task called %s.", req.Title)}, nil
+		case errors.Is(err, ErrAmbiguous):
+			return &AssistantResponse{
+				Speech: fmt.Sprintf("Did you mean %s or %s?", candidates[0].Content, candidates[1].Content),
+				Tasks:  candidates,
+			}, nil
+		case err != nil:
+			return nil, err
+		}
+		return &AssistantResponse{Speech: fmt.Sprintf("Marked %s as done.", match.Content), Task: match}, nil
+	}
+	return nil, fmt.Errorf("unknown intent %q", req.Intent)
+}
+
+func spokenSummary(summary *Summary) string {
+	var b strings.Builder
+	switch summary.Due {
+	case 0:
+		b.WriteString("Nothing is due today")
+	case 1:
+		b.WriteString("You have 1 task due today")
+	default:
+		fmt.Fprintf(&b, "You have %d tasks due today", summary.Due)
+	}
+	if summary.Overdue > 0 {
+		fmt.Fprintf(&b, " and %d overdue", summary.Overdue)
+	}
+	b.WriteString(".")
+	if len(summary.Next) > 0 {
+		names := make([]string, 0, spokenLimit)
+		for _, t := range summary.Next[:min(len(summary.Next), spokenLimit)] {
+			names = append(names, t.Content)
+		}
+		b.WriteString(" Next up: " + strings.Join(names, ", ") + ".")
+	}
+	return b.String()
+}
+
+// completeByTitle completes the open task best matching title, or returns the two closest when it can't tell
+func (s *Service) completeByTitle(ctx context.Context, userID primitive.ObjectID, title string) (*SummaryTask, []SummaryTask, error) {
+	cursor, err := s.Users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": userID}}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$match", Value: softdelete.LiveAt("categories")}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$categories.tasks"}}},
+		{{Key: "$match", Value: softdelete.Filter(bson.M{"completed": bson.M{"$ne": true}, "draft": bson.M{"$ne": true}})}},
+		{{Key: "$project", Value: bson.M{"content": 1, "priority": 1, "due_date": 1}}},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	var open []SummaryTask
+	if err := cursor.All(ctx, &open); err != nil {
+		return nil, nil, err
+	}
+
+	query := normalize(title)
+	best, second := -1, -1
+	var bestScore, secondScore float64
+	for i := range open {
+		score := similarity(query, normalize(open[i].Content))
+		if score > bestScore {
+			second, secondScore = best, bestScore
+			best, bestScore = i, score
+		} else if score > secondScore {
+			second, secondScore = i, score
+		}
+	}
+	if best < 0 || bestScore < matchThreshold {
+		return nil, nil, ErrNoMatch
+	}
+	if bestScore < 1 && second >= 0 && bestScore-secondScore < ambiguityMargin {
+		return nil, []SummaryTask{open[best], open[second]}, ErrAmbiguous
+	}
+
+	match := &open[best]
+	if err := s.tasks.CompleteTask(ctx, userID, match.ID); err != nil {
+		return nil, nil, err
+	}
+	return match, nil, nil
+}
+
+// normalize lowercases and drops punctuation, so "Buy milk!" and "buy milk" compare equal
+func normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return unicode.ToLower(r)
+		case unicode.IsSpace(r):
+			return ' '
+		}
+		return -1
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+/*
+similarity scores two normalized titles from 0 to 1: 1 when equal, high when
+one contains the other or has all of its words, otherwise the better of the
+shared-word ratio and the edit distance relative to the longer title.
+*/
+func similarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if a == b {
+		return 1
+	}
+	ra, rb := []rune(a), []rune(b)
+	shorter, longer := float64(min(len(ra), len(rb))), float64(max(len(ra), len(rb)))
+	if strings.Contains(a, b) || strings.Contains(b, a) {
+		return 0.8 + 0.19*shorter/longer
+	}
+	wa, wb := strings.Fields(a), strings.Fields(b)
+	shared := jaccard(wa, wb)
+	if covers(wb, wa) || covers(wa, wb) {
+		return 0.7 + 0.25*shared
+	}
+	edit := 1 - float64(levenshtein(ra, rb))/longer
+	return max(edit, shared)
+}
+
+func jaccard(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, w := range a {
+		set[w] = true
+	}
+	shared, union := 0, len(set)
+	seen := make(map[string]bool, len(b))
+	for _, w := range b {
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		if set[w] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+// covers reports whether every word of b is in a
+func covers(a, b []string) bool {
+	for _, w := range b {
+		if !slices.Contains(a, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
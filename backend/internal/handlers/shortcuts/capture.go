@@ -0,0 +1,232 @@
+package shortcuts
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xhttp"
+	"github.com/abhikaboy/SocialToDo/internal/xunfurl"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	UnfurlJob = "capture.unfurl"
+
+	// made on first capture when the user has no category by this name
+	inboxName = "Inbox"
+)
+
+// RegisterJobs adds the link unfurl job handler to the worker
+func RegisterJobs(worker *jobs.Worker, collections map[string]*mongo.Collection, cache xcache.Cache) {
+	s := newService(collections, cache, "")
+	worker.Handle(UnfurlJob, s.unfurl)
+}
+
+/*
+Capture saves a page as a task in the user's capture category: titled with
+the page's title, the selected text in its notes. The preview is unfurled in
+the background, and names the task when the extension sent no title.
+*/
+func (s *Service) Capture(ctx context.Context, userID primitive.ObjectID, req CaptureRequest) (*QuickAddResponse, error) {
+	var target *CaptureCategory
+	var err error
+	if req.Category != "" {
+		id, _ := primitive.ObjectIDFromHex(req.Category)
+		target, err = s.liveCategory(ctx, userID, id)
+	} else {
+		target, err = s.CaptureCategory(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	title := strings.TrimSpace(req.Title)
+	now := time.Now()
+	doc := task.TaskDocument{
+		ID:        primitive.NewObjectID(),
+		Priority:  1,
+		Content:   title,
+		Value:     defaultValue,
+		Active:    true,
+		Timestamp: now,
+		UpdatedAt: now,
+		Notes:     quote(req.Selection),
+		Link:      &task.Link{URL: req.URL},
+	}
+	if title == "" {
+		doc.Content = req.URL
+	}
+	if _, err := s.tasks.CreateTask(ctx, userID, target.ID, &doc); err != nil {
+		return nil, err
+	}
+	payload := UnfurlPayload{User: userID, Task: doc.ID, Retitle: title == ""}
+	if _, err := s.queue.Enqueue(ctx, UnfurlJob, payload, jobs.MaxAttempts(3)); err != nil {
+		return nil, err
+	}
+	return &QuickAddResponse{ID: doc.ID, Category: target.Name}, nil
+}
+
+// quote formats the selection as a Markdown blockquote
+func quote(selection string) string {
+	selection = strings.TrimSpace(selection)
+	if selection == "" {
+		return ""
+	}
+	return "> " + strings.ReplaceAll(selection, "\n", "\n> ")
+}
+
+/*
+CaptureCategory is the category captured pages go to: the one the user
+picked while it is live, otherwise their "Inbox", made on first use.
+*/
+func (s *Service) CaptureCategory(ctx context.Context, userID primitive.ObjectID) (*CaptureCategory, error) {
+	var owner struct {
+		CaptureCategory *primitive.ObjectID         `bson:"capture_category"`
+		Categories      []category.CategoryDocument `bson:"categories"`
+	}
+	err := s.Users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(bson.M{
+			"capture_category": 1, "categories._id": 1, "categories.name": 1, "categories." + softdelete.Field: 1,
+		}),
+	).Decode(&owner)
+	if err != nil {
+		return nil, err
+	}
+	live := softdelete.Visible(owner.Categories)
+	var inbox *category.CategoryDocument
+	for i := range live {
+		if owner.CaptureCategory != nil && live[i].ID == *owner.CaptureCategory {
+			return &CaptureCategory{ID: live[i].ID, Name: live[i].Name}, nil
+		}
+		if inbox == nil && strings.EqualFold(live[i].Name, inboxName) {
+			inbox = &live[i]
+		}
+	}
+	if inbox != nil {
+		return &CaptureCategory{ID: inbox.ID, Name: inbox.Name}, nil
+	}
+
+	categories := category.NewServiceWithRepository(category.NewMongoRepository(map[string]*mongo.Collection{"users": s.Users}), s.cache)
+	created, err := categories.CreateCategory(ctx, &category.CategoryDocument{
+		ID:         primitive.NewObjectID(),
+		Name:       inboxName,
+		LastEdited: time.Now(),
+		Tasks:      []task.TaskDocument{},
+		User:       userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &CaptureCategory{ID: created.ID, Name: created.Name}, nil
+}
+
+// SetCaptureCategory picks the category captured pages go to
+func (s *Service) SetCaptureCategory(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*CaptureCategory, error) {
+	target, err := s.liveCategory(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.Users.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": bson.M{"capture_category": id}})
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+func (s *Service) liveCategory(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*CaptureCategory, error) {
+	var owner struct {
+		Categories []category.CategoryDocument `bson:"categories"`
+	}
+	err := s.Users.FindOne(ctx,
+		softdelete.Filter(bson.M{"_id": userID, "categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": id})}}),
+		options.FindOne().SetProjection(bson.M{"categories.$": 1}),
+	).Decode(&owner)
+	if err != nil {
+		return nil, err
+	}
+	return &CaptureCategory{ID: owner.Categories[0].ID, Name: owner.Categories[0].Name}, nil
+}
+
+/*
+unfurl stores the captured page's preview on the task. A page on a private
+address is never fetched; the task keeps its bare link. Only a task still
+titled with its URL is renamed, so a title the user typed meanwhile stays.
+*/
+func (s *Service) unfurl(ctx context.Context, job *jobs.Job) error {
+	var payload UnfurlPayload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	var captured struct {
+		Link *task.Link `bson:"link"`
+	}
+	cursor, err := s.Users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": payload.User}}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$match", Value: bson.M{"categories.tasks._id": payload.Task}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$categories.tasks"}}},
+		{{Key: "$project", Value: bson.M{"link": 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	if !cursor.Next(ctx) {
+		// deleted for good before its preview came in
+		return errors.Join(cursor.Err(), cursor.Close(ctx))
+	}
+	err = errors.Join(cursor.Decode(&captured), cursor.Close(ctx))
+	if err != nil {
+		return err
+	}
+	if captured.Link == nil {
+		return nil
+	}
+
+	preview, err := xunfurl.Unfurl(ctx, captured.Link.URL)
+	if errors.Is(err, xhttp.ErrPrivateAddress) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	link := task.Link{
+		URL:         captured.Link.URL,
+		Title:       preview.Title,
+		Description: preview.Description,
+		Image:       preview.Image,
+		SiteName:    preview.SiteName,
+	}
+	if err := s.setTask(ctx, payload.User, bson.M{"t._id": payload.Task}, bson.M{"link": link}); err != nil {
+		return err
+	}
+	if !payload.Retitle || preview.Title == "" {
+		return nil
+	}
+	return s.setTask(ctx, payload.User, bson.M{"t._id": payload.Task, "t.content": captured.Link.URL}, bson.M{"content": preview.Title})
+}
+
+// setTask sets fields on the user's task matching filter, which names the task t
+func (s *Service) setTask(ctx context.Context, userID primitive.ObjectID, filter bson.M, fields bson.M) error {
+	set := bson.M{"categories.$[].tasks.$[t].updated_at": time.Now()}
+	for field, value := range fields {
+		set["categories.$[].tasks.$[t]."+field] = value
+	}
+	_, err := s.Users.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": set, "$inc": bson.M{"categories.$[].tasks.$[t]." + xmongo.VersionField: 1}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{filter}}))
+	return err
+}
@@ -0,0 +1,201 @@
+package shortcuts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	deviceCodeTTL = 10 * time.Minute
+	// seconds between polls, raised by slowDownStep each time a device polls too soon
+	pollInterval = 5
+	slowDownStep = 5
+	// no vowels, so codes don't spell words, and nothing easily misread
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength   = 8
+)
+
+// the device flow's poll errors, spelled as RFC 8628 has clients expect them
+var (
+	ErrAuthorizationPending = errors.New("authorization_pending")
+	ErrSlowDown             = errors.New("slow_down")
+	ErrAccessDenied         = errors.New("access_denied")
+	ErrExpiredToken         = errors.New("expired_token")
+)
+
+// StartDevice begins a device sign-in for a device that can't take a password, such as a browser extension
+func (s *Service) StartDevice(ctx context.Context, req DeviceCodeRequest) (*DeviceCodeResponse, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	deviceCode := base64.RawURLEncoding.EncodeToString(b)
+
+	scopes := make([]Scope, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !slices.Contains(scopes, Scope(scope)) {
+			scopes = append(scopes, Scope(scope))
+		}
+	}
+	now := time.Now()
+	device := DeviceCode{
+		ID:        primitive.NewObjectID(),
+		Hash:      hash(deviceCode),
+		Name:      req.Name,
+		Scopes:    scopes,
+		Interval:  pollInterval,
+		CreatedAt: now,
+		ExpiresAt: now.Add(deviceCodeTTL),
+	}
+	// a fresh user code on the rare clash with a pending one
+	for range 3 {
+		code, err := userCode()
+		if err != nil {
+			return nil, err
+		}
+		device.UserCode = code
+		_, err = s.Devices.InsertOne(ctx, device)
+		if !mongo.IsDuplicateKeyError(err) {
+			if err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+	if device.UserCode == "" {
+		return nil, errors.New("no free user code")
+	}
+
+	display := device.UserCode[:userCodeLength/2] + "-" + device.UserCode[userCodeLength/2:]
+	verification := s.webURL + "/device"
+	return &DeviceCodeResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                display,
+		VerificationURI:         verification,
+		VerificationURIComplete: verification + "?code=" + url.QueryEscape(display),
+		ExpiresIn:               int(deviceCodeTTL.Seconds()),
+		Interval:                pollInterval,
+	}, nil
+}
+
+func userCode() (string, error) {
+	code := make([]byte, userCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(userCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = userCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// normalizeUserCode accepts the code as typed: any case, with or without the dash
+func normalizeUserCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func (s *Service) pendingDevice(ctx context.Context, code string) (*DeviceCode, error) {
+	var device DeviceCode
+	err := s.Devices.FindOne(ctx, bson.M{
+		"user_code":  normalizeUserCode(code),
+		"user":       bson.M{"$exists": false},
+		"denied":     false,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&device)
+	if err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// Device shows what a pending sign-in asks for, so the user knows what they approve
+func (s *Service) Device(ctx context.Context, code string) (*DeviceRequest, error) {
+	device, err := s.pendingDevice(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceRequest{Name: device.Name, Scopes: device.Scopes, ExpiresAt: device.ExpiresAt}, nil
+}
+
+// DecideDevice approves the pending sign-in for userID, or denies it
+func (s *Service) DecideDevice(ctx context.Context, userID primitive.ObjectID, code string, approve bool) error {
+	device, err := s.pendingDevice(ctx, code)
+	if err != nil {
+		return err
+	}
+	set := bson.M{"denied": true}
+	if approve {
+		set = bson.M{"user": userID}
+	}
+	result, err := s.Devices.UpdateOne(ctx,
+		bson.M{"_id": device.ID, "user": bson.M{"$exists": false}, "denied": false},
+		bson.M{"$set": set})
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+/*
+PollDevice is the device's side: one of the poll errors until the user
+decides, then the scoped token, minted once. Polling sooner than the
+interval gets ErrSlowDown and a longer interval.
+*/
+func (s *Service) PollDevice(ctx context.Context, deviceCode string) (*MintResponse, error) {
+	now := time.Now()
+	var device DeviceCode
+	err := s.Devices.FindOneAndUpdate(ctx,
+		bson.M{"hash": hash(deviceCode)},
+		bson.M{"$set": bson.M{"polled_at": now}},
+	).Decode(&device)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// expired codes are purged by a TTL index, and used ones deleted
+		return nil, ErrExpiredToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case now.After(device.ExpiresAt):
+		return nil, ErrExpiredToken
+	case device.Denied:
+		_, err := s.Devices.DeleteOne(ctx, bson.M{"_id": device.ID})
+		return nil, errors.Join(ErrAccessDenied, err)
+	case device.PolledAt != nil && now.Sub(*device.PolledAt) < time.Duration(device.Interval)*time.Second:
+		_, err := s.Devices.UpdateOne(ctx, bson.M{"_id": device.ID}, bson.M{"$inc": bson.M{"interval": slowDownStep}})
+		return nil, errors.Join(ErrSlowDown, err)
+	case device.User == nil:
+		return nil, ErrAuthorizationPending
+	}
+
+	// deleting first means two racing polls can't both get a token
+	err = s.Devices.FindOneAndDelete(ctx, bson.M{"_id": device.ID}, options.FindOneAndDelete().SetProjection(bson.M{"_id": 1})).Err()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrExpiredToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	scopes := make([]string, len(device.Scopes))
+	for i, scope := range device.Scopes {
+		scopes[i] = string(scope)
+	}
+	return s.Mint(ctx, *device.User, MintRequest{Name: device.Name, Scopes: scopes})
+}
@@ -0,0 +1,118 @@
+package shortcuts
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUserCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 50 {
+		code, err := userCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(code) != userCodeLength || strings.Trim(code, userCodeAlphabet) != "" {
+			t.Errorf("expected %d letters of %s, got %q", userCodeLength, userCodeAlphabet, code)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 45 {
+		t.Errorf("expected mostly distinct codes, got %d of 50", len(seen))
+	}
+}
+
+func TestNormalizeUserCode(t *testing.T) {
+	for _, typed := range []string{"BCDF-GHJK", "bcdf-ghjk", "BCDFGHJK", " bcdf ghjk "} {
+		if got := normalizeUserCode(typed); got != "BCDFGHJK" {
+			t.Errorf("%q: expected BCDFGHJK, got %q", typed, got)
+		}
+	}
+}
+
+func TestPollDevice(t *testing.T) {
+	service := NewMockShortcuts(gomock.NewController(t))
+	for _, err := range []error{ErrAuthorizationPending, ErrSlowDown, ErrAccessDenied, ErrExpiredToken, ErrTooManyTokens} {
+		service.EXPECT().PollDevice(gomock.Any(), err.Error()).Return(nil, err)
+	}
+	// a denied code is deleted as it is reported, the deletion's error rides along
+	service.EXPECT().PollDevice(gomock.Any(), "denied-with-cleanup").Return(nil, errors.Join(ErrAccessDenied, errors.New("delete failed")))
+	service.EXPECT().PollDevice(gomock.Any(), "approved").Return(&MintResponse{Secret: tokenPrefix + "secret"}, nil)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Post("/device/token", handler.PollDevice)
+
+	tests := []struct {
+		name     string
+		code     string
+		expected int
+		error    string
+	}{
+		{"pending", "authorization_pending", fiber.StatusBadRequest, "authorization_pending"},
+		{"too soon", "slow_down", fiber.StatusBadRequest, "slow_down"},
+		{"denied", "access_denied", fiber.StatusBadRequest, "access_denied"},
+		{"denied with cleanup error", "denied-with-cleanup", fiber.StatusBadRequest, "access_denied"},
+		{"expired", "expired_token", fiber.StatusBadRequest, "expired_token"},
+		{"too many tokens", "too many tokens", fiber.StatusConflict, "Too many tokens, revoke one first"},
+		{"approved", "approved", fiber.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		body, _ := gojson.Marshal(DeviceTokenRequest{DeviceCode: tt.code})
+		res := call(t, app, http.MethodPost, "/device/token", "", string(body))
+		var got struct {
+			Error string `json:"error"`
+			Token string `json:"token"`
+		}
+		raw, _ := io.ReadAll(res.Body)
+		if err := gojson.Unmarshal(raw, &got); err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expected || got.Error != tt.error {
+			t.Errorf("%s: expected %d %q, got %d %q", tt.name, tt.expected, tt.error, res.StatusCode, got.Error)
+		}
+		if tt.error == "" && !strings.HasPrefix(got.Token, tokenPrefix) {
+			t.Errorf("%s: expected a scoped token, got %q", tt.name, got.Token)
+		}
+	}
+}
+
+func TestDecideDevice(t *testing.T) {
+	userID := primitive.NewObjectID()
+	service := NewMockShortcuts(gomock.NewController(t))
+	service.EXPECT().DecideDevice(gomock.Any(), userID, "BCDF-GHJK", true).Return(nil)
+	service.EXPECT().DecideDevice(gomock.Any(), userID, "BCDF-GHJK", false).Return(nil)
+	// decided, expired or never issued
+	service.EXPECT().DecideDevice(gomock.Any(), userID, "ZZZZ-ZZZZ", true).Return(mongo.ErrNoDocuments)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Post("/device/:code/approve", session(userID), handler.ApproveDevice)
+	app.Post("/device/:code/deny", session(userID), handler.DenyDevice)
+
+	tests := []struct {
+		name     string
+		path     string
+		token    string
+		expected int
+	}{
+		{"approve", "/device/BCDF-GHJK/approve", sessionToken, fiber.StatusNoContent},
+		{"deny", "/device/BCDF-GHJK/deny", sessionToken, fiber.StatusNoContent},
+		{"unknown code", "/device/ZZZZ-ZZZZ/approve", sessionToken, fiber.StatusNotFound},
+		{"signed out", "/device/BCDF-GHJK/approve", "", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if res := call(t, app, http.MethodPost, tt.path, tt.token, ""); res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package shortcuts
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler, webURL string) {
+	service := newService(collections, cache, webURL)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	// minting and revoking take a session; a scoped token can't make more of itself
+	Tokens := apiV1.Group("/tokens", authenticate)
+	Tokens.Post("/", handler.MintToken)
+	Tokens.Get("/", handler.ListTokens)
+	Tokens.Delete("/:id", handler.RevokeToken)
+
+	// the device flow, for clients that can't take a password; approving takes a session
+	Device := apiV1.Group("/device")
+	Device.Post("/code", handler.StartDevice)
+	Device.Post("/token", handler.PollDevice)
+	Device.Get("/:code", authenticate, handler.GetDevice)
+	Device.Post("/:code/approve", authenticate, handler.ApproveDevice)
+	Device.Post("/:code/deny", authenticate, handler.DenyDevice)
+
+	apiV1.Get("/today/summary", handler.Authenticate(ScopeTasksReadToday, authenticate), handler.TodaySummary)
+	apiV1.Get("/widget", handler.Authenticate(ScopeTasksReadToday, authenticate), handler.Widget)
+	apiV1.Put("/widget/goal", authenticate, handler.SetGoal)
+	apiV1.Post("/quick-add", handler.Authenticate(ScopeTasksCreate, authenticate), handler.QuickAdd)
+	apiV1.Post("/capture", handler.Authenticate(ScopeTasksCreate, authenticate), handler.Capture)
+	apiV1.Get("/capture/category", authenticate, handler.GetCaptureCategory)
+	apiV1.Put("/capture/category", authenticate, handler.SetCaptureCategory)
+	// the intent decides the scope, see Assistant
+	apiV1.Post("/assistant", handler.Authenticate("", authenticate), handler.Assistant)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/tokens/":              {Summary: "Mint a scoped token; the secret is only shown here", Auth: true, Request: MintRequest{}, Response: MintResponse{}, Status: fiber.StatusCreated},
+		"GET /api/v1/tokens/":               {Summary: "The caller's scoped tokens", Auth: true, Response: []Token{}},
+		"DELETE /api/v1/tokens/:id":         {Summary: "Revoke a scoped token", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/device/code":          {Summary: "Start a device sign-in", Request: DeviceCodeRequest{}, Response: DeviceCodeResponse{}},
+		"POST /api/v1/device/token":         {Summary: "Poll a device sign-in for its token", Request: DeviceTokenRequest{}, Response: MintResponse{}},
+		"GET /api/v1/device/:code":          {Summary: "The device sign-in a user code belongs to", Auth: true, Response: DeviceRequest{}},
+		"POST /api/v1/device/:code/approve": {Summary: "Approve a device sign-in", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/device/:code/deny":    {Summary: "Deny a device sign-in", Auth: true, Status: fiber.StatusNoContent},
+		"GET /api/v1/today/summary":         {Summary: "Today's tasks in brief, for watches and voice assistants", Auth: true, Query: xopenapi.Query{"tz"}, Response: Summary{}},
+		"GET /api/v1/widget":                {Summary: "What home-screen widgets show", Auth: true, Query: xopenapi.Query{"tz"}, Response: Widget{}},
+		"PUT /api/v1/widget/goal":           {Summary: "Set the daily goal", Auth: true, Request: GoalRequest{}, Status: fiber.StatusNoContent},
+		"POST /api/v1/quick-add":            {Summary: "Add a task from a line of text", Auth: true, Request: QuickAddRequest{}, Response: QuickAddResponse{}, Status: fiber.StatusCreated},
+		"POST /api/v1/capture":              {Summary: "Add a task from a web page", Auth: true, Request: CaptureRequest{}, Response: QuickAddResponse{}, Status: fiber.StatusCreated},
+		"GET /api/v1/capture/category":      {Summary: "The category captured pages go to", Auth: true, Response: CaptureCategory{}},
+		"PUT /api/v1/capture/category":      {Summary: "Change the category captured pages go to", Auth: true, Request: CaptureCategoryRequest{}, Response: CaptureCategory{}},
+		"POST /api/v1/assistant":            {Summary: "Fulfill a voice assistant intent", Auth: true, Request: AssistantRequest{}, Response: AssistantResponse{}},
+	})
+}
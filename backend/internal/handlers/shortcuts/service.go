@@ -0,0 +1,243 @@
+package shortcuts
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	Collection       = "scoped_tokens"
+	DeviceCollection = "device_codes"
+
+	// tells a scoped token apart from a session JWT in the Authorization header
+	tokenPrefix = "sct_"
+	maxTokens   = 20
+	// last_used_at is only written this often, so polling widgets don't write on every read
+	touchInterval = time.Hour
+	nextLimit     = 5
+	// quick-added tasks get the middle of the 1-10 value scale, like other tasks made without the app
+	defaultValue = 5
+)
+
+var (
+	ErrTooManyTokens = errors.New("too many tokens")
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrNoCategory    = errors.New("no category to add the task to")
+)
+
+// newService receives the map of collections and picks out Tokens, Users and Devices
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache, webURL string) *Service {
+	return &Service{
+		Tokens:  collections[Collection],
+		Users:   collections["users"],
+		Devices: collections[DeviceCollection],
+		tasks:   task.NewService(collections, cache),
+		cache:   cache,
+		queue:   jobs.New(collections[jobs.Collection]),
+		webURL:  strings.TrimSuffix(webURL, "/"),
+	}
+}
+
+func (s *Service) Mint(ctx context.Context, userID primitive.ObjectID, req MintRequest) (*MintResponse, error) {
+	count, err := s.Tokens.CountDocuments(ctx, bson.M{"user": userID})
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxTokens {
+		return nil, ErrTooManyTokens
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	scopes := make([]Scope, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !slices.Contains(scopes, Scope(scope)) {
+			scopes = append(scopes, Scope(scope))
+		}
+	}
+	token := Token{
+		ID:        primitive.NewObjectID(),
+		User:      userID,
+		Name:      req.Name,
+		Hash:      hash(secret),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+	if _, err := s.Tokens.InsertOne(ctx, token); err != nil {
+		return nil, err
+	}
+	return &MintResponse{Token: token, Secret: secret}, nil
+}
+
+func (s *Service) List(ctx context.Context, userID primitive.ObjectID) ([]Token, error) {
+	cursor, err := s.Tokens.Find(ctx, bson.M{"user": userID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]Token, 0)
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *Service) Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	result, err := s.Tokens.DeleteOne(ctx, bson.M{"_id": id, "user": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+/*
+Check returns the scoped token secret belongs to. Tokens of deleted or
+suspended users stop working without being revoked.
+*/
+func (s *Service) Check(ctx context.Context, secret string) (*Token, error) {
+	var token Token
+	err := s.Tokens.FindOne(ctx, bson.M{"hash": hash(secret)}).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.Users.CountDocuments(ctx, softdelete.Filter(bson.M{
+		"_id":          token.User,
+		"suspended_at": bson.M{"$exists": false},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if active == 0 {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > touchInterval {
+		_, err = s.Tokens.UpdateOne(ctx, bson.M{"_id": token.ID}, bson.M{"$set": bson.M{"last_used_at": now}})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &token, nil
+}
+
+// Today summarizes the user's day in loc: what is due, overdue and done, and the next few tasks
+func (s *Service) Today(ctx context.Context, userID primitive.ObjectID, loc *time.Location) (*Summary, error) {
+	now := time.Now().In(loc)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, 1)
+
+	cursor, err := s.Users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": userID}}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$match", Value: softdelete.LiveAt("categories")}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$categories.tasks"}}},
+		{{Key: "$match", Value: softdelete.Filter(bson.M{
+			"draft": bson.M{"$ne": true},
+			"$or": bson.A{
+				bson.M{"completed": bson.M{"$ne": true}, "due_date": bson.M{"$lt": end}},
+				bson.M{"completed_at": bson.M{"$gte": start}},
+			},
+		})}},
+		{{Key: "$sort", Value: bson.D{{Key: "due_date", Value: 1}, {Key: "priority", Value: 1}}}},
+		{{Key: "$project", Value: bson.M{"content": 1, "priority": 1, "due_date": 1, "completed": 1, "completed_at": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var tasks []SummaryTask
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{Date: start.Format(time.DateOnly), Next: make([]SummaryTask, 0, nextLimit)}
+	for _, t := range tasks {
+		switch {
+		case t.Completed:
+			summary.Completed++
+			continue
+		case t.DueDate.Before(start):
+			summary.Overdue++
+		default:
+			summary.Due++
+		}
+		if len(summary.Next) < nextLimit {
+			summary.Next = append(summary.Next, t)
+		}
+	}
+	return summary, nil
+}
+
+// QuickAdd adds a task with just its content, to the named category or the user's first one
+func (s *Service) QuickAdd(ctx context.Context, userID primitive.ObjectID, req QuickAddRequest) (*QuickAddResponse, error) {
+	var owner struct {
+		Categories []category.CategoryDocument `bson:"categories"`
+	}
+	err := s.Users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(bson.M{"categories._id": 1, "categories.name": 1, "categories." + softdelete.Field: 1}),
+	).Decode(&owner)
+	if err != nil {
+		return nil, err
+	}
+	live := softdelete.Visible(owner.Categories)
+	if len(live) == 0 {
+		return nil, ErrNoCategory
+	}
+	target := live[0]
+	if req.Category != "" {
+		id, _ := primitive.ObjectIDFromHex(req.Category)
+		i := slices.IndexFunc(live, func(c category.CategoryDocument) bool { return c.ID == id })
+		if i < 0 {
+			return nil, mongo.ErrNoDocuments
+		}
+		target = live[i]
+	}
+
+	now := time.Now()
+	doc := task.TaskDocument{
+		ID:        primitive.NewObjectID(),
+		Priority:  1,
+		Content:   strings.TrimSpace(req.Content),
+		Value:     defaultValue,
+		Active:    true,
+		Timestamp: now,
+		UpdatedAt: now,
+	}
+	if _, err := s.tasks.CreateTask(ctx, userID, target.ID, &doc); err != nil {
+		return nil, err
+	}
+	return &QuickAddResponse{ID: doc.ID, Category: target.Name}, nil
+}
+
+func hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,413 @@
+package shortcuts
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=shortcuts.go -destination=mock_test.go -package=shortcuts
+
+// Shortcuts is what Handler needs for scoped tokens and what they unlock
+type Shortcuts interface {
+	Assist(ctx context.Context, userID primitive.ObjectID, req AssistantRequest, loc *time.Location) (*AssistantResponse, error)
+	Capture(ctx context.Context, userID primitive.ObjectID, req CaptureRequest) (*QuickAddResponse, error)
+	CaptureCategory(ctx context.Context, userID primitive.ObjectID) (*CaptureCategory, error)
+	SetCaptureCategory(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*CaptureCategory, error)
+	StartDevice(ctx context.Context, req DeviceCodeRequest) (*DeviceCodeResponse, error)
+	Device(ctx context.Context, code string) (*DeviceRequest, error)
+	DecideDevice(ctx context.Context, userID primitive.ObjectID, code string, approve bool) error
+	PollDevice(ctx context.Context, deviceCode string) (*MintResponse, error)
+	Mint(ctx context.Context, userID primitive.ObjectID, req MintRequest) (*MintResponse, error)
+	List(ctx context.Context, userID primitive.ObjectID) ([]Token, error)
+	Revoke(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	Check(ctx context.Context, secret string) (*Token, error)
+	Today(ctx context.Context, userID primitive.ObjectID, loc *time.Location) (*Summary, error)
+	QuickAdd(ctx context.Context, userID primitive.ObjectID, req QuickAddRequest) (*QuickAddResponse, error)
+	Widget(ctx context.Context, userID primitive.ObjectID, loc *time.Location) (*Widget, error)
+	SetGoal(ctx context.Context, userID primitive.ObjectID, target int) error
+}
+
+var _ Shortcuts = (*Service)(nil)
+
+/*
+Handler to execute business logic for Shortcuts Endpoint
+*/
+type Handler struct {
+	service Shortcuts
+}
+
+var validator = xvalidator.Validator
+
+/*
+Authenticate lets a request through with a scoped token carrying scope, or
+hands it to authenticate when the header holds a regular session token, so
+the app itself can use the same endpoints. With no scope any scoped token
+passes and the handler checks the scopes it needs with allowed.
+*/
+func (h *Handler) Authenticate(scope Scope, authenticate fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		secret, ok := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(secret, tokenPrefix) {
+			return authenticate(c)
+		}
+		token, err := h.service.Check(c.UserContext(), secret)
+		if errors.Is(err, ErrInvalidToken) {
+			return fiber.NewError(fiber.StatusUnauthorized, "Not Authorized, Invalid Token")
+		}
+		if err != nil {
+			return err
+		}
+		c.Locals("user_id", token.User.Hex())
+		c.Locals("scopes", token.Scopes)
+		if scope != "" && !allowed(c, scope) {
+			return fiber.NewError(fiber.StatusForbidden, "Token lacks the "+string(scope)+" scope")
+		}
+		return c.Next()
+	}
+}
+
+// allowed reports whether the caller may use scope; session tokens may do anything
+func allowed(c *fiber.Ctx, scope Scope) bool {
+	scopes, ok := c.Locals("scopes").([]Scope)
+	return !ok || slices.Contains(scopes, scope)
+}
+
+func (h *Handler) MintToken(c *fiber.Ctx) error {
+	var req MintRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	token, err := h.service.Mint(c.UserContext(), userID(c), req)
+	if errors.Is(err, ErrTooManyTokens) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Too many tokens, revoke one first",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(token)
+}
+
+func (h *Handler) ListTokens(c *fiber.Ctx) error {
+	tokens, err := h.service.List(c.UserContext(), userID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(tokens)
+}
+
+func (h *Handler) RevokeToken(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	err = h.service.Revoke(c.UserContext(), userID(c), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Token not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TodaySummary takes the device's IANA time zone in ?tz= to know where its day starts, UTC otherwise
+func (h *Handler) TodaySummary(c *fiber.Ctx) error {
+	loc, err := time.LoadLocation(c.Query("tz", "UTC"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid time zone",
+		})
+	}
+	summary, err := h.service.Today(c.UserContext(), userID(c), loc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(summary)
+}
+
+/*
+Widget is polled by home-screen widgets. The app-wide ETag middleware tags
+it, so an unchanged widget costs a 304, and clients may show a stale copy
+while revalidating or when the network fails.
+*/
+func (h *Handler) Widget(c *fiber.Ctx) error {
+	loc, err := time.LoadLocation(c.Query("tz", "UTC"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid time zone",
+		})
+	}
+	widget, err := h.service.Widget(c.UserContext(), userID(c), loc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderCacheControl, "private, max-age=60, stale-while-revalidate=600, stale-if-error=86400")
+	c.Set(fiber.HeaderVary, fiber.HeaderAuthorization)
+	return c.JSON(widget)
+}
+
+func (h *Handler) SetGoal(c *fiber.Ctx) error {
+	var req GoalRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+	err := h.service.SetGoal(c.UserContext(), userID(c), req.Target)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handler) QuickAdd(c *fiber.Ctx) error {
+	var req QuickAddRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	created, err := h.service.QuickAdd(c.UserContext(), userID(c), req)
+	switch {
+	case errors.Is(err, ErrNoCategory):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Create a category first",
+		})
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	case err != nil:
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+/*
+Assistant fulfills a voice skill's intent. Linked skills call it with a
+scoped token, which needs the scope of the intent it asks for.
+*/
+func (h *Handler) Assistant(c *fiber.Ctx) error {
+	var req AssistantRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+	if scope := IntentScope[Intent(req.Intent)]; !allowed(c, scope) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Token lacks the " + string(scope) + " scope",
+		})
+	}
+	tz := req.TimeZone
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid time zone",
+		})
+	}
+
+	response, err := h.service.Assist(c.UserContext(), userID(c), req, loc)
+	if err != nil {
+		return err
+	}
+	return c.JSON(response)
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	return id
+}
+
+// Capture is the browser extension's quick-add, with a scoped token carrying tasks:create
+func (h *Handler) Capture(c *fiber.Ctx) error {
+	var req CaptureRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	created, err := h.service.Capture(c.UserContext(), userID(c), req)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+func (h *Handler) GetCaptureCategory(c *fiber.Ctx) error {
+	target, err := h.service.CaptureCategory(c.UserContext(), userID(c))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(target)
+}
+
+func (h *Handler) SetCaptureCategory(c *fiber.Ctx) error {
+	var req CaptureCategoryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+	id, _ := primitive.ObjectIDFromHex(req.Category)
+	target, err := h.service.SetCaptureCategory(c.UserContext(), userID(c), id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(target)
+}
+
+func (h *Handler) StartDevice(c *fiber.Ctx) error {
+	var req DeviceCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+	started, err := h.service.StartDevice(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+	return c.JSON(started)
+}
+
+/*
+PollDevice answers the device's polls. Until the user decides it fails with
+the RFC 8628 error codes, which device flow clients branch on.
+*/
+func (h *Handler) PollDevice(c *fiber.Ctx) error {
+	var req DeviceTokenRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); errs != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	token, err := h.service.PollDevice(c.UserContext(), req.DeviceCode)
+	for _, pollErr := range []error{ErrAuthorizationPending, ErrSlowDown, ErrAccessDenied, ErrExpiredToken} {
+		if errors.Is(err, pollErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": pollErr.Error(),
+			})
+		}
+	}
+	if errors.Is(err, ErrTooManyTokens) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Too many tokens, revoke one first",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(token)
+}
+
+func (h *Handler) GetDevice(c *fiber.Ctx) error {
+	device, err := h.service.Device(c.UserContext(), c.Params("code"))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Code not found or expired",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.JSON(device)
+}
+
+func (h *Handler) ApproveDevice(c *fiber.Ctx) error {
+	return h.decideDevice(c, true)
+}
+
+func (h *Handler) DenyDevice(c *fiber.Ctx) error {
+	return h.decideDevice(c, false)
+}
+
+func (h *Handler) decideDevice(c *fiber.Ctx, approve bool) error {
+	err := h.service.DecideDevice(c.UserContext(), userID(c), c.Params("code"), approve)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Code not found or expired",
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,144 @@
+package shortcuts
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+const sessionToken = "session.jwt.token"
+
+// session stands in for the auth middleware, letting sessionToken through as userID
+func session(userID primitive.ObjectID) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") != "Bearer "+sessionToken {
+			return fiber.NewError(fiber.StatusUnauthorized, "Not Authorized")
+		}
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	}
+}
+
+func call(t *testing.T, app *fiber.App, method string, path string, token string, body string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestAuthenticate(t *testing.T) {
+	userID := primitive.NewObjectID()
+	service := NewMockShortcuts(gomock.NewController(t))
+	service.EXPECT().Check(gomock.Any(), "sct_widget").Return(&Token{User: userID, Scopes: []Scope{ScopeTasksReadToday}}, nil).AnyTimes()
+	service.EXPECT().Check(gomock.Any(), "sct_revoked").Return(nil, ErrInvalidToken).AnyTimes()
+	service.EXPECT().Check(gomock.Any(), "sct_broken").Return(nil, errors.New("connection reset")).AnyTimes()
+
+	handler := Handler{service}
+	app := fiber.New()
+	reached := func(c *fiber.Ctx) error {
+		if c.Locals("user_id") != userID.Hex() {
+			return c.SendStatus(fiber.StatusTeapot)
+		}
+		return c.SendStatus(fiber.StatusOK)
+	}
+	app.Get("/today", handler.Authenticate(ScopeTasksReadToday, session(userID)), reached)
+	app.Post("/quick-add", handler.Authenticate(ScopeTasksCreate, session(userID)), reached)
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		token    string
+		expected int
+	}{
+		{"scoped token with the scope", http.MethodGet, "/today", "sct_widget", fiber.StatusOK},
+		{"scoped token without the scope", http.MethodPost, "/quick-add", "sct_widget", fiber.StatusForbidden},
+		{"revoked token", http.MethodGet, "/today", "sct_revoked", fiber.StatusUnauthorized},
+		{"failing lookup", http.MethodGet, "/today", "sct_broken", fiber.StatusInternalServerError},
+		// anything else is the auth middleware's to judge
+		{"session token", http.MethodPost, "/quick-add", sessionToken, fiber.StatusOK},
+		{"no token", http.MethodGet, "/today", "", fiber.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if res := call(t, app, tt.method, tt.path, tt.token, ""); res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
+
+func TestAssistantScope(t *testing.T) {
+	userID := primitive.NewObjectID()
+	service := NewMockShortcuts(gomock.NewController(t))
+	service.EXPECT().Check(gomock.Any(), "sct_speaker").Return(&Token{User: userID, Scopes: []Scope{ScopeTasksReadToday}}, nil).AnyTimes()
+	service.EXPECT().Assist(gomock.Any(), userID, gomock.Any(), gomock.Any()).Return(&AssistantResponse{Speech: "Nothing due today"}, nil).AnyTimes()
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Post("/assistant", handler.Authenticate("", session(userID)), handler.Assistant)
+
+	tests := []struct {
+		name     string
+		token    string
+		body     string
+		expected int
+	}{
+		{"intent within the scopes", "sct_speaker", `{"intent": "list_today"}`, fiber.StatusOK},
+		{"intent outside the scopes", "sct_speaker", `{"intent": "add_task", "title": "Buy milk"}`, fiber.StatusForbidden},
+		{"session token", sessionToken, `{"intent": "complete_task", "title": "Buy milk"}`, fiber.StatusOK},
+		{"unknown intent", "sct_speaker", `{"intent": "order_pizza"}`, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if res := call(t, app, http.MethodPost, "/assistant", tt.token, tt.body); res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
+
+func TestMintToken(t *testing.T) {
+	userID := primitive.NewObjectID()
+	service := NewMockShortcuts(gomock.NewController(t))
+	service.EXPECT().Mint(gomock.Any(), userID, MintRequest{Name: "Watch", Scopes: []string{"tasks:read-today"}}).Return(&MintResponse{Secret: tokenPrefix + "secret"}, nil)
+	service.EXPECT().Mint(gomock.Any(), userID, MintRequest{Name: "Phone", Scopes: []string{"tasks:create"}}).Return(nil, ErrTooManyTokens)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Post("/tokens", session(userID), handler.MintToken)
+
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"minted", `{"name": "Watch", "scopes": ["tasks:read-today"]}`, fiber.StatusCreated},
+		{"over the limit", `{"name": "Phone", "scopes": ["tasks:create"]}`, fiber.StatusConflict},
+		{"unknown scope", `{"name": "Watch", "scopes": ["admin"]}`, fiber.StatusBadRequest},
+		{"no scopes", `{"name": "Watch", "scopes": []}`, fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if res := call(t, app, http.MethodPost, "/tokens", sessionToken, tt.body); res.StatusCode != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, res.StatusCode)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	if hash("sct_a") == hash("sct_b") || hash("sct_a") != hash("sct_a") {
+		t.Error("expected the hash to tell secrets apart and be stable")
+	}
+	if strings.Contains(hash("sct_a"), "sct_a") {
+		t.Error("expected the hash not to contain the secret")
+	}
+}
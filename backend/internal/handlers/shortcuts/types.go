@@ -0,0 +1,211 @@
+package shortcuts
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Scope is what a scoped token is allowed to do
+type Scope string
+
+const (
+	ScopeTasksCreate    Scope = "tasks:create"
+	ScopeTasksReadToday Scope = "tasks:read-today"
+	ScopeTasksComplete  Scope = "tasks:complete"
+)
+
+/*
+Token is a long-lived token for Shortcuts, widgets and watch apps. Only a
+hash is stored; the token itself is shown once, when it is minted.
+*/
+type Token struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	User       primitive.ObjectID `bson:"user" json:"-"`
+	Name       string             `bson:"name" json:"name"`
+	Hash       string             `bson:"hash" json:"-"`
+	Scopes     []Scope            `bson:"scopes" json:"scopes"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+}
+
+type MintRequest struct {
+	// shown in the token list, e.g. "Apple Watch"
+	Name   string   `validate:"required,max=100" json:"name"`
+	Scopes []string `validate:"required,min=1,dive,oneof=tasks:create tasks:read-today tasks:complete" json:"scopes"`
+}
+
+type MintResponse struct {
+	Token
+	Secret string `json:"token"`
+}
+
+type QuickAddRequest struct {
+	Content string `validate:"required,max=500" json:"content"`
+	// one of the user's categories, the first one when empty
+	Category string `validate:"omitempty,mongodb" json:"category"`
+}
+
+type QuickAddResponse struct {
+	ID       primitive.ObjectID `json:"id"`
+	Category string             `json:"category"`
+}
+
+// Summary is kept small enough for a watch complication or a spoken Siri reply
+type Summary struct {
+	Date      string        `json:"date"`
+	Due       int           `json:"due"`
+	Overdue   int           `json:"overdue"`
+	Completed int           `json:"completed"`
+	Next      []SummaryTask `json:"next"`
+}
+
+type SummaryTask struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	Content  string             `bson:"content" json:"content"`
+	Priority int                `bson:"priority" json:"priority"`
+	DueDate  *time.Time         `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	// only read to sort tasks into the counts
+	Completed   bool       `bson:"completed" json:"-"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"-"`
+}
+
+type Intent string
+
+const (
+	IntentAddTask      Intent = "add_task"
+	IntentListToday    Intent = "list_today"
+	IntentCompleteTask Intent = "complete_task"
+)
+
+/*
+AssistantRequest is the intent schema a voice skill's fulfillment sends once
+its platform has matched what the user said to an intent.
+*/
+type AssistantRequest struct {
+	Intent string `validate:"required,oneof=add_task list_today complete_task" json:"intent"`
+	// what the user said the task is, for adding and completing
+	Title string `validate:"required_unless=Intent list_today,max=500" json:"title"`
+	// the device's IANA time zone, for where today starts
+	TimeZone string `validate:"max=64" json:"tz"`
+}
+
+// AssistantResponse is meant to be read out as is; the other fields are for cards on devices with screens
+type AssistantResponse struct {
+	Speech string        `json:"speech"`
+	Task   *SummaryTask  `json:"task,omitempty"`
+	Tasks  []SummaryTask `json:"tasks,omitempty"`
+}
+
+/*
+Widget is what home-screen widgets show. It only changes when tasks or the
+goal do, or the day turns, so its ETag stays valid across many refreshes.
+*/
+type Widget struct {
+	Date   string        `json:"date"`
+	Next   []SummaryTask `json:"next"`
+	Streak int           `json:"streak"`
+	Goal   Goal          `json:"goal"`
+}
+
+// Goal is the daily goal: how many tasks to complete each day, and how many are done today
+type Goal struct {
+	Target int `json:"target"`
+	Done   int `json:"done"`
+}
+
+type GoalRequest struct {
+	Target int `validate:"required,min=1,max=50" json:"target"`
+}
+
+/*
+DeviceCode is a pending device sign-in (RFC 8628). The device polls with the
+device code, which is only stored hashed, while the user enters the short
+user code in a signed-in app and approves it. Approval mints a scoped token.
+*/
+type DeviceCode struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	Hash     string             `bson:"hash"`
+	UserCode string             `bson:"user_code"`
+	Name     string             `bson:"name"`
+	Scopes   []Scope            `bson:"scopes"`
+	// set once approved
+	User   *primitive.ObjectID `bson:"user,omitempty"`
+	Denied bool                `bson:"denied"`
+	// seconds the device must wait between polls
+	Interval  int        `bson:"interval"`
+	PolledAt  *time.Time `bson:"polled_at,omitempty"`
+	CreatedAt time.Time  `bson:"created_at"`
+	ExpiresAt time.Time  `bson:"expires_at"`
+}
+
+type DeviceCodeRequest struct {
+	// becomes the token's name, e.g. "Chrome extension"
+	Name   string   `validate:"required,max=100" json:"name"`
+	Scopes []string `validate:"required,min=1,dive,oneof=tasks:create tasks:read-today tasks:complete" json:"scopes"`
+}
+
+// DeviceCodeResponse uses the RFC 8628 field names so stock device flow clients work
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type DeviceTokenRequest struct {
+	DeviceCode string `validate:"required" json:"device_code"`
+}
+
+// DeviceRequest is what the user is shown before approving a device
+type DeviceRequest struct {
+	Name      string    `json:"name"`
+	Scopes    []Scope   `json:"scopes"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type CaptureRequest struct {
+	URL   string `validate:"required,http_url,max=2048" json:"url"`
+	Title string `validate:"max=500" json:"title"`
+	// text the user had selected on the page
+	Selection string `validate:"max=5000" json:"selection"`
+	// overrides the capture category for this task
+	Category string `validate:"omitempty,mongodb" json:"category"`
+}
+
+type CaptureCategoryRequest struct {
+	Category string `validate:"required,mongodb" json:"category"`
+}
+
+type CaptureCategory struct {
+	ID   primitive.ObjectID `json:"id"`
+	Name string             `json:"name"`
+}
+
+type UnfurlPayload struct {
+	User primitive.ObjectID `bson:"user"`
+	Task primitive.ObjectID `bson:"task"`
+	// the task is titled with its URL until the page's title is known
+	Retitle bool `bson:"retitle"`
+}
+
+/*
+Shortcuts Service to be used by Shortcuts Handler to mint and check scoped
+tokens and serve the lightweight endpoints they unlock
+*/
+type Service struct {
+	Tokens  *mongo.Collection
+	Users   *mongo.Collection
+	Devices *mongo.Collection
+	tasks   *task.Service
+	cache   xcache.Cache
+	queue   *jobs.Queue
+	// the web app, for device verification links
+	webURL string
+}
@@ -0,0 +1,123 @@
+package shortcuts
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// until the user sets one
+	defaultGoal = 3
+	widgetTasks = 3
+	// the cache entry is per day, this only bounds how stale a missed invalidation leaves it
+	widgetTTL = 15 * time.Minute
+	// how far back a streak is counted
+	maxStreak = 365
+)
+
+// Widget is served from the cache, which task and profile writes invalidate
+func (s *Service) Widget(ctx context.Context, userID primitive.ObjectID, loc *time.Location) (*Widget, error) {
+	now := time.Now().In(loc)
+	date := now.Format(time.DateOnly)
+	return xcache.Fetch(ctx, s.cache, xcache.WidgetKey(userID.Hex(), loc.String(), date), widgetTTL,
+		[]string{xcache.CategoriesTag(userID.Hex()), xcache.UserTag(userID.Hex())},
+		func() (*Widget, error) {
+			return s.widget(ctx, userID, loc, now)
+		})
+}
+
+func (s *Service) widget(ctx context.Context, userID primitive.ObjectID, loc *time.Location, now time.Time) (*Widget, error) {
+	var owner struct {
+		DailyGoal int `bson:"daily_goal"`
+	}
+	err := s.Users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(bson.M{"daily_goal": 1})).Decode(&owner)
+	if err != nil {
+		return nil, err
+	}
+	summary, err := s.Today(ctx, userID, loc)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.completionDays(ctx, userID, loc, now)
+	if err != nil {
+		return nil, err
+	}
+
+	widget := &Widget{
+		Date:   summary.Date,
+		Next:   summary.Next[:min(len(summary.Next), widgetTasks)],
+		Streak: streak(days, now),
+		Goal:   Goal{Target: owner.DailyGoal, Done: summary.Completed},
+	}
+	if widget.Goal.Target == 0 {
+		widget.Goal.Target = defaultGoal
+	}
+	return widget, nil
+}
+
+// completionDays returns the days in loc on which the user completed a task, going back maxStreak days
+func (s *Service) completionDays(ctx context.Context, userID primitive.ObjectID, loc *time.Location, now time.Time) (map[string]bool, error) {
+	cursor, err := s.Users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": userID}}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$match", Value: bson.M{
+			"categories.tasks.completed_at": bson.M{"$gte": now.AddDate(0, 0, -maxStreak-1)},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%d",
+				"date":     "$categories.tasks.completed_at",
+				"timezone": loc.String(),
+			}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Day string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	days := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		days[row.Day] = true
+	}
+	return days, nil
+}
+
+// streak counts the days in a row with a completion, ending today, or yesterday while today is still open
+func streak(days map[string]bool, now time.Time) int {
+	day := now
+	if !days[day.Format(time.DateOnly)] {
+		day = day.AddDate(0, 0, -1)
+	}
+	count := 0
+	for count < maxStreak && days[day.Format(time.DateOnly)] {
+		count++
+		day = day.AddDate(0, 0, -1)
+	}
+	return count
+}
+
+func (s *Service) SetGoal(ctx context.Context, userID primitive.ObjectID, target int) error {
+	result, err := s.Users.UpdateOne(ctx, softdelete.Filter(bson.M{"_id": userID}), bson.M{"$set": bson.M{"daily_goal": target}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	xcache.Invalidate(ctx, s.cache, xcache.UserTag(userID.Hex()))
+	return nil
+}
@@ -1,6 +1,9 @@
 package socket
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/contrib/socketio"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -9,14 +12,18 @@ import (
 Router maps endpoints to handlers
 */
 
-func Routes(app *fiber.App, collections map[string]*mongo.Collection, stream *mongo.ChangeStream) {
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
 	service := newService(collections)
 	handler := Handler{service}
 
-	// waitGroup.Wait()
+	app.Get("/ws", xauth.TokenFromQuery, authenticate, handler.Connect)
 
-	app.Post("/ws/broadcast", handler.BroadcastRequest)
+	// a room is left when its socket disconnects
+	app.Get("/ws/:type/:id", xauth.TokenFromQuery, authenticate, xauth.Self("id"), handler.JoinRoom)
+	socketio.On(socketio.EventDisconnect, handler.leaveRoom)
 
-	app.Get("/ws/:type/:id", handler.JoinRoom)
-	app.Delete("/ws/:type/:id", handler.LeaveRoom)
+	xopenapi.Register(xopenapi.Operations{
+		"GET /ws":           {Summary: "Upgrade to a WebSocket of the caller's events; the token may be passed as ?access_token=", Auth: true, Query: xopenapi.Query{"access_token"}, Status: fiber.StatusSwitchingProtocols},
+		"GET /ws/:type/:id": {Summary: "Join the caller's room over a WebSocket; the token may be passed as ?access_token=", Auth: true, Query: xopenapi.Query{"access_token"}, Status: fiber.StatusSwitchingProtocols},
+	})
 }
@@ -13,10 +13,10 @@ func newService(collections map[string]*mongo.Collection) *Service {
 	return &Service{collections["sample"]}
 }
 
-func (s *Service) LeaveRoom(userId string) error {
+func (s *Service) LeaveRoom(ctx context.Context, userId string) error {
 	// remove the socketID from the user document
 
-	slog.LogAttrs(context.Background(), slog.LevelInfo, "Leaving Room", slog.String("userId", userId))
+	slog.LogAttrs(ctx, slog.LevelInfo, "Leaving Room", slog.String("userId", userId))
 
 	// turns the user id into an ObjectID
 	id, err := primitive.ObjectIDFromHex(userId)
@@ -26,7 +26,7 @@ func (s *Service) LeaveRoom(userId string) error {
 	filter := bson.M{"_id": id}
 	update := bson.M{"$set": bson.M{"socketID": nil}}
 
-	_, err = s.sample.UpdateOne(context.Background(), filter, update)
+	_, err = s.sample.UpdateOne(ctx, filter, update)
 
 	if err != nil {
 		return err
@@ -34,7 +34,7 @@ func (s *Service) LeaveRoom(userId string) error {
 	return nil
 }
 
-func (s *Service) JoinRoom(userId string, socketId string) error {
+func (s *Service) JoinRoom(ctx context.Context, userId string, socketId string) error {
 
 	// turns the user id into an ObjectID
 	id, err := primitive.ObjectIDFromHex(userId)
@@ -45,7 +45,7 @@ func (s *Service) JoinRoom(userId string, socketId string) error {
 	filter := bson.M{"_id": id}
 	update := bson.M{"$set": bson.M{"socketID": socketId}}
 
-	_, err = s.sample.UpdateOne(context.Background(), filter, update)
+	_, err = s.sample.UpdateOne(ctx, filter, update)
 
 	if err != nil {
 		return err
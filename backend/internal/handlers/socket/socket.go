@@ -1,47 +1,66 @@
 package socket
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
+	"github.com/abhikaboy/SocialToDo/internal/sockets"
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
 	"github.com/abhikaboy/SocialToDo/internal/xslog"
 	"github.com/gofiber/contrib/socketio"
+	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 )
 
+//go:generate mockgen -source=socket.go -destination=mock_test.go -package=socket
+
+// Rooms keeps track of the socket rooms users are in
+type Rooms interface {
+	LeaveRoom(ctx context.Context, userId string) error
+	JoinRoom(ctx context.Context, userId string, socketId string) error
+}
+
+var _ Rooms = (*Service)(nil)
+
 /*
 Handler to execute business logic for Health Endpoint
 */
 type Handler struct {
-	service *Service
+	service Rooms
 }
 
-func (h *Handler) LeaveRoom(c *fiber.Ctx) error {
-	slog.LogAttrs(c.Context(), slog.LevelInfo, "Leaving Room")
-	userId := c.Params("id")
-
-	err := h.service.LeaveRoom(userId)
-	if err != nil {
-		xslog.Error(err)
-		return err
+// leaveRoom drops the user's socket once it disconnects
+func (h *Handler) leaveRoom(ep *socketio.EventPayload) {
+	userId, _ := ep.Kws.GetAttribute("user_id").(string)
+	if userId == "" {
+		return
+	}
+	slog.LogAttrs(context.Background(), slog.LevelInfo, "Leaving Room")
+	if err := h.service.LeaveRoom(context.Background(), userId); err != nil {
+		slog.LogAttrs(context.Background(), slog.LevelError, "Failed to leave room", xslog.Error(err))
 	}
-	return c.SendStatus(fiber.StatusOK)
 }
 
+// JoinRoom upgrades to a socket.io connection in the caller's room, after xauth.Self checked :id is them
 func (h *Handler) JoinRoom(c *fiber.Ctx) error {
 	slog.LogAttrs(c.Context(), slog.LevelInfo, "Joining Room")
+	caller, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	userId := caller.Hex()
 	connectSocket := socketio.New(func(kws *socketio.Websocket) {
-
-		// Retrieve the user id from endpoint
-		userId := kws.Params("id") // get the user id
-
 		// Every websocket connection has an optional session key => value storage
 		kws.SetAttribute("user_id", userId)
-		h.service.JoinRoom(userId, kws.UUID)
+		kws.SetAttribute("user_type", kws.Params("type"))
+		// the upgraded connection outlives the request and its context
+		h.service.JoinRoom(context.Background(), userId, kws.UUID)
 
 		kws.Emit([]byte(fmt.Sprintf("Hello user: %s with UUID: %s", userId, kws.UUID)))
 	})
-	err := connectSocket(c)
+	err = connectSocket(c)
 	if err != nil {
 		xslog.Error(err)
 		return err
@@ -50,7 +69,54 @@ func (h *Handler) JoinRoom(c *fiber.Ctx) error {
 
 }
 
-func (h *Handler) BroadcastRequest(c *fiber.Ctx) error {
-	socketio.Broadcast([]byte("Hello World"))
-	return c.SendString("Broadcast Request Sent")
+/*
+Connect upgrades to a WebSocket for the signed-in user and writes them every
+event the hub has for them: their task changes from other devices, their
+notifications and their friends' activity. Nothing is read from the client
+but the close.
+*/
+func (h *Handler) Connect(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return fiber.ErrUpgradeRequired
+	}
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Not Authorized")
+	}
+
+	return websocket.New(func(conn *websocket.Conn) {
+		messages, stop := sockets.Listen(userID)
+		defer stop()
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		ping := time.NewTicker(pingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case msg, ok := <-messages:
+				if !ok {
+					conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow"))
+					return
+				}
+				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+					return
+				}
+			case <-ping.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+					return
+				}
+			case <-closed:
+				return
+			}
+		}
+	})(c)
 }
@@ -1,9 +1,17 @@
 package socket
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	// keeps idle /ws connections open through proxies
+	pingInterval = 30 * time.Second
+	writeWait    = 10 * time.Second
+)
+
 /*
 Review Service to be used by Review Handler to interact with the
 Database layer of the application
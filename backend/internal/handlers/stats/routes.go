@@ -0,0 +1,26 @@
+package stats
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, authenticate fiber.Handler) {
+	service := newService(collections)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Users := apiV1.Group("/users", authenticate)
+	Users.Get("/me/stats", handler.GetMyStats)
+	Users.Get("/:id/stats", handler.GetUserStats)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/users/me/stats":  {Summary: "The caller's completion counts and streaks, by day and week in their time zone", Auth: true, Response: Stats{}},
+		"GET /api/v1/users/:id/stats": {Summary: "A friend's streaks and tasks completed this week, while they share their activity", Auth: true, Response: FriendStats{}},
+	})
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+RegisterJobs adds the stats job handlers to the worker. A recorded
+completion publishes the user's new stats as stats.updated.
+*/
+func RegisterJobs(worker *jobs.Worker, bus *events.Bus, collections map[string]*mongo.Collection) {
+	s := newService(collections)
+	worker.Handle(RecordJob, func(ctx context.Context, job *jobs.Job) error {
+		var payload RecordPayload
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		stats, err := s.Record(ctx, payload.User, payload.Task, payload.CompletedAt)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// deleted since
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		bus.Publish(ctx, events.Event{
+			Type:       events.StatsUpdated,
+			UserID:     payload.User.Hex(),
+			Collection: Collection,
+			DocumentID: payload.User.Hex(),
+			Payload:    stats,
+		})
+		return nil
+	})
+	worker.Handle(RecomputeJob, func(ctx context.Context, job *jobs.Job) error {
+		var payload RecomputePayload
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		_, err := s.Recompute(ctx, payload.User, time.Now())
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil
+		}
+		return err
+	})
+}
+
+// SubscribeEvents queues recording every completion for whoever completed the task, the owner or a collaborator
+func SubscribeEvents(bus *events.Bus, collections map[string]*mongo.Collection) {
+	s := newService(collections)
+	bus.SubscribeAsync(func(ctx context.Context, event events.Event) {
+		taskID, err := primitive.ObjectIDFromHex(event.DocumentID)
+		if err != nil {
+			return
+		}
+		userID, _ := primitive.ObjectIDFromHex(event.UserID)
+		if payload, err := events.DecodePayload[events.TaskCompletedPayload](event); err == nil && !payload.CompletedBy.IsZero() {
+			userID = payload.CompletedBy
+		}
+		if userID.IsZero() {
+			return
+		}
+		_, err = s.queue.Enqueue(ctx, RecordJob, RecordPayload{User: userID, Task: taskID, CompletedAt: event.OccurredAt})
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to queue completion stats",
+				slog.String("user_id", userID.Hex()), xslog.Error(err))
+		}
+	}, events.TaskCompleted)
+}
+
+// RegisterSchedules queues recomputing the stats of recently active users every night
+func RegisterSchedules(cron *scheduler.Scheduler, collections map[string]*mongo.Collection) {
+	s := newService(collections)
+	cron.Register("stats-recompute", "0 2 * * *", 10*time.Minute, s.QueueRecomputes)
+}
@@ -0,0 +1,175 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newService receives the map of collections and picks out Stats, Completions, Users and Jobs
+func newService(collections map[string]*mongo.Collection) *Service {
+	return &Service{
+		stats:       collections[Collection],
+		completions: collections[CompletionsCollection],
+		users:       collections["users"],
+		queue:       jobs.New(collections[jobs.Collection]),
+	}
+}
+
+// Record adds a completion and returns the user's stats brought up to date with it
+func (s *Service) Record(ctx context.Context, userID primitive.ObjectID, taskID primitive.ObjectID, at time.Time) (*Stats, error) {
+	_, err := s.completions.UpdateOne(ctx, bson.M{"_id": taskID},
+		bson.M{"$setOnInsert": bson.M{"user": userID, "completed_at": at}},
+		options.Update().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	return s.Recompute(ctx, userID, time.Now())
+}
+
+// Recompute sums up all of the user's completions again, in the time zone they have now
+func (s *Service) Recompute(ctx context.Context, userID primitive.ObjectID, now time.Time) (*Stats, error) {
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := s.completions.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user": userID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format":   "%Y-%m-%d",
+				"date":     "$completed_at",
+				"timezone": loc.String(),
+			}},
+			"completed": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "date": "$_id", "completed": 1}}},
+		{{Key: "$sort", Value: bson.M{"date": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var days []Day
+	if err := cursor.All(ctx, &days); err != nil {
+		return nil, err
+	}
+
+	stats := summarize(days, now.In(loc))
+	stats.User = userID
+	_, err = s.stats.ReplaceOne(ctx, bson.M{"_id": userID}, stats, options.Replace().SetUpsert(true))
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Mine is the user's stats, recomputed when they were last computed on an earlier day or in another time zone
+func (s *Service) Mine(ctx context.Context, userID primitive.ObjectID) (*Stats, error) {
+	now := time.Now()
+	stats, err := s.load(ctx, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if stats.TimeZone != loc.String() || !sameDay(stats.ComputedAt, now, loc) {
+		return s.Recompute(ctx, userID, now)
+	}
+	return stats, nil
+}
+
+/*
+Friend is the part of a user's stats their friends see, and only while the
+user shares their activity, as the feed does.
+*/
+func (s *Service) Friend(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) (*FriendStats, error) {
+	relations, err := privacy.Load(ctx, s.users, []primitive.ObjectID{viewer, id})
+	if err != nil {
+		return nil, err
+	}
+	owner, ok := relations[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	me, ok := relations[viewer]
+	if viewer != id && (!ok || !slices.Contains(owner.Friends, viewer) || !owner.Privacy.SharesActivity() || !privacy.CanView(me, owner)) {
+		return nil, ErrNotVisible
+	}
+
+	now := time.Now()
+	stats, err := s.load(ctx, id, now)
+	if err != nil {
+		return nil, err
+	}
+	return &FriendStats{
+		User:          id,
+		CurrentStreak: stats.Streak(now),
+		LongestStreak: stats.LongestStreak,
+		Week:          stats.ThisWeek(now),
+	}, nil
+}
+
+// load reads the user's stored stats, computing them the first time
+func (s *Service) load(ctx context.Context, userID primitive.ObjectID, now time.Time) (*Stats, error) {
+	var stats Stats
+	err := s.stats.FindOne(ctx, bson.M{"_id": userID}).Decode(&stats)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return s.Recompute(ctx, userID, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// location is the user's time zone, UTC until they set one
+func (s *Service) location(ctx context.Context, userID primitive.ObjectID) (*time.Location, error) {
+	var user struct {
+		TimeZone string `bson:"timezone"`
+	}
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}),
+		options.FindOne().SetProjection(bson.M{"timezone": 1})).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return Location(user.TimeZone), nil
+}
+
+/*
+QueueRecomputes queues refreshing the stats of everyone active within the
+weeks Stats covers, so streaks that lapsed end and the days and weeks move
+on for the feed and friends, who read the stored stats as they are.
+*/
+func (s *Service) QueueRecomputes(ctx context.Context) error {
+	since := time.Now().UTC().AddDate(0, 0, -7*historyWeeks-1).Format(time.DateOnly)
+	cursor, err := s.stats.Find(ctx, bson.M{"last_active": bson.M{"$gte": since}},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var stale struct {
+			User primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&stale); err != nil {
+			return err
+		}
+		if _, err := s.queue.Enqueue(ctx, RecomputeJob, RecomputePayload{User: stale.User}, jobs.MaxAttempts(5)); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=stats.go -destination=mock_test.go -package=stats
+
+// Statistics is what Handler needs of the service
+type Statistics interface {
+	Mine(ctx context.Context, userID primitive.ObjectID) (*Stats, error)
+	Friend(ctx context.Context, viewer primitive.ObjectID, id primitive.ObjectID) (*FriendStats, error)
+}
+
+var _ Statistics = (*Service)(nil)
+
+/*
+Handler to execute business logic for completion stats
+*/
+type Handler struct {
+	service Statistics
+}
+
+func (h *Handler) GetMyStats(c *fiber.Ctx) error {
+	userID, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	stats, err := h.service.Mine(c.UserContext(), userID)
+	if err != nil {
+		return statsError(c, err)
+	}
+	return c.JSON(stats)
+}
+
+func (h *Handler) GetUserStats(c *fiber.Ctx) error {
+	viewer, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+	stats, err := h.service.Friend(c.UserContext(), viewer, id)
+	if err != nil {
+		return statsError(c, err)
+	}
+	return c.JSON(stats)
+}
+
+func statsError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	case errors.Is(err, ErrNotVisible):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to load stats",
+	})
+}
@@ -0,0 +1,124 @@
+package stats
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+func TestSummarize(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a Wednesday
+	now := time.Date(2026, time.March, 11, 10, 0, 0, 0, newYork)
+	days := func(dates ...string) []Day {
+		list := make([]Day, 0, len(dates))
+		for _, date := range dates {
+			list = append(list, Day{Date: date, Completed: 2})
+		}
+		return list
+	}
+
+	tests := []struct {
+		name            string
+		days            []Day
+		expectedCurrent int
+		expectedLongest int
+		expectedWeek    int
+	}{
+		{"none", nil, 0, 0, 0},
+		{"through today", days("2026-03-01", "2026-03-02", "2026-03-03", "2026-03-05", "2026-03-09", "2026-03-10", "2026-03-11"), 3, 3, 6},
+		{"today still open", days("2026-03-08", "2026-03-09", "2026-03-10"), 3, 3, 4},
+		{"lapsed", days("2026-03-02", "2026-03-03", "2026-03-04", "2026-03-05", "2026-03-09"), 0, 4, 2},
+		{"across a month", days("2026-02-27", "2026-02-28", "2026-03-01"), 0, 3, 0},
+	}
+	for _, tt := range tests {
+		stats := summarize(tt.days, now)
+		if stats.CurrentStreak != tt.expectedCurrent || stats.LongestStreak != tt.expectedLongest {
+			t.Errorf("%s: expected streaks %d and %d, got %d and %d", tt.name, tt.expectedCurrent, tt.expectedLongest, stats.CurrentStreak, stats.LongestStreak)
+		}
+		if week := stats.ThisWeek(now); week != tt.expectedWeek {
+			t.Errorf("%s: expected %d this week, got %d", tt.name, tt.expectedWeek, week)
+		}
+		if stats.Total != 2*len(tt.days) {
+			t.Errorf("%s: expected %d in total, got %d", tt.name, 2*len(tt.days), stats.Total)
+		}
+		if len(stats.Days) != historyDays || stats.Days[historyDays-1].Date != "2026-03-11" {
+			t.Errorf("%s: expected %d days ending today, got %v", tt.name, historyDays, stats.Days)
+		}
+		if len(stats.Weeks) != historyWeeks || stats.Weeks[historyWeeks-1].Start != "2026-03-09" {
+			t.Errorf("%s: expected %d weeks ending this one, got %v", tt.name, historyWeeks, stats.Weeks)
+		}
+	}
+}
+
+func TestStreak(t *testing.T) {
+	stats := Stats{CurrentStreak: 5, LastActive: "2026-03-10"}
+	// 01:00 on March 12 in Tokyo, still March 11 in UTC
+	now := time.Date(2026, time.March, 11, 16, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		timeZone string
+		expected int
+	}{
+		{"yesterday in UTC", "UTC", 5},
+		{"the day before yesterday in Tokyo", "Asia/Tokyo", 0},
+		{"unknown time zone", "Mars/Base", 5},
+	}
+	for _, tt := range tests {
+		stats.TimeZone = tt.timeZone
+		if got := stats.Streak(now); got != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestStatsRoutes(t *testing.T) {
+	userID, friendID, strangerID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	service := NewMockStatistics(gomock.NewController(t))
+	service.EXPECT().Mine(gomock.Any(), userID).Return(&Stats{User: userID, CurrentStreak: 3}, nil)
+	service.EXPECT().Friend(gomock.Any(), userID, friendID).Return(&FriendStats{User: friendID, CurrentStreak: 2}, nil)
+	service.EXPECT().Friend(gomock.Any(), userID, strangerID).Return(nil, ErrNotVisible)
+	service.EXPECT().Friend(gomock.Any(), userID, userID).Return(nil, mongo.ErrNoDocuments)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Get("/users/me/stats", handler.GetMyStats)
+	app.Get("/users/:id/stats", handler.GetUserStats)
+
+	tests := []struct {
+		name         string
+		route        string
+		expectedCode int
+	}{
+		{"mine", "/users/me/stats", fiber.StatusOK},
+		{"a friend's", "/users/" + friendID.Hex() + "/stats", fiber.StatusOK},
+		{"a stranger's", "/users/" + strangerID.Hex() + "/stats", fiber.StatusForbidden},
+		{"a deleted user's", "/users/" + userID.Hex() + "/stats", fiber.StatusNotFound},
+		{"invalid id", "/users/nope/stats", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, tt.route, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expectedCode {
+			body, _ := io.ReadAll(res.Body)
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.expectedCode, res.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package stats
+
+import "time"
+
+// summarize works out the stats from the days with completions, oldest first, as of now in the user's time zone
+func summarize(days []Day, now time.Time) Stats {
+	loc := now.Location()
+	stats := Stats{TimeZone: loc.String(), ComputedAt: now}
+
+	counts := make(map[string]int, len(days))
+	run := 0
+	var previous time.Time
+	for _, day := range days {
+		date, err := time.ParseInLocation(time.DateOnly, day.Date, loc)
+		if err != nil {
+			continue
+		}
+		counts[day.Date] = day.Completed
+		stats.Total += day.Completed
+		if !previous.IsZero() && date.Equal(previous.AddDate(0, 0, 1)) {
+			run++
+		} else {
+			run = 1
+		}
+		stats.LongestStreak = max(stats.LongestStreak, run)
+		stats.LastActive = day.Date
+		previous = date
+	}
+	// the run up to the last day with completions, which only counts while that is today or yesterday
+	stats.CurrentStreak = run
+	stats.CurrentStreak = stats.Streak(now)
+
+	today := dayOf(now)
+	stats.Days = make([]Day, 0, historyDays)
+	for i := historyDays - 1; i >= 0; i-- {
+		date := today.AddDate(0, 0, -i).Format(time.DateOnly)
+		stats.Days = append(stats.Days, Day{Date: date, Completed: counts[date]})
+	}
+
+	monday := WeekOf(now)
+	stats.Weeks = make([]Week, 0, historyWeeks)
+	for i := historyWeeks - 1; i >= 0; i-- {
+		start := monday.AddDate(0, 0, -7*i)
+		week := Week{Start: start.Format(time.DateOnly)}
+		for d := range 7 {
+			if completed := counts[start.AddDate(0, 0, d).Format(time.DateOnly)]; completed > 0 {
+				week.Completed += completed
+				week.ActiveDays++
+			}
+		}
+		stats.Weeks = append(stats.Weeks, week)
+	}
+	return stats
+}
+
+// Streak is CurrentStreak as of now, over once a whole day has gone by without a completion
+func (s *Stats) Streak(now time.Time) int {
+	now = now.In(Location(s.TimeZone))
+	if s.LastActive != now.Format(time.DateOnly) && s.LastActive != now.AddDate(0, 0, -1).Format(time.DateOnly) {
+		return 0
+	}
+	return s.CurrentStreak
+}
+
+// ThisWeek is how many tasks were completed in the week now falls in
+func (s *Stats) ThisWeek(now time.Time) int {
+	start := WeekOf(now.In(Location(s.TimeZone))).Format(time.DateOnly)
+	for _, week := range s.Weeks {
+		if week.Start == start {
+			return week.Completed
+		}
+	}
+	return 0
+}
+
+// Location loads a time zone name, falling back to UTC for none or one that isn't known
+func Location(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// weekOf is the start of t's week, Monday 00:00 in t's location
+func WeekOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+}
+
+func sameDay(a time.Time, b time.Time, loc *time.Location) bool {
+	return a.In(loc).Format(time.DateOnly) == b.In(loc).Format(time.DateOnly)
+}
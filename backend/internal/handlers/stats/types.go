@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	Collection = "stats"
+	// every task completed, which the stats are computed from
+	CompletionsCollection = "completions"
+
+	// crediting a completion to whoever completed the task
+	RecordJob = "stats.record"
+	// summing up one user's completions again, queued nightly for the recently active
+	RecomputeJob = "stats.recompute"
+
+	// the days and weeks Stats carries counts for, ending today
+	historyDays  = 28
+	historyWeeks = 8
+)
+
+var ErrNotVisible = errors.New("their stats are only visible to friends they share their activity with")
+
+// Completion is one task completed; keyed by the task, so an event delivered twice counts once
+type Completion struct {
+	Task        primitive.ObjectID `bson:"_id"`
+	User        primitive.ObjectID `bson:"user"`
+	CompletedAt time.Time          `bson:"completed_at"`
+}
+
+type RecordPayload struct {
+	User        primitive.ObjectID `bson:"user"`
+	Task        primitive.ObjectID `bson:"task"`
+	CompletedAt time.Time          `bson:"completed_at"`
+}
+
+type RecomputePayload struct {
+	User primitive.ObjectID `bson:"user"`
+}
+
+/*
+Stats is a user's completions summed up in their time zone, where days start
+at midnight and weeks on Monday. A streak is the days in a row with at least
+one completion, ending today, or yesterday while today is still open.
+*/
+type Stats struct {
+	User     primitive.ObjectID `bson:"_id" json:"user_id"`
+	TimeZone string             `bson:"tz" json:"tz"`
+	Total    int                `bson:"total" json:"total"`
+	// as of ComputedAt, see Streak for now
+	CurrentStreak int `bson:"current_streak" json:"current_streak"`
+	LongestStreak int `bson:"longest_streak" json:"longest_streak"`
+	// the last day with a completion, 2006-01-02
+	LastActive string `bson:"last_active,omitempty" json:"last_active,omitempty"`
+	// oldest first, today last
+	Days       []Day     `bson:"days" json:"days"`
+	Weeks      []Week    `bson:"weeks" json:"weeks"`
+	ComputedAt time.Time `bson:"computed_at" json:"computed_at"`
+}
+
+type Day struct {
+	Date      string `bson:"date" json:"date"`
+	Completed int    `bson:"completed" json:"completed"`
+}
+
+type Week struct {
+	// the Monday it starts
+	Start      string `bson:"start" json:"start"`
+	Completed  int    `bson:"completed" json:"completed"`
+	ActiveDays int    `bson:"active_days" json:"active_days"`
+}
+
+// FriendStats is what a user's friends see of their stats, alongside their activity
+type FriendStats struct {
+	User          primitive.ObjectID `json:"user_id"`
+	CurrentStreak int                `json:"current_streak"`
+	LongestStreak int                `json:"longest_streak"`
+	// tasks completed this week
+	Week int `json:"week"`
+}
+
+/*
+Stats Service to be used by Stats Handler to interact with the
+Database layer of the application
+*/
+type Service struct {
+	stats       *mongo.Collection
+	completions *mongo.Collection
+	users       *mongo.Collection
+	queue       *jobs.Queue
+}
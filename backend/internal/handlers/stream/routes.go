@@ -0,0 +1,24 @@
+package stream
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, bus *events.Bus, authenticate fiber.Handler) {
+	service := newService(bus)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	apiV1.Get("/stream", xauth.TokenFromQuery, authenticate, handler.Stream)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/stream": {Summary: "Server-sent events of the caller's changes, replayed from Last-Event-ID", Auth: true, Query: xopenapi.Query{"access_token", "lastEventId"}, Response: "", ResponseType: "text/event-stream"},
+	})
+}
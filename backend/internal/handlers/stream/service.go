@@ -0,0 +1,142 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newService(bus *events.Bus) *Service {
+	s := &Service{
+		instance:    primitive.NewObjectID().Hex(),
+		history:     make(map[string]*history),
+		subscribers: make(map[string]map[chan Message]struct{}),
+	}
+	// same event types the WebSocket hub forwards
+	bus.Subscribe(s.publish, events.TasksChanged, events.FeedCreated, events.NotificationCreated)
+	return s
+}
+
+func (s *Service) publish(_ context.Context, event events.Event) {
+	if event.UserID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(time.Now())
+
+	s.seq++
+	msg := Message{ID: s.id(s.seq), Event: event, seq: s.seq}
+
+	// only users who connected recently have a history to resume from
+	if h, ok := s.history[event.UserID]; ok {
+		h.messages = append(h.messages, msg)
+		if len(h.messages) > bufferSize {
+			h.from = h.messages[len(h.messages)-bufferSize-1].seq
+			h.messages = h.messages[len(h.messages)-bufferSize:]
+		}
+	}
+
+	for ch := range s.subscribers[event.UserID] {
+		select {
+		case ch <- msg:
+		default:
+			// the client isn't keeping up; closing makes it reconnect and replay
+			delete(s.subscribers[event.UserID], ch)
+			close(ch)
+		}
+	}
+}
+
+/*
+Subscribe registers a new client for userID and returns the buffered events
+newer than lastEventID along with the live channel. When the history can't
+cover lastEventID, the replay is a single Resync message instead.
+*/
+func (s *Service) Subscribe(userID string, lastEventID string) ([]Message, chan Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweep(now)
+
+	h, ok := s.history[userID]
+	if !ok {
+		h = &history{from: s.seq}
+		s.history[userID] = h
+	}
+	h.seen = now
+
+	replay := make([]Message, 0)
+	if lastEventID != "" {
+		if seq, ok := s.parseID(lastEventID); ok && seq >= h.from {
+			for _, msg := range h.messages {
+				if msg.seq > seq {
+					replay = append(replay, msg)
+				}
+			}
+		} else {
+			// from another instance, or older than the history
+			replay = append(replay, Message{
+				ID:    s.id(s.seq),
+				Event: events.Event{Type: Resync, UserID: userID, OccurredAt: now},
+				seq:   s.seq,
+			})
+		}
+	}
+
+	ch := make(chan Message, subscriberBuffer)
+	if s.subscribers[userID] == nil {
+		s.subscribers[userID] = make(map[chan Message]struct{})
+	}
+	s.subscribers[userID][ch] = struct{}{}
+	return replay, ch
+}
+
+func (s *Service) Unsubscribe(userID string, ch chan Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[userID][ch]; ok {
+		delete(s.subscribers[userID], ch)
+		close(ch)
+	}
+	if len(s.subscribers[userID]) == 0 {
+		delete(s.subscribers, userID)
+	}
+	if h, ok := s.history[userID]; ok {
+		h.seen = time.Now()
+	}
+}
+
+// sweep drops the histories of users with no client connected for historyTTL; s.mu is held
+func (s *Service) sweep(now time.Time) {
+	if now.Sub(s.swept) < sweepInterval {
+		return
+	}
+	s.swept = now
+	for userID, h := range s.history {
+		if len(s.subscribers[userID]) == 0 && now.Sub(h.seen) > historyTTL {
+			delete(s.history, userID)
+		}
+	}
+}
+
+func (s *Service) id(seq uint64) string {
+	return fmt.Sprintf("%s-%d", s.instance, seq)
+}
+
+// parseID reads the sequence number out of one of this instance's ids
+func (s *Service) parseID(id string) (uint64, bool) {
+	instance, seq, ok := strings.Cut(id, "-")
+	if !ok || instance != s.instance {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
@@ -0,0 +1,75 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+)
+
+func TestReplay(t *testing.T) {
+	s := newService(events.NewBus())
+	ctx := context.Background()
+	changed := events.Event{Type: events.TasksChanged, UserID: "alice"}
+
+	// nobody has connected yet, so nothing is kept
+	s.publish(ctx, changed)
+	s.publish(ctx, changed)
+	_, ch := s.Subscribe("alice", "")
+	s.publish(ctx, changed)
+	last := (<-ch).ID
+	s.Unsubscribe("alice", ch)
+	s.publish(ctx, changed)
+	s.publish(ctx, changed)
+
+	other := newService(events.NewBus())
+	tests := []struct {
+		name        string
+		lastEventID string
+		expected    int
+		resync      bool
+	}{
+		{"fresh connection", "", 0, false},
+		{"resumed", last, 2, false},
+		{"from before the history", s.id(1), 1, true},
+		{"from another instance", other.id(2), 1, true},
+		{"malformed", "2", 1, true},
+	}
+	for _, tt := range tests {
+		replay, ch := s.Subscribe("alice", tt.lastEventID)
+		s.Unsubscribe("alice", ch)
+		if len(replay) != tt.expected {
+			t.Fatalf("%s: expected %d messages, got %+v", tt.name, tt.expected, replay)
+		}
+		if resync := len(replay) > 0 && replay[0].Event.Type == Resync; resync != tt.resync {
+			t.Errorf("%s: expected resync %v, got %+v", tt.name, tt.resync, replay)
+		}
+	}
+
+	// the history outlives the last connection by historyTTL
+	s.history["alice"].seen = time.Now().Add(-historyTTL - time.Second)
+	s.swept = time.Time{}
+	s.publish(ctx, changed)
+	if _, ok := s.history["alice"]; ok {
+		t.Error("expected the expired history dropped")
+	}
+	if replay, _ := s.Subscribe("alice", last); len(replay) != 1 || replay[0].Event.Type != Resync {
+		t.Errorf("expired history: expected a resync, got %+v", replay)
+	}
+}
+
+func TestOverflow(t *testing.T) {
+	s := newService(events.NewBus())
+	_, ch := s.Subscribe("alice", "")
+	s.Unsubscribe("alice", ch)
+	for range bufferSize + 2 {
+		s.publish(context.Background(), events.Event{Type: events.TasksChanged, UserID: "alice"})
+	}
+	if replay, _ := s.Subscribe("alice", s.id(1)); len(replay) != 1 || replay[0].Event.Type != Resync {
+		t.Errorf("overflowed: expected a resync, got %+v", replay)
+	}
+	if replay, _ := s.Subscribe("alice", s.id(2)); len(replay) != bufferSize {
+		t.Errorf("expected %d messages, got %d", bufferSize, len(replay))
+	}
+}
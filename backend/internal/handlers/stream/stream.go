@@ -0,0 +1,102 @@
+package stream
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+//go:generate mockgen -source=stream.go -destination=mock_test.go -package=stream
+
+// Streams is the event stream service as Handler uses it
+type Streams interface {
+	Subscribe(userID string, lastEventID string) ([]Message, chan Message)
+	Unsubscribe(userID string, ch chan Message)
+}
+
+var _ Streams = (*Service)(nil)
+
+/*
+Handler to execute business logic for the Server-Sent Events stream
+*/
+type Handler struct {
+	service Streams
+}
+
+/*
+Stream holds the connection open and writes events as they happen.
+Clients reconnect with the Last-Event-ID header (sent automatically by
+EventSource) to receive what they missed, or a stream.resync event when it
+can't be replayed, on which they refetch.
+*/
+func (h *Handler) Stream(c *fiber.Ctx) error {
+	userID, _ := c.Locals("user_id").(string)
+	if userID == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "Not Authorized")
+	}
+
+	replay, ch := h.service.Subscribe(userID, c.Get("Last-Event-ID", c.Query("lastEventId")))
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	// stop reverse proxies (nginx) from buffering the stream
+	c.Set("X-Accel-Buffering", "no")
+
+	// closed when the server starts shutting down
+	done := c.Context().Done()
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer h.service.Unsubscribe(userID, ch)
+
+		// tell EventSource how long to wait before reconnecting
+		fmt.Fprint(w, "retry: 3000\n\n")
+		for _, msg := range replay {
+			if err := write(w, msg); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := write(w, msg); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			}
+			// a failed flush means the client went away
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}))
+	return nil
+}
+
+func write(w *bufio.Writer, msg Message) error {
+	data, err := json.Marshal(msg.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Event.Type, data)
+	return err
+}
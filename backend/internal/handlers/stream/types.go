@@ -0,0 +1,55 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+)
+
+const (
+	// events kept per user for Last-Event-ID replay
+	bufferSize = 100
+	// events a slow client can fall behind before it is disconnected
+	subscriberBuffer = 32
+	// how long a user's history is kept after their last client disconnects
+	historyTTL = 5 * time.Minute
+	// how often expired histories are looked for
+	sweepInterval = time.Minute
+)
+
+// Resync is sent instead of a replay the history can't cover; the client refetches its state
+const Resync events.Type = "stream.resync"
+
+// Message is an event numbered for Last-Event-ID
+type Message struct {
+	// the instance and sequence number, so an id from another process is recognised
+	ID    string
+	Event events.Event
+	seq   uint64
+}
+
+// history is the events kept for a user who connected recently
+type history struct {
+	messages []Message
+	// the newest sequence number not kept, a Last-Event-ID before it missed events
+	from uint64
+	// when a client last connected or disconnected
+	seen time.Time
+}
+
+/*
+Stream Service fans events from the bus out to connected SSE clients and keeps
+a short history for users who connected recently, so reconnecting clients can
+resume from Last-Event-ID. History is per process: a client that reconnects to
+another instance, or after its history expired or overflowed, is told to
+resync instead.
+*/
+type Service struct {
+	mu          sync.Mutex
+	instance    string
+	seq         uint64
+	history     map[string]*history
+	subscribers map[string]map[chan Message]struct{}
+	swept       time.Time
+}
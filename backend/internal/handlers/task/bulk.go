@@ -0,0 +1,476 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	"github.com/abhikaboy/SocialToDo/internal/outbox"
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/tombstone"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrBulkOperation = errors.New("invalid operation")
+	// a change naming a category the user can't edit, told apart from a missing task
+	errNoCategory = fmt.Errorf("category: %w", mongo.ErrNoDocuments)
+)
+
+/*
+BulkTasks applies the operations in order, each to the tasks as the ones
+before it left them, and reports how each went. An operation that fails
+doesn't stop the others; they are written together, so only an error writing
+them fails the request.
+*/
+func (s *Service) BulkTasks(ctx context.Context, userId primitive.ObjectID, operations []BulkOperation) (_ []BulkResult, err error) {
+	defer xmetrics.Track("task", "BulkTasks")(&err)
+
+	now := time.Now()
+	results := make([]BulkResult, len(operations))
+	changes := make([]BulkChange, 0, len(operations))
+	// the operation each change came from
+	from := make([]int, 0, len(operations))
+	for i, op := range operations {
+		change, err := bulkChange(op, now)
+		if err != nil {
+			results[i] = bulkResult(op.Action, op.ID, err)
+			continue
+		}
+		changes = append(changes, change)
+		from = append(from, i)
+	}
+	if len(changes) == 0 {
+		return results, nil
+	}
+
+	outcomes, owners, err := s.repo.Bulk(ctx, userId, changes, now)
+	if err != nil {
+		return nil, err
+	}
+	for i, outcome := range outcomes {
+		results[from[i]] = bulkResult(changes[i].Action, changes[i].ID, outcome)
+	}
+	for _, owner := range owners {
+		xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(owner.Hex()))
+	}
+	return results, nil
+}
+
+// bulkChange checks an operation has what its action needs, building the task a create adds
+func bulkChange(op BulkOperation, now time.Time) (BulkChange, error) {
+	change := BulkChange{Action: op.Action, ID: op.ID, Category: op.Category}
+	switch op.Action {
+	case BulkCreate:
+		if op.Task == nil || op.Category.IsZero() {
+			return change, fmt.Errorf("%w: create needs a task and a category", ErrBulkOperation)
+		}
+		doc := newTask(*op.Task, now)
+		if !op.ID.IsZero() {
+			doc.ID = op.ID
+		}
+		if err := prepare(&doc); err != nil {
+			return change, err
+		}
+		change.ID, change.Task = doc.ID, &doc
+	case BulkMove:
+		if op.ID.IsZero() || op.Category.IsZero() {
+			return change, fmt.Errorf("%w: move needs an id and a category", ErrBulkOperation)
+		}
+	default:
+		if op.ID.IsZero() {
+			return change, fmt.Errorf("%w: %s needs an id", ErrBulkOperation, op.Action)
+		}
+	}
+	return change, nil
+}
+
+// bulkResult is the status an operation would have been answered with on its own
+func bulkResult(action BulkAction, id primitive.ObjectID, err error) BulkResult {
+	result := BulkResult{Status: fiber.StatusOK}
+	if !id.IsZero() {
+		result.ID = &id
+	}
+	switch {
+	case err == nil && action == BulkCreate:
+		result.Status = fiber.StatusCreated
+	case err == nil:
+	case errors.Is(err, errNoCategory):
+		result.Status, result.Error = fiber.StatusNotFound, "Category not found"
+	case errors.Is(err, mongo.ErrNoDocuments):
+		result.Status, result.Error = fiber.StatusNotFound, "Task not found"
+	case errors.Is(err, ErrAlreadyCompleted), errors.Is(err, ErrTaskExists):
+		result.Status, result.Error = fiber.StatusConflict, err.Error()
+	case errors.Is(err, ErrBulkOperation), errors.Is(err, ErrMoveToOtherOwner), invalidSchedule(err):
+		result.Status, result.Error = fiber.StatusBadRequest, err.Error()
+	default:
+		result.Status, result.Error = fiber.StatusInternalServerError, "Failed to apply operation"
+	}
+	return result
+}
+
+/*
+bulkState is the live categories a bulk request touches, keyed by their
+owner, as the changes applied so far left them. MemoryRepository keeps its
+tasks in one.
+*/
+type bulkState map[primitive.ObjectID][]*memoryCategory
+
+// bulkStep is what applying a change did, for writing it
+type bulkStep struct {
+	owner primitive.ObjectID
+	// the task as the change left it
+	task TaskDocument
+	// the category the task was in before the change, and after it
+	from primitive.ObjectID
+	to   primitive.ObjectID
+	// the completion took the rule of its series along to next, nil when the series ended
+	advanced bool
+	next     *TaskDocument
+}
+
+// apply makes the change as userID, who has to be able to edit the categories it touches
+func (s bulkState) apply(userID primitive.ObjectID, change BulkChange, at time.Time) (bulkStep, error) {
+	if change.Action == BulkCreate {
+		if _, existing, _ := s.find(change.ID); existing != nil {
+			return bulkStep{}, ErrTaskExists
+		}
+		owner, category := s.shared(userID, change.Category, sharing.Editor)
+		if category == nil {
+			return bulkStep{}, errNoCategory
+		}
+		category.Tasks = append(category.Tasks, *change.Task)
+		return bulkStep{owner: owner, task: *change.Task, to: category.ID}, nil
+	}
+
+	owner, category, i := s.find(change.ID)
+	if category == nil || !sharing.Allows(owner, category.Members, userID, sharing.Editor) {
+		return bulkStep{}, mongo.ErrNoDocuments
+	}
+	step := bulkStep{owner: owner, from: category.ID, to: category.ID}
+	t := &category.Tasks[i]
+	switch change.Action {
+	case BulkComplete:
+		if t.Completed {
+			return bulkStep{}, ErrAlreadyCompleted
+		}
+		t.Completed, t.CompletedAt, t.Status = true, &at, Done
+		t.Version++
+		t.UpdatedAt = at
+		if t.Recurrence != nil {
+			// as in Complete, the next occurrence is due after the one completed, or after now when that one was late
+			after := *t.DueDate
+			if at.After(after) {
+				after = at
+			}
+			step.advanced, step.next = true, nextOccurrence(*t, after, at)
+			t.Recurrence = nil
+		}
+		step.task = *t
+		if step.next != nil {
+			category.Tasks = append(category.Tasks, *step.next)
+		}
+	case BulkDelete:
+		step.task = *t
+		category.Tasks = slices.Delete(category.Tasks, i, i+1)
+	case BulkMove:
+		to, target := s.shared(userID, change.Category, sharing.Editor)
+		if target == nil {
+			return bulkStep{}, errNoCategory
+		}
+		if to != owner {
+			return bulkStep{}, ErrMoveToOtherOwner
+		}
+		step.task = *t
+		if target != category {
+			step.task.Version++
+			step.task.UpdatedAt = at
+			step.to = target.ID
+			target.Tasks = append(target.Tasks, step.task)
+			category.Tasks = slices.Delete(category.Tasks, i, i+1)
+		}
+	}
+	return step, nil
+}
+
+// shared looks up a category the user owns or has a role in granting need, and its owner
+func (s bulkState) shared(userID primitive.ObjectID, categoryID primitive.ObjectID, need sharing.Role) (primitive.ObjectID, *memoryCategory) {
+	for owner, categories := range s {
+		for _, category := range categories {
+			if category.ID == categoryID && sharing.Allows(owner, category.Members, userID, need) {
+				return owner, category
+			}
+		}
+	}
+	return primitive.NilObjectID, nil
+}
+
+// find locates a task, its category and owner
+func (s bulkState) find(id primitive.ObjectID) (primitive.ObjectID, *memoryCategory, int) {
+	for owner, categories := range s {
+		for _, category := range categories {
+			for i, t := range category.Tasks {
+				if t.ID == id {
+					return owner, category, i
+				}
+			}
+		}
+	}
+	return primitive.NilObjectID, nil, -1
+}
+
+func (r *MemoryRepository) Bulk(ctx context.Context, userID primitive.ObjectID, changes []BulkChange, at time.Time) ([]error, []primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	outcomes := make([]error, len(changes))
+	var owners []primitive.ObjectID
+	for i, change := range changes {
+		step, err := bulkState(r.categories).apply(userID, change, at)
+		outcomes[i] = err
+		if err == nil && !slices.Contains(owners, step.owner) {
+			owners = append(owners, step.owner)
+		}
+	}
+	return outcomes, owners, nil
+}
+
+/*
+Bulk works the changes out on the documents holding the categories and tasks
+they name, read in the transaction, and writes them with one ordered
+bulkWrite. It queues the events, activity and completed counts the single
+writes do in the same transaction, and records tombstones once it commits.
+*/
+func (r *mongoRepository) Bulk(ctx context.Context, userID primitive.ObjectID, changes []BulkChange, at time.Time) ([]error, []primitive.ObjectID, error) {
+	var (
+		outcomes []error
+		owners   []primitive.ObjectID
+		deleted  map[primitive.ObjectID][]primitive.ObjectID
+	)
+	err := xmongo.WithTransaction(ctx, r.users, func(sc mongo.SessionContext) error {
+		state, err := r.bulkState(sc, changes)
+		if err != nil {
+			return err
+		}
+		// a retried transaction starts over
+		outcomes, owners, deleted = make([]error, len(changes)), nil, make(map[primitive.ObjectID][]primitive.ObjectID)
+
+		var (
+			models    []mongo.WriteModel
+			queued    []events.Event
+			entries   []interface{}
+			completed int
+		)
+		for i, change := range changes {
+			step, err := state.apply(userID, change, at)
+			outcomes[i] = err
+			if err != nil {
+				continue
+			}
+			if !slices.Contains(owners, step.owner) {
+				owners = append(owners, step.owner)
+			}
+			models = append(models, bulkModels(change.Action, step, at)...)
+
+			switch change.Action {
+			case BulkCreate:
+				queued = append(queued, events.Event{
+					Type:       events.TaskCreated,
+					UserID:     step.owner.Hex(),
+					Collection: "users",
+					DocumentID: step.task.ID.Hex(),
+					Payload:    events.TaskCreatedPayload{TaskID: step.task.ID, CategoryID: step.to, SeriesID: step.task.SeriesID},
+					OccurredAt: at,
+				})
+			case BulkComplete:
+				completed++
+				queued = append(queued, events.Event{
+					Type:       events.TaskCompleted,
+					UserID:     step.owner.Hex(),
+					Collection: "users",
+					DocumentID: step.task.ID.Hex(),
+					Payload:    events.TaskCompletedPayload{TaskID: step.task.ID, CompletedAt: at, CompletedBy: userID},
+					OccurredAt: at,
+				})
+				if step.task.Public || userID != step.owner {
+					entries = append(entries, activity.ActivityEntry{
+						ActivityDocument: activity.ActivityDocument{
+							ID:        primitive.NewObjectID(),
+							Field1:    step.task.Content,
+							Field2:    activity.Completed,
+							Timestamp: at,
+						},
+						User: userID,
+					})
+				}
+				if step.next != nil {
+					queued = append(queued, events.Event{
+						Type:       events.TaskCreated,
+						UserID:     step.owner.Hex(),
+						Collection: "users",
+						DocumentID: step.next.ID.Hex(),
+						Payload:    events.TaskCreatedPayload{TaskID: step.next.ID, SeriesID: step.next.SeriesID},
+						OccurredAt: at,
+					})
+				}
+			case BulkDelete:
+				deleted[step.owner] = append(deleted[step.owner], step.task.ID)
+			}
+		}
+		if len(models) == 0 {
+			return nil
+		}
+
+		if _, err := r.users.BulkWrite(sc, models, options.BulkWrite().SetOrdered(true)); err != nil {
+			return err
+		}
+		if completed > 0 {
+			if _, err := r.users.UpdateOne(sc, bson.M{"_id": userID}, bson.M{"$inc": bson.M{"tasks_complete": completed}}); err != nil {
+				return err
+			}
+		}
+		if len(entries) > 0 {
+			if _, err := r.activity.InsertMany(sc, entries); err != nil {
+				return err
+			}
+		}
+		for _, event := range queued {
+			if err := outbox.Write(sc, r.outbox, event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// offline clients learn about the deletes on their next sync
+	for owner, ids := range deleted {
+		if err := tombstone.Record(ctx, r.tombstones, owner, tombstone.Task, ids...); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to record task tombstones", xslog.Error(err))
+		}
+	}
+	return outcomes, owners, nil
+}
+
+/*
+bulkState reads the live categories of the users holding any category or task
+the changes name. Whole categories are read, so later changes see what earlier
+ones did to them.
+*/
+func (r *mongoRepository) bulkState(ctx context.Context, changes []BulkChange) (bulkState, error) {
+	categoryIDs := make([]primitive.ObjectID, 0, len(changes))
+	taskIDs := make([]primitive.ObjectID, 0, len(changes))
+	for _, change := range changes {
+		if !change.Category.IsZero() {
+			categoryIDs = append(categoryIDs, change.Category)
+		}
+		taskIDs = append(taskIDs, change.ID)
+	}
+	cursor, err := r.users.Find(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"categories._id": bson.M{"$in": categoryIDs}},
+			bson.M{"categories.tasks._id": bson.M{"$in": taskIDs}},
+		}},
+		options.Find().SetProjection(bson.M{"categories": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var users []struct {
+		ID         primitive.ObjectID `bson:"_id"`
+		Categories []struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			Name      string             `bson:"name"`
+			Tasks     []TaskDocument     `bson:"tasks"`
+			Members   []sharing.Member   `bson:"members"`
+			DeletedAt *time.Time         `bson:"deleted_at"`
+		} `bson:"categories"`
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	state := make(bulkState, len(users))
+	for _, user := range users {
+		for _, category := range user.Categories {
+			if category.DeletedAt != nil {
+				continue
+			}
+			state[user.ID] = append(state[user.ID], &memoryCategory{
+				ID:      category.ID,
+				Name:    category.Name,
+				Tasks:   softdelete.Visible(category.Tasks),
+				Members: category.Members,
+			})
+		}
+	}
+	return state, nil
+}
+
+// bulkModels are the writes of a step, addressing the owner's document; access was checked on reading it
+func bulkModels(action BulkAction, step bulkStep, at time.Time) []mongo.WriteModel {
+	owner := bson.M{"_id": step.owner}
+	in := func(category primitive.ObjectID) options.ArrayFilters {
+		return options.ArrayFilters{Filters: []interface{}{bson.M{"c._id": category}}}
+	}
+	task := options.ArrayFilters{Filters: []interface{}{bson.M{"t._id": step.task.ID}}}
+
+	switch action {
+	case BulkCreate:
+		return []mongo.WriteModel{mongo.NewUpdateOneModel().SetFilter(owner).
+			SetUpdate(bson.M{"$push": bson.M{"categories.$[c].tasks": step.task}}).
+			SetArrayFilters(in(step.to))}
+	case BulkComplete:
+		update := bson.M{
+			"$set": bson.M{
+				"categories.$[].tasks.$[t].completed":    true,
+				"categories.$[].tasks.$[t].completed_at": at,
+				"categories.$[].tasks.$[t].status":       Done,
+				"categories.$[].tasks.$[t].updated_at":   at,
+			},
+			"$inc": bson.M{"categories.$[].tasks.$[t]." + xmongo.VersionField: 1},
+		}
+		if step.advanced {
+			update["$unset"] = bson.M{"categories.$[].tasks.$[t].recurrence": ""}
+		}
+		models := []mongo.WriteModel{mongo.NewUpdateOneModel().SetFilter(owner).SetUpdate(update).SetArrayFilters(task)}
+		if step.next != nil {
+			models = append(models, mongo.NewUpdateOneModel().SetFilter(owner).
+				SetUpdate(bson.M{"$push": bson.M{"categories.$[c].tasks": step.next}}).
+				SetArrayFilters(in(step.from)))
+		}
+		return models
+	case BulkDelete:
+		return []mongo.WriteModel{mongo.NewUpdateOneModel().SetFilter(owner).
+			SetUpdate(bson.M{"$set": bson.M{"categories.$[].tasks.$[t]." + softdelete.Field: at}}).
+			SetArrayFilters(task)}
+	case BulkMove:
+		if step.from == step.to {
+			return nil
+		}
+		return []mongo.WriteModel{
+			mongo.NewUpdateOneModel().SetFilter(owner).
+				SetUpdate(bson.M{"$pull": bson.M{"categories.$[c].tasks": bson.M{"_id": step.task.ID}}}).
+				SetArrayFilters(in(step.from)),
+			mongo.NewUpdateOneModel().SetFilter(owner).
+				SetUpdate(bson.M{"$push": bson.M{"categories.$[c].tasks": step.task}}).
+				SetArrayFilters(in(step.to)),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package task
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+The /categories/:category/tasks routes are the signed-in user's own tasks,
+and those of categories shared with them. Viewers of a shared category can
+list its tasks and editors can change them too. The routes answer 404 for a
+category or task the caller can't reach, and otherwise share the handlers of
+the /Tasks routes, which OwnTask guards the same way.
+*/
+
+func (h *Handler) CreateCategoryTask(c *fiber.Ctx) error {
+	userId, categoryId, err := ownCategory(c)
+	if err != nil {
+		return err
+	}
+	return h.createTask(c, userId, categoryId)
+}
+
+func (h *Handler) GetCategoryTasks(c *fiber.Ctx) error {
+	userId, categoryId, err := ownCategory(c)
+	if err != nil {
+		return err
+	}
+
+	sort, err := sortParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid sortBy or sortDir",
+		})
+	}
+	filter, err := filterParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	Tasks, err := h.service.GetTasksByCategory(c.UserContext(), userId, categoryId, sort, filter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch Tasks",
+		})
+	}
+
+	return c.JSON(Tasks)
+}
+
+/*
+OwnTask lets the request through to the /Tasks handler only for a task the
+caller may edit, their own or a shared category's, in the :category of the
+route when it has one.
+*/
+func (h *Handler) OwnTask(c *fiber.Ctx) error {
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if c.Params("category") == "" {
+		err = h.service.Owns(c.UserContext(), userId, id)
+	} else {
+		var categoryId primitive.ObjectID
+		if _, categoryId, err = ownCategory(c); err != nil {
+			return err
+		}
+		err = h.service.InCategory(c.UserContext(), userId, categoryId, id)
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch Task",
+		})
+	}
+	return c.Next()
+}
+
+// ownCategory reads the caller and the :category param
+func ownCategory(c *fiber.Ctx) (primitive.ObjectID, primitive.ObjectID, error) {
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, err
+	}
+	categoryId, err := primitive.ObjectIDFromHex(c.Params("category"))
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, fiber.NewError(fiber.StatusBadRequest, "Invalid ID format")
+	}
+	return userId, categoryId, nil
+}
+
+/*
+sortParams reads ?sortBy= and ?sortDir=, newest first by default. Without
+?sortDir= priorities sort most urgent first and due dates soonest first.
+*/
+func sortParams(c *fiber.Ctx) (SortParams, error) {
+	sort := SortParams{SortBy: c.Query("sortBy"), SortDir: -1}
+	switch SortTypes(sort.SortBy) {
+	case "", "none":
+		sort.SortBy = string(Time)
+	case "difficulty":
+		sort.SortBy = string(Difficulty)
+	case Priority, Due:
+		sort.SortDir = 1
+	case Time, Difficulty:
+	default:
+		return sort, ErrInvalidSort
+	}
+	if c.Query("sortDir") != "" {
+		dir, err := strconv.Atoi(c.Query("sortDir"))
+		if err != nil {
+			return sort, err
+		}
+		if dir != 0 {
+			sort.SortDir = dir
+		}
+	}
+	return sort, nil
+}
@@ -0,0 +1,85 @@
+package task
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	ErrInvalidFilter = errors.New("label, priority or status is invalid")
+	ErrInvalidSort   = errors.New("sortBy must be timestamp, priority, due_date or value")
+)
+
+// filterParams reads ?label=, ?priority= and ?status=; labels and priorities are comma separated
+func filterParams(c *fiber.Ctx) (Filter, error) {
+	var filter Filter
+	if raw := c.Query("label"); raw != "" {
+		filter.Labels = normalizeLabels(strings.Split(raw, ","))
+	}
+	if raw := c.Query("priority"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			p, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(part)), "P"))
+			if err != nil || !ValidPriority(p) {
+				return filter, ErrInvalidFilter
+			}
+			filter.Priorities = append(filter.Priorities, p)
+		}
+	}
+	switch status := Status(c.Query("status")); status {
+	case "", Todo, InProgress, Done:
+		filter.Status = status
+	default:
+		return filter, ErrInvalidFilter
+	}
+	return filter, nil
+}
+
+// match is the filter as a $match on task documents
+func (f Filter) match() bson.M {
+	match := bson.M{}
+	if len(f.Labels) > 0 {
+		match["labels"] = bson.M{"$in": f.Labels}
+	}
+	if len(f.Priorities) > 0 {
+		match["priority"] = bson.M{"$in": f.Priorities}
+	}
+	switch f.Status {
+	case Done:
+		match["completed"] = true
+	case InProgress:
+		match["completed"] = bson.M{"$ne": true}
+		match["status"] = InProgress
+	case Todo:
+		match["completed"] = bson.M{"$ne": true}
+		match["status"] = bson.M{"$ne": InProgress}
+	}
+	return match
+}
+
+// Matches is match for tasks already in memory
+func (f Filter) Matches(t TaskDocument) bool {
+	if len(f.Labels) > 0 && !slices.ContainsFunc(t.Labels, func(label string) bool { return slices.Contains(f.Labels, label) }) {
+		return false
+	}
+	if len(f.Priorities) > 0 && !slices.Contains(f.Priorities, t.Priority) {
+		return false
+	}
+	return f.Status == "" || t.CurrentStatus() == f.Status
+}
+
+// normalizeLabels trims and lowercases labels, dropping empty ones and duplicates
+func normalizeLabels(labels []string) []string {
+	normalized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.ToLower(strings.TrimSpace(label))
+		if label != "" && !slices.Contains(normalized, label) {
+			normalized = append(normalized, label)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,393 @@
+package task
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xquery"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+MemoryRepository keeps tasks in process, for handler tests and local tools.
+Categories have to be added with AddCategory before tasks can go in them.
+*/
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	categories map[primitive.ObjectID][]*memoryCategory
+}
+
+type memoryCategory struct {
+	ID      primitive.ObjectID
+	Name    string
+	Tasks   []TaskDocument
+	Members []sharing.Member
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{categories: make(map[primitive.ObjectID][]*memoryCategory)}
+}
+
+// AddCategory creates an empty category for userID
+func (r *MemoryRepository) AddCategory(userID primitive.ObjectID, categoryID primitive.ObjectID, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.categories[userID] = append(r.categories[userID], &memoryCategory{ID: categoryID, Name: name})
+}
+
+// Share adds member to a category added with AddCategory
+func (r *MemoryRepository) Share(categoryID primitive.ObjectID, member sharing.Member) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, categories := range r.categories {
+		for _, category := range categories {
+			if category.ID == categoryID {
+				category.Members = append(category.Members, member)
+			}
+		}
+	}
+}
+
+func (r *MemoryRepository) All(ctx context.Context) ([]TaskDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := make([]TaskDocument, 0)
+	for _, categories := range r.categories {
+		for _, category := range categories {
+			results = append(results, category.Tasks...)
+		}
+	}
+	return results, nil
+}
+
+func (r *MemoryRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := make([]TaskDocument, 0)
+	for _, category := range r.categories[userID] {
+		for _, t := range category.Tasks {
+			if filter.Matches(t) {
+				results = append(results, t)
+			}
+		}
+	}
+	sortTasks(results, func(t TaskDocument) TaskDocument { return t }, sort)
+	return results, nil
+}
+
+func (r *MemoryRepository) ListViewsByUser(ctx context.Context, userID primitive.ObjectID, sort SortParams, filter Filter, fields *xquery.Fields, expandCategory bool) ([]bson.M, error) {
+	type view struct {
+		task     TaskDocument
+		category *memoryCategory
+	}
+	r.mu.RLock()
+	views := make([]view, 0)
+	for _, category := range r.categories[userID] {
+		for _, t := range category.Tasks {
+			if filter.Matches(t) {
+				views = append(views, view{t, category})
+			}
+		}
+	}
+	r.mu.RUnlock()
+	sortTasks(views, func(v view) TaskDocument { return v.task }, sort)
+
+	var keep bson.M
+	if fields != nil {
+		keep = fields.Projection("category")
+	}
+	docs := make([]bson.M, 0, len(views))
+	for _, v := range views {
+		doc, err := toM(v.task)
+		if err != nil {
+			return nil, err
+		}
+		if expandCategory {
+			doc["category"] = bson.M{"id": v.category.ID, "name": v.category.Name}
+		}
+		if keep != nil {
+			for key := range doc {
+				if _, ok := keep[key]; !ok {
+					delete(doc, key)
+				}
+			}
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+func (r *MemoryRepository) ListByCategory(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, category := r.shared(userID, categoryID, sharing.Viewer)
+	if category == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	results := make([]TaskDocument, 0)
+	for _, t := range category.Tasks {
+		if filter.Matches(t) {
+			results = append(results, t)
+		}
+	}
+	sortTasks(results, func(t TaskDocument) TaskDocument { return t }, sort)
+	return results, nil
+}
+
+func (r *MemoryRepository) Upcoming(ctx context.Context, userID primitive.ObjectID, from time.Time, until time.Time) ([]TaskDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := make([]TaskDocument, 0)
+	for _, category := range r.categories[userID] {
+		for _, t := range category.Tasks {
+			if !t.Completed && t.DueDate != nil && !t.DueDate.Before(from) && !t.DueDate.After(until) {
+				results = append(results, t)
+			}
+		}
+	}
+	slices.SortStableFunc(results, func(a, b TaskDocument) int {
+		return a.DueDate.Compare(*b.DueDate)
+	})
+	return results, nil
+}
+
+func (r *MemoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*TaskDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, category, i := r.find(id)
+	if category == nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	t := category.Tasks[i]
+	return &t, nil
+}
+
+func (r *MemoryRepository) InCategory(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, id primitive.ObjectID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	owner, category, _ := r.find(id)
+	return category != nil && category.ID == categoryID && sharing.Allows(owner, category.Members, userID, sharing.Editor), nil
+}
+
+func (r *MemoryRepository) Owns(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	owner, category, _ := r.find(id)
+	return category != nil && sharing.Allows(owner, category.Members, userID, sharing.Editor), nil
+}
+
+func (r *MemoryRepository) Insert(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, doc *TaskDocument) (primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owner, category := r.shared(userID, categoryID, sharing.Editor)
+	if category == nil {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+	category.Tasks = append(category.Tasks, *doc)
+	return owner, nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, id primitive.ObjectID, updated UpdateTaskDocument, at time.Time) (primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owner, category, i := r.find(id)
+	if category == nil {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+	t := &category.Tasks[i]
+	if updated.Version != nil && *updated.Version != t.Version {
+		return primitive.NilObjectID, &xmongo.VersionConflict{Expected: *updated.Version, Current: t.Version}
+	}
+	t.Version++
+	if updated.Priority != nil {
+		t.Priority = *updated.Priority
+	}
+	if updated.Labels != nil {
+		t.Labels = *updated.Labels
+	}
+	if updated.Status != "" {
+		t.Status = updated.Status
+	}
+	t.Content = updated.Content
+	t.Value = updated.Value
+	t.Recurring = updated.Recurring
+	t.RecurDetails = updated.RecurDetails
+	t.Public = updated.Public
+	t.Active = updated.Active
+	t.StartDate = updated.StartDate
+	t.DueDate = updated.DueDate
+	t.Notes = updated.Notes
+	t.Draft = updated.Draft
+	t.UpdatedAt = at
+	return owner, nil
+}
+
+func (r *MemoryRepository) Complete(ctx context.Context, id primitive.ObjectID, by primitive.ObjectID, at time.Time) (primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owner, category, i := r.find(id)
+	if category == nil {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+	t := &category.Tasks[i]
+	if t.Completed {
+		return primitive.NilObjectID, ErrAlreadyCompleted
+	}
+	t.Completed = true
+	t.CompletedAt = &at
+	t.Status = Done
+	t.Version++
+	t.UpdatedAt = at
+	if t.Recurrence != nil {
+		after := *t.DueDate
+		if at.After(after) {
+			after = at
+		}
+		r.advance(category, i, after, at)
+	}
+	return owner, nil
+}
+
+func (r *MemoryRepository) UpdateSeries(ctx context.Context, id primitive.ObjectID, updated UpdateSeriesDocument, at time.Time) (primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owner, category, i := r.find(id)
+	if category == nil {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+	current := category.Tasks[i]
+
+	var head *TaskDocument
+	if updated.Recurrence != nil || updated.EndRecurrence {
+		head = &category.Tasks[i]
+		if current.SeriesID != nil && current.Recurrence == nil {
+			for j, t := range category.Tasks {
+				if t.SeriesID != nil && *t.SeriesID == *current.SeriesID && t.Recurrence != nil {
+					head = &category.Tasks[j]
+				}
+			}
+		}
+	}
+	if updated.Recurrence != nil {
+		rule, err := seriesRule(*updated.Recurrence, *head)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		series := seriesID(*head)
+		head.Recurrence, head.SeriesID, head.Recurring = &rule, &series, true
+	} else if updated.EndRecurrence {
+		head.Recurrence = nil
+	}
+
+	for j := range category.Tasks {
+		t := &category.Tasks[j]
+		later := current.SeriesID != nil && current.DueDate != nil && t.SeriesID != nil && *t.SeriesID == *current.SeriesID &&
+			!t.Completed && t.DueDate != nil && t.DueDate.After(*current.DueDate)
+		if t.ID != id && !later {
+			continue
+		}
+		if updated.Priority != nil {
+			t.Priority = *updated.Priority
+		}
+		if updated.Content != nil {
+			t.Content = *updated.Content
+		}
+		if updated.Value != nil {
+			t.Value = *updated.Value
+		}
+		if updated.Public != nil {
+			t.Public = *updated.Public
+		}
+		if updated.Active != nil {
+			t.Active = *updated.Active
+		}
+		if updated.Notes != nil {
+			t.Notes = *updated.Notes
+		}
+		t.Version++
+		t.UpdatedAt = at
+	}
+	return owner, nil
+}
+
+// advance is mongoRepository.advance for the task at i; callers hold the lock
+func (r *MemoryRepository) advance(category *memoryCategory, i int, after time.Time, at time.Time) {
+	next := nextOccurrence(category.Tasks[i], after, at)
+	category.Tasks[i].Recurrence = nil
+	if next != nil {
+		category.Tasks = append(category.Tasks, *next)
+	}
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, id primitive.ObjectID, version *int64) (primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owner, category, i := r.find(id)
+	if category == nil {
+		return primitive.NilObjectID, mongo.ErrNoDocuments
+	}
+	if t := category.Tasks[i]; version != nil && *version != t.Version {
+		return primitive.NilObjectID, &xmongo.VersionConflict{Expected: *version, Current: t.Version}
+	}
+	category.Tasks = slices.Delete(category.Tasks, i, i+1)
+	return owner, nil
+}
+
+// shared looks up a category the user owns or has a role in granting need, and its owner; callers hold the lock
+func (r *MemoryRepository) shared(userID primitive.ObjectID, categoryID primitive.ObjectID, need sharing.Role) (primitive.ObjectID, *memoryCategory) {
+	return bulkState(r.categories).shared(userID, categoryID, need)
+}
+
+// find locates a task; callers hold the lock
+func (r *MemoryRepository) find(id primitive.ObjectID) (primitive.ObjectID, *memoryCategory, int) {
+	return bulkState(r.categories).find(id)
+}
+
+func sortTasks[T any](items []T, task func(T) TaskDocument, sort SortParams) {
+	slices.SortStableFunc(items, func(a, b T) int {
+		x, y := task(a), task(b)
+		var c int
+		switch SortTypes(sort.SortBy) {
+		case Priority:
+			c = cmp.Compare(x.Priority, y.Priority)
+		case Difficulty:
+			c = cmp.Compare(x.Value, y.Value)
+		case Due:
+			// undated tasks last, whichever the direction
+			if x.DueDate == nil || y.DueDate == nil {
+				return cmp.Compare(boolInt(x.DueDate == nil), boolInt(y.DueDate == nil))
+			}
+			c = x.DueDate.Compare(*y.DueDate)
+		default:
+			c = x.Timestamp.Compare(y.Timestamp)
+		}
+		if sort.SortDir < 0 {
+			return -c
+		}
+		return c
+	})
+}
+
+func toM(v interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	err = bson.Unmarshal(raw, &doc)
+	return doc, err
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
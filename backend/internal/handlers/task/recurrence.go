@@ -0,0 +1,172 @@
+package task
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+A recurring task is a series of occurrences sharing a series id. Only the
+latest occurrence, the head, carries the Recurrence; completing it (or the
+scheduler finding it overdue) materializes the next occurrence, which takes
+the rule over. Occurrences before the head are plain tasks.
+*/
+
+type Frequency string
+
+const (
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+)
+
+type Recurrence struct {
+	Freq Frequency `validate:"required,oneof=daily weekly monthly" bson:"freq" json:"freq"`
+	// every Interval days, weeks or months; 0 means 1
+	Interval int `validate:"min=0,max=365" bson:"interval,omitempty" json:"interval,omitempty"`
+	// for weekly rules, 0 (Sunday) to 6; defaults to the weekday of the first due date
+	Weekdays []int `validate:"omitempty,max=7,dive,min=0,max=6" bson:"weekdays,omitempty" json:"weekdays,omitempty"`
+	// no occurrence is due after this
+	Until *time.Time `bson:"until,omitempty" json:"until,omitempty"`
+	// IANA zone the weekdays and days of the month are counted in, UTC by default
+	TimeZone string `validate:"omitempty,timezone" bson:"tz,omitempty" json:"tz,omitempty"`
+	// the due date the series is counted from, set by the server
+	Start time.Time `bson:"start" json:"start"`
+}
+
+/*
+Next returns the first occurrence due after after, and false once the series
+has ended. Occurrences keep the time of day of Start, and a monthly rule
+falls back to the end of months too short for Start's day.
+*/
+func (r Recurrence) Next(after time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(r.TimeZone)
+	if err != nil {
+		loc = time.UTC
+	}
+	start, after := r.Start.In(loc), after.In(loc)
+	if after.Before(start) {
+		after = start
+	}
+	interval := max(r.Interval, 1)
+
+	var next time.Time
+	switch r.Freq {
+	case Daily:
+		n := days(start, after) / interval * interval
+		for next = start.AddDate(0, 0, n); !next.After(after); next = start.AddDate(0, 0, n) {
+			n += interval
+		}
+	case Weekly:
+		weekdays := slices.Clone(r.Weekdays)
+		if len(weekdays) == 0 {
+			weekdays = []int{int(start.Weekday())}
+		}
+		slices.Sort(weekdays)
+		week := start.AddDate(0, 0, -int(start.Weekday()))
+		w := days(week, after) / 7 / interval * interval
+	weeks:
+		for ; ; w += interval {
+			for _, day := range weekdays {
+				next = week.AddDate(0, 0, 7*w+day)
+				if next.After(after) {
+					break weeks
+				}
+			}
+		}
+	case Monthly:
+		m := (after.Year()-start.Year())*12 + int(after.Month()-start.Month())
+		m = m / interval * interval
+		for next = addMonths(start, m); !next.After(after); next = addMonths(start, m) {
+			m += interval
+		}
+	default:
+		return time.Time{}, false
+	}
+
+	if r.Until != nil && next.After(*r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+// days counts the calendar days from a to b
+func days(a time.Time, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(db.Sub(da).Hours() / 24)
+}
+
+// addMonths is t.AddDate(0, n, 0) without overflowing into the month after
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(t.Day(), last)-1)
+}
+
+func checkRecurrence(r *Recurrence, due *time.Time) error {
+	if r == nil {
+		return nil
+	}
+	if due == nil {
+		return ErrRecurrenceNeedsDue
+	}
+	if r.Freq != Weekly && len(r.Weekdays) > 0 {
+		return ErrWeekdaysNotWeekly
+	}
+	return nil
+}
+
+/*
+nextOccurrence is the task that follows head in its series, or nil when the
+series ends with head. The rule moves to it and its dates move along with the
+due date; at stamps its creation.
+*/
+func nextOccurrence(head TaskDocument, after time.Time, at time.Time) *TaskDocument {
+	if head.Recurrence == nil || head.DueDate == nil {
+		return nil
+	}
+	due, ok := head.Recurrence.Next(after)
+	if !ok {
+		return nil
+	}
+
+	next := head
+	next.ID = primitive.NewObjectID()
+	if next.SeriesID == nil {
+		next.SeriesID = &head.ID
+	}
+	if head.StartDate != nil {
+		start := head.StartDate.Add(due.Sub(*head.DueDate))
+		next.StartDate = &start
+	}
+	next.DueDate = &due
+	next.Completed = false
+	next.CompletedAt = nil
+	next.Timestamp = at
+	next.UpdatedAt = at
+	next.Version = 0
+	// a re-import must not mistake the occurrence for the task it imported
+	next.Source = ""
+	next.Draft = false
+	return &next
+}
+
+// seriesRule checks rule for the series headed by head and counts it from head's due date
+func seriesRule(rule Recurrence, head TaskDocument) (Recurrence, error) {
+	if err := checkRecurrence(&rule, head.DueDate); err != nil {
+		return rule, err
+	}
+	rule.Start = *head.DueDate
+	return rule, nil
+}
+
+// seriesID is the id shared by the occurrences of the series task belongs to, or would start
+func seriesID(task TaskDocument) primitive.ObjectID {
+	if task.SeriesID != nil {
+		return *task.SeriesID
+	}
+	return task.ID
+}
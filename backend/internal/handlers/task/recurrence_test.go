@@ -0,0 +1,178 @@
+package task
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRecurrenceNext(t *testing.T) {
+	// a Monday
+	start := time.Date(2026, time.January, 5, 9, 30, 0, 0, time.UTC)
+	until := time.Date(2026, time.January, 20, 0, 0, 0, 0, time.UTC)
+	endOfMonth := time.Date(2026, time.January, 31, 9, 30, 0, 0, time.UTC)
+	// 23:00 in New York is already the next day in UTC
+	evening := time.Date(2026, time.January, 5, 23, 0, 0, 0, time.FixedZone("EST", -5*60*60))
+
+	tests := []struct {
+		name     string
+		rule     Recurrence
+		after    time.Time
+		expected time.Time
+		ok       bool
+	}{
+		{"daily", Recurrence{Freq: Daily, Start: start}, start, start.AddDate(0, 0, 1), true},
+		{"every other day", Recurrence{Freq: Daily, Interval: 2, Start: start}, start.AddDate(0, 0, 3), start.AddDate(0, 0, 4), true},
+		{"daily from before the start", Recurrence{Freq: Daily, Start: start}, start.AddDate(0, 0, -3), start.AddDate(0, 0, 1), true},
+		{"weekly", Recurrence{Freq: Weekly, Start: start}, start, start.AddDate(0, 0, 7), true},
+		{"weekdays", Recurrence{Freq: Weekly, Weekdays: []int{4, 1}, Start: start}, start, start.AddDate(0, 0, 3), true},
+		{"weekdays into next week", Recurrence{Freq: Weekly, Weekdays: []int{1, 4}, Start: start}, start.AddDate(0, 0, 3), start.AddDate(0, 0, 7), true},
+		{"every other week", Recurrence{Freq: Weekly, Interval: 2, Start: start}, start, start.AddDate(0, 0, 14), true},
+		{"monthly", Recurrence{Freq: Monthly, Start: start}, start, start.AddDate(0, 1, 0), true},
+		{"monthly on a short month", Recurrence{Freq: Monthly, Start: endOfMonth}, endOfMonth, time.Date(2026, time.February, 28, 9, 30, 0, 0, time.UTC), true},
+		{"monthly after a short month", Recurrence{Freq: Monthly, Start: endOfMonth}, time.Date(2026, time.February, 28, 9, 30, 0, 0, time.UTC), time.Date(2026, time.March, 31, 9, 30, 0, 0, time.UTC), true},
+		{"until", Recurrence{Freq: Weekly, Until: &until, Start: start}, start, start.AddDate(0, 0, 7), true},
+		{"past until", Recurrence{Freq: Weekly, Until: &until, Start: start}, start.AddDate(0, 0, 14), time.Time{}, false},
+		{"weekday in the rule's zone", Recurrence{Freq: Weekly, Weekdays: []int{2}, TimeZone: "America/New_York", Start: evening}, evening, evening.AddDate(0, 0, 1), true},
+		{"unknown frequency", Recurrence{Freq: "yearly", Start: start}, start, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		next, ok := tt.rule.Next(tt.after)
+		if ok != tt.ok || !next.Equal(tt.expected) {
+			t.Errorf("%s: expected %s %v, got %s %v", tt.name, tt.expected, tt.ok, next, ok)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	start := time.Date(2026, time.January, 5, 8, 0, 0, 0, time.UTC)
+	due := time.Date(2026, time.January, 5, 17, 0, 0, 0, time.UTC)
+	completed := due.Add(time.Hour)
+	head := TaskDocument{
+		ID:          primitive.NewObjectID(),
+		Content:     "Water the plants",
+		StartDate:   &start,
+		DueDate:     &due,
+		Recurrence:  &Recurrence{Freq: Weekly, Start: due},
+		Completed:   true,
+		CompletedAt: &completed,
+		Version:     3,
+		Source:      "todoist:1",
+	}
+
+	next := nextOccurrence(head, due, completed)
+	if next == nil {
+		t.Fatal("expected a next occurrence")
+	}
+	if next.ID == head.ID || next.SeriesID == nil || *next.SeriesID != head.ID {
+		t.Errorf("expected a new task in the series of %s, got %s in %v", head.ID.Hex(), next.ID.Hex(), next.SeriesID)
+	}
+	if !next.DueDate.Equal(due.AddDate(0, 0, 7)) || !next.StartDate.Equal(start.AddDate(0, 0, 7)) {
+		t.Errorf("expected the dates a week on, got %s to %s", next.StartDate, next.DueDate)
+	}
+	if next.Completed || next.CompletedAt != nil || next.Version != 0 || next.Source != "" {
+		t.Errorf("expected a fresh occurrence, got %+v", next)
+	}
+
+	until := due.AddDate(0, 0, 3)
+	head.Recurrence.Until = &until
+	if next := nextOccurrence(head, due, completed); next != nil {
+		t.Errorf("expected the series to end, got %s", next.DueDate)
+	}
+}
+
+func TestTaskSeries(t *testing.T) {
+	repo := NewMemoryRepository()
+	userID, categoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	repo.AddCategory(userID, categoryID, "Chores")
+
+	handler := Handler{NewServiceWithRepository(repo, xcache.Noop{})}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Post("/:id/complete", handler.CompleteTask)
+	app.Post("/:user/:category", handler.CreateTask)
+	app.Patch("/:id/series", handler.UpdateTaskSeries)
+
+	res := do(t, app, http.MethodPost, "/"+userID.Hex()+"/"+categoryID.Hex(), `{"priority": 1, "content": "Water the plants", "value": 2}`)
+	if res.StatusCode != fiber.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", res.StatusCode)
+	}
+	if res := do(t, app, http.MethodPost, "/"+userID.Hex()+"/"+categoryID.Hex(),
+		`{"priority": 1, "content": "Water the plants", "value": 2, "recurrence": {"freq": "daily"}}`); res.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("recurring without a due date: expected 400, got %d", res.StatusCode)
+	}
+
+	due := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	res = do(t, app, http.MethodPost, "/"+userID.Hex()+"/"+categoryID.Hex(),
+		`{"priority": 1, "content": "Take out the trash", "value": 2, "due_date": "`+due.Format(time.RFC3339)+`", "recurrence": {"freq": "weekly"}}`)
+	if res.StatusCode != fiber.StatusCreated {
+		t.Fatalf("create series: expected 201, got %d", res.StatusCode)
+	}
+	var head TaskDocument
+	body, _ := io.ReadAll(res.Body)
+	if err := gojson.Unmarshal(body, &head); err != nil {
+		t.Fatal(err)
+	}
+
+	if res := do(t, app, http.MethodPost, "/"+head.ID.Hex()+"/complete", ""); res.StatusCode != fiber.StatusOK {
+		t.Fatalf("complete: expected 200, got %d", res.StatusCode)
+	}
+	series := occurrences(t, repo, userID, head.ID)
+	if len(series) != 2 {
+		t.Fatalf("expected the next occurrence after completing, got %d tasks", len(series))
+	}
+	next := series[1]
+	if next.Completed || next.Recurrence == nil || !next.DueDate.Equal(due.AddDate(0, 0, 7)) {
+		t.Fatalf("expected an open head due a week later, got %+v", next)
+	}
+	if series[0].Recurrence != nil {
+		t.Error("expected the completed occurrence to hand the rule over")
+	}
+
+	// editing the completed occurrence's series reaches the open one, and the rule moves to every other week
+	if res := do(t, app, http.MethodPatch, "/"+head.ID.Hex()+"/series", `{"content": "Take out the recycling", "recurrence": {"freq": "weekly", "interval": 2}}`); res.StatusCode != fiber.StatusOK {
+		t.Fatalf("update series: expected 200, got %d", res.StatusCode)
+	}
+	next = occurrences(t, repo, userID, head.ID)[1]
+	if next.Content != "Take out the recycling" || next.Recurrence == nil || next.Recurrence.Interval != 2 {
+		t.Errorf("expected the series edit on the open occurrence, got %+v", next)
+	}
+
+	if res := do(t, app, http.MethodPatch, "/"+next.ID.Hex()+"/series", `{"end_recurrence": true}`); res.StatusCode != fiber.StatusOK {
+		t.Fatalf("end series: expected 200, got %d", res.StatusCode)
+	}
+	if res := do(t, app, http.MethodPost, "/"+next.ID.Hex()+"/complete", ""); res.StatusCode != fiber.StatusOK {
+		t.Fatalf("complete last: expected 200, got %d", res.StatusCode)
+	}
+	if series := occurrences(t, repo, userID, head.ID); len(series) != 2 {
+		t.Errorf("expected no occurrence after the series ended, got %d tasks", len(series))
+	}
+}
+
+// occurrences lists the user's tasks of the series, in order of their due dates
+func occurrences(t *testing.T, repo *MemoryRepository, userID primitive.ObjectID, series primitive.ObjectID) []TaskDocument {
+	t.Helper()
+	tasks, err := repo.ListByUser(context.Background(), userID, SortParams{SortBy: string(Time), SortDir: 1}, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []TaskDocument
+	for _, task := range tasks {
+		if seriesID(task) == series {
+			found = append(found, task)
+		}
+	}
+	slices.SortFunc(found, func(a TaskDocument, b TaskDocument) int { return a.DueDate.Compare(*b.DueDate) })
+	return found
+}
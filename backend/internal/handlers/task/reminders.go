@@ -0,0 +1,99 @@
+package task
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const reminderBatch = 500
+
+type dueTask struct {
+	User    primitive.ObjectID `bson:"user"`
+	ID      primitive.ObjectID `bson:"_id"`
+	Content string             `bson:"content"`
+	DueDate time.Time          `bson:"due_date"`
+}
+
+/*
+remindDue notifies the owners of open tasks due between from and until. The
+scans overlap, the notification key (task and due date) keeps each reminder
+to one, and moving the due date earns the task another.
+*/
+func remindDue(ctx context.Context, users *mongo.Collection, inbox *mongo.Collection, from time.Time, until time.Time) (int, error) {
+	due := bson.M{"$gt": from, "$lte": until}
+	cursor, err := users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: softdelete.Filter(bson.M{"categories.tasks.due_date": due})},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$match", Value: softdelete.LiveAt("categories")},
+		},
+		{
+			{Key: "$unwind", Value: "$categories.tasks"},
+		},
+		{
+			{Key: "$match", Value: bson.M{
+				"categories.tasks.completed":           bson.M{"$ne": true},
+				"categories.tasks.due_date":            due,
+				"categories.tasks." + softdelete.Field: nil,
+			}},
+		},
+		{
+			{Key: "$project", Value: bson.M{
+				"_id":      "$categories.tasks._id",
+				"user":     "$_id",
+				"content":  "$categories.tasks.content",
+				"due_date": "$categories.tasks.due_date",
+			}},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	sent := 0
+	batch := make([]notifications.Notification, 0, reminderBatch)
+	flush := func() error {
+		n, err := notifications.Insert(ctx, inbox, batch...)
+		sent += n
+		batch = batch[:0]
+		return err
+	}
+	now := time.Now()
+	for cursor.Next(ctx) {
+		var t dueTask
+		if err := cursor.Decode(&t); err != nil {
+			return sent, err
+		}
+		batch = append(batch, notifications.Notification{
+			ID:        primitive.NewObjectID(),
+			User:      t.User,
+			Type:      notifications.TaskDue,
+			Key:       string(notifications.TaskDue) + ":" + t.ID.Hex() + ":" + strconv.FormatInt(t.DueDate.Unix(), 10),
+			Title:     t.Content,
+			TaskID:    &t.ID,
+			DueDate:   &t.DueDate,
+			CreatedAt: now,
+		})
+		if len(batch) == reminderBatch {
+			if err := flush(); err != nil {
+				return sent, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return sent, err
+	}
+	return sent, flush()
+}
@@ -0,0 +1,759 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	"github.com/abhikaboy/SocialToDo/internal/outbox"
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/tombstone"
+	"github.com/abhikaboy/SocialToDo/internal/xquery"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/abhikaboy/SocialToDo/xutils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Repository is the storage the task Service needs. Tasks are embedded in their
+owner's categories, so lookups by task id report the owner back for cache
+invalidation. A missing task is mongo.ErrNoDocuments for every implementation.
+*/
+type Repository interface {
+	All(ctx context.Context) ([]TaskDocument, error)
+	ListByUser(ctx context.Context, userID primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error)
+	// ListViewsByUser returns bson keyed documents, projected to fields when it isn't nil
+	ListViewsByUser(ctx context.Context, userID primitive.ObjectID, sort SortParams, filter Filter, fields *xquery.Fields, expandCategory bool) ([]bson.M, error)
+	// ListByCategory returns mongo.ErrNoDocuments when the user can't view such a category
+	ListByCategory(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error)
+	// Upcoming returns the user's open tasks due between from and until, soonest first
+	Upcoming(ctx context.Context, userID primitive.ObjectID, from time.Time, until time.Time) ([]TaskDocument, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*TaskDocument, error)
+	// InCategory reports whether the task is in the category and the user may edit it
+	InCategory(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, id primitive.ObjectID) (bool, error)
+	// Owns reports whether the task is in any category the user may edit, their own or one shared with them
+	Owns(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (bool, error)
+	// Insert returns mongo.ErrNoDocuments when the user can't edit such a category
+	Insert(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, doc *TaskDocument) (owner primitive.ObjectID, err error)
+	// Update returns a *xmongo.VersionConflict when updated.Version is set and stale
+	Update(ctx context.Context, id primitive.ObjectID, updated UpdateTaskDocument, at time.Time) (owner primitive.ObjectID, err error)
+	// UpdateSeries applies updated to the task and the open occurrences after it in its series
+	UpdateSeries(ctx context.Context, id primitive.ObjectID, updated UpdateSeriesDocument, at time.Time) (owner primitive.ObjectID, err error)
+	// Complete returns ErrAlreadyCompleted for a task that is already done, and materializes the
+	// next occurrence when it was the head of a series; by is who completed it, the owner or a collaborator
+	Complete(ctx context.Context, id primitive.ObjectID, by primitive.ObjectID, at time.Time) (owner primitive.ObjectID, err error)
+	// Delete returns a *xmongo.VersionConflict when version is set and stale
+	Delete(ctx context.Context, id primitive.ObjectID, version *int64) (owner primitive.ObjectID, err error)
+	// Bulk applies the changes in order as userID, returning how each went and the owners of the tasks they changed
+	Bulk(ctx context.Context, userID primitive.ObjectID, changes []BulkChange, at time.Time) (outcomes []error, owners []primitive.ObjectID, err error)
+}
+
+type mongoRepository struct {
+	users      *mongo.Collection
+	activity   *mongo.Collection
+	tombstones *mongo.Collection
+	outbox     *mongo.Collection
+}
+
+// NewMongoRepository stores tasks inside the categories of the users collection
+func NewMongoRepository(collections map[string]*mongo.Collection) Repository {
+	return &mongoRepository{
+		users:      collections["users"],
+		activity:   collections["activity"],
+		tombstones: collections[tombstone.Collection],
+		outbox:     collections[outbox.Collection],
+	}
+}
+
+func (r *mongoRepository) All(ctx context.Context) ([]TaskDocument, error) {
+	cursor, err := r.users.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []TaskDocument
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *mongoRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error) {
+	pipeline := mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"_id": userID}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories",
+			}},
+		},
+		{
+			{Key: "$match", Value: softdelete.Live()},
+		},
+		{
+			{Key: "$unwind", Value: "$tasks"},
+		},
+		{
+			{Key: "$match", Value: softdelete.LiveAt("tasks")},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$tasks",
+			}},
+		},
+		{
+			{Key: "$match", Value: filter.match()},
+		},
+	}
+	cursor, err := r.users.Aggregate(ctx, append(pipeline, sortStages(sort)...))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []TaskDocument
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (r *mongoRepository) ListViewsByUser(ctx context.Context, userID primitive.ObjectID, sort SortParams, filter Filter, fields *xquery.Fields, expandCategory bool) ([]bson.M, error) {
+	var root interface{} = "$tasks"
+	if expandCategory {
+		root = bson.M{"$mergeObjects": bson.A{
+			"$tasks",
+			bson.M{"category": bson.M{"id": "$_id", "name": "$name"}},
+		}}
+	}
+	pipeline := mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"_id": userID}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories",
+			}},
+		},
+		{
+			{Key: "$match", Value: softdelete.Live()},
+		},
+		{
+			{Key: "$unwind", Value: "$tasks"},
+		},
+		{
+			{Key: "$match", Value: softdelete.LiveAt("tasks")},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": root,
+			}},
+		},
+		{
+			{Key: "$match", Value: filter.match()},
+		},
+	}
+	pipeline = append(pipeline, sortStages(sort)...)
+	if fields != nil {
+		var extra []string
+		if expandCategory {
+			extra = append(extra, "category")
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: fields.Projection(extra...)}})
+	}
+
+	cursor, err := r.users.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.M
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *mongoRepository) ListByCategory(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error) {
+	var user struct {
+		Categories []struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Tasks []TaskDocument     `bson:"tasks"`
+		} `bson:"categories"`
+	}
+	// the owner's other categories are read too, "categories.$" isn't reliable under access's $or
+	err := r.users.FindOne(ctx,
+		access(userID, bson.M{"_id": categoryID}, sharing.Viewer),
+		options.FindOne().SetProjection(bson.M{"categories._id": 1, "categories.tasks": 1}),
+	).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TaskDocument, 0)
+	for _, category := range user.Categories {
+		if category.ID != categoryID {
+			continue
+		}
+		for _, t := range category.Tasks {
+			if !t.IsDeleted() && filter.Matches(t) {
+				results = append(results, t)
+			}
+		}
+	}
+	sortTasks(results, func(t TaskDocument) TaskDocument { return t }, sort)
+	return results, nil
+}
+
+func (r *mongoRepository) Upcoming(ctx context.Context, userID primitive.ObjectID, from time.Time, until time.Time) ([]TaskDocument, error) {
+	cursor, err := r.users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"_id": userID}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories",
+			}},
+		},
+		{
+			{Key: "$match", Value: softdelete.Live()},
+		},
+		{
+			{Key: "$unwind", Value: "$tasks"},
+		},
+		{
+			{Key: "$match", Value: bson.M{
+				"tasks.completed":           bson.M{"$ne": true},
+				"tasks.due_date":            bson.M{"$gte": from, "$lte": until},
+				"tasks." + softdelete.Field: nil,
+			}},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$tasks",
+			}},
+		},
+		{
+			{Key: "$sort", Value: bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	results := make([]TaskDocument, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *mongoRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*TaskDocument, error) {
+	cursor, err := r.users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"categories.tasks._id": id}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$unwind", Value: "$categories.tasks"},
+		},
+		{
+			{Key: "$match", Value: bson.M{
+				"categories.tasks._id":                 id,
+				"categories." + softdelete.Field:       nil,
+				"categories.tasks." + softdelete.Field: nil,
+			}},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories.tasks",
+			}},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []TaskDocument
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		// No matching Task found
+		return nil, mongo.ErrNoDocuments
+	}
+
+	return &results[0], nil
+}
+
+func (r *mongoRepository) InCategory(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, id primitive.ObjectID) (bool, error) {
+	n, err := r.users.CountDocuments(ctx, access(userID, bson.M{
+		"_id":   categoryID,
+		"tasks": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": id})},
+	}, sharing.Editor), options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+func (r *mongoRepository) Owns(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (bool, error) {
+	n, err := r.users.CountDocuments(ctx, access(userID, bson.M{
+		"tasks": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": id})},
+	}, sharing.Editor), options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// Insert queues task.created through the outbox in the same transaction, like Complete
+func (r *mongoRepository) Insert(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID, doc *TaskDocument) (primitive.ObjectID, error) {
+	var owner ownerID
+	err := xmongo.WithTransaction(ctx, r.users, func(sc mongo.SessionContext) error {
+		err := r.users.FindOneAndUpdate(sc,
+			access(userID, bson.M{"_id": categoryID}, sharing.Editor),
+			bson.M{"$push": bson.M{"categories.$[c].tasks": doc}},
+			options.FindOneAndUpdate().
+				SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"c._id": categoryID}}}).
+				SetProjection(bson.M{"_id": 1}),
+		).Decode(&owner)
+		if err != nil {
+			return err
+		}
+		return outbox.Write(sc, r.outbox, events.Event{
+			Type:       events.TaskCreated,
+			UserID:     owner.ID.Hex(),
+			Collection: "users",
+			DocumentID: doc.ID.Hex(),
+			Payload:    events.TaskCreatedPayload{TaskID: doc.ID, CategoryID: categoryID},
+			OccurredAt: doc.Timestamp,
+		})
+	})
+	return owner.ID, err
+}
+
+func (r *mongoRepository) Update(ctx context.Context, id primitive.ObjectID, updated UpdateTaskDocument, at time.Time) (primitive.ObjectID, error) {
+	updateFields, err := xutils.ToDoc(updated)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	// tasks are embedded two arrays deep, $[t] picks the task out of whichever category holds it
+	set := bson.M{"categories.$[].tasks.$[t].updated_at": at}
+	for _, field := range *updateFields {
+		set["categories.$[].tasks.$[t]."+field.Key] = field.Value
+	}
+
+	var owner ownerID
+	err = xmongo.UpdateVersioned(ctx, r.users, updated.Version, xmongo.VersionedUpdate{
+		Filter: func(version any) bson.M {
+			if version == nil {
+				return liveTask(bson.M{"_id": id})
+			}
+			return liveTask(bson.M{"_id": id, xmongo.VersionField: version})
+		},
+		Update:       bson.M{"$set": set},
+		VersionPath:  "categories.$[].tasks.$[t]." + xmongo.VersionField,
+		ArrayFilters: []interface{}{bson.M{"t._id": id}},
+		Projection:   bson.M{"_id": 1},
+		Current: func(ctx context.Context) (int64, error) {
+			current, err := r.FindByID(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			return current.Version, nil
+		},
+	}, &owner)
+	return owner.ID, err
+}
+
+/*
+Complete bumps the completed count of whoever completed the task alongside
+it, and queues task.completed through the outbox in the same transaction so
+the feed and achievements hear about every completion that commits. A public
+task's completion, or a collaborator's completion of a shared task, goes in
+their activity in the same transaction too.
+*/
+func (r *mongoRepository) Complete(ctx context.Context, id primitive.ObjectID, by primitive.ObjectID, at time.Time) (primitive.ObjectID, error) {
+	var owner ownerID
+	err := xmongo.WithTransaction(ctx, r.users, func(sc mongo.SessionContext) error {
+		err := r.users.FindOneAndUpdate(sc,
+			liveTask(bson.M{"_id": id, "completed": bson.M{"$ne": true}}),
+			bson.M{
+				"$set": bson.M{
+					"categories.$[].tasks.$[t].completed":    true,
+					"categories.$[].tasks.$[t].completed_at": at,
+					"categories.$[].tasks.$[t].status":       Done,
+					"categories.$[].tasks.$[t].updated_at":   at,
+				},
+				"$inc": bson.M{"categories.$[].tasks.$[t].version": 1},
+			},
+			options.FindOneAndUpdate().
+				SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"t._id": id}}}).
+				SetProjection(bson.M{"_id": 1}),
+		).Decode(&owner)
+		if err != nil {
+			return err
+		}
+		if _, err := r.users.UpdateOne(sc, bson.M{"_id": by}, bson.M{"$inc": bson.M{"tasks_complete": 1}}); err != nil {
+			return err
+		}
+		err = outbox.Write(sc, r.outbox, events.Event{
+			Type:       events.TaskCompleted,
+			UserID:     owner.ID.Hex(),
+			Collection: "users",
+			DocumentID: id.Hex(),
+			Payload:    events.TaskCompletedPayload{TaskID: id, CompletedAt: at, CompletedBy: by},
+			OccurredAt: at,
+		})
+		if err != nil {
+			return err
+		}
+
+		done, err := r.FindByID(sc, id)
+		if err != nil {
+			return err
+		}
+		if done.Public || by != owner.ID {
+			_, err := r.activity.InsertOne(sc, activity.ActivityEntry{
+				ActivityDocument: activity.ActivityDocument{
+					ID:        primitive.NewObjectID(),
+					Field1:    done.Content,
+					Field2:    activity.Completed,
+					Timestamp: at,
+				},
+				User: by,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if done.Recurrence == nil {
+			return nil
+		}
+		// the next occurrence is due after the one completed, or after now when that one was late
+		after := *done.DueDate
+		if at.After(after) {
+			after = at
+		}
+		_, err = r.advance(sc, owner.ID, *done, after, at)
+		return err
+	})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if _, findErr := r.FindByID(ctx, id); findErr == nil {
+			return primitive.NilObjectID, ErrAlreadyCompleted
+		}
+	}
+	return owner.ID, err
+}
+
+func (r *mongoRepository) UpdateSeries(ctx context.Context, id primitive.ObjectID, updated UpdateSeriesDocument, at time.Time) (primitive.ObjectID, error) {
+	current, err := r.FindByID(ctx, id)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	updateFields, err := xutils.ToDoc(updated)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	set := bson.M{"categories.$[].tasks.$[t].updated_at": at}
+	for _, field := range *updateFields {
+		set["categories.$[].tasks.$[t]."+field.Key] = field.Value
+	}
+	unset := bson.M{}
+	filters := []interface{}{seriesFilter(*current)}
+
+	if updated.Recurrence != nil || updated.EndRecurrence {
+		head, err := r.seriesHead(ctx, *current)
+		if err != nil {
+			return primitive.NilObjectID, err
+		}
+		if updated.Recurrence != nil {
+			rule, err := seriesRule(*updated.Recurrence, head)
+			if err != nil {
+				return primitive.NilObjectID, err
+			}
+			set["categories.$[].tasks.$[h].recurrence"] = rule
+			set["categories.$[].tasks.$[h].series_id"] = seriesID(head)
+			set["categories.$[].tasks.$[h].recurring"] = true
+		} else {
+			unset["categories.$[].tasks.$[h].recurrence"] = ""
+		}
+		filters = append(filters, bson.M{"h._id": head.ID})
+	}
+
+	update := bson.M{
+		"$set": set,
+		"$inc": bson.M{"categories.$[].tasks.$[t]." + xmongo.VersionField: 1},
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	var owner ownerID
+	err = r.users.FindOneAndUpdate(ctx, liveTask(bson.M{"_id": id}), update,
+		options.FindOneAndUpdate().
+			SetArrayFilters(options.ArrayFilters{Filters: filters}).
+			SetProjection(bson.M{"_id": 1}),
+	).Decode(&owner)
+	return owner.ID, err
+}
+
+/*
+AdvanceOverdue moves every series whose head is overdue on to its next
+occurrence after now, leaving the missed one open, and returns how many it
+moved.
+*/
+func (r *mongoRepository) AdvanceOverdue(ctx context.Context, now time.Time) (int, error) {
+	cursor, err := r.users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: softdelete.Filter(bson.M{
+				"categories.tasks.recurrence": bson.M{"$exists": true},
+				"categories.tasks.due_date":   bson.M{"$lt": now},
+			})},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$match", Value: softdelete.LiveAt("categories")},
+		},
+		{
+			{Key: "$unwind", Value: "$categories.tasks"},
+		},
+		{
+			{Key: "$match", Value: bson.M{
+				"categories.tasks.recurrence":          bson.M{"$exists": true},
+				"categories.tasks.completed":           bson.M{"$ne": true},
+				"categories.tasks.due_date":            bson.M{"$lt": now},
+				"categories.tasks." + softdelete.Field: nil,
+			}},
+		},
+		{
+			{Key: "$project", Value: bson.M{
+				"owner": "$_id",
+				"task":  "$categories.tasks",
+			}},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var heads []struct {
+		Owner primitive.ObjectID `bson:"owner"`
+		Task  TaskDocument       `bson:"task"`
+	}
+	if err := cursor.All(ctx, &heads); err != nil {
+		return 0, err
+	}
+
+	moved := 0
+	for _, head := range heads {
+		err := xmongo.WithTransaction(ctx, r.users, func(sc mongo.SessionContext) error {
+			next, err := r.advance(sc, head.Owner, head.Task, now, now)
+			if next != nil {
+				moved++
+			}
+			return err
+		})
+		if err != nil {
+			return moved, err
+		}
+	}
+	return moved, nil
+}
+
+/*
+advance hands the rule of head on to the next occurrence after after and
+inserts it next to head, returning it. It returns nil when the series ended
+with head, or when head was no longer the head, because a concurrent advance
+got there first.
+*/
+func (r *mongoRepository) advance(sc mongo.SessionContext, owner primitive.ObjectID, head TaskDocument, after time.Time, at time.Time) (*TaskDocument, error) {
+	result, err := r.users.UpdateOne(sc,
+		bson.M{"_id": owner, "categories.tasks": bson.M{"$elemMatch": bson.M{"_id": head.ID, "recurrence": bson.M{"$exists": true}}}},
+		bson.M{"$unset": bson.M{"categories.$[].tasks.$[t].recurrence": ""}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"t._id": head.ID}}}),
+	)
+	if err != nil || result.ModifiedCount == 0 {
+		return nil, err
+	}
+	next := nextOccurrence(head, after, at)
+	if next == nil {
+		return nil, nil
+	}
+
+	_, err = r.users.UpdateOne(sc,
+		bson.M{"_id": owner, "categories.tasks._id": head.ID},
+		bson.M{"$push": bson.M{"categories.$.tasks": next}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return next, outbox.Write(sc, r.outbox, events.Event{
+		Type:       events.TaskCreated,
+		UserID:     owner.Hex(),
+		Collection: "users",
+		DocumentID: next.ID.Hex(),
+		Payload:    events.TaskCreatedPayload{TaskID: next.ID, SeriesID: next.SeriesID},
+		OccurredAt: at,
+	})
+}
+
+// seriesHead finds the occurrence carrying the rule of task's series, which is task itself when there is none
+func (r *mongoRepository) seriesHead(ctx context.Context, task TaskDocument) (TaskDocument, error) {
+	if task.SeriesID == nil || task.Recurrence != nil {
+		return task, nil
+	}
+	cursor, err := r.users.Aggregate(ctx, mongo.Pipeline{
+		{
+			{Key: "$match", Value: bson.M{"categories.tasks.series_id": task.SeriesID}},
+		},
+		{
+			{Key: "$unwind", Value: "$categories"},
+		},
+		{
+			{Key: "$unwind", Value: "$categories.tasks"},
+		},
+		{
+			{Key: "$match", Value: bson.M{
+				"categories.tasks.series_id":           task.SeriesID,
+				"categories.tasks.recurrence":          bson.M{"$exists": true},
+				"categories." + softdelete.Field:       nil,
+				"categories.tasks." + softdelete.Field: nil,
+			}},
+		},
+		{
+			{Key: "$replaceRoot", Value: bson.M{
+				"newRoot": "$categories.tasks",
+			}},
+		},
+		{
+			{Key: "$limit", Value: 1},
+		},
+	})
+	if err != nil {
+		return task, err
+	}
+	defer cursor.Close(ctx)
+
+	var results []TaskDocument
+	if err := cursor.All(ctx, &results); err != nil {
+		return task, err
+	}
+	if len(results) == 0 {
+		return task, nil
+	}
+	return results[0], nil
+}
+
+// seriesFilter is the array filter for task and the open occurrences due after it in its series
+func seriesFilter(task TaskDocument) bson.M {
+	if task.SeriesID == nil || task.DueDate == nil {
+		return bson.M{"t._id": task.ID}
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{"t._id": task.ID},
+		bson.M{
+			"t.series_id":           task.SeriesID,
+			"t.due_date":            bson.M{"$gt": task.DueDate},
+			"t.completed":           bson.M{"$ne": true},
+			"t." + softdelete.Field: nil,
+		},
+	}}
+}
+
+// Delete moves the task to the trash, the purge schedule removes it for good
+func (r *mongoRepository) Delete(ctx context.Context, id primitive.ObjectID, version *int64) (primitive.ObjectID, error) {
+	var owner ownerID
+	err := xmongo.UpdateVersioned(ctx, r.users, version, xmongo.VersionedUpdate{
+		Filter: func(version any) bson.M {
+			if version == nil {
+				return liveTask(bson.M{"_id": id})
+			}
+			return liveTask(bson.M{"_id": id, xmongo.VersionField: version})
+		},
+		Update:       bson.M{"$set": bson.M{"categories.$[].tasks.$[t]." + softdelete.Field: time.Now()}},
+		VersionPath:  "categories.$[].tasks.$[t]." + xmongo.VersionField,
+		ArrayFilters: []interface{}{bson.M{"t._id": id}},
+		Projection:   bson.M{"_id": 1},
+		Current: func(ctx context.Context) (int64, error) {
+			current, err := r.FindByID(ctx, id)
+			if err != nil {
+				return 0, err
+			}
+			return current.Version, nil
+		},
+	}, &owner)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	// offline clients learn about the delete on their next sync
+	if err := tombstone.Record(ctx, r.tombstones, owner.ID, tombstone.Task, id); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to record task tombstone", xslog.Error(err))
+	}
+	return owner.ID, nil
+}
+
+/*
+access matches the user document holding the live category that matches
+category, when userID owns it or is a member whose role grants need
+*/
+func access(userID primitive.ObjectID, category bson.M, need sharing.Role) bson.M {
+	shared := maps.Clone(category)
+	maps.Copy(shared, sharing.Filter(userID, need))
+	return bson.M{"$or": bson.A{
+		bson.M{"_id": userID, "categories": bson.M{"$elemMatch": softdelete.Filter(category)}},
+		bson.M{"categories": bson.M{"$elemMatch": softdelete.Filter(shared)}},
+	}}
+}
+
+// liveTask matches users holding a live task that matches task, in a live category
+func liveTask(task bson.M) bson.M {
+	return bson.M{"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{
+		"tasks": bson.M{"$elemMatch": softdelete.Filter(task)},
+	})}}
+}
+
+type ownerID struct {
+	ID primitive.ObjectID `bson:"_id"`
+}
+
+/*
+sortStages sorts task documents by sort, ties in creation order. Tasks
+without a due date go last when sorting by it, whichever the direction.
+*/
+func sortStages(sort SortParams) []bson.D {
+	if SortTypes(sort.SortBy) != Due {
+		return []bson.D{{{Key: "$sort", Value: bson.D{{Key: sort.SortBy, Value: sort.SortDir}, {Key: "_id", Value: 1}}}}}
+	}
+	return []bson.D{
+		{{Key: "$set", Value: bson.M{"_undated": bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$due_date", nil}}, nil}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_undated", Value: 1}, {Key: "due_date", Value: sort.SortDir}, {Key: "_id", Value: 1}}}},
+		{{Key: "$unset", Value: "_undated"}},
+	}
+}
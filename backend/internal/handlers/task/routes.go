@@ -1,6 +1,9 @@
 package task
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -8,8 +11,8 @@ import (
 /*
 Router maps endpoints to handlers
 */
-func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
-	service := newService(collections)
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler, idempotent fiber.Handler) {
+	service := newService(collections, cache)
 	handler := Handler{service}
 
 	// Add a group for API versioning
@@ -18,12 +21,50 @@ func Routes(app *fiber.App, collections map[string]*mongo.Collection) {
 	// Add Sample group under API Version 1
 	Tasks := apiV1.Group("/Tasks")
 
-	Tasks.Get("/user/:id", handler.GetTasksByUser)
-	Tasks.Post("/:user/:category", handler.CreateTask)
+	// only the caller's own tasks, and those of categories shared with them as an editor,
+	// answering 403 for a :user or :id naming anyone else and 404 for a task they can't edit
+	Tasks.Get("/user/:id", authenticate, xauth.Self("id"), handler.GetTasksByUser)
+	// ahead of /:id, which would otherwise match it
+	Tasks.Get("/upcoming", authenticate, handler.GetUpcomingTasks)
+	// ahead of /:user/:category, which would otherwise match it
+	Tasks.Post("/:id/complete", authenticate, handler.OwnTask, handler.CompleteTask)
+	// each operation checks the caller may edit the tasks and categories it names
+	Tasks.Post("/bulk", authenticate, idempotent, handler.BulkTasks)
+	Tasks.Post("/:user/:category", authenticate, xauth.Self("user"), idempotent, handler.CreateTask)
 
-	Tasks.Get("/", handler.GetTasks)
-	Tasks.Get("/:id", handler.GetTask)
-	Tasks.Patch("/:id", handler.UpdatePartialTask)
-	Tasks.Delete("/:id", handler.DeleteTask)
+	// every user's tasks
+	Tasks.Get("/", authenticate, xauth.Require(xauth.AdminRole), handler.GetTasks)
+	Tasks.Get("/:id", authenticate, handler.OwnTask, handler.GetTask)
+	Tasks.Patch("/:id", authenticate, handler.OwnTask, handler.UpdatePartialTask)
+	Tasks.Patch("/:id/series", authenticate, handler.OwnTask, handler.UpdateTaskSeries)
+	Tasks.Delete("/:id", authenticate, handler.OwnTask, handler.DeleteTask)
 
+	// the caller's own tasks, or a shared category's, addressed through their category
+	CategoryTasks := apiV1.Group("/categories/:category/tasks", authenticate)
+
+	CategoryTasks.Post("/", idempotent, handler.CreateCategoryTask)
+	CategoryTasks.Get("/", handler.GetCategoryTasks)
+	CategoryTasks.Patch("/:id", handler.OwnTask, handler.UpdatePartialTask)
+	CategoryTasks.Patch("/:id/series", handler.OwnTask, handler.UpdateTaskSeries)
+	CategoryTasks.Post("/:id/complete", handler.OwnTask, handler.CompleteTask)
+	CategoryTasks.Delete("/:id", handler.OwnTask, handler.DeleteTask)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/Tasks/user/:id":                           {Summary: "The caller's tasks, optionally trimmed to some fields or with their category expanded", Auth: true, Query: xopenapi.Query{"fields", "expand", "sortBy", "sortDir", "label", "priority", "status"}, Response: []TaskDocument{}},
+		"GET /api/v1/Tasks/upcoming":                           {Summary: "The caller's tasks due soon", Auth: true, Query: xopenapi.Query{"within"}, Response: []TaskDocument{}},
+		"POST /api/v1/Tasks/:id/complete":                      {Summary: "Complete a task, scheduling the next one of a series", Auth: true},
+		"POST /api/v1/Tasks/bulk":                              {Summary: "Create, complete, delete and move tasks in one request, each operation getting a status of its own", Auth: true, Request: BulkParams{}, Response: BulkResponse{}},
+		"POST /api/v1/Tasks/:user/:category":                   {Summary: "Add a task to one of the caller's categories", Auth: true, Request: CreateTaskParams{}, Response: TaskDocument{}, Status: fiber.StatusCreated},
+		"GET /api/v1/Tasks/":                                   {Summary: "Every user's tasks, for admins", Auth: true, Response: []TaskDocument{}},
+		"GET /api/v1/Tasks/:id":                                {Summary: "One of the caller's tasks", Auth: true, Response: TaskDocument{}},
+		"PATCH /api/v1/Tasks/:id":                              {Summary: "Change a task; If-Match must name the version changed", Auth: true, Request: UpdateTaskDocument{}},
+		"PATCH /api/v1/Tasks/:id/series":                       {Summary: "Change a task and the rest of its series", Auth: true, Request: UpdateSeriesDocument{}},
+		"DELETE /api/v1/Tasks/:id":                             {Summary: "Move a task to the trash; If-Match must name the version deleted", Auth: true},
+		"POST /api/v1/categories/:category/tasks/":             {Summary: "Add a task to one of the caller's categories", Auth: true, Request: CreateTaskParams{}, Response: TaskDocument{}, Status: fiber.StatusCreated},
+		"GET /api/v1/categories/:category/tasks/":              {Summary: "The tasks in one of the caller's categories", Auth: true, Query: xopenapi.Query{"sortBy", "sortDir", "label", "priority", "status"}, Response: []TaskDocument{}},
+		"PATCH /api/v1/categories/:category/tasks/:id":         {Summary: "Change a task in a category; If-Match must name the version changed", Auth: true, Request: UpdateTaskDocument{}},
+		"PATCH /api/v1/categories/:category/tasks/:id/series":  {Summary: "Change a task in a category and the rest of its series", Auth: true, Request: UpdateSeriesDocument{}},
+		"POST /api/v1/categories/:category/tasks/:id/complete": {Summary: "Complete a task in a category", Auth: true},
+		"DELETE /api/v1/categories/:category/tasks/:id":        {Summary: "Move a task in a category to the trash; If-Match must name the version deleted", Auth: true},
+	})
 }
@@ -0,0 +1,31 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/outbox"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RegisterSchedules adds the recurring task and due date reminder entries to the scheduler
+func RegisterSchedules(cron *scheduler.Scheduler, collections map[string]*mongo.Collection, cfg config.Reminders) {
+	repo := &mongoRepository{users: collections["users"], outbox: collections[outbox.Collection]}
+	cron.Register("task-recurrence", "*/15 * * * *", 10*time.Minute, func(ctx context.Context) error {
+		_, err := repo.AdvanceOverdue(ctx, time.Now())
+		return err
+	})
+
+	if cfg.Window <= 0 {
+		return
+	}
+	users, inbox := collections["users"], collections[notifications.Collection]
+	cron.Register("task-reminders", "*/5 * * * *", 5*time.Minute, func(ctx context.Context) error {
+		now := time.Now()
+		_, err := remindDue(ctx, users, inbox, now, now.Add(cfg.Window))
+		return err
+	})
+}
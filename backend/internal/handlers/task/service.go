@@ -2,146 +2,215 @@ package task
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
+	"time"
 
-	"github.com/abhikaboy/SocialToDo/xutils"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/abhikaboy/SocialToDo/internal/xquery"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // newService receives the map of collections and picks out Jobs
-func newService(collections map[string]*mongo.Collection) *Service {
-	return &Service{
-		Tasks: collections["users"],
-	}
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache) *Service {
+	return NewServiceWithRepository(NewMongoRepository(collections), cache)
 }
 
-// GetAllTasks fetches all Task documents from MongoDB
-func (s *Service) GetAllTasks() ([]TaskDocument, error) {
-	ctx := context.Background()
-	cursor, err := s.Tasks.Find(ctx, bson.M{})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
+// NewService builds the task service for callers outside this package (the internal RPC server)
+func NewService(collections map[string]*mongo.Collection, cache xcache.Cache) *Service {
+	return newService(collections, cache)
+}
 
-	var results []TaskDocument
-	if err := cursor.All(ctx, &results); err != nil {
-		return nil, err
-	}
+// NewServiceWithRepository builds the service on any store, e.g. NewMemoryRepository in tests
+func NewServiceWithRepository(repo Repository, cache xcache.Cache) *Service {
+	return &Service{repo: repo, cache: cache}
+}
 
-	return results, nil
+// GetAllTasks fetches all Task documents
+func (s *Service) GetAllTasks(ctx context.Context) ([]TaskDocument, error) {
+	return s.repo.All(ctx)
+}
+
+// GetTasksByUser lists the user's tasks that match filter
+func (s *Service) GetTasksByUser(ctx context.Context, id primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error) {
+	return s.repo.ListByUser(ctx, id, sort, filter)
 }
 
-func (s *Service) GetTasksByUser(id primitive.ObjectID, sort bson.D) ([]TaskDocument, error) {
-	ctx := context.Background()
-
-	fmt.Println(sort)
-
-	filter := bson.M{"_id": id}
-	cursor, err := s.Tasks.Aggregate(ctx, mongo.Pipeline{
-		{
-			{Key: "$match", Value: filter},
-		},
-		{
-			{Key: "$unwind", Value: "$categories"},
-		},
-		{
-			{Key: "$replaceRoot", Value: bson.M{
-				"newRoot": "$categories",
-			}},
-		},
-		{
-			{Key: "$unwind", Value: "$tasks"},
-		},
-		{
-			{Key: "$replaceRoot", Value: bson.M{
-				"newRoot": "$tasks",
-			}},
-		},
-		sort,
-	})
+// GetTasksByCategory lists the tasks that match filter in one of the user's categories, or one shared with them
+func (s *Service) GetTasksByCategory(ctx context.Context, userId primitive.ObjectID, categoryId primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error) {
+	return s.repo.ListByCategory(ctx, userId, categoryId, sort, filter)
+}
+
+// GetUpcomingTasks lists the user's open tasks due within the next within, soonest first
+func (s *Service) GetUpcomingTasks(ctx context.Context, userId primitive.ObjectID, within time.Duration) ([]TaskDocument, error) {
+	now := time.Now()
+	return s.repo.Upcoming(ctx, userId, now, now.Add(within))
+}
 
+/*
+GetTaskViewsByUser is GetTasksByUser for ?fields= and ?expand= requests. It
+projects only the requested fields and can attach each task's category.
+*/
+func (s *Service) GetTaskViewsByUser(ctx context.Context, id primitive.ObjectID, sort SortParams, filter Filter, fields *xquery.Fields, expandCategory bool) ([]map[string]any, error) {
+	docs, err := s.repo.ListViewsByUser(ctx, id, sort, filter, fields, expandCategory)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	var results []TaskDocument
-	if err := cursor.All(ctx, &results); err != nil {
-		return nil, err
+	// without ?fields= every field is returned, still under its json name
+	names := fields
+	if names == nil {
+		names = xquery.All(TaskDocument{})
+	}
+	results := make([]map[string]any, 0, len(docs))
+	for _, doc := range docs {
+		results = append(results, names.Rename(doc))
 	}
-
 	return results, nil
 }
 
+// GetTaskByID returns a single Task by its ObjectID, wherever it is embedded
+func (s *Service) GetTaskByID(ctx context.Context, id primitive.ObjectID) (*TaskDocument, error) {
+	return s.repo.FindByID(ctx, id)
+}
 
-// GetTaskByID returns a single Task document by its ObjectID
-func (s *Service) GetTaskByID(id primitive.ObjectID) (*TaskDocument, error) {
-	ctx := context.Background()
-	filter := bson.M{"_id": id}
-
-	var Task TaskDocument
-	err := s.Tasks.FindOne(ctx, filter).Decode(&Task)
-
-	if err == mongo.ErrNoDocuments {
-		// No matching Task found
-		return nil, mongo.ErrNoDocuments
-	} else if err != nil {
-		// Different error occurred
-		return nil, err
+// InCategory returns mongo.ErrNoDocuments unless the task is in the category and the user may edit it
+func (s *Service) InCategory(ctx context.Context, userId primitive.ObjectID, categoryId primitive.ObjectID, id primitive.ObjectID) error {
+	ok, err := s.repo.InCategory(ctx, userId, categoryId, id)
+	if err != nil {
+		return err
 	}
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
 
-	return &Task, nil
+/*
+Owns returns mongo.ErrNoDocuments unless the user may change the task: it is
+in one of their categories, or in one shared with them as an editor
+*/
+func (s *Service) Owns(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) error {
+	ok, err := s.repo.Owns(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return mongo.ErrNoDocuments
+	}
+	return nil
 }
 
 // InsertTask adds a new Task document
-func (s *Service) CreateTask(userId primitive.ObjectID, categoryId primitive.ObjectID, r *TaskDocument) (*TaskDocument, error) {
-	ctx := context.Background()
-	// Insert the document into the collection
-
-	_, err := s.Tasks.UpdateOne(
-		ctx, 
-		bson.M{
-			"_id": userId, 
-			"categories": bson.M{"$elemMatch": bson.M{"_id": categoryId}},
-		},
-		bson.M{"$push": bson.M{"categories.$.tasks": r}},
-	)
+func (s *Service) CreateTask(ctx context.Context, userId primitive.ObjectID, categoryId primitive.ObjectID, r *TaskDocument) (_ *TaskDocument, err error) {
+	defer xmetrics.Track("task", "CreateTask")(&err)
+
+	if err := prepare(r); err != nil {
+		return nil, err
+	}
+	owner, err := s.repo.Insert(ctx, userId, categoryId, r)
 	if err != nil {
 		return nil, err
 	}
+	// category listings embed their tasks
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(owner.Hex()))
 
-	// Cast the inserted ID to ObjectID
 	slog.LogAttrs(ctx, slog.LevelInfo, "Task inserted")
 
 	return r, nil
 }
 
 // UpdatePartialTask updates only specified fields of a Task document by ObjectID.
-func (s *Service) UpdatePartialTask(id primitive.ObjectID, updated UpdateTaskDocument) error {
-	ctx := context.Background()
-	filter := bson.M{"_id": id}
+func (s *Service) UpdatePartialTask(ctx context.Context, id primitive.ObjectID, updated UpdateTaskDocument) error {
+	if (updated.StartDate == nil) != (updated.DueDate == nil) {
+		// one date on its own has to fit the stored other one
+		stored, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if updated.StartDate == nil {
+			updated.StartDate = stored.StartDate
+		} else {
+			updated.DueDate = stored.DueDate
+		}
+		if updated.Version == nil {
+			// so the dates checked are still the ones written over
+			updated.Version = &stored.Version
+		}
+	}
+	if err := checkDates(updated.StartDate, updated.DueDate); err != nil {
+		return err
+	}
+	if updated.Labels != nil {
+		labels := normalizeLabels(*updated.Labels)
+		updated.Labels = &labels
+	}
+	owner, err := s.repo.Update(ctx, id, updated, time.Now())
+	if err != nil {
+		return err
+	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(owner.Hex()))
+	return nil
+}
 
-	updateFields, err := xutils.ToDoc(updated)
+/*
+UpdateTaskSeries edits a task along with the later occurrences of its series,
+and can change or end the series' rule. UpdatePartialTask edits just the one
+occurrence.
+*/
+func (s *Service) UpdateTaskSeries(ctx context.Context, id primitive.ObjectID, updated UpdateSeriesDocument) error {
+	owner, err := s.repo.UpdateSeries(ctx, id, updated, time.Now())
 	if err != nil {
 		return err
 	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(owner.Hex()))
+	return nil
+}
 
-	update := bson.M{"$set": updateFields}
+// CompleteTask marks a task done and bumps the completed count of userId, its owner or a collaborator
+func (s *Service) CompleteTask(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) (err error) {
+	defer xmetrics.Track("task", "CompleteTask")(&err)
 
-	_, err = s.Tasks.UpdateOne(ctx, filter, update)
-	return err
+	owner, err := s.repo.Complete(ctx, id, userId, time.Now())
+	if err != nil {
+		return err
+	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(owner.Hex()))
+	return nil
 }
 
-// DeleteTask removes a Task document by ObjectID.
-func (s *Service) DeleteTask(id primitive.ObjectID) error {
-	ctx := context.Background()
+// DeleteTask removes a Task document by ObjectID, if it is still at version when that is set.
+func (s *Service) DeleteTask(ctx context.Context, id primitive.ObjectID, version *int64) error {
+	owner, err := s.repo.Delete(ctx, id, version)
+	if err != nil {
+		return err
+	}
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(owner.Hex()))
+	return nil
+}
 
-	filter := bson.M{"_id": id}
+// prepare checks the dates and rule of a task about to be created, and starts its series
+func prepare(r *TaskDocument) error {
+	if err := checkDates(r.StartDate, r.DueDate); err != nil {
+		return err
+	}
+	if err := checkRecurrence(r.Recurrence, r.DueDate); err != nil {
+		return err
+	}
+	r.Labels = normalizeLabels(r.Labels)
+	if r.Recurrence != nil {
+		// the first task of a series names it
+		r.Recurrence.Start = *r.DueDate
+		r.SeriesID = &r.ID
+		r.Recurring = true
+	}
+	return nil
+}
 
-	_, err := s.Tasks.DeleteOne(ctx, filter)
-	return err
+func checkDates(start *time.Time, due *time.Time) error {
+	if start != nil && due != nil && start.After(*due) {
+		return ErrStartAfterDue
+	}
+	return nil
 }
@@ -1,18 +1,45 @@
 package task
 
 import (
-	"strconv"
+	"context"
+	"errors"
 	"time"
 
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/abhikaboy/SocialToDo/internal/xetag"
+	"github.com/abhikaboy/SocialToDo/internal/xquery"
 	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
 	"github.com/gofiber/fiber/v2"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 var validator = xvalidator.Validator
+//go:generate mockgen -source=task.go -destination=mock_test.go -package=task
+
+// Tasks is the task service as Handler uses it
+type Tasks interface {
+	GetAllTasks(ctx context.Context) ([]TaskDocument, error)
+	GetTasksByUser(ctx context.Context, id primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error)
+	GetTasksByCategory(ctx context.Context, userId primitive.ObjectID, categoryId primitive.ObjectID, sort SortParams, filter Filter) ([]TaskDocument, error)
+	GetUpcomingTasks(ctx context.Context, userId primitive.ObjectID, within time.Duration) ([]TaskDocument, error)
+	GetTaskViewsByUser(ctx context.Context, id primitive.ObjectID, sort SortParams, filter Filter, fields *xquery.Fields, expandCategory bool) ([]map[string]any, error)
+	GetTaskByID(ctx context.Context, id primitive.ObjectID) (*TaskDocument, error)
+	InCategory(ctx context.Context, userId primitive.ObjectID, categoryId primitive.ObjectID, id primitive.ObjectID) error
+	Owns(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) error
+	CreateTask(ctx context.Context, userId primitive.ObjectID, categoryId primitive.ObjectID, r *TaskDocument) (*TaskDocument, error)
+	UpdatePartialTask(ctx context.Context, id primitive.ObjectID, updated UpdateTaskDocument) error
+	UpdateTaskSeries(ctx context.Context, id primitive.ObjectID, updated UpdateSeriesDocument) error
+	CompleteTask(ctx context.Context, userId primitive.ObjectID, id primitive.ObjectID) error
+	DeleteTask(ctx context.Context, id primitive.ObjectID, version *int64) error
+	BulkTasks(ctx context.Context, userId primitive.ObjectID, operations []BulkOperation) ([]BulkResult, error)
+}
+
+var _ Tasks = (*Service)(nil)
+
 type Handler struct {
-	service *Service
+	service Tasks
 }
 
 func (h *Handler) GetTasksByUser(c *fiber.Ctx) error {
@@ -23,41 +50,41 @@ func (h *Handler) GetTasksByUser(c *fiber.Ctx) error {
 		})
 	}
 
-	var sort SortParams
-
-	if c.Query("sortBy") == "" {
-		sort.SortBy = "timestamp"
-	} else{
-		sort.SortBy = c.Query("sortBy")
-	}
-	
-	if c.Query("sortDir") == "" {
-		sort.SortDir = -1
-	} else {
-		sort.SortDir, err = strconv.Atoi(c.Query("sortDir"))
+	sort, err := sortParams(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid sortBy or sortDir",
+		})
 	}
-
-
+	filter, err := filterParams(c)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid sortDir format",
+			"error": err.Error(),
 		})
 	}
 
-	if sort.SortBy == "none" {
-		sort.SortBy = "timestamp"
+	// ?fields=content,priority&expand=category for small clients (widget, watch)
+	fields, err := xquery.ParseFields(c.Query("fields"), TaskDocument{})
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-	if sort.SortDir == 0 {
-		sort.SortDir = -1
+	expand, err := xquery.ParseExpand(c.Query("expand"), "category")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
-
-  sortAggregation := bson.D{
-		{Key: "$sort", Value: bson.M{
-			sort.SortBy: sort.SortDir,
-		}},
+	if fields != nil || len(expand) > 0 {
+		views, err := h.service.GetTaskViewsByUser(c.UserContext(), userId, sort, filter, fields, expand["category"])
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(err)
+		}
+		return c.JSON(views)
 	}
 
-	Tasks, err := h.service.GetTasksByUser(userId, sortAggregation)
+	Tasks, err := h.service.GetTasksByUser(c.UserContext(), userId, sort, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(err)
 	}
@@ -66,8 +93,6 @@ func (h *Handler) GetTasksByUser(c *fiber.Ctx) error {
 }
 
 func (h *Handler) CreateTask(c *fiber.Ctx) error {
-	var params CreateTaskParams
-
 	categoryId, err := primitive.ObjectIDFromHex(c.Params("category"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -82,6 +107,11 @@ func (h *Handler) CreateTask(c *fiber.Ctx) error {
 		})
 	}
 
+	return h.createTask(c, userId, categoryId)
+}
+
+func (h *Handler) createTask(c *fiber.Ctx, userId primitive.ObjectID, categoryId primitive.ObjectID) error {
+	var params CreateTaskParams
 	if err := c.BodyParser(&params); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request body",
@@ -92,28 +122,88 @@ func (h *Handler) CreateTask(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(err)
 	}
 
-	doc := TaskDocument{
+	doc := newTask(params, time.Now())
+	_, err := h.service.CreateTask(c.UserContext(), userId, categoryId, &doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category not found",
+		})
+	}
+	if invalidSchedule(err) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(doc)
+}
+
+// newTask is the task params create, defaults filled in
+func newTask(params CreateTaskParams, now time.Time) TaskDocument {
+	priority := DefaultPriority
+	if params.Priority != nil {
+		priority = *params.Priority
+	}
+	status := params.Status
+	if status == "" {
+		status = Todo
+	}
+
+	return TaskDocument{
 		ID:        primitive.NewObjectID(),
-		Priority:  params.Priority,
+		Priority:  priority,
 		Content:   params.Content,
 		Value:     params.Value,
 		Recurring: params.Recurring,
 		RecurDetails: params.RecurDetails,
 		Public:    params.Public,
 		Active:    params.Active,
-		Timestamp: time.Now(),
+		StartDate: params.StartDate,
+		DueDate:   params.DueDate,
+		Recurrence: params.Recurrence,
+		Labels:    params.Labels,
+		Status:    status,
+		Timestamp: now,
+		UpdatedAt: now,
 	}
+}
 
-	_, err = h.service.CreateTask(userId, categoryId, &doc)
+func (h *Handler) GetTasks(c *fiber.Ctx) error {
+	Tasks, err := h.service.GetAllTasks(c.UserContext())
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch Tasks",
+		})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(doc)
+	return c.JSON(Tasks)
 }
 
-func (h *Handler) GetTasks(c *fiber.Ctx) error {
-	Tasks, err := h.service.GetAllTasks()
+/*
+GetUpcomingTasks lists the caller's open tasks due in the next ?within= (a
+duration such as 48h, up to maxUpcoming), soonest first.
+*/
+func (h *Handler) GetUpcomingTasks(c *fiber.Ctx) error {
+	id, _ := c.Locals("user_id").(string)
+	userId, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Not Authorized")
+	}
+
+	within := defaultUpcoming
+	if c.Query("within") != "" {
+		within, err = time.ParseDuration(c.Query("within"))
+		if err != nil || within <= 0 || within > maxUpcoming {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "within must be a duration such as 48h, up to 90 days",
+			})
+		}
+	}
+
+	Tasks, err := h.service.GetUpcomingTasks(c.UserContext(), userId, within)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch Tasks",
@@ -131,13 +221,18 @@ func (h *Handler) GetTask(c *fiber.Ctx) error {
 		})
 	}
 
-	Task, err := h.service.GetTaskByID(id)
+	Task, err := h.service.GetTaskByID(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Task not found",
 		})
 	}
 
+	etag, err := xetag.Versioned(Task.Version, Task)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderETag, etag)
 	return c.JSON(Task)
 }
 
@@ -149,14 +244,36 @@ func (h *Handler) UpdatePartialTask(c *fiber.Ctx) error {
 		})
 	}
 
+	version, err := xetag.IfMatch(c)
+	if err != nil {
+		return err
+	}
+
 	var update UpdateTaskDocument
 	if err := c.BodyParser(&update); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid request body",
 		})
 	}
+	if err := validator.Validate(update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+	if version != nil {
+		update.Version = version
+	}
 
-	if err := h.service.UpdatePartialTask(id, update); err != nil {
+	var conflict *xmongo.VersionConflict
+	if err := h.service.UpdatePartialTask(c.UserContext(), id, update); errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	} else if invalidSchedule(err) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	} else if errors.As(err, &conflict) {
+		return h.conflict(c, id, conflict)
+	} else if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to update Task",
 		})
@@ -165,6 +282,75 @@ func (h *Handler) UpdatePartialTask(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusOK)
 }
 
+// UpdateTaskSeries edits "this and all future occurrences" of a recurring task
+func (h *Handler) UpdateTaskSeries(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var update UpdateSeriesDocument
+	if err := c.BodyParser(&update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validator.Validate(update); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	if err := h.service.UpdateTaskSeries(c.UserContext(), id, update); errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	} else if invalidSchedule(err) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	} else if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update Task",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (h *Handler) CompleteTask(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	err = h.service.CompleteTask(c.UserContext(), userId, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	}
+	if errors.Is(err, ErrAlreadyCompleted) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to complete Task",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
 func (h *Handler) DeleteTask(c *fiber.Ctx) error {
 	id, err := primitive.ObjectIDFromHex(c.Params("id"))
 	if err != nil {
@@ -173,7 +359,19 @@ func (h *Handler) DeleteTask(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.service.DeleteTask(id); err != nil {
+	version, err := xetag.IfMatch(c)
+	if err != nil {
+		return err
+	}
+
+	var conflict *xmongo.VersionConflict
+	if err := h.service.DeleteTask(c.UserContext(), id, version); errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Task not found",
+		})
+	} else if errors.As(err, &conflict) {
+		return h.conflict(c, id, conflict)
+	} else if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete Task",
 		})
@@ -181,3 +379,57 @@ func (h *Handler) DeleteTask(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusOK)
 }
+
+/*
+BulkTasks applies a list of creates, completions, deletes and moves, such as
+the changes an offline client queued up, in one request. Each operation is
+answered with the status it would have got on its own.
+*/
+func (h *Handler) BulkTasks(c *fiber.Ctx) error {
+	userId, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+
+	var params BulkParams
+	if err := c.BodyParser(&params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if err := validator.Validate(params); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	results, err := h.service.BulkTasks(c.UserContext(), userId, params.Operations)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to apply operations",
+		})
+	}
+	return c.JSON(BulkResponse{Results: results})
+}
+
+/*
+conflict answers a write made against a stale version with the task as it is
+now, and its ETag, for the client to redo its change on top of
+*/
+func (h *Handler) conflict(c *fiber.Ctx, id primitive.ObjectID, conflict *xmongo.VersionConflict) error {
+	body := fiber.Map{
+		"error":   "Task was changed by another request",
+		"version": conflict.Current,
+	}
+	if current, err := h.service.GetTaskByID(c.UserContext(), id); err == nil {
+		body["version"] = current.Version
+		body["current"] = current
+		if etag, err := xetag.Versioned(current.Version, current); err == nil {
+			c.Set(fiber.HeaderETag, etag)
+		}
+	}
+	return c.Status(fiber.StatusConflict).JSON(body)
+}
+
+// invalidSchedule reports whether err rejects the dates or recurrence a request asked for
+func invalidSchedule(err error) bool {
+	return errors.Is(err, ErrStartAfterDue) || errors.Is(err, ErrRecurrenceNeedsDue) || errors.Is(err, ErrWeekdaysNotWeekly)
+}
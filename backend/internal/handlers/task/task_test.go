@@ -0,0 +1,463 @@
+package task
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/sharing"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTaskLifecycle(t *testing.T) {
+	repo := NewMemoryRepository()
+	userID, categoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	repo.AddCategory(userID, categoryID, "Chores")
+
+	handler := Handler{NewServiceWithRepository(repo, xcache.Noop{})}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Post("/:id/complete", handler.CompleteTask)
+	app.Post("/:user/:category", handler.CreateTask)
+	app.Get("/:id", handler.GetTask)
+	app.Patch("/:id", handler.UpdatePartialTask)
+	app.Delete("/:id", handler.DeleteTask)
+
+	res := do(t, app, http.MethodPost, "/"+userID.Hex()+"/"+categoryID.Hex(),
+		`{"priority": 1, "content": "Take out the trash", "value": 2}`)
+	if res.StatusCode != fiber.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", res.StatusCode)
+	}
+	var created TaskDocument
+	body, _ := io.ReadAll(res.Body)
+	if err := gojson.Unmarshal(body, &created); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	res = do(t, app, http.MethodGet, "/"+created.ID.Hex(), "")
+	first := res.Header.Get(fiber.HeaderETag)
+	if res.StatusCode != fiber.StatusOK || !strings.HasPrefix(first, `"v0-`) {
+		t.Fatalf("get: expected 200 with a version 0 ETag, got %d and %s", res.StatusCode, first)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		route        string
+		body         string
+		ifMatch      string
+		expectedCode int
+	}{
+		{"update without If-Match", http.MethodPatch, "/" + created.ID.Hex(), `{"content": "Take out the recycling"}`, "", fiber.StatusPreconditionRequired},
+		{"update with a foreign ETag", http.MethodPatch, "/" + created.ID.Hex(), `{"content": "Take out the recycling"}`, `"abc"`, fiber.StatusPreconditionFailed},
+		{"update", http.MethodPatch, "/" + created.ID.Hex(), `{"content": "Take out the recycling"}`, first, fiber.StatusOK},
+		{"update stale version", http.MethodPatch, "/" + created.ID.Hex(), `{"content": "Take out the compost"}`, first, fiber.StatusConflict},
+		{"complete", http.MethodPost, "/" + created.ID.Hex() + "/complete", "", "", fiber.StatusOK},
+		{"complete again", http.MethodPost, "/" + created.ID.Hex() + "/complete", "", "", fiber.StatusConflict},
+		{"delete without If-Match", http.MethodDelete, "/" + created.ID.Hex(), "", "", fiber.StatusPreconditionRequired},
+		{"delete stale version", http.MethodDelete, "/" + created.ID.Hex(), "", first, fiber.StatusConflict},
+		{"delete", http.MethodDelete, "/" + created.ID.Hex(), "", "*", fiber.StatusOK},
+		{"get deleted", http.MethodGet, "/" + created.ID.Hex(), "", "", fiber.StatusNotFound},
+		{"complete deleted", http.MethodPost, "/" + created.ID.Hex() + "/complete", "", "", fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if res := doIfMatch(t, app, tt.method, tt.route, tt.body, tt.ifMatch); res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+		}
+	}
+}
+
+func TestTaskConflict(t *testing.T) {
+	repo := NewMemoryRepository()
+	userID, categoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	repo.AddCategory(userID, categoryID, "Chores")
+	chore := TaskDocument{ID: primitive.NewObjectID(), Content: "Take out the trash"}
+	if _, err := repo.Insert(context.Background(), userID, categoryID, &chore); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := Handler{NewServiceWithRepository(repo, xcache.Noop{})}
+	app := fiber.New()
+	app.Get("/:id", handler.GetTask)
+	app.Patch("/:id", handler.UpdatePartialTask)
+
+	// two devices read the task, the second one's write loses
+	route := "/" + chore.ID.Hex()
+	etag := do(t, app, http.MethodGet, route, "").Header.Get(fiber.HeaderETag)
+	if res := doIfMatch(t, app, http.MethodPatch, route, `{"content": "Take out the recycling"}`, etag); res.StatusCode != fiber.StatusOK {
+		t.Fatalf("first write: expected 200, got %d", res.StatusCode)
+	}
+	res := doIfMatch(t, app, http.MethodPatch, route, `{"content": "Take out the compost"}`, etag)
+	if res.StatusCode != fiber.StatusConflict {
+		t.Fatalf("second write: expected 409, got %d", res.StatusCode)
+	}
+	var conflict struct {
+		Version int64        `json:"version"`
+		Current TaskDocument `json:"current"`
+	}
+	body, _ := io.ReadAll(res.Body)
+	if err := gojson.Unmarshal(body, &conflict); err != nil {
+		t.Fatal(err)
+	}
+	if conflict.Version != 1 || conflict.Current.Content != "Take out the recycling" {
+		t.Errorf("expected the server copy at version 1, got %s", body)
+	}
+
+	// retrying against the ETag the conflict sent goes through
+	if res := doIfMatch(t, app, http.MethodPatch, route, `{"content": "Take out the compost"}`, res.Header.Get(fiber.HeaderETag)); res.StatusCode != fiber.StatusOK {
+		t.Errorf("retry: expected 200, got %d", res.StatusCode)
+	}
+}
+
+func TestCategoryTasks(t *testing.T) {
+	repo := NewMemoryRepository()
+	userID, categoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	otherID, otherCategoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	repo.AddCategory(userID, categoryID, "Chores")
+	repo.AddCategory(otherID, otherCategoryID, "Errands")
+
+	handler := Handler{NewServiceWithRepository(repo, xcache.Noop{})}
+	app := fiber.New()
+	tasks := app.Group("/:category/tasks", func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	tasks.Post("/", handler.CreateCategoryTask)
+	tasks.Get("/", handler.GetCategoryTasks)
+	tasks.Patch("/:id", handler.OwnTask, handler.UpdatePartialTask)
+	tasks.Post("/:id/complete", handler.OwnTask, handler.CompleteTask)
+	tasks.Delete("/:id", handler.OwnTask, handler.DeleteTask)
+
+	other := TaskDocument{ID: primitive.NewObjectID(), Content: "Buy milk"}
+	if _, err := repo.Insert(context.Background(), otherID, otherCategoryID, &other); err != nil {
+		t.Fatal(err)
+	}
+
+	res := do(t, app, http.MethodPost, "/"+categoryID.Hex()+"/tasks",
+		`{"priority": 1, "content": "Take out the trash", "value": 2}`)
+	if res.StatusCode != fiber.StatusCreated {
+		t.Fatalf("create: expected 201, got %d", res.StatusCode)
+	}
+	var created TaskDocument
+	body, _ := io.ReadAll(res.Body)
+	if err := gojson.Unmarshal(body, &created); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	own := "/" + categoryID.Hex() + "/tasks/" + created.ID.Hex()
+	foreign := "/" + otherCategoryID.Hex() + "/tasks/" + other.ID.Hex()
+	tests := []struct {
+		name         string
+		method       string
+		route        string
+		body         string
+		expectedCode int
+	}{
+		{"create in foreign category", http.MethodPost, "/" + otherCategoryID.Hex() + "/tasks", `{"priority": 1, "content": "Sneak in", "value": 2}`, fiber.StatusNotFound},
+		{"list", http.MethodGet, "/" + categoryID.Hex() + "/tasks", "", fiber.StatusOK},
+		{"list foreign category", http.MethodGet, "/" + otherCategoryID.Hex() + "/tasks", "", fiber.StatusNotFound},
+		{"update", http.MethodPatch, own, `{"content": "Take out the recycling"}`, fiber.StatusOK},
+		{"update foreign task", http.MethodPatch, foreign, `{"content": "Buy oat milk"}`, fiber.StatusNotFound},
+		{"update in wrong category", http.MethodPatch, "/" + otherCategoryID.Hex() + "/tasks/" + created.ID.Hex(), `{"content": "Moved"}`, fiber.StatusNotFound},
+		{"complete", http.MethodPost, own + "/complete", "", fiber.StatusOK},
+		{"complete foreign task", http.MethodPost, foreign + "/complete", "", fiber.StatusNotFound},
+		{"delete foreign task", http.MethodDelete, foreign, "", fiber.StatusNotFound},
+		{"delete", http.MethodDelete, own, "", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		if res := doIfMatch(t, app, tt.method, tt.route, tt.body, "*"); res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+		}
+	}
+	if _, err := repo.FindByID(context.Background(), other.ID); err != nil {
+		t.Errorf("foreign task: %v", err)
+	}
+}
+
+func TestSharedCategoryTasks(t *testing.T) {
+	repo := NewMemoryRepository()
+	ownerID, categoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	editorID, viewerID, invitedID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	repo.AddCategory(ownerID, categoryID, "Groceries")
+	accepted := time.Now()
+	repo.Share(categoryID, sharing.Member{User: editorID, Role: sharing.Editor, AcceptedAt: &accepted})
+	repo.Share(categoryID, sharing.Member{User: viewerID, Role: sharing.Viewer, AcceptedAt: &accepted})
+	repo.Share(categoryID, sharing.Member{User: invitedID, Role: sharing.Editor})
+
+	handler := Handler{NewServiceWithRepository(repo, xcache.Noop{})}
+	app := fiber.New()
+	tasks := app.Group("/:caller/:category/tasks", func(c *fiber.Ctx) error {
+		c.Locals("user_id", c.Params("caller"))
+		return c.Next()
+	})
+	tasks.Post("/", handler.CreateCategoryTask)
+	tasks.Get("/", handler.GetCategoryTasks)
+	tasks.Patch("/:id", handler.OwnTask, handler.UpdatePartialTask)
+	tasks.Post("/:id/complete", handler.OwnTask, handler.CompleteTask)
+
+	milk := TaskDocument{ID: primitive.NewObjectID(), Content: "Buy milk"}
+	if _, err := repo.Insert(context.Background(), ownerID, categoryID, &milk); err != nil {
+		t.Fatal(err)
+	}
+
+	route := func(caller primitive.ObjectID) string {
+		return "/" + caller.Hex() + "/" + categoryID.Hex() + "/tasks"
+	}
+	tests := []struct {
+		name         string
+		method       string
+		route        string
+		body         string
+		expectedCode int
+	}{
+		{"editor lists", http.MethodGet, route(editorID), "", fiber.StatusOK},
+		{"viewer lists", http.MethodGet, route(viewerID), "", fiber.StatusOK},
+		{"pending invite lists", http.MethodGet, route(invitedID), "", fiber.StatusNotFound},
+		{"editor creates", http.MethodPost, route(editorID), `{"priority": 1, "content": "Buy eggs", "value": 1}`, fiber.StatusCreated},
+		{"viewer creates", http.MethodPost, route(viewerID), `{"priority": 1, "content": "Buy candy", "value": 1}`, fiber.StatusNotFound},
+		{"viewer updates", http.MethodPatch, route(viewerID) + "/" + milk.ID.Hex(), `{"content": "Buy oat milk"}`, fiber.StatusNotFound},
+		{"pending invite completes", http.MethodPost, route(invitedID) + "/" + milk.ID.Hex() + "/complete", "", fiber.StatusNotFound},
+		{"editor updates", http.MethodPatch, route(editorID) + "/" + milk.ID.Hex(), `{"content": "Buy oat milk"}`, fiber.StatusOK},
+		{"editor completes", http.MethodPost, route(editorID) + "/" + milk.ID.Hex() + "/complete", "", fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		if res := doIfMatch(t, app, tt.method, tt.route, tt.body, "*"); res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+		}
+	}
+
+	// the editor's task lands in the owner's category
+	owned, err := repo.ListByCategory(context.Background(), ownerID, categoryID, SortParams{SortBy: "timestamp", SortDir: 1}, Filter{})
+	if err != nil || len(owned) != 2 {
+		t.Errorf("owner's category: expected 2 tasks, got %d, %v", len(owned), err)
+	}
+}
+
+func TestPartialTaskDates(t *testing.T) {
+	repo := NewMemoryRepository()
+	userID, categoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	repo.AddCategory(userID, categoryID, "Chores")
+
+	handler := Handler{NewServiceWithRepository(repo, xcache.Noop{})}
+	app := fiber.New()
+	app.Patch("/:id", handler.UpdatePartialTask)
+
+	start := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	due := time.Date(2026, time.March, 6, 17, 0, 0, 0, time.UTC)
+	task := TaskDocument{ID: primitive.NewObjectID(), Content: "File taxes", StartDate: &start, DueDate: &due}
+	if _, err := repo.Insert(context.Background(), userID, categoryID, &task); err != nil {
+		t.Fatal(err)
+	}
+
+	route := "/" + task.ID.Hex()
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{"start after the stored due", `{"content": "File taxes", "start_date": "2026-03-10T09:00:00Z"}`, fiber.StatusBadRequest},
+		{"due before the stored start", `{"content": "File taxes", "due_date": "2026-03-01T17:00:00Z"}`, fiber.StatusBadRequest},
+		{"both dates out of order", `{"content": "File taxes", "start_date": "2026-03-10T09:00:00Z", "due_date": "2026-03-01T17:00:00Z"}`, fiber.StatusBadRequest},
+		{"start before the stored due", `{"content": "File taxes", "start_date": "2026-03-04T09:00:00Z"}`, fiber.StatusOK},
+		{"due after the stored start", `{"content": "File taxes", "due_date": "2026-03-20T17:00:00Z"}`, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		if res := doIfMatch(t, app, http.MethodPatch, route, tt.body, "*"); res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+		}
+	}
+
+	stored, err := repo.FindByID(context.Background(), task.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedStart := time.Date(2026, time.March, 4, 9, 0, 0, 0, time.UTC)
+	expectedDue := time.Date(2026, time.March, 20, 17, 0, 0, 0, time.UTC)
+	if stored.StartDate == nil || !stored.StartDate.Equal(expectedStart) || stored.DueDate == nil || !stored.DueDate.Equal(expectedDue) {
+		t.Errorf("expected dates %s to %s, got %v to %v", expectedStart, expectedDue, stored.StartDate, stored.DueDate)
+	}
+}
+
+func TestTaskFilters(t *testing.T) {
+	repo := NewMemoryRepository()
+	userID, categoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	repo.AddCategory(userID, categoryID, "Chores")
+
+	handler := Handler{NewServiceWithRepository(repo, xcache.Noop{})}
+	app := fiber.New()
+	tasks := app.Group("/:category/tasks", func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	tasks.Get("/", handler.GetCategoryTasks)
+
+	soon := time.Date(2026, time.March, 2, 9, 0, 0, 0, time.UTC)
+	later := soon.Add(48 * time.Hour)
+	for _, task := range []TaskDocument{
+		{Content: "taxes", Priority: P0, DueDate: &later, Labels: []string{"home", "money"}},
+		{Content: "groceries", Priority: P2, DueDate: &soon, Labels: []string{"errands"}, Status: InProgress},
+		{Content: "laundry", Priority: P3, Labels: []string{"home"}},
+		{Content: "dentist", Priority: P1, Completed: true},
+	} {
+		task.ID = primitive.NewObjectID()
+		if _, err := repo.Insert(context.Background(), userID, categoryID, &task); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	route := "/" + categoryID.Hex() + "/tasks"
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expected     string
+	}{
+		{"most urgent first", "?sortBy=priority", fiber.StatusOK, "taxes dentist groceries laundry"},
+		{"least urgent first", "?sortBy=priority&sortDir=-1", fiber.StatusOK, "laundry groceries dentist taxes"},
+		{"due soonest, undated last", "?sortBy=due_date", fiber.StatusOK, "groceries taxes laundry dentist"},
+		{"by label", "?label=Home&sortBy=priority", fiber.StatusOK, "taxes laundry"},
+		{"by any of the labels", "?label=money,errands&sortBy=priority", fiber.StatusOK, "taxes groceries"},
+		{"by priority", "?priority=P0,1&sortBy=priority", fiber.StatusOK, "taxes dentist"},
+		{"todo", "?status=todo&sortBy=priority", fiber.StatusOK, "taxes laundry"},
+		{"in progress", "?status=in_progress", fiber.StatusOK, "groceries"},
+		{"done", "?status=done", fiber.StatusOK, "dentist"},
+		{"combined", "?label=home&status=todo&priority=3", fiber.StatusOK, "laundry"},
+		{"unknown priority", "?priority=P4", fiber.StatusBadRequest, ""},
+		{"unknown status", "?status=blocked", fiber.StatusBadRequest, ""},
+		{"unknown sort", "?sortBy=content", fiber.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		res := do(t, app, http.MethodGet, route+tt.query, "")
+		if res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+			continue
+		}
+		if tt.expectedCode != fiber.StatusOK {
+			continue
+		}
+		var listed []TaskDocument
+		body, _ := io.ReadAll(res.Body)
+		if err := gojson.Unmarshal(body, &listed); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		contents := make([]string, 0, len(listed))
+		for _, task := range listed {
+			contents = append(contents, task.Content)
+		}
+		if got := strings.Join(contents, " "); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestBulkTasks(t *testing.T) {
+	repo := NewMemoryRepository()
+	userID, choresID, errandsID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	otherID, sharedID, privateID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	repo.AddCategory(userID, choresID, "Chores")
+	repo.AddCategory(userID, errandsID, "Errands")
+	repo.AddCategory(otherID, sharedID, "Household")
+	repo.AddCategory(otherID, privateID, "Diary")
+	accepted := time.Now()
+	repo.Share(sharedID, sharing.Member{User: userID, Role: sharing.Editor, AcceptedAt: &accepted})
+
+	handler := Handler{NewServiceWithRepository(repo, xcache.Noop{})}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Post("/bulk", handler.BulkTasks)
+
+	taskID, unknownID := primitive.NewObjectID(), primitive.NewObjectID()
+	create := func(id primitive.ObjectID, category primitive.ObjectID) string {
+		return `{"action": "create", "id": "` + id.Hex() + `", "category": "` + category.Hex() + `", "task": {"content": "Buy milk", "value": 1}}`
+	}
+	on := func(action string, id primitive.ObjectID, category primitive.ObjectID) string {
+		return `{"action": "` + action + `", "id": "` + id.Hex() + `", "category": "` + category.Hex() + `"}`
+	}
+	operations := []struct {
+		name           string
+		operation      string
+		expectedStatus int
+	}{
+		{"create", create(taskID, choresID), fiber.StatusCreated},
+		{"create again", create(taskID, choresID), fiber.StatusConflict},
+		{"create without a task", `{"action": "create", "category": "` + choresID.Hex() + `"}`, fiber.StatusBadRequest},
+		{"create in another user's category", create(primitive.NewObjectID(), privateID), fiber.StatusNotFound},
+		{"create in a shared category", create(primitive.NewObjectID(), sharedID), fiber.StatusCreated},
+		{"complete", on("complete", taskID, primitive.NilObjectID), fiber.StatusOK},
+		{"complete again", on("complete", taskID, primitive.NilObjectID), fiber.StatusConflict},
+		{"complete unknown", on("complete", unknownID, primitive.NilObjectID), fiber.StatusNotFound},
+		{"move", on("move", taskID, errandsID), fiber.StatusOK},
+		{"move to another owner", on("move", taskID, sharedID), fiber.StatusBadRequest},
+		{"move to a missing category", on("move", taskID, privateID), fiber.StatusNotFound},
+		{"delete", on("delete", taskID, primitive.NilObjectID), fiber.StatusOK},
+		{"delete again", on("delete", taskID, primitive.NilObjectID), fiber.StatusNotFound},
+	}
+	list := make([]string, 0, len(operations))
+	for _, op := range operations {
+		list = append(list, op.operation)
+	}
+	res := do(t, app, http.MethodPost, "/bulk", `{"operations": [`+strings.Join(list, ", ")+`]}`)
+	if res.StatusCode != fiber.StatusOK {
+		t.Fatalf("bulk: expected 200, got %d", res.StatusCode)
+	}
+	var response BulkResponse
+	body, _ := io.ReadAll(res.Body)
+	if err := gojson.Unmarshal(body, &response); err != nil {
+		t.Fatalf("bulk: %v", err)
+	}
+	if len(response.Results) != len(operations) {
+		t.Fatalf("bulk: expected %d results, got %s", len(operations), body)
+	}
+	for i, op := range operations {
+		if got := response.Results[i]; got.Status != op.expectedStatus {
+			t.Errorf("%s: expected %d, got %d: %s", op.name, op.expectedStatus, got.Status, got.Error)
+		}
+	}
+
+	errands, err := repo.ListByCategory(context.Background(), userID, errandsID, SortParams{SortBy: string(Time), SortDir: 1}, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errands) != 0 {
+		t.Errorf("expected the moved task to be deleted, got %+v", errands)
+	}
+
+	if res := do(t, app, http.MethodPost, "/bulk", `{"operations": []}`); res.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("no operations: expected 400, got %d", res.StatusCode)
+	}
+}
+
+func do(t *testing.T, app *fiber.App, method string, route string, body string) *http.Response {
+	t.Helper()
+	return doIfMatch(t, app, method, route, body, "")
+}
+
+// doIfMatch is do with an If-Match precondition, which PATCH and DELETE need
+func doIfMatch(t *testing.T, app *fiber.App, method string, route string, body string, ifMatch string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, route, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if ifMatch != "" {
+		req.Header.Set(fiber.HeaderIfMatch, ifMatch)
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
@@ -1,27 +1,72 @@
 package task
 
 import (
+	"errors"
 	"time"
 
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CreateTaskParams struct {
-	Priority  int                `validate:"required,min=1,max=3" bson:"priority" json:"priority"`
+	// P0 to P3, DefaultPriority when left out
+	Priority  *int               `validate:"omitempty,min=0,max=3" bson:"priority" json:"priority"`
 	Content   string             `validate:"required" bson:"content" json:"content"`
 	Value     float64            `validate:"required,min=0,max=10" bson:"value" json:"value"`
 	Recurring bool               `bson:"recurring" json:"recurring"`
 	RecurDetails map[string]interface{} `bson:"recurDetails,omitempty" bsonjson:"recurDetails,omitempty"`
 	Public    bool               `bson:"public" json:"public"`
 	Active    bool               `bson:"active" json:"active"`
+	StartDate *time.Time         `bson:"start_date,omitempty" json:"start_date,omitempty"`
+	DueDate   *time.Time         `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	// makes the task the first of a series, which needs a due date
+	Recurrence *Recurrence `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	Labels     []string    `validate:"omitempty,max=20,dive,min=1,max=50" bson:"labels,omitempty" json:"labels,omitempty"`
+	Status     Status      `validate:"omitempty,oneof=todo in_progress" bson:"status,omitempty" json:"status,omitempty"`
 }
 
 type SortParams struct {
-	SortBy string `validate:"oneof=priority timestamp difficulty none" bson:"sortBy" json:"sortBy"`
+	SortBy string `validate:"oneof=priority timestamp due_date value" bson:"sortBy" json:"sortBy"`
 	SortDir int `validate:"oneof=1 -1" bson:"sortDir" json:"sortDir"`
 }
 
+// Filter narrows a task listing; each field that is set has to match
+type Filter struct {
+	// tasks with any of the labels
+	Labels     []string
+	Priorities []int
+	Status     Status
+}
+
+/*
+Priorities run from P0, the most urgent, to P3. Tasks created without one
+are DefaultPriority. Before migration 2 they ran from 1 (low) to 3 (high).
+*/
+const (
+	P0 = iota
+	P1
+	P2
+	P3
+
+	DefaultPriority = P2
+)
+
+func ValidPriority(p int) bool {
+	return p >= P0 && p <= P3
+}
+
+/*
+Status is where a task stands. Completing a task makes it Done, and Done is
+read from Completed, so tasks from before statuses were stored are Todo or Done.
+*/
+type Status string
+
+const (
+	Todo       Status = "todo"
+	InProgress Status = "in_progress"
+	Done       Status = "done"
+)
+
 type TaskDocument struct {
 	ID        primitive.ObjectID `bson:"_id" json:"id"`
 	Priority  int                `bson:"priority" json:"priority"`
@@ -32,16 +77,99 @@ type TaskDocument struct {
 	Public    bool               `bson:"public" json:"public"`
 	Active    bool               `bson:"active" json:"active"`
 	Timestamp time.Time          `bson:"timestamp" json:"timestamp"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	Completed   bool             `bson:"completed" json:"completed"`
+	CompletedAt *time.Time       `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	DeletedAt   *time.Time       `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	// when the user means to start on it, the due date being when it has to be done
+	StartDate   *time.Time       `bson:"start_date,omitempty" json:"start_date,omitempty"`
+	DueDate     *time.Time       `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	// set on the head of a series only, see recurrence.go
+	Recurrence *Recurrence         `bson:"recurrence,omitempty" json:"recurrence,omitempty"`
+	SeriesID   *primitive.ObjectID `bson:"series_id,omitempty" json:"series_id,omitempty"`
+	// files attached through the uploads API
+	Attachments []Attachment `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	// longer text than the content, so far only from inbound email
+	Notes string `bson:"notes,omitempty" json:"notes,omitempty"`
+	// made for the user (e.g. from an email) and not reviewed yet; any update clears it
+	Draft bool `bson:"draft,omitempty" json:"draft,omitempty"`
+	// free-form, lowercased and without duplicates
+	Labels []string `bson:"labels,omitempty" json:"labels,omitempty"`
+	// see CurrentStatus for tasks without one
+	Status Status `bson:"status,omitempty" json:"status,omitempty"`
+	// where an imported task came from, e.g. "apple_reminders:<id>"; re-imports skip tasks already carrying it
+	Source string `bson:"source,omitempty" json:"source,omitempty"`
+	// the page a task was captured from, with its preview once unfurled
+	Link *Link `bson:"link,omitempty" json:"link,omitempty"`
+	// bumped by every write, see xmongo.UpdateVersioned
+	Version int64 `bson:"version" json:"version"`
+}
+
+type Link struct {
+	URL         string `bson:"url" json:"url"`
+	Title       string `bson:"title,omitempty" json:"title,omitempty"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+	Image       string `bson:"image,omitempty" json:"image,omitempty"`
+	SiteName    string `bson:"site_name,omitempty" json:"site_name,omitempty"`
+}
+
+type Attachment struct {
+	UploadID    primitive.ObjectID `bson:"upload_id" json:"upload_id"`
+	Name        string             `bson:"name" json:"name"`
+	ContentType string             `bson:"content_type" json:"content_type"`
+	Size        int64              `bson:"size" json:"size"`
+}
+
+func (t TaskDocument) IsDeleted() bool {
+	return t.DeletedAt != nil
+}
+
+// CurrentStatus is the task's status, also for tasks stored before it was
+func (t TaskDocument) CurrentStatus() Status {
+	switch {
+	case t.Completed:
+		return Done
+	case t.Status == InProgress:
+		return InProgress
+	}
+	return Todo
 }
 
 type UpdateTaskDocument struct {
-	Priority  int                `bson:"priority" json:"priority"`
+	// kept when left out
+	Priority  *int               `validate:"omitempty,min=0,max=3" bson:"priority,omitempty" json:"priority,omitempty"`
 	Content   string             `bson:"content" json:"content"`
 	Value     float64            `bson:"value" json:"value"`
 	Recurring bool               `bson:"recurring" json:"recurring"`
 	RecurDetails map[string]interface{} `bson:"recurDetails" json:"recurDetails"`
 	Public    bool               `bson:"public" json:"public"`
 	Active    bool               `bson:"active" json:"active"`
+	// leaving out one date keeps the stored one, leaving out both clears them
+	StartDate *time.Time `bson:"start_date" json:"start_date"`
+	DueDate   *time.Time `bson:"due_date" json:"due_date"`
+	Notes   string     `bson:"notes" json:"notes"`
+	// saving a draft publishes it unless the client keeps it a draft
+	Draft bool `bson:"draft" json:"draft"`
+	// kept when left out, an empty list clears them
+	Labels *[]string `validate:"omitempty,max=20,dive,min=1,max=50" bson:"labels,omitempty" json:"labels,omitempty"`
+	// done only by completing the task
+	Status Status `validate:"omitempty,oneof=todo in_progress" bson:"status,omitempty" json:"status,omitempty"`
+	// the version the client last read; when set, the update fails with a conflict if the task has changed since
+	Version *int64 `bson:"-" json:"version,omitempty"`
+}
+
+// UpdateSeriesDocument edits an occurrence and every later one in its series; fields left out are kept
+type UpdateSeriesDocument struct {
+	Priority *int     `validate:"omitempty,min=0,max=3" bson:"priority,omitempty" json:"priority,omitempty"`
+	Content  *string  `validate:"omitempty,min=1" bson:"content,omitempty" json:"content,omitempty"`
+	Value    *float64 `validate:"omitempty,min=0,max=10" bson:"value,omitempty" json:"value,omitempty"`
+	Public   *bool    `bson:"public,omitempty" json:"public,omitempty"`
+	Active   *bool    `bson:"active,omitempty" json:"active,omitempty"`
+	Notes    *string  `bson:"notes,omitempty" json:"notes,omitempty"`
+	// replaces the rule of the series, counted from the due date of its head
+	Recurrence *Recurrence `bson:"-" json:"recurrence,omitempty"`
+	// ends the series with its current head
+	EndRecurrence bool `bson:"-" json:"end_recurrence,omitempty"`
 }
 
 type SortTypes string
@@ -51,6 +179,7 @@ const (
 	Priority SortTypes = "priority"
 	Time SortTypes = "timestamp"
 	Difficulty SortTypes = "value"
+	Due SortTypes = "due_date"
 
 	Ascending SortDirection = 1
 	Descending SortDirection = -1
@@ -62,5 +191,69 @@ Database layer of the application
 */
 
 type Service struct {
-	Tasks *mongo.Collection
+	repo  Repository
+	cache xcache.Cache
+}
+
+const (
+	// how far ahead GET /Tasks/upcoming looks without ?within=, and at most
+	defaultUpcoming = 7 * 24 * time.Hour
+	maxUpcoming     = 90 * 24 * time.Hour
+)
+
+var (
+	ErrAlreadyCompleted = errors.New("task is already completed")
+	ErrStartAfterDue    = errors.New("start_date is after due_date")
+	// a series is counted from the due date of its first task
+	ErrRecurrenceNeedsDue = errors.New("a recurring task needs a due_date")
+	ErrWeekdaysNotWeekly  = errors.New("weekdays only apply to weekly recurrence")
+	// a bulk create naming the id of a task that is already there, as when a client retries
+	ErrTaskExists = errors.New("task already exists")
+	// tasks are embedded in their owner's document, so they only move between categories of the same owner
+	ErrMoveToOtherOwner = errors.New("a task can only move to another category of the same owner")
+)
+
+// BulkAction is what one operation of a bulk request does
+type BulkAction string
+
+const (
+	BulkCreate   BulkAction = "create"
+	BulkComplete BulkAction = "complete"
+	BulkDelete   BulkAction = "delete"
+	BulkMove     BulkAction = "move"
+)
+
+type BulkOperation struct {
+	Action BulkAction `validate:"required,oneof=create complete delete move" json:"action"`
+	// the task completed, deleted or moved; creates may pick it, so a retried create is reported as a conflict and not added twice
+	ID primitive.ObjectID `json:"id,omitempty"`
+	// the category a task is created in or moved to
+	Category primitive.ObjectID `json:"category,omitempty"`
+	// the task to create
+	Task *CreateTaskParams `json:"task,omitempty"`
+}
+
+type BulkParams struct {
+	Operations []BulkOperation `validate:"required,min=1,max=100,dive" json:"operations"`
+}
+
+// BulkResult is how one operation went, with the status it would have been answered with on its own
+type BulkResult struct {
+	Status int                 `json:"status"`
+	ID     *primitive.ObjectID `json:"id,omitempty"`
+	Error  string              `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	// in the order of the operations
+	Results []BulkResult `json:"results"`
+}
+
+// BulkChange is a BulkOperation the service checked, as the repository applies it
+type BulkChange struct {
+	Action   BulkAction
+	ID       primitive.ObjectID
+	Category primitive.ObjectID
+	// the task BulkCreate adds, defaults filled in
+	Task *TaskDocument
 }
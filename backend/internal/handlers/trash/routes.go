@@ -0,0 +1,29 @@
+package trash
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, cache xcache.Cache, authenticate fiber.Handler) {
+	service := newService(collections, cache)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Bin := apiV1.Group("/trash", authenticate)
+	Bin.Get("/", handler.GetTrash)
+	Bin.Post("/categories/:id/restore", handler.RestoreCategory)
+	Bin.Post("/tasks/:id/restore", handler.RestoreTask)
+
+	xopenapi.Register(xopenapi.Operations{
+		"GET /api/v1/trash/":                        {Summary: "The caller's deleted categories and tasks, until the purge removes them for good", Auth: true, Response: Trash{}},
+		"POST /api/v1/trash/categories/:id/restore": {Summary: "Restore a deleted category with the tasks deleted along with it", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/trash/tasks/:id/restore":      {Summary: "Restore a deleted task whose category isn't deleted", Auth: true, Status: fiber.StatusNoContent},
+	})
+}
@@ -0,0 +1,164 @@
+package trash
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/tombstone"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// newService receives the map of collections and picks out Users and Tombstones
+func newService(collections map[string]*mongo.Collection, cache xcache.Cache) *Service {
+	return &Service{
+		users:      collections["users"],
+		tombstones: collections[tombstone.Collection],
+		cache:      cache,
+	}
+}
+
+// Get lists the user's trash
+func (s *Service) Get(ctx context.Context, userID primitive.ObjectID) (*Trash, error) {
+	var user owned
+	err := s.users.FindOne(ctx, softdelete.Filter(bson.M{"_id": userID}), options.FindOne().SetProjection(bson.M{
+		"categories._id":              1,
+		"categories.name":             1,
+		"categories.deleted_at":       1,
+		"categories.tasks._id":        1,
+		"categories.tasks.content":    1,
+		"categories.tasks.deleted_at": 1,
+	})).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return list(user), nil
+}
+
+func list(user owned) *Trash {
+	trash := &Trash{Categories: make([]TrashedCategory, 0), Tasks: make([]TrashedTask, 0)}
+	for _, c := range user.Categories {
+		if c.DeletedAt != nil {
+			live := 0
+			for _, t := range c.Tasks {
+				if t.DeletedAt == nil {
+					live++
+				}
+			}
+			trash.Categories = append(trash.Categories, TrashedCategory{
+				ID:         c.ID,
+				Name:       c.Name,
+				Tasks:      live,
+				DeletedAt:  *c.DeletedAt,
+				PurgeAfter: c.DeletedAt.Add(softdelete.Retention),
+			})
+			continue
+		}
+		for _, t := range c.Tasks {
+			if t.DeletedAt != nil {
+				trash.Tasks = append(trash.Tasks, TrashedTask{
+					ID:           t.ID,
+					Content:      t.Content,
+					CategoryID:   c.ID,
+					CategoryName: c.Name,
+					DeletedAt:    *t.DeletedAt,
+					PurgeAfter:   t.DeletedAt.Add(softdelete.Retention),
+				})
+			}
+		}
+	}
+	slices.SortStableFunc(trash.Categories, func(a, b TrashedCategory) int { return b.DeletedAt.Compare(a.DeletedAt) })
+	slices.SortStableFunc(trash.Tasks, func(a, b TrashedTask) int { return b.DeletedAt.Compare(a.DeletedAt) })
+	return trash
+}
+
+/*
+RestoreCategory takes one of the user's categories out of the trash, with the
+tasks that went in with it. Those count as edited now, so syncing clients that
+dropped them with the category get them back.
+*/
+func (s *Service) RestoreCategory(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := s.users.UpdateOne(ctx,
+		softdelete.Filter(bson.M{
+			"_id":        userID,
+			"categories": bson.M{"$elemMatch": bson.M{"_id": id, softdelete.Field: bson.M{"$ne": nil}}},
+		}),
+		bson.M{
+			"$unset": bson.M{"categories.$[c]." + softdelete.Field: ""},
+			"$set": bson.M{
+				"categories.$[c].lastEdited":            now,
+				"categories.$[c].tasks.$[t].updated_at": now,
+			},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{"c._id": id},
+			bson.M{"t." + softdelete.Field: nil},
+		}}),
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotInTrash
+	}
+	s.restored(ctx, userID, tombstone.Category, id)
+	return nil
+}
+
+// RestoreTask takes one of the user's tasks out of the trash, unless its category is in there too
+func (s *Service) RestoreTask(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := s.users.UpdateOne(ctx,
+		softdelete.Filter(bson.M{
+			"_id": userID,
+			"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{
+				"tasks": bson.M{"$elemMatch": bson.M{"_id": id, softdelete.Field: bson.M{"$ne": nil}}},
+			})},
+		}),
+		bson.M{
+			"$unset": bson.M{"categories.$[].tasks.$[t]." + softdelete.Field: ""},
+			"$set":   bson.M{"categories.$[].tasks.$[t].updated_at": now},
+			"$inc":   bson.M{"categories.$[].tasks.$[t].version": 1},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"t._id": id}}}),
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		s.restored(ctx, userID, tombstone.Task, id)
+		return nil
+	}
+
+	n, err := s.users.CountDocuments(ctx, softdelete.Filter(bson.M{
+		"_id": userID,
+		"categories": bson.M{"$elemMatch": bson.M{
+			softdelete.Field: bson.M{"$ne": nil},
+			"tasks._id":      id,
+		}},
+	}), options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrCategoryInTrash
+	}
+	return ErrNotInTrash
+}
+
+// restored drops the cached categories and the tombstone of what came back
+func (s *Service) restored(ctx context.Context, userID primitive.ObjectID, entity tombstone.Entity, id primitive.ObjectID) {
+	xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(userID.Hex()))
+	if err := tombstone.Forget(ctx, s.tombstones, userID, entity, id); err != nil {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to forget tombstone of a restored item",
+			slog.String("entity", string(entity)), slog.String("id", id.Hex()), xslog.Error(err))
+	}
+}
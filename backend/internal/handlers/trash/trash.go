@@ -0,0 +1,86 @@
+package trash
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+//go:generate mockgen -source=trash.go -destination=mock_test.go -package=trash
+
+// Trashcan is what Handler needs of the service
+type Trashcan interface {
+	Get(ctx context.Context, userID primitive.ObjectID) (*Trash, error)
+	RestoreCategory(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+	RestoreTask(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+}
+
+var _ Trashcan = (*Service)(nil)
+
+/*
+Handler to execute business logic for the trash
+*/
+type Handler struct {
+	service Trashcan
+}
+
+func (h *Handler) GetTrash(c *fiber.Ctx) error {
+	userID, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	trash, err := h.service.Get(c.UserContext(), userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to load the trash",
+		})
+	}
+	return c.JSON(trash)
+}
+
+func (h *Handler) RestoreCategory(c *fiber.Ctx) error {
+	return h.restore(c, h.service.RestoreCategory)
+}
+
+func (h *Handler) RestoreTask(c *fiber.Ctx) error {
+	return h.restore(c, h.service.RestoreTask)
+}
+
+func (h *Handler) restore(c *fiber.Ctx, restore func(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error) error {
+	userID, err := xauth.UserID(c)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	err = restore(c.UserContext(), userID, id)
+	switch {
+	case errors.Is(err, ErrNotInTrash):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, ErrCategoryInTrash):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to restore",
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
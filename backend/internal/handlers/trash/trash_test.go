@@ -0,0 +1,98 @@
+package trash
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/mock/gomock"
+)
+
+func TestList(t *testing.T) {
+	monday := time.Date(2026, time.March, 9, 10, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+	kept, trashed := primitive.NewObjectID(), primitive.NewObjectID()
+	older, newer, inTrashed := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	// decoded as the service reads it, through BSON
+	raw, err := bson.Marshal(bson.M{"categories": bson.A{
+		bson.M{"_id": kept, "name": "Home", "tasks": bson.A{
+			bson.M{"_id": primitive.NewObjectID(), "content": "live"},
+			bson.M{"_id": older, "content": "older", "deleted_at": monday},
+			bson.M{"_id": newer, "content": "newer", "deleted_at": tuesday},
+		}},
+		bson.M{"_id": trashed, "name": "Work", "deleted_at": monday, "tasks": bson.A{
+			bson.M{"_id": primitive.NewObjectID(), "content": "went with it"},
+			bson.M{"_id": inTrashed, "content": "deleted before", "deleted_at": monday.Add(-time.Hour)},
+		}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var user owned
+	if err := bson.Unmarshal(raw, &user); err != nil {
+		t.Fatal(err)
+	}
+
+	trash := list(user)
+	if len(trash.Categories) != 1 || trash.Categories[0].ID != trashed || trash.Categories[0].Tasks != 1 {
+		t.Fatalf("expected Work with 1 task, got %+v", trash.Categories)
+	}
+	if !trash.Categories[0].PurgeAfter.Equal(monday.Add(softdelete.Retention)) {
+		t.Errorf("expected Work purged after %v, got %v", monday.Add(softdelete.Retention), trash.Categories[0].PurgeAfter)
+	}
+	if len(trash.Tasks) != 2 || trash.Tasks[0].ID != newer || trash.Tasks[1].ID != older {
+		t.Fatalf("expected newer then older, got %+v", trash.Tasks)
+	}
+	if trash.Tasks[0].CategoryID != kept || trash.Tasks[0].CategoryName != "Home" {
+		t.Errorf("expected the task's category, got %+v", trash.Tasks[0])
+	}
+}
+
+func TestRestore(t *testing.T) {
+	userID, categoryID, taskID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	service := NewMockTrashcan(gomock.NewController(t))
+	service.EXPECT().Get(gomock.Any(), userID).Return(&Trash{}, nil)
+	service.EXPECT().RestoreCategory(gomock.Any(), userID, categoryID).Return(nil)
+	service.EXPECT().RestoreCategory(gomock.Any(), userID, taskID).Return(ErrNotInTrash)
+	service.EXPECT().RestoreTask(gomock.Any(), userID, taskID).Return(ErrCategoryInTrash)
+
+	handler := Handler{service}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID.Hex())
+		return c.Next()
+	})
+	app.Get("/trash", handler.GetTrash)
+	app.Post("/trash/categories/:id/restore", handler.RestoreCategory)
+	app.Post("/trash/tasks/:id/restore", handler.RestoreTask)
+
+	tests := []struct {
+		name         string
+		method       string
+		route        string
+		expectedCode int
+	}{
+		{"list", http.MethodGet, "/trash", fiber.StatusOK},
+		{"category", http.MethodPost, "/trash/categories/" + categoryID.Hex() + "/restore", fiber.StatusNoContent},
+		{"not in the trash", http.MethodPost, "/trash/categories/" + taskID.Hex() + "/restore", fiber.StatusNotFound},
+		{"task of a deleted category", http.MethodPost, "/trash/tasks/" + taskID.Hex() + "/restore", fiber.StatusConflict},
+		{"invalid id", http.MethodPost, "/trash/tasks/nope/restore", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		res, err := app.Test(httptest.NewRequest(tt.method, tt.route, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != tt.expectedCode {
+			body, _ := io.ReadAll(res.Body)
+			t.Errorf("%s: expected %d, got %d: %s", tt.name, tt.expectedCode, res.StatusCode, body)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package trash
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrNotInTrash      = errors.New("not in the trash")
+	ErrCategoryInTrash = errors.New("the task's category is in the trash, restore it first")
+)
+
+/*
+Trash is what the caller deleted and can still restore, most recently
+deleted first. Tasks deleted along with their category come back with it and
+are only counted; tasks deleted on their own are listed, once their category
+is out of the trash.
+*/
+type Trash struct {
+	Categories []TrashedCategory `json:"categories"`
+	Tasks      []TrashedTask     `json:"tasks"`
+}
+
+type TrashedCategory struct {
+	ID        primitive.ObjectID `json:"id"`
+	Name      string             `json:"name"`
+	Tasks     int                `json:"tasks"`
+	DeletedAt time.Time          `json:"deleted_at"`
+	// the purge schedule removes it for good the first night after
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+type TrashedTask struct {
+	ID           primitive.ObjectID `json:"id"`
+	Content      string             `json:"content"`
+	CategoryID   primitive.ObjectID `json:"category_id"`
+	CategoryName string             `json:"category_name"`
+	DeletedAt    time.Time          `json:"deleted_at"`
+	PurgeAfter   time.Time          `json:"purge_after"`
+}
+
+// owned is the part of the user document the trash is read from
+type owned struct {
+	Categories []struct {
+		ID        primitive.ObjectID `bson:"_id"`
+		Name      string             `bson:"name"`
+		DeletedAt *time.Time         `bson:"deleted_at"`
+		Tasks     []struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			Content   string             `bson:"content"`
+			DeletedAt *time.Time         `bson:"deleted_at"`
+		} `bson:"tasks"`
+	} `bson:"categories"`
+}
+
+/*
+Trash Service to be used by Trash Handler to interact with the
+Database layer of the application
+*/
+type Service struct {
+	users      *mongo.Collection
+	tombstones *mongo.Collection
+	cache      xcache.Cache
+}
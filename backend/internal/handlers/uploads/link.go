@@ -0,0 +1,105 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// avatars are shown through their medium variant
+const avatarVariant = "medium"
+
+// avatarPath is the profile_picture of a user with upload as their avatar; it redirects to a signed URL when the backend has them
+func avatarPath(upload *Upload) string {
+	return "/api/v1/uploads/" + upload.ID.Hex() + "/variants/" + avatarVariant
+}
+
+/*
+link attaches a ready upload to what it was uploaded for: the user's profile
+picture, a category's cover or a task's attachments. A new avatar or cover
+replaces the previous one, whose upload is removed.
+*/
+func (s *Service) link(ctx context.Context, upload *Upload) error {
+	var err error
+	switch upload.Purpose {
+	case Avatar:
+		_, err = s.users.UpdateOne(ctx, bson.M{"_id": upload.User}, bson.M{"$set": bson.M{
+			"profile_picture": avatarPath(upload),
+		}})
+	case CategoryCover:
+		_, err = s.users.UpdateOne(ctx, bson.M{"_id": upload.User},
+			bson.M{"$set": bson.M{"categories.$[c].cover": upload.ID}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"c._id": upload.Target}}}))
+	case TaskAttachment:
+		_, err = s.users.UpdateOne(ctx, bson.M{"_id": upload.User},
+			bson.M{"$push": bson.M{"categories.$[].tasks.$[t].attachments": bson.M{
+				"upload_id":    upload.ID,
+				"name":         upload.Name,
+				"content_type": upload.ContentType,
+				"size":         upload.Size,
+			}}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"t._id": upload.Target}}}))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	s.removePrevious(ctx, upload)
+	return nil
+}
+
+// unlink undoes link for an upload about to be deleted
+func (s *Service) unlink(ctx context.Context, upload *Upload) error {
+	if upload.Status != Ready {
+		return nil
+	}
+	var err error
+	switch upload.Purpose {
+	case Avatar:
+		_, err = s.users.UpdateOne(ctx,
+			bson.M{"_id": upload.User, "profile_picture": avatarPath(upload)},
+			bson.M{"$set": bson.M{"profile_picture": ""}})
+	case CategoryCover:
+		_, err = s.users.UpdateOne(ctx, bson.M{"_id": upload.User},
+			bson.M{"$unset": bson.M{"categories.$[c].cover": ""}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"c.cover": upload.ID}}}))
+	case TaskAttachment:
+		_, err = s.users.UpdateOne(ctx, bson.M{"_id": upload.User},
+			bson.M{"$pull": bson.M{"categories.$[].tasks.$[t].attachments": bson.M{"upload_id": upload.ID}}},
+			options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"t._id": upload.Target}}}))
+	}
+	return err
+}
+
+// removePrevious deletes the uploads a new avatar or cover replaced; failures only leave orphaned files behind
+func (s *Service) removePrevious(ctx context.Context, upload *Upload) {
+	filter := bson.M{
+		"_id":     bson.M{"$ne": upload.ID},
+		"user":    upload.User,
+		"purpose": upload.Purpose,
+		"status":  Ready,
+	}
+	if upload.Target != nil {
+		filter["target"] = upload.Target
+	}
+	cursor, err := s.uploads.Find(ctx, filter)
+	if err == nil {
+		var previous []Upload
+		if err = cursor.All(ctx, &previous); err == nil {
+			for i := range previous {
+				if err = s.remove(ctx, &previous[i]); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		slog.LogAttrs(ctx, slog.LevelError, "Failed to remove replaced uploads",
+			slog.String("upload_id", upload.ID.Hex()), xslog.Error(err))
+	}
+}
@@ -0,0 +1,41 @@
+package uploads
+
+import (
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Router maps endpoints to handlers
+*/
+func Routes(app *fiber.App, collections map[string]*mongo.Collection, files xfiles.Backend, authenticate fiber.Handler, cfg config.Uploads) {
+	service := newService(collections, files, cfg)
+	handler := Handler{service}
+
+	apiV1 := app.Group("/api/v1")
+
+	Uploads := apiV1.Group("/uploads", authenticate)
+	Uploads.Post("/", handler.CreateUpload)
+	Uploads.Get("/:id", handler.GetUpload)
+	Uploads.Put("/:id/content", handler.PutContent)
+	Uploads.Post("/:id/complete", handler.CompleteUpload)
+	Uploads.Get("/:id/content", handler.GetContent)
+	Uploads.Get("/:id/variants/:variant", handler.GetVariant)
+	Uploads.Delete("/:id", handler.DeleteUpload)
+
+	apiV1.Post("/users/me/picture", authenticate, handler.UploadPicture)
+
+	xopenapi.Register(xopenapi.Operations{
+		"POST /api/v1/uploads/":                     {Summary: "Start an upload and get where to send the file", Auth: true, Request: CreateUploadRequest{}, Response: CreateUploadResponse{}, Status: fiber.StatusCreated},
+		"GET /api/v1/uploads/:id":                   {Summary: "An upload, with its URL once complete", Auth: true, Response: UploadView{}},
+		"PUT /api/v1/uploads/:id/content":           {Summary: "Send an upload's file, when the file backend can't take it directly", Auth: true, Request: []byte{}, RequestType: fiber.MIMEOctetStream, Response: Upload{}},
+		"POST /api/v1/uploads/:id/complete":         {Summary: "Finish an upload once its file is sent", Auth: true, Response: Upload{}},
+		"GET /api/v1/uploads/:id/content":           {Summary: "An upload's file, or a redirect to it", Auth: true, Response: []byte{}, ResponseType: fiber.MIMEOctetStream},
+		"GET /api/v1/uploads/:id/variants/:variant": {Summary: "A resized copy of an uploaded image", Auth: true, Response: []byte{}, ResponseType: "image/*"},
+		"DELETE /api/v1/uploads/:id":                {Summary: "Delete an upload", Auth: true, Status: fiber.StatusNoContent},
+		"POST /api/v1/users/me/picture":             {Summary: "Upload the caller's profile picture as the picture form field", Auth: true, Request: PictureForm{}, RequestType: fiber.MIMEMultipartForm, Response: UploadView{}, Status: fiber.StatusCreated},
+	})
+}
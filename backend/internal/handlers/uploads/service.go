@@ -0,0 +1,356 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"github.com/abhikaboy/SocialToDo/internal/ximage"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const Collection = "uploads"
+
+var (
+	ErrTargetNotFound = errors.New("upload target not found")
+	ErrNotPending     = errors.New("upload was already completed")
+	ErrNotImage       = errors.New("upload is not an image")
+)
+
+// newService receives the map of collections and picks out Uploads and Users
+func newService(collections map[string]*mongo.Collection, files xfiles.Backend, cfg config.Uploads) *Service {
+	return &Service{
+		uploads: collections[Collection],
+		users:   collections["users"],
+		files:   files,
+		scanner: newScanner(cfg.ScanURL),
+		cfg:     cfg,
+	}
+}
+
+// NewService builds the uploads service for callers outside this package (inbound email)
+func NewService(collections map[string]*mongo.Collection, files xfiles.Backend, cfg config.Uploads) *Service {
+	return newService(collections, files, cfg)
+}
+
+// Create records a pending upload and returns where the client should send the file
+func (s *Service) Create(ctx context.Context, userID primitive.ObjectID, req CreateUploadRequest) (*CreateUploadResponse, error) {
+	rules := purposes[req.Purpose]
+	if !slices.Contains(rules.Types, req.ContentType) {
+		return nil, &rejection{fmt.Sprintf("%s files can't be uploaded as %s", req.ContentType, req.Purpose)}
+	}
+	if req.Size > rules.MaxSize {
+		return nil, &rejection{fmt.Sprintf("Files for %s are at most %d bytes", req.Purpose, rules.MaxSize)}
+	}
+
+	upload := &Upload{
+		ID:          primitive.NewObjectID(),
+		User:        userID,
+		Purpose:     req.Purpose,
+		Name:        req.Name,
+		ContentType: req.ContentType,
+		Size:        req.Size,
+		Status:      Pending,
+		CreatedAt:   time.Now(),
+	}
+	if req.Target != "" {
+		target, _ := primitive.ObjectIDFromHex(req.Target)
+		if err := s.checkTarget(ctx, userID, req.Purpose, target); err != nil {
+			return nil, err
+		}
+		upload.Target = &target
+	}
+	upload.Key = fmt.Sprintf("uploads/%s/%s", userID.Hex(), upload.ID.Hex())
+
+	if _, err := s.uploads.InsertOne(ctx, upload); err != nil {
+		return nil, err
+	}
+
+	response := &CreateUploadResponse{
+		Upload:  upload,
+		Method:  "PUT",
+		Headers: map[string]string{"Content-Type": req.ContentType},
+	}
+	url, err := s.files.PresignPut(ctx, upload.Key, req.ContentType, s.cfg.PresignTTL)
+	if errors.Is(err, xfiles.ErrNoPresign) {
+		// the client sends the file through the API, which completes the upload itself
+		url, err = "/api/v1/uploads/"+upload.ID.Hex()+"/content", nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	response.UploadURL = url
+	return response, nil
+}
+
+// Get returns an upload its owner can see; avatars are visible to everyone
+func (s *Service) Get(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Upload, error) {
+	var upload Upload
+	err := s.uploads.FindOne(ctx, bson.M{
+		"_id": id,
+		"$or": bson.A{bson.M{"user": userID}, bson.M{"purpose": Avatar, "status": Ready}},
+	}).Decode(&upload)
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Store writes a file sent through the API and completes the upload
+func (s *Service) Store(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, body []byte) (*Upload, error) {
+	upload, err := s.pending(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > purposes[upload.Purpose].MaxSize {
+		return nil, &rejection{fmt.Sprintf("Files for %s are at most %d bytes", upload.Purpose, purposes[upload.Purpose].MaxSize)}
+	}
+	if err := s.files.Put(ctx, upload.Key, upload.ContentType, bytes.NewReader(body)); err != nil {
+		return nil, err
+	}
+	return s.complete(ctx, upload)
+}
+
+/*
+Attach stores a file the server received itself, like an emailed attachment,
+on one of the user's tasks. It goes through the same checks as a client
+upload, so an unaccepted file comes back as a rejection.
+*/
+func (s *Service) Attach(ctx context.Context, userID primitive.ObjectID, taskID primitive.ObjectID, name string, contentType string, body []byte) (*Upload, error) {
+	upload := &Upload{
+		ID:          primitive.NewObjectID(),
+		User:        userID,
+		Purpose:     TaskAttachment,
+		Target:      &taskID,
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(body)),
+		Status:      Pending,
+		CreatedAt:   time.Now(),
+	}
+	return s.receive(ctx, upload, body)
+}
+
+/*
+SetAvatar makes a picture sent straight to the API the user's profile
+picture. It is resized to the variant profiles show before anything is
+stored, so a picture that can't be decoded never replaces the current one.
+It returns the upload and the key of that variant.
+*/
+func (s *Service) SetAvatar(ctx context.Context, userID primitive.ObjectID, name string, contentType string, body []byte) (*Upload, string, error) {
+	variant, _ := ximage.Lookup(avatarVariant)
+	var resized bytes.Buffer
+	resizedType, err := ximage.Resize(bytes.NewReader(body), &resized, variant)
+	if err != nil {
+		return nil, "", &rejection{"Picture is not a JPEG, PNG or GIF image that can be decoded"}
+	}
+
+	upload, err := s.receive(ctx, &Upload{
+		ID:          primitive.NewObjectID(),
+		User:        userID,
+		Purpose:     Avatar,
+		Name:        name,
+		ContentType: contentType,
+		Size:        int64(len(body)),
+		Status:      Pending,
+		CreatedAt:   time.Now(),
+	}, body)
+	if err != nil {
+		return nil, "", err
+	}
+	key := upload.Key + "/" + variant.Name
+	if err := s.files.Put(ctx, key, resizedType, &resized); err != nil {
+		return nil, "", err
+	}
+	_, err = s.uploads.UpdateOne(ctx, bson.M{"_id": upload.ID}, bson.M{"$addToSet": bson.M{"variants": variant.Name}})
+	if err != nil {
+		return nil, "", err
+	}
+	upload.Variants = append(upload.Variants, variant.Name)
+	return upload, key, nil
+}
+
+// receive records a pending upload of a file the API was sent whole, stores it and completes it
+func (s *Service) receive(ctx context.Context, upload *Upload, body []byte) (*Upload, error) {
+	upload.Key = fmt.Sprintf("uploads/%s/%s", upload.User.Hex(), upload.ID.Hex())
+	if limit := purposes[upload.Purpose].MaxSize; upload.Size > limit {
+		return nil, &rejection{fmt.Sprintf("Files for %s are at most %d bytes", upload.Purpose, limit)}
+	}
+
+	if _, err := s.uploads.InsertOne(ctx, upload); err != nil {
+		return nil, err
+	}
+	if err := s.files.Put(ctx, upload.Key, upload.ContentType, bytes.NewReader(body)); err != nil {
+		return nil, err
+	}
+	return s.complete(ctx, upload)
+}
+
+// Complete verifies a file the client uploaded with a presigned URL
+func (s *Service) Complete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Upload, error) {
+	upload, err := s.pending(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.complete(ctx, upload)
+}
+
+func (s *Service) pending(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Upload, error) {
+	var upload Upload
+	if err := s.uploads.FindOne(ctx, bson.M{"_id": id, "user": userID}).Decode(&upload); err != nil {
+		return nil, err
+	}
+	if upload.Status != Pending {
+		return nil, ErrNotPending
+	}
+	return &upload, nil
+}
+
+/*
+complete checks the stored file and either marks the upload ready and links
+it to its target, or rejects it and deletes the file.
+*/
+func (s *Service) complete(ctx context.Context, upload *Upload) (*Upload, error) {
+	body, err := s.files.Open(ctx, upload.Key)
+	if errors.Is(err, xfiles.ErrNotFound) {
+		return nil, &rejection{"No file has been uploaded yet"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	contentType, size, err := s.verify(ctx, body, purposes[upload.Purpose])
+	body.Close()
+
+	var rejected *rejection
+	if errors.As(err, &rejected) {
+		if err := s.files.Delete(ctx, upload.Key); err != nil && !errors.Is(err, xfiles.ErrNotFound) {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to delete rejected upload", slog.String("key", upload.Key), xslog.Error(err))
+		}
+		_, updateErr := s.uploads.UpdateOne(ctx, bson.M{"_id": upload.ID}, bson.M{"$set": bson.M{
+			"status":        Rejected,
+			"reject_reason": rejected.reason,
+		}})
+		if updateErr != nil {
+			return nil, updateErr
+		}
+		slog.LogAttrs(ctx, slog.LevelWarn, "Upload rejected",
+			slog.String("upload_id", upload.ID.Hex()), slog.String("reason", rejected.reason))
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	upload.ContentType, upload.Size, upload.Status, upload.CompletedAt = contentType, size, Ready, &now
+	_, err = s.uploads.UpdateOne(ctx, bson.M{"_id": upload.ID}, bson.M{"$set": bson.M{
+		"content_type": contentType,
+		"size":         size,
+		"status":       Ready,
+		"completed_at": now,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.link(ctx, upload); err != nil {
+		return nil, err
+	}
+	return upload, nil
+}
+
+// Variant returns the key of an image variant, generating and storing it on first use
+func (s *Service) Variant(ctx context.Context, upload *Upload, variant ximage.Variant) (string, error) {
+	if !upload.isImage() {
+		return "", ErrNotImage
+	}
+	key := upload.Key + "/" + variant.Name
+	if slices.Contains(upload.Variants, variant.Name) {
+		return key, nil
+	}
+
+	original, err := s.files.Open(ctx, upload.Key)
+	if err != nil {
+		return "", err
+	}
+	defer original.Close()
+	var resized bytes.Buffer
+	contentType, err := ximage.Resize(original, &resized, variant)
+	if err != nil {
+		return "", err
+	}
+	if err := s.files.Put(ctx, key, contentType, &resized); err != nil {
+		return "", err
+	}
+	_, err = s.uploads.UpdateOne(ctx, bson.M{"_id": upload.ID}, bson.M{"$addToSet": bson.M{"variants": variant.Name}})
+	return key, err
+}
+
+// URL returns a direct download URL for key, or "" when the backend can't presign and the API has to serve it
+func (s *Service) URL(ctx context.Context, key string) (string, error) {
+	url, err := s.files.PresignGet(ctx, key, s.cfg.PresignTTL)
+	if errors.Is(err, xfiles.ErrNoPresign) {
+		return "", nil
+	}
+	return url, err
+}
+
+func (s *Service) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.files.Open(ctx, key)
+}
+
+// Delete unlinks an upload from whatever uses it and removes its files
+func (s *Service) Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error {
+	var upload Upload
+	if err := s.uploads.FindOne(ctx, bson.M{"_id": id, "user": userID}).Decode(&upload); err != nil {
+		return err
+	}
+	if err := s.unlink(ctx, &upload); err != nil {
+		return err
+	}
+	return s.remove(ctx, &upload)
+}
+
+func (s *Service) remove(ctx context.Context, upload *Upload) error {
+	keys := []string{upload.Key}
+	for _, variant := range upload.Variants {
+		keys = append(keys, upload.Key+"/"+variant)
+	}
+	for _, key := range keys {
+		if err := s.files.Delete(ctx, key); err != nil && !errors.Is(err, xfiles.ErrNotFound) {
+			return err
+		}
+	}
+	_, err := s.uploads.DeleteOne(ctx, bson.M{"_id": upload.ID})
+	return err
+}
+
+// checkTarget makes sure a cover or attachment goes on the uploader's own live category or task
+func (s *Service) checkTarget(ctx context.Context, userID primitive.ObjectID, purpose Purpose, target primitive.ObjectID) error {
+	filter := softdelete.Filter(bson.M{"_id": userID})
+	switch purpose {
+	case CategoryCover:
+		filter["categories"] = bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": target})}
+	case TaskAttachment:
+		filter["categories.tasks"] = bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": target})}
+	}
+	count, err := s.users.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrTargetNotFound
+	}
+	return nil
+}
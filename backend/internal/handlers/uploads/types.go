@@ -0,0 +1,100 @@
+package uploads
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Purpose string
+
+const (
+	Avatar         Purpose = "avatar"
+	CategoryCover  Purpose = "category_cover"
+	TaskAttachment Purpose = "task_attachment"
+)
+
+type Status string
+
+const (
+	Pending  Status = "pending"
+	Ready    Status = "ready"
+	Rejected Status = "rejected"
+)
+
+// rules for what each purpose accepts, checked against the declared and then the sniffed type
+type rules struct {
+	MaxSize int64
+	Types   []string
+}
+
+var imageTypes = []string{"image/jpeg", "image/png", "image/gif"}
+
+var purposes = map[Purpose]rules{
+	Avatar:         {MaxSize: 10 << 20, Types: imageTypes},
+	CategoryCover:  {MaxSize: 10 << 20, Types: imageTypes},
+	TaskAttachment: {MaxSize: 25 << 20, Types: append([]string{"application/pdf", "text/plain"}, imageTypes...)},
+}
+
+type Upload struct {
+	ID      primitive.ObjectID `bson:"_id" json:"id"`
+	User    primitive.ObjectID `bson:"user" json:"user"`
+	Purpose Purpose            `bson:"purpose" json:"purpose"`
+	// the category for a cover, the task for an attachment
+	Target       *primitive.ObjectID `bson:"target,omitempty" json:"target,omitempty"`
+	Key          string              `bson:"key" json:"-"`
+	Name         string              `bson:"name,omitempty" json:"name,omitempty"`
+	ContentType  string              `bson:"content_type" json:"content_type"`
+	Size         int64               `bson:"size" json:"size"`
+	Status       Status              `bson:"status" json:"status"`
+	RejectReason string              `bson:"reject_reason,omitempty" json:"reject_reason,omitempty"`
+	// image variants generated so far, by name
+	Variants    []string   `bson:"variants,omitempty" json:"variants,omitempty"`
+	CreatedAt   time.Time  `bson:"created_at" json:"created_at"`
+	CompletedAt *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+func (u *Upload) isImage() bool {
+	return u.ContentType == "image/jpeg" || u.ContentType == "image/png" || u.ContentType == "image/gif"
+}
+
+type CreateUploadRequest struct {
+	Purpose     Purpose `validate:"required,oneof=avatar category_cover task_attachment" json:"purpose"`
+	Target      string  `validate:"required_unless=Purpose avatar,omitempty,mongodb" json:"target"`
+	Name        string  `validate:"max=200" json:"name"`
+	ContentType string  `validate:"required,max=100" json:"content_type"`
+	Size        int64   `validate:"required,min=1" json:"size"`
+}
+
+// CreateUploadResponse tells the client where to PUT the file; Headers have to be sent with it
+type CreateUploadResponse struct {
+	Upload    *Upload           `json:"upload"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+}
+
+type UploadView struct {
+	*Upload
+	URL string `json:"url"`
+}
+
+// PictureForm documents the multipart form UploadPicture reads
+type PictureForm struct {
+	Picture []byte `validate:"required" json:"picture"`
+}
+
+/*
+Uploads Service to be used by Uploads Handler to interact with the
+Database layer of the application and the file backend
+*/
+type Service struct {
+	uploads *mongo.Collection
+	users   *mongo.Collection
+	files   xfiles.Backend
+	scanner Scanner
+	cfg     config.Uploads
+}
@@ -0,0 +1,258 @@
+package uploads
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"github.com/abhikaboy/SocialToDo/internal/ximage"
+	"github.com/abhikaboy/SocialToDo/internal/xvalidator"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var validator = xvalidator.Validator
+
+//go:generate mockgen -source=uploads.go -destination=mock_test.go -package=uploads
+
+// Uploads is the upload service as Handler uses it
+type Uploads interface {
+	Create(ctx context.Context, userID primitive.ObjectID, req CreateUploadRequest) (*CreateUploadResponse, error)
+	Get(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Upload, error)
+	Store(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, body []byte) (*Upload, error)
+	SetAvatar(ctx context.Context, userID primitive.ObjectID, name string, contentType string, body []byte) (*Upload, string, error)
+	Complete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) (*Upload, error)
+	Variant(ctx context.Context, upload *Upload, variant ximage.Variant) (string, error)
+	URL(ctx context.Context, key string) (string, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID) error
+}
+
+var _ Uploads = (*Service)(nil)
+
+/*
+Handler to execute business logic for file uploads
+*/
+type Handler struct {
+	service Uploads
+}
+
+// CreateUpload registers an upload and returns the URL to send the file to
+func (h *Handler) CreateUpload(c *fiber.Ctx) error {
+	var req CreateUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if errs := validator.Validate(req); len(errs) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(errs)
+	}
+
+	response, err := h.service.Create(c.UserContext(), userID(c), req)
+	if err != nil {
+		return h.error(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+func (h *Handler) GetUpload(c *fiber.Ctx) error {
+	upload, err := h.upload(c, false)
+	if err != nil {
+		return h.error(c, err)
+	}
+	view := UploadView{Upload: upload, URL: "/api/v1/uploads/" + upload.ID.Hex() + "/content"}
+	if upload.Status == Ready {
+		url, err := h.service.URL(c.UserContext(), upload.Key)
+		if err != nil {
+			return err
+		}
+		if url != "" {
+			view.URL = url
+		}
+	}
+	return c.JSON(view)
+}
+
+// PutContent takes the file itself, for backends without presigned uploads
+func (h *Handler) PutContent(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid upload id",
+		})
+	}
+	upload, err := h.service.Store(c.UserContext(), userID(c), id, c.Body())
+	if err != nil {
+		return h.error(c, err)
+	}
+	return c.JSON(upload)
+}
+
+// CompleteUpload verifies a file sent to a presigned URL
+func (h *Handler) CompleteUpload(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid upload id",
+		})
+	}
+	upload, err := h.service.Complete(c.UserContext(), userID(c), id)
+	if err != nil {
+		return h.error(c, err)
+	}
+	return c.JSON(upload)
+}
+
+func (h *Handler) GetContent(c *fiber.Ctx) error {
+	upload, err := h.upload(c, true)
+	if err != nil {
+		return h.error(c, err)
+	}
+	return h.send(c, upload.Key, upload.ContentType)
+}
+
+// GetVariant serves a resized copy of an image, made the first time it's asked for
+func (h *Handler) GetVariant(c *fiber.Ctx) error {
+	variant, ok := ximage.Lookup(c.Params("variant"))
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Unknown image variant",
+		})
+	}
+	upload, err := h.upload(c, true)
+	if err != nil {
+		return h.error(c, err)
+	}
+	key, err := h.service.Variant(c.UserContext(), upload, variant)
+	if err != nil {
+		return h.error(c, err)
+	}
+	contentType := "image/jpeg"
+	if upload.ContentType != "image/jpeg" {
+		contentType = "image/png"
+	}
+	return h.send(c, key, contentType)
+}
+
+/*
+UploadPicture takes a profile picture as the "picture" field of a multipart
+form and answers with the URL profiles show it through.
+*/
+func (h *Handler) UploadPicture(c *fiber.Ctx) error {
+	header, err := c.FormFile("picture")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Expected a multipart form with a picture file",
+		})
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	body, err := io.ReadAll(io.LimitReader(file, purposes[Avatar].MaxSize+1))
+	if err != nil {
+		return err
+	}
+
+	upload, key, err := h.service.SetAvatar(c.UserContext(), userID(c), header.Filename, header.Header.Get(fiber.HeaderContentType), body)
+	if err != nil {
+		return h.error(c, err)
+	}
+	view := UploadView{Upload: upload, URL: avatarPath(upload)}
+	url, err := h.service.URL(c.UserContext(), key)
+	if err != nil {
+		return err
+	}
+	if url != "" {
+		view.URL = url
+	}
+	return c.Status(fiber.StatusCreated).JSON(view)
+}
+
+func (h *Handler) DeleteUpload(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid upload id",
+		})
+	}
+	if err := h.service.Delete(c.UserContext(), userID(c), id); err != nil {
+		return h.error(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// upload loads the :id upload for reading; with ready set, pending and rejected uploads are not found
+func (h *Handler) upload(c *fiber.Ctx, ready bool) (*Upload, error) {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, mongo.ErrNoDocuments
+	}
+	upload, err := h.service.Get(c.UserContext(), userID(c), id)
+	if err != nil {
+		return nil, err
+	}
+	if ready && upload.Status != Ready {
+		return nil, xfiles.ErrNotFound
+	}
+	return upload, nil
+}
+
+// send redirects to a presigned URL when the backend has them and streams the file otherwise
+func (h *Handler) send(c *fiber.Ctx, key string, contentType string) error {
+	url, err := h.service.URL(c.UserContext(), key)
+	if err != nil {
+		return err
+	}
+	if url != "" {
+		return c.Redirect(url, fiber.StatusFound)
+	}
+	body, err := h.service.Open(c.UserContext(), key)
+	if err != nil {
+		return h.error(c, err)
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	// uploads never change under their key
+	c.Set(fiber.HeaderCacheControl, "private, max-age=86400, immutable")
+	return c.SendStream(body)
+}
+
+func (h *Handler) error(c *fiber.Ctx, err error) error {
+	var rejected *rejection
+	switch {
+	case errors.As(err, &rejected):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": rejected.reason,
+		})
+	case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, xfiles.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Upload not found",
+		})
+	case errors.Is(err, ErrTargetNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Category or task not found",
+		})
+	case errors.Is(err, ErrNotPending):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Upload was already completed",
+		})
+	case errors.Is(err, ErrNotImage):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Only images have variants",
+		})
+	case errors.Is(err, ximage.ErrUnsupported):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "Image could not be decoded",
+		})
+	}
+	return err
+}
+
+func userID(c *fiber.Ctx) primitive.ObjectID {
+	id, _ := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	return id
+}
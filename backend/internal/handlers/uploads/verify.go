@@ -0,0 +1,126 @@
+package uploads
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"time"
+
+	gojson "github.com/goccy/go-json"
+)
+
+// ErrInfected is returned by a Scanner for a file that must not be accepted
+var ErrInfected = errors.New("file failed the virus scan")
+
+// Scanner is the virus scan hook every upload passes through before it is accepted
+type Scanner interface {
+	Scan(ctx context.Context, body io.Reader) error
+}
+
+func newScanner(url string) Scanner {
+	if url == "" {
+		return noScanner{}
+	}
+	return &httpScanner{url: url, client: &http.Client{Timeout: time.Minute}}
+}
+
+type noScanner struct{}
+
+func (noScanner) Scan(_ context.Context, body io.Reader) error {
+	_, err := io.Copy(io.Discard, body)
+	return err
+}
+
+/*
+httpScanner posts the file to a scanning service (e.g. a ClamAV REST
+wrapper), which answers {"infected": bool, "signature": string}.
+*/
+type httpScanner struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpScanner) Scan(ctx context.Context, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("virus scanner responded %s", resp.Status)
+	}
+	var verdict struct {
+		Infected  bool   `json:"infected"`
+		Signature string `json:"signature"`
+	}
+	if err := gojson.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return err
+	}
+	if verdict.Infected {
+		return fmt.Errorf("%w: %s", ErrInfected, verdict.Signature)
+	}
+	return nil
+}
+
+// rejection is a file that was received but isn't acceptable; its message is shown to the client
+type rejection struct {
+	reason string
+}
+
+func (r *rejection) Error() string { return r.reason }
+
+/*
+verify reads a stored file once: it sniffs the real type from the first
+bytes, counts the size and streams everything through the scanner. It
+returns the sniffed type and size, or a *rejection.
+*/
+func (s *Service) verify(ctx context.Context, body io.Reader, rules rules) (string, int64, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", 0, err
+	}
+	head = head[:n]
+	if n == 0 {
+		return "", 0, &rejection{"File is empty"}
+	}
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if !slices.Contains(rules.Types, contentType) {
+		return "", 0, &rejection{fmt.Sprintf("File content is %s, which isn't accepted here", contentType)}
+	}
+
+	// one past the limit is enough to know the file is too big
+	counted := &countingReader{r: io.LimitReader(io.MultiReader(bytes.NewReader(head), body), rules.MaxSize+1)}
+	err = s.scanner.Scan(ctx, counted)
+	if errors.Is(err, ErrInfected) {
+		return "", 0, &rejection{"File failed the virus scan"}
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if counted.n > rules.MaxSize {
+		return "", 0, &rejection{fmt.Sprintf("File is larger than %d bytes", rules.MaxSize)}
+	}
+	return contentType, counted.n, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,26 @@
+package handles
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+/*
+Handles are stored lowercase with a leading "@" and are unique across users,
+deleted ones included (the users_handle_unique index). Users may type them
+with or without the "@" and in any case.
+*/
+
+var ErrInvalid = errors.New("handles are 3 to 30 letters, digits, underscores or dots")
+
+var charset = regexp.MustCompile(`^[a-z0-9_.]{3,30}$`)
+
+// Normalize returns raw as it is stored, or ErrInvalid
+func Normalize(raw string) (string, error) {
+	handle := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(raw), "@"))
+	if !charset.MatchString(handle) {
+		return "", ErrInvalid
+	}
+	return "@" + handle, nil
+}
@@ -0,0 +1,106 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	gojson "github.com/goccy/go-json"
+)
+
+/*
+Discord has no per-user link like the other integrations: a group admin
+creates an incoming webhook for a channel in Discord and pastes its URL,
+and messages are posted to it. No bot or OAuth app is needed.
+*/
+
+// MaxContent is the longest message Discord accepts
+const MaxContent = 2000
+
+var (
+	// ErrInvalidWebhook is a URL that isn't a Discord webhook
+	ErrInvalidWebhook = errors.New("discord: not a Discord webhook URL")
+	// ErrGone means the webhook was deleted or its token reset in Discord; it won't work again
+	ErrGone = errors.New("discord: webhook no longer exists")
+)
+
+var hosts = map[string]bool{
+	"discord.com":        true,
+	"discordapp.com":     true,
+	"ptb.discord.com":    true,
+	"canary.discord.com": true,
+}
+
+// ValidWebhook checks raw is https://discord.com/api/webhooks/<id>/<token>, so posts only ever go to Discord
+func ValidWebhook(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || !hosts[u.Host] || u.User != nil || u.RawQuery != "" {
+		return ErrInvalidWebhook
+	}
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/api/webhooks/"), "/")
+	if !strings.HasPrefix(u.Path, "/api/webhooks/") || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return ErrInvalidWebhook
+	}
+	return nil
+}
+
+type Client struct {
+	http *http.Client
+}
+
+func NewClient() *Client {
+	return &Client{http: &http.Client{
+		Timeout: 10 * time.Second,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+type message struct {
+	Content         string          `json:"content"`
+	AllowedMentions allowedMentions `json:"allowed_mentions"`
+}
+
+type allowedMentions struct {
+	Parse []string `json:"parse"`
+}
+
+/*
+Post sends content to the webhook, cut to MaxContent. Mentions are
+disabled, a display name like @everyone is posted as text and pings nobody.
+*/
+func (c *Client) Post(ctx context.Context, webhookURL string, content string) error {
+	if runes := []rune(content); len(runes) > MaxContent {
+		content = string(runes[:MaxContent-1]) + "…"
+	}
+	body, err := gojson.Marshal(message{Content: content, AllowedMentions: allowedMentions{Parse: []string{}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusUnauthorized:
+		return ErrGone
+	case resp.StatusCode >= 300:
+		// 429s included, the job queue's backoff outlasts Discord's webhook rate limit
+		return fmt.Errorf("discord responded %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	gojson "github.com/goccy/go-json"
+)
+
+func TestValidWebhook(t *testing.T) {
+	tests := []struct {
+		url   string
+		valid bool
+	}{
+		{"https://discord.com/api/webhooks/123/abc-DEF_ghi", true},
+		{"https://canary.discord.com/api/webhooks/123/abc", true},
+		{"http://discord.com/api/webhooks/123/abc", false},
+		{"https://discord.com.evil.example/api/webhooks/123/abc", false},
+		{"https://user@discord.com/api/webhooks/123/abc", false},
+		{"https://discord.com/api/webhooks/123", false},
+		{"https://discord.com/api/webhooks/123/abc/slack", false},
+		{"https://discord.com/api/webhooks/123/abc?wait=true", false},
+		{"https://discord.com/channels/123/456", false},
+		{"not a url", false},
+	}
+	for _, tt := range tests {
+		if err := ValidWebhook(tt.url); (err == nil) != tt.valid {
+			t.Errorf("%s: expected valid %v, got %v", tt.url, tt.valid, err)
+		}
+	}
+}
+
+func TestPost(t *testing.T) {
+	var got message
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = message{}
+		gojson.Unmarshal(body, &got)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	client := NewClient()
+
+	tests := []struct {
+		name   string
+		status int
+		err    error
+	}{
+		{"posted", http.StatusNoContent, nil},
+		{"deleted webhook", http.StatusNotFound, ErrGone},
+		{"reset token", http.StatusUnauthorized, ErrGone},
+		{"rate limited", http.StatusTooManyRequests, errors.New("discord responded 429 Too Many Requests")},
+	}
+	for _, tt := range tests {
+		status = tt.status
+		err := client.Post(context.Background(), server.URL, "@everyone done")
+		if (err == nil) != (tt.err == nil) || err != nil && err.Error() != tt.err.Error() {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+	if got.Content != "@everyone done" || got.AllowedMentions.Parse == nil || len(got.AllowedMentions.Parse) != 0 {
+		t.Errorf("expected the content with mentions disabled, got %+v", got)
+	}
+
+	client.Post(context.Background(), server.URL, strings.Repeat("é", MaxContent+10))
+	if runes := []rune(got.Content); len(runes) != MaxContent || runes[MaxContent-1] != '…' {
+		t.Errorf("expected the content cut to %d characters, got %d", MaxContent, len(runes))
+	}
+}
@@ -0,0 +1,266 @@
+package gcal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	gojson "github.com/goccy/go-json"
+)
+
+const (
+	authURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	tokenURL    = "https://oauth2.googleapis.com/token"
+	revokeURL   = "https://oauth2.googleapis.com/revoke"
+	calendarAPI = "https://www.googleapis.com/calendar/v3"
+	scope       = "https://www.googleapis.com/auth/calendar.events"
+)
+
+var (
+	// ErrNotFound is a 404 or 410 for an event or channel
+	ErrNotFound = errors.New("google calendar: not found")
+	// ErrSyncTokenExpired means the next pull has to start over with a full listing
+	ErrSyncTokenExpired = errors.New("google calendar: sync token expired")
+	// ErrRevoked means the user withdrew access; the integration can't continue
+	ErrRevoked = errors.New("google calendar: access revoked")
+)
+
+// Token is an OAuth token pair; AccessToken is refreshed with RefreshToken once it expires
+type Token struct {
+	AccessToken  string    `bson:"access_token"`
+	RefreshToken string    `bson:"refresh_token"`
+	Expiry       time.Time `bson:"expiry"`
+}
+
+/*
+Client talks to Google's OAuth and Calendar v3 REST APIs directly, the
+handful of calls the sync needs don't justify the generated SDK.
+*/
+type Client struct {
+	cfg  config.Google
+	http *http.Client
+}
+
+func NewClient(cfg config.Google) *Client {
+	return &Client{cfg: cfg, http: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// AuthURL is the consent page; offline access with forced consent so Google always returns a refresh token
+func (c *Client) AuthURL(state string) string {
+	return authURL + "?" + url.Values{
+		"client_id":     {c.cfg.ClientID},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {scope},
+		"access_type":   {"offline"},
+		"prompt":        {"consent"},
+		"state":         {state},
+	}.Encode()
+}
+
+func (c *Client) Exchange(ctx context.Context, code string) (*Token, error) {
+	return c.token(ctx, url.Values{
+		"code":         {code},
+		"redirect_uri": {c.cfg.RedirectURL},
+		"grant_type":   {"authorization_code"},
+	})
+}
+
+func (c *Client) Refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	token, err := c.token(ctx, url.Values{
+		"refresh_token": {refreshToken},
+		"grant_type":    {"refresh_token"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// refreshes don't return a new refresh token
+	token.RefreshToken = refreshToken
+	return token, nil
+}
+
+func (c *Client) token(ctx context.Context, form url.Values) (*Token, error) {
+	form.Set("client_id", c.cfg.ClientID)
+	form.Set("client_secret", c.cfg.ClientSecret)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := gojson.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Error == "invalid_grant" {
+		return nil, ErrRevoked
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("google token endpoint responded %s: %s", resp.Status, body.Error)
+	}
+	return &Token{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(body.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// Revoke withdraws the grant, best effort on disconnect
+func (c *Client) Revoke(ctx context.Context, token string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, revokeURL, strings.NewReader(url.Values{"token": {token}}.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type EventTime struct {
+	DateTime *time.Time `json:"dateTime,omitempty"`
+	Date     string     `json:"date,omitempty"`
+}
+
+// Time returns the instant, all-day events start at midnight UTC
+func (t EventTime) Time() (time.Time, bool) {
+	if t.DateTime != nil {
+		return *t.DateTime, true
+	}
+	day, err := time.Parse(time.DateOnly, t.Date)
+	return day, err == nil
+}
+
+type Event struct {
+	ID          string     `json:"id,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Summary     string     `json:"summary,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Start       *EventTime `json:"start,omitempty"`
+	End         *EventTime `json:"end,omitempty"`
+}
+
+type EventList struct {
+	Items         []Event `json:"items"`
+	NextPageToken string  `json:"nextPageToken"`
+	NextSyncToken string  `json:"nextSyncToken"`
+}
+
+type Channel struct {
+	ID         string `json:"id"`
+	ResourceID string `json:"resourceId,omitempty"`
+	Token      string `json:"token,omitempty"`
+	Type       string `json:"type,omitempty"`
+	Address    string `json:"address,omitempty"`
+	// milliseconds since the epoch, as Google sends it
+	Expiration string `json:"expiration,omitempty"`
+}
+
+// PutEvent creates or replaces the event with the given id
+func (c *Client) PutEvent(ctx context.Context, token string, calendarID string, event Event) error {
+	path := "/calendars/" + url.PathEscape(calendarID) + "/events/" + url.PathEscape(event.ID)
+	err := c.call(ctx, token, http.MethodPut, path, nil, event, nil)
+	if errors.Is(err, ErrNotFound) {
+		return c.call(ctx, token, http.MethodPost, "/calendars/"+url.PathEscape(calendarID)+"/events", nil, event, nil)
+	}
+	return err
+}
+
+func (c *Client) DeleteEvent(ctx context.Context, token string, calendarID string, eventID string) error {
+	return c.call(ctx, token, http.MethodDelete, "/calendars/"+url.PathEscape(calendarID)+"/events/"+url.PathEscape(eventID), nil, nil, nil)
+}
+
+// ListEvents returns a page of changes since syncToken, or of every event when it is empty
+func (c *Client) ListEvents(ctx context.Context, token string, calendarID string, syncToken string, pageToken string) (*EventList, error) {
+	query := url.Values{"showDeleted": {"true"}, "maxResults": {"250"}}
+	if syncToken != "" {
+		query.Set("syncToken", syncToken)
+	}
+	if pageToken != "" {
+		query.Set("pageToken", pageToken)
+	}
+	var list EventList
+	err := c.call(ctx, token, http.MethodGet, "/calendars/"+url.PathEscape(calendarID)+"/events", query, nil, &list)
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// Watch opens a notification channel that posts to address whenever the calendar changes
+func (c *Client) Watch(ctx context.Context, token string, calendarID string, channel Channel) (*Channel, error) {
+	channel.Type = "web_hook"
+	var opened Channel
+	if err := c.call(ctx, token, http.MethodPost, "/calendars/"+url.PathEscape(calendarID)+"/events/watch", nil, channel, &opened); err != nil {
+		return nil, err
+	}
+	return &opened, nil
+}
+
+func (c *Client) Stop(ctx context.Context, token string, channel Channel) error {
+	return c.call(ctx, token, http.MethodPost, "/channels/stop", nil, Channel{ID: channel.ID, ResourceID: channel.ResourceID}, nil)
+}
+
+func (c *Client) call(ctx context.Context, token string, method string, path string, query url.Values, in any, out any) error {
+	target := calendarAPI + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := gojson.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusGone && query.Has("syncToken"):
+		return ErrSyncTokenExpired
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return ErrNotFound
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrRevoked
+	case resp.StatusCode >= 300:
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("google calendar %s %s responded %s: %s", method, path, resp.Status, detail)
+	}
+	if out == nil {
+		return nil
+	}
+	return gojson.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,66 @@
+package gcal
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const SyncJob = "gcal.sync"
+
+type SyncPayload struct {
+	User primitive.ObjectID `bson:"user"`
+}
+
+// RegisterJobs adds the calendar sync job handler to the worker
+func RegisterJobs(worker *jobs.Worker, s *Syncer) {
+	worker.Handle(SyncJob, func(ctx context.Context, job *jobs.Job) error {
+		var payload SyncPayload
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		return s.Sync(ctx, payload.User)
+	})
+}
+
+/*
+RegisterSchedules renews notification channels before they lapse and polls
+every connected calendar now and then, which also covers deployments without
+a webhook address and notifications that never arrived.
+*/
+func RegisterSchedules(cron *scheduler.Scheduler, s *Syncer) {
+	cron.Register("gcal-channel-renewal", "15 * * * *", 10*time.Minute, s.RenewChannels)
+	cron.Register("gcal-poll", "*/30 * * * *", 10*time.Minute, func(ctx context.Context) error {
+		integrations, err := s.store.Connections(ctx, bson.M{})
+		if err != nil {
+			return err
+		}
+		for _, integration := range integrations {
+			if err := s.Enqueue(ctx, integration.User); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SyncOn queues a sync whenever a connected user's tasks change
+func SyncOn(bus *events.Bus, s *Syncer) {
+	bus.SubscribeAsync(func(ctx context.Context, event events.Event) {
+		userID, err := primitive.ObjectIDFromHex(event.UserID)
+		if err != nil {
+			return
+		}
+		if err := s.Enqueue(ctx, userID); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to queue calendar sync",
+				slog.String("user_id", event.UserID), xslog.Error(err))
+		}
+	}, events.TasksChanged)
+}
@@ -0,0 +1,149 @@
+package gcal
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Each connected user has one document in the integrations collection, keyed
+by user and provider, holding the OAuth tokens and the sync position in both
+directions.
+*/
+
+const (
+	Collection = "integrations"
+	Provider   = "google_calendar"
+)
+
+type Integration struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	User       primitive.ObjectID `bson:"user"`
+	Provider   string             `bson:"provider"`
+	CalendarID string             `bson:"calendar_id"`
+	Token      *Token             `bson:"token,omitempty"`
+	// OAuth state between starting the flow and the callback
+	State string `bson:"state,omitempty"`
+	// tasks changed after PushedAt haven't been sent to the calendar yet
+	PushedAt  *time.Time `bson:"pushed_at,omitempty"`
+	SyncToken string     `bson:"sync_token,omitempty"`
+	Channel   *Watch     `bson:"channel,omitempty"`
+	// set while a sync job is queued, so bursts of changes queue one job
+	SyncQueued   bool       `bson:"sync_queued"`
+	LastSyncedAt *time.Time `bson:"last_synced_at,omitempty"`
+	LastError    string     `bson:"last_error,omitempty"`
+	CreatedAt    time.Time  `bson:"created_at"`
+}
+
+func (i *Integration) Connected() bool {
+	return i.Token != nil
+}
+
+// Watch is an open notification channel; Token authenticates its webhook calls
+type Watch struct {
+	ID         string    `bson:"id"`
+	ResourceID string    `bson:"resource_id"`
+	Token      string    `bson:"token"`
+	Expiration time.Time `bson:"expiration"`
+}
+
+type Store struct {
+	integrations *mongo.Collection
+}
+
+func NewStore(integrations *mongo.Collection) *Store {
+	return &Store{integrations: integrations}
+}
+
+func (s *Store) Get(ctx context.Context, userID primitive.ObjectID) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOne(ctx, bson.M{"user": userID, "provider": Provider}).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// Begin stores the OAuth state for a new connection, keeping an existing connection until the callback replaces it
+func (s *Store) Begin(ctx context.Context, userID primitive.ObjectID, state string) error {
+	_, err := s.integrations.UpdateOne(ctx,
+		bson.M{"user": userID, "provider": Provider},
+		bson.M{
+			"$set": bson.M{"state": state},
+			"$setOnInsert": bson.M{
+				"_id":         primitive.NewObjectID(),
+				"calendar_id": "primary",
+				"sync_queued": false,
+				"created_at":  time.Now(),
+			},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// Connect saves the tokens for the flow started with state, starting the sync over
+func (s *Store) Connect(ctx context.Context, state string, token *Token) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOneAndUpdate(ctx,
+		bson.M{"provider": Provider, "state": state},
+		bson.M{
+			"$set":   bson.M{"token": token},
+			"$unset": bson.M{"state": "", "pushed_at": "", "sync_token": "", "last_error": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.integrations.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (s *Store) set(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	_, err := s.integrations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return err
+}
+
+// MarkQueued claims the right to queue a sync, false when one is already queued or the user isn't connected
+func (s *Store) MarkQueued(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	result, err := s.integrations.UpdateOne(ctx,
+		bson.M{"user": userID, "provider": Provider, "token": bson.M{"$ne": nil}, "sync_queued": false},
+		bson.M{"$set": bson.M{"sync_queued": true}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// ByChannel finds the integration a webhook call is for
+func (s *Store) ByChannel(ctx context.Context, channelID string) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOne(ctx, bson.M{"provider": Provider, "channel.id": channelID}).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// Connections returns every connected integration matching filter
+func (s *Store) Connections(ctx context.Context, filter bson.M) ([]Integration, error) {
+	filter["provider"] = Provider
+	filter["token"] = bson.M{"$ne": nil}
+	cursor, err := s.integrations.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Integration, 0)
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,343 @@
+package gcal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Two-way sync between a user's due-dated tasks and their Google calendar.
+Every task with a due date is an event whose id is derived from the task id,
+so no mapping has to be stored: pushing sends the tasks changed since the
+last push, pulling reads the events changed since the last sync token and
+moves the matching tasks' due dates. Completed, deleted and undated tasks
+have their events removed. Events deleted in the calendar leave the task
+alone, the next change to it puts the event back.
+*/
+
+const (
+	eventPrefix   = "task"
+	eventDuration = 30 * time.Minute
+	// channels are renewed when they have less than this left; Google caps them at about a week
+	renewBefore = 24 * time.Hour
+)
+
+// ErrDisabled is returned when no Google OAuth client is configured
+var ErrDisabled = errors.New("google calendar integration is not configured")
+
+type Syncer struct {
+	store  *Store
+	client *Client
+	users  *mongo.Collection
+	queue  *jobs.Queue
+	cfg    config.Google
+}
+
+func New(collections map[string]*mongo.Collection, cfg config.Google) *Syncer {
+	return &Syncer{
+		store:  NewStore(collections[Collection]),
+		client: NewClient(cfg),
+		users:  collections["users"],
+		queue:  jobs.New(collections[jobs.Collection]),
+		cfg:    cfg,
+	}
+}
+
+func (s *Syncer) Enabled() bool {
+	return s.cfg.ClientID != ""
+}
+
+func (s *Syncer) Store() *Store {
+	return s.store
+}
+
+func (s *Syncer) Client() *Client {
+	return s.client
+}
+
+// eventID is a valid Google event id: base32hex characters only, which hex digits and "task" are
+func eventID(task primitive.ObjectID) string {
+	return eventPrefix + task.Hex()
+}
+
+func taskID(event string) (primitive.ObjectID, bool) {
+	if !strings.HasPrefix(event, eventPrefix) {
+		return primitive.NilObjectID, false
+	}
+	id, err := primitive.ObjectIDFromHex(strings.TrimPrefix(event, eventPrefix))
+	return id, err == nil
+}
+
+// Enqueue queues a sync for the user unless one is already waiting
+func (s *Syncer) Enqueue(ctx context.Context, userID primitive.ObjectID) error {
+	queued, err := s.store.MarkQueued(ctx, userID)
+	if err != nil || !queued {
+		return err
+	}
+	_, err = s.queue.Enqueue(ctx, SyncJob, SyncPayload{User: userID})
+	return err
+}
+
+// Sync pushes local changes to the calendar, then pulls the calendar's changes
+func (s *Syncer) Sync(ctx context.Context, userID primitive.ObjectID) error {
+	integration, err := s.store.Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// changes from here on need another run
+	if err := s.store.set(ctx, integration.ID, bson.M{"sync_queued": false}); err != nil {
+		return err
+	}
+	if !integration.Connected() {
+		return nil
+	}
+
+	err = s.sync(ctx, integration)
+	if err != nil {
+		if err := s.store.set(ctx, integration.ID, bson.M{"last_error": err.Error()}); err != nil {
+			return err
+		}
+		return err
+	}
+	now := time.Now()
+	return s.store.set(ctx, integration.ID, bson.M{"last_synced_at": now, "last_error": ""})
+}
+
+func (s *Syncer) sync(ctx context.Context, integration *Integration) error {
+	token, err := s.accessToken(ctx, integration)
+	if err != nil {
+		return err
+	}
+	if err := s.push(ctx, integration, token); err != nil {
+		return fmt.Errorf("push: %w", err)
+	}
+	if err := s.pull(ctx, integration, token); err != nil {
+		return fmt.Errorf("pull: %w", err)
+	}
+	return nil
+}
+
+// accessToken refreshes the access token when it is about to expire; a revoked grant disconnects the integration
+func (s *Syncer) accessToken(ctx context.Context, integration *Integration) (string, error) {
+	if time.Until(integration.Token.Expiry) > time.Minute {
+		return integration.Token.AccessToken, nil
+	}
+	token, err := s.client.Refresh(ctx, integration.Token.RefreshToken)
+	if errors.Is(err, ErrRevoked) {
+		_, updateErr := s.store.integrations.UpdateOne(ctx, bson.M{"_id": integration.ID}, bson.M{
+			"$unset": bson.M{"token": "", "channel": ""},
+			"$set":   bson.M{"last_error": "Google Calendar access was revoked, connect again to resume syncing"},
+		})
+		if updateErr != nil {
+			return "", updateErr
+		}
+		return "", jobs.Permanent(err)
+	}
+	if err != nil {
+		return "", err
+	}
+	integration.Token = token
+	return token.AccessToken, s.store.set(ctx, integration.ID, bson.M{"token": token})
+}
+
+type syncedTask struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Content   string             `bson:"content"`
+	DueDate   *time.Time         `bson:"due_date"`
+	Completed bool               `bson:"completed"`
+	Deleted   bool               `bson:"deleted"`
+}
+
+func (s *Syncer) push(ctx context.Context, integration *Integration, token string) error {
+	started := time.Now()
+	match := bson.M{"categories.tasks.due_date": bson.M{"$ne": nil}}
+	if since := integration.PushedAt; since != nil {
+		// changed, deleted, or in a category deleted since the last push
+		match = bson.M{"$or": bson.A{
+			bson.M{"categories.tasks.updated_at": bson.M{"$gt": since}},
+			bson.M{"categories.tasks." + softdelete.Field: bson.M{"$gt": since}},
+			bson.M{"categories." + softdelete.Field: bson.M{"$gt": since}},
+		}}
+	}
+	cursor, err := s.users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": integration.User}}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$match", Value: match}},
+		{{Key: "$project", Value: bson.M{
+			"_id":       "$categories.tasks._id",
+			"content":   "$categories.tasks.content",
+			"due_date":  "$categories.tasks.due_date",
+			"completed": "$categories.tasks.completed",
+			"deleted": bson.M{"$or": bson.A{
+				bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$categories.tasks." + softdelete.Field, nil}}, nil}},
+				bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$categories." + softdelete.Field, nil}}, nil}},
+			}},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	var tasks []syncedTask
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		if task.DueDate != nil && !task.Completed && !task.Deleted {
+			due := task.DueDate.UTC()
+			end := due.Add(eventDuration)
+			err = s.client.PutEvent(ctx, token, integration.CalendarID, Event{
+				ID:          eventID(task.ID),
+				Summary:     task.Content,
+				Description: "Synced from SocialToDo",
+				Start:       &EventTime{DateTime: &due},
+				End:         &EventTime{DateTime: &end},
+			})
+		} else if integration.PushedAt != nil {
+			err = s.client.DeleteEvent(ctx, token, integration.CalendarID, eventID(task.ID))
+			if errors.Is(err, ErrNotFound) {
+				err = nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	integration.PushedAt = &started
+	return s.store.set(ctx, integration.ID, bson.M{"pushed_at": started})
+}
+
+func (s *Syncer) pull(ctx context.Context, integration *Integration, token string) error {
+	syncToken, pageToken := integration.SyncToken, ""
+	for {
+		list, err := s.client.ListEvents(ctx, token, integration.CalendarID, syncToken, pageToken)
+		if errors.Is(err, ErrSyncTokenExpired) {
+			syncToken, pageToken = "", ""
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, event := range list.Items {
+			if err := s.applyEvent(ctx, integration.User, event); err != nil {
+				return err
+			}
+		}
+		if list.NextPageToken == "" {
+			integration.SyncToken = list.NextSyncToken
+			return s.store.set(ctx, integration.ID, bson.M{"sync_token": list.NextSyncToken})
+		}
+		pageToken = list.NextPageToken
+	}
+}
+
+// applyEvent moves a task's due date to its event's start; unchanged dates are left alone so syncs settle
+func (s *Syncer) applyEvent(ctx context.Context, userID primitive.ObjectID, event Event) error {
+	id, ok := taskID(event.ID)
+	if !ok || event.Status == "cancelled" || event.Start == nil {
+		return nil
+	}
+	start, ok := event.Start.Time()
+	if !ok {
+		return nil
+	}
+	start = start.UTC().Truncate(time.Millisecond)
+	now := time.Now()
+	_, err := s.users.UpdateOne(ctx,
+		bson.M{"_id": userID, "categories.tasks": bson.M{"$elemMatch": softdelete.Filter(bson.M{
+			"_id":      id,
+			"due_date": bson.M{"$ne": start},
+		})}},
+		bson.M{
+			"$set": bson.M{
+				"categories.$[].tasks.$[t].due_date":   start,
+				"categories.$[].tasks.$[t].updated_at": now,
+			},
+			"$inc": bson.M{"categories.$[].tasks.$[t]." + xmongo.VersionField: 1},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"t._id": id}}}))
+	return err
+}
+
+// Watch opens a change notification channel for the integration, replacing any open one
+func (s *Syncer) Watch(ctx context.Context, integration *Integration) error {
+	if s.cfg.WebhookURL == "" {
+		return nil
+	}
+	token, err := s.accessToken(ctx, integration)
+	if err != nil {
+		return err
+	}
+	if integration.Channel != nil {
+		s.stop(ctx, token, integration.Channel)
+	}
+	opened, err := s.client.Watch(ctx, token, integration.CalendarID, Channel{
+		ID:      uuid.NewString(),
+		Token:   uuid.NewString(),
+		Address: s.cfg.WebhookURL,
+	})
+	if err != nil {
+		return err
+	}
+	watch := &Watch{ID: opened.ID, ResourceID: opened.ResourceID, Token: opened.Token}
+	if ms, err := strconv.ParseInt(opened.Expiration, 10, 64); err == nil {
+		watch.Expiration = time.UnixMilli(ms)
+	}
+	integration.Channel = watch
+	return s.store.set(ctx, integration.ID, bson.M{"channel": watch})
+}
+
+// RenewChannels reopens the channels that are about to expire
+func (s *Syncer) RenewChannels(ctx context.Context) error {
+	if s.cfg.WebhookURL == "" {
+		return nil
+	}
+	integrations, err := s.store.Connections(ctx, bson.M{"$or": bson.A{
+		bson.M{"channel": nil},
+		bson.M{"channel.expiration": bson.M{"$lt": time.Now().Add(renewBefore)}},
+	}})
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range integrations {
+		if err := s.Watch(ctx, &integrations[i]); err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", integrations[i].User.Hex(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Disconnect stops notifications, revokes access and forgets the integration; events already in the calendar stay
+func (s *Syncer) Disconnect(ctx context.Context, integration *Integration) error {
+	if integration.Connected() {
+		if integration.Channel != nil {
+			s.stop(ctx, integration.Token.AccessToken, integration.Channel)
+		}
+		s.client.Revoke(ctx, integration.Token.RefreshToken)
+	}
+	return s.store.Delete(ctx, integration.ID)
+}
+
+// stop closes a channel, best effort: an unstopped channel only sends notifications that are ignored
+func (s *Syncer) stop(ctx context.Context, token string, watch *Watch) {
+	s.client.Stop(ctx, token, Channel{ID: watch.ID, ResourceID: watch.ResourceID})
+}
@@ -0,0 +1,277 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	gojson "github.com/goccy/go-json"
+)
+
+const (
+	authURL  = "https://github.com/login/oauth/authorize"
+	tokenURL = "https://github.com/login/oauth/access_token"
+	api      = "https://api.github.com"
+	// reading and closing issues, and adding webhooks, in private repos too
+	scopes = "repo admin:repo_hook"
+
+	perPage = 100
+	// assigned issues read per repo in one sync, newest updates first
+	maxPages = 10
+)
+
+var (
+	// ErrRevoked means the user revoked the app or its token; the link can't continue
+	ErrRevoked  = errors.New("github: access revoked")
+	ErrNotFound = errors.New("github: not found")
+	// ErrBadSignature is a webhook delivery that wasn't signed with the webhook secret
+	ErrBadSignature = errors.New("github: invalid webhook signature")
+)
+
+/*
+Client talks to GitHub's REST API directly, the integration only needs the
+OAuth exchange and a handful of issue and webhook calls.
+*/
+type Client struct {
+	cfg  config.GitHub
+	http *http.Client
+}
+
+func NewClient(cfg config.GitHub) *Client {
+	return &Client{cfg: cfg, http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// AuthURL is the authorization page the user is sent to
+func (c *Client) AuthURL(state string) string {
+	return authURL + "?" + url.Values{
+		"client_id":    {c.cfg.ClientID},
+		"scope":        {scopes},
+		"redirect_uri": {c.cfg.RedirectURL},
+		"state":        {state},
+	}.Encode()
+}
+
+// Exchange trades the callback's code for an access token; OAuth app tokens don't expire
+func (c *Client) Exchange(ctx context.Context, code string) (string, error) {
+	form := url.Values{
+		"client_id":     {c.cfg.ClientID},
+		"client_secret": {c.cfg.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := gojson.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("github token exchange: %s", body.Error)
+	}
+	return body.AccessToken, nil
+}
+
+// Login is the GitHub username the token acts as
+func (c *Client) Login(ctx context.Context, token string) (string, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := c.call(ctx, token, http.MethodGet, "/user", nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+// Repo checks that the token can see repo ("owner/name") and returns its canonical name
+func (c *Client) Repo(ctx context.Context, token string, repo string) (string, error) {
+	var body struct {
+		FullName string `json:"full_name"`
+	}
+	if err := c.call(ctx, token, http.MethodGet, "/repos/"+repo, nil, &body); err != nil {
+		return "", err
+	}
+	return body.FullName, nil
+}
+
+type Issue struct {
+	Number    int       `json:"number"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	State     string    `json:"state"`
+	HTMLURL   string    `json:"html_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// set when the "issue" is a pull request, which the issues API lists too
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+func (i *Issue) Closed() bool {
+	return i.State == "closed"
+}
+
+// AssignedIssues lists login's issues in repo, open and closed, updated after since when set
+func (c *Client) AssignedIssues(ctx context.Context, token string, repo string, login string, since *time.Time) ([]Issue, error) {
+	query := url.Values{
+		"assignee":  {login},
+		"state":     {"all"},
+		"sort":      {"updated"},
+		"direction": {"desc"},
+		"per_page":  {strconv.Itoa(perPage)},
+	}
+	if since != nil {
+		query.Set("since", since.UTC().Format(time.RFC3339))
+	}
+	issues := make([]Issue, 0)
+	for page := 1; page <= maxPages; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var list []Issue
+		if err := c.call(ctx, token, http.MethodGet, "/repos/"+repo+"/issues?"+query.Encode(), nil, &list); err != nil {
+			return nil, err
+		}
+		for _, issue := range list {
+			if issue.PullRequest == nil {
+				issues = append(issues, issue)
+			}
+		}
+		if len(list) < perPage {
+			break
+		}
+	}
+	return issues, nil
+}
+
+func (c *Client) Issue(ctx context.Context, token string, repo string, number int) (*Issue, error) {
+	var issue Issue
+	if err := c.call(ctx, token, http.MethodGet, fmt.Sprintf("/repos/%s/issues/%d", repo, number), nil, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+func (c *Client) Comment(ctx context.Context, token string, repo string, number int, body string) error {
+	return c.call(ctx, token, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number),
+		map[string]string{"body": body}, nil)
+}
+
+func (c *Client) Close(ctx context.Context, token string, repo string, number int) error {
+	return c.call(ctx, token, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/%d", repo, number),
+		map[string]string{"state": "closed", "state_reason": "completed"}, nil)
+}
+
+// CreateHook adds a webhook for issue events to repo, which takes admin rights on it
+func (c *Client) CreateHook(ctx context.Context, token string, repo string) (int64, error) {
+	var hook struct {
+		ID int64 `json:"id"`
+	}
+	err := c.call(ctx, token, http.MethodPost, "/repos/"+repo+"/hooks", map[string]any{
+		"name":   "web",
+		"active": true,
+		"events": []string{"issues"},
+		"config": map[string]string{
+			"url":          c.cfg.WebhookURL,
+			"content_type": "json",
+			"secret":       c.cfg.WebhookSecret,
+		},
+	}, &hook)
+	return hook.ID, err
+}
+
+// DeleteHook removes a webhook, best effort: deliveries for unlinked repos are ignored
+func (c *Client) DeleteHook(ctx context.Context, token string, repo string, id int64) error {
+	return c.call(ctx, token, http.MethodDelete, fmt.Sprintf("/repos/%s/hooks/%d", repo, id), nil, nil)
+}
+
+// Revoke deletes the app's grant for the token, best effort
+func (c *Client) Revoke(ctx context.Context, token string) error {
+	body, err := gojson.Marshal(map[string]string{"access_token": token})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, api+"/applications/"+c.cfg.ClientID+"/grant", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.cfg.ClientID, c.cfg.ClientSecret)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (c *Client) call(ctx context.Context, token string, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := gojson.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, api+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrRevoked
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("github %s %s responded %s", method, path, resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return gojson.NewDecoder(resp.Body).Decode(out)
+}
+
+// Verify checks a webhook delivery's X-Hub-Signature-256, an HMAC of the raw body under the webhook secret
+func Verify(secret string, signature string, body []byte) error {
+	if secret == "" {
+		return ErrBadSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrBadSignature
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	SyncJob  = "github.sync"
+	CloseJob = "github.close"
+)
+
+type SyncPayload struct {
+	User primitive.ObjectID `bson:"user"`
+}
+
+type ClosePayload struct {
+	User primitive.ObjectID `bson:"user"`
+	Task primitive.ObjectID `bson:"task"`
+}
+
+// RegisterJobs adds the issue sync and close job handlers to the worker
+func RegisterJobs(worker *jobs.Worker, s *Syncer) {
+	worker.Handle(SyncJob, func(ctx context.Context, job *jobs.Job) error {
+		var payload SyncPayload
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		return s.Sync(ctx, payload.User)
+	})
+	worker.Handle(CloseJob, func(ctx context.Context, job *jobs.Job) error {
+		var payload ClosePayload
+		if err := job.Decode(&payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		return s.CloseIssue(ctx, payload.User, payload.Task)
+	})
+}
+
+// RegisterSchedules polls every connected user now and then, which covers repos without a webhook
+func RegisterSchedules(cron *scheduler.Scheduler, s *Syncer) {
+	cron.Register("github-poll", "*/30 * * * *", 10*time.Minute, func(ctx context.Context) error {
+		integrations, err := s.store.Connections(ctx, bson.M{"links.0": bson.M{"$exists": true}})
+		if err != nil {
+			return err
+		}
+		for _, integration := range integrations {
+			if err := s.Enqueue(ctx, integration.User); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CloseOn queues closing the issue behind a task when a connected user completes it
+func CloseOn(bus *events.Bus, s *Syncer) {
+	bus.SubscribeAsync(func(ctx context.Context, event events.Event) {
+		userID, err := primitive.ObjectIDFromHex(event.UserID)
+		if err != nil {
+			return
+		}
+		taskID, err := primitive.ObjectIDFromHex(event.DocumentID)
+		if err != nil {
+			return
+		}
+		if err := s.enqueueClose(ctx, userID, taskID); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to queue GitHub issue close",
+				slog.String("user_id", event.UserID), xslog.Error(err))
+		}
+	}, events.TaskCompleted)
+}
+
+// enqueueClose only queues for tasks made from issues, most completions have nothing to close
+func (s *Syncer) enqueueClose(ctx context.Context, userID primitive.ObjectID, taskID primitive.ObjectID) error {
+	t, err := s.tasks.GetTaskByID(ctx, taskID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil || !strings.HasPrefix(t.Source, sourcePrefix) {
+		return err
+	}
+	_, err = s.queue.Enqueue(ctx, CloseJob, ClosePayload{User: userID, Task: taskID})
+	return err
+}
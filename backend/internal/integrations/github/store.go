@@ -0,0 +1,161 @@
+package github
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+A user's GitHub connection is one document in the integrations collection,
+next to their other integrations, holding the token and the repos linked to
+their categories.
+*/
+
+const (
+	Collection = "integrations"
+	Provider   = "github"
+)
+
+type Integration struct {
+	ID       primitive.ObjectID `bson:"_id"`
+	User     primitive.ObjectID `bson:"user"`
+	Provider string             `bson:"provider"`
+	Token    string             `bson:"token,omitempty"`
+	// the GitHub user whose assigned issues are synced
+	Login string `bson:"login,omitempty"`
+	// OAuth state between starting the flow and the callback
+	State string `bson:"state,omitempty"`
+	Links []Link `bson:"links"`
+	// set while a sync job is queued, so bursts of webhooks queue one job
+	SyncQueued   bool       `bson:"sync_queued"`
+	LastSyncedAt *time.Time `bson:"last_synced_at,omitempty"`
+	LastError    string     `bson:"last_error,omitempty"`
+	CreatedAt    time.Time  `bson:"created_at"`
+}
+
+func (i *Integration) Connected() bool {
+	return i.Token != ""
+}
+
+// Link puts the issues assigned to the user in Repo into Category
+type Link struct {
+	Repo     string             `bson:"repo" json:"repo"`
+	Category primitive.ObjectID `bson:"category" json:"category"`
+	// the repo webhook, zero when there is none and the poll picks changes up
+	HookID int64 `bson:"hook_id,omitempty" json:"webhook"`
+	// issues updated before this have been synced
+	SyncedAt *time.Time `bson:"synced_at,omitempty" json:"synced_at,omitempty"`
+}
+
+type Store struct {
+	integrations *mongo.Collection
+}
+
+func NewStore(integrations *mongo.Collection) *Store {
+	return &Store{integrations: integrations}
+}
+
+func (s *Store) Get(ctx context.Context, userID primitive.ObjectID) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOne(ctx, bson.M{"user": userID, "provider": Provider}).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// Begin stores the OAuth state for a new connection, keeping an existing connection until the callback replaces it
+func (s *Store) Begin(ctx context.Context, userID primitive.ObjectID, state string) error {
+	_, err := s.integrations.UpdateOne(ctx,
+		bson.M{"user": userID, "provider": Provider},
+		bson.M{
+			"$set": bson.M{"state": state},
+			"$setOnInsert": bson.M{
+				"_id":         primitive.NewObjectID(),
+				"links":       []Link{},
+				"sync_queued": false,
+				"created_at":  time.Now(),
+			},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+// Connect saves the token for the flow started with state; links made before a reconnect stay
+func (s *Store) Connect(ctx context.Context, state string, token string, login string) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOneAndUpdate(ctx,
+		bson.M{"provider": Provider, "state": state},
+		bson.M{
+			"$set":   bson.M{"token": token, "login": login},
+			"$unset": bson.M{"state": "", "last_error": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.integrations.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (s *Store) set(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	_, err := s.integrations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return err
+}
+
+// AddLink links a repo, replacing the repo's previous link if there was one
+func (s *Store) AddLink(ctx context.Context, id primitive.ObjectID, link Link) error {
+	_, err := s.integrations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"links": bson.M{"repo": link.Repo}}})
+	if err != nil {
+		return err
+	}
+	_, err = s.integrations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$push": bson.M{"links": link}})
+	return err
+}
+
+func (s *Store) RemoveLink(ctx context.Context, id primitive.ObjectID, repo string) error {
+	_, err := s.integrations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{"links": bson.M{"repo": repo}}})
+	return err
+}
+
+func (s *Store) linkSynced(ctx context.Context, id primitive.ObjectID, repo string, at time.Time) error {
+	_, err := s.integrations.UpdateOne(ctx,
+		bson.M{"_id": id, "links.repo": repo},
+		bson.M{"$set": bson.M{"links.$.synced_at": at}})
+	return err
+}
+
+// MarkQueued claims the right to queue a sync, false when one is already queued or the user isn't connected
+func (s *Store) MarkQueued(ctx context.Context, userID primitive.ObjectID) (bool, error) {
+	result, err := s.integrations.UpdateOne(ctx,
+		bson.M{"user": userID, "provider": Provider, "token": bson.M{"$exists": true}, "sync_queued": false},
+		bson.M{"$set": bson.M{"sync_queued": true}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// Connections returns every connected integration matching filter
+func (s *Store) Connections(ctx context.Context, filter bson.M) ([]Integration, error) {
+	filter["provider"] = Provider
+	filter["token"] = bson.M{"$exists": true}
+	cursor, err := s.integrations.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Integration, 0)
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,396 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/jobs"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Sync between the issues assigned to a user in their linked repos and the
+tasks in the linked categories. Each synced task carries its issue in its
+source, "github:owner/repo#12", so no mapping has to be stored. Syncing
+creates tasks for new open issues, renames tasks whose issue was retitled
+and completes tasks whose issue was closed; completing such a task in the
+app comments on and closes the issue. A task deleted in the app is not
+brought back, and reopening an issue leaves its completed task alone.
+*/
+
+const (
+	sourcePrefix = "github:"
+	// tasks made from issues get the middle of the 1-10 value scale
+	defaultValue = 5
+	closeComment = "Completed in SocialToDo."
+)
+
+var (
+	// ErrDisabled is returned when no GitHub OAuth app is configured
+	ErrDisabled   = errors.New("github integration is not configured")
+	ErrNoCategory = errors.New("category not found")
+)
+
+type Syncer struct {
+	store  *Store
+	client *Client
+	users  *mongo.Collection
+	tasks  *task.Service
+	queue  *jobs.Queue
+	cache  xcache.Cache
+	cfg    config.GitHub
+}
+
+func New(collections map[string]*mongo.Collection, cache xcache.Cache, cfg config.GitHub) *Syncer {
+	return &Syncer{
+		store:  NewStore(collections[Collection]),
+		client: NewClient(cfg),
+		users:  collections["users"],
+		tasks:  task.NewService(collections, cache),
+		queue:  jobs.New(collections[jobs.Collection]),
+		cache:  cache,
+		cfg:    cfg,
+	}
+}
+
+func (s *Syncer) Enabled() bool {
+	return s.cfg.ClientID != ""
+}
+
+func (s *Syncer) WebhookSecret() string {
+	return s.cfg.WebhookSecret
+}
+
+func (s *Syncer) Store() *Store {
+	return s.store
+}
+
+func (s *Syncer) Client() *Client {
+	return s.client
+}
+
+func source(repo string, number int) string {
+	return sourcePrefix + repo + "#" + strconv.Itoa(number)
+}
+
+func parseSource(source string) (repo string, number int, ok bool) {
+	rest, ok := strings.CutPrefix(source, sourcePrefix)
+	if !ok {
+		return "", 0, false
+	}
+	i := strings.LastIndexByte(rest, '#')
+	if i < 0 {
+		return "", 0, false
+	}
+	number, err := strconv.Atoi(rest[i+1:])
+	return rest[:i], number, err == nil
+}
+
+// Enqueue queues a sync for the user unless one is already waiting
+func (s *Syncer) Enqueue(ctx context.Context, userID primitive.ObjectID) error {
+	queued, err := s.store.MarkQueued(ctx, userID)
+	if err != nil || !queued {
+		return err
+	}
+	_, err = s.queue.Enqueue(ctx, SyncJob, SyncPayload{User: userID})
+	return err
+}
+
+// Sync brings every linked category up to date with the repo's issues
+func (s *Syncer) Sync(ctx context.Context, userID primitive.ObjectID) error {
+	integration, err := s.store.Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// changes from here on need another run
+	if err := s.store.set(ctx, integration.ID, bson.M{"sync_queued": false}); err != nil {
+		return err
+	}
+	if !integration.Connected() {
+		return nil
+	}
+
+	var errs []error
+	for _, link := range integration.Links {
+		if err := s.syncLink(ctx, integration, link); err != nil {
+			if errors.Is(err, ErrRevoked) {
+				return s.revoked(ctx, integration, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", link.Repo, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		if err := s.store.set(ctx, integration.ID, bson.M{"last_error": err.Error()}); err != nil {
+			return err
+		}
+		return err
+	}
+	return s.store.set(ctx, integration.ID, bson.M{"last_synced_at": time.Now(), "last_error": ""})
+}
+
+// revoked disconnects an integration whose token stopped working, keeping its links for a reconnect
+func (s *Syncer) revoked(ctx context.Context, integration *Integration, err error) error {
+	_, updateErr := s.store.integrations.UpdateOne(ctx, bson.M{"_id": integration.ID}, bson.M{
+		"$unset": bson.M{"token": ""},
+		"$set":   bson.M{"last_error": "GitHub access was revoked, connect again to resume syncing"},
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+	return jobs.Permanent(err)
+}
+
+type syncedTask struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	Content   string             `bson:"content"`
+	Source    string             `bson:"source"`
+	Completed bool               `bson:"completed"`
+	Deleted   bool               `bson:"deleted"`
+}
+
+func (s *Syncer) syncLink(ctx context.Context, integration *Integration, link Link) error {
+	live, err := s.users.CountDocuments(ctx, bson.M{
+		"_id":        integration.User,
+		"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": link.Category})},
+	})
+	if err != nil {
+		return err
+	}
+	if live == 0 {
+		return ErrNoCategory
+	}
+
+	started := time.Now()
+	issues, err := s.client.AssignedIssues(ctx, integration.Token, link.Repo, integration.Login, link.SyncedAt)
+	if err != nil {
+		return err
+	}
+	existing, err := s.syncedTasks(ctx, integration.User, link.Repo)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, issue := range issues {
+		t, found := existing[source(link.Repo, issue.Number)]
+		switch {
+		case !found && !issue.Closed():
+			now := time.Now()
+			doc := task.TaskDocument{
+				ID:        primitive.NewObjectID(),
+				Priority:  1,
+				Content:   issue.Title,
+				Notes:     issue.HTMLURL,
+				Value:     defaultValue,
+				Active:    true,
+				Source:    source(link.Repo, issue.Number),
+				Timestamp: now,
+				UpdatedAt: now,
+			}
+			if _, err := s.tasks.CreateTask(ctx, integration.User, link.Category, &doc); err != nil {
+				return err
+			}
+		case !found, t.Deleted:
+		case issue.Closed() && !t.Completed:
+			err := s.tasks.CompleteTask(ctx, integration.User, t.ID)
+			if err != nil && !errors.Is(err, task.ErrAlreadyCompleted) {
+				return err
+			}
+		case t.Content != issue.Title:
+			if err := s.rename(ctx, integration.User, t.ID, issue.Title); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+	if changed {
+		xcache.Invalidate(ctx, s.cache, xcache.CategoriesTag(integration.User.Hex()))
+	}
+	return s.store.linkSynced(ctx, integration.ID, link.Repo, started)
+}
+
+// syncedTasks returns the user's tasks made from repo's issues by source, deleted ones included so they stay deleted
+func (s *Syncer) syncedTasks(ctx context.Context, userID primitive.ObjectID, repo string) (map[string]syncedTask, error) {
+	cursor, err := s.users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": userID}}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$match", Value: bson.M{"categories.tasks.source": bson.M{
+			"$regex": "^" + regexp.QuoteMeta(sourcePrefix+repo+"#"),
+		}}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":       "$categories.tasks._id",
+			"content":   "$categories.tasks.content",
+			"source":    "$categories.tasks.source",
+			"completed": "$categories.tasks.completed",
+			"deleted": bson.M{"$or": bson.A{
+				bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$categories.tasks." + softdelete.Field, nil}}, nil}},
+				bson.M{"$ne": bson.A{bson.M{"$ifNull": bson.A{"$categories." + softdelete.Field, nil}}, nil}},
+			}},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var tasks []syncedTask
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+	bySource := make(map[string]syncedTask, len(tasks))
+	for _, t := range tasks {
+		bySource[t.Source] = t
+	}
+	return bySource, nil
+}
+
+func (s *Syncer) rename(ctx context.Context, userID primitive.ObjectID, id primitive.ObjectID, content string) error {
+	_, err := s.users.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{
+			"$set": bson.M{
+				"categories.$[].tasks.$[t].content":    content,
+				"categories.$[].tasks.$[t].updated_at": time.Now(),
+			},
+			"$inc": bson.M{"categories.$[].tasks.$[t]." + xmongo.VersionField: 1},
+		},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"t._id": id}}}))
+	return err
+}
+
+/*
+Link puts repo's issues assigned to the user into category and queues the
+first sync. Without admin rights on the repo no webhook can be added, and
+the poll picks its changes up instead.
+*/
+func (s *Syncer) Link(ctx context.Context, integration *Integration, repo string, category primitive.ObjectID) (*Link, error) {
+	live, err := s.users.CountDocuments(ctx, bson.M{
+		"_id":        integration.User,
+		"categories": bson.M{"$elemMatch": softdelete.Filter(bson.M{"_id": category})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if live == 0 {
+		return nil, ErrNoCategory
+	}
+	name, err := s.client.Repo(ctx, integration.Token, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	link := Link{Repo: name, Category: category}
+	for _, old := range integration.Links {
+		if strings.EqualFold(old.Repo, name) {
+			link.HookID = old.HookID
+		}
+	}
+	if link.HookID == 0 && s.cfg.WebhookURL != "" {
+		link.HookID, err = s.client.CreateHook(ctx, integration.Token, name)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelWarn, "Failed to add GitHub webhook", slog.String("repo", name), xslog.Error(err))
+		}
+	}
+	if err := s.store.AddLink(ctx, integration.ID, link); err != nil {
+		return nil, err
+	}
+	return &link, s.Enqueue(ctx, integration.User)
+}
+
+// Unlink stops syncing repo; its tasks stay where they are
+func (s *Syncer) Unlink(ctx context.Context, integration *Integration, repo string) error {
+	for _, link := range integration.Links {
+		if !strings.EqualFold(link.Repo, repo) {
+			continue
+		}
+		if link.HookID != 0 {
+			s.client.DeleteHook(ctx, integration.Token, link.Repo, link.HookID)
+		}
+		return s.store.RemoveLink(ctx, integration.ID, link.Repo)
+	}
+	return ErrNotFound
+}
+
+// Disconnect removes the webhooks, revokes access and forgets the integration; synced tasks stay
+func (s *Syncer) Disconnect(ctx context.Context, integration *Integration) error {
+	if integration.Connected() {
+		for _, link := range integration.Links {
+			if link.HookID != 0 {
+				s.client.DeleteHook(ctx, integration.Token, link.Repo, link.HookID)
+			}
+		}
+		s.client.Revoke(ctx, integration.Token)
+	}
+	return s.store.Delete(ctx, integration.ID)
+}
+
+// Delivered queues a sync for everyone who linked the repo a webhook delivery is about
+func (s *Syncer) Delivered(ctx context.Context, repo string) error {
+	integrations, err := s.store.Connections(ctx, bson.M{"links.repo": repo})
+	if err != nil {
+		return err
+	}
+	for _, integration := range integrations {
+		if err := s.Enqueue(ctx, integration.User); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseIssue comments on and closes the issue a completed task was made from, unless it is closed already
+func (s *Syncer) CloseIssue(ctx context.Context, userID primitive.ObjectID, taskID primitive.ObjectID) error {
+	integration, err := s.store.Get(ctx, userID)
+	if errors.Is(err, mongo.ErrNoDocuments) || (err == nil && !integration.Connected()) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	t, err := s.tasks.GetTaskByID(ctx, taskID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	repo, number, ok := parseSource(t.Source)
+	if !ok {
+		return nil
+	}
+
+	issue, err := s.client.Issue(ctx, integration.Token, repo, number)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if errors.Is(err, ErrRevoked) {
+		return s.revoked(ctx, integration, err)
+	}
+	if err != nil {
+		return err
+	}
+	if issue.Closed() {
+		return nil
+	}
+	if err := s.client.Comment(ctx, integration.Token, repo, number, closeComment); err != nil {
+		return err
+	}
+	return s.client.Close(ctx, integration.Token, repo, number)
+}
@@ -0,0 +1,215 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	gojson "github.com/goccy/go-json"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	authURL = "https://api.notion.com/v1/oauth/authorize"
+	api     = "https://api.notion.com/v1"
+	version = "2022-06-28"
+
+	// Notion takes at most this many blocks per request
+	maxBlocks = 100
+	// and this many characters per piece of text
+	maxText = 2000
+)
+
+var (
+	// ErrDisabled is returned when no Notion integration is configured
+	ErrDisabled = errors.New("notion integration is not configured")
+	// ErrRevoked means the user removed the integration from their workspace
+	ErrRevoked = errors.New("notion: access revoked")
+	// ErrNoPage means the user shared no page the export could go under
+	ErrNoPage = errors.New("notion: no page shared with the integration")
+)
+
+// Notion ties the client to the stored connections
+type Notion struct {
+	store  *Store
+	client *Client
+	cfg    config.Notion
+}
+
+func New(collections map[string]*mongo.Collection, cfg config.Notion) *Notion {
+	return &Notion{store: NewStore(collections[Collection]), client: NewClient(cfg), cfg: cfg}
+}
+
+func (n *Notion) Enabled() bool {
+	return n.cfg.ClientID != ""
+}
+
+func (n *Notion) Store() *Store {
+	return n.store
+}
+
+func (n *Notion) Client() *Client {
+	return n.client
+}
+
+/*
+Client talks to Notion's API directly, exports only need the OAuth exchange,
+a search for shared pages and creating pages.
+*/
+type Client struct {
+	cfg  config.Notion
+	http *http.Client
+}
+
+func NewClient(cfg config.Notion) *Client {
+	return &Client{cfg: cfg, http: &http.Client{Timeout: 20 * time.Second}}
+}
+
+// AuthURL is where the user picks the workspace and the pages exports may go under
+func (c *Client) AuthURL(state string) string {
+	return authURL + "?" + url.Values{
+		"client_id":     {c.cfg.ClientID},
+		"response_type": {"code"},
+		"owner":         {"user"},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"state":         {state},
+	}.Encode()
+}
+
+// Grant is what an OAuth exchange returns; Notion tokens don't expire
+type Grant struct {
+	AccessToken   string `json:"access_token"`
+	WorkspaceID   string `json:"workspace_id"`
+	WorkspaceName string `json:"workspace_name"`
+}
+
+func (c *Client) Exchange(ctx context.Context, code string) (*Grant, error) {
+	body, err := gojson.Marshal(map[string]string{
+		"grant_type":   "authorization_code",
+		"code":         code,
+		"redirect_uri": c.cfg.RedirectURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api+"/oauth/token", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.cfg.ClientID, c.cfg.ClientSecret)
+	var grant Grant
+	if err := c.do(req, &grant); err != nil {
+		return nil, err
+	}
+	return &grant, nil
+}
+
+// FirstPage returns a page shared with the integration, where exports go when the user names none
+func (c *Client) FirstPage(ctx context.Context, token string) (string, error) {
+	var result struct {
+		Results []struct {
+			ID string `json:"id"`
+		} `json:"results"`
+	}
+	err := c.call(ctx, token, http.MethodPost, "/search", map[string]any{
+		"filter":    map[string]string{"property": "object", "value": "page"},
+		"page_size": 1,
+	}, &result)
+	if err != nil {
+		return "", err
+	}
+	if len(result.Results) == 0 {
+		return "", ErrNoPage
+	}
+	return result.Results[0].ID, nil
+}
+
+// Block is one line of a page, the parts of Notion's block object exports use
+type Block map[string]any
+
+func text(content string) []map[string]any {
+	if r := []rune(content); len(r) > maxText {
+		content = string(r[:maxText])
+	}
+	return []map[string]any{{"type": "text", "text": map[string]string{"content": content}}}
+}
+
+func ToDo(content string, checked bool) Block {
+	return Block{"object": "block", "type": "to_do", "to_do": map[string]any{"rich_text": text(content), "checked": checked}}
+}
+
+func Paragraph(content string) Block {
+	return Block{"object": "block", "type": "paragraph", "paragraph": map[string]any{"rich_text": text(content)}}
+}
+
+// CreatePage adds a page under parent and returns its URL; blocks past Notion's per-request limit are appended after
+func (c *Client) CreatePage(ctx context.Context, token string, parent string, title string, blocks []Block) (string, error) {
+	first := blocks[:min(len(blocks), maxBlocks)]
+	var page struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	}
+	err := c.call(ctx, token, http.MethodPost, "/pages", map[string]any{
+		"parent":     map[string]string{"page_id": parent},
+		"properties": map[string]any{"title": map[string]any{"title": text(title)}},
+		"children":   first,
+	}, &page)
+	if err != nil {
+		return "", err
+	}
+	for rest := blocks[len(first):]; len(rest) > 0; {
+		n := min(len(rest), maxBlocks)
+		if err := c.call(ctx, token, http.MethodPatch, "/blocks/"+page.ID+"/children", map[string]any{"children": rest[:n]}, nil); err != nil {
+			return "", err
+		}
+		rest = rest[n:]
+	}
+	return page.URL, nil
+}
+
+func (c *Client) call(ctx context.Context, token string, method string, path string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := gojson.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, api+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return c.do(req, out)
+}
+
+func (c *Client) do(req *http.Request, out any) error {
+	req.Header.Set("Notion-Version", version)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrRevoked
+	case resp.StatusCode >= 300:
+		var failure struct {
+			Message string `json:"message"`
+		}
+		gojson.NewDecoder(resp.Body).Decode(&failure)
+		return fmt.Errorf("notion %s %s responded %s: %s", req.Method, req.URL.Path, resp.Status, failure.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return gojson.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,100 @@
+package notion
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+A user's Notion connection is one document in the integrations collection,
+next to their other integrations, holding the token for the workspace they
+picked.
+*/
+
+const (
+	Collection = "integrations"
+	Provider   = "notion"
+)
+
+type Integration struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	User          primitive.ObjectID `bson:"user"`
+	Provider      string             `bson:"provider"`
+	Token         string             `bson:"token,omitempty"`
+	WorkspaceID   string             `bson:"workspace_id,omitempty"`
+	WorkspaceName string             `bson:"workspace_name,omitempty"`
+	// OAuth state between starting the flow and the callback
+	State     string    `bson:"state,omitempty"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func (i *Integration) Connected() bool {
+	return i.Token != ""
+}
+
+type Store struct {
+	integrations *mongo.Collection
+}
+
+func NewStore(integrations *mongo.Collection) *Store {
+	return &Store{integrations: integrations}
+}
+
+func (s *Store) Get(ctx context.Context, userID primitive.ObjectID) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOne(ctx, bson.M{"user": userID, "provider": Provider}).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// Begin stores the OAuth state for a new connection, keeping an existing connection until the callback replaces it
+func (s *Store) Begin(ctx context.Context, userID primitive.ObjectID, state string) error {
+	_, err := s.integrations.UpdateOne(ctx,
+		bson.M{"user": userID, "provider": Provider},
+		bson.M{
+			"$set": bson.M{"state": state},
+			"$setOnInsert": bson.M{
+				"_id":        primitive.NewObjectID(),
+				"created_at": time.Now(),
+			},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *Store) Connect(ctx context.Context, state string, grant *Grant) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOneAndUpdate(ctx,
+		bson.M{"provider": Provider, "state": state},
+		bson.M{
+			"$set": bson.M{
+				"token":          grant.AccessToken,
+				"workspace_id":   grant.WorkspaceID,
+				"workspace_name": grant.WorkspaceName,
+			},
+			"$unset": bson.M{"state": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.integrations.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// Disconnected drops the token of a connection removed from the workspace
+func (s *Store) Disconnected(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.integrations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"token": ""}})
+	return err
+}
@@ -0,0 +1,251 @@
+package slack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// how far ahead a due task is announced
+	dueLead = 15 * time.Minute
+	// tasks listed in one DM, the rest are summed up
+	maxListed = 10
+	// tasks made from Slack get the middle of the 1-10 value scale
+	defaultValue = 5
+)
+
+// ErrDisabled is returned when no Slack app is configured
+var ErrDisabled = errors.New("slack integration is not configured")
+
+// ErrNoCategory means the user has nowhere to put a task yet
+var ErrNoCategory = errors.New("no category to add the task to")
+
+type App struct {
+	store  *Store
+	client *Client
+	users  *mongo.Collection
+	tasks  *task.Service
+	cfg    config.Slack
+}
+
+func New(collections map[string]*mongo.Collection, cache xcache.Cache, cfg config.Slack) *App {
+	return &App{
+		store:  NewStore(collections[Collection]),
+		client: NewClient(cfg),
+		users:  collections["users"],
+		tasks:  task.NewService(collections, cache),
+		cfg:    cfg,
+	}
+}
+
+func (a *App) Enabled() bool {
+	return a.cfg.ClientID != "" && a.cfg.SigningSecret != ""
+}
+
+func (a *App) Store() *Store {
+	return a.store
+}
+
+func (a *App) Client() *Client {
+	return a.client
+}
+
+// Verify checks a slash command request against the app's signing secret
+func (a *App) Verify(timestamp string, signature string, body []byte) error {
+	return Verify(a.cfg.SigningSecret, timestamp, signature, body, time.Now())
+}
+
+// Command is the part of a slash command request the app reads
+type Command struct {
+	TeamID string
+	UserID string
+	Text   string
+}
+
+/*
+Run handles /todo and returns the reply, shown only to the member who typed
+it. "/todo add <task>" creates a task, anything else gets the usage.
+*/
+func (a *App) Run(ctx context.Context, command Command) (string, error) {
+	integration, err := a.store.ByMember(ctx, command.TeamID, command.UserID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "Your Slack account isn't linked yet. Connect Slack from the app's integration settings first.", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	verb, rest, _ := strings.Cut(strings.TrimSpace(command.Text), " ")
+	switch strings.ToLower(verb) {
+	case "add":
+		content := strings.TrimSpace(rest)
+		if content == "" {
+			return "What should the task say? Try `/todo add Water the plants`.", nil
+		}
+		name, err := a.addTask(ctx, integration, content)
+		if errors.Is(err, ErrNoCategory) {
+			return "You don't have any categories yet. Create one in the app first.", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Added \"%s\" to %s.", content, name), nil
+	default:
+		return "Usage: `/todo add <task>` adds a task to your default category.", nil
+	}
+}
+
+func (a *App) addTask(ctx context.Context, integration *Integration, content string) (string, error) {
+	target, err := a.category(ctx, integration)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	doc := task.TaskDocument{
+		ID:        primitive.NewObjectID(),
+		Priority:  1,
+		Content:   content,
+		Value:     defaultValue,
+		Active:    true,
+		Timestamp: now,
+		UpdatedAt: now,
+	}
+	if _, err := a.tasks.CreateTask(ctx, integration.User, target.ID, &doc); err != nil {
+		return "", err
+	}
+	return target.Name, nil
+}
+
+// category is the link's chosen category while it is live, otherwise the user's first one
+func (a *App) category(ctx context.Context, integration *Integration) (*category.CategoryDocument, error) {
+	var owner struct {
+		Categories []category.CategoryDocument `bson:"categories"`
+	}
+	err := a.users.FindOne(ctx, bson.M{"_id": integration.User},
+		options.FindOne().SetProjection(bson.M{"categories._id": 1, "categories.name": 1, "categories." + softdelete.Field: 1}),
+	).Decode(&owner)
+	if err != nil {
+		return nil, err
+	}
+	live := softdelete.Visible(owner.Categories)
+	if len(live) == 0 {
+		return nil, ErrNoCategory
+	}
+	if integration.Category != nil {
+		for i := range live {
+			if live[i].ID == *integration.Category {
+				return &live[i], nil
+			}
+		}
+	}
+	return &live[0], nil
+}
+
+// Disconnect uninstalls the bot token and removes the link
+func (a *App) Disconnect(ctx context.Context, integration *Integration) error {
+	if integration.Connected() {
+		if err := a.client.Revoke(ctx, integration.BotToken); err != nil && !errors.Is(err, ErrRevoked) {
+			slog.LogAttrs(ctx, slog.LevelWarn, "Failed to revoke Slack token", xslog.Error(err))
+		}
+	}
+	return a.store.Delete(ctx, integration.ID)
+}
+
+type dueTask struct {
+	Content string    `bson:"content"`
+	DueDate time.Time `bson:"due_date"`
+}
+
+/*
+NotifyDue DMs each opted-in member the tasks coming due since their last
+notice. The window always starts where the previous one ended, so a task is
+announced once even when a run is skipped or late.
+*/
+func (a *App) NotifyDue(ctx context.Context) error {
+	integrations, err := a.store.Notifiable(ctx)
+	if err != nil {
+		return err
+	}
+	until := time.Now().Add(dueLead)
+	var errs []error
+	for i := range integrations {
+		if err := a.notify(ctx, &integrations[i], until); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *App) notify(ctx context.Context, integration *Integration, until time.Time) error {
+	from := time.Now()
+	if integration.NotifiedUntil != nil && integration.NotifiedUntil.After(from.Add(-dueLead)) {
+		from = *integration.NotifiedUntil
+	}
+
+	cursor, err := a.users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": integration.User}}},
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$match", Value: softdelete.LiveAt("categories")}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$categories.tasks"}}},
+		{{Key: "$match", Value: softdelete.Filter(bson.M{
+			"completed": bson.M{"$ne": true},
+			"due_date":  bson.M{"$gt": from, "$lte": until},
+		})}},
+		{{Key: "$sort", Value: bson.M{"due_date": 1}}},
+		{{Key: "$project", Value: bson.M{"content": 1, "due_date": 1}}},
+	})
+	if err != nil {
+		return err
+	}
+	var due []dueTask
+	if err := cursor.All(ctx, &due); err != nil {
+		return err
+	}
+
+	if len(due) > 0 {
+		err := a.client.PostMessage(ctx, integration.BotToken, integration.SlackUser, dueMessage(due))
+		if errors.Is(err, ErrRevoked) {
+			return a.store.Disconnected(ctx, integration.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return a.store.Update(ctx, integration.ID, bson.M{"notified_until": until})
+}
+
+func dueMessage(due []dueTask) string {
+	var b strings.Builder
+	b.WriteString("Coming up soon:")
+	for i, t := range due {
+		if i == maxListed {
+			fmt.Fprintf(&b, "\n…and %d more", len(due)-maxListed)
+			break
+		}
+		// Slack renders the date in the reader's own timezone
+		fmt.Fprintf(&b, "\n• %s, due <!date^%d^{time}|%s>", t.Content, t.DueDate.Unix(), t.DueDate.UTC().Format(time.Kitchen+" UTC"))
+	}
+	return b.String()
+}
+
+// RegisterSchedules adds the due task notifications
+func RegisterSchedules(cron *scheduler.Scheduler, a *App) {
+	cron.Register("slack-due-notifications", "*/5 * * * *", 4*time.Minute, a.NotifyDue)
+}
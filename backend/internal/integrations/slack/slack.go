@@ -0,0 +1,174 @@
+package slack
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	gojson "github.com/goccy/go-json"
+)
+
+const (
+	authURL = "https://slack.com/oauth/v2/authorize"
+	api     = "https://slack.com/api"
+	// slash commands, and DMs from the bot
+	scopes = "commands,chat:write,im:write"
+
+	// Slack's advice for rejecting replayed requests
+	maxClockSkew = 5 * time.Minute
+)
+
+var (
+	// ErrRevoked means the app was uninstalled or its token revoked; the link can't continue
+	ErrRevoked = errors.New("slack: access revoked")
+	// ErrBadSignature is a request that didn't come from Slack, or came too long ago
+	ErrBadSignature = errors.New("slack: invalid request signature")
+)
+
+/*
+Client talks to Slack's Web API directly, the integration needs an OAuth
+exchange and one message call.
+*/
+type Client struct {
+	cfg  config.Slack
+	http *http.Client
+}
+
+func NewClient(cfg config.Slack) *Client {
+	return &Client{cfg: cfg, http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// AuthURL is the install page for the app, adding it to the user's workspace
+func (c *Client) AuthURL(state string) string {
+	return authURL + "?" + url.Values{
+		"client_id":    {c.cfg.ClientID},
+		"scope":        {scopes},
+		"redirect_uri": {c.cfg.RedirectURL},
+		"state":        {state},
+	}.Encode()
+}
+
+// Install is what an OAuth exchange returns: the bot token for the workspace and who installed it
+type Install struct {
+	BotToken  string
+	TeamID    string
+	TeamName  string
+	SlackUser string
+}
+
+func (c *Client) Exchange(ctx context.Context, code string) (*Install, error) {
+	var body struct {
+		AccessToken string `json:"access_token"`
+		Team        struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"team"`
+		AuthedUser struct {
+			ID string `json:"id"`
+		} `json:"authed_user"`
+	}
+	err := c.call(ctx, "", "oauth.v2.access", url.Values{
+		"client_id":     {c.cfg.ClientID},
+		"client_secret": {c.cfg.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+	}, &body)
+	if err != nil {
+		return nil, err
+	}
+	return &Install{
+		BotToken:  body.AccessToken,
+		TeamID:    body.Team.ID,
+		TeamName:  body.Team.Name,
+		SlackUser: body.AuthedUser.ID,
+	}, nil
+}
+
+// PostMessage sends text to channel; a user id as the channel is a DM from the bot
+func (c *Client) PostMessage(ctx context.Context, token string, channel string, text string) error {
+	return c.call(ctx, token, "chat.postMessage", url.Values{
+		"channel": {channel},
+		"text":    {text},
+	}, nil)
+}
+
+// Revoke uninstalls the bot token, best effort
+func (c *Client) Revoke(ctx context.Context, token string) error {
+	return c.call(ctx, token, "auth.revoke", url.Values{}, nil)
+}
+
+// call posts a form to a Web API method; Slack answers 200 with ok false on failure
+func (c *Client) call(ctx context.Context, token string, method string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, api+"/"+method, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack %s responded %s", method, resp.Status)
+	}
+
+	var raw gojson.RawMessage
+	if err := gojson.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return err
+	}
+	var status struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := gojson.Unmarshal(raw, &status); err != nil {
+		return err
+	}
+	switch {
+	case status.OK:
+	case status.Error == "invalid_auth", status.Error == "token_revoked", status.Error == "account_inactive", status.Error == "invalid_code":
+		return ErrRevoked
+	default:
+		return fmt.Errorf("slack %s: %s", method, status.Error)
+	}
+	if out == nil {
+		return nil
+	}
+	return gojson.Unmarshal(raw, out)
+}
+
+/*
+Verify checks a request's X-Slack-Signature: an HMAC of the timestamp and the
+raw body under the app's signing secret. Requests older than a few minutes
+are refused so a captured one can't be replayed.
+*/
+func Verify(secret string, timestamp string, signature string, body []byte, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > maxClockSkew || skew < -maxClockSkew {
+		return ErrBadSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return ErrBadSignature
+	}
+	return nil
+}
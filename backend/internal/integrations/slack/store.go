@@ -0,0 +1,158 @@
+package slack
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+A user's link to a Slack workspace is one document in the integrations
+collection, next to their other integrations, naming the workspace and the
+Slack member the user is there.
+*/
+
+const (
+	Collection = "integrations"
+	Provider   = "slack"
+)
+
+type Integration struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	User      primitive.ObjectID `bson:"user"`
+	Provider  string             `bson:"provider"`
+	TeamID    string             `bson:"team_id,omitempty"`
+	TeamName  string             `bson:"team_name,omitempty"`
+	SlackUser string             `bson:"slack_user,omitempty"`
+	BotToken  string             `bson:"bot_token,omitempty"`
+	// OAuth state between starting the install and the callback
+	State string `bson:"state,omitempty"`
+	// DM the user about tasks coming due
+	NotifyDue bool `bson:"notify_due"`
+	// where /todo add puts tasks, the first category when unset
+	Category *primitive.ObjectID `bson:"category,omitempty"`
+	// tasks due up to here have been notified
+	NotifiedUntil *time.Time `bson:"notified_until,omitempty"`
+	CreatedAt     time.Time  `bson:"created_at"`
+}
+
+func (i *Integration) Connected() bool {
+	return i.BotToken != ""
+}
+
+type Store struct {
+	integrations *mongo.Collection
+}
+
+func NewStore(integrations *mongo.Collection) *Store {
+	return &Store{integrations: integrations}
+}
+
+func (s *Store) Get(ctx context.Context, userID primitive.ObjectID) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOne(ctx, bson.M{"user": userID, "provider": Provider}).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// Begin stores the OAuth state for a new link, keeping an existing link until the callback replaces it
+func (s *Store) Begin(ctx context.Context, userID primitive.ObjectID, state string) error {
+	_, err := s.integrations.UpdateOne(ctx,
+		bson.M{"user": userID, "provider": Provider},
+		bson.M{
+			"$set": bson.M{"state": state},
+			"$setOnInsert": bson.M{
+				"_id":        primitive.NewObjectID(),
+				"notify_due": false,
+				"created_at": time.Now(),
+			},
+		},
+		options.Update().SetUpsert(true))
+	return err
+}
+
+/*
+Connect saves the install for the flow started with state. A Slack member
+links to one user here, so a link the member made from another account is
+removed.
+*/
+func (s *Store) Connect(ctx context.Context, state string, install *Install) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOneAndUpdate(ctx,
+		bson.M{"provider": Provider, "state": state},
+		bson.M{
+			"$set": bson.M{
+				"team_id":    install.TeamID,
+				"team_name":  install.TeamName,
+				"slack_user": install.SlackUser,
+				"bot_token":  install.BotToken,
+			},
+			"$unset": bson.M{"state": "", "notified_until": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	_, err = s.integrations.DeleteMany(ctx, bson.M{
+		"provider":   Provider,
+		"team_id":    install.TeamID,
+		"slack_user": install.SlackUser,
+		"_id":        bson.M{"$ne": integration.ID},
+	})
+	return &integration, err
+}
+
+func (s *Store) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.integrations.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// Update sets the user-editable settings
+func (s *Store) Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	_, err := s.integrations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return err
+}
+
+// Disconnected drops the token of a link whose app was uninstalled, keeping the settings for a reinstall
+func (s *Store) Disconnected(ctx context.Context, id primitive.ObjectID) error {
+	_, err := s.integrations.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$unset": bson.M{"bot_token": ""}})
+	return err
+}
+
+// ByMember finds the link a slash command is for
+func (s *Store) ByMember(ctx context.Context, teamID string, slackUser string) (*Integration, error) {
+	var integration Integration
+	err := s.integrations.FindOne(ctx, bson.M{
+		"provider":   Provider,
+		"team_id":    teamID,
+		"slack_user": slackUser,
+		"bot_token":  bson.M{"$exists": true},
+	}).Decode(&integration)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// Notifiable returns every connected link with due notifications on
+func (s *Store) Notifiable(ctx context.Context) ([]Integration, error) {
+	cursor, err := s.integrations.Find(ctx, bson.M{
+		"provider":   Provider,
+		"notify_due": true,
+		"bot_token":  bson.M{"$exists": true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Integration, 0)
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
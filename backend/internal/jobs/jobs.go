@@ -0,0 +1,243 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Jobs is a persistent queue for work that shouldn't hold up a request: feed
+fan-out, emails, push delivery, exports and imports. Jobs live in Mongo so
+they survive restarts, are retried with exponential backoff and end up in
+the dead status once they run out of attempts, where they stay until an
+admin retries them.
+*/
+
+const (
+	Collection = "jobs"
+	// finished jobs are kept this long (TTL index in xmongo.Indexes)
+	Retention = 7 * 24 * time.Hour
+
+	DefaultMaxAttempts = 10
+)
+
+type Status string
+
+const (
+	Queued  Status = "queued"
+	Running Status = "running"
+	Done    Status = "done"
+	// Dead jobs ran out of attempts (or failed permanently) and wait for an admin
+	Dead Status = "dead"
+)
+
+var ErrNotFound = errors.New("job not found")
+
+type Job struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	Kind        string             `bson:"kind" json:"kind"`
+	Payload     bson.Raw           `bson:"payload" json:"-"`
+	Status      Status             `bson:"status" json:"status"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	MaxAttempts int                `bson:"max_attempts" json:"max_attempts"`
+	// next time the job may be claimed; while running, when the lease expires
+	RunAt      time.Time          `bson:"run_at" json:"run_at"`
+	Lease      primitive.ObjectID `bson:"lease,omitempty" json:"-"`
+	LastError  string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	return bson.Unmarshal(j.Payload, v)
+}
+
+type Queue struct {
+	jobs *mongo.Collection
+}
+
+func New(jobs *mongo.Collection) *Queue {
+	return &Queue{jobs: jobs}
+}
+
+type enqueueOptions struct {
+	delay       time.Duration
+	maxAttempts int
+}
+
+type Option func(*enqueueOptions)
+
+// Delay holds the job back for d before it can run
+func Delay(d time.Duration) Option {
+	return func(o *enqueueOptions) { o.delay = d }
+}
+
+// MaxAttempts overrides DefaultMaxAttempts
+func MaxAttempts(n int) Option {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+/*
+Enqueue stores a job of the given kind. payload must marshal to a BSON
+document (a struct or a map); workers read it back with Job.Decode.
+*/
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload interface{}, opts ...Option) (primitive.ObjectID, error) {
+	o := enqueueOptions{maxAttempts: DefaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	raw, err := bson.Marshal(payload)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	now := time.Now()
+	job := Job{
+		ID:          primitive.NewObjectID(),
+		Kind:        kind,
+		Payload:     raw,
+		Status:      Queued,
+		MaxAttempts: o.maxAttempts,
+		RunAt:       now.Add(o.delay),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if _, err := q.jobs.InsertOne(ctx, job); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return job.ID, nil
+}
+
+/*
+claim leases the next due job of one of kinds. Running jobs whose lease ran
+out (the worker died mid-job) are claimable again, which makes delivery
+at-least-once: handlers must be safe to run twice.
+*/
+func (q *Queue) claim(ctx context.Context, kinds []string, lease time.Duration) (*Job, error) {
+	now := time.Now()
+	filter := bson.M{
+		"kind":   bson.M{"$in": kinds},
+		"status": bson.M{"$in": []Status{Queued, Running}},
+		"run_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     Running,
+			"run_at":     now.Add(lease),
+			"lease":      primitive.NewObjectID(),
+			"updated_at": now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job Job
+	err := q.jobs.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// complete marks a leased job done; a job whose lease was taken over is left alone
+func (q *Queue) complete(ctx context.Context, job *Job) error {
+	now := time.Now()
+	_, err := q.jobs.UpdateOne(ctx, bson.M{"_id": job.ID, "lease": job.Lease}, bson.M{
+		"$set":   bson.M{"status": Done, "updated_at": now, "finished_at": now},
+		"$unset": bson.M{"lease": "", "last_error": ""},
+	})
+	return err
+}
+
+// fail requeues a leased job at retryAt, or dead-letters it when retryAt is zero
+func (q *Queue) fail(ctx context.Context, job *Job, cause error, retryAt time.Time) error {
+	now := time.Now()
+	set := bson.M{"status": Queued, "run_at": retryAt, "last_error": cause.Error(), "updated_at": now}
+	if retryAt.IsZero() {
+		set = bson.M{"status": Dead, "last_error": cause.Error(), "updated_at": now, "finished_at": now}
+	}
+	_, err := q.jobs.UpdateOne(ctx, bson.M{"_id": job.ID, "lease": job.Lease}, bson.M{
+		"$set":   set,
+		"$unset": bson.M{"lease": ""},
+	})
+	return err
+}
+
+// Retry puts a dead job back on the queue with a fresh set of attempts
+func (q *Queue) Retry(ctx context.Context, id primitive.ObjectID) error {
+	now := time.Now()
+	result, err := q.jobs.UpdateOne(ctx, bson.M{"_id": id, "status": Dead}, bson.M{
+		"$set":   bson.M{"status": Queued, "attempts": 0, "run_at": now, "updated_at": now},
+		"$unset": bson.M{"finished_at": ""},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type Depth struct {
+	Kind   string `bson:"kind" json:"kind"`
+	Status Status `bson:"status" json:"status"`
+	Count  int64  `bson:"count" json:"count"`
+	// age of the oldest job in this bucket
+	Oldest time.Time `bson:"oldest" json:"oldest"`
+}
+
+// Depths counts unfinished (queued, running and dead) jobs per kind and status
+func (q *Queue) Depths(ctx context.Context) ([]Depth, error) {
+	cursor, err := q.jobs.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": bson.M{"$ne": Done}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"kind": "$kind", "status": "$status"},
+			"count":  bson.M{"$sum": 1},
+			"oldest": bson.M{"$min": "$created_at"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":    0,
+			"kind":   "$_id.kind",
+			"status": "$_id.status",
+			"count":  1,
+			"oldest": 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "kind", Value: 1}, {Key: "status", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	depths := make([]Depth, 0)
+	if err := cursor.All(ctx, &depths); err != nil {
+		return nil, err
+	}
+	return depths, nil
+}
+
+// List returns up to limit jobs with the given status, most recently updated first
+func (q *Queue) List(ctx context.Context, status Status, limit int64) ([]Job, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: -1}}).SetLimit(limit)
+	cursor, err := q.jobs.Find(ctx, bson.M{"status": status}, opts)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0)
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+)
+
+// Handler runs one job. Returning an error schedules a retry.
+type Handler func(ctx context.Context, job *Job) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error that retrying won't fix (bad payload, deleted user) so the job is dead-lettered right away
+func Permanent(err error) error {
+	return permanentError{err}
+}
+
+type WorkerConfig struct {
+	Concurrency  int
+	PollInterval time.Duration
+	// how long a claimed job may run before another worker may take it over
+	Lease time.Duration
+}
+
+/*
+Worker polls the queue for the kinds it has handlers for. Register every
+handler before Run; Run blocks until ctx is cancelled and the jobs in
+progress have finished.
+*/
+type Worker struct {
+	queue    *Queue
+	cfg      WorkerConfig
+	handlers map[string]Handler
+}
+
+func NewWorker(queue *Queue, cfg WorkerConfig) *Worker {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 5 * time.Minute
+	}
+	return &Worker{queue: queue, cfg: cfg, handlers: make(map[string]Handler)}
+}
+
+func (w *Worker) Handle(kind string, handler Handler) {
+	w.handlers[kind] = handler
+}
+
+func (w *Worker) Run(ctx context.Context) {
+	kinds := make([]string, 0, len(w.handlers))
+	for kind := range w.handlers {
+		kinds = append(kinds, kind)
+	}
+	if len(kinds) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for range w.cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, kinds)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context, kinds []string) {
+	for {
+		job, err := w.queue.claim(ctx, kinds, w.cfg.Lease)
+		if err != nil && ctx.Err() == nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to claim job", xslog.Error(err))
+		}
+		if job != nil {
+			w.process(ctx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.cfg.PollInterval):
+		}
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job *Job) {
+	// finish the job being worked on even when shutdown starts; the lease bounds it
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), w.cfg.Lease)
+	defer cancel()
+	runCtx = xslog.WithAttrs(runCtx,
+		slog.String("job_id", job.ID.Hex()), slog.String("job_kind", job.Kind), slog.Int("attempt", job.Attempts))
+
+	start := time.Now()
+	err := w.run(runCtx, job)
+	xmetrics.JobDuration.WithLabelValues(job.Kind).Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		xmetrics.JobsProcessed.WithLabelValues(job.Kind, "success").Inc()
+		if err := w.queue.complete(runCtx, job); err != nil {
+			slog.LogAttrs(runCtx, slog.LevelError, "Failed to mark job done", xslog.Error(err))
+		}
+		return
+	}
+
+	var retryAt time.Time
+	var permanent permanentError
+	if !errors.As(err, &permanent) && job.Attempts < job.MaxAttempts {
+		retryAt = time.Now().Add(Backoff(job.Attempts))
+	}
+	if retryAt.IsZero() {
+		xmetrics.JobsProcessed.WithLabelValues(job.Kind, "dead").Inc()
+		slog.LogAttrs(runCtx, slog.LevelError, "Job dead-lettered", xslog.Error(err))
+	} else {
+		xmetrics.JobsProcessed.WithLabelValues(job.Kind, "retry").Inc()
+		slog.LogAttrs(runCtx, slog.LevelWarn, "Job failed, retrying", xslog.Error(err), slog.Time("retry_at", retryAt))
+	}
+	if err := w.queue.fail(runCtx, job, err, retryAt); err != nil {
+		slog.LogAttrs(runCtx, slog.LevelError, "Failed to record job failure", xslog.Error(err))
+	}
+}
+
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = Permanent(fmt.Errorf("job panicked: %v", r))
+		}
+	}()
+	if job.Attempts > job.MaxAttempts {
+		// the last attempt crashed the worker before it could record a result
+		return Permanent(errors.New("out of attempts"))
+	}
+	return w.handlers[job.Kind](ctx, job)
+}
+
+// Backoff is the delay before retry number attempt: 5s doubling up to an hour, with jitter
+func Backoff(attempt int) time.Duration {
+	delay := time.Hour
+	if attempt < 10 {
+		delay = min(5*time.Second<<attempt, time.Hour)
+	}
+	return delay/2 + rand.N(delay/2)
+}
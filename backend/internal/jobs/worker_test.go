@@ -0,0 +1,101 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		base    time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, 10 * time.Second},
+		{4, 80 * time.Second},
+		{9, 5 * time.Second << 9},
+		{10, time.Hour},
+		// far past the cap, where the shift would overflow
+		{80, time.Hour},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if delay := Backoff(tt.attempt); delay < tt.base/2 || delay >= tt.base {
+				t.Errorf("attempt %d: expected between %s and %s, got %s", tt.attempt, tt.base/2, tt.base, delay)
+			}
+		}
+	}
+}
+
+func TestWorkerRun(t *testing.T) {
+	worker := NewWorker(nil, WorkerConfig{})
+	failure := errors.New("smtp timeout")
+	worker.Handle("ok", func(ctx context.Context, job *Job) error { return nil })
+	worker.Handle("fails", func(ctx context.Context, job *Job) error { return failure })
+	worker.Handle("bad payload", func(ctx context.Context, job *Job) error { return Permanent(failure) })
+	worker.Handle("panics", func(ctx context.Context, job *Job) error { panic("nil map") })
+
+	tests := []struct {
+		name      string
+		job       Job
+		err       error
+		permanent bool
+	}{
+		{"succeeds", Job{Kind: "ok", Attempts: 1, MaxAttempts: 3}, nil, false},
+		{"retryable failure", Job{Kind: "fails", Attempts: 1, MaxAttempts: 3}, failure, false},
+		{"permanent failure", Job{Kind: "bad payload", Attempts: 1, MaxAttempts: 3}, failure, true},
+		{"panic", Job{Kind: "panics", Attempts: 1, MaxAttempts: 3}, nil, true},
+		// claimed again after the last attempt crashed the worker
+		{"out of attempts", Job{Kind: "ok", Attempts: 4, MaxAttempts: 3}, nil, true},
+	}
+	for _, tt := range tests {
+		err := worker.run(context.Background(), &tt.job)
+		var permanent permanentError
+		if errors.As(err, &permanent) != tt.permanent {
+			t.Errorf("%s: expected permanent %v, got %v", tt.name, tt.permanent, err)
+		}
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+		if tt.err == nil && !tt.permanent && err != nil {
+			t.Errorf("%s: expected no error, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestNewWorker(t *testing.T) {
+	worker := NewWorker(nil, WorkerConfig{Concurrency: -1})
+	if worker.cfg.Concurrency != 1 || worker.cfg.PollInterval != time.Second || worker.cfg.Lease != 5*time.Minute {
+		t.Errorf("expected the defaults, got %+v", worker.cfg)
+	}
+	// with no handlers there is nothing to claim, Run returns at once
+	done := make(chan struct{})
+	go func() {
+		worker.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("expected Run to return without handlers")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type payload struct {
+		UserID string `bson:"user_id"`
+	}
+	queued := payload{UserID: "65f1c0ffee"}
+	raw, err := bson.Marshal(queued)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got payload
+	if err := (&Job{Payload: raw}).Decode(&got); err != nil || got != queued {
+		t.Errorf("expected %+v, got %+v %v", queued, got, err)
+	}
+}
@@ -0,0 +1,76 @@
+package lifecycle
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+)
+
+/*
+Manager runs the process's shutdown. Each dependency registers how to stop
+it as it is started, and on SIGINT or SIGTERM the steps run in reverse order,
+like defers: the HTTP servers, started last, drain first, then the workers
+that feed each other (the scheduler before the job worker it enqueues for),
+then the connections everything else needed. All steps share one deadline,
+so a slow step eats into the budget of the ones after it, and a failing step
+is logged without stopping the rest.
+*/
+type Manager struct {
+	timeout time.Duration
+	steps   []step
+}
+
+type step struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+func New(timeout time.Duration) *Manager {
+	return &Manager{timeout: timeout}
+}
+
+// OnStop registers stop to run at shutdown, before every step registered ahead of it
+func (m *Manager) OnStop(name string, stop func(ctx context.Context) error) {
+	m.steps = append(m.steps, step{name, stop})
+}
+
+// Wait blocks until the process is told to stop, then shuts down
+func (m *Manager) Wait(ctx context.Context) {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	sig := <-quit
+	slog.LogAttrs(ctx, slog.LevelInfo, "Stopping server",
+		slog.String("signal", sig.String()), slog.Duration("timeout", m.timeout))
+	m.Stop(ctx)
+	slog.LogAttrs(ctx, slog.LevelInfo, "Server shutdown")
+}
+
+// Stop runs every step, newest first
+func (m *Manager) Stop(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.timeout)
+	defer cancel()
+
+	for i := len(m.steps) - 1; i >= 0; i-- {
+		s := m.steps[i]
+		start := time.Now()
+		if err := s.stop(ctx); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to stop "+s.name, xslog.Error(err))
+			continue
+		}
+		slog.LogAttrs(ctx, slog.LevelDebug, "Stopped "+s.name, slog.Duration("took", time.Since(start)))
+	}
+}
+
+// Close adapts a Close method without a context to a step
+func Close(close func() error) func(ctx context.Context) error {
+	return func(context.Context) error {
+		return close()
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"slices"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+RequireAdmin only lets admins through: users whose document carries the admin
+role, plus the configured admin user ids so the first admin can be
+bootstrapped. It reads the caller and their roles from the auth middleware, so
+it has to run after it.
+*/
+func RequireAdmin(userIDs []string) fiber.Handler {
+	return RequireRole(userIDs, xauth.AdminRole)
+}
+
+// RequireRole lets through callers with any of roles, and the configured admin user ids as RequireAdmin does
+func RequireRole(userIDs []string, roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, err := xauth.UserID(c)
+		if err != nil {
+			return fiber.NewError(fiber.StatusForbidden, "Admin access required")
+		}
+		if slices.Contains(userIDs, id.Hex()) || slices.ContainsFunc(roles, func(role string) bool { return xauth.HasRole(c, role) }) {
+			return c.Next()
+		}
+		return fiber.NewError(fiber.StatusForbidden, "Admin access required")
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/xauth"
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRequireRole(t *testing.T) {
+	bootstrap := primitive.NewObjectID().Hex()
+	app := fiber.New()
+	// stands in for the auth middleware: ?user= signs in as that id, ?roles= holds their roles
+	app.Use(func(c *fiber.Ctx) error {
+		if user := c.Query("user"); user != "" {
+			var roles []string
+			if c.Query("roles") != "" {
+				roles = strings.Split(c.Query("roles"), ",")
+			}
+			xauth.Set(c, user, "", roles)
+		}
+		return c.Next()
+	})
+	app.Get("/admin", RequireAdmin([]string{bootstrap}), ok)
+	app.Get("/moderation", RequireRole([]string{bootstrap}, xauth.AdminRole, xauth.ModeratorRole), ok)
+
+	user := primitive.NewObjectID().Hex()
+	tests := []struct {
+		name         string
+		route        string
+		expectedCode int
+	}{
+		{"signed out", "/admin", fiber.StatusForbidden},
+		{"no roles", "/admin?user=" + user, fiber.StatusForbidden},
+		{"admin", "/admin?user=" + user + "&roles=admin", fiber.StatusOK},
+		{"configured admin", "/admin?user=" + bootstrap, fiber.StatusOK},
+		{"moderator on admin routes", "/admin?user=" + user + "&roles=moderator", fiber.StatusForbidden},
+		{"moderator on moderation routes", "/moderation?user=" + user + "&roles=moderator", fiber.StatusOK},
+		{"admin on moderation routes", "/moderation?user=" + user + "&roles=admin", fiber.StatusOK},
+		{"configured admin on moderation routes", "/moderation?user=" + bootstrap, fiber.StatusOK},
+		{"unknown role", "/moderation?user=" + user + "&roles=owner", fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if res := request(t, app, tt.route, nil); res.StatusCode != tt.expectedCode {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expectedCode, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+BodyLimit rejects requests whose body is larger than limit bytes with 413.
+Routes under a skipped prefix are left to a BodyLimit of their own. fiber's
+BodyLimit has to be at least the largest of these, requests over it never
+reach a handler.
+*/
+func BodyLimit(limit int, skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, prefix := range skip {
+			if strings.HasPrefix(c.Path(), prefix) {
+				return c.Next()
+			}
+		}
+		if c.Request().Header.ContentLength() > limit || len(c.Body()) > limit {
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge,
+				fmt.Sprintf("Request body is larger than the %d byte limit for this route", limit))
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/helmet"
+)
+
+// request headers the clients send beyond the CORS safelisted ones
+var corsAllowHeaders = []string{
+	fiber.HeaderAuthorization,
+	fiber.HeaderContentType,
+	fiber.HeaderIfMatch,
+	fiber.HeaderIfNoneMatch,
+	"refresh_token",
+	"X-Device-ID",
+	IdempotencyKeyHeader,
+	RequestIDHeader,
+	"Last-Event-ID",
+	// W3C trace context, for web clients that trace their own requests
+	"traceparent",
+	"tracestate",
+}
+
+// response headers the web client has to be able to read
+var corsExposeHeaders = []string{
+	"access_token",
+	"refresh_token",
+	fiber.HeaderETag,
+	fiber.HeaderRetryAfter,
+	RequestIDHeader,
+	RateLimitLimitHeader,
+	RateLimitRemainingHeader,
+	RateLimitResetHeader,
+	IdempotentReplayedHeader,
+}
+
+// CORS allows the configured web client origins; config.Load has already rejected credentials with a wildcard
+func CORS(cfg config.CORS) fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(cfg.AllowOrigins, ","),
+		AllowMethods:     "GET,POST,PUT,PATCH,DELETE",
+		AllowHeaders:     strings.Join(corsAllowHeaders, ","),
+		ExposeHeaders:    strings.Join(corsExposeHeaders, ","),
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	})
+}
+
+/*
+SecurityHeaders sets the standard hardening headers. Responses are JSON for
+other origins' scripts, so resources are shared cross-origin but nothing may
+frame or render them as a page.
+*/
+func SecurityHeaders(cfg config.HTTP) fiber.Handler {
+	return helmet.New(helmet.Config{
+		XSSProtection:             "0",
+		ContentTypeNosniff:        "nosniff",
+		XFrameOptions:             "DENY",
+		HSTSMaxAge:                cfg.HSTSMaxAge,
+		ContentSecurityPolicy:     "default-src 'none'; frame-ancestors 'none'",
+		ReferrerPolicy:            "no-referrer",
+		CrossOriginEmbedderPolicy: "require-corp",
+		CrossOriginOpenerPolicy:   "same-origin",
+		CrossOriginResourcePolicy: "cross-origin",
+		OriginAgentCluster:        "?1",
+		XDNSPrefetchControl:       "off",
+		XDownloadOptions:          "noopen",
+		XPermittedCrossDomain:     "none",
+	})
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xredis"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// set on responses served from the idempotency store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyPrefix   = "idempotency:"
+	idempotencyLifetime = 24 * time.Hour
+	// how long a retry waits out a request that is still running (or crashed)
+	idempotencyLockTTL = time.Minute
+	idempotencyPending = "pending"
+)
+
+type idempotentResponse struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers"`
+	Body        []byte            `json:"body"`
+}
+
+// only these headers are replayed; rotated auth tokens must never be
+var replayedHeaders = []string{fiber.HeaderContentType, fiber.HeaderLocation}
+
+/*
+Idempotency replays the first response for a user's Idempotency-Key for 24h
+so a retried POST (flaky mobile network, client timeout) doesn't create a
+second task or friend request. Keys are scoped to the authenticated user, so
+it has to run after the auth middleware; routes without auth fall back to
+the :user route param.
+
+Reusing a key for a different request is rejected with 422, and a retry that
+arrives while the original is still running gets 409. Server errors are not
+stored so the client can retry them. Without Redis the middleware is a no-op.
+*/
+func Idempotency(redis *xredis.Client) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if redis == nil || key == "" || c.Method() != fiber.MethodPost {
+			return c.Next()
+		}
+		if len(key) > 255 {
+			return fiber.NewError(fiber.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+		}
+
+		scope, _ := c.Locals("user_id").(string)
+		if scope == "" {
+			scope = c.Params("user")
+		}
+		storeKey := idempotencyPrefix + scope + ":" + key
+		fingerprint := requestFingerprint(c)
+		ctx := c.UserContext()
+
+		// claim the key; losing the race means a response exists or is on its way
+		claimed, err := redis.SetNX(ctx, storeKey, idempotencyPending, idempotencyLockTTL).Result()
+		if err != nil {
+			// fail open, a duplicate is better than an outage
+			slog.LogAttrs(ctx, slog.LevelError, "Idempotency store unavailable", xslog.Error(err))
+			return c.Next()
+		}
+		if !claimed {
+			return replay(c, redis, storeKey, fingerprint)
+		}
+
+		if err := c.Next(); err != nil {
+			release(c, redis, storeKey)
+			return err
+		}
+		status := c.Response().StatusCode()
+		if status >= fiber.StatusInternalServerError {
+			release(c, redis, storeKey)
+			return nil
+		}
+
+		stored := idempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      status,
+			Headers:     map[string]string{},
+			Body:        c.Response().Body(),
+		}
+		for _, header := range replayedHeaders {
+			if value := c.GetRespHeader(header); value != "" {
+				stored.Headers[header] = value
+			}
+		}
+		data, err := gojson.Marshal(stored)
+		if err == nil {
+			err = redis.Set(ctx, storeKey, data, idempotencyLifetime).Err()
+		}
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to store idempotent response", xslog.Error(err))
+			release(c, redis, storeKey)
+		}
+		return nil
+	}
+}
+
+func replay(c *fiber.Ctx, redis *xredis.Client, storeKey string, fingerprint string) error {
+	data, err := redis.Get(c.UserContext(), storeKey).Bytes()
+	if errors.Is(err, xredis.ErrNil) {
+		// the original failed and released the key between our SET and GET
+		return fiber.NewError(fiber.StatusConflict, "a request with this Idempotency-Key is being retried, try again")
+	}
+	if err != nil {
+		return err
+	}
+	if string(data) == idempotencyPending {
+		return fiber.NewError(fiber.StatusConflict, "a request with this Idempotency-Key is still being processed")
+	}
+
+	var stored idempotentResponse
+	if err := gojson.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+	if stored.Fingerprint != fingerprint {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+	}
+
+	for header, value := range stored.Headers {
+		c.Set(header, value)
+	}
+	c.Set(IdempotentReplayedHeader, "true")
+	return c.Status(stored.Status).Send(stored.Body)
+}
+
+func release(c *fiber.Ctx, redis *xredis.Client, storeKey string) {
+	if err := redis.Del(c.UserContext(), storeKey).Err(); err != nil {
+		slog.LogAttrs(c.UserContext(), slog.LevelError, "Failed to release idempotency key", xslog.Error(err))
+	}
+}
+
+func requestFingerprint(c *fiber.Ctx) string {
+	sum := sha256.New()
+	sum.Write([]byte(c.Method()))
+	sum.Write([]byte(c.OriginalURL()))
+	sum.Write(c.Body())
+	return hex.EncodeToString(sum.Sum(nil))
+}
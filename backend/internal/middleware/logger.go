@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+Logger writes one structured access log line per request once the handler chain
+(and the error handler, if it returned an error) has produced a status code.
+The request id comes from the context RequestID set up. Fast, successful
+requests are sampled at cfg.AccessLogSampleRate, and successful requests to
+the quiet paths (health probes, hit every few seconds) aren't logged at all.
+The query string is never logged, as some routes take tokens there, and
+neither are the tokens some routes take in their path.
+*/
+func Logger(cfg config.HTTP, quiet ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		chainErr := c.Next()
+		if chainErr != nil {
+			// let the app's ErrorHandler set the final status before we log it
+			if err := c.App().ErrorHandler(c, chainErr); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		latency := time.Since(start)
+		if status < fiber.StatusBadRequest && latency < cfg.SlowRequest &&
+			(slices.Contains(quiet, c.Path()) || rand.Float64() >= cfg.AccessLogSampleRate) {
+			return nil
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Method()),
+			slog.String("path", loggedPath(c)),
+			slog.Int("status", status),
+			slog.Duration("latency", latency),
+			slog.String("ip", c.IP()),
+		}
+		if userID, ok := c.Locals("user_id").(string); ok && userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+
+		slog.LogAttrs(c.UserContext(), level, "Request handled", attrs...)
+		return nil
+	}
+}
+
+// secretParams are the route parameters that are credentials, like the calendar feed's /ics/:token
+var secretParams = []string{"token", "secret"}
+
+// loggedPath is the request's path for logs and traces: the route template when a parameter is a credential
+func loggedPath(c *fiber.Ctx) string {
+	route := c.Route()
+	for _, param := range route.Params {
+		if slices.Contains(secretParams, param) {
+			return route.Path
+		}
+	}
+	return c.Path()
+}
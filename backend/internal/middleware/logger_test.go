@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const feedToken = "cal-7f3a9c"
+
+func TestSecretParams(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	spans := tracetest.NewSpanRecorder()
+	defaultProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	t.Cleanup(func() { otel.SetTracerProvider(defaultProvider) })
+
+	app := fiber.New()
+	app.Use(Tracing(), Logger(config.HTTP{AccessLogSampleRate: 1}))
+	app.Get("/ics/:token", ok)
+	app.Get("/tasks/:id", ok)
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/ics/" + feedToken, "/ics/:token"},
+		{"/tasks/42", "/tasks/42"},
+	}
+	for _, tt := range tests {
+		logs.Reset()
+		if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.path, nil)); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(logs.String(), `"path":"`+tt.expected+`"`) {
+			t.Errorf("%s: expected the path logged as %s, got %s", tt.path, tt.expected, logs.String())
+		}
+		ended := spans.Ended()
+		if path := spanAttribute(ended[len(ended)-1], semconv.URLPathKey); path != tt.expected {
+			t.Errorf("%s: expected the span's url.path %s, got %q", tt.path, tt.expected, path)
+		}
+	}
+	if strings.Contains(logs.String(), feedToken) {
+		t.Errorf("token logged: %s", logs.String())
+	}
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == key {
+			return attr.Value.AsString()
+		}
+	}
+	return ""
+}
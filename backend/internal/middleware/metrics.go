@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+Metrics records request counts and latency per route template (e.g. /api/v1/Tasks/:id)
+rather than per raw path, so ids don't explode the label cardinality; requests
+no route matched count under the last middleware's, like "/". It runs inside
+Logger, before the error handler has written an error's status, so that status
+is predicted with statusOf.
+*/
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		route := c.Route().Path
+		method := c.Method()
+
+		xmetrics.HTTPRequests.WithLabelValues(route, method, strconv.Itoa(statusOf(c, err))).Inc()
+		xmetrics.HTTPDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// statusOf predicts the status the error handler will write for err
+func statusOf(c *fiber.Ctx, err error) int {
+	if err == nil {
+		return c.Response().StatusCode()
+	}
+	return xerr.Status(err)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	app := fiber.New()
+	app.Use(Logger(config.HTTP{}), Metrics())
+	app.Get("/metrics-test/tasks/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return xerr.New(fiber.StatusNotFound, xerr.CodeNotFound, "Task not found")
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for _, path := range []string{"/metrics-test/tasks/1", "/metrics-test/tasks/2", "/metrics-test/tasks/missing", "/metrics-test/nowhere"} {
+		if _, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		route    string
+		status   string
+		expected float64
+	}{
+		{"/metrics-test/tasks/:id", "200", 2},
+		// a route's own 404s stay its own
+		{"/metrics-test/tasks/:id", "404", 1},
+		// an unknown url counts under the middleware that last ran, not its own path
+		{"/", "404", 1},
+		{"/metrics-test/nowhere", "404", 0},
+	}
+	for _, tt := range tests {
+		if got := testutil.ToFloat64(xmetrics.HTTPRequests.WithLabelValues(tt.route, fiber.MethodGet, tt.status)); got != tt.expected {
+			t.Errorf("%s %s: expected %v requests, got %v", tt.route, tt.status, tt.expected, got)
+		}
+	}
+}
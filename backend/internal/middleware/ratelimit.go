@@ -0,0 +1,276 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xredis"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/abhikaboy/SocialToDo/internal/xtokens"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+
+	rateLimitPrefix = "ratelimit:"
+)
+
+// increments the window counter and returns it with the window's remaining ttl in ms
+var rateLimitScript = goredis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {count, redis.call('PTTL', KEYS[1])}
+`)
+
+/*
+RateLimiter hands out fixed-window rate limits. Buckets are per user for
+requests with a valid access token and per client IP otherwise. Counters
+live in Redis so every instance shares them; without Redis each instance
+counts on its own.
+*/
+type RateLimiter struct {
+	store rateStore
+	// checks access tokens' signatures; nil counts every request by IP
+	tokens *xtokens.Keyset
+	// paths that are never limited (probes, metrics)
+	skip []string
+}
+
+type rateStore interface {
+	// incr counts a hit in key's current window
+	incr(ctx context.Context, key string, window time.Duration) (count int64, reset time.Duration, err error)
+}
+
+func NewRateLimiter(redis *xredis.Client, tokens *xtokens.Keyset, skip ...string) *RateLimiter {
+	var store rateStore = &memoryRateStore{buckets: map[string]*memoryBucket{}}
+	if redis != nil {
+		store = &redisRateStore{redis}
+	}
+	return &RateLimiter{store: store, tokens: tokens, skip: skip}
+}
+
+/*
+Limit returns middleware allowing limit requests per window for each caller
+in the named bucket. Mount it on a route group with its own name to give the
+group a separate (usually stricter) budget.
+*/
+func (l *RateLimiter) Limit(name string, limit int, window time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if l.skipped(c) {
+			return c.Next()
+		}
+		return l.enforce(c, window, rateBucket{rateLimitPrefix + name + ":" + l.subject(c), limit})
+	}
+}
+
+/*
+RatePolicy is a route's own budget per window, counted separately for the
+client IP and for the user the request is for, so rotating accounts doesn't
+get around it. Zero leaves that side unlimited.
+*/
+type RatePolicy struct {
+	PerIP   int
+	PerUser int
+	Window  time.Duration
+}
+
+/*
+Policy returns middleware enforcing policy in the named bucket. The user is
+the access token's, or for signed out requests the email the JSON body is
+about (login, register, password resets) together with the client IP. The IP
+is part of that key since anyone can name an account: keyed on the email
+alone, a stranger spending its budget would lock its owner out.
+*/
+func (l *RateLimiter) Policy(name string, policy RatePolicy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if l.skipped(c) {
+			return c.Next()
+		}
+		var buckets []rateBucket
+		if policy.PerIP > 0 {
+			buckets = append(buckets, rateBucket{rateLimitPrefix + name + ":ip:" + c.IP(), policy.PerIP})
+		}
+		if user := l.user(c); policy.PerUser > 0 && user != "" {
+			buckets = append(buckets, rateBucket{rateLimitPrefix + name + ":" + user, policy.PerUser})
+		}
+		return l.enforce(c, policy.Window, buckets...)
+	}
+}
+
+type rateBucket struct {
+	key   string
+	limit int
+}
+
+/*
+enforce counts the request in every bucket and refuses it with 429 and
+Retry-After once any of them is spent. The rate limit headers describe the
+bucket closest to running out.
+*/
+func (l *RateLimiter) enforce(c *fiber.Ctx, window time.Duration, buckets ...rateBucket) error {
+	var (
+		tightest  *rateBucket
+		remaining int64
+		reset     time.Duration
+		exceeded  bool
+	)
+	for i, bucket := range buckets {
+		count, ttl, err := l.store.incr(c.UserContext(), bucket.key, window)
+		if err != nil {
+			// fail open, losing the limiter shouldn't take the API down
+			slog.LogAttrs(c.UserContext(), slog.LevelError, "Rate limiter unavailable", xslog.Error(err))
+			return c.Next()
+		}
+		left := int64(bucket.limit) - count
+		if tightest == nil || left < remaining {
+			tightest, remaining, reset = &buckets[i], left, ttl
+		}
+		exceeded = exceeded || left < 0
+	}
+	if tightest == nil {
+		return c.Next()
+	}
+
+	resetSeconds := strconv.Itoa(int((reset + time.Second - 1) / time.Second))
+	c.Set(RateLimitLimitHeader, strconv.Itoa(tightest.limit))
+	c.Set(RateLimitRemainingHeader, strconv.FormatInt(max(remaining, 0), 10))
+	c.Set(RateLimitResetHeader, resetSeconds)
+
+	if exceeded {
+		c.Set(fiber.HeaderRetryAfter, resetSeconds)
+		return fiber.NewError(fiber.StatusTooManyRequests, "Too many requests, retry in "+resetSeconds+"s")
+	}
+	return c.Next()
+}
+
+// skipped reports whether the path is one that is never limited
+func (l *RateLimiter) skipped(c *fiber.Ctx) bool {
+	for _, path := range l.skip {
+		if c.Path() == path {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+subject identifies the caller, by user for a valid access token and by IP
+otherwise.
+*/
+func (l *RateLimiter) subject(c *fiber.Ctx) string {
+	if userID := l.tokenUser(c); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.IP()
+}
+
+// user is who the request is for: the token's user, or the account (email or phone) a signed out body names from the client's IP
+func (l *RateLimiter) user(c *fiber.Ctx) string {
+	if userID := l.tokenUser(c); userID != "" {
+		return "user:" + userID
+	}
+	if !c.Is("json") {
+		return ""
+	}
+	var body struct {
+		Email string `json:"email"`
+		Phone string `json:"phone"`
+	}
+	if err := gojson.Unmarshal(c.Body(), &body); err != nil {
+		return ""
+	}
+	account := strings.ToLower(strings.TrimSpace(body.Email))
+	if account == "" {
+		account = strings.TrimSpace(body.Phone)
+	}
+	if account == "" {
+		return ""
+	}
+	return "account:" + account + ":" + c.IP()
+}
+
+/*
+tokenUser reads the user from the access token. Its signature is checked (not
+its revocation, that's the auth middleware's job) so a forged token can't
+spend someone else's budget, and refresh tokens don't count.
+*/
+func (l *RateLimiter) tokenUser(c *fiber.Ctx) string {
+	header := c.Get(fiber.HeaderAuthorization)
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || l.tokens == nil {
+		return ""
+	}
+	claims := jwt.MapClaims{}
+	parsed, err := l.tokens.Parse(token, claims)
+	if err != nil || !parsed.Valid {
+		return ""
+	}
+	if typ, _ := claims["typ"].(string); typ == "refresh" {
+		return ""
+	}
+	userID, _ := claims["user_id"].(string)
+	return userID
+}
+
+type redisRateStore struct {
+	redis *xredis.Client
+}
+
+func (s *redisRateStore) incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	values, err := rateLimitScript.Run(ctx, s.redis, []string{key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	count, ttl := values[0], values[1]
+	if ttl < 0 {
+		ttl = window.Milliseconds()
+	}
+	return count, time.Duration(ttl) * time.Millisecond, nil
+}
+
+type memoryRateStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	hits    int
+}
+
+type memoryBucket struct {
+	count   int64
+	expires time.Time
+}
+
+func (s *memoryRateStore) incr(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	// sweep expired windows now and then so idle callers don't accumulate
+	s.hits++
+	if s.hits%1000 == 0 {
+		for k, b := range s.buckets {
+			if now.After(b.expires) {
+				delete(s.buckets, k)
+			}
+		}
+	}
+
+	b, ok := s.buckets[key]
+	if !ok || now.After(b.expires) {
+		b = &memoryBucket{expires: now.Add(window)}
+		s.buckets[key] = b
+	}
+	b.count++
+	return b.count, b.expires.Sub(now), nil
+}
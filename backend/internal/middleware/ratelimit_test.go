@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/xtokens"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func TestRateLimit(t *testing.T) {
+	limiter := NewRateLimiter(nil, xtokens.New(config.Tokens{}, testSecret), "/health")
+	app := fiber.New()
+	app.Use(limiter.Limit("global", 2, time.Minute))
+	app.Get("/", ok)
+	app.Get("/health", ok)
+
+	for i, expected := range []int{fiber.StatusOK, fiber.StatusOK, fiber.StatusTooManyRequests} {
+		res := request(t, app, "/", nil)
+		if res.StatusCode != expected {
+			t.Fatalf("request %d: expected %d, got %d", i, expected, res.StatusCode)
+		}
+		if remaining := res.Header.Get(RateLimitRemainingHeader); remaining != strconv.Itoa(max(1-i, 0)) {
+			t.Errorf("request %d: unexpected %s %q", i, RateLimitRemainingHeader, remaining)
+		}
+	}
+	if res := request(t, app, "/", nil); res.Header.Get(fiber.HeaderRetryAfter) == "" {
+		t.Error("expected Retry-After on a refused request")
+	}
+	if res := request(t, app, "/health", nil); res.StatusCode != fiber.StatusOK {
+		t.Errorf("skipped path: expected 200, got %d", res.StatusCode)
+	}
+
+	// a signed in caller has a bucket of their own
+	if res := request(t, app, "/", map[string]string{fiber.HeaderAuthorization: "Bearer " + token(t, testSecret, "alice")}); res.StatusCode != fiber.StatusOK {
+		t.Errorf("signed in: expected 200, got %d", res.StatusCode)
+	}
+	// a token signed with another secret doesn't get one
+	if res := request(t, app, "/", map[string]string{fiber.HeaderAuthorization: "Bearer " + token(t, "forged", "bob")}); res.StatusCode != fiber.StatusTooManyRequests {
+		t.Errorf("forged token: expected 429, got %d", res.StatusCode)
+	}
+}
+
+func TestRateLimitBehindProxy(t *testing.T) {
+	limiter := NewRateLimiter(nil, xtokens.New(config.Tokens{}, testSecret))
+	app := fiber.New(fiber.Config{
+		ProxyHeader:             "X-Real-IP",
+		EnableTrustedProxyCheck: true,
+		// app.Test connects from 0.0.0.0
+		TrustedProxies:     []string{"0.0.0.0"},
+		EnableIPValidation: true,
+	})
+	app.Use(limiter.Limit("global", 1, time.Minute))
+	app.Get("/", ok)
+
+	for _, ip := range []string{"203.0.113.1", "203.0.113.2"} {
+		if res := request(t, app, "/", map[string]string{"X-Real-IP": ip}); res.StatusCode != fiber.StatusOK {
+			t.Errorf("%s: expected 200, got %d", ip, res.StatusCode)
+		}
+	}
+	if res := request(t, app, "/", map[string]string{"X-Real-IP": "203.0.113.1"}); res.StatusCode != fiber.StatusTooManyRequests {
+		t.Errorf("repeat: expected 429, got %d", res.StatusCode)
+	}
+}
+
+func TestRatePolicy(t *testing.T) {
+	limiter := NewRateLimiter(nil, xtokens.New(config.Tokens{}, testSecret))
+	app := fiber.New(fiber.Config{
+		ProxyHeader:             "X-Real-IP",
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"0.0.0.0"},
+		EnableIPValidation:      true,
+	})
+	app.Post("/login", limiter.Policy("login", RatePolicy{PerIP: 4, PerUser: 2, Window: time.Minute}), ok)
+
+	login := func(ip string, email string) int {
+		req, err := http.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"email": "`+email+`"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		req.Header.Set("X-Real-IP", ip)
+		res, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.StatusCode
+	}
+
+	tests := []struct {
+		name     string
+		ip       string
+		email    string
+		expected int
+	}{
+		{"attacker", "203.0.113.1", "victim@example.com", fiber.StatusOK},
+		{"attacker again", "203.0.113.1", "Victim@Example.com", fiber.StatusOK},
+		{"attacker spent the account's budget", "203.0.113.1", "victim@example.com", fiber.StatusTooManyRequests},
+		// the attacker naming the account doesn't lock its owner out
+		{"victim", "198.51.100.7", "victim@example.com", fiber.StatusOK},
+		{"attacker on another account", "203.0.113.1", "other@example.com", fiber.StatusOK},
+		{"attacker spent the IP's budget", "203.0.113.1", "third@example.com", fiber.StatusTooManyRequests},
+		{"victim again", "198.51.100.7", "victim@example.com", fiber.StatusOK},
+		{"victim spent their own budget", "198.51.100.7", "victim@example.com", fiber.StatusTooManyRequests},
+	}
+	for _, tt := range tests {
+		if code := login(tt.ip, tt.email); code != tt.expected {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.expected, code)
+		}
+	}
+}
+
+func ok(c *fiber.Ctx) error {
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func token(t *testing.T, secret string, userID string) string {
+	t.Helper()
+	signed, err := xtokens.New(config.Tokens{}, secret).Sign(jwt.MapClaims{
+		"user_id": userID,
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func request(t *testing.T, app *fiber.App, route string, headers map[string]string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, route, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	// kept identical to fiber's requestid middleware so ${locals:requestid} keeps working
+	RequestIDKey = "requestid"
+)
+
+/*
+RequestID reuses the X-Request-ID sent by the caller (load balancer, mobile client)
+or generates a new one, echoes it back on the response and attaches it to the
+slog context so every log line for the request can be correlated.
+*/
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+		}
+
+		c.Set(RequestIDHeader, id)
+		c.Locals(RequestIDKey, id)
+		xslog.AddAttrs(c, slog.String("request_id", id))
+
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the id assigned by RequestID, or "" if the middleware did not run
+func GetRequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(RequestIDKey).(string)
+	return id
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+Timeout puts a deadline on the request's user context. Services run their
+Mongo calls on c.UserContext(), so a slow query gives up with
+context.DeadlineExceeded instead of holding a Fiber worker. Long lived
+connections (the event stream, websockets) are skipped by path prefix.
+*/
+func Timeout(d time.Duration, skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if d <= 0 {
+			return c.Next()
+		}
+		for _, prefix := range skip {
+			if strings.HasPrefix(c.Path(), prefix) {
+				return c.Next()
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), d)
+		defer cancel()
+		c.SetUserContext(ctx)
+
+		return c.Next()
+	}
+}
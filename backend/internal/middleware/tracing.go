@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/abhikaboy/SocialToDo/internal/xtrace"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+Tracing starts a server span per request, continuing the trace named in the
+caller's traceparent header, and puts it on the user context so the MongoDB
+spans of the handler's queries become its children. The trace id is added to
+the request's log attributes. Register it between RequestID and Logger: it
+reads the status once Logger has run the error handler.
+*/
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := xtrace.Tracer().Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.ClientAddress(c.IP()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+		if span.SpanContext().IsValid() {
+			xslog.AddAttrs(c, slog.String("trace_id", span.SpanContext().TraceID().String()))
+		}
+
+		err := c.Next()
+
+		// the route template is only known once routing has matched, and with it which parameters are secret
+		route := c.Route().Path
+		span.SetName(c.Method() + " " + route)
+		status := statusOf(c, err)
+		span.SetAttributes(semconv.URLPath(loggedPath(c)), semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+			if err != nil {
+				span.RecordError(err)
+			}
+		}
+		return err
+	}
+}
+
+// headerCarrier reads the request's headers for the propagator
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key string, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+RequireVerifiedEmail keeps users who haven't verified their email from
+reaching other people, through friend requests or public posts. Accounts from
+before verification have no email_verified field and are let through. It
+reads the caller from the auth middleware, so it has to run after it.
+*/
+func RequireVerifiedEmail(users *mongo.Collection) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id, _ := c.Locals("user_id").(string)
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return fiber.NewError(fiber.StatusForbidden, "Verify your email first")
+		}
+		count, err := users.CountDocuments(c.UserContext(), bson.M{"_id": oid, "email_verified": false})
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return fiber.NewError(fiber.StatusForbidden, "Verify your email first")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Registration used to give every account the handle "@default", which blocks the
+unique handle index. Give those accounts the same id-derived placeholder new
+registrations get.
+*/
+func init() {
+	register(Migration{
+		Version: 1,
+		Name:    "unique_default_handles",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			users := db.Collection("users")
+			cursor, err := users.Find(ctx, bson.M{"handle": "@default"})
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				var user struct {
+					ID primitive.ObjectID `bson:"_id"`
+				}
+				if err := cursor.Decode(&user); err != nil {
+					return err
+				}
+				_, err := users.UpdateOne(ctx,
+					bson.M{"_id": user.ID},
+					bson.M{"$set": bson.M{"handle": "@user" + user.ID.Hex()}},
+				)
+				if err != nil {
+					return fmt.Errorf("failed to update handle for %s: %w", user.ID.Hex(), err)
+				}
+			}
+			return cursor.Err()
+		},
+		// the old value was a shared placeholder, there's nothing meaningful to restore
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return nil
+		},
+	})
+}
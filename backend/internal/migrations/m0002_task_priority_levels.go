@@ -0,0 +1,75 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Task priorities ran from 1 (low) to 3 (high) and now run from P0, the most
+urgent, to P3. 3 becomes P1 and 1 becomes P3, leaving P0 to tasks marked
+urgent from now on. Tasks without a priority, which used to sort below low,
+become P3 as well. Inbound hooks store a priority for the tasks they create
+and are flipped the same way.
+*/
+func init() {
+	register(Migration{
+		Version: 2,
+		Name:    "task_priority_levels",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			return setPriorities(ctx, db, func(p any) bson.M {
+				return bson.M{"$cond": bson.A{
+					bson.M{"$in": bson.A{p, bson.A{1, 2, 3}}},
+					bson.M{"$subtract": bson.A{4, p}},
+					3,
+				}}
+			})
+		},
+		// P0 has no old level of its own and goes back to high with P1
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return setPriorities(ctx, db, func(p any) bson.M {
+				return bson.M{"$cond": bson.A{
+					bson.M{"$eq": bson.A{p, 0}},
+					3,
+					bson.M{"$subtract": bson.A{4, p}},
+				}}
+			})
+		},
+	})
+}
+
+// setPriorities rewrites every task's and inbound hook's priority with the expression level builds from the old one
+func setPriorities(ctx context.Context, db *mongo.Database, level func(p any) bson.M) error {
+	_, err := db.Collection("users").UpdateMany(ctx,
+		bson.M{"categories.tasks.0": bson.M{"$exists": true}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"categories": bson.M{"$map": bson.M{
+				"input": "$categories",
+				"as":    "c",
+				"in": bson.M{"$mergeObjects": bson.A{"$$c", bson.M{
+					"tasks": bson.M{"$map": bson.M{
+						"input": bson.M{"$ifNull": bson.A{"$$c.tasks", bson.A{}}},
+						"as":    "t",
+						"in": bson.M{"$mergeObjects": bson.A{"$$t", bson.M{
+							"priority": level(bson.M{"$ifNull": bson.A{"$$t.priority", 0}}),
+						}}},
+					}},
+				}}},
+			}},
+		}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// hooks without a priority create DefaultPriority tasks, before and after
+	_, err = db.Collection("inbound_hooks").UpdateMany(ctx,
+		bson.M{"mapping.priority": bson.M{"$in": bson.A{0, 1, 2, 3}}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"mapping.priority": level("$mapping.priority"),
+		}}}},
+	)
+	return err
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Stats are summed from the completions collection, which only hears of tasks
+completed since it was added. Fill it in from the tasks completed before, all
+of them credited to the task's owner since collaborators weren't recorded.
+*/
+func init() {
+	register(Migration{
+		Version: 3,
+		Name:    "backfill_completions",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			cursor, err := db.Collection("users").Aggregate(ctx, mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{"categories.tasks.completed_at": bson.M{"$type": "date"}}}},
+				{{Key: "$unwind", Value: "$categories"}},
+				{{Key: "$unwind", Value: "$categories.tasks"}},
+				{{Key: "$match", Value: bson.M{"categories.tasks.completed_at": bson.M{"$type": "date"}}}},
+				{{Key: "$project", Value: bson.M{
+					"_id":          "$categories.tasks._id",
+					"user":         "$_id",
+					"completed_at": "$categories.tasks.completed_at",
+				}}},
+				{{Key: "$merge", Value: bson.M{
+					"into":           "completions",
+					"on":             "_id",
+					"whenMatched":    "keepExisting",
+					"whenNotMatched": "insert",
+				}}},
+			})
+			if err != nil {
+				return err
+			}
+			return cursor.Close(ctx)
+		},
+		// completions recorded since can't be told apart from the backfilled ones, so they all stay
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return nil
+		},
+	})
+}
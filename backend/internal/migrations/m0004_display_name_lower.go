@@ -0,0 +1,55 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+User search matches a prefix of display_name_lower, through the
+users_display_name_lower index, instead of a case-insensitive regex on
+display_name, which no index can answer. Fill it in for the users from before.
+It is lowercased here rather than with $toLower, which only handles ASCII, so
+it agrees with what the repository writes.
+*/
+func init() {
+	register(Migration{
+		Version: 4,
+		Name:    "display_name_lower",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			users := db.Collection("users")
+			cursor, err := users.Find(ctx, bson.M{"display_name_lower": bson.M{"$exists": false}})
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				var user struct {
+					ID          primitive.ObjectID `bson:"_id"`
+					DisplayName string             `bson:"display_name"`
+				}
+				if err := cursor.Decode(&user); err != nil {
+					return err
+				}
+				_, err := users.UpdateOne(ctx,
+					bson.M{"_id": user.ID},
+					bson.M{"$set": bson.M{"display_name_lower": strings.ToLower(user.DisplayName)}},
+				)
+				if err != nil {
+					return fmt.Errorf("failed to set display_name_lower for %s: %w", user.ID.Hex(), err)
+				}
+			}
+			return cursor.Err()
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("users").UpdateMany(ctx, bson.M{}, bson.M{"$unset": bson.M{"display_name_lower": ""}})
+			return err
+		},
+	})
+}
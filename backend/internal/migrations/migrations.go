@@ -0,0 +1,244 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Versioned data migrations. Each migration lives in its own file (mXXXX_name.go)
+and registers itself in init(). Applied versions are recorded in the
+"migrations" collection so every environment can be brought to the same state.
+*/
+
+const (
+	Collection = "migrations"
+	// LockCollection holds the one lock document, so two processes never migrate at once
+	LockCollection = "migration_lock"
+	// how long a lock outlives a runner that died holding it
+	lockLease = 10 * time.Minute
+)
+
+// ErrLocked means another process is migrating the database
+var ErrLocked = errors.New("migrations: another process holds the migration lock")
+
+type Func func(ctx context.Context, db *mongo.Database) error
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      Func
+	// Down may be nil for migrations that cannot be reversed
+	Down Func
+}
+
+type Record struct {
+	Version   int       `bson:"_id" json:"version"`
+	Name      string    `bson:"name" json:"name"`
+	AppliedAt time.Time `bson:"applied_at" json:"applied_at"`
+}
+
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+var registry = map[int]Migration{}
+
+func register(m Migration) {
+	if _, ok := registry[m.Version]; ok {
+		panic(fmt.Sprintf("migrations: version %d registered twice", m.Version))
+	}
+	registry[m.Version] = m
+}
+
+// Registered returns every known migration ordered by version
+func Registered() []Migration {
+	list := make([]Migration, 0, len(registry))
+	for _, m := range registry {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+type Runner struct {
+	db         *mongo.Database
+	records    *mongo.Collection
+	locks      *mongo.Collection
+	owner      string
+	migrations []Migration
+}
+
+func NewRunner(db *mongo.Database) *Runner {
+	host, _ := os.Hostname()
+	return &Runner{
+		db:         db,
+		records:    db.Collection(Collection),
+		locks:      db.Collection(LockCollection),
+		owner:      host + ":" + strconv.Itoa(os.Getpid()),
+		migrations: Registered(),
+	}
+}
+
+/*
+lock takes the migration lock, or fails with ErrLocked while someone else
+holds an unexpired one. Instances starting together then apply each
+migration once rather than racing to record it.
+*/
+func (r *Runner) lock(ctx context.Context) (func(), error) {
+	now := time.Now()
+	_, err := r.locks.UpdateOne(ctx,
+		bson.M{"_id": "lock", "locked_until": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"locked_by": r.owner, "locked_until": now.Add(lockLease)}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// the upsert lost to a live lock
+		return nil, ErrLocked
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to take the migration lock: %w", err)
+	}
+	return func() {
+		// a lock left behind expires with its lease
+		_, err := r.locks.UpdateOne(context.WithoutCancel(ctx),
+			bson.M{"_id": "lock", "locked_by": r.owner},
+			bson.M{"$set": bson.M{"locked_until": time.Time{}}},
+		)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to release the migration lock", xslog.Error(err))
+		}
+	}, nil
+}
+
+func (r *Runner) applied(ctx context.Context) (map[int]Record, error) {
+	cursor, err := r.records.Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied migrations: %w", err)
+	}
+	var records []Record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode applied migrations: %w", err)
+	}
+	applied := make(map[int]Record, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Status lists every registered migration with whether it has been applied
+func (r *Runner) Status(ctx context.Context) ([]Status, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, 0, len(r.migrations))
+	for _, m := range r.migrations {
+		status := Status{Version: m.Version, Name: m.Name}
+		if record, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &record.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+/*
+Up applies every pending migration with a version <= target, in order.
+A target of 0 means "latest". Stops at the first failure.
+*/
+func (r *Runner) Up(ctx context.Context, target int) ([]int, error) {
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := make([]int, 0)
+	for _, m := range r.migrations {
+		if target > 0 && m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		start := time.Now()
+		if err := m.Up(ctx, r.db); err != nil {
+			return done, fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		record := Record{Version: m.Version, Name: m.Name, AppliedAt: time.Now()}
+		if _, err := r.records.InsertOne(ctx, record); err != nil {
+			return done, fmt.Errorf("migration %d (%s) ran but could not be recorded: %w", m.Version, m.Name, err)
+		}
+
+		slog.LogAttrs(
+			ctx,
+			slog.LevelInfo,
+			"Migration applied",
+			slog.Int("version", m.Version),
+			slog.String("name", m.Name),
+			slog.Duration("took", time.Since(start)),
+		)
+		done = append(done, m.Version)
+	}
+	return done, nil
+}
+
+/*
+Down reverts the most recently applied migrations, newest first.
+*/
+func (r *Runner) Down(ctx context.Context, steps int) ([]int, error) {
+	unlock, err := r.lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reverted := make([]int, 0)
+	for i := len(r.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		m := r.migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == nil {
+			return reverted, fmt.Errorf("migration %d (%s) is irreversible", m.Version, m.Name)
+		}
+		if err := m.Down(ctx, r.db); err != nil {
+			return reverted, fmt.Errorf("reverting migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		if _, err := r.records.DeleteOne(ctx, bson.M{"_id": m.Version}); err != nil {
+			return reverted, fmt.Errorf("migration %d (%s) reverted but record not removed: %w", m.Version, m.Name, err)
+		}
+
+		slog.LogAttrs(ctx, slog.LevelInfo, "Migration reverted", slog.Int("version", m.Version), slog.String("name", m.Name))
+		reverted = append(reverted, m.Version)
+	}
+	return reverted, nil
+}
@@ -0,0 +1,138 @@
+//go:build integration
+
+package migrations
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo/mongotest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// newRunner is a runner on a fresh database with its own migrations, recording which ran
+func newRunner(t *testing.T, versions ...int) (*Runner, *[]string) {
+	t.Helper()
+	var ran []string
+	runner := NewRunner(mongotest.Database(t).DB)
+	runner.migrations = nil
+	for _, version := range versions {
+		runner.migrations = append(runner.migrations, Migration{
+			Version: version,
+			Name:    "step",
+			Up: func(ctx context.Context, db *mongo.Database) error {
+				ran = append(ran, "up "+strconv.Itoa(version))
+				return nil
+			},
+			Down: func(ctx context.Context, db *mongo.Database) error {
+				ran = append(ran, "down "+strconv.Itoa(version))
+				return nil
+			},
+		})
+	}
+	return runner, &ran
+}
+
+func TestUpAndDown(t *testing.T) {
+	runner, ran := newRunner(t, 1, 2, 3)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		run      func() ([]int, error)
+		expected []int
+	}{
+		{"up to a target", func() ([]int, error) { return runner.Up(ctx, 2) }, []int{1, 2}},
+		{"up to latest", func() ([]int, error) { return runner.Up(ctx, 0) }, []int{3}},
+		{"nothing pending", func() ([]int, error) { return runner.Up(ctx, 0) }, []int{}},
+		{"down newest first", func() ([]int, error) { return runner.Down(ctx, 2) }, []int{3, 2}},
+		{"up again", func() ([]int, error) { return runner.Up(ctx, 0) }, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		versions, err := tt.run()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !slices.Equal(versions, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, versions)
+		}
+	}
+
+	expected := []string{"up 1", "up 2", "up 3", "down 3", "down 2", "up 2", "up 3"}
+	if !slices.Equal(*ran, expected) {
+		t.Errorf("expected %v, got %v", expected, *ran)
+	}
+	statuses, err := runner.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if !status.Applied || status.AppliedAt == nil {
+			t.Errorf("expected %d applied, got %+v", status.Version, status)
+		}
+	}
+}
+
+func TestIrreversible(t *testing.T) {
+	runner, _ := newRunner(t, 1, 2)
+	runner.migrations[1].Down = nil
+	ctx := context.Background()
+	if _, err := runner.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	reverted, err := runner.Down(ctx, 2)
+	if err == nil || len(reverted) != 0 {
+		t.Errorf("expected the irreversible migration to stop the rollback, got %v %v", reverted, err)
+	}
+	if statuses, _ := runner.Status(ctx); !statuses[1].Applied {
+		t.Error("expected the irreversible migration left applied")
+	}
+}
+
+func TestFailedMigration(t *testing.T) {
+	runner, _ := newRunner(t, 1, 2, 3)
+	failure := errors.New("boom")
+	runner.migrations[1].Up = func(ctx context.Context, db *mongo.Database) error { return failure }
+	ctx := context.Background()
+
+	applied, err := runner.Up(ctx, 0)
+	if !errors.Is(err, failure) || !slices.Equal(applied, []int{1}) {
+		t.Errorf("expected to stop after 1 with the failure, got %v %v", applied, err)
+	}
+	count, err := runner.records.CountDocuments(ctx, bson.M{})
+	if err != nil || count != 1 {
+		t.Errorf("expected only 1 recorded, got %d %v", count, err)
+	}
+}
+
+func TestLock(t *testing.T) {
+	runner, ran := newRunner(t, 1)
+	ctx := context.Background()
+
+	other := *runner
+	other.owner = "other"
+	unlock, err := other.lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runner.Up(ctx, 0); !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked while another runner migrates, got %v", err)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("expected nothing run, got %v", *ran)
+	}
+
+	unlock()
+	if applied, err := runner.Up(ctx, 0); err != nil || len(applied) != 1 {
+		t.Errorf("expected the lock free once released, got %v %v", applied, err)
+	}
+	// and released again after the run
+	if _, err := other.lock(ctx); err != nil {
+		t.Errorf("expected the lock released after Up, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Notifications are the entries of a user's in-app inbox. Each carries a key
+that is unique per user, so whatever writes them can run again (a scheduler
+retrying a scan) without notifying twice. They expire after Retention (TTL
+index in xmongo.Indexes).
+*/
+
+const (
+	Collection = "notifications"
+	Retention  = 90 * 24 * time.Hour
+)
+
+type Type string
+
+const (
+	TaskDue        Type = "task_due"
+	FriendRequest  Type = "friend_request"
+	FriendAccepted Type = "friend_accepted"
+	// a friend completed one of their public tasks
+	FriendCompleted Type = "friend_completed"
+	// someone commented on the user's task
+	TaskComment Type = "task_comment"
+	// someone @mentioned the user in a comment
+	CommentMention Type = "comment_mention"
+	// a friend reacted to the user's activity
+	ActivityReaction Type = "activity_reaction"
+	// the user's data export can be downloaded
+	ExportReady Type = "export_ready"
+)
+
+type Notification struct {
+	ID   primitive.ObjectID `bson:"_id" json:"id"`
+	User primitive.ObjectID `bson:"user" json:"-"`
+	Type Type               `bson:"type" json:"type"`
+	// what the notification is about, e.g. "task_due:<task id>:<due unix>"
+	Key     string              `bson:"key" json:"-"`
+	Title   string              `bson:"title" json:"title"`
+	TaskID  *primitive.ObjectID `bson:"task_id,omitempty" json:"task_id,omitempty"`
+	DueDate *time.Time          `bson:"due_date,omitempty" json:"due_date,omitempty"`
+	Emoji   string              `bson:"emoji,omitempty" json:"emoji,omitempty"`
+	// the export, for ExportReady
+	ExportID *primitive.ObjectID `bson:"export_id,omitempty" json:"export_id,omitempty"`
+	// the other user, for the friend notifications
+	FromUser  *primitive.ObjectID `bson:"from_user,omitempty" json:"from_user,omitempty"`
+	CreatedAt time.Time           `bson:"created_at" json:"created_at"`
+	ReadAt    *time.Time          `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	// set by whichever instance claimed sending the push
+	PushedAt *time.Time `bson:"pushed_at,omitempty" json:"-"`
+}
+
+// Insert stores the notifications whose key the user doesn't have yet and returns how many that was
+func Insert(ctx context.Context, notifications *mongo.Collection, docs ...Notification) (int, error) {
+	if notifications == nil || len(docs) == 0 {
+		return 0, nil
+	}
+	batch := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		batch = append(batch, doc)
+	}
+	result, err := notifications.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicates(err) {
+		return 0, err
+	}
+	if result == nil {
+		return 0, nil
+	}
+	return len(result.InsertedIDs), nil
+}
+
+func onlyDuplicates(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,78 @@
+package notifications
+
+import (
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xpush"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const DeviceCollection = "devices"
+
+// Device is a phone the user's pushes go to; a token belongs to one user at a time
+type Device struct {
+	ID         primitive.ObjectID `bson:"_id" json:"id"`
+	User       primitive.ObjectID `bson:"user" json:"-"`
+	Platform   xpush.Platform     `bson:"platform" json:"platform"`
+	Token      string             `bson:"token" json:"token"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastSeenAt time.Time          `bson:"last_seen_at" json:"last_seen_at"`
+}
+
+/*
+Preferences is the notification_preferences sub-document of a user and
+decides which notifications are pushed to their devices; the inbox keeps
+everything. Unset switches are on.
+*/
+type Preferences struct {
+	// off stops every push
+	Push           *bool `bson:"push,omitempty" json:"push"`
+	FriendRequests *bool `bson:"friend_requests,omitempty" json:"friend_requests"`
+	// friends completing their public tasks
+	FriendActivity *bool `bson:"friend_activity,omitempty" json:"friend_activity"`
+	Reminders      *bool `bson:"reminders,omitempty" json:"reminders"`
+	// comments on the user's tasks and mentions in comments
+	Comments *bool `bson:"comments,omitempty" json:"comments"`
+	// reactions to the user's activity
+	Reactions *bool `bson:"reactions,omitempty" json:"reactions"`
+}
+
+// Pushes reports whether a notification of type t goes out as a push
+func (p Preferences) Pushes(t Type) bool {
+	if !on(p.Push) {
+		return false
+	}
+	switch t {
+	case FriendRequest, FriendAccepted:
+		return on(p.FriendRequests)
+	case FriendCompleted:
+		return on(p.FriendActivity)
+	case TaskDue:
+		return on(p.Reminders)
+	case TaskComment, CommentMention:
+		return on(p.Comments)
+	case ActivityReaction:
+		return on(p.Reactions)
+	}
+	return true
+}
+
+// Resolved fills in the defaults, as the preferences are shown to their owner
+func (p Preferences) Resolved() Preferences {
+	resolve := func(b *bool) *bool {
+		v := on(b)
+		return &v
+	}
+	return Preferences{
+		Push:           resolve(p.Push),
+		FriendRequests: resolve(p.FriendRequests),
+		FriendActivity: resolve(p.FriendActivity),
+		Reminders:      resolve(p.Reminders),
+		Comments:       resolve(p.Comments),
+		Reactions:      resolve(p.Reactions),
+	}
+}
+
+func on(b *bool) bool {
+	return b == nil || *b
+}
@@ -0,0 +1,145 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+The outbox makes side effects of a write (feed entries, notifications,
+gamification) as durable as the write itself. A service inserts the event
+into the outbox in the same transaction as its domain change, and the relay
+publishes committed events to the in-process bus. If the process dies after
+the commit the event is still delivered, by whichever instance's relay gets
+to it first; delivery is at-least-once, so consumers must tolerate repeats.
+*/
+
+const (
+	Collection = "outbox"
+	// published events are kept this long (TTL index in xmongo.Indexes)
+	Retention = 7 * 24 * time.Hour
+
+	pollInterval = time.Second
+	// a relay that claimed an event and died releases it after this
+	claimLease = 30 * time.Second
+)
+
+type Message struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	Type        events.Type        `bson:"type"`
+	UserID      string             `bson:"user_id"`
+	Collection  string             `bson:"collection,omitempty"`
+	DocumentID  string             `bson:"document_id,omitempty"`
+	Payload     bson.Raw           `bson:"payload,omitempty"`
+	OccurredAt  time.Time          `bson:"occurred_at"`
+	LockedUntil time.Time          `bson:"locked_until"`
+	Attempts    int                `bson:"attempts"`
+	PublishedAt *time.Time         `bson:"published_at"`
+}
+
+/*
+Write adds event to the outbox. Pass the mongo.SessionContext of the
+transaction making the domain change so both commit or neither does.
+event.Payload must marshal to a BSON document.
+*/
+func Write(ctx context.Context, outbox *mongo.Collection, event events.Event) error {
+	message := Message{
+		ID:         primitive.NewObjectID(),
+		Type:       event.Type,
+		UserID:     event.UserID,
+		Collection: event.Collection,
+		DocumentID: event.DocumentID,
+		OccurredAt: event.OccurredAt,
+	}
+	if message.OccurredAt.IsZero() {
+		message.OccurredAt = time.Now()
+	}
+	if event.Payload != nil {
+		raw, err := bson.Marshal(event.Payload)
+		if err != nil {
+			return err
+		}
+		message.Payload = raw
+	}
+	_, err := outbox.InsertOne(ctx, message)
+	return err
+}
+
+// Relay publishes committed outbox events to the bus, oldest first
+type Relay struct {
+	outbox *mongo.Collection
+	bus    *events.Bus
+}
+
+func NewRelay(outbox *mongo.Collection, bus *events.Bus) *Relay {
+	return &Relay{outbox: outbox, bus: bus}
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		// drain everything pending before going back to sleep
+		for {
+			published, err := r.relayOne(ctx)
+			if err != nil && ctx.Err() == nil {
+				slog.LogAttrs(ctx, slog.LevelError, "Failed to relay outbox event", xslog.Error(err))
+			}
+			if !published || err != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (r *Relay) relayOne(ctx context.Context) (bool, error) {
+	now := time.Now()
+	var message Message
+	err := r.outbox.FindOneAndUpdate(ctx,
+		bson.M{"published_at": nil, "locked_until": bson.M{"$lte": now}},
+		bson.M{
+			"$set": bson.M{"locked_until": now.Add(claimLease)},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "_id", Value: 1}}).SetReturnDocument(options.After),
+	).Decode(&message)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	event := events.Event{
+		ID:         message.ID.Hex(),
+		Type:       message.Type,
+		UserID:     message.UserID,
+		Collection: message.Collection,
+		DocumentID: message.DocumentID,
+		OccurredAt: message.OccurredAt,
+	}
+	if len(message.Payload) > 0 {
+		var payload bson.M
+		if err := bson.Unmarshal(message.Payload, &payload); err != nil {
+			return false, err
+		}
+		event.Payload = payload
+	}
+	r.bus.Publish(ctx, event)
+
+	_, err = r.outbox.UpdateOne(context.WithoutCancel(ctx), bson.M{"_id": message.ID},
+		bson.M{"$set": bson.M{"published_at": time.Now()}})
+	return true, err
+}
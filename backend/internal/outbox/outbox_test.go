@@ -0,0 +1,145 @@
+//go:build integration
+
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo/mongotest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// newRelay is a relay on a fresh outbox, with the events it publishes
+func newRelay(t *testing.T) (*Relay, *[]events.Event) {
+	t.Helper()
+	outbox := mongotest.Database(t).Collections[Collection]
+	bus := events.NewBus()
+	var published []events.Event
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		published = append(published, event)
+	})
+	return NewRelay(outbox, bus), &published
+}
+
+// drain relays until nothing is pending, like one pass of Run
+func drain(t *testing.T, relay *Relay) {
+	t.Helper()
+	for {
+		ok, err := relay.relayOne(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+func TestRelay(t *testing.T) {
+	relay, published := newRelay(t)
+	ctx := context.Background()
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	written := []events.Event{
+		{Type: events.TaskCompleted, UserID: "u1", Collection: "users", DocumentID: "t1", Payload: bson.M{"task_id": "t1"}, OccurredAt: at},
+		{Type: events.FriendAdded, UserID: "u2"},
+		{Type: events.TaskCompleted, UserID: "u1", DocumentID: "t2"},
+	}
+	for _, event := range written {
+		if err := Write(ctx, relay.outbox, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	drain(t, relay)
+
+	if len(*published) != len(written) {
+		t.Fatalf("expected %d events, got %d", len(written), len(*published))
+	}
+	for i, event := range *published {
+		// oldest first, each with the outbox id so consumers can drop repeats
+		if event.Type != written[i].Type || event.UserID != written[i].UserID || event.DocumentID != written[i].DocumentID || event.ID == "" {
+			t.Errorf("event %d: expected %+v, got %+v", i, written[i], event)
+		}
+	}
+	first := (*published)[0]
+	if !first.OccurredAt.Equal(at) || first.Payload.(bson.M)["task_id"] != "t1" {
+		t.Errorf("expected the time and payload written, got %+v", first)
+	}
+	if (*published)[1].OccurredAt.IsZero() {
+		t.Error("expected the time of writing when the event had none")
+	}
+
+	pending, err := relay.outbox.CountDocuments(ctx, bson.M{"published_at": nil})
+	if err != nil || pending != 0 {
+		t.Errorf("expected every event marked published, got %d pending %v", pending, err)
+	}
+	// a second pass publishes nothing again
+	drain(t, relay)
+	if len(*published) != len(written) {
+		t.Errorf("expected no repeats, got %d events", len(*published))
+	}
+}
+
+func TestWriteInTransaction(t *testing.T) {
+	relay, published := newRelay(t)
+	ctx := context.Background()
+	rollback := errors.New("domain write failed")
+
+	err := xmongo.WithTransaction(ctx, relay.outbox, func(sc mongo.SessionContext) error {
+		if err := Write(sc, relay.outbox, events.Event{Type: events.TaskCompleted, UserID: "aborted"}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("expected the transaction to fail with %v, got %v", rollback, err)
+	}
+	err = xmongo.WithTransaction(ctx, relay.outbox, func(sc mongo.SessionContext) error {
+		return Write(sc, relay.outbox, events.Event{Type: events.TaskCompleted, UserID: "committed"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	drain(t, relay)
+
+	if len(*published) != 1 || (*published)[0].UserID != "committed" {
+		t.Errorf("expected only the committed event, got %+v", *published)
+	}
+}
+
+func TestClaimLease(t *testing.T) {
+	relay, published := newRelay(t)
+	ctx := context.Background()
+	if err := Write(ctx, relay.outbox, events.Event{Type: events.TaskCompleted, UserID: "u1"}); err != nil {
+		t.Fatal(err)
+	}
+	// another relay claimed it and is still within its lease
+	if _, err := relay.outbox.UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"locked_until": time.Now().Add(claimLease)}, "$inc": bson.M{"attempts": 1}}); err != nil {
+		t.Fatal(err)
+	}
+	drain(t, relay)
+	if len(*published) != 0 {
+		t.Fatalf("expected a claimed event to be left alone, got %+v", *published)
+	}
+
+	// that relay died before publishing, the lease runs out
+	if _, err := relay.outbox.UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"locked_until": time.Now().Add(-time.Second)}}); err != nil {
+		t.Fatal(err)
+	}
+	drain(t, relay)
+	if len(*published) != 1 {
+		t.Fatalf("expected the event once its lease ran out, got %+v", *published)
+	}
+	var message Message
+	if err := relay.outbox.FindOne(ctx, bson.M{}).Decode(&message); err != nil {
+		t.Fatal(err)
+	}
+	if message.Attempts != 2 || message.PublishedAt == nil {
+		t.Errorf("expected 2 attempts and published, got %d %v", message.Attempts, message.PublishedAt)
+	}
+}
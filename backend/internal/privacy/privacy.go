@@ -0,0 +1,132 @@
+package privacy
+
+import (
+	"context"
+	"slices"
+
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Privacy decides what one user gets to see of another. A block, by either
+side, hides the two users from each other entirely; otherwise the owner's
+settings decide who sees their profile, whether their activity reaches
+their friends' feeds and whether they show on their friends' leaderboards.
+Users without settings are public and share both.
+*/
+
+type Visibility string
+
+const (
+	Public Visibility = "public"
+	// only the user's friends
+	Friends Visibility = "friends"
+	// nobody but the user
+	Private Visibility = "private"
+)
+
+// Settings is the privacy sub-document of a user
+type Settings struct {
+	ProfileVisibility Visibility `validate:"omitempty,oneof=public friends private" bson:"profile_visibility,omitempty" json:"profile_visibility"`
+	ShareActivity     *bool      `bson:"share_activity,omitempty" json:"share_activity"`
+	Leaderboard       *bool      `bson:"leaderboard,omitempty" json:"leaderboard"`
+}
+
+func (s Settings) Visibility() Visibility {
+	if s.ProfileVisibility == "" {
+		return Public
+	}
+	return s.ProfileVisibility
+}
+
+func (s Settings) SharesActivity() bool {
+	return s.ShareActivity == nil || *s.ShareActivity
+}
+
+func (s Settings) OnLeaderboard() bool {
+	return s.Leaderboard == nil || *s.Leaderboard
+}
+
+// Resolved fills in the defaults, as the settings are shown to their owner
+func (s Settings) Resolved() Settings {
+	share, leaderboard := s.SharesActivity(), s.OnLeaderboard()
+	return Settings{ProfileVisibility: s.Visibility(), ShareActivity: &share, Leaderboard: &leaderboard}
+}
+
+// Relations is the part of a user document privacy is decided on
+type Relations struct {
+	ID           primitive.ObjectID   `bson:"_id"`
+	Friends      []primitive.ObjectID `bson:"friends"`
+	BlockedUsers []primitive.ObjectID `bson:"blocked_users"`
+	Privacy      Settings             `bson:"privacy"`
+}
+
+// Projection loads Relations from a user document
+var Projection = bson.M{"friends": 1, "blocked_users": 1, "privacy": 1}
+
+// Blocked reports whether either user blocked the other
+func Blocked(a Relations, b Relations) bool {
+	return slices.Contains(a.BlockedUsers, b.ID) || slices.Contains(b.BlockedUsers, a.ID)
+}
+
+// CanView reports whether viewer may see owner's profile
+func CanView(viewer Relations, owner Relations) bool {
+	if viewer.ID == owner.ID {
+		return true
+	}
+	if Blocked(viewer, owner) {
+		return false
+	}
+	switch owner.Privacy.Visibility() {
+	case Private:
+		return false
+	case Friends:
+		return slices.Contains(owner.Friends, viewer.ID)
+	}
+	return true
+}
+
+// Load reads the relations of the live users among ids
+func Load(ctx context.Context, users *mongo.Collection, ids []primitive.ObjectID) (map[primitive.ObjectID]Relations, error) {
+	cursor, err := users.Find(ctx, softdelete.Filter(bson.M{"_id": bson.M{"$in": ids}}), options.Find().SetProjection(Projection))
+	if err != nil {
+		return nil, err
+	}
+	var found []Relations
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	results := make(map[primitive.ObjectID]Relations, len(found))
+	for _, r := range found {
+		results[r.ID] = r
+	}
+	return results, nil
+}
+
+/*
+FeedAuthors is whose activity the viewer's feed shows: the viewer's own and
+that of the friends who share it and whose profile the viewer can see.
+*/
+func FeedAuthors(ctx context.Context, users *mongo.Collection, viewer primitive.ObjectID) ([]primitive.ObjectID, error) {
+	var me Relations
+	err := users.FindOne(ctx, bson.M{"_id": viewer}, options.FindOne().SetProjection(Projection)).Decode(&me)
+	if err != nil {
+		return nil, err
+	}
+	friends, err := Load(ctx, users, me.Friends)
+	if err != nil {
+		return nil, err
+	}
+
+	authors := []primitive.ObjectID{viewer}
+	for _, id := range me.Friends {
+		if friend, ok := friends[id]; ok && friend.Privacy.SharesActivity() && CanView(me, friend) {
+			authors = append(authors, id)
+		}
+	}
+	return authors, nil
+}
@@ -0,0 +1,74 @@
+package privacy
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCanView(t *testing.T) {
+	viewer := Relations{ID: primitive.NewObjectID()}
+	user := func(visibility Visibility, friends ...primitive.ObjectID) Relations {
+		return Relations{ID: primitive.NewObjectID(), Friends: friends, Privacy: Settings{ProfileVisibility: visibility}}
+	}
+	blockedBy := func(owner Relations, blocked primitive.ObjectID) Relations {
+		owner.BlockedUsers = []primitive.ObjectID{blocked}
+		return owner
+	}
+
+	tests := []struct {
+		name     string
+		viewer   Relations
+		owner    Relations
+		expected bool
+	}{
+		{"self", viewer, viewer, true},
+		{"no settings", viewer, user(""), true},
+		{"public", viewer, user(Public), true},
+		{"friends only, as a friend", viewer, user(Friends, viewer.ID), true},
+		{"friends only, as a stranger", viewer, user(Friends), false},
+		{"private, as a friend", viewer, user(Private, viewer.ID), false},
+		{"blocked by the owner", viewer, blockedBy(user(Public, viewer.ID), viewer.ID), false},
+		{"self, having blocked", blockedBy(viewer, viewer.ID), viewer, true},
+	}
+	for _, tt := range tests {
+		if got := CanView(tt.viewer, tt.owner); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+
+	// blocking works both ways: whoever blocked can't see the other either
+	owner := user(Public, viewer.ID)
+	blocking := blockedBy(viewer, owner.ID)
+	if CanView(blocking, owner) {
+		t.Error("expected a user to lose sight of the user they blocked")
+	}
+	if !Blocked(blocking, owner) || !Blocked(owner, blocking) {
+		t.Error("expected Blocked to hold from either side")
+	}
+}
+
+func TestSettings(t *testing.T) {
+	off := false
+	tests := []struct {
+		name       string
+		settings   Settings
+		visibility Visibility
+		shares     bool
+		ranked     bool
+	}{
+		{"defaults", Settings{}, Public, true, true},
+		{"friends only", Settings{ProfileVisibility: Friends}, Friends, true, true},
+		{"not sharing", Settings{ShareActivity: &off}, Public, false, true},
+		{"off the leaderboard", Settings{Leaderboard: &off}, Public, true, false},
+	}
+	for _, tt := range tests {
+		resolved := tt.settings.Resolved()
+		if resolved.ProfileVisibility != tt.visibility || resolved.ShareActivity == nil || *resolved.ShareActivity != tt.shares {
+			t.Errorf("%s: expected %s %v, got %+v", tt.name, tt.visibility, tt.shares, resolved)
+		}
+		if resolved.Leaderboard == nil || *resolved.Leaderboard != tt.ranked {
+			t.Errorf("%s: expected leaderboard %v, got %+v", tt.name, tt.ranked, resolved)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"context"
+
+	socialtodov1 "github.com/abhikaboy/SocialToDo/internal/rpc/socialtodov1"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ValidateToken reports whether an access token is valid and who it belongs to
+func (s *Server) ValidateToken(ctx context.Context, req *socialtodov1.ValidateTokenRequest) (*socialtodov1.ValidateTokenResponse, error) {
+	if req.GetAccessToken() == "" {
+		return nil, invalidArgument("access_token", "is required")
+	}
+
+	userID, _, err := s.auth.ValidateToken(ctx, req.GetAccessToken())
+	if err != nil {
+		return &socialtodov1.ValidateTokenResponse{Valid: false, Reason: err.Error()}, nil
+	}
+
+	resp := &socialtodov1.ValidateTokenResponse{Valid: true, UserId: userID}
+	// already verified above, only reading the expiry
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(req.GetAccessToken(), claims); err == nil {
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			resp.ExpiresAt = timestamppb.New(exp.Time)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,31 @@
+package rpc
+
+import (
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func invalidArgument(field string, reason string) error {
+	return status.Error(codes.InvalidArgument, field+" "+reason)
+}
+
+// toStatus maps err to a gRPC status; errors the services didn't name don't leak their message
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return status.Error(codes.NotFound, "not found")
+	case errors.Is(err, task.ErrStartAfterDue):
+		return invalidArgument("start_date", "must not be after due_date")
+	}
+	return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	socialtodov1 "github.com/abhikaboy/SocialToDo/internal/rpc/socialtodov1"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 50
+)
+
+type feedDocument struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	User      primitive.ObjectID `bson:"user"`
+	Field1    string             `bson:"field1"`
+	Field2    string             `bson:"field2"`
+	Picture   *string            `bson:"picture"`
+	Timestamp time.Time          `bson:"timestamp"`
+}
+
+func (s *Server) ListFeed(ctx context.Context, req *socialtodov1.ListFeedRequest) (*socialtodov1.ListFeedResponse, error) {
+	userID, err := primitive.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, invalidArgument("user_id", "must be an object id")
+	}
+	limit := req.GetLimit()
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	if limit > maxFeedLimit {
+		limit = maxFeedLimit
+	}
+
+	authors, err := privacy.FeedAuthors(ctx, s.users, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"user": bson.M{"$in": authors}}
+	if req.GetBefore() != nil {
+		filter["timestamp"] = bson.M{"$lt": req.GetBefore().AsTime()}
+	}
+	cursor, err := s.feed.Find(ctx, filter, options.Find().SetSort(bson.M{"timestamp": -1}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	var docs []feedDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	resp := &socialtodov1.ListFeedResponse{Items: make([]*socialtodov1.Activity, 0, len(docs))}
+	for _, doc := range docs {
+		item := &socialtodov1.Activity{
+			Id:        doc.ID.Hex(),
+			UserId:    doc.User.Hex(),
+			Field1:    doc.Field1,
+			Field2:    doc.Field2,
+			Timestamp: timestamppb.New(doc.Timestamp),
+		}
+		if doc.Picture != nil {
+			item.Picture = *doc.Picture
+		}
+		resp.Items = append(resp.Items, item)
+	}
+	return resp, nil
+}
@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	socialtodov1 "github.com/abhikaboy/SocialToDo/internal/rpc/socialtodov1"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//go:generate buf generate ../.. --template ../../buf.gen.yaml -o ../..
+
+/*
+Internal RPC server for other backend services (recommendation engine,
+analytics), listening on its own port next to the public REST API.
+
+The services are defined in proto/socialtodo/v1 and served over gRPC with the
+code buf generates into socialtodov1. Every call must carry the shared RPC
+token as a bearer token in its authorization metadata.
+*/
+type Server struct {
+	socialtodov1.UnimplementedAuthServiceServer
+	socialtodov1.UnimplementedTaskServiceServer
+	socialtodov1.UnimplementedFeedServiceServer
+
+	auth  *auth.Service
+	tasks *task.Service
+	users *mongo.Collection
+	feed  *mongo.Collection
+}
+
+func New(collections map[string]*mongo.Collection, cache xcache.Cache, cfg config.Config) *grpc.Server {
+	s := &Server{
+		auth:  auth.NewService(collections, cfg),
+		tasks: task.NewService(collections, cache),
+		users: collections["users"],
+		feed:  collections["activity"],
+	}
+
+	return s.serve(cfg.RPC.Token)
+}
+
+func (s *Server) serve(token string) *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(recoverPanics, authenticateCaller(token), logCalls))
+	socialtodov1.RegisterAuthServiceServer(server, s)
+	socialtodov1.RegisterTaskServiceServer(server, s)
+	socialtodov1.RegisterFeedServiceServer(server, s)
+	return server
+}
+
+func authenticateCaller(token string) grpc.UnaryServerInterceptor {
+	expected := []byte("Bearer " + token)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var got []byte
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+			got = []byte(md.Get("authorization")[0])
+		}
+		if token == "" || subtle.ConstantTimeCompare(got, expected) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid RPC token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// logCalls logs every call like the REST logger does, and turns errors the services return into statuses
+func logCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	code := status.Code(toStatus(err))
+	level := slog.LevelInfo
+	if code == codes.Internal || code == codes.Unknown {
+		level = slog.LevelError
+	}
+	attrs := []slog.Attr{
+		slog.String("method", info.FullMethod),
+		slog.String("code", code.String()),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, xslog.Error(err))
+	}
+	slog.LogAttrs(ctx, level, "RPC", attrs...)
+	return resp, toStatus(err)
+}
+
+func recoverPanics(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "RPC panicked", slog.String("method", info.FullMethod), slog.String("panic", fmt.Sprint(r)))
+			err = status.Error(codes.Internal, "internal error")
+		}
+	}()
+	return handler(ctx, req)
+}
@@ -0,0 +1,223 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	socialtodov1 "github.com/abhikaboy/SocialToDo/internal/rpc/socialtodov1"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const rpcToken = "shared-rpc-token"
+
+type testServer struct {
+	conn  *grpc.ClientConn
+	users *auth.MemoryRepository
+	tasks *task.MemoryRepository
+	auth  *auth.Service
+}
+
+// newTestServer serves the RPC services over an in-memory connection, on memory repositories
+func newTestServer(t *testing.T) testServer {
+	t.Helper()
+	cfg := config.Config{Auth: config.Auth{Secret: "test-secret"}}
+	ts := testServer{users: auth.NewMemoryRepository(), tasks: task.NewMemoryRepository()}
+	ts.auth = auth.NewServiceWithRepository(ts.users, cfg)
+	server := (&Server{auth: ts.auth, tasks: task.NewServiceWithRepository(ts.tasks, xcache.Noop{})}).serve(rpcToken)
+
+	lis := bufconn.Listen(1 << 20)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	ts.conn = conn
+	return ts
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func withIncoming(authorization string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", authorization))
+}
+
+func TestAuthenticateCaller(t *testing.T) {
+	client := socialtodov1.NewAuthServiceClient(newTestServer(t).conn)
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected codes.Code
+	}{
+		{"no token", context.Background(), codes.Unauthenticated},
+		{"wrong token", withToken("guessed"), codes.Unauthenticated},
+		// reaches the service, which wants an access token
+		{"shared token", withToken(rpcToken), codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if _, err := client.ValidateToken(tt.ctx, &socialtodov1.ValidateTokenRequest{}); status.Code(err) != tt.expected {
+			t.Errorf("%s: expected %s, got %v", tt.name, tt.expected, err)
+		}
+	}
+
+	// with no token configured nothing gets in
+	if _, err := authenticateCaller("")(withIncoming("Bearer "), nil, &grpc.UnaryServerInfo{}, nil); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("empty token: expected %s, got %v", codes.Unauthenticated, err)
+	}
+}
+
+func TestValidateToken(t *testing.T) {
+	ts := newTestServer(t)
+	client := socialtodov1.NewAuthServiceClient(ts.conn)
+	userID := primitive.NewObjectID()
+	if err := ts.users.Create(context.Background(), auth.User{ID: userID, Email: "abhi@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	access, _, err := ts.auth.GenerateTokens(userID.Hex(), "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.ValidateToken(withToken(rpcToken), &socialtodov1.ValidateTokenRequest{AccessToken: access})
+	if err != nil || !resp.GetValid() || resp.GetUserId() != userID.Hex() || resp.GetExpiresAt() == nil {
+		t.Errorf("valid token: expected user %s with an expiry, got %v %v", userID.Hex(), resp, err)
+	}
+	resp, err = client.ValidateToken(withToken(rpcToken), &socialtodov1.ValidateTokenRequest{AccessToken: "not.a.jwt"})
+	if err != nil || resp.GetValid() || resp.GetReason() == "" {
+		t.Errorf("bad token: expected invalid with a reason, got %v %v", resp, err)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	ts := newTestServer(t)
+	client := socialtodov1.NewTaskServiceClient(ts.conn)
+	ctx := withToken(rpcToken)
+	userID, categoryID := primitive.NewObjectID(), primitive.NewObjectID()
+	ts.tasks.AddCategory(userID, categoryID, "Chores")
+
+	due := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	details, _ := structpb.NewStruct(map[string]interface{}{"every": "week"})
+	created, err := client.CreateTask(ctx, &socialtodov1.CreateTaskRequest{
+		UserId:     userID.Hex(),
+		CategoryId: categoryID.Hex(),
+		Task:       &socialtodov1.Task{Priority: 2, Content: "Take out the trash", RecurDetails: details, DueDate: timestamppb.New(due)},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.GetPriority() != 2 || !created.GetDueDate().AsTime().Equal(due) || created.GetStartDate() != nil ||
+		created.GetRecurDetails().AsMap()["every"] != "week" {
+		t.Errorf("create: expected the task back as sent, got %v", created)
+	}
+
+	updated, err := client.UpdateTask(ctx, &socialtodov1.UpdateTaskRequest{
+		Id:   created.GetId(),
+		Task: &socialtodov1.Task{Priority: 1, Content: "Take out the recycling", DueDate: timestamppb.New(due)},
+	})
+	if err != nil || updated.GetContent() != "Take out the recycling" || updated.GetPriority() != 1 {
+		t.Errorf("update: expected the new content, got %v %v", updated, err)
+	}
+	list, err := client.ListTasks(ctx, &socialtodov1.ListTasksRequest{UserId: userID.Hex()})
+	if err != nil || len(list.GetTasks()) != 1 {
+		t.Errorf("list: expected 1 task, got %v %v", list, err)
+	}
+	if _, err := client.DeleteTask(ctx, &socialtodov1.DeleteTaskRequest{Id: created.GetId()}); err != nil {
+		t.Errorf("delete: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		call     func() error
+		expected codes.Code
+	}{
+		{"get deleted", func() error {
+			_, err := client.GetTask(ctx, &socialtodov1.GetTaskRequest{Id: created.GetId()})
+			return err
+		}, codes.NotFound},
+		{"get a bad id", func() error {
+			_, err := client.GetTask(ctx, &socialtodov1.GetTaskRequest{Id: "abhi"})
+			return err
+		}, codes.InvalidArgument},
+		{"list by an unknown field", func() error {
+			_, err := client.ListTasks(ctx, &socialtodov1.ListTasksRequest{UserId: userID.Hex(), SortBy: "content"})
+			return err
+		}, codes.InvalidArgument},
+		{"create without content", func() error {
+			_, err := client.CreateTask(ctx, &socialtodov1.CreateTaskRequest{UserId: userID.Hex(), CategoryId: categoryID.Hex()})
+			return err
+		}, codes.InvalidArgument},
+		{"create starting after due", func() error {
+			_, err := client.CreateTask(ctx, &socialtodov1.CreateTaskRequest{
+				UserId:     userID.Hex(),
+				CategoryId: categoryID.Hex(),
+				Task:       &socialtodov1.Task{Content: "Mow the lawn", StartDate: timestamppb.New(due.Add(time.Hour)), DueDate: timestamppb.New(due)},
+			})
+			return err
+		}, codes.InvalidArgument},
+		{"update without a task", func() error {
+			_, err := client.UpdateTask(ctx, &socialtodov1.UpdateTaskRequest{Id: created.GetId()})
+			return err
+		}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if err := tt.call(); status.Code(err) != tt.expected {
+			t.Errorf("%s: expected %s, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected codes.Code
+		message  string
+	}{
+		{"not found", mongo.ErrNoDocuments, codes.NotFound, "not found"},
+		{"wrapped", errors.Join(errors.New("finding task"), mongo.ErrNoDocuments), codes.NotFound, "not found"},
+		{"bad dates", task.ErrStartAfterDue, codes.InvalidArgument, "start_date must not be after due_date"},
+		{"already a status", status.Error(codes.PermissionDenied, "not yours"), codes.PermissionDenied, "not yours"},
+		// the cause stays in the logs
+		{"unnamed", errors.New("connection reset by peer"), codes.Internal, "internal error"},
+	}
+	for _, tt := range tests {
+		got := status.Convert(toStatus(tt.err))
+		if got.Code() != tt.expected || got.Message() != tt.message {
+			t.Errorf("%s: expected %s %q, got %s %q", tt.name, tt.expected, tt.message, got.Code(), got.Message())
+		}
+	}
+	if toStatus(nil) != nil {
+		t.Error("expected no status for no error")
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	_, err := recoverPanics(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/socialtodo.v1.TaskService/GetTask"},
+		func(ctx context.Context, req any) (any, error) { panic("nil map") })
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected %s, got %v", codes.Internal, err)
+	}
+}
@@ -0,0 +1,189 @@
+package rpc
+
+import (
+	"context"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	socialtodov1 "github.com/abhikaboy/SocialToDo/internal/rpc/socialtodov1"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (s *Server) ListTasks(ctx context.Context, req *socialtodov1.ListTasksRequest) (*socialtodov1.ListTasksResponse, error) {
+	userID, err := primitive.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, invalidArgument("user_id", "must be an object id")
+	}
+	sortBy := req.GetSortBy()
+	switch sortBy {
+	case "":
+		sortBy = "timestamp"
+	case "priority", "timestamp", "due_date", "value":
+	default:
+		return nil, invalidArgument("sort_by", "must be priority, timestamp, due_date or value")
+	}
+	sortDir := -1
+	if req.GetSortDir() == 1 {
+		sortDir = 1
+	}
+
+	tasks, err := s.tasks.GetTasksByUser(ctx, userID, task.SortParams{SortBy: sortBy, SortDir: sortDir}, task.Filter{})
+	if err != nil {
+		return nil, err
+	}
+	resp := &socialtodov1.ListTasksResponse{Tasks: make([]*socialtodov1.Task, 0, len(tasks))}
+	for _, t := range tasks {
+		pb, err := toTask(t)
+		if err != nil {
+			return nil, err
+		}
+		resp.Tasks = append(resp.Tasks, pb)
+	}
+	return resp, nil
+}
+
+func (s *Server) GetTask(ctx context.Context, req *socialtodov1.GetTaskRequest) (*socialtodov1.Task, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, invalidArgument("id", "must be an object id")
+	}
+	t, err := s.tasks.GetTaskByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toTask(*t)
+}
+
+func (s *Server) CreateTask(ctx context.Context, req *socialtodov1.CreateTaskRequest) (*socialtodov1.Task, error) {
+	userID, err := primitive.ObjectIDFromHex(req.GetUserId())
+	if err != nil {
+		return nil, invalidArgument("user_id", "must be an object id")
+	}
+	categoryID, err := primitive.ObjectIDFromHex(req.GetCategoryId())
+	if err != nil {
+		return nil, invalidArgument("category_id", "must be an object id")
+	}
+	in := req.GetTask()
+	if in.GetContent() == "" {
+		return nil, invalidArgument("task.content", "is required")
+	}
+	if !task.ValidPriority(int(in.GetPriority())) {
+		return nil, invalidArgument("task.priority", "must be 0 (P0) to 3 (P3)")
+	}
+
+	now := time.Now()
+	doc := task.TaskDocument{
+		ID:           primitive.NewObjectID(),
+		Priority:     int(in.GetPriority()),
+		Content:      in.GetContent(),
+		Value:        in.GetValue(),
+		Recurring:    in.GetRecurring(),
+		RecurDetails: toMap(in.GetRecurDetails()),
+		Public:       in.GetPublic(),
+		Active:       in.GetActive(),
+		StartDate:    toTime(in.GetStartDate()),
+		DueDate:      toTime(in.GetDueDate()),
+		Notes:        in.GetNotes(),
+		Draft:        in.GetDraft(),
+		Timestamp:    now,
+		UpdatedAt:    now,
+	}
+	if _, err := s.tasks.CreateTask(ctx, userID, categoryID, &doc); err != nil {
+		return nil, err
+	}
+	return toTask(doc)
+}
+
+func (s *Server) UpdateTask(ctx context.Context, req *socialtodov1.UpdateTaskRequest) (*socialtodov1.Task, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, invalidArgument("id", "must be an object id")
+	}
+	in := req.GetTask()
+	if in == nil {
+		return nil, invalidArgument("task", "is required")
+	}
+	priority := int(in.GetPriority())
+	if !task.ValidPriority(priority) {
+		return nil, invalidArgument("task.priority", "must be 0 (P0) to 3 (P3)")
+	}
+	err = s.tasks.UpdatePartialTask(ctx, id, task.UpdateTaskDocument{
+		Priority:     &priority,
+		Content:      in.GetContent(),
+		Value:        in.GetValue(),
+		Recurring:    in.GetRecurring(),
+		RecurDetails: toMap(in.GetRecurDetails()),
+		Public:       in.GetPublic(),
+		Active:       in.GetActive(),
+		StartDate:    toTime(in.GetStartDate()),
+		DueDate:      toTime(in.GetDueDate()),
+		Notes:        in.GetNotes(),
+		Draft:        in.GetDraft(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTask(ctx, &socialtodov1.GetTaskRequest{Id: req.GetId()})
+}
+
+func (s *Server) DeleteTask(ctx context.Context, req *socialtodov1.DeleteTaskRequest) (*socialtodov1.DeleteTaskResponse, error) {
+	id, err := primitive.ObjectIDFromHex(req.GetId())
+	if err != nil {
+		return nil, invalidArgument("id", "must be an object id")
+	}
+	if err := s.tasks.DeleteTask(ctx, id, nil); err != nil {
+		return nil, err
+	}
+	return &socialtodov1.DeleteTaskResponse{}, nil
+}
+
+func toTask(t task.TaskDocument) (*socialtodov1.Task, error) {
+	pb := &socialtodov1.Task{
+		Id:        t.ID.Hex(),
+		Priority:  int32(t.Priority),
+		Content:   t.Content,
+		Value:     t.Value,
+		Recurring: t.Recurring,
+		Public:    t.Public,
+		Active:    t.Active,
+		StartDate: toTimestamp(t.StartDate),
+		DueDate:   toTimestamp(t.DueDate),
+		Notes:     t.Notes,
+		Draft:     t.Draft,
+		Timestamp: timestamppb.New(t.Timestamp),
+		UpdatedAt: timestamppb.New(t.UpdatedAt),
+	}
+	if t.RecurDetails != nil {
+		details, err := structpb.NewStruct(t.RecurDetails)
+		if err != nil {
+			return nil, err
+		}
+		pb.RecurDetails = details
+	}
+	return pb, nil
+}
+
+// toTime leaves a date the caller didn't set unset instead of the zero time
+func toTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func toTimestamp(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func toMap(s *structpb.Struct) map[string]interface{} {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
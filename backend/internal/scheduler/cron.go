@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule computes the next run strictly after t
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+type every time.Duration
+
+// Every runs at a fixed interval, aligned to the interval since the Unix epoch
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(e)).Add(time.Duration(e))
+}
+
+var shorthands = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// field bounds: minute, hour, day of month, month, day of week
+var bounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+/*
+cron is a standard five field expression (minute hour day-of-month month
+day-of-week) evaluated in UTC. Each field takes *, numbers, ranges (1-5),
+steps (0-59/15, or a star with a step) and comma separated lists of those.
+*/
+type cron struct {
+	fields [5]uint64
+	// when both day fields are restricted a day matches if either one does
+	anyDay bool
+}
+
+// Parse reads a five field cron expression or one of @hourly, @daily, @weekly, @monthly
+func Parse(spec string) (Schedule, error) {
+	if expanded, ok := shorthands[spec]; ok {
+		spec = expanded
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", spec, len(parts))
+	}
+
+	var c cron
+	for i, part := range parts {
+		bits, err := parseField(part, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("cron %q: %w", spec, err)
+		}
+		c.fields[i] = bits
+	}
+	c.anyDay = parts[2] != "*" && parts[4] != "*"
+	return c, nil
+}
+
+// MustParse is Parse for specs written in code
+func MustParse(spec string) Schedule {
+	schedule, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+func parseField(field string, low, high int) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %q", item)
+			}
+			step = n
+		}
+
+		start, end := low, high
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value in %q", item)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(to); err != nil {
+					return 0, fmt.Errorf("invalid range in %q", item)
+				}
+			} else if hasStep {
+				end = high
+			}
+		}
+		if start < low || end > high || start > end {
+			return 0, fmt.Errorf("%q out of range %d-%d", item, low, high)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (c cron) matches(field int, v int) bool {
+	return c.fields[field]&(1<<v) != 0
+}
+
+func (c cron) dayMatches(t time.Time) bool {
+	dom := c.matches(2, t.Day())
+	dow := c.matches(4, int(t.Weekday()))
+	if c.anyDay {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+func (c cron) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	// every valid expression fires within a few years (Feb 29 being the worst case)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !c.matches(3, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+		case !c.matches(1, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !c.matches(0, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
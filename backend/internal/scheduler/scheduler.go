@@ -0,0 +1,215 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Scheduler runs recurring maintenance (digests, overdue sweeps, activity
+archival, trash purges) on every instance, but each run is claimed through a
+lease on the entry's document in the schedules collection, so only one
+instance executes a given run. The same documents record the outcome of the
+last run for the admin status endpoint.
+*/
+
+const (
+	Collection = "schedules"
+
+	// how often each instance checks for due entries
+	tick = 15 * time.Second
+	// default upper bound on a run; the lease is released early when it finishes
+	defaultTimeout = 10 * time.Minute
+)
+
+type Func func(ctx context.Context) error
+
+type Entry struct {
+	Name     string
+	Spec     string
+	Schedule Schedule
+	Timeout  time.Duration
+	Run      Func
+}
+
+// Run is the persisted state of an entry, shared by every instance
+type Run struct {
+	Name         string     `bson:"_id" json:"name"`
+	Spec         string     `bson:"spec" json:"spec"`
+	NextRunAt    time.Time  `bson:"next_run_at" json:"next_run_at"`
+	LockedUntil  time.Time  `bson:"locked_until" json:"-"`
+	LockedBy     string     `bson:"locked_by,omitempty" json:"locked_by,omitempty"`
+	Running      bool       `bson:"running" json:"running"`
+	LastRunAt    *time.Time `bson:"last_run_at,omitempty" json:"last_run_at,omitempty"`
+	LastStatus   string     `bson:"last_status,omitempty" json:"last_status,omitempty"`
+	LastError    string     `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastDuration int64      `bson:"last_duration_ms" json:"last_duration_ms"`
+}
+
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+type Scheduler struct {
+	runs    *mongo.Collection
+	owner   string
+	entries []*Entry
+}
+
+// New creates a scheduler; owner identifies this instance in the lock (hostname, pod name)
+func New(runs *mongo.Collection, owner string) *Scheduler {
+	return &Scheduler{runs: runs, owner: owner}
+}
+
+/*
+Register adds a recurring entry. spec is a cron expression (see Parse) and
+timeout bounds a single run, 0 meaning the default. Register every entry
+before Run.
+*/
+func (s *Scheduler) Register(name string, spec string, timeout time.Duration, run Func) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	schedule := MustParse(spec)
+	if schedule.Next(time.Now()).IsZero() {
+		panic(fmt.Sprintf("schedule %s: %q never fires", name, spec))
+	}
+	s.entries = append(s.entries, &Entry{
+		Name:     name,
+		Spec:     spec,
+		Schedule: schedule,
+		Timeout:  timeout,
+		Run:      run,
+	})
+}
+
+func (s *Scheduler) Run(ctx context.Context) {
+	for _, entry := range s.entries {
+		if err := s.init(ctx, entry); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to register scheduled entry",
+				slog.String("schedule", entry.Name), xslog.Error(err))
+		}
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		for _, entry := range s.entries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.tryRun(ctx, entry)
+			}()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// init creates the entry's document the first time any instance sees it and keeps the spec current
+func (s *Scheduler) init(ctx context.Context, entry *Entry) error {
+	_, err := s.runs.UpdateOne(ctx, bson.M{"_id": entry.Name}, bson.M{
+		"$set": bson.M{"spec": entry.Spec},
+		"$setOnInsert": bson.M{
+			"next_run_at":      entry.Schedule.Next(time.Now()),
+			"locked_until":     time.Time{},
+			"running":          false,
+			"last_duration_ms": 0,
+		},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+func (s *Scheduler) tryRun(ctx context.Context, entry *Entry) {
+	now := time.Now()
+	// the lease outlives the timeout slightly so a run that overruns can still record itself
+	result, err := s.runs.UpdateOne(ctx, bson.M{
+		"_id":          entry.Name,
+		"next_run_at":  bson.M{"$lte": now},
+		"locked_until": bson.M{"$lte": now},
+	}, bson.M{"$set": bson.M{
+		"locked_until": now.Add(entry.Timeout + time.Minute),
+		"locked_by":    s.owner,
+		"running":      true,
+	}})
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to claim scheduled run",
+				slog.String("schedule", entry.Name), xslog.Error(err))
+		}
+		return
+	}
+	if result.ModifiedCount == 0 {
+		// not due, or another instance has it
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), entry.Timeout)
+	defer cancel()
+	runCtx = xslog.WithAttrs(runCtx, slog.String("schedule", entry.Name))
+
+	slog.LogAttrs(runCtx, slog.LevelInfo, "Scheduled run started")
+	start := time.Now()
+	err = safeRun(runCtx, entry.Run)
+	duration := time.Since(start)
+	xmetrics.ScheduledRuns.WithLabelValues(entry.Name, xmetrics.Outcome(err)).Inc()
+
+	status, lastError := StatusSuccess, ""
+	if err != nil {
+		status, lastError = StatusError, err.Error()
+		slog.LogAttrs(runCtx, slog.LevelError, "Scheduled run failed", xslog.Error(err), slog.Duration("duration", duration))
+	} else {
+		slog.LogAttrs(runCtx, slog.LevelInfo, "Scheduled run finished", slog.Duration("duration", duration))
+	}
+
+	_, err = s.runs.UpdateOne(runCtx, bson.M{"_id": entry.Name, "locked_by": s.owner}, bson.M{"$set": bson.M{
+		"next_run_at":      entry.Schedule.Next(time.Now()),
+		"locked_until":     time.Time{},
+		"running":          false,
+		"last_run_at":      start,
+		"last_status":      status,
+		"last_error":       lastError,
+		"last_duration_ms": duration.Milliseconds(),
+	}})
+	if err != nil {
+		slog.LogAttrs(runCtx, slog.LevelError, "Failed to record scheduled run", xslog.Error(err))
+	}
+}
+
+func safeRun(ctx context.Context, run Func) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("scheduled run panicked: %v", r)
+		}
+	}()
+	return run(ctx)
+}
+
+// List returns the state of every entry any instance has registered, soonest first
+func List(ctx context.Context, runs *mongo.Collection) ([]Run, error) {
+	cursor, err := runs.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "next_run_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Run, 0)
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
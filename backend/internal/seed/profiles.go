@@ -0,0 +1,74 @@
+package seed
+
+import (
+	"fmt"
+	"sort"
+)
+
+/*
+Profile sizes a seeded data set. History spreads task and activity timestamps
+over that many days before the seed runs, so feeds and stats have some depth.
+*/
+type Profile struct {
+	Name              string `json:"name"`
+	Users             int    `json:"users" validate:"min=1,max=5000"`
+	FriendsPerUser    int    `json:"friends_per_user" validate:"min=0,max=200"`
+	CategoriesPerUser int    `json:"categories_per_user" validate:"min=0,max=50"`
+	TasksPerCategory  int    `json:"tasks_per_category" validate:"min=0,max=200"`
+	ActivityPerUser   int    `json:"activity_per_user" validate:"min=0,max=1000"`
+	// share of tasks that are already completed, 0 to 1
+	CompletedRatio float64 `json:"completed_ratio" validate:"min=0,max=1"`
+	HistoryDays    int     `json:"history_days" validate:"min=1,max=730"`
+}
+
+var profiles = map[string]Profile{
+	// enough to click through every screen
+	"small": {
+		Users:             5,
+		FriendsPerUser:    2,
+		CategoriesPerUser: 3,
+		TasksPerCategory:  5,
+		ActivityPerUser:   10,
+		CompletedRatio:    0.4,
+		HistoryDays:       14,
+	},
+	// a believable friend group for demos
+	"demo": {
+		Users:             25,
+		FriendsPerUser:    6,
+		CategoriesPerUser: 4,
+		TasksPerCategory:  8,
+		ActivityPerUser:   30,
+		CompletedRatio:    0.5,
+		HistoryDays:       60,
+	},
+	// sizable collections for looking at query plans and pagination
+	"load": {
+		Users:             1000,
+		FriendsPerUser:    20,
+		CategoriesPerUser: 5,
+		TasksPerCategory:  20,
+		ActivityPerUser:   100,
+		CompletedRatio:    0.6,
+		HistoryDays:       180,
+	},
+}
+
+// Lookup returns a built-in profile by name
+func Lookup(name string) (Profile, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("unknown seed profile %q, choose from %v", name, Names())
+	}
+	profile.Name = name
+	return profile, nil
+}
+
+func Names() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,255 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+Seed fills a development database with generated users, friendships,
+categories, tasks and feed history. Every generated document is tagged with a
+seed field so Reset can remove exactly what was seeded and nothing else.
+*/
+
+// Password every seeded account logs in with
+const Password = "seedpassword"
+
+// the database environment that is never seeded
+const production = "Production"
+
+const (
+	// emails use a reserved domain so nothing is ever mailed to a real inbox
+	emailDomain = "seed.example"
+	// how many of the newest activity items are embedded on the user
+	recentActivity = 5
+	batchSize      = 500
+)
+
+type Result struct {
+	Profile     string `json:"profile"`
+	Users       int    `json:"users"`
+	Friendships int    `json:"friendships"`
+	Categories  int    `json:"categories"`
+	Tasks       int    `json:"tasks"`
+	Activity    int    `json:"activity"`
+}
+
+// users and activity carry the seed tag next to their normal fields
+type seededUser struct {
+	auth.User `bson:",inline"`
+	Seed      int64 `bson:"seed"`
+}
+
+type seededActivity struct {
+	activity.ActivityDocument `bson:",inline"`
+	User                      primitive.ObjectID `bson:"user"`
+	Seed                      int64              `bson:"seed"`
+}
+
+/*
+Run generates profile's data set from seed and inserts it. The same seed
+always generates the same names and content, so Reset before running it again
+or the unique email and handle indexes will reject the second copy.
+*/
+func Run(ctx context.Context, collections map[string]*mongo.Collection, profile Profile, seed int64) (*Result, error) {
+	rng := rand.New(rand.NewPCG(uint64(seed), uint64(seed)>>1|1))
+	now := time.Now()
+	result := &Result{Profile: profile.Name}
+
+	// hashed once and cheaply, the first login rehashes it at the configured cost
+	password, err := auth.HashPassword(Password, bcrypt.MinCost)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]*seededUser, profile.Users)
+	for i := range users {
+		users[i] = newUser(rng, profile, seed, i, password, now)
+		result.Categories += len(users[i].Categories)
+		for _, c := range users[i].Categories {
+			result.Tasks += len(c.Tasks)
+		}
+	}
+	result.Friendships = befriend(rng, users, profile.FriendsPerUser)
+
+	feed := make([]interface{}, 0, profile.Users*profile.ActivityPerUser)
+	for _, user := range users {
+		items := newActivity(rng, profile, seed, user, now)
+		// newest first, the same order the feed shows them in
+		for i := 0; i < len(items) && i < recentActivity; i++ {
+			user.RecentActivity = append(user.RecentActivity, items[i].ActivityDocument)
+		}
+		for _, item := range items {
+			feed = append(feed, item)
+		}
+	}
+
+	docs := make([]interface{}, len(users))
+	for i, user := range users {
+		docs[i] = user
+	}
+	if err := insertBatches(ctx, collections["users"], docs); err != nil {
+		return nil, fmt.Errorf("failed to seed users: %w", err)
+	}
+	if err := insertBatches(ctx, collections["activity"], feed); err != nil {
+		return nil, fmt.Errorf("failed to seed activity: %w", err)
+	}
+	result.Users = len(users)
+	result.Activity = len(feed)
+	return result, nil
+}
+
+// Reset deletes every seeded document, whatever seed produced it
+func Reset(ctx context.Context, collections map[string]*mongo.Collection) (int64, error) {
+	var deleted int64
+	for _, name := range []string{"users", "activity"} {
+		result, err := collections[name].DeleteMany(ctx, bson.M{"seed": bson.M{"$exists": true}})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to reset %s: %w", name, err)
+		}
+		deleted += result.DeletedCount
+	}
+	return deleted, nil
+}
+
+// CheckEnvironment refuses to seed production
+func CheckEnvironment(environment string) error {
+	if environment == production {
+		return fmt.Errorf("refusing to seed the %s environment", production)
+	}
+	return nil
+}
+
+func newUser(rng *rand.Rand, profile Profile, seed int64, n int, password string, now time.Time) *seededUser {
+	first, last := pick(rng, firstNames), pick(rng, lastNames)
+	user := &seededUser{
+		User: auth.User{
+			ID:               objectIDAt(past(rng, now, profile.HistoryDays)),
+			Email:            fmt.Sprintf("user%d.%d@%s", n, seed, emailDomain),
+			Password:         password,
+			DisplayName:      first + " " + last,
+			DisplayNameLower: strings.ToLower(first + " " + last),
+			Handle:           fmt.Sprintf("@%s%d_%d", strings.ToLower(first), n, seed),
+			Categories:       make([]category.CategoryDocument, 0, profile.CategoriesPerUser),
+			Friends:          make([]primitive.ObjectID, 0, profile.FriendsPerUser),
+		},
+		Seed: seed,
+	}
+
+	names := rng.Perm(len(categoryNames))
+	for c := 0; c < profile.CategoriesPerUser; c++ {
+		cat := category.CategoryDocument{
+			ID:         primitive.NewObjectID(),
+			Name:       categoryNames[names[c%len(names)]],
+			LastEdited: past(rng, now, profile.HistoryDays),
+			Tasks:      make([]task.TaskDocument, 0, profile.TasksPerCategory),
+			User:       user.ID,
+		}
+		for t := 0; t < profile.TasksPerCategory; t++ {
+			created := past(rng, now, profile.HistoryDays)
+			doc := task.TaskDocument{
+				ID:        objectIDAt(created),
+				Priority:  1 + rng.IntN(3),
+				Content:   pick(rng, taskVerbs) + " " + pick(rng, taskObjects),
+				Value:     float64(1 + rng.IntN(10)),
+				Public:    rng.Float64() < 0.7,
+				Active:    true,
+				Timestamp: created,
+				UpdatedAt: created,
+			}
+			if rng.Float64() < profile.CompletedRatio {
+				done := created.Add(time.Duration(rng.Int64N(int64(now.Sub(created)) + 1)))
+				doc.Completed = true
+				doc.CompletedAt = &done
+				doc.UpdatedAt = done
+				user.TasksComplete++
+			}
+			cat.Tasks = append(cat.Tasks, doc)
+		}
+		user.Categories = append(user.Categories, cat)
+	}
+	return user
+}
+
+// befriend links each user to about perUser others, both ways, and returns the number of pairs
+func befriend(rng *rand.Rand, users []*seededUser, perUser int) int {
+	type pair struct{ a, b int }
+	seen := map[pair]bool{}
+	for i := range users {
+		for _, j := range rng.Perm(len(users)) {
+			if len(users[i].Friends) >= perUser {
+				break
+			}
+			p := pair{min(i, j), max(i, j)}
+			if i == j || seen[p] || len(users[j].Friends) >= perUser {
+				continue
+			}
+			seen[p] = true
+			users[i].Friends = append(users[i].Friends, users[j].ID)
+			users[j].Friends = append(users[j].Friends, users[i].ID)
+		}
+	}
+	return len(seen)
+}
+
+// newActivity returns the user's feed history, newest first
+func newActivity(rng *rand.Rand, profile Profile, seed int64, user *seededUser, now time.Time) []seededActivity {
+	options := []activity.Enumeration{activity.Option1, activity.Option2, activity.Option3}
+	items := make([]seededActivity, profile.ActivityPerUser)
+	for i := range items {
+		at := past(rng, now, profile.HistoryDays)
+		items[i] = seededActivity{
+			ActivityDocument: activity.ActivityDocument{
+				ID:        objectIDAt(at),
+				Field1:    fmt.Sprintf("%s %s %s", user.DisplayName, pick(rng, activityVerbs), pick(rng, taskObjects)),
+				Field2:    options[rng.IntN(len(options))],
+				Timestamp: at,
+			},
+			User: user.ID,
+			Seed: seed,
+		}
+	}
+	slices.SortFunc(items, func(a, b seededActivity) int {
+		return b.Timestamp.Compare(a.Timestamp)
+	})
+	return items
+}
+
+func insertBatches(ctx context.Context, coll *mongo.Collection, docs []interface{}) error {
+	for start := 0; start < len(docs); start += batchSize {
+		end := min(start+batchSize, len(docs))
+		if _, err := coll.InsertMany(ctx, docs[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// past returns a time in the last days days
+func past(rng *rand.Rand, now time.Time, days int) time.Time {
+	return now.Add(-time.Duration(rng.Int64N(int64(days) * int64(24*time.Hour))))
+}
+
+func pick(rng *rand.Rand, words []string) string {
+	return words[rng.IntN(len(words))]
+}
+
+// objectIDAt is a fresh ObjectID dated at, so creation times read back from ids look like history
+func objectIDAt(at time.Time) primitive.ObjectID {
+	id := primitive.NewObjectID()
+	stamp := primitive.NewObjectIDFromTimestamp(at)
+	copy(id[:4], stamp[:4])
+	return id
+}
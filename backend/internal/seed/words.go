@@ -0,0 +1,28 @@
+package seed
+
+var firstNames = []string{
+	"Ava", "Ben", "Chloe", "Diego", "Elena", "Felix", "Grace", "Hiro", "Isla", "Jonah",
+	"Kira", "Leo", "Maya", "Noah", "Olivia", "Priya", "Quinn", "Rafael", "Sofia", "Theo",
+}
+
+var lastNames = []string{
+	"Adams", "Brooks", "Chen", "Diaz", "Evans", "Fischer", "Garcia", "Hughes", "Ito", "Jensen",
+	"Kim", "Lopez", "Martin", "Nguyen", "Okafor", "Patel", "Rossi", "Silva", "Turner", "Wong",
+}
+
+var categoryNames = []string{
+	"Work", "School", "Fitness", "Home", "Errands", "Reading", "Side Project", "Health", "Finance", "Travel",
+}
+
+var taskVerbs = []string{
+	"Finish", "Review", "Plan", "Clean", "Call about", "Write up", "Practice", "Schedule", "Organize", "Read",
+}
+
+var taskObjects = []string{
+	"the quarterly report", "chapter 3", "the kitchen", "a 5k run", "the dentist appointment",
+	"the group presentation", "guitar scales", "the grocery list", "the budget spreadsheet", "flight options",
+}
+
+var activityVerbs = []string{
+	"completed", "started", "finished", "made progress on", "crossed off",
+}
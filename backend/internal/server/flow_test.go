@@ -0,0 +1,127 @@
+//go:build integration
+
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/changestream"
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
+	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/outbox"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo/mongotest"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xworker"
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+// newIntegrationApp is the app main serves, on a fresh database and with the workers that feed the bus
+func newIntegrationApp(t *testing.T) (*fiber.App, *events.Bus) {
+	t.Helper()
+	t.Setenv("AUTH_SECRET", "integration-secret")
+	t.Setenv("AUTH_PASSWORD_COST", "4")
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := mongotest.Database(t)
+	bus := events.NewBus()
+	workers := xworker.NewGroup(context.Background())
+	workers.Go("change-stream", changestream.New(db.DB, bus).Run)
+	workers.Go("outbox-relay", outbox.NewRelay(db.Collections[outbox.Collection], bus).Run)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		workers.Stop(ctx)
+		bus.Close(ctx)
+	})
+	return New(db.Collections, nil, xcache.Noop{}, bus, nil, cfg), bus
+}
+
+func TestFlow(t *testing.T) {
+	app, bus := newIntegrationApp(t)
+	completed := make(chan events.Event, 1)
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		select {
+		case completed <- event:
+		default:
+		}
+	}, events.TaskCompleted)
+
+	credentials := `{"email": "abhi@socialtodo.app", "password": "correct horse battery"}`
+	call(t, app, "", http.MethodPost, "/api/v1/auth/register", credentials, fiber.StatusOK, nil)
+	var session auth.TokenResponse
+	call(t, app, "", http.MethodPost, "/api/v1/auth/login", credentials, fiber.StatusOK, &session)
+	token := session.AccessToken
+	userID := session.User.ID.Hex()
+
+	var chores category.CategoryDocument
+	call(t, app, token, http.MethodPost, "/api/v1/Categories/", `{"name": "Chores"}`, fiber.StatusCreated, &chores)
+	var trash task.TaskDocument
+	call(t, app, token, http.MethodPost, "/api/v1/Tasks/"+userID+"/"+chores.ID.Hex(),
+		`{"priority": 1, "content": "Take out the trash", "value": 2}`, fiber.StatusCreated, &trash)
+	call(t, app, token, http.MethodPost, "/api/v1/Tasks/"+trash.ID.Hex()+"/complete", "", fiber.StatusOK, nil)
+
+	// written to the outbox with the completion, published by the relay
+	select {
+	case event := <-completed:
+		if event.UserID != userID || event.DocumentID != trash.ID.Hex() {
+			t.Errorf("task.completed: expected task %s of %s, got %+v", trash.ID.Hex(), userID, event)
+		}
+	case <-time.After(10 * time.Second):
+		t.Error("task.completed: expected the relay to publish it")
+	}
+
+	call(t, app, token, http.MethodPost, "/api/v1/Activity/",
+		`{"field1": "Took out the trash", "field2": "Option1", "picture": "https://socialtodo.app/trash.png"}`, fiber.StatusCreated, nil)
+	var feed activity.FeedPage
+	call(t, app, token, http.MethodGet, "/api/v1/feed", "", fiber.StatusOK, &feed)
+	if len(feed.Items) != 1 || feed.Items[0].Field1 != "Took out the trash" {
+		t.Errorf("feed: expected the new activity, got %+v", feed.Items)
+	}
+
+	// completing a public task adds it to the feed, in the completion's transaction
+	var plants task.TaskDocument
+	call(t, app, token, http.MethodPost, "/api/v1/Tasks/"+userID+"/"+chores.ID.Hex(),
+		`{"priority": 1, "content": "Water the plants", "value": 1, "public": true}`, fiber.StatusCreated, &plants)
+	call(t, app, token, http.MethodPost, "/api/v1/Tasks/"+plants.ID.Hex()+"/complete", "", fiber.StatusOK, nil)
+	call(t, app, token, http.MethodGet, "/api/v1/feed", "", fiber.StatusOK, &feed)
+	if len(feed.Items) != 2 || feed.Items[0].Field1 != "Water the plants" || feed.Items[0].Field2 != activity.Completed {
+		t.Errorf("feed: expected the completed public task first, got %+v", feed.Items)
+	}
+}
+
+// call sends one request and fails the test unless it gets expected, decoding the body into out when given
+func call(t *testing.T, app *fiber.App, token string, method string, path string, body string, expected int, out any) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, _ := io.ReadAll(res.Body)
+	if res.StatusCode != expected {
+		t.Fatalf("%s %s: expected %d, got %d %s", method, path, expected, res.StatusCode, raw)
+	}
+	if out != nil {
+		if err := gojson.Unmarshal(raw, out); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+}
@@ -1,66 +1,193 @@
 package server
 
 import (
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/events"
 	activity "github.com/abhikaboy/SocialToDo/internal/handlers/activity"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/admin"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/analytics"
 	"github.com/abhikaboy/SocialToDo/internal/handlers/auth"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/auth/forgot_pass"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/batch"
 	category "github.com/abhikaboy/SocialToDo/internal/handlers/category"
 	chat "github.com/abhikaboy/SocialToDo/internal/handlers/chat"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/comments"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/dev"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/exports"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/feeds"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/friends"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/graphql"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/groups"
 	"github.com/abhikaboy/SocialToDo/internal/handlers/health"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/hooks"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/imports"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/inbound"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/integrations"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/notifications"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/offline"
 	post "github.com/abhikaboy/SocialToDo/internal/handlers/post"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/profile"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/search"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/shortcuts"
 	"github.com/abhikaboy/SocialToDo/internal/handlers/socket"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/stream"
 	"github.com/abhikaboy/SocialToDo/internal/handlers/task"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/uploads"
+	"github.com/abhikaboy/SocialToDo/internal/middleware"
+	"github.com/abhikaboy/SocialToDo/internal/seed"
 	"github.com/abhikaboy/SocialToDo/internal/sockets"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xfiles"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xredis"
+	"github.com/abhikaboy/SocialToDo/internal/xanalytics"
+	"github.com/abhikaboy/SocialToDo/internal/xcache"
+	"github.com/abhikaboy/SocialToDo/internal/xetag"
+	"github.com/abhikaboy/SocialToDo/internal/xmetrics"
+	"github.com/abhikaboy/SocialToDo/internal/xopenapi"
+	"github.com/abhikaboy/SocialToDo/internal/xsearch"
+	"github.com/abhikaboy/SocialToDo/internal/xtokens"
 
+	"github.com/abhikaboy/SocialToDo/internal/handlers/achievements"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/leaderboard"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/stats"
+	"github.com/abhikaboy/SocialToDo/internal/handlers/trash"
 	"github.com/abhikaboy/SocialToDo/internal/xerr"
 	gojson "github.com/goccy/go-json"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func New(collections map[string]*mongo.Collection, stream *mongo.ChangeStream) *fiber.App {
+/*
+New builds the application. clientEvents may be nil, which leaves the client
+events endpoint unmounted.
+*/
+func New(collections map[string]*mongo.Collection, redis *xredis.Client, cache xcache.Cache, bus *events.Bus, clientEvents *xanalytics.Buffer, cfg config.Config) *fiber.App {
 
-	app := setupApp()
-	sockets.New()
+	app := setupApp(cfg.HTTP, cfg.CORS)
+	// bulk routes take many records in one body, everything else gets the default limit
+	bulk := []string{"/api/v1/batch", "/api/v1/sync", "/api/v1/import"}
+	// file routes, uploads and emails with attachments
+	files := []string{"/api/v1/uploads", "/api/v1/users/me/picture", "/hooks/inbound-email"}
+	app.Use(middleware.BodyLimit(cfg.HTTP.BodyLimit, append(bulk, files...)...))
+	for _, prefix := range bulk {
+		app.Use(prefix, middleware.BodyLimit(cfg.HTTP.BulkBodyLimit))
+	}
+	for _, prefix := range files {
+		app.Use(prefix, middleware.BodyLimit(cfg.HTTP.UploadBodyLimit))
+	}
+	// the stream and websocket connections outlive any request deadline
+	app.Use(middleware.Timeout(cfg.App.RequestTimeout, "/api/v1/stream", "/ws"))
+	if cfg.RateLimit.Enabled {
+		limiter := middleware.NewRateLimiter(redis, xtokens.New(cfg.Tokens, cfg.Auth.Secret), "/health", "/healthz", "/readyz", "/metrics")
+		app.Use(limiter.Limit("global", cfg.RateLimit.Requests, cfg.RateLimit.Window))
+		app.Use("/api/v1/auth", limiter.Limit("auth", cfg.RateLimit.AuthRequests, cfg.RateLimit.AuthWindow))
+		rate := cfg.RateLimit
+		// prefixes, so the Google and Apple variants share the budget
+		app.Use("/api/v1/auth/login", limiter.Policy("login", middleware.RatePolicy{PerIP: rate.LoginPerIP, PerUser: rate.LoginPerUser, Window: rate.LoginWindow}))
+		app.Use("/api/v1/auth/register", limiter.Policy("register", middleware.RatePolicy{PerIP: rate.RegisterPerIP, PerUser: rate.RegisterPerUser, Window: rate.RegisterWindow}))
+		reset := limiter.Policy("reset", middleware.RatePolicy{PerIP: rate.ResetPerIP, PerUser: rate.ResetPerUser, Window: rate.ResetWindow})
+		app.Use([]string{"/api/v1/auth/forgot-password", "/api/v1/auth/reset-password"}, reset)
+		otp := limiter.Policy("otp", middleware.RatePolicy{PerIP: rate.OTPPerIP, PerUser: rate.OTPPerUser, Window: rate.OTPWindow})
+		app.Use([]string{"/api/v1/auth/otp", "/api/v1/auth/phone"}, otp)
+		search := limiter.Policy("search", middleware.RatePolicy{PerIP: rate.SearchPerIP, PerUser: rate.SearchPerUser, Window: rate.SearchWindow})
+		app.Use([]string{"/api/v1/search", "/api/v1/users/search"}, search)
+	}
+	sockets.New(bus, collections["users"])
+	xcache.InvalidateOn(bus, cache)
 
-	health.Routes(app, collections)
-	auth.Routes(app, collections)
+	health.Routes(app, collections, redis)
+	app.Get("/metrics", xmetrics.Handler)
+	auth.Routes(app, collections, cfg)
+	forgot_pass.Routes(app, collections, cfg.Auth.PasswordCost)
+	authenticate := auth.Middleware(collections, cfg)
+	idempotent := middleware.Idempotency(redis)
+	verified := middleware.RequireVerifiedEmail(collections["users"])
 
-	task.Routes(app, collections)
+	task.Routes(app, collections, cache, authenticate, idempotent)
 	chat.Routes(app, collections)
-	category.Routes(app, collections)
-	post.Routes(app, collections)
-	activity.Routes(app, collections)
+	category.Routes(app, collections, cache, authenticate)
+	post.Routes(app, collections, authenticate, verified)
+	friends.Routes(app, collections, authenticate, verified)
+	groups.Routes(app, collections, authenticate)
+	// before profile, whose /users/:id would take /users/search
+	search.Routes(app, collections, xsearch.New(collections, cfg.Search), authenticate)
+	profile.Routes(app, collections, authenticate)
+	stats.Routes(app, collections, authenticate)
+	leaderboard.Routes(app, collections, cache, authenticate)
+	achievements.Routes(app, collections, authenticate)
+	comments.Routes(app, collections, authenticate)
+	notifications.Routes(app, collections, authenticate)
+	activity.Routes(app, collections, authenticate)
+	offline.Routes(app, collections, cache, authenticate)
+	trash.Routes(app, collections, cache, authenticate)
+	graphql.Routes(app, collections, authenticate)
+	fileStore := xfiles.New(collections[uploads.Collection].Database(), cfg.Uploads, cfg.AWS)
+	uploads.Routes(app, collections, fileStore, authenticate, cfg.Uploads)
+	inbound.Routes(app, collections, cache, fileStore, authenticate, cfg.Uploads, cfg.Inbound)
+	exports.Routes(app, collections, fileStore, authenticate, cfg.Notion, cfg.Uploads, cfg.Auth.Secret, cfg.App.PublicURL)
+	feeds.Routes(app, collections, authenticate, cfg.App.PublicURL)
+	hooks.Routes(app, collections, cache, authenticate, cfg.App.PublicURL)
+	imports.Routes(app, collections, cache, authenticate)
+	shortcuts.Routes(app, collections, cache, authenticate, cfg.App.WebURL)
+	integrations.Routes(app, collections, cache, authenticate, cfg.Google, cfg.Slack, cfg.GitHub, cfg.Notion)
+	batch.Routes(app)
+	admin.Routes(app, collections, authenticate, cfg.Admin)
+	if clientEvents != nil {
+		analytics.Routes(app, clientEvents, authenticate, cfg.Analytics)
+	}
+	if cfg.App.DevEndpoints && seed.CheckEnvironment(cfg.Atlas.Environment) == nil {
+		dev.Routes(app, collections)
+	}
 
-	socket.Routes(app, collections, stream)
+	socket.Routes(app, collections, authenticate)
+	stream.Routes(app, bus, authenticate)
+
+	app.Get("/api/v1/openapi.json", xopenapi.Handler(app, xopenapi.Info{Title: "SocialToDo API", Version: "1", ServerURL: cfg.App.PublicURL}))
+	app.Get("/docs", xopenapi.UI("SocialToDo API", "/api/v1/openapi.json"))
+	xopenapi.Register(xopenapi.Operations{
+		"GET /":                    {Summary: "Welcome message", Response: "", ResponseType: fiber.MIMETextPlain},
+		"GET /metrics":             {Summary: "Prometheus metrics", Response: "", ResponseType: fiber.MIMETextPlain},
+		"GET /api/v1/openapi.json": {Summary: "This document", Response: map[string]any{}},
+		"GET /docs":                {Summary: "Swagger UI for this document", Response: "", ResponseType: fiber.MIMETextHTML},
+	})
 
 	return app
 }
 
-func setupApp() *fiber.App {
+func setupApp(cfg config.HTTP, corsCfg config.CORS) *fiber.App {
 	app := fiber.New(fiber.Config{
 		JSONEncoder:  gojson.Marshal,
 		JSONDecoder:  gojson.Unmarshal,
 		ErrorHandler: xerr.ErrorHandler,
+		// the per-route limits are enforced by middleware.BodyLimit, this only has to admit the largest
+		BodyLimit: max(cfg.BodyLimit, cfg.BulkBodyLimit, cfg.UploadBodyLimit),
+		// behind a load balancer c.IP() is the client's address from ProxyHeader, so per-IP
+		// rate limits and logs see clients rather than the balancer
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: len(cfg.TrustedProxies) > 0,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
 	})
 	app.Use(recover.New())
-	app.Use(requestid.New())
+	app.Use(middleware.RequestID())
+	app.Use(middleware.Tracing())
+	app.Use(middleware.Logger(cfg, "/health", "/health/", "/healthz", "/readyz"))
+	app.Use(middleware.Metrics())
 	app.Use(favicon.New())
-	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowMethods: "GET,POST,PUT,PATCH,DELETE",
-	}))
-	app.Use(logger.New(logger.Config{
-		Format: "[${time}] ${ip}:${port} ${pid} ${locals:requestid} ${status} - ${latency} ${method} ${path}\n",
-	}))
+	app.Use(middleware.SecurityHeaders(cfg))
+	app.Use(middleware.CORS(corsCfg))
+	// gzip, brotli or deflate, whichever the client prefers
 	app.Use(compress.New(compress.Config{
-		Level: compress.LevelBestSpeed,
+		// compressing would buffer the event stream
+		Next: func(c *fiber.Ctx) bool {
+			return c.Path() == "/api/v1/stream"
+		},
+		Level: compress.Level(cfg.CompressionLevel),
 	}))
+	// the event stream body is never complete, don't try to hash it
+	app.Use(xetag.New("/api/v1/stream"))
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusOK).SendString("Welcome to [NAME]!")
 	})
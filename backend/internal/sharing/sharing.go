@@ -0,0 +1,80 @@
+package sharing
+
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+A category can be shared with some of its owner's friends. Each is a member
+with a role: viewers see the category and its tasks, editors also add,
+change and complete its tasks and rename it. Only the owner invites, changes
+roles and deletes the category. An invited member has no access until they
+accept.
+*/
+
+type Role string
+
+const (
+	Viewer Role = "viewer"
+	Editor Role = "editor"
+)
+
+// MaxMembers is how many friends a category can be shared with
+const MaxMembers = 20
+
+// Member is an entry of a category's members array
+type Member struct {
+	User      primitive.ObjectID `bson:"user" json:"user_id"`
+	Role      Role               `bson:"role" json:"role"`
+	InvitedAt time.Time          `bson:"invited_at" json:"invited_at"`
+	// nil while the invite is pending
+	AcceptedAt *time.Time `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+}
+
+func (m Member) Accepted() bool {
+	return m.AcceptedAt != nil
+}
+
+// Grants reports whether r allows what need does; an editor can do anything a viewer can
+func (r Role) Grants(need Role) bool {
+	return r == need || r == Editor
+}
+
+// roles are the roles that grant need
+func (r Role) roles() []Role {
+	if r == Editor {
+		return []Role{Editor}
+	}
+	return []Role{Viewer, Editor}
+}
+
+// Find returns userID's entry in members, or nil
+func Find(members []Member, userID primitive.ObjectID) *Member {
+	i := slices.IndexFunc(members, func(m Member) bool { return m.User == userID })
+	if i < 0 {
+		return nil
+	}
+	return &members[i]
+}
+
+// Allows reports whether userID is the owner, or an accepted member whose role grants need
+func Allows(owner primitive.ObjectID, members []Member, userID primitive.ObjectID, need Role) bool {
+	if owner == userID {
+		return true
+	}
+	m := Find(members, userID)
+	return m != nil && m.Accepted() && m.Role.Grants(need)
+}
+
+// Filter matches categories userID is an accepted member of with a role granting need; merge it into their $elemMatch
+func Filter(userID primitive.ObjectID, need Role) bson.M {
+	return bson.M{"members": bson.M{"$elemMatch": bson.M{
+		"user":        userID,
+		"role":        bson.M{"$in": need.roles()},
+		"accepted_at": bson.M{"$ne": nil},
+	}}}
+}
@@ -0,0 +1,37 @@
+package sharing
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAllows(t *testing.T) {
+	owner, user := primitive.NewObjectID(), primitive.NewObjectID()
+	accepted := time.Now()
+	member := func(role Role, accepted *time.Time) []Member {
+		return []Member{{User: primitive.NewObjectID(), Role: Editor, AcceptedAt: accepted}, {User: user, Role: role, AcceptedAt: accepted}}
+	}
+
+	tests := []struct {
+		name     string
+		user     primitive.ObjectID
+		members  []Member
+		need     Role
+		expected bool
+	}{
+		{"owner edits", owner, nil, Editor, true},
+		{"stranger views", user, member(Editor, &accepted)[:1], Viewer, false},
+		{"viewer views", user, member(Viewer, &accepted), Viewer, true},
+		{"viewer edits", user, member(Viewer, &accepted), Editor, false},
+		{"editor views", user, member(Editor, &accepted), Viewer, true},
+		{"editor edits", user, member(Editor, &accepted), Editor, true},
+		{"pending editor views", user, member(Editor, nil), Viewer, false},
+	}
+	for _, tt := range tests {
+		if got := Allows(owner, tt.members, tt.user, tt.need); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
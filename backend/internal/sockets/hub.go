@@ -0,0 +1,207 @@
+package sockets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+	"github.com/abhikaboy/SocialToDo/internal/privacy"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+	"github.com/gofiber/contrib/socketio"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Hub keeps track of which connections belong to which user so internal events
+can be pushed to every device a user has connected: the socket.io rooms by
+their uuid, and /ws connections by the channel Listen handed them.
+*/
+type hub struct {
+	mu       sync.RWMutex
+	byUser   map[string]map[string]struct{}
+	channels map[string]map[chan []byte]struct{}
+}
+
+// messages a slow /ws client can fall behind before it is disconnected
+const channelBuffer = 32
+
+var connections = &hub{
+	byUser:   make(map[string]map[string]struct{}),
+	channels: make(map[string]map[chan []byte]struct{}),
+}
+
+func (h *hub) track(userID string, uuid string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.byUser[userID] == nil {
+		h.byUser[userID] = make(map[string]struct{})
+	}
+	h.byUser[userID][uuid] = struct{}{}
+}
+
+func (h *hub) untrack(userID string, uuid string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.byUser[userID], uuid)
+	if len(h.byUser[userID]) == 0 {
+		delete(h.byUser, userID)
+	}
+}
+
+func (h *hub) uuids(userID string) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	list := make([]string, 0, len(h.byUser[userID]))
+	for uuid := range h.byUser[userID] {
+		list = append(list, uuid)
+	}
+	return list
+}
+
+func (h *hub) connected(userID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.byUser[userID]) > 0 || len(h.channels[userID]) > 0
+}
+
+func (h *hub) send(userID string, message []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.channels[userID] {
+		select {
+		case ch <- message:
+		default:
+			// the client isn't keeping up; closing ends the connection and it reconnects
+			h.remove(userID, ch)
+		}
+	}
+}
+
+// remove expects h.mu to be held
+func (h *hub) remove(userID string, ch chan []byte) {
+	if _, ok := h.channels[userID][ch]; !ok {
+		return
+	}
+	delete(h.channels[userID], ch)
+	close(ch)
+	if len(h.channels[userID]) == 0 {
+		delete(h.channels, userID)
+	}
+}
+
+/*
+Listen opens a channel of the messages for userID. The channel is closed by
+the returned stop, or by the hub when the listener falls too far behind.
+*/
+func Listen(userID string) (<-chan []byte, func()) {
+	ch := make(chan []byte, channelBuffer)
+	connections.mu.Lock()
+	if connections.channels[userID] == nil {
+		connections.channels[userID] = make(map[chan []byte]struct{})
+	}
+	connections.channels[userID][ch] = struct{}{}
+	connections.mu.Unlock()
+
+	return ch, func() {
+		connections.mu.Lock()
+		defer connections.mu.Unlock()
+		connections.remove(userID, ch)
+	}
+}
+
+// EmitToUser sends message to every connection the user has open
+func EmitToUser(userID string, message []byte) {
+	connections.send(userID, message)
+	uuids := connections.uuids(userID)
+	if len(uuids) == 0 {
+		return
+	}
+	socketio.EmitToList(uuids, message)
+}
+
+/*
+Subscribe forwards real-time events from the bus to the affected user's
+sockets. New activity also goes to the author's connected friends whose feed
+shows it (see privacy.FeedAuthors).
+*/
+func Subscribe(bus *events.Bus, users *mongo.Collection) {
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		if event.UserID == "" {
+			return
+		}
+		message, err := json.Marshal(event)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to encode socket event", xslog.Error(err))
+			return
+		}
+		EmitToUser(event.UserID, message)
+	}, events.TasksChanged, events.TaskCompleted, events.FeedCreated, events.NotificationCreated)
+
+	// finding the audience reads the author's friends
+	bus.SubscribeAsync(func(ctx context.Context, event events.Event) {
+		friends, err := feedAudience(ctx, users, event.UserID)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to find who sees new activity", xslog.Error(err))
+			return
+		}
+		if len(friends) == 0 {
+			return
+		}
+		message, err := json.Marshal(event)
+		if err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to encode socket event", xslog.Error(err))
+			return
+		}
+		for _, friend := range friends {
+			EmitToUser(friend, message)
+		}
+	}, events.FeedCreated)
+}
+
+// feedAudience lists the author's friends connected here whose feed shows the author's activity
+func feedAudience(ctx context.Context, users *mongo.Collection, authorID string) ([]string, error) {
+	author, err := primitive.ObjectIDFromHex(authorID)
+	if err != nil {
+		return nil, nil
+	}
+	var relations privacy.Relations
+	err = users.FindOne(ctx, bson.M{"_id": author}, options.FindOne().SetProjection(privacy.Projection)).Decode(&relations)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil || !relations.Privacy.SharesActivity() {
+		return nil, err
+	}
+
+	online := make([]primitive.ObjectID, 0)
+	for _, friend := range relations.Friends {
+		if connections.connected(friend.Hex()) {
+			online = append(online, friend)
+		}
+	}
+	if len(online) == 0 {
+		return nil, nil
+	}
+	friends, err := privacy.Load(ctx, users, online)
+	if err != nil {
+		return nil, err
+	}
+	audience := make([]string, 0, len(friends))
+	for id, friend := range friends {
+		if privacy.CanView(friend, relations) {
+			audience = append(audience, id.Hex())
+		}
+	}
+	return audience, nil
+}
+
+func attribute(ep *socketio.EventPayload, key string) string {
+	value, _ := ep.Kws.GetAttribute(key).(string)
+	return value
+}
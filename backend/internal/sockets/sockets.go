@@ -3,17 +3,21 @@ package sockets
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"log/slog"
-	"net/http"
 
+	"github.com/abhikaboy/SocialToDo/internal/events"
 	"github.com/gofiber/contrib/socketio"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func New() {
+func New(bus *events.Bus, users *mongo.Collection) {
+	Subscribe(bus, users)
 
 	socketio.On(socketio.EventConnect, func(ep *socketio.EventPayload) {
 		ctx := context.Background()
+		if userID := attribute(ep, "user_id"); userID != "" {
+			connections.track(userID, ep.Kws.UUID)
+		}
 		slog.LogAttrs(ctx, slog.LevelInfo, "Connected Client")
 	})
 
@@ -21,30 +25,11 @@ func New() {
 
 	socketio.On(socketio.EventDisconnect, func(ep *socketio.EventPayload) {
 		ctx := context.Background()
-		user_type := ep.Kws.GetAttribute("user_type")
-		id := ep.Kws.GetAttribute("user_id")
-
-		fmt.Printf("Disconnected Client with UUID: %s and Type: %s\n", id, user_type)
-		// make an DELETE request to the socket endpoint
-		req, err := http.NewRequest(
-			"DELETE",
-			"http://localhost:8080/ws/"+user_type.(string)+"/"+id.(string),
-			nil,
-		)
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			slog.LogAttrs(ctx, slog.LevelError, "Error making request", slog.String("error", err.Error()))
-			return
-		}
-		defer resp.Body.Close()
-
-		fmt.Printf("http://localhost:8080/ws/" + user_type.(string) + "/" + id.(string) + "\n")
-
-		if err != nil {
-			slog.LogAttrs(ctx, slog.LevelError, "Error creating request", slog.String("error", err.Error()))
-			return
+		if userID := attribute(ep, "user_id"); userID != "" {
+			connections.untrack(userID, ep.Kws.UUID)
 		}
-		slog.LogAttrs(ctx, slog.LevelInfo, "Disconnected Client with UUID", slog.String("UUID", id.(string)))
+		// the socket handlers leave the room on their own disconnect listener
+		slog.LogAttrs(ctx, slog.LevelInfo, "Disconnected Client with UUID", slog.String("UUID", ep.Kws.UUID))
 	})
 
 	// Event CustomEvent
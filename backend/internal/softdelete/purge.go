@@ -0,0 +1,39 @@
+package softdelete
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/scheduler"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RegisterSchedules adds the nightly purge of everything deleted more than Retention ago
+func RegisterSchedules(s *scheduler.Scheduler, collections map[string]*mongo.Collection) {
+	users := collections["users"]
+	s.Register("trash-purge", "0 4 * * *", time.Hour, func(ctx context.Context) error {
+		cutoff := time.Now().Add(-Retention)
+
+		tasks, err := PurgeEmbedded(ctx, users, "categories.tasks", "categories.$[].tasks", cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to purge tasks: %w", err)
+		}
+		categories, err := PurgeEmbedded(ctx, users, "categories", "categories", cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to purge categories: %w", err)
+		}
+		purgedUsers, err := Purge(ctx, users, cutoff)
+		if err != nil {
+			return fmt.Errorf("failed to purge users: %w", err)
+		}
+
+		slog.LogAttrs(ctx, slog.LevelInfo, "Deleted documents purged",
+			slog.Int64("users_with_tasks", tasks),
+			slog.Int64("users_with_categories", categories),
+			slog.Int64("users", purgedUsers),
+		)
+		return nil
+	})
+}
@@ -0,0 +1,97 @@
+package softdelete
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Deleting a user, category or task stamps deleted_at instead of removing it.
+Reads exclude stamped documents (and stamped entries of embedded arrays), and
+the purge schedule removes them for good once Retention has passed, which
+leaves room for a trash to restore from.
+*/
+
+const (
+	Field = "deleted_at"
+
+	// how long deleted documents are kept before the purge removes them
+	Retention = 30 * 24 * time.Hour
+)
+
+// Deletable is implemented by documents that can be embedded in arrays, so Go code can drop deleted entries
+type Deletable interface {
+	IsDeleted() bool
+}
+
+// Live matches documents that haven't been deleted; merge it into $match and $elemMatch filters
+func Live() bson.M {
+	return bson.M{Field: nil}
+}
+
+// LiveAt is Live for the entries of an unwound array, e.g. LiveAt("categories.tasks")
+func LiveAt(path string) bson.M {
+	return bson.M{path + "." + Field: nil}
+}
+
+// Filter adds the Live condition to filter
+func Filter(filter bson.M) bson.M {
+	filter[Field] = nil
+	return filter
+}
+
+/*
+LiveElements is an aggregation expression for the array at path with its
+deleted entries left out, for use in $set or $project stages.
+*/
+func LiveElements(path string) bson.M {
+	return bson.M{"$filter": bson.M{
+		"input": "$" + path,
+		"cond":  bson.M{"$eq": bson.A{bson.M{"$ifNull": bson.A{"$$this." + Field, nil}}, nil}},
+	}}
+}
+
+// Visible returns the entries of items that haven't been deleted
+func Visible[T Deletable](items []T) []T {
+	visible := make([]T, 0, len(items))
+	for _, item := range items {
+		if !item.IsDeleted() {
+			visible = append(visible, item)
+		}
+	}
+	return visible
+}
+
+// Delete marks the live document matching filter as deleted at at
+func Delete(ctx context.Context, coll *mongo.Collection, filter bson.M, at time.Time) (*mongo.UpdateResult, error) {
+	return coll.UpdateOne(ctx, Filter(filter), bson.M{"$set": bson.M{Field: at}})
+}
+
+// Purge removes the documents of coll deleted before cutoff
+func Purge(ctx context.Context, coll *mongo.Collection, cutoff time.Time) (int64, error) {
+	result, err := coll.DeleteMany(ctx, bson.M{Field: bson.M{"$lt": cutoff}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+/*
+PurgeEmbedded pulls the entries deleted before cutoff out of an embedded array.
+match is the path to the entries as a query sees it ("categories.tasks") and
+array the update path of the array holding them ("categories.$[].tasks").
+*/
+func PurgeEmbedded(ctx context.Context, coll *mongo.Collection, match string, array string, cutoff time.Time) (int64, error) {
+	expired := bson.M{"$lt": cutoff}
+	result, err := coll.UpdateMany(ctx,
+		bson.M{match + "." + Field: expired},
+		bson.M{"$pull": bson.M{array: bson.M{Field: expired}}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
@@ -0,0 +1,86 @@
+package xfiles
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSBucket names the files and chunks collections, uploads.files and uploads.chunks
+const GridFSBucket = "uploads"
+
+/*
+GridFS keeps files in the database, for deployments without S3. Keys are used
+as the GridFS file ids. Every file passes through the API since nothing can
+be presigned.
+*/
+type GridFS struct {
+	db *mongo.Database
+}
+
+func NewGridFS(db *mongo.Database) *GridFS {
+	return &GridFS{db: db}
+}
+
+// bucket returns a bucket bounded by ctx's deadline; deadlines are per bucket, so buckets aren't shared
+func (g *GridFS) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(g.db, options.GridFSBucket().SetName(GridFSBucket))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		bucket.SetWriteDeadline(deadline)
+		bucket.SetReadDeadline(deadline)
+	}
+	return bucket, nil
+}
+
+func (g *GridFS) Put(ctx context.Context, key string, contentType string, body io.Reader) error {
+	bucket, err := g.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	// replacing a file means deleting the old one first, ids are unique
+	if err := bucket.DeleteContext(ctx, key); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return err
+	}
+	opts := options.GridFSUpload().SetMetadata(map[string]string{"content_type": contentType})
+	return bucket.UploadFromStreamWithID(key, key, body, opts)
+}
+
+func (g *GridFS) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	bucket, err := g.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	return stream, err
+}
+
+func (g *GridFS) Delete(ctx context.Context, key string) error {
+	bucket, err := g.bucket(ctx)
+	if err != nil {
+		return err
+	}
+	err = bucket.DeleteContext(ctx, key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (g *GridFS) PresignPut(context.Context, string, string, time.Duration) (string, error) {
+	return "", ErrNoPresign
+}
+
+func (g *GridFS) PresignGet(context.Context, string, time.Duration) (string, error) {
+	return "", ErrNoPresign
+}
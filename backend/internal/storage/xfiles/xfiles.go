@@ -0,0 +1,45 @@
+package xfiles
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Backends for user uploaded files. Files are addressed by key; metadata
+(owner, type, what the file is for) lives with the caller, not here.
+*/
+
+var (
+	// ErrNotFound is returned when no file is stored under the key
+	ErrNotFound = errors.New("file not found")
+	// ErrNoPresign is returned by backends that can't hand out direct URLs; clients upload through the API instead
+	ErrNoPresign = errors.New("backend does not support presigned urls")
+)
+
+type Backend interface {
+	Put(ctx context.Context, key string, contentType string, body io.Reader) error
+	// Open returns the file's contents; callers close it
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// PresignPut returns a URL the client can PUT the file to directly
+	PresignPut(ctx context.Context, key string, contentType string, ttl time.Duration) (string, error)
+	// PresignGet returns a URL the client can download the file from directly
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+}
+
+/*
+New returns the backend chosen by config, GridFS storing into db. config.Load
+has already rejected unknown backends.
+*/
+func New(db *mongo.Database, cfg config.Uploads, aws config.AWS) Backend {
+	if cfg.Backend == "s3" {
+		return NewS3(aws)
+	}
+	return NewGridFS(db)
+}
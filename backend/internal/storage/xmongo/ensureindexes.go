@@ -0,0 +1,64 @@
+package xmongo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+)
+
+/*
+Create every index in the registry. Creating an index that already exists with
+the same definition is a no-op on the server, so this is safe to run on every boot.
+Failures are logged and returned together; a missing index should degrade
+readiness rather than stop the process from starting.
+*/
+func (db *DB) EnsureIndexes(ctx context.Context) error {
+	var failed []string
+	for _, collection := range sortedCollections() {
+		models := Indexes[collection]
+		names, err := db.DB.Collection(collection).Indexes().CreateMany(ctx, models)
+		if err != nil {
+			slog.LogAttrs(
+				ctx,
+				slog.LevelError,
+				"Failed to ensure indexes",
+				slog.String("collection_name", collection),
+				slog.String("database_name", db.DB.Name()),
+				xslog.Error(err),
+			)
+			failed = append(failed, collection)
+			continue
+		}
+
+		// index creation implicitly creates the collection, make sure handlers can see it
+		if _, ok := db.Collections[collection]; !ok {
+			db.Collections[collection] = db.DB.Collection(collection)
+		}
+
+		slog.LogAttrs(
+			ctx,
+			slog.LevelInfo,
+			"Indexes ensured",
+			slog.String("collection_name", collection),
+			slog.String("database_name", db.DB.Name()),
+			slog.Any("indexes", names),
+		)
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to ensure indexes on %v", failed)
+	}
+	return nil
+}
+
+func sortedCollections() []string {
+	names := make([]string, 0, len(Indexes))
+	for name := range Indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -15,17 +15,413 @@ type Index struct {
 }
 
 /*
-Indexes to be applied to the database.
+Index registry, keyed by collection. Every index the application relies on is
+declared here and created (or verified) by EnsureIndexes at boot.
+Give each index an explicit name so changes show up clearly in the logs.
 */
-var Indexes = []Index{
-	{
-		Collection: "users",
-		Model: mongo.IndexModel{Keys: bson.D{
-			{Key: "apple_id", Value: 1},
-			{Key: "email", Value: 1},
-			{Key: "google_id", Value: 1},
+var Indexes = map[string][]mongo.IndexModel{
+	"users": {
+		{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetName("users_email_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}}),
 		},
-			Options: options.Index().SetUnique(true),
+		{
+			Keys: bson.D{{Key: "handle", Value: 1}},
+			Options: options.Index().SetName("users_handle_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"handle": bson.M{"$type": "string"}}),
+		},
+		{
+			Keys: bson.D{{Key: "apple_id", Value: 1}},
+			Options: options.Index().SetName("users_apple_id_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"apple_id": bson.M{"$type": "string"}}),
+		},
+		{
+			Keys: bson.D{{Key: "google_id", Value: 1}},
+			Options: options.Index().SetName("users_google_id_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"google_id": bson.M{"$type": "string"}}),
+		},
+		// one account signs in with each proven number
+		{
+			Keys: bson.D{{Key: "verified_phone", Value: 1}},
+			Options: options.Index().SetName("users_verified_phone_unique").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"verified_phone": bson.M{"$type": "string"}}),
+		},
+		// user search by display name prefix
+		{
+			Keys:    bson.D{{Key: "display_name_lower", Value: 1}},
+			Options: options.Index().SetName("users_display_name_lower"),
+		},
+		// single category / task lookups by id
+		{
+			Keys:    bson.D{{Key: "categories._id", Value: 1}},
+			Options: options.Index().SetName("users_categories_id"),
+		},
+		{
+			Keys:    bson.D{{Key: "categories.tasks._id", Value: 1}},
+			Options: options.Index().SetName("users_tasks_id"),
+		},
+		// the categories shared with a user
+		{
+			Keys:    bson.D{{Key: "categories.members.user", Value: 1}},
+			Options: options.Index().SetName("users_categories_members").SetSparse(true),
+		},
+		// tasks are embedded in the owner's categories, so the owner half of
+		// owner+due_date is the user document itself
+		{
+			Keys:    bson.D{{Key: "categories.tasks.due_date", Value: 1}},
+			Options: options.Index().SetName("users_tasks_due_date"),
+		},
+		// the ?label= and ?priority= filters of the task listings
+		{
+			Keys:    bson.D{{Key: "categories.tasks.labels", Value: 1}},
+			Options: options.Index().SetName("users_tasks_labels").SetSparse(true),
+		},
+		{
+			Keys:    bson.D{{Key: "categories.tasks.priority", Value: 1}},
+			Options: options.Index().SetName("users_tasks_priority"),
+		},
+		{
+			Keys:    bson.D{{Key: "categories.tasks.content", Value: "text"}},
+			Options: options.Index().SetName("users_tasks_text"),
+		},
+		// the trash purge
+		{
+			Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetName("users_deleted_at").SetSparse(true),
+		},
+		// admin lookups by role
+		{
+			Keys:    bson.D{{Key: "roles", Value: 1}},
+			Options: options.Index().SetName("users_roles").SetSparse(true),
+		},
+	},
+	"activity": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("activity_user_timestamp"),
+		},
+	},
+	"activity_archive": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "timestamp", Value: -1}},
+			Options: options.Index().SetName("activity_archive_user_timestamp"),
+		},
+	},
+	"analytics_events": {
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}, {Key: "occurred_at", Value: -1}},
+			Options: options.Index().SetName("analytics_events_name_occurred_at"),
+		},
+		{
+			// 90 days, keep in sync with xanalytics.Retention
+			Keys:    bson.D{{Key: "received_at", Value: 1}},
+			Options: options.Index().SetName("analytics_events_ttl").SetExpireAfterSeconds(90 * 24 * 60 * 60),
+		},
+	},
+	"flags": {
+		// the admin listing
+		{
+			Keys:    bson.D{{Key: "updated_at", Value: -1}},
+			Options: options.Index().SetName("flags_updated_at"),
+		},
+	},
+	"schedules": {
+		// the admin status listing
+		{
+			Keys:    bson.D{{Key: "next_run_at", Value: 1}},
+			Options: options.Index().SetName("schedules_next_run_at"),
+		},
+	},
+	"tombstones": {
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetName("tombstones_user_deleted_at"),
+		},
+		{
+			// 30 days, keep in sync with tombstone.Retention
+			Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetName("tombstones_ttl").SetExpireAfterSeconds(30 * 24 * 60 * 60),
+		},
+	},
+	"jobs": {
+		// claim order
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "kind", Value: 1}, {Key: "run_at", Value: 1}},
+			Options: options.Index().SetName("jobs_status_kind_run_at"),
+		},
+		{
+			// 7 days, keep in sync with jobs.Retention; dead jobs are kept for inspection
+			Keys: bson.D{{Key: "finished_at", Value: 1}},
+			Options: options.Index().SetName("jobs_done_ttl").SetExpireAfterSeconds(7 * 24 * 60 * 60).
+				SetPartialFilterExpression(bson.M{"status": "done"}),
+		},
+	},
+	"outbox": {
+		// relay claim order
+		{
+			Keys:    bson.D{{Key: "published_at", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("outbox_pending"),
+		},
+		{
+			// 7 days, keep in sync with outbox.Retention; pending events have no published_at
+			Keys:    bson.D{{Key: "published_at", Value: 1}},
+			Options: options.Index().SetName("outbox_ttl").SetExpireAfterSeconds(7 * 24 * 60 * 60),
+		},
+	},
+	"uploads": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "purpose", Value: 1}, {Key: "status", Value: 1}},
+			Options: options.Index().SetName("uploads_user_purpose_status"),
+		},
+		{
+			// uploads never completed are dropped after a day
+			Keys: bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("uploads_pending_ttl").SetExpireAfterSeconds(24 * 60 * 60).
+				SetPartialFilterExpression(bson.M{"status": "pending"}),
+		},
+	},
+	"calendar_feeds": {
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetName("calendar_feeds_token").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}},
+			Options: options.Index().SetName("calendar_feeds_user"),
+		},
+	},
+	"device_codes": {
+		{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetName("device_codes_hash").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user_code", Value: 1}},
+			Options: options.Index().SetName("device_codes_user_code").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("device_codes_ttl").SetExpireAfterSeconds(0),
+		},
+	},
+	"exports": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("exports_user_created"),
+		},
+		// the purge schedule removes archives along with the documents, no TTL here
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("exports_created"),
+		},
+	},
+	"hooks": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "event", Value: 1}},
+			Options: options.Index().SetName("hooks_user_event"),
+		},
+	},
+	"imports": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("imports_user_created_at"),
+		},
+		{
+			// progress is only polled for a while, a month is plenty
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("imports_ttl").SetExpireAfterSeconds(30 * 24 * 60 * 60),
 		},
 	},
+	"inbound_hooks": {
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetName("inbound_hooks_token").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}},
+			Options: options.Index().SetName("inbound_hooks_user"),
+		},
+	},
+	// _id is the delivery's signature, kept until its timestamp could no longer pass
+	"inbound_hook_deliveries": {
+		{
+			Keys:    bson.D{{Key: "received_at", Value: 1}},
+			Options: options.Index().SetName("inbound_hook_deliveries_ttl").SetExpireAfterSeconds(15 * 60),
+		},
+	},
+	"inbound_addresses": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}},
+			Options: options.Index().SetName("inbound_addresses_user").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetName("inbound_addresses_token").SetUnique(true),
+		},
+	},
+	"integrations": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "provider", Value: 1}},
+			Options: options.Index().SetName("integrations_user_provider").SetUnique(true),
+		},
+		// webhook notifications look the connection up by channel
+		{
+			Keys:    bson.D{{Key: "channel.id", Value: 1}},
+			Options: options.Index().SetName("integrations_channel").SetSparse(true),
+		},
+		// slash commands look the link up by Slack member
+		{
+			Keys:    bson.D{{Key: "team_id", Value: 1}, {Key: "slack_user", Value: 1}},
+			Options: options.Index().SetName("integrations_slack_member").SetSparse(true),
+		},
+		// GitHub webhook deliveries look up who linked the repo
+		{
+			Keys:    bson.D{{Key: "links.repo", Value: 1}},
+			Options: options.Index().SetName("integrations_links_repo").SetSparse(true),
+		},
+		// the OAuth callbacks
+		{
+			Keys: bson.D{{Key: "state", Value: 1}},
+			Options: options.Index().SetName("integrations_state").
+				SetPartialFilterExpression(bson.M{"state": bson.M{"$type": "string"}}),
+		},
+	},
+	"scoped_tokens": {
+		{
+			Keys:    bson.D{{Key: "hash", Value: 1}},
+			Options: options.Index().SetName("scoped_tokens_hash").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}},
+			Options: options.Index().SetName("scoped_tokens_user"),
+		},
+	},
+	// a user's security events are listed newest first
+	"audit_log": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("audit_log_user_id"),
+		},
+		{
+			// 180 days, keep in sync with audit.Retention
+			Keys:    bson.D{{Key: "at", Value: 1}},
+			Options: options.Index().SetName("audit_log_ttl").SetExpireAfterSeconds(180 * 24 * 60 * 60),
+		},
+	},
+	"sessions": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "device_id", Value: 1}},
+			Options: options.Index().SetName("sessions_user_device").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("sessions_ttl").SetExpireAfterSeconds(0),
+		},
+	},
+	// one pending request per direction; the recipient's are listed newest first
+	"friend_requests": {
+		{
+			Keys:    bson.D{{Key: "from", Value: 1}, {Key: "to", Value: 1}},
+			Options: options.Index().SetName("friend_requests_from_to").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "to", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("friend_requests_to_created_at"),
+		},
+	},
+	// a task's comments, oldest first
+	"comments": {
+		{
+			Keys:    bson.D{{Key: "task", Value: 1}, {Key: "_id", Value: 1}},
+			Options: options.Index().SetName("comments_task_id"),
+		},
+	},
+	// each user adds an emoji to an activity item once, and the feed counts them per item
+	"reactions": {
+		{
+			Keys:    bson.D{{Key: "activity", Value: 1}, {Key: "user", Value: 1}, {Key: "emoji", Value: 1}},
+			Options: options.Index().SetName("reactions_activity_user_emoji").SetUnique(true),
+		},
+	},
+	// a push token belongs to one user at a time
+	"devices": {
+		{
+			Keys:    bson.D{{Key: "token", Value: 1}},
+			Options: options.Index().SetName("devices_token").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}},
+			Options: options.Index().SetName("devices_user"),
+		},
+	},
+	// unique keys make writing a notification idempotent, see notifications.Insert
+	"notifications": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetName("notifications_user_key").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("notifications_user_created_at"),
+		},
+		{
+			// 90 days, keep in sync with notifications.Retention
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("notifications_ttl").SetExpireAfterSeconds(90 * 24 * 60 * 60),
+		},
+	},
+	// the groups a user is in
+	"groups": {
+		{
+			Keys:    bson.D{{Key: "members", Value: 1}},
+			Options: options.Index().SetName("groups_members"),
+		},
+	},
+	// keyed by task, so a completion counts once; the stats of a user are summed from theirs
+	"completions": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "completed_at", Value: 1}},
+			Options: options.Index().SetName("completions_user_completed_at"),
+		},
+	},
+	// keyed by user; the nightly recomputation picks out the recently active
+	"stats": {
+		{
+			Keys:    bson.D{{Key: "last_active", Value: 1}},
+			Options: options.Index().SetName("stats_last_active").SetSparse(true),
+		},
+	},
+	// a badge is earned once
+	"user_badges": {
+		{
+			Keys:    bson.D{{Key: "user", Value: 1}, {Key: "badge", Value: 1}},
+			Options: options.Index().SetName("user_badges_user_badge").SetUnique(true),
+		},
+	},
+	// keyed by user, one code at a time
+	"password_resets": {
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetName("password_resets_ttl").SetExpireAfterSeconds(0),
+		},
+	},
+	// keyed by the number
+	"phone_codes": {
+		{
+			Keys:    bson.D{{Key: "purge_at", Value: 1}},
+			Options: options.Index().SetName("phone_codes_ttl").SetExpireAfterSeconds(0),
+		},
+	},
+}
+
+// IndexList flattens the registry, ordered by collection name
+func IndexList() []Index {
+	list := make([]Index, 0)
+	for _, collection := range sortedCollections() {
+		for _, model := range Indexes[collection] {
+			list = append(list, Index{Collection: collection, Model: model})
+		}
+	}
+	return list
 }
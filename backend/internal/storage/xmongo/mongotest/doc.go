@@ -0,0 +1,12 @@
+/*
+Package mongotest gives integration tests a throwaway MongoDB. Its helpers
+are only built with the integration tag:
+
+	go test -tags integration ./...
+
+By default a single-node replica set (transactions and change streams need
+one) is started in a container on first use and shared by the test binary;
+set MONGOTEST_URI to point the tests at a running replica set instead.
+Without either, the tests that need a database are skipped.
+*/
+package mongotest
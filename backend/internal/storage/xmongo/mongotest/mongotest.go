@@ -0,0 +1,110 @@
+//go:build integration
+
+package mongotest
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const image = "mongo:7.0"
+
+// collections the app reads without declaring indexes, EnsureIndexes creates the rest
+var unindexed = []string{"chats", "health", "migrations", "posts", "sample"}
+
+var (
+	start  sync.Once
+	client *mongo.Client
+	failed error
+
+	dockerAvailable = sync.OnceValue(func() (ok bool) {
+		// finding no Docker host panics rather than erring
+		defer func() {
+			if recover() != nil {
+				ok = false
+			}
+		}()
+		provider, err := testcontainers.ProviderDocker.GetProvider()
+		if err != nil {
+			return false
+		}
+		defer provider.Close()
+		return provider.Health(context.Background()) == nil
+	})
+)
+
+/*
+Database returns a fresh database on the shared server, with the indexes the
+app declares and every collection it reads, dropped when the test ends.
+*/
+func Database(t *testing.T) *xmongo.DB {
+	t.Helper()
+	if os.Getenv("MONGOTEST_URI") == "" && !dockerAvailable() {
+		t.Skip("mongotest: no Docker to start MongoDB in and MONGOTEST_URI isn't set")
+	}
+	start.Do(func() { client, failed = connect() })
+	if failed != nil {
+		t.Fatalf("mongotest: %v", failed)
+	}
+
+	ctx := context.Background()
+	db := &xmongo.DB{
+		Client:      client,
+		DB:          client.Database("test_" + primitive.NewObjectID().Hex()),
+		Collections: make(map[string]*mongo.Collection),
+	}
+	t.Cleanup(func() {
+		if err := db.DB.Drop(context.Background()); err != nil {
+			t.Logf("mongotest: dropping %s: %v", db.DB.Name(), err)
+		}
+	})
+	if err := db.EnsureIndexes(ctx); err != nil {
+		t.Fatalf("mongotest: %v", err)
+	}
+	for _, name := range unindexed {
+		if err := db.DB.CreateCollection(ctx, name); err != nil {
+			t.Fatalf("mongotest: %v", err)
+		}
+		db.Collections[name] = db.DB.Collection(name)
+	}
+	return db
+}
+
+// connect starts the container unless MONGOTEST_URI names a server; Ryuk removes it when the binary exits
+func connect() (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	uri := os.Getenv("MONGOTEST_URI")
+	if uri == "" {
+		container, err := mongodb.Run(ctx, image, mongodb.WithReplicaSet("rs0"))
+		if err != nil {
+			return nil, err
+		}
+		endpoint, err := container.ConnectionString(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// the replica set advertises the container's address, talk to the mapped port directly
+		uri = endpoint + "/?directConnection=true"
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
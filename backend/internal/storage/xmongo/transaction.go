@@ -0,0 +1,25 @@
+package xmongo
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+WithTransaction runs fn in a multi-document transaction on the client behind
+coll, retrying it on transient errors. Every operation inside fn must use the
+session context it is given, or it runs outside the transaction.
+*/
+func WithTransaction(ctx context.Context, coll *mongo.Collection, fn func(ctx mongo.SessionContext) error) error {
+	session, err := coll.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
@@ -14,7 +14,7 @@ var (
 		"restaurants": bson.M{
 			"$jsonSchema": restaurantsValidator,
 		},
-		"passwordResets": pwResetsValidator,
+		"password_resets": bson.M{"$jsonSchema": passwordResetsValidator},
 	}
 
 	defaultValidator = bson.M{
@@ -207,26 +207,24 @@ var (
 			},
 		},
 	}
-	pwResetsValidator = bson.M{
+	passwordResetsValidator = bson.M{
 		"bsonType": "object",
-		"required": []string{"_id", "email", "otp", "verified", "createdAt", "expiresAt"},
+		"required": []string{"_id", "hash", "attempts", "created_at", "expires_at"},
 		"properties": bson.M{
 			"_id": bson.M{
-				"bsonType": "objectId",
-			},
-			"email": bson.M{
-				"bsonType": "string",
+				"bsonType":    "objectId",
+				"description": "the user resetting their password",
 			},
-			"otp": bson.M{
+			"hash": bson.M{
 				"bsonType": "string",
 			},
-			"verified": bson.M{
-				"bsonType": "bool",
+			"attempts": bson.M{
+				"bsonType": bson.A{"int", "long"},
 			},
-			"createdAt": bson.M{
+			"created_at": bson.M{
 				"bsonType": "date",
 			},
-			"expiresAt": bson.M{
+			"expires_at": bson.M{
 				"bsonType": "date",
 			},
 		},
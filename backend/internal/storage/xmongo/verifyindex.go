@@ -0,0 +1,112 @@
+package xmongo
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Report which indexes from the Indexes registry are not present on the database.
+Indexes are matched on their key specification, names are ignored.
+*/
+func MissingIndexes(ctx context.Context, db *mongo.Database) ([]string, error) {
+	missing := make([]string, 0)
+	existing := make(map[string][]bson.Raw)
+
+	for _, index := range IndexList() {
+		specs, ok := existing[index.Collection]
+		if !ok {
+			cursor, err := db.Collection(index.Collection).Indexes().List(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list indexes on '%s': %w", index.Collection, err)
+			}
+			specs, err = indexKeys(ctx, cursor)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode indexes on '%s': %w", index.Collection, err)
+			}
+			existing[index.Collection] = specs
+		}
+
+		want, err := bson.Marshal(index.Model.Keys)
+		if err != nil {
+			return nil, err
+		}
+		if !containsKeys(specs, want) {
+			missing = append(missing, index.Collection+"."+keyString(index.Model.Keys))
+		}
+	}
+	return missing, nil
+}
+
+// indexKeys reads the key specifications off a cursor of indexes, in their fields' stored order
+func indexKeys(ctx context.Context, cursor *mongo.Cursor) ([]bson.Raw, error) {
+	var results []bson.Raw
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	specs := make([]bson.Raw, 0, len(results))
+	for _, result := range results {
+		// a bson.M would lose the order that makes {a: 1, b: 1} and {b: 1, a: 1} different indexes
+		key, ok := result.Lookup("key").DocumentOK()
+		if !ok {
+			return nil, fmt.Errorf("index without a key: %s", result)
+		}
+		specs = append(specs, key)
+	}
+	return specs, nil
+}
+
+func containsKeys(specs []bson.Raw, want bson.Raw) bool {
+	wantElems, err := want.Elements()
+	if err != nil {
+		return false
+	}
+	for _, spec := range specs {
+		elems, err := spec.Elements()
+		if err != nil || len(elems) != len(wantElems) {
+			continue
+		}
+		match := true
+		for i := range elems {
+			// compare numerically so int32(1) and int64(1) are the same direction
+			if elems[i].Key() != wantElems[i].Key() || !sameDirection(elems[i].Value(), wantElems[i].Value()) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func sameDirection(a, b bson.RawValue) bool {
+	if an, ok := a.AsInt64OK(); ok {
+		if bn, ok := b.AsInt64OK(); ok {
+			return an == bn
+		}
+	}
+	if af, ok := a.DoubleOK(); ok {
+		if bn, ok := b.AsInt64OK(); ok {
+			return int64(af) == bn
+		}
+	}
+	if bf, ok := b.DoubleOK(); ok {
+		if an, ok := a.AsInt64OK(); ok {
+			return int64(bf) == an
+		}
+	}
+	return a.Equal(b)
+}
+
+func keyString(keys interface{}) string {
+	raw, err := bson.Marshal(keys)
+	if err != nil {
+		return fmt.Sprint(keys)
+	}
+	return bson.Raw(raw).String()
+}
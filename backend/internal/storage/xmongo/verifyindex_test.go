@@ -0,0 +1,45 @@
+package xmongo
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIndexKeys(t *testing.T) {
+	// as listIndexes returns them, the compound key's fields in index order
+	cursor, err := mongo.NewCursorFromDocuments([]interface{}{
+		bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "_id", Value: 1}}}, {Key: "name", Value: "_id_"}},
+		bson.D{{Key: "v", Value: 2}, {Key: "key", Value: bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: -1}, {Key: "kind", Value: 1}}}, {Key: "name", Value: "user_created_at_kind"}},
+	}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	specs, err := indexKeys(context.Background(), cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		keys     bson.D
+		expected bool
+	}{
+		{"as stored", bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: -1}, {Key: "kind", Value: 1}}, true},
+		{"int64 directions", bson.D{{Key: "user", Value: int64(1)}, {Key: "created_at", Value: int64(-1)}, {Key: "kind", Value: int64(1)}}, true},
+		{"fields reordered", bson.D{{Key: "created_at", Value: -1}, {Key: "user", Value: 1}, {Key: "kind", Value: 1}}, false},
+		{"direction flipped", bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: 1}, {Key: "kind", Value: 1}}, false},
+		{"prefix", bson.D{{Key: "user", Value: 1}, {Key: "created_at", Value: -1}}, false},
+	}
+	for _, tt := range tests {
+		want, err := bson.Marshal(tt.keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := containsKeys(specs, want); got != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package xmongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Optimistic concurrency: versioned documents (or embedded entries) carry a
+version counter that every write bumps. A writer that read version n only
+succeeds while the stored version is still n, otherwise it gets a
+*VersionConflict and can reload and retry instead of overwriting the other
+write.
+*/
+
+const VersionField = "version"
+
+// ErrVersionConflict matches every *VersionConflict with errors.Is
+var ErrVersionConflict = errors.New("version conflict")
+
+type VersionConflict struct {
+	Expected int64
+	Current  int64
+}
+
+func (e *VersionConflict) Error() string {
+	return fmt.Sprintf("version conflict: expected %d, stored %d", e.Expected, e.Current)
+}
+
+func (e *VersionConflict) Is(target error) bool {
+	return target == ErrVersionConflict
+}
+
+type VersionedUpdate struct {
+	// Filter matches the target at the given version value; nil must match any version
+	Filter func(version any) bson.M
+	// Update is applied on a match; VersionPath is incremented alongside it
+	Update      bson.M
+	VersionPath string
+	// ArrayFilters and Projection are passed through to FindOneAndUpdate
+	ArrayFilters []interface{}
+	Projection   interface{}
+	// Current reads the stored version after a miss, to tell a conflict from a missing target
+	Current func(ctx context.Context) (int64, error)
+}
+
+/*
+UpdateVersioned applies u only if the target is still at version expected,
+and decodes the updated document into result when it isn't nil. A nil
+expected skips the check but still bumps the version. A missing target is
+mongo.ErrNoDocuments.
+*/
+func UpdateVersioned(ctx context.Context, coll *mongo.Collection, expected *int64, u VersionedUpdate, result interface{}) error {
+	var version any
+	if expected != nil {
+		version = MatchVersion(*expected)
+	}
+
+	update := bson.M{}
+	for op, fields := range u.Update {
+		update[op] = fields
+	}
+	inc, _ := update["$inc"].(bson.M)
+	if inc == nil {
+		inc = bson.M{}
+	}
+	inc[u.VersionPath] = 1
+	update["$inc"] = inc
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if u.ArrayFilters != nil {
+		opts.SetArrayFilters(options.ArrayFilters{Filters: u.ArrayFilters})
+	}
+	if u.Projection != nil {
+		opts.SetProjection(u.Projection)
+	}
+
+	res := coll.FindOneAndUpdate(ctx, u.Filter(version), update, opts)
+	err := res.Err()
+	if errors.Is(err, mongo.ErrNoDocuments) && expected != nil {
+		current, currentErr := u.Current(ctx)
+		if currentErr != nil {
+			return currentErr
+		}
+		return &VersionConflict{Expected: *expected, Current: current}
+	}
+	if err != nil || result == nil {
+		return err
+	}
+	return res.Decode(result)
+}
+
+// MatchVersion filters on a version field, documents written before versioning count as version 0
+func MatchVersion(version int64) any {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
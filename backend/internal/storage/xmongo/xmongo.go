@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"time"
 
 	"github.com/abhikaboy/SocialToDo/internal/config"
 	"go.mongodb.org/mongo-driver/bson"
@@ -15,11 +16,10 @@ type DB struct {
 	Client      *mongo.Client
 	DB          *mongo.Database
 	Collections map[string]*mongo.Collection
-	Stream      *mongo.ChangeStream
 }
 
 func New(ctx context.Context, cfg config.Atlas) (*DB, error) {
-	client, err := connectClient(ctx, cfg.URI())
+	client, err := connectClient(ctx, cfg.URI(), cfg.Timeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to setup client: %w", err)
 	}
@@ -32,23 +32,20 @@ func New(ctx context.Context, cfg config.Atlas) (*DB, error) {
 		return nil, err
 	}
 
-	requestsStream, err := db.Collection("users").Watch(ctx, bson.D{},
-		options.ChangeStream().SetFullDocument(options.UpdateLookup))
-
-	if err != nil {
-		return nil, err
-	}
 	return &DB{
 		Client:      client,
 		DB:          db,
 		Collections: collections,
-		Stream:      requestsStream,
 	}, nil
 }
 
-func connectClient(ctx context.Context, uri string) (*mongo.Client, error) {
+func connectClient(ctx context.Context, uri string, timeout time.Duration) (*mongo.Client, error) {
 	serverAPI := options.ServerAPI(options.ServerAPIVersion1)
-	opts := options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI)
+	opts := options.Client().ApplyURI(uri).SetServerAPIOptions(serverAPI).SetMonitor(newMonitor())
+	if timeout > 0 {
+		// applies to operations whose context has no deadline of its own (workers, scripts)
+		opts.SetTimeout(timeout)
+	}
 	client, err := mongo.Connect(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
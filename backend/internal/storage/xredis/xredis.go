@@ -0,0 +1,36 @@
+package xredis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+Redis through go-redis: a pooled client whose commands honour the context
+deadline. Callers use go-redis's typed commands; the names here only keep
+them from importing the driver for the client type and the missing-key error.
+*/
+
+// Client is a go-redis client
+type Client = redis.Client
+
+// ErrNil is returned for a missing key
+var ErrNil = redis.Nil
+
+func New(ctx context.Context, cfg config.Redis) (*Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:                  cfg.Addr,
+		Password:              cfg.Password,
+		DB:                    cfg.DB,
+		PoolSize:              cfg.PoolSize,
+		ContextTimeoutEnabled: true,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis at %s: %w", cfg.Addr, err)
+	}
+	return client, nil
+}
@@ -0,0 +1,82 @@
+package tombstone
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Tombstones record hard deletes so offline clients can learn about them on
+their next sync. They expire after Retention (TTL index in xmongo.Indexes);
+clients that have been offline longer than that must do a full resync.
+*/
+
+const (
+	Collection = "tombstones"
+	Retention  = 30 * 24 * time.Hour
+)
+
+type Entity string
+
+const (
+	Category Entity = "category"
+	Task     Entity = "task"
+)
+
+type Tombstone struct {
+	ID        primitive.ObjectID `bson:"_id" json:"-"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"-"`
+	Entity    Entity             `bson:"entity" json:"entity"`
+	EntityID  primitive.ObjectID `bson:"entity_id" json:"id"`
+	DeletedAt time.Time          `bson:"deleted_at" json:"deleted_at"`
+}
+
+// Record stores a tombstone for each deleted entity id
+func Record(ctx context.Context, tombstones *mongo.Collection, userID primitive.ObjectID, entity Entity, ids ...primitive.ObjectID) error {
+	if tombstones == nil || len(ids) == 0 {
+		return nil
+	}
+	now := time.Now()
+	docs := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		docs = append(docs, Tombstone{
+			ID:        primitive.NewObjectID(),
+			UserID:    userID,
+			Entity:    entity,
+			EntityID:  id,
+			DeletedAt: now,
+		})
+	}
+	_, err := tombstones.InsertMany(ctx, docs)
+	return err
+}
+
+// Forget drops the tombstones of an entity brought back from the trash, so syncing clients don't delete it again
+func Forget(ctx context.Context, tombstones *mongo.Collection, userID primitive.ObjectID, entity Entity, id primitive.ObjectID) error {
+	if tombstones == nil {
+		return nil
+	}
+	_, err := tombstones.DeleteMany(ctx, bson.M{"user_id": userID, "entity": entity, "entity_id": id})
+	return err
+}
+
+// Since lists the user's tombstones newer than since, oldest first
+func Since(ctx context.Context, tombstones *mongo.Collection, userID primitive.ObjectID, since time.Time) ([]Tombstone, error) {
+	cursor, err := tombstones.Find(ctx,
+		bson.M{"user_id": userID, "deleted_at": bson.M{"$gt": since}},
+		options.Find().SetSort(bson.M{"deleted_at": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Tombstone, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
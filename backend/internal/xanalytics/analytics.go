@@ -0,0 +1,126 @@
+package xanalytics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	gojson "github.com/goccy/go-json"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Client analytics: screen views and feature usage reported by the apps. Events
+are best effort end to end. They are sampled per user, scrubbed of anything
+that looks like personal data, buffered in memory and written to the sink in
+batches, and dropped rather than slowing requests down when the sink can't
+keep up.
+*/
+
+const (
+	Collection = "analytics_events"
+	// stored events are kept this long (TTL index in xmongo.Indexes)
+	Retention = 90 * 24 * time.Hour
+)
+
+type Type string
+
+const (
+	ScreenView   Type = "screen_view"
+	FeatureUsage Type = "feature_usage"
+)
+
+type Event struct {
+	Type       Type           `bson:"type" json:"type"`
+	Name       string         `bson:"name" json:"name"`
+	UserID     string         `bson:"user_id" json:"user_id"`
+	SessionID  string         `bson:"session_id,omitempty" json:"session_id,omitempty"`
+	Platform   string         `bson:"platform,omitempty" json:"platform,omitempty"`
+	AppVersion string         `bson:"app_version,omitempty" json:"app_version,omitempty"`
+	Properties map[string]any `bson:"properties,omitempty" json:"properties,omitempty"`
+	// OccurredAt is the client's clock, ReceivedAt the server's
+	OccurredAt time.Time `bson:"occurred_at" json:"occurred_at"`
+	ReceivedAt time.Time `bson:"received_at" json:"received_at"`
+}
+
+// Sink is where flushed batches go
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// NewSink returns the sink chosen by config; config.Load has already rejected unknown sinks
+func NewSink(collections map[string]*mongo.Collection, cfg config.Analytics) Sink {
+	switch cfg.Sink {
+	case "http":
+		return &HTTPSink{url: cfg.URL, token: cfg.Token, client: &http.Client{Timeout: 10 * time.Second}}
+	case "none":
+		return Discard{}
+	}
+	return &MongoSink{events: collections[Collection]}
+}
+
+type MongoSink struct {
+	events *mongo.Collection
+}
+
+func (s *MongoSink) Write(ctx context.Context, events []Event) error {
+	docs := make([]interface{}, len(events))
+	for i := range events {
+		docs[i] = events[i]
+	}
+	_, err := s.events.InsertMany(ctx, docs)
+	return err
+}
+
+// HTTPSink posts each batch as a JSON array to an external pipeline
+type HTTPSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (s *HTTPSink) Write(ctx context.Context, events []Event) error {
+	body, err := gojson.Marshal(events)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("analytics sink responded %s", resp.Status)
+	}
+	return nil
+}
+
+// Discard drops every event
+type Discard struct{}
+
+func (Discard) Write(context.Context, []Event) error { return nil }
+
+/*
+Sampled reports whether userID's events are kept at rate. Sampling by user
+rather than by event keeps whole sessions, so funnels stay intact.
+*/
+func Sampled(userID string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	return float64(h.Sum32()%10000) < rate*10000
+}
@@ -0,0 +1,91 @@
+package xanalytics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+)
+
+// flushes still pending at shutdown get this long, detached from the cancelled worker context
+const finalFlushTimeout = 5 * time.Second
+
+/*
+Buffer queues events in memory and flushes them to the sink from its own
+worker, in batches of up to batchSize or every interval, whichever comes
+first. A failed batch is logged and dropped.
+*/
+type Buffer struct {
+	sink      Sink
+	queue     chan Event
+	batchSize int
+	interval  time.Duration
+}
+
+func NewBuffer(sink Sink, size int, batchSize int, interval time.Duration) *Buffer {
+	return &Buffer{
+		sink:      sink,
+		queue:     make(chan Event, size),
+		batchSize: batchSize,
+		interval:  interval,
+	}
+}
+
+// Add queues events without blocking and returns how many fit in the buffer
+func (b *Buffer) Add(ctx context.Context, events []Event) int {
+	for i, event := range events {
+		select {
+		case b.queue <- event:
+		default:
+			slog.LogAttrs(ctx, slog.LevelWarn, "Analytics buffer full, dropping events", slog.Int("dropped", len(events)-i))
+			return i
+		}
+	}
+	return len(events)
+}
+
+// Run flushes until ctx is cancelled, then flushes what is left
+func (b *Buffer) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, b.batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.sink.Write(ctx, batch); err != nil {
+			slog.LogAttrs(ctx, slog.LevelError, "Failed to flush analytics events",
+				slog.Int("events", len(batch)), xslog.Error(err))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case event := <-b.queue:
+			batch = append(batch, event)
+			if len(batch) >= b.batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.Background(), finalFlushTimeout)
+			defer cancel()
+			for {
+				select {
+				case event := <-b.queue:
+					batch = append(batch, event)
+					if len(batch) >= b.batchSize {
+						flush(final)
+					}
+				default:
+					flush(final)
+					return
+				}
+			}
+		}
+	}
+}
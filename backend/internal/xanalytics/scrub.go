@@ -0,0 +1,64 @@
+package xanalytics
+
+import (
+	"regexp"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// properties whose names suggest personal data or secrets are dropped outright
+var sensitiveKeys = []string{"email", "phone", "password", "token", "secret", "name", "address", "birthday", "location"}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// runs of 7 or more digits, allowing the usual separators: phone and card numbers
+	numberPattern = regexp.MustCompile(`\+?\d[\d\s().\-]{5,}\d`)
+)
+
+/*
+Scrub removes personal data from event properties before they are stored:
+sensitive keys are dropped and emails and long numbers inside string values
+are masked. Nested maps and lists are scrubbed the same way.
+*/
+func Scrub(properties map[string]any) map[string]any {
+	if len(properties) == 0 {
+		return nil
+	}
+	clean := make(map[string]any, len(properties))
+	for key, value := range properties {
+		if sensitive(key) {
+			continue
+		}
+		clean[key] = scrubValue(value)
+	}
+	return clean
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func scrubValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		v = emailPattern.ReplaceAllString(v, redacted)
+		return numberPattern.ReplaceAllString(v, redacted)
+	case map[string]any:
+		return Scrub(v)
+	case []any:
+		list := make([]any, len(v))
+		for i := range v {
+			list[i] = scrubValue(v[i])
+		}
+		return list
+	default:
+		return v
+	}
+}
@@ -0,0 +1,26 @@
+package xcache
+
+import (
+	"context"
+
+	"github.com/abhikaboy/SocialToDo/internal/events"
+)
+
+/*
+InvalidateOn drops cached reads derived from a user's data whenever the change
+stream reports a write to it, covering writes that bypass the services
+(other instances, scripts, manual fixes).
+*/
+func InvalidateOn(bus *events.Bus, cache Cache) {
+	bus.Subscribe(func(ctx context.Context, event events.Event) {
+		if event.UserID == "" {
+			return
+		}
+		switch event.Type {
+		case events.TasksChanged:
+			Invalidate(ctx, cache, CategoriesTag(event.UserID))
+		case events.UserChanged:
+			Invalidate(ctx, cache, UserTag(event.UserID))
+		}
+	}, events.TasksChanged, events.UserChanged)
+}
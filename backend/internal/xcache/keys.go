@@ -0,0 +1,35 @@
+package xcache
+
+import "fmt"
+
+/*
+Keys and tags shared between the services that read and the services that
+write the same data. Tags are named after the data they cover.
+*/
+
+func UserTag(userID string) string {
+	return fmt.Sprintf("user:%s", userID)
+}
+
+func CategoriesTag(userID string) string {
+	return fmt.Sprintf("user:%s:categories", userID)
+}
+
+func CategoriesKey(userID string) string {
+	return fmt.Sprintf("categories:user:%s", userID)
+}
+
+// CategoriesFieldsKey caches a ?fields= projection of a user's categories
+func CategoriesFieldsKey(userID string, fields string) string {
+	return fmt.Sprintf("categories:user:%s:fields:%s", userID, fields)
+}
+
+// WidgetKey caches the home-screen widget payload for one day in one time zone
+func WidgetKey(userID string, tz string, date string) string {
+	return fmt.Sprintf("widget:user:%s:%s:%s", userID, tz, date)
+}
+
+// LeaderboardKey caches a user's leaderboard ranked by one count for one week
+func LeaderboardKey(userID string, by string, week string) string {
+	return fmt.Sprintf("leaderboard:user:%s:%s:%s", userID, by, week)
+}
@@ -0,0 +1,17 @@
+package xcache
+
+import (
+	"context"
+	"time"
+)
+
+// Noop never stores anything, every read goes to the database
+type Noop struct{}
+
+func (Noop) Get(context.Context, string, any) (bool, error) { return false, nil }
+
+func (Noop) Set(context.Context, string, any, time.Duration, ...string) error { return nil }
+
+func (Noop) Delete(context.Context, ...string) error { return nil }
+
+func (Noop) Invalidate(context.Context, ...string) error { return nil }
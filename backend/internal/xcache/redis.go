@@ -0,0 +1,84 @@
+package xcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xredis"
+	gojson "github.com/goccy/go-json"
+)
+
+const (
+	keyPrefix = "cache:"
+	tagPrefix = "cache-tag:"
+)
+
+type Redis struct {
+	client     *xredis.Client
+	defaultTTL time.Duration
+}
+
+func (r *Redis) Get(ctx context.Context, key string, dest any) (bool, error) {
+	data, err := r.client.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, xredis.ErrNil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := gojson.Unmarshal(data, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error {
+	if ttl <= 0 {
+		ttl = r.defaultTTL
+	}
+	data, err := gojson.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := r.client.Set(ctx, keyPrefix+key, data, ttl).Err(); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if err := r.client.SAdd(ctx, tagPrefix+tag, key).Err(); err != nil {
+			return err
+		}
+		// the tag set only has to outlive the entries it points at
+		if err := r.client.PExpire(ctx, tagPrefix+tag, ttl).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, keyPrefix+key)
+	}
+	return r.client.Del(ctx, prefixed...).Err()
+}
+
+func (r *Redis) Invalidate(ctx context.Context, tags ...string) error {
+	for _, tag := range tags {
+		keys, err := r.client.SMembers(ctx, tagPrefix+tag).Result()
+		if err != nil {
+			return err
+		}
+		if err := r.Delete(ctx, keys...); err != nil {
+			return err
+		}
+		if err := r.client.Del(ctx, tagPrefix+tag).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
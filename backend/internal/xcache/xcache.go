@@ -0,0 +1,71 @@
+package xcache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/storage/xredis"
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+)
+
+/*
+Cache for hot read paths. Entries are JSON encoded and can be grouped under
+tags so a write can invalidate every entry derived from the data it changed
+(e.g. all cached pages of a user's categories) without knowing the exact keys.
+*/
+type Cache interface {
+	// Get decodes the entry for key into dest and reports whether it was found
+	Get(ctx context.Context, key string, dest any) (bool, error)
+	Set(ctx context.Context, key string, value any, ttl time.Duration, tags ...string) error
+	Delete(ctx context.Context, keys ...string) error
+	// Invalidate removes every entry stored under any of the tags
+	Invalidate(ctx context.Context, tags ...string) error
+}
+
+/*
+New returns a Redis backed cache, or a no-op cache when caching is disabled
+or Redis is not configured.
+*/
+func New(redis *xredis.Client, cfg config.Cache) Cache {
+	if !cfg.Enabled || redis == nil {
+		slog.LogAttrs(context.Background(), slog.LevelInfo, "Running without a cache")
+		return Noop{}
+	}
+	ttl := cfg.DefaultTTL
+	if ttl <= 0 {
+		ttl = 5 * time.Minute
+	}
+	return &Redis{client: redis, defaultTTL: ttl}
+}
+
+/*
+Fetch returns the cached value for key, or calls load, caches its result under
+tags and returns it. Cache failures are logged and never fail the read.
+*/
+func Fetch[T any](ctx context.Context, cache Cache, key string, ttl time.Duration, tags []string, load func() (T, error)) (T, error) {
+	var cached T
+	found, err := cache.Get(ctx, key, &cached)
+	if err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "Cache read failed", slog.String("key", key), xslog.Error(err))
+	} else if found {
+		return cached, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	if err := cache.Set(ctx, key, value, ttl, tags...); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "Cache write failed", slog.String("key", key), xslog.Error(err))
+	}
+	return value, nil
+}
+
+// Invalidate drops the tagged entries, logging rather than failing the write that triggered it
+func Invalidate(ctx context.Context, cache Cache, tags ...string) {
+	if err := cache.Invalidate(ctx, tags...); err != nil {
+		slog.LogAttrs(ctx, slog.LevelWarn, "Cache invalidation failed", slog.Any("tags", tags), xslog.Error(err))
+	}
+}
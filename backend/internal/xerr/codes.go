@@ -0,0 +1,97 @@
+package xerr
+
+import (
+	"net/http"
+)
+
+/*
+Every error the API answers through ErrorHandler has the same body:
+
+	{"error": "message", "code": "NOT_FOUND", "request_id": "...", "details": ...}
+
+"error" stays the message string handlers have always answered with, so
+clients reading it keep working; "code" is what they should branch on.
+*/
+
+type Code string
+
+const (
+	CodeBadRequest  Code = "BAD_REQUEST"
+	CodeValidation  Code = "VALIDATION"
+	CodeInvalidJSON Code = "INVALID_JSON"
+	// no or malformed credentials
+	CodeAuthRequired Code = "AUTH_REQUIRED"
+	CodeAuthInvalid  Code = "AUTH_INVALID"
+	// the access and refresh tokens have both expired, sign in again
+	CodeAuthExpired    Code = "AUTH_EXPIRED"
+	CodeSessionRevoked Code = "SESSION_REVOKED"
+	// a rotated refresh token came back, the session was ended
+	CodeTokenReuse  Code = "TOKEN_REUSE"
+	CodeForbidden   Code = "FORBIDDEN"
+	CodeNotFound    Code = "NOT_FOUND"
+	CodeConflict    Code = "CONFLICT"
+	CodeTooLarge    Code = "TOO_LARGE"
+	CodeRateLimited Code = "RATE_LIMITED"
+	CodeTimeout     Code = "TIMEOUT"
+	CodeInternal    Code = "INTERNAL"
+)
+
+// Error is an API error with its status and code; return it from a handler and ErrorHandler answers it
+type Error struct {
+	Status  int
+	Code    Code
+	Message string
+	// e.g. the failed fields of a validation error
+	Details any
+}
+
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithDetails returns a copy of e carrying details, so package level errors can be shared
+func (e *Error) WithDetails(details any) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// Envelope is the JSON body of every error
+type Envelope struct {
+	Error     string `json:"error"`
+	Code      Code   `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// codeFor is the code of errors that only carry a status, like fiber.NewError's
+func codeFor(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeAuthInvalid
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodeTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeValidation
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusRequestTimeout, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
@@ -1,13 +1,13 @@
 package xerr
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 
-	go_json "github.com/goccy/go-json"
 	"go.mongodb.org/mongo-driver/mongo"
 
 	"github.com/abhikaboy/SocialToDo/internal/xslog"
@@ -54,84 +54,91 @@ func FailedValidation(c *fiber.Ctx, err mongo.CommandError) error {
 	return c.Status(int(err.Code)).JSON(msg)
 }
 
+/*
+ErrorHandler answers every error a handler or middleware returns with the
+Envelope. *Error and *fiber.Error keep their status; a missing document is
+a 404, a request past its deadline a 503, and anything else a 500 whose
+cause is only logged.
+*/
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	var e *fiber.Error
-	if errors.As(err, &e) {
-		e = err.(*fiber.Error)
-	} else {
-		ise := InternalServerError()
-		e = &ise
+	e := resolve(err)
+	// client errors are in the access log already
+	level := slog.LevelDebug
+	if e.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
 	}
-
 	slog.LogAttrs(
-		c.Context(),
-		slog.LevelError,
+		c.UserContext(),
+		level,
 		"Error handling request",
+		slog.String("code", string(e.Code)),
 		xslog.Error(err),
 	)
 
-	return c.Status(e.Code).JSON(e)
+	return c.Status(e.Status).JSON(Envelope{
+		Error:     e.Message,
+		Code:      e.Code,
+		RequestID: c.GetRespHeader(fiber.HeaderXRequestID),
+		Details:   e.Details,
+	})
 }
 
-func BadRequest(err error) fiber.Error {
-	return fiber.Error{
-		Code:    http.StatusBadRequest,
-		Message: err.Error(),
-	}
+// Status is the status ErrorHandler answers err with
+func Status(err error) int {
+	return resolve(err).Status
 }
 
-func InvalidJSON() fiber.Error {
-	return fiber.Error{
-		Code:    http.StatusBadRequest,
-		Message: "invalid JSON request data",
+func resolve(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return New(fiberErr.Code, codeFor(fiberErr.Code), fiberErr.Message)
 	}
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return New(http.StatusNotFound, CodeNotFound, "not found")
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(http.StatusServiceUnavailable, CodeTimeout, "request timed out")
+	}
+	return InternalServerError()
 }
 
-func NotFound(title string, withKey string, withValue any) fiber.Error {
-	return fiber.Error{
-		Code:    http.StatusNotFound,
-		Message: fmt.Sprintf("%s with %s='%s' not found", title, withKey, withValue),
-	}
+func BadRequest(err error) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, err.Error())
 }
 
-func Timeout(reason string) fiber.Error {
-	return fiber.Error{
-		Code:    http.StatusRequestTimeout,
-		Message: fmt.Sprintf("timeout: %s", reason),
-	}
+func InvalidJSON() *Error {
+	return New(http.StatusBadRequest, CodeInvalidJSON, "invalid JSON request data")
 }
 
-func Conflict(title string, withKey string, withValue any) fiber.Error {
-	return fiber.Error{
-		Code:    http.StatusConflict,
-		Message: fmt.Sprintf("conflict: %s with %s='%s' already exists", title, withKey, withValue),
-	}
+// Validation carries the fields validator rejected
+func Validation(details any) *Error {
+	return New(http.StatusBadRequest, CodeValidation, "invalid request data").WithDetails(details)
 }
 
-func InvalidRequestData(errors map[string]string) fiber.Error {
-	errorJSON, err := go_json.Marshal(errors)
-	if err != nil {
-		return fiber.Error{
-			Code:    http.StatusUnprocessableEntity,
-			Message: "invalid request data",
-		}
-	}
-	return fiber.Error{
-		Code:    http.StatusUnprocessableEntity,
-		Message: string(errorJSON),
-	}
+func NotFound(title string, withKey string, withValue any) *Error {
+	return New(http.StatusNotFound, CodeNotFound, fmt.Sprintf("%s with %s='%s' not found", title, withKey, withValue))
 }
 
-func InternalServerError() fiber.Error {
-	return fiber.Error{
-		Code:    http.StatusInternalServerError,
-		Message: "internal server error",
-	}
+func Timeout(reason string) *Error {
+	return New(http.StatusRequestTimeout, CodeTimeout, fmt.Sprintf("timeout: %s", reason))
 }
 
-func Unauthorized(reason string) fiber.Error {
-	return fiber.Error{
-		Code:    http.StatusUnauthorized,
-		Message: reason,
-	}
+func Conflict(title string, withKey string, withValue any) *Error {
+	return New(http.StatusConflict, CodeConflict, fmt.Sprintf("conflict: %s with %s='%s' already exists", title, withKey, withValue))
+}
+
+func InvalidRequestData(errors map[string]string) *Error {
+	return New(http.StatusUnprocessableEntity, CodeValidation, "invalid request data").WithDetails(errors)
+}
+
+func InternalServerError() *Error {
+	return New(http.StatusInternalServerError, CodeInternal, "internal server error")
+}
+
+func Unauthorized(reason string) *Error {
+	return New(http.StatusUnauthorized, CodeAuthInvalid, reason)
 }
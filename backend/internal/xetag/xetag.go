@@ -0,0 +1,132 @@
+package xetag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+ETags are a hash of the JSON body, so a resource's tag can be recomputed from
+the document alone. Versioned resources (tasks and categories) put their
+version in front of the hash: If-Match is checked against the version, which
+the repositories compare and bump atomically with the write, while the hash
+still changes with everything the response holds.
+*/
+
+var (
+	ErrIfMatchRequired = fiber.NewError(fiber.StatusPreconditionRequired, "If-Match is required, send the ETag of the version being changed")
+	ErrIfMatchInvalid  = fiber.NewError(fiber.StatusPreconditionFailed, "If-Match doesn't name a version of this resource, fetch it again before changing it")
+)
+
+// Compute returns the strong ETag for a response body
+func Compute(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// Of returns the ETag a GET handler would send for v
+func Of(v any) (string, error) {
+	body, err := gojson.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return Compute(body), nil
+}
+
+/*
+New tags successful GET responses and answers 304 Not Modified when the
+client's If-None-Match already has the current representation.
+*/
+func New(skip ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+		for _, path := range skip {
+			if c.Path() == path {
+				return c.Next()
+			}
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		body := c.Response().Body()
+		if c.Response().StatusCode() != fiber.StatusOK || len(body) == 0 {
+			return nil
+		}
+
+		etag := c.GetRespHeader(fiber.HeaderETag)
+		if etag == "" {
+			etag = Compute(body)
+			c.Set(fiber.HeaderETag, etag)
+		}
+		if matches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+			c.Context().ResetBody()
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return nil
+	}
+}
+
+// Versioned returns the ETag a GET handler sends for v at version
+func Versioned(version int64, v any) (string, error) {
+	etag, err := Of(v)
+	if err != nil {
+		return "", err
+	}
+	return `"v` + strconv.FormatInt(version, 10) + "-" + strings.Trim(etag, `"`) + `"`, nil
+}
+
+/*
+IfMatch is the version the request's If-Match precondition names, for the
+write to be made against. It is nil for *, which only requires the resource
+to exist. Modifying requests without If-Match are ErrIfMatchRequired.
+*/
+func IfMatch(c *fiber.Ctx) (*int64, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return nil, ErrIfMatchRequired
+	}
+	if header == "*" {
+		return nil, nil
+	}
+	// a single strong tag, a list couldn't name one version to write against
+	tag, ok := strings.CutPrefix(header, `"v`)
+	if !ok || !strings.HasSuffix(tag, `"`) || strings.Contains(tag, ",") {
+		return nil, ErrIfMatchInvalid
+	}
+	number, _, ok := strings.Cut(strings.TrimSuffix(tag, `"`), "-")
+	if !ok {
+		return nil, ErrIfMatchInvalid
+	}
+	version, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || version < 0 {
+		return nil, ErrIfMatchInvalid
+	}
+	return &version, nil
+}
+
+// matches checks a comma separated If-None-Match list against etag
+func matches(header string, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		// If-None-Match uses weak comparison (RFC 9110)
+		candidate = strings.TrimPrefix(candidate, "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
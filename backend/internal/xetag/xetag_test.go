@@ -0,0 +1,47 @@
+package xetag
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestIfMatch(t *testing.T) {
+	etag, err := Versioned(3, map[string]string{"name": "Chores"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	three := int64(3)
+
+	tests := []struct {
+		name            string
+		header          string
+		expectedVersion *int64
+		expectedErr     error
+	}{
+		{"a versioned ETag", etag, &three, nil},
+		{"any version", "*", nil, nil},
+		{"missing", "", nil, ErrIfMatchRequired},
+		{"a content hash", `"0123456789abcdef01234567"`, nil, ErrIfMatchInvalid},
+		{"weak", "W/" + etag, nil, ErrIfMatchInvalid},
+		{"a list", etag + `, "v4-abc"`, nil, ErrIfMatchInvalid},
+		{"not a number", `"vx-abc"`, nil, ErrIfMatchInvalid},
+	}
+	app := fiber.New()
+	for _, tt := range tests {
+		ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+		if tt.header != "" {
+			ctx.Request().Header.Set(fiber.HeaderIfMatch, tt.header)
+		}
+		version, err := IfMatch(ctx)
+		app.ReleaseCtx(ctx)
+
+		if err != tt.expectedErr {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.expectedErr, err)
+		}
+		if (version == nil) != (tt.expectedVersion == nil) || version != nil && *version != *tt.expectedVersion {
+			t.Errorf("%s: expected version %v, got %v", tt.name, tt.expectedVersion, version)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package xhttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateAddress is returned when a URL resolves to an address the server must not reach
+var ErrPrivateAddress = errors.New("target resolves to a private address")
+
+/*
+NewPublicClient returns a client for user-supplied URLs, such as webhook
+targets and pages to unfurl. It refuses to connect to loopback, private and
+link-local addresses, checked after DNS resolution so neither a redirect nor
+a rebound name gets around it. It follows up to maxRedirects redirects.
+*/
+func NewPublicClient(timeout time.Duration, maxRedirects int) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 5 * time.Second,
+				Control: func(_, address string, _ syscall.RawConn) error {
+					host, _, err := net.SplitHostPort(address)
+					if err != nil {
+						return err
+					}
+					ip := net.ParseIP(host)
+					if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() || ip.IsMulticast() {
+						return ErrPrivateAddress
+					}
+					return nil
+				},
+			}).DialContext,
+		},
+		CheckRedirect: func(_ *http.Request, via []*http.Request) error {
+			if maxRedirects == 0 {
+				return http.ErrUseLastResponse
+			}
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,138 @@
+package ximage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+/*
+Image variants for uploads, with only the standard library: images are
+decoded, scaled down with area averaging so thumbnails don't alias, and
+re-encoded. Re-encoding also strips metadata such as EXIF locations.
+*/
+
+type Variant struct {
+	Name string
+	// the longest side in pixels; images already smaller are kept at their size
+	MaxSide int
+}
+
+var Variants = []Variant{
+	{Name: "thumb", MaxSide: 128},
+	{Name: "small", MaxSide: 320},
+	{Name: "medium", MaxSide: 800},
+	{Name: "large", MaxSide: 1600},
+}
+
+// ErrUnsupported is returned for formats that can't be decoded
+var ErrUnsupported = errors.New("unsupported image format")
+
+// Largest pixel count that will be decoded, so a tiny file can't claim a huge canvas
+const maxPixels = 40_000_000
+
+func Lookup(name string) (Variant, bool) {
+	for _, v := range Variants {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+/*
+Resize reads an image and writes the variant to w, as PNG for sources with
+transparency (PNG and GIF) and JPEG otherwise. It returns the written
+content type.
+*/
+func Resize(r io.Reader, w io.Writer, v Variant) (string, error) {
+	src, format, err := decode(r)
+	if err != nil {
+		return "", err
+	}
+	dst := scale(src, v.MaxSide)
+	if format == "png" || format == "gif" {
+		return "image/png", png.Encode(w, dst)
+	}
+	return "image/jpeg", jpeg.Encode(w, dst, &jpeg.Options{Quality: 85})
+}
+
+func decode(r io.Reader) (image.Image, string, error) {
+	// header first, to refuse oversized canvases before allocating them
+	buf := &peekReader{r: r}
+	cfg, format, err := image.DecodeConfig(buf)
+	if err != nil {
+		return nil, "", ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", errors.New("image dimensions are too large")
+	}
+	full := io.MultiReader(buf.replay(), r)
+	var img image.Image
+	switch format {
+	case "jpeg":
+		img, err = jpeg.Decode(full)
+	case "png":
+		img, err = png.Decode(full)
+	case "gif":
+		img, err = gif.Decode(full)
+	default:
+		return nil, "", ErrUnsupported
+	}
+	return img, format, err
+}
+
+// scale fits src within maxSide, averaging every source pixel that falls in a destination pixel
+func scale(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	dw, dh := maxSide, h*maxSide/w
+	if h > w {
+		dw, dh = w*maxSide/h, maxSide
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+max((x+1)*w/dw, x*w/dw+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(src.At(sx, sy)).(color.NRGBA64)
+					r, g, bl, a = r+uint64(c.R), g+uint64(c.G), bl+uint64(c.B), a+uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(bl / n >> 8), A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return dst
+}
+
+// peekReader records what DecodeConfig reads so decoding can start from the beginning again
+type peekReader struct {
+	r    io.Reader
+	read []byte
+}
+
+func (p *peekReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read = append(p.read, b[:n]...)
+	return n, err
+}
+
+func (p *peekReader) replay() io.Reader {
+	return bytes.NewReader(p.read)
+}
@@ -0,0 +1,22 @@
+package xmail
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Log writes messages to the log instead of sending them, so codes can be read off it in development
+type Log struct{}
+
+func NewLog() *Log {
+	return &Log{}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	slog.LogAttrs(ctx, slog.LevelInfo, "Email not sent, MAIL_BACKEND=log",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("text", msg.Text),
+	)
+	return nil
+}
@@ -0,0 +1,94 @@
+package xmail
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	gojson "github.com/goccy/go-json"
+)
+
+// SES sends through the Amazon SES v2 API, signing requests with the AWS credentials from config
+type SES struct {
+	client      *http.Client
+	signer      *v4.Signer
+	credentials aws.Credentials
+	region      string
+	endpoint    string
+	from        string
+}
+
+func NewSES(cfg config.Mail, creds config.AWS) *SES {
+	return &SES{
+		client:      &http.Client{Timeout: 30 * time.Second},
+		signer:      v4.NewSigner(),
+		credentials: aws.Credentials{AccessKeyID: creds.AccessKeyID, SecretAccessKey: creds.SecretAccessKey},
+		region:      creds.Region,
+		endpoint:    "https://email." + creds.Region + ".amazonaws.com/v2/email/outbound-emails",
+		from:        cfg.From,
+	}
+}
+
+type sesContent struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+type sesEmail struct {
+	FromEmailAddress string `json:"FromEmailAddress"`
+	Destination      struct {
+		ToAddresses []string `json:"ToAddresses"`
+	} `json:"Destination"`
+	Content struct {
+		Simple struct {
+			Subject sesContent `json:"Subject"`
+			Body    struct {
+				Text sesContent `json:"Text"`
+			} `json:"Body"`
+		} `json:"Simple"`
+	} `json:"Content"`
+}
+
+func (s *SES) Send(ctx context.Context, msg Message) error {
+	var email sesEmail
+	email.FromEmailAddress = s.from
+	email.Destination.ToAddresses = []string{msg.To}
+	email.Content.Simple.Subject = sesContent{Data: msg.Subject, Charset: "UTF-8"}
+	email.Content.Simple.Body.Text = sesContent{Data: msg.Text, Charset: "UTF-8"}
+	body, err := gojson.Marshal(email)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	sum := sha256.Sum256(body)
+	if err := s.signer.SignHTTP(ctx, s.credentials, req, hex.EncodeToString(sum[:]), "ses", s.region, time.Now()); err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: ses responded %d: %s", ErrRejected, resp.StatusCode, detail)
+	}
+	return fmt.Errorf("ses responded %d: %s", resp.StatusCode, detail)
+}
@@ -0,0 +1,40 @@
+package xmail
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+)
+
+/*
+Outgoing email. Messages are plain text and sent from the configured From
+address; callers queue them as jobs so a provider hiccup is retried.
+*/
+
+// ErrRejected wraps failures retrying won't fix, like a malformed recipient
+var ErrRejected = errors.New("message rejected")
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+/*
+New returns the sender chosen by config. config.Load has already rejected
+unknown backends.
+*/
+func New(cfg config.Mail, aws config.AWS) Sender {
+	switch cfg.Backend {
+	case "smtp":
+		return NewSMTP(cfg)
+	case "ses":
+		return NewSES(cfg, aws)
+	}
+	return NewLog()
+}
@@ -0,0 +1,9 @@
+package xmetrics
+
+import (
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serves the default registry in the Prometheus exposition format
+var Handler = adaptor.HTTPHandler(promhttp.Handler())
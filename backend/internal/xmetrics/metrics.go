@@ -0,0 +1,80 @@
+package xmetrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+/*
+Application metrics, registered on the Prometheus default registry Handler
+serves alongside the Go runtime and process collectors. Keep every metric the
+service exports in this file so the full set of series is discoverable in one
+place.
+*/
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Count of HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "http_request_duration_seconds",
+		Help: "Latency of HTTP requests by route template and method.",
+	}, []string{"route", "method"})
+	MongoDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "mongo_command_duration_seconds",
+		Help: "Latency of MongoDB commands by command name and outcome.",
+	}, []string{"command", "outcome"})
+	ServiceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "service_call_duration_seconds",
+		Help: "Latency of service layer calls by service, method and outcome.",
+	}, []string{"service", "method", "outcome"})
+	TokenRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_refreshes_total",
+		Help: "Count of token pair refreshes by outcome.",
+	}, []string{"outcome"})
+	NotificationsDelivered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notifications_delivered_total",
+		Help: "Count of notification deliveries by channel and outcome.",
+	}, []string{"channel", "outcome"})
+	JobsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_processed_total",
+		Help: "Count of background job runs by kind and outcome (success, retry, dead).",
+	}, []string{"kind", "outcome"})
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "job_duration_seconds",
+		Help: "Latency of background job runs by kind.",
+	}, []string{"kind"})
+	ScheduledRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "scheduled_runs_total",
+		Help: "Count of scheduled (cron) runs executed by this instance by schedule and outcome.",
+	}, []string{"schedule", "outcome"})
+)
+
+// Outcome maps an error to the "outcome" label value used across metrics
+func Outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
+
+/*
+Track times a service layer call. Use it with a named error return:
+
+	func (s *Service) Login(...) (err error) {
+		defer xmetrics.Track("auth", "Login")(&err)
+		...
+	}
+*/
+func Track(service, method string) func(*error) {
+	start := time.Now()
+	return func(errp *error) {
+		var err error
+		if errp != nil {
+			err = *errp
+		}
+		ServiceDuration.WithLabelValues(service, method, Outcome(err)).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,168 @@
+package xopenapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	rawType      = reflect.TypeOf(json.RawMessage{})
+	bytesType    = reflect.TypeOf([]byte{})
+)
+
+// schemas collects the named struct types the operations refer to under components
+type schemas struct {
+	components map[string]any
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]any{}, names: map[reflect.Type]string{}}
+}
+
+// of returns the schema for t, a $ref for named structs
+func (s *schemas) of(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "description": "nanoseconds"}
+	case objectIDType:
+		return map[string]any{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	case rawType:
+		return map[string]any{}
+	case bytesType:
+		return map[string]any{"type": "string", "format": "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := s.of(t.Elem())
+		if _, ref := schema["$ref"]; ref {
+			return map[string]any{"allOf": []any{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	}
+	// interfaces and anything else take any value
+	return map[string]any{}
+}
+
+// ref registers the struct under components, named after its package and type
+func (s *schemas) ref(t reflect.Type) map[string]any {
+	name, ok := s.names[t]
+	if !ok {
+		name = path.Base(t.PkgPath()) + "." + t.Name()
+		// generic instantiations name their type arguments in brackets
+		name = strings.NewReplacer("[", "_", "]", "", "/", "_", "*", "").Replace(name)
+		s.names[t] = name
+		// placeholder first so a recursive type refers back to itself
+		s.components[name] = map[string]any{}
+		s.components[name] = s.object(t)
+	}
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *schemas) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	s.fields(t, properties, &required)
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// fields adds t's json fields, flattening embedded structs like encoding/json does
+func (s *schemas) fields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.fields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.of(field.Type)
+		if isRequired(field) {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// query lists the query tagged fields of t as query parameters
+func (s *schemas) query(t reflect.Type) []any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var params []any
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// embedded query structs, like a page's limit and cursor
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("query") == "" {
+			params = append(params, s.query(field.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("query"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		params = append(params, map[string]any{
+			"name":     name,
+			"in":       "query",
+			"required": isRequired(field),
+			"schema":   s.of(field.Type),
+		})
+	}
+	return params
+}
+
+func isRequired(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
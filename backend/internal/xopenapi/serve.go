@@ -0,0 +1,68 @@
+package xopenapi
+
+import (
+	"html/template"
+	"strings"
+	"sync"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+Handler serves the spec. It is built on the first request, once every package
+has mounted its routes.
+*/
+func Handler(app *fiber.App, info Info) fiber.Handler {
+	var (
+		once sync.Once
+		spec []byte
+		err  error
+	)
+	return func(c *fiber.Ctx) error {
+		once.Do(func() {
+			spec, err = gojson.Marshal(Document(app, info))
+		})
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(spec)
+	}
+}
+
+var ui = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="utf-8">
+	<title>{{.Title}}</title>
+	<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+	<div id="docs"></div>
+	<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+	<script>
+		window.ui = SwaggerUIBundle({ url: "{{.SpecURL}}", dom_id: "#docs" });
+	</script>
+</body>
+</html>
+`))
+
+/*
+UI serves Swagger UI for the spec at specURL. The page loads its assets from
+unpkg, so it relaxes the API's security headers, which allow no scripts or
+cross-origin resources at all.
+*/
+func UI(title string, specURL string) fiber.Handler {
+	var page strings.Builder
+	if err := ui.Execute(&page, struct{ Title, SpecURL string }{title, specURL}); err != nil {
+		panic(err)
+	}
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'none'; script-src 'unsafe-inline' https://unpkg.com; "+
+			"style-src https://unpkg.com; img-src 'self' data: https://unpkg.com; connect-src 'self'; frame-ancestors 'none'")
+		c.Set("Cross-Origin-Embedder-Policy", "unsafe-none")
+		c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+		return c.SendString(page.String())
+	}
+}
@@ -0,0 +1,259 @@
+package xopenapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/abhikaboy/SocialToDo/internal/xerr"
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+OpenAPI 3 documentation for the routes on the app. Handler packages register
+an Operation for each route they mount, keyed by method and full path as
+mounted ("GET /api/v1/Tasks/:id"), and the spec is built from the app's route
+table, so a route without an Operation shows up in Missing rather than
+silently dropping out of the docs. Schemas come from the Go types by
+reflection, reading the json and validate tags.
+*/
+
+// Operation documents one route
+type Operation struct {
+	Summary string
+	// Auth marks routes behind the auth middleware
+	Auth bool
+	// Query is a struct whose query tagged fields are the query parameters, or a Query
+	Query any
+	// Request is the body, nil for none
+	Request any
+	// RequestType is the body's media type, JSON when empty
+	RequestType string
+	// Response is the success body, nil for none
+	Response any
+	// ResponseType is the success body's media type, JSON when empty
+	ResponseType string
+	// Status of the success response, 200 when zero
+	Status int
+}
+
+// Query names optional string query parameters, for handlers that read them one by one
+type Query []string
+
+// Operations maps "METHOD /full/path" to the route's Operation
+type Operations map[string]Operation
+
+var registry = struct {
+	sync.Mutex
+	ops Operations
+}{ops: Operations{}}
+
+// Register adds ops to the documented routes; handler packages call it from Routes
+func Register(ops Operations) {
+	registry.Lock()
+	defer registry.Unlock()
+	for key, op := range ops {
+		registry.ops[key] = op
+	}
+}
+
+// Info heads the spec
+type Info struct {
+	Title   string
+	Version string
+	// ServerURL is where clients reach the API
+	ServerURL string
+}
+
+/*
+Missing lists the app's routes that have no Operation registered, and
+Operations registered for routes the app doesn't have (usually a typo in the
+key).
+*/
+func Missing(app *fiber.App) []string {
+	registry.Lock()
+	defer registry.Unlock()
+
+	var missing []string
+	mounted := map[string]bool{}
+	for _, route := range routes(app) {
+		key := route.Method + " " + route.Path
+		mounted[key] = true
+		if _, ok := registry.ops[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	for key := range registry.ops {
+		if !mounted[key] {
+			missing = append(missing, key+" (not mounted)")
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// Document builds the spec for the app's routes
+func Document(app *fiber.App, info Info) map[string]any {
+	registry.Lock()
+	defer registry.Unlock()
+
+	schemas := newSchemas()
+	paths := map[string]any{}
+	for _, route := range routes(app) {
+		op, ok := registry.ops[route.Method+" "+route.Path]
+		if !ok {
+			continue
+		}
+		path, params := openAPIPath(route)
+		item, _ := paths[path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation(route, op, params, schemas)
+	}
+
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   info.Title,
+			"version": info.Version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+	if info.ServerURL != "" {
+		doc["servers"] = []any{map[string]any{"url": info.ServerURL}}
+	}
+	return doc
+}
+
+// routes is the app's route table without the HEAD twins of GET routes and the Use middleware
+func routes(app *fiber.App) []fiber.Route {
+	var table []fiber.Route
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == http.MethodHead || route.Method == http.MethodConnect || route.Method == http.MethodTrace {
+			continue
+		}
+		table = append(table, route)
+	}
+	return table
+}
+
+// openAPIPath turns "/Tasks/:id" into "/Tasks/{id}", and "*" into "{path}"
+func openAPIPath(route fiber.Route) (string, []string) {
+	segments := strings.Split(route.Path, "/")
+	var params []string
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			name := strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?")
+			segments[i] = "{" + name + "}"
+			params = append(params, name)
+		case segment == "*" || segment == "+":
+			segments[i] = "{path}"
+			params = append(params, "path")
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func operation(route fiber.Route, op Operation, params []string, schemas *schemas) map[string]any {
+	out := map[string]any{
+		"operationId": operationID(route),
+		"tags":        []string{tag(route.Path)},
+	}
+	if op.Summary != "" {
+		out["summary"] = op.Summary
+	}
+	if op.Auth {
+		out["security"] = []any{map[string]any{"bearer": []string{}}}
+	}
+
+	var parameters []any
+	for _, name := range params {
+		parameters = append(parameters, map[string]any{
+			"name": name, "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+		})
+	}
+	if names, ok := op.Query.(Query); ok {
+		for _, name := range names {
+			parameters = append(parameters, map[string]any{
+				"name": name, "in": "query", "schema": map[string]any{"type": "string"},
+			})
+		}
+	} else if op.Query != nil {
+		parameters = append(parameters, schemas.query(reflect.TypeOf(op.Query))...)
+	}
+	if len(parameters) > 0 {
+		out["parameters"] = parameters
+	}
+
+	if op.Request != nil {
+		out["requestBody"] = map[string]any{
+			"required": true,
+			"content":  content(op.RequestType, schemas.of(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := map[string]any{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = content(op.ResponseType, schemas.of(reflect.TypeOf(op.Response)))
+	}
+	out["responses"] = map[string]any{
+		fmt.Sprint(status): success,
+		"default": map[string]any{
+			"description": "Error",
+			"content":     content("", schemas.of(reflect.TypeOf(xerr.Envelope{}))),
+		},
+	}
+	return out
+}
+
+func content(mediaType string, schema map[string]any) map[string]any {
+	if mediaType == "" {
+		mediaType = fiber.MIMEApplicationJSON
+	}
+	return map[string]any{mediaType: map[string]any{"schema": schema}}
+}
+
+// operationID is the method and path in camel case, "getTasksById" for GET /api/v1/Tasks/:id
+func operationID(route fiber.Route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, segment := range strings.Split(strings.TrimPrefix(route.Path, "/api/v1"), "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, ":") {
+			b.WriteString("By")
+			segment = strings.TrimSuffix(segment[1:], "?")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' || r == '*' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+// tag groups operations by the first segment after the version, "tasks" for /api/v1/Tasks/:id
+func tag(path string) string {
+	for _, segment := range strings.Split(strings.TrimPrefix(path, "/api/v1"), "/") {
+		if segment != "" && !strings.HasPrefix(segment, ":") {
+			return strings.ToLower(segment)
+		}
+	}
+	return "root"
+}
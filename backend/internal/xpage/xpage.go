@@ -0,0 +1,157 @@
+package xpage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+Keyset pagination for the list endpoints. A list is sorted by one field and
+then _id, and a page's cursor holds both of its last item, so the next page
+starts right after it however many items share the sort key. Cursors are
+opaque to clients: they pass back ?cursor= as they got it in next_cursor.
+*/
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Query is the ?limit= and ?cursor= of a list request; embed it in the endpoint's own query
+type Query struct {
+	Limit int `query:"limit" validate:"omitempty,min=1,max=100"`
+	// next_cursor of the page before
+	Cursor string `query:"cursor"`
+}
+
+// Size is the page size asked for, or def when the client left it out
+func (q Query) Size(def int) int {
+	if q.Limit <= 0 {
+		return def
+	}
+	return min(q.Limit, MaxLimit)
+}
+
+type Page[T any] struct {
+	Items []T `json:"items"`
+	// pass as ?cursor= for the next page; empty on the last one
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type cursor struct {
+	Key any                `bson:"k,omitempty"`
+	ID  primitive.ObjectID `bson:"i"`
+}
+
+// Encode makes the cursor of an item sorted by key and then id; key is nil for lists sorted by _id alone
+func Encode(key any, id primitive.ObjectID) string {
+	raw, err := bson.Marshal(cursor{Key: key, ID: id})
+	if err != nil {
+		// every sort key is a plain bson value
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode reads a cursor from Encode, storing its sort key in key unless that's nil
+func Decode(raw string, key any) (primitive.ObjectID, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidCursor
+	}
+	var c struct {
+		Key bson.RawValue      `bson:"k"`
+		ID  primitive.ObjectID `bson:"i"`
+	}
+	if err := bson.Unmarshal(data, &c); err != nil || c.ID.IsZero() {
+		return primitive.NilObjectID, ErrInvalidCursor
+	}
+	if key != nil {
+		if c.Key.Type == 0 {
+			return primitive.NilObjectID, ErrInvalidCursor
+		}
+		if err := c.Key.Unmarshal(key); err != nil {
+			return primitive.NilObjectID, ErrInvalidCursor
+		}
+	}
+	return c.ID, nil
+}
+
+// Sort orders a list by field and then _id, ascending for order 1 and descending for -1
+func Sort(field string, order int) bson.D {
+	if field == "" || field == "_id" {
+		return bson.D{{Key: "_id", Value: order}}
+	}
+	return bson.D{{Key: field, Value: order}, {Key: "_id", Value: order}}
+}
+
+// After matches what follows the cursor's item in the Sort of field and order
+func After(field string, order int, key any, id primitive.ObjectID) bson.M {
+	op := "$gt"
+	if order < 0 {
+		op = "$lt"
+	}
+	if field == "" || field == "_id" {
+		return bson.M{"_id": bson.M{op: id}}
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: key}},
+		bson.M{field: key, "_id": bson.M{op: id}},
+	}}
+}
+
+/*
+New cuts items, fetched with a limit of size+1, to a page of size. When the
+extra item came back another page follows, and key gives the sort key and
+id of the page's last item for its cursor.
+*/
+func New[T any](items []T, size int, key func(T) (any, primitive.ObjectID)) *Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	page := &Page[T]{Items: items}
+	if len(items) > size {
+		page.Items = items[:size]
+		page.NextCursor = Encode(key(page.Items[size-1]))
+	}
+	return page
+}
+
+/*
+Cut pages a list loaded whole, like the categories embedded in a user: items
+are taken in id order, after the cursor's id. It's for lists a document
+bounds, where fetching everything is cheap and caching it is simpler.
+*/
+func Cut[T any](items []T, query Query, def int, id func(T) primitive.ObjectID) (*Page[T], error) {
+	sorted := slices.SortedStableFunc(slices.Values(items), func(a, b T) int {
+		return compare(id(a), id(b))
+	})
+	if query.Cursor != "" {
+		after, err := Decode(query.Cursor, nil)
+		if err != nil {
+			return nil, err
+		}
+		start, _ := slices.BinarySearchFunc(sorted, after, func(item T, after primitive.ObjectID) int {
+			return compare(id(item), after)
+		})
+		for start < len(sorted) && id(sorted[start]) == after {
+			start++
+		}
+		sorted = sorted[start:]
+	}
+	size := query.Size(def)
+	return New(sorted[:min(len(sorted), size+1)], size, func(item T) (any, primitive.ObjectID) {
+		return nil, id(item)
+	}), nil
+}
+
+func compare(a, b primitive.ObjectID) int {
+	return bytes.Compare(a[:], b[:])
+}
@@ -0,0 +1,139 @@
+package xpage
+
+import (
+	"encoding/base64"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursor(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+
+	var got time.Time
+	if decoded, err := Decode(Encode(at, id), &got); err != nil || decoded != id || !got.Equal(at) {
+		t.Errorf("time key: expected %s %s, got %s %s %v", at, id.Hex(), got, decoded.Hex(), err)
+	}
+	var offset int
+	if decoded, err := Decode(Encode(40, id), &offset); err != nil || decoded != id || offset != 40 {
+		t.Errorf("int key: expected 40 %s, got %d %s %v", id.Hex(), offset, decoded.Hex(), err)
+	}
+	if decoded, err := Decode(Encode(nil, id), nil); err != nil || decoded != id {
+		t.Errorf("id only: expected %s, got %s %v", id.Hex(), decoded.Hex(), err)
+	}
+
+	raw, _ := bson.Marshal(bson.M{"k": "soon"})
+	tests := []struct {
+		name   string
+		cursor string
+		key    any
+	}{
+		{"not base64", "not a cursor!", nil},
+		{"not bson", Encode(nil, id)[:5], nil},
+		{"no id", base64.RawURLEncoding.EncodeToString(raw), nil},
+		{"no key", Encode(nil, id), &got},
+		{"wrong key type", Encode("soon", id), &got},
+	}
+	for _, tt := range tests {
+		if _, err := Decode(tt.cursor, tt.key); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestAfter(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		field    string
+		order    int
+		key      any
+		expected bson.M
+	}{
+		{"by id", "_id", 1, nil, bson.M{"_id": bson.M{"$gt": id}}},
+		{"newest first", "timestamp", -1, at, bson.M{"$or": bson.A{
+			bson.M{"timestamp": bson.M{"$lt": at}},
+			bson.M{"timestamp": at, "_id": bson.M{"$lt": id}},
+		}}},
+	}
+	for _, tt := range tests {
+		got, _ := bson.MarshalExtJSON(After(tt.field, tt.order, tt.key, id), true, false)
+		expected, _ := bson.MarshalExtJSON(tt.expected, true, false)
+		if string(got) != string(expected) {
+			t.Errorf("%s: expected %s, got %s", tt.name, expected, got)
+		}
+	}
+	if sort := Sort("timestamp", -1); len(sort) != 2 || sort[1].Key != "_id" || sort[1].Value != -1 {
+		t.Errorf("expected timestamp then _id descending, got %v", sort)
+	}
+}
+
+func TestNew(t *testing.T) {
+	ids := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()}
+	key := func(id primitive.ObjectID) (any, primitive.ObjectID) { return nil, id }
+
+	page := New(ids, 2, key)
+	if len(page.Items) != 2 || page.NextCursor != Encode(nil, ids[1]) {
+		t.Errorf("expected 2 items and a cursor at the second, got %d %q", len(page.Items), page.NextCursor)
+	}
+	if page := New(ids[:2], 2, key); len(page.Items) != 2 || page.NextCursor != "" {
+		t.Errorf("expected the last page without a cursor, got %d %q", len(page.Items), page.NextCursor)
+	}
+	if page := New[primitive.ObjectID](nil, 2, key); page.Items == nil {
+		t.Error("expected an empty page to list no items rather than null")
+	}
+}
+
+func TestCut(t *testing.T) {
+	ids := make([]primitive.ObjectID, 5)
+	for i := range ids {
+		ids[i] = primitive.NewObjectIDFromTimestamp(time.Unix(int64(i), 0))
+	}
+	// stored out of order
+	items := []primitive.ObjectID{ids[3], ids[0], ids[4], ids[1], ids[2]}
+	id := func(id primitive.ObjectID) primitive.ObjectID { return id }
+
+	var seen []primitive.ObjectID
+	query := Query{Limit: 2}
+	for range 5 {
+		page, err := Cut(items, query, DefaultLimit, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen = append(seen, page.Items...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+	if !slices.Equal(seen, ids) {
+		t.Errorf("expected every item once in id order, got %v", seen)
+	}
+
+	// the item the cursor points at was deleted since
+	page, err := Cut([]primitive.ObjectID{ids[0], ids[2]}, Query{Cursor: Encode(nil, ids[1])}, DefaultLimit, id)
+	if err != nil || !slices.Equal(page.Items, ids[2:3]) {
+		t.Errorf("expected to carry on after a deleted item, got %v %v", page, err)
+	}
+	if _, err := Cut(items, Query{Cursor: "forged"}, DefaultLimit, id); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		limit    int
+		expected int
+	}{{0, 20}, {5, 5}, {500, MaxLimit}}
+	for _, tt := range tests {
+		if got := (Query{Limit: tt.limit}).Size(20); got != tt.expected {
+			t.Errorf("limit %d: expected %d, got %d", tt.limit, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package xpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	gojson "github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Apple accepts a provider token for an hour and throttles refreshing it more often than every 20 minutes
+const apnsTokenLifetime = 45 * time.Minute
+
+// APNs sends through Apple's HTTP/2 API with token-based authentication
+type APNs struct {
+	client   *http.Client
+	key      *ecdsa.PrivateKey
+	keyID    string
+	teamID   string
+	topic    string
+	endpoint string
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func NewAPNs(cfg config.Push) (*APNs, error) {
+	key, err := jwt.ParseECPrivateKeyFromPEM([]byte(cfg.APNsKey))
+	if err != nil {
+		return nil, err
+	}
+	endpoint := "https://api.push.apple.com/3/device/"
+	if cfg.APNsSandbox {
+		endpoint = "https://api.sandbox.push.apple.com/3/device/"
+	}
+	return &APNs{
+		// net/http negotiates HTTP/2 over TLS, which APNs requires
+		client:   &http.Client{Timeout: 30 * time.Second},
+		key:      key,
+		keyID:    cfg.APNsKeyID,
+		teamID:   cfg.APNsTeamID,
+		topic:    cfg.APNsTopic,
+		endpoint: endpoint,
+	}, nil
+}
+
+type apnsAlert struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+type apnsResponse struct {
+	Reason string `json:"reason"`
+}
+
+func (a *APNs) Send(ctx context.Context, msg Message) error {
+	payload := map[string]any{
+		"aps": map[string]any{
+			"alert": apnsAlert{Title: msg.Title, Body: msg.Body},
+			"sound": "default",
+		},
+	}
+	for k, v := range msg.Data {
+		payload[k] = v
+	}
+	body, err := gojson.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	token, err := a.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+msg.Token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("apns-topic", a.topic)
+	req.Header.Set("apns-push-type", "alert")
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var reply apnsResponse
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	_ = gojson.Unmarshal(detail, &reply)
+	switch {
+	case resp.StatusCode == http.StatusGone, reply.Reason == "BadDeviceToken", reply.Reason == "DeviceTokenNotForTopic":
+		return fmt.Errorf("%w: apns responded %d %s", ErrUnregistered, resp.StatusCode, reply.Reason)
+	case reply.Reason == "ExpiredProviderToken":
+		a.mu.Lock()
+		a.token = ""
+		a.mu.Unlock()
+	case resp.StatusCode == http.StatusBadRequest, resp.StatusCode == http.StatusRequestEntityTooLarge:
+		return fmt.Errorf("%w: apns responded %d %s", ErrRejected, resp.StatusCode, reply.Reason)
+	}
+	return fmt.Errorf("apns responded %d %s", resp.StatusCode, reply.Reason)
+}
+
+// providerToken is the signed JWT APNs authenticates the requests with, reused for apnsTokenLifetime
+func (a *APNs) providerToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Since(a.issuedAt) < apnsTokenLifetime {
+		return a.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": a.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = a.keyID
+	signed, err := token.SignedString(a.key)
+	if err != nil {
+		return "", err
+	}
+	a.token, a.issuedAt = signed, now
+	return signed, nil
+}
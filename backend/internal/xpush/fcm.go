@@ -0,0 +1,190 @@
+package xpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	gojson "github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCM sends through the Firebase Cloud Messaging HTTP v1 API as the project's service account
+type FCM struct {
+	client      *http.Client
+	key         *rsa.PrivateKey
+	clientEmail string
+	tokenURI    string
+	endpoint    string
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+type serviceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+func NewFCM(cfg config.Push) (*FCM, error) {
+	var account serviceAccount
+	if err := gojson.Unmarshal([]byte(cfg.FCMCredentials), &account); err != nil {
+		return nil, err
+	}
+	if account.ProjectID == "" || account.ClientEmail == "" {
+		return nil, errors.New("not a service account key")
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &FCM{
+		client:      &http.Client{Timeout: 30 * time.Second},
+		key:         key,
+		clientEmail: account.ClientEmail,
+		tokenURI:    account.TokenURI,
+		endpoint:    "https://fcm.googleapis.com/v1/projects/" + account.ProjectID + "/messages:send",
+	}, nil
+}
+
+type fcmMessage struct {
+	Message struct {
+		Token        string `json:"token"`
+		Notification struct {
+			Title string `json:"title,omitempty"`
+			Body  string `json:"body,omitempty"`
+		} `json:"notification"`
+		Data map[string]string `json:"data,omitempty"`
+	} `json:"message"`
+}
+
+type fcmError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (f *FCM) Send(ctx context.Context, msg Message) error {
+	var message fcmMessage
+	message.Message.Token = msg.Token
+	message.Message.Notification.Title = msg.Title
+	message.Message.Notification.Body = msg.Body
+	message.Message.Data = msg.Data
+	body, err := gojson.Marshal(message)
+	if err != nil {
+		return err
+	}
+	token, err := f.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var reply fcmError
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	_ = gojson.Unmarshal(detail, &reply)
+	for _, d := range reply.Error.Details {
+		if d.ErrorCode == "UNREGISTERED" {
+			return fmt.Errorf("%w: fcm responded %d", ErrUnregistered, resp.StatusCode)
+		}
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		f.mu.Lock()
+		f.accessToken = ""
+		f.mu.Unlock()
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: fcm responded %d", ErrUnregistered, resp.StatusCode)
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: fcm responded %d: %s", ErrRejected, resp.StatusCode, reply.Error.Message)
+	}
+	return fmt.Errorf("fcm responded %d: %s", resp.StatusCode, reply.Error.Message)
+}
+
+/*
+token is an OAuth access token for the service account, from the JWT bearer
+grant, reused until shortly before it expires.
+*/
+func (f *FCM) token(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.accessToken != "" && time.Until(f.expiresAt) > time.Minute {
+		return f.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.clientEmail,
+		"scope": fcmScope,
+		"aud":   f.tokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(f.key)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("google token endpoint responded %d: %s", resp.StatusCode, detail)
+	}
+
+	var grant struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := gojson.NewDecoder(resp.Body).Decode(&grant); err != nil {
+		return "", err
+	}
+	f.accessToken = grant.AccessToken
+	f.expiresAt = now.Add(time.Duration(grant.ExpiresIn) * time.Second)
+	return f.accessToken, nil
+}
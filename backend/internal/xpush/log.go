@@ -0,0 +1,24 @@
+package xpush
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Log writes pushes to the log instead of sending them, for platforms without credentials
+type Log struct {
+	platform Platform
+}
+
+func NewLog(platform Platform) *Log {
+	return &Log{platform: platform}
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) error {
+	slog.LogAttrs(ctx, slog.LevelInfo, "Push not sent, no credentials",
+		slog.String("platform", string(l.platform)),
+		slog.String("title", msg.Title),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
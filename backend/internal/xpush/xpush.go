@@ -0,0 +1,75 @@
+package xpush
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+)
+
+/*
+Push notifications to devices, through APNs for iOS and FCM for Android.
+Callers queue them as jobs so a provider hiccup is retried; a platform
+without credentials only logs its pushes.
+*/
+
+var (
+	// ErrUnregistered means the token is no longer valid and the device should be forgotten
+	ErrUnregistered = errors.New("device token unregistered")
+	// ErrRejected wraps failures retrying won't fix, like a malformed payload
+	ErrRejected = errors.New("push rejected")
+)
+
+type Platform string
+
+const (
+	IOS     Platform = "ios"
+	Android Platform = "android"
+)
+
+type Message struct {
+	Token string
+	Title string
+	Body  string
+	// delivered to the app alongside the alert, e.g. what to open
+	Data map[string]string
+}
+
+// Sender delivers messages through one platform's push service
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Pusher sends each message through the sender of its device's platform
+type Pusher struct {
+	senders map[Platform]Sender
+}
+
+// New returns a pusher with the senders config has credentials for
+func New(cfg config.Push) (*Pusher, error) {
+	p := &Pusher{senders: map[Platform]Sender{IOS: NewLog(IOS), Android: NewLog(Android)}}
+	if cfg.APNsKey != "" {
+		apns, err := NewAPNs(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("PUSH_APNS_KEY: %w", err)
+		}
+		p.senders[IOS] = apns
+	}
+	if cfg.FCMCredentials != "" {
+		fcm, err := NewFCM(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("PUSH_FCM_CREDENTIALS: %w", err)
+		}
+		p.senders[Android] = fcm
+	}
+	return p, nil
+}
+
+func (p *Pusher) Send(ctx context.Context, platform Platform, msg Message) error {
+	sender, ok := p.senders[platform]
+	if !ok {
+		return fmt.Errorf("%w: unknown platform %q", ErrRejected, platform)
+	}
+	return sender.Send(ctx, msg)
+}
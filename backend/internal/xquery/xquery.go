@@ -0,0 +1,150 @@
+package xquery
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Fields is a parsed ?fields= list. Clients name fields by their JSON names;
+Fields maps them to document paths for the Mongo projection and maps the
+projected document back to JSON names for the response.
+*/
+type Fields struct {
+	json []string
+	// json name -> bson name
+	paths map[string]string
+}
+
+/*
+ParseFields validates a comma separated ?fields= value against the json/bson
+tags of model. It returns nil when raw is empty, meaning all fields. The id
+is always included.
+*/
+func ParseFields(raw string, model any) (*Fields, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	known := tagNames(reflect.TypeOf(model))
+
+	f := &Fields{paths: map[string]string{"id": "_id"}}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		path, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		f.paths[name] = path
+	}
+	for name := range f.paths {
+		f.json = append(f.json, name)
+	}
+	sort.Strings(f.json)
+	return f, nil
+}
+
+// All selects every field of model, for renaming documents that weren't projected
+func All(model any) *Fields {
+	f := &Fields{paths: tagNames(reflect.TypeOf(model))}
+	for name := range f.paths {
+		f.json = append(f.json, name)
+	}
+	sort.Strings(f.json)
+	return f
+}
+
+// Projection returns a $project stage value including extra (already projected) keys
+func (f *Fields) Projection(extra ...string) bson.M {
+	projection := bson.M{}
+	for _, path := range f.paths {
+		projection[path] = 1
+	}
+	for _, key := range extra {
+		projection[key] = 1
+	}
+	return projection
+}
+
+// Key is a stable string form of the field list, for cache keys
+func (f *Fields) Key() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(f.json, ",")
+}
+
+// Rename converts a projected document's keys from bson to json names
+func (f *Fields) Rename(doc bson.M) map[string]any {
+	names := make(map[string]string, len(f.paths))
+	for name, path := range f.paths {
+		names[path] = name
+	}
+	out := make(map[string]any, len(doc))
+	for key, value := range doc {
+		if name, ok := names[key]; ok {
+			key = name
+		}
+		out[key] = value
+	}
+	return out
+}
+
+/*
+ParseExpand validates a comma separated ?expand= value against the relations
+the endpoint supports.
+*/
+func ParseExpand(raw string, supported ...string) (map[string]bool, error) {
+	expand := map[string]bool{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, s := range supported {
+			found = found || s == name
+		}
+		if !found {
+			return nil, fmt.Errorf("cannot expand %q, supported: %s", name, strings.Join(supported, ", "))
+		}
+		expand[name] = true
+	}
+	return expand, nil
+}
+
+// tagNames maps the json name of every field of t to its bson name
+func tagNames(t reflect.Type) map[string]string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	names := map[string]string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		jsonName := tagName(field.Tag.Get("json"))
+		bsonName := tagName(field.Tag.Get("bson"))
+		if jsonName == "-" || bsonName == "-" {
+			continue
+		}
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		if bsonName == "" {
+			bsonName = strings.ToLower(field.Name)
+		}
+		names[jsonName] = bsonName
+	}
+	return names
+}
+
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(tag, ",")
+	return name
+}
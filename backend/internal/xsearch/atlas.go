@@ -0,0 +1,70 @@
+package xsearch
+
+import (
+	"context"
+
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Atlas searches through an Atlas Search index on the users collection, which
+adds typo tolerance. The index has to map handle, display_name and
+categories.tasks.content as strings and _id and friends as objectIds; it is
+managed in Atlas, not by EnsureIndexes.
+*/
+type Atlas struct {
+	users *mongo.Collection
+	index string
+}
+
+var fuzzy = bson.M{"maxEdits": 1, "prefixLength": 1}
+
+func (a *Atlas) Tasks(ctx context.Context, owner primitive.ObjectID, q Query) ([]TaskHit, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$search", Value: bson.M{
+			"index": a.index,
+			"compound": bson.M{
+				"filter": bson.A{bson.M{"equals": bson.M{"path": "_id", "value": owner}}},
+				"must":   bson.A{bson.M{"text": bson.M{"query": q.Text, "path": "categories.tasks.content", "fuzzy": fuzzy}}},
+			},
+		}}},
+		{{Key: "$match", Value: softdelete.Live()}},
+	}
+	cursor, err := a.users.Aggregate(ctx, append(pipeline, taskStages(q.Text, q.Limit)...))
+	return decode[TaskHit](ctx, cursor, err)
+}
+
+func (a *Atlas) Users(ctx context.Context, q Query) ([]UserHit, error) {
+	compound := bson.M{
+		"should": bson.A{
+			// a handle match outranks a display name match
+			bson.M{"text": bson.M{"query": q.Text, "path": "handle", "fuzzy": fuzzy, "score": bson.M{"boost": bson.M{"value": 2}}}},
+			bson.M{"text": bson.M{"query": q.Text, "path": "display_name", "fuzzy": fuzzy}},
+		},
+		"minimumShouldMatch": 1,
+	}
+	if q.Network != nil {
+		compound["filter"] = bson.A{bson.M{"compound": bson.M{
+			"should": bson.A{
+				bson.M{"in": bson.M{"path": "_id", "value": q.Network}},
+				bson.M{"in": bson.M{"path": "friends", "value": q.Network}},
+			},
+			"minimumShouldMatch": 1,
+		}}}
+	}
+	cursor, err := a.users.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$search", Value: bson.M{"index": a.index, "compound": compound}}},
+		{{Key: "$match", Value: softdelete.Live()}},
+		{{Key: "$limit", Value: q.Limit}},
+		{{Key: "$project", Value: bson.M{
+			"handle":          1,
+			"display_name":    1,
+			"profile_picture": 1,
+			"score":           bson.M{"$meta": "searchScore"},
+		}}},
+	})
+	return decode[UserHit](ctx, cursor, err)
+}
@@ -0,0 +1,85 @@
+package xsearch
+
+import (
+	"cmp"
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+Mongo searches with plain MongoDB queries: tasks through the users_tasks_text
+index, users by prefix on handle and display name. A collection can only have
+one text index and the users one covers task content, so user search can't
+use $text. Handles are stored lowercase after an "@" and display names are
+copied lowercase to display_name_lower, so both are matched by a
+case-sensitive prefix, which is answered from the users_handle_unique and
+users_display_name_lower indexes.
+*/
+type Mongo struct {
+	users *mongo.Collection
+}
+
+func (m *Mongo) Tasks(ctx context.Context, owner primitive.ObjectID, q Query) ([]TaskHit, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: softdelete.Filter(bson.M{"_id": owner, "$text": bson.M{"$search": q.Text}})}},
+	}
+	cursor, err := m.users.Aggregate(ctx, append(pipeline, taskStages(q.Text, q.Limit)...))
+	return decode[TaskHit](ctx, cursor, err)
+}
+
+func (m *Mongo) Users(ctx context.Context, q Query) ([]UserHit, error) {
+	term := handleTerm(q.Text)
+	match := bson.A{bson.M{"handle": primitive.Regex{Pattern: "^@" + regexp.QuoteMeta(term)}}}
+	// "@ab" only asks for handles
+	if !strings.HasPrefix(q.Text, "@") {
+		match = append(match, bson.M{"display_name_lower": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.ToLower(q.Text))}})
+	}
+	filter := bson.M{"$or": match}
+	if q.Network != nil {
+		filter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{"_id": bson.M{"$in": q.Network}},
+			bson.M{"friends": bson.M{"$in": q.Network}},
+		}}}}
+	}
+	filter = softdelete.Filter(filter)
+	cursor, err := m.users.Find(ctx, filter, options.Find().
+		SetProjection(bson.M{"handle": 1, "display_name": 1, "profile_picture": 1}).
+		SetSort(bson.D{{Key: "handle", Value: 1}}).
+		SetLimit(int64(q.Limit)))
+	users, err := decode[UserHit](ctx, cursor, err)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		users[i].Score = prefixScore(users[i], q.Text)
+	}
+	slices.SortStableFunc(users, func(a, b UserHit) int { return cmp.Compare(b.Score, a.Score) })
+	return users, nil
+}
+
+// prefixScore ranks an exact handle above a handle prefix above a display name prefix
+func prefixScore(user UserHit, text string) float64 {
+	term, handle := handleTerm(text), strings.TrimPrefix(user.Handle, "@")
+	switch {
+	case handle == term:
+		return 3
+	case strings.HasPrefix(handle, term):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// handleTerm is text as it would appear in a stored handle, without the "@"
+func handleTerm(text string) string {
+	return strings.ToLower(strings.TrimPrefix(text, "@"))
+}
@@ -0,0 +1,67 @@
+//go:build integration
+
+package xsearch
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/abhikaboy/SocialToDo/internal/storage/xmongo/mongotest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMongoUsers(t *testing.T) {
+	db := mongotest.Database(t)
+	engine := &Mongo{users: db.Collections["users"]}
+	ctx := context.Background()
+
+	me, friend, friendOfFriend, stranger := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	users := []struct {
+		id      primitive.ObjectID
+		name    string
+		friends []primitive.ObjectID
+	}{
+		{me, "Me", []primitive.ObjectID{friend}},
+		{friend, "Sam Friend", []primitive.ObjectID{me, friendOfFriend}},
+		{friendOfFriend, "SAM Once Removed", []primitive.ObjectID{friend}},
+		{stranger, "Samantha Stranger", []primitive.ObjectID{}},
+	}
+	for _, u := range users {
+		_, err := db.Collections["users"].InsertOne(ctx, map[string]any{
+			"_id":                u.id,
+			"handle":             "@user" + u.id.Hex(),
+			"display_name":       u.name,
+			"display_name_lower": strings.ToLower(u.name),
+			"friends":            u.friends,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected []primitive.ObjectID
+	}{
+		{"display names in any case", Query{Text: "sAm", Limit: 10}, []primitive.ObjectID{friend, friendOfFriend, stranger}},
+		{"among friends and their friends", Query{Text: "sam", Limit: 10, Network: []primitive.ObjectID{friend}}, []primitive.ObjectID{friend, friendOfFriend}},
+		{"handles only", Query{Text: "@sam", Limit: 10}, []primitive.ObjectID{}},
+	}
+	for _, tt := range tests {
+		hits, err := engine.Users(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		found := make([]primitive.ObjectID, 0, len(hits))
+		for _, hit := range hits {
+			found = append(found, hit.ID)
+		}
+		slices.SortFunc(found, func(a, b primitive.ObjectID) int { return strings.Compare(a.Hex(), b.Hex()) })
+		if !slices.Equal(found, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, found)
+		}
+	}
+}
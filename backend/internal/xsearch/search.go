@@ -0,0 +1,111 @@
+package xsearch
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/abhikaboy/SocialToDo/internal/softdelete"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+/*
+Engine is the search backend behind the search endpoints. Both engines here
+query the users collection directly, tasks being embedded in their owner's
+categories, so nothing has to be indexed on write. An external engine such as
+Meilisearch would keep its own index and follow writes through the events bus
+(events.TasksChanged, events.UserChanged).
+*/
+type Engine interface {
+	// Tasks searches the owner's own live tasks
+	Tasks(ctx context.Context, owner primitive.ObjectID, q Query) ([]TaskHit, error)
+	// Users searches live users by handle and display name
+	Users(ctx context.Context, q Query) ([]UserHit, error)
+}
+
+type Query struct {
+	Text  string
+	Limit int
+	// for Users, when set: only these users and their friends
+	Network []primitive.ObjectID
+}
+
+type TaskHit struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	CategoryID   primitive.ObjectID `bson:"category_id" json:"category_id"`
+	CategoryName string             `bson:"category_name" json:"category_name"`
+	Content      string             `bson:"content" json:"content"`
+	Priority     int                `bson:"priority" json:"priority"`
+	Completed    bool               `bson:"completed" json:"completed"`
+	Score        float64            `bson:"score" json:"score"`
+}
+
+type UserHit struct {
+	ID             primitive.ObjectID `bson:"_id" json:"id"`
+	Handle         string             `bson:"handle" json:"handle"`
+	DisplayName    string             `bson:"display_name" json:"display_name"`
+	ProfilePicture string             `bson:"profile_picture" json:"profile_picture"`
+	Score          float64            `bson:"score" json:"score"`
+	// filled in by the search service, which also ranks by them
+	Friend        bool `bson:"-" json:"friend"`
+	MutualFriends int  `bson:"-" json:"mutual_friends"`
+}
+
+// New returns the engine chosen by config; config.Load has already rejected unknown engines
+func New(collections map[string]*mongo.Collection, cfg config.Search) Engine {
+	if cfg.Engine == "atlas" {
+		return &Atlas{users: collections["users"], index: cfg.AtlasIndex}
+	}
+	return &Mongo{users: collections["users"]}
+}
+
+/*
+taskStages narrows a matched owner document down to the tasks that contain
+one of the query's terms. Text and Atlas Search both score whole user
+documents, so the terms are matched again per task; a task's score is the
+number of terms it contains.
+*/
+func taskStages(text string, limit int) mongo.Pipeline {
+	terms := strings.Fields(text)
+	contains := make(bson.A, 0, len(terms))
+	matched := make(bson.A, 0, len(terms))
+	for _, term := range terms {
+		pattern := regexp.QuoteMeta(term)
+		contains = append(contains, bson.M{"categories.tasks.content": primitive.Regex{Pattern: pattern, Options: "i"}})
+		matched = append(matched, bson.M{"$cond": bson.A{
+			bson.M{"$regexMatch": bson.M{"input": "$categories.tasks.content", "regex": pattern, "options": "i"}}, 1, 0,
+		}})
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$unwind", Value: "$categories"}},
+		{{Key: "$match", Value: softdelete.LiveAt("categories")}},
+		{{Key: "$unwind", Value: "$categories.tasks"}},
+		{{Key: "$match", Value: bson.M{"$and": bson.A{softdelete.LiveAt("categories.tasks"), bson.M{"$or": contains}}}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":           "$categories.tasks._id",
+			"category_id":   "$categories._id",
+			"category_name": "$categories.name",
+			"content":       "$categories.tasks.content",
+			"priority":      "$categories.tasks.priority",
+			"completed":     "$categories.tasks.completed",
+			"score":         bson.M{"$add": matched},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+}
+
+func decode[T any](ctx context.Context, cursor *mongo.Cursor, err error) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+	results := make([]T, 0)
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
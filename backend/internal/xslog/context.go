@@ -0,0 +1,67 @@
+package xslog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type attrsKey struct{}
+
+/*
+ContextHandler wraps another slog.Handler and appends any attributes stored on
+the context (request id, user id, ...) to every record logged with that context.
+*/
+type ContextHandler struct {
+	slog.Handler
+}
+
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	return &ContextHandler{h}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := Attrs(ctx); len(attrs) > 0 {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{h.Handler.WithGroup(name)}
+}
+
+// Attrs returns the attributes stored on ctx by WithAttrs or AddAttrs
+func Attrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// WithAttrs returns a copy of ctx carrying attrs in addition to any already stored
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, attrsKey{}, merge(Attrs(ctx), attrs))
+}
+
+/*
+AddAttrs stores attrs on both the fasthttp request context (c.Context()) and the
+user context (c.UserContext()) so either one can be handed to slog.LogAttrs.
+*/
+func AddAttrs(c *fiber.Ctx, attrs ...slog.Attr) {
+	existing, _ := c.Locals(attrsKey{}).([]slog.Attr)
+	c.Locals(attrsKey{}, merge(existing, attrs))
+	c.SetUserContext(WithAttrs(c.UserContext(), attrs...))
+}
+
+func merge(existing []slog.Attr, attrs []slog.Attr) []slog.Attr {
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	merged = append(merged, existing...)
+	return append(merged, attrs...)
+}
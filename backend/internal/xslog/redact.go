@@ -0,0 +1,66 @@
+package xslog
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+/*
+Redact is a slog.HandlerOptions.ReplaceAttr that blanks credentials: any attr
+whose key names a password, token or secret, and the same keys inside structs
+and maps logged as a whole, which are walked through their JSON form.
+*/
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	if sensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+	// errors are mostly structs with nothing exported, their message is what's wanted
+	if _, ok := a.Value.Any().(error); ok {
+		return a
+	}
+	switch reflect.Indirect(reflect.ValueOf(a.Value.Any())).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return a
+	}
+	raw, err := json.Marshal(a.Value.Any())
+	if err != nil {
+		return a
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return a
+	}
+	return slog.Any(a.Key, redact(v))
+}
+
+func redact(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if sensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redact(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redact(value)
+		}
+	}
+	return v
+}
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "password") || strings.Contains(key, "token") || strings.Contains(key, "secret") ||
+		key == "authorization" || key == "cookie"
+}
@@ -0,0 +1,62 @@
+package xsms
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+)
+
+// Twilio sends through Twilio's Messages API
+type Twilio struct {
+	client     *http.Client
+	accountSID string
+	authToken  string
+	from       string
+	endpoint   string
+}
+
+func NewTwilio(cfg config.SMS) *Twilio {
+	return &Twilio{
+		client:     &http.Client{Timeout: 30 * time.Second},
+		accountSID: cfg.TwilioAccountSID,
+		authToken:  cfg.TwilioAuthToken,
+		from:       cfg.From,
+		endpoint:   "https://api.twilio.com/2010-04-01/Accounts/" + cfg.TwilioAccountSID + "/Messages.json",
+	}
+}
+
+func (t *Twilio) Send(ctx context.Context, msg Message) error {
+	form := url.Values{"To": {msg.To}, "Body": {msg.Text}}
+	// a messaging service picks the sending number itself
+	if strings.HasPrefix(t.from, "MG") {
+		form.Set("MessagingServiceSid", t.from)
+	} else {
+		form.Set("From", t.from)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.accountSID, t.authToken)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: twilio responded %d: %s", ErrRejected, resp.StatusCode, detail)
+	}
+	return fmt.Errorf("twilio responded %d: %s", resp.StatusCode, detail)
+}
@@ -0,0 +1,36 @@
+package xsms
+
+import (
+	"context"
+	"errors"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+)
+
+/*
+Outgoing text messages, to E.164 numbers. Like email, callers queue them as
+jobs so a provider hiccup is retried.
+*/
+
+// ErrRejected wraps failures retrying won't fix, like a number that can't receive texts
+var ErrRejected = errors.New("message rejected")
+
+type Message struct {
+	To   string
+	Text string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+/*
+New returns the sender chosen by config. config.Load has already rejected
+unknown backends.
+*/
+func New(cfg config.SMS) Sender {
+	if cfg.Backend == "twilio" {
+		return NewTwilio(cfg)
+	}
+	return NewLog()
+}
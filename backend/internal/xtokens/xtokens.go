@@ -0,0 +1,235 @@
+package xtokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	gojson "github.com/goccy/go-json"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+/*
+Access and refresh tokens are signed with the keyset's current key and name
+it in their kid header, so the key can be rotated: tokens signed with the
+previous key are still accepted until it retires, by which time they have
+been refreshed onto the new one. Tokens from before kids retire the same
+way, at TOKENS_LEGACY_UNTIL. Public keys are published as a JWKS for
+other services to verify tokens with; HS256 secrets never are.
+*/
+
+// lifetimes for configs not from config.Load, like tests'
+const (
+	defaultAccessTTL  = time.Hour
+	defaultRefreshTTL = 24 * 7 * 30 * time.Hour
+)
+
+var (
+	ErrUnknownKey = errors.New("token signed with an unknown key")
+	ErrRetiredKey = errors.New("token signed with a retired key")
+)
+
+type key struct {
+	id     string
+	method jwt.SigningMethod
+	// nil for a key kept only to verify
+	private any
+	public  any
+	// when tokens signed with it stop being accepted, zero for the current key
+	until time.Time
+}
+
+type Keyset struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+
+	current *key
+	keys    map[string]*key
+	// tokens from before kids were signed HS256 with AUTH_SECRET, nil once they aren't accepted
+	legacy      []byte
+	legacyUntil time.Time
+}
+
+/*
+New builds the keyset config names. config.Load has already checked the keys
+suit their algorithms; secret is AUTH_SECRET, the HS256 key when none is set.
+*/
+func New(cfg config.Tokens, secret string) *Keyset {
+	k := &Keyset{AccessTTL: cfg.AccessTTL, RefreshTTL: cfg.RefreshTTL, keys: make(map[string]*key)}
+	if k.AccessTTL <= 0 {
+		k.AccessTTL = defaultAccessTTL
+	}
+	if k.RefreshTTL <= 0 {
+		k.RefreshTTL = defaultRefreshTTL
+	}
+	if secret != "" && !cfg.LegacyUntil.IsZero() {
+		k.legacy, k.legacyUntil = []byte(secret), cfg.LegacyUntil
+	}
+
+	k.current = newKey(cfg.Algorithm, cfg.KeyID, cfg.Key.Key, []byte(secret))
+	k.keys[k.current.id] = k.current
+	if cfg.PreviousKey.Key != nil {
+		algorithm := cfg.PreviousAlgorithm
+		if algorithm == "" {
+			algorithm = cfg.Algorithm
+		}
+		previous := newKey(algorithm, cfg.PreviousKeyID, cfg.PreviousKey.Key, nil)
+		previous.until = cfg.PreviousUntil
+		// rotating to the same key changes nothing
+		if _, ok := k.keys[previous.id]; !ok {
+			k.keys[previous.id] = previous
+		}
+	}
+	return k
+}
+
+func newKey(algorithm string, id string, material any, secret []byte) *key {
+	k := &key{id: id}
+	switch algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		k.method = jwt.SigningMethodRS256
+		switch m := material.(type) {
+		case *rsa.PrivateKey:
+			k.private, k.public = m, &m.PublicKey
+		case *rsa.PublicKey:
+			k.public = m
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		k.method = jwt.SigningMethodEdDSA
+		switch m := material.(type) {
+		case ed25519.PrivateKey:
+			k.private, k.public = m, m.Public().(ed25519.PublicKey)
+		case ed25519.PublicKey:
+			k.public = m
+		}
+	default:
+		k.method = jwt.SigningMethodHS256
+		if m, ok := material.([]byte); ok {
+			secret = m
+		}
+		k.private, k.public = secret, secret
+	}
+	if k.id == "" {
+		k.id = thumbprint(k)
+	}
+	return k
+}
+
+// Sign signs claims with the current key
+func (k *Keyset) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(k.current.method, claims)
+	t.Header["kid"] = k.current.id
+	return t.SignedString(k.current.private)
+}
+
+// Keyfunc looks the token's key up by its kid, for jwt.Parse
+func (k *Keyset) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		if k.legacy == nil || token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, ErrUnknownKey
+		}
+		if time.Now().After(k.legacyUntil) {
+			return nil, ErrRetiredKey
+		}
+		return k.legacy, nil
+	}
+	key, ok := k.keys[kid]
+	// the token's alg has to be the key's, or an RSA public key could pass for an HMAC secret
+	if !ok || token.Method.Alg() != key.method.Alg() {
+		return nil, ErrUnknownKey
+	}
+	if !key.until.IsZero() && time.Now().After(key.until) {
+		return nil, ErrRetiredKey
+	}
+	return key.public, nil
+}
+
+// Parse checks token's signature and expiry, filling in claims
+func (k *Keyset) Parse(token string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(token, claims, k.Keyfunc)
+}
+
+// JWK is a public key as RFC 7517 has it
+type JWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys tokens are accepted from, the current one first
+func (k *Keyset) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	if jwk, ok := publicJWK(k.current); ok {
+		set.Keys = append(set.Keys, jwk)
+	}
+	now := time.Now()
+	for _, key := range k.keys {
+		if key == k.current || now.After(key.until) {
+			continue
+		}
+		if jwk, ok := publicJWK(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+func publicJWK(k *key) (JWK, bool) {
+	jwk := JWK{Kid: k.id, Alg: k.method.Alg(), Use: "sig"}
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return JWK{}, false
+	}
+	return jwk, true
+}
+
+// thumbprint is the key's RFC 7638 thumbprint, a kid that changes with the key
+func thumbprint(k *key) string {
+	var members any
+	if jwk, ok := publicJWK(k); ok && jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else if ok {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	} else {
+		secret, _ := k.public.([]byte)
+		members = struct {
+			K   string `json:"k"`
+			Kty string `json:"kty"`
+		}{base64.RawURLEncoding.EncodeToString(secret), "oct"}
+	}
+	data, _ := gojson.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,149 @@
+package xtokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func pemKey(t *testing.T, key any) config.SigningKey {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var k config.SigningKey
+	if err := k.UnmarshalText(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})); err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": "alice", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func TestAlgorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		cfg         config.Tokens
+		expectedKty string
+	}{
+		{"HS256 with AUTH_SECRET", config.Tokens{Algorithm: "HS256"}, ""},
+		{"HS256 with its own secret", config.Tokens{Algorithm: "HS256", Key: config.SigningKey{Key: []byte("other")}}, ""},
+		{"RS256", config.Tokens{Algorithm: "RS256", Key: pemKey(t, rsaKey)}, "RSA"},
+		{"EdDSA", config.Tokens{Algorithm: "EdDSA", KeyID: "ed-1", Key: pemKey(t, edKey)}, "OKP"},
+	}
+	for _, tt := range tests {
+		keys := New(tt.cfg, testSecret)
+		token, err := keys.Sign(claims())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		parsed, err := keys.Parse(token, jwt.MapClaims{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if parsed.Method.Alg() != tt.cfg.Algorithm || parsed.Header["kid"] != keys.current.id {
+			t.Errorf("%s: signed with %s as %v", tt.name, parsed.Method.Alg(), parsed.Header["kid"])
+		}
+		if tt.cfg.KeyID != "" && keys.current.id != tt.cfg.KeyID {
+			t.Errorf("%s: expected kid %q, got %q", tt.name, tt.cfg.KeyID, keys.current.id)
+		}
+
+		jwks := keys.JWKS()
+		if tt.expectedKty == "" && len(jwks.Keys) != 0 {
+			t.Errorf("%s: published a secret: %+v", tt.name, jwks.Keys)
+		}
+		if tt.expectedKty != "" && (len(jwks.Keys) != 1 || jwks.Keys[0].Kty != tt.expectedKty || jwks.Keys[0].Kid != keys.current.id) {
+			t.Errorf("%s: unexpected JWKS %+v", tt.name, jwks.Keys)
+		}
+	}
+}
+
+func TestRotation(t *testing.T) {
+	_, oldKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := New(config.Tokens{Algorithm: "EdDSA", Key: pemKey(t, oldKey)}, testSecret)
+	token, err := old.Sign(claims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims()).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := func(until time.Time) *Keyset {
+		return New(config.Tokens{
+			Algorithm:     "EdDSA",
+			Key:           pemKey(t, newKey),
+			PreviousKey:   config.SigningKey{Key: oldKey.Public()},
+			PreviousUntil: until,
+			LegacyUntil:   until,
+		}, testSecret)
+	}
+
+	inGrace := rotated(time.Now().Add(time.Hour))
+	if _, err := inGrace.Parse(token, jwt.MapClaims{}); err != nil {
+		t.Errorf("previous key in its grace period: %v", err)
+	}
+	if len(inGrace.JWKS().Keys) != 2 {
+		t.Errorf("expected both keys published, got %+v", inGrace.JWKS().Keys)
+	}
+	if _, err := inGrace.Parse(legacy, jwt.MapClaims{}); err != nil {
+		t.Errorf("token without a kid: %v", err)
+	}
+
+	retired := rotated(time.Now().Add(-time.Minute))
+	if _, err := retired.Parse(token, jwt.MapClaims{}); !errors.Is(err, ErrRetiredKey) {
+		t.Errorf("retired key: expected ErrRetiredKey, got %v", err)
+	}
+	if _, err := retired.Parse(legacy, jwt.MapClaims{}); !errors.Is(err, ErrRetiredKey) {
+		t.Errorf("token without a kid after its cutoff: expected ErrRetiredKey, got %v", err)
+	}
+	// with no cutoff set, tokens without a kid were never accepted
+	if _, err := old.Parse(legacy, jwt.MapClaims{}); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("token without a kid: expected ErrUnknownKey, got %v", err)
+	}
+	if len(retired.JWKS().Keys) != 1 {
+		t.Errorf("expected only the current key published, got %+v", retired.JWKS().Keys)
+	}
+
+	// a kid the keyset doesn't have, and one used with another algorithm
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	for _, kid := range []string{"unknown", old.current.id} {
+		forged.Header["kid"] = kid
+		signed, err := forged.SignedString([]byte(testSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := inGrace.Parse(signed, jwt.MapClaims{}); !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("kid %s: expected ErrUnknownKey, got %v", kid, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package xunfurl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/abhikaboy/SocialToDo/internal/xhttp"
+	"golang.org/x/net/html"
+)
+
+/*
+Link unfurling: fetch a page and read the preview it advertises, Open Graph
+and Twitter card tags first, then the plain <title> and meta description.
+Only the head is read, and at most maxBody bytes of it.
+*/
+
+const (
+	maxBody   = 1 << 20
+	userAgent = "SocialToDoBot/1.0 (+https://socialtodo.app)"
+	// previews are shown as one line and a short paragraph
+	maxTitle       = 300
+	maxDescription = 1000
+)
+
+var client = xhttp.NewPublicClient(10*time.Second, 5)
+
+type Preview struct {
+	// where the page ended up after redirects
+	URL         string
+	Title       string
+	Description string
+	Image       string
+	SiteName    string
+}
+
+// Unfurl fetches rawURL and reads its preview. A page that isn't HTML gets a preview with just its URL.
+func Unfurl(ctx context.Context, rawURL string) (*Preview, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml;q=0.9,*/*;q=0.1")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("page responded %s", resp.Status)
+	}
+
+	preview := &Preview{URL: resp.Request.URL.String()}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return preview, nil
+	}
+	read(io.LimitReader(resp.Body, maxBody), resp.Request.URL, preview)
+	return preview, nil
+}
+
+func read(body io.Reader, base *url.URL, preview *Preview) {
+	var title, description, ogTitle, ogDescription, twitterTitle, twitterDescription, image string
+	tokens := html.NewTokenizer(body)
+scan:
+	for {
+		switch tokens.Next() {
+		case html.ErrorToken:
+			break scan
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokens.TagName()
+			switch string(name) {
+			case "title":
+				if tokens.Next() == html.TextToken && title == "" {
+					title = string(tokens.Text())
+				}
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				var key, content string
+				for {
+					attr, value, more := tokens.TagAttr()
+					switch string(attr) {
+					case "property", "name":
+						key = strings.ToLower(string(value))
+					case "content":
+						content = string(value)
+					}
+					if !more {
+						break
+					}
+				}
+				set := func(field *string) {
+					if *field == "" {
+						*field = content
+					}
+				}
+				switch key {
+				case "og:title":
+					set(&ogTitle)
+				case "og:description":
+					set(&ogDescription)
+				case "og:image", "og:image:url", "twitter:image":
+					set(&image)
+				case "og:site_name":
+					set(&preview.SiteName)
+				case "twitter:title":
+					set(&twitterTitle)
+				case "twitter:description":
+					set(&twitterDescription)
+				case "description":
+					set(&description)
+				}
+			case "body":
+				break scan
+			}
+		case html.EndTagToken:
+			if name, _ := tokens.TagName(); string(name) == "head" {
+				break scan
+			}
+		}
+	}
+	preview.Title = clean(first(ogTitle, twitterTitle, title), maxTitle)
+	preview.Description = clean(first(ogDescription, twitterDescription, description), maxDescription)
+	preview.SiteName = clean(preview.SiteName, maxTitle)
+	if ref, err := url.Parse(strings.TrimSpace(image)); err == nil && image != "" {
+		if resolved := base.ResolveReference(ref); resolved.Scheme == "http" || resolved.Scheme == "https" {
+			preview.Image = resolved.String()
+		}
+	}
+}
+
+func first(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// clean collapses whitespace and cuts s to at most limit runes
+func clean(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > limit {
+		s = strings.TrimSpace(string(runes[:limit-1])) + "…"
+	}
+	return s
+}
@@ -1,6 +1,8 @@
 package xvalidator
 
 import (
+	"strconv"
+
 	"github.com/go-playground/validator/v10"
 )
 
@@ -22,6 +24,14 @@ type GlobalErrorHandlerResp struct {
 
 var Validate = validator.New()
 
+func init() {
+	// max counts runes; maxbytes=72 is for bcrypt, which reads at most 72 bytes
+	Validate.RegisterValidation("maxbytes", func(fl validator.FieldLevel) bool {
+		limit, err := strconv.Atoi(fl.Param())
+		return err == nil && len(fl.Field().String()) <= limit
+	})
+}
+
 func (v XValidator) Validate(data interface{}) []ErrorResponse {
 	var validationErrors []ErrorResponse
 
@@ -0,0 +1,71 @@
+package xworker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/abhikaboy/SocialToDo/internal/xslog"
+)
+
+/*
+Group tracks long-running background goroutines (change stream consumers,
+schedulers, fan-out workers) so shutdown can cancel them and wait for them
+to finish their current unit of work.
+*/
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewGroup(ctx context.Context) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{ctx: ctx, cancel: cancel}
+}
+
+/*
+Go runs fn in its own goroutine. fn must return once ctx is cancelled.
+A panicking worker is logged rather than taking the process down.
+*/
+func (g *Group) Go(name string, fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				slog.LogAttrs(g.ctx, slog.LevelError, "Background worker panicked",
+					slog.String("worker", name), xslog.Error(fmt.Errorf("%v", r)))
+			}
+		}()
+		slog.LogAttrs(g.ctx, slog.LevelDebug, "Background worker started", slog.String("worker", name))
+		fn(g.ctx)
+		slog.LogAttrs(context.Background(), slog.LevelDebug, "Background worker stopped", slog.String("worker", name))
+	}()
+}
+
+// Context is cancelled when Stop is called
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+/*
+Stop cancels every worker and waits for them to return, giving up when ctx expires.
+*/
+func (g *Group) Stop(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("background workers did not stop in time: %w", ctx.Err())
+	}
+}
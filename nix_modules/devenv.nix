@@ -33,6 +33,10 @@
           python3.pkgs.pip
           python3.pkgs.typer
           dos2unix
+          buf
+          protoc-gen-go
+          protoc-gen-go-grpc
+          gqlgen
         ];
 
         scripts = {
@@ -57,6 +61,13 @@
               go run cmd/server/main.go
             '';
           };
+          "backend-generate" = {
+            description = "Regenerates backend code (RPC stubs, mocks).";
+            exec = ''
+              cd "$DEVENV_ROOT"/backend
+              go generate ./...
+            '';
+          };
           "backend-test" = {
             description = "Tests backend code.";
             exec = ''
@@ -64,6 +75,13 @@
               go test ./...
             '';
           };
+          "backend-test-integration" = {
+            description = "Runs the backend integration tests against MongoDB in a container (needs Docker).";
+            exec = ''
+              cd "$DEVENV_ROOT"/backend
+              go test -tags integration ./...
+            '';
+          };
           "database-script" = {
             description = "Runs a script against the connected Database";
             exec = ''